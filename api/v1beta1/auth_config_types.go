@@ -28,6 +28,11 @@ const (
 	IdentityOidc                     = "IDENTITY_OIDC"
 	IdentityApiKey                   = "IDENTITY_APIKEY"
 	IdentityMTLS                     = "IDENTITY_MTLS"
+	IdentitySpiffe                   = "IDENTITY_SPIFFE"
+	IdentityAwsSigV4                 = "IDENTITY_AWS_SIGV4"
+	IdentityGoogleIDToken            = "IDENTITY_GOOGLE_ID_TOKEN"
+	IdentityGitToken                 = "IDENTITY_GIT_TOKEN"
+	IdentityVaultTransit             = "IDENTITY_VAULT_TRANSIT"
 	IdentityKubernetesAuth           = "IDENTITY_KUBERNETESAUTH"
 	IdentityAnonymous                = "IDENTITY_ANONYMOUS"
 	IdentityPlain                    = "IDENTITY_PLAIN"
@@ -38,24 +43,41 @@ const (
 	AuthorizationJSONPatternMatching = "AUTHORIZATION_JSON"
 	AuthorizationKubernetesAuthz     = "AUTHORIZATION_KUBERNETESAUTHZ"
 	AuthorizationAuthzed             = "AUTHORIZATION_AUTHZED"
+	AuthorizationQuota               = "AUTHORIZATION_QUOTA"
+	AuthorizationRBAC                = "AUTHORIZATION_RBAC"
+	AuthorizationRequiredScopes      = "AUTHORIZATION_REQUIREDSCOPES"
+	AuthorizationWasm                = "AUTHORIZATION_WASM"
+	AuthorizationLua                 = "AUTHORIZATION_LUA"
 	ResponseWristband                = "RESPONSE_WRISTBAND"
 	ResponseDynamicJSON              = "RESPONSE_DYNAMIC_JSON"
 	ResponsePlain                    = "RESPONSE_PLAIN"
+	ResponseSignature                = "RESPONSE_SIGNATURE"
 	CallbackHTTP                     = "CALLBACK_HTTP"
+	CallbackKafka                    = "CALLBACK_KAFKA"
 	EvaluatorDefaultCacheTTL         = 60
+	EnforcementModeEnforce           = "enforce"
+	EnforcementModeDryRun            = "dryRun"
+	CallbackEventAllow               = "allow"
+	CallbackEventDeny                = "deny"
+	CallbackEventError               = "error"
 
 	// Status conditions
-	StatusConditionAvailable ConditionType = "Available"
-	StatusConditionReady     ConditionType = "Ready"
+	StatusConditionAvailable           ConditionType = "Available"
+	StatusConditionReady               ConditionType = "Ready"
+	StatusConditionDependenciesHealthy ConditionType = "DependenciesHealthy"
 
 	// Status reasons
-	StatusReasonReconciling     string = "Reconciling"
-	StatusReasonReconciled      string = "Reconciled"
-	StatusReasonInvalidResource string = "Invalid"
-	StatusReasonHostsLinked     string = "HostsLinked"
-	StatusReasonHostsNotLinked  string = "HostsNotLinked"
-	StatusReasonCachingError    string = "CachingError"
-	StatusReasonUnknown         string = "Unknown"
+	StatusReasonReconciling             string = "Reconciling"
+	StatusReasonReconciled              string = "Reconciled"
+	StatusReasonInvalidResource         string = "Invalid"
+	StatusReasonHostsLinked             string = "HostsLinked"
+	StatusReasonHostsNotLinked          string = "HostsNotLinked"
+	StatusReasonCachingError            string = "CachingError"
+	StatusReasonPolicyTestsFailed       string = "PolicyTestsFailed"
+	StatusReasonPolicyCompilationFailed string = "PolicyCompilationFailed"
+	StatusReasonDependenciesHealthy     string = "DependenciesHealthy"
+	StatusReasonDependenciesUnhealthy   string = "DependenciesUnhealthy"
+	StatusReasonUnknown                 string = "Unknown"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
@@ -70,6 +92,15 @@ type SecretKeyReference struct {
 	Key string `json:"key"`
 }
 
+// ConfigMapKeyReference selects a key of a ConfigMap.
+type ConfigMapKeyReference struct {
+	// The name of the configmap in the namespace of the AuthConfig to select from.
+	Name string `json:"name"`
+
+	// The key of the configmap to select from. Must be a valid configmap key.
+	Key string `json:"key"`
+}
+
 // StaticOrDynamicValue is either a constant static string value or a config for fetching a value from a dynamic source (e.g. a path pattern of authorization JSON)
 type StaticOrDynamicValue struct {
 	// Static value
@@ -115,6 +146,24 @@ type AuthConfigSpec struct {
 	// Authorino uses the requested host to lookup for the corresponding authentication/authorization configs to enforce.
 	Hosts []string `json:"hosts"`
 
+	// Precedence of the AuthConfig over others that claim the same host names (or host names covered by the same wildcard).
+	// When two AuthConfigs collide, the one with the highest priority is linked to the host; AuthConfigs with the same
+	// priority keep the one linked first.
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:default:=0
+	Priority int `json:"priority,omitempty"`
+
+	// Whether to generate individual observability metrics for all the configs of the AuthConfig, regardless of the
+	// `metrics` field of each config.
+	// +kubebuilder:default:=false
+	Metrics bool `json:"metrics,omitempty"`
+
+	// Log level of the auth pipelines evaluated with the AuthConfig, overriding the log level of the Authorino instance,
+	// so verbose logs can be enabled for the hosts of the AuthConfig only.
+	// Omit it to apply the log level of the Authorino instance.
+	// +kubebuilder:validation:Enum:=debug;info;error
+	LogLevel string `json:"logLevel,omitempty"`
+
 	// Named sets of JSON patterns that can be referred in `when` conditionals and in JSON-pattern matching policy rules.
 	Patterns map[string]JSONPatternExpressions `json:"patterns,omitempty"`
 
@@ -132,13 +181,27 @@ type AuthConfigSpec struct {
 	Metadata []*Metadata `json:"metadata,omitempty"`
 
 	// Authorization is the list of authorization policies.
-	// All policies in this list MUST evaluate to "true" for a request be successful in the authorization phase.
+	// By default, all policies in this list MUST evaluate to "true" for a request be successful in the authorization phase.
 	Authorization []*Authorization `json:"authorization,omitempty"`
 
+	// Strategy to combine the verdicts of the authorization policies.
+	// Possible values are: "allOf" (default; all policies must allow the request), "anyOf" (at least one policy must allow the request),
+	// "denyOverrides" (the verdicts of the policies with the highest weight prevail; a single denial among them denies the request)
+	// and "permitOverrides" (the verdicts of the policies with the highest weight prevail; a single permission among them allows the request).
+	// +kubebuilder:validation:Enum:=allOf;anyOf;denyOverrides;permitOverrides
+	AuthorizationStrategy string `json:"authorizationStrategy,omitempty"`
+
 	// List of response configs.
 	// Authorino gathers data from the auth pipeline to build custom responses for the client.
 	Response []*Response `json:"response,omitempty"`
 
+	// Root property of the Envoy Dynamic Metadata under which the response configs wrapped as "envoyDynamicMetadata" are emitted.
+	// If omitted, each response config is emitted at the root of the Dynamic Metadata of the external authorization filter.
+	DynamicMetadataNamespace string `json:"dynamicMetadataNamespace,omitempty"`
+
+	// Names of the query parameters to remove from the request forwarded upstream, when the auth check succeeds.
+	QueryParametersToRemove []string `json:"queryParametersToRemove,omitempty"`
+
 	// List of callback configs.
 	// Authorino sends callbacks to specified endpoints at the end of the auth pipeline.
 	Callbacks []*Callback `json:"callbacks,omitempty"`
@@ -174,17 +237,17 @@ type JSONPatternExpression struct {
 	// The value is used to fetch content from the input authorization JSON built by Authorino along the identity and metadata phases.
 	Selector string `json:"selector,omitempty"`
 	// The binary operator to be applied to the content fetched from the authorization JSON, for comparison with "value".
-	// Possible values are: "eq" (equal to), "neq" (not equal to), "incl" (includes; for arrays), "excl" (excludes; for arrays), "matches" (regex)
+	// Possible values are: "eq" (equal to), "neq" (not equal to), "incl" (includes; for arrays), "excl" (excludes; for arrays), "matches" (regex), "rar_type" (includes an authorization detail of the type), "rar_action" (includes an authorization detail that grants the action, optionally prefixed with the type and ":")
 	Operator JSONPatternOperator `json:"operator,omitempty"`
 	// The value of reference for the comparison with the content fetched from the authorization JSON.
 	// If used with the "matches" operator, the value must compile to a valid Golang regex.
 	Value string `json:"value,omitempty"`
 }
 
-// +kubebuilder:validation:Enum:=eq;neq;incl;excl;matches
+// +kubebuilder:validation:Enum:=eq;neq;incl;excl;matches;rar_type;rar_action
 type JSONPatternOperator string
 
-// +kubebuilder:validation:Enum:=authorization_header;custom_header;query;cookie
+// +kubebuilder:validation:Enum:=authorization_header;custom_header;query;cookie;websocket_protocol;grpc_metadata
 type Credentials_In string
 
 type Credentials struct {
@@ -194,6 +257,8 @@ type Credentials struct {
 	// Used in conjunction with the `in` parameter.
 	// When used with `authorization_header`, the value is the prefix of the client credentials string, separated by a white-space, in the HTTP Authorization header (e.g. "Bearer", "Basic").
 	// When used with `custom_header`, `query` or `cookie`, the value is the name of the HTTP header, query string parameter or cookie key, respectively.
+	// When used with `websocket_protocol`, the value is the prefix of the subprotocol that carries the client credentials in the Sec-WebSocket-Protocol header of WebSocket handshakes (e.g. "bearer.").
+	// When used with `grpc_metadata`, the value is the gRPC metadata key; values of binary keys (suffixed with `-bin`) are base64-decoded.
 	KeySelector string `json:"keySelector"`
 }
 
@@ -234,6 +299,11 @@ type Identity struct {
 	// If omitted, it defaults to client credentials passed in the HTTP Authorization header and the "Bearer" prefix expected prepended to the credentials value (token, API key, etc).
 	Credentials Credentials `json:"credentials,omitempty"`
 
+	// Whether to remove the client credentials from the request forwarded upstream after the identity is successfully verified by this config.
+	// Only credentials passed in the HTTP Authorization header, in a custom header, in a gRPC metadata key or in a WebSocket subprotocol can be removed.
+	// +kubebuilder:default:=false
+	StripCredentials bool `json:"stripCredentials,omitempty"`
+
 	// Extends the resolved identity object with additional custom properties before appending to the authorization JSON.
 	// It requires the resolved identity object to always be of the JSON type 'object'. Other JSON types (array, string, etc) will break.
 	ExtendedProperties []ExtendedProperty `json:"extendedProperties,omitempty"`
@@ -242,6 +312,11 @@ type Identity struct {
 	Oidc           *Identity_OidcConfig     `json:"oidc,omitempty"`
 	APIKey         *Identity_APIKey         `json:"apiKey,omitempty"`
 	MTLS           *Identity_MTLS           `json:"mtls,omitempty"`
+	Spiffe         *Identity_Spiffe         `json:"spiffe,omitempty"`
+	AwsSigV4       *Identity_AwsSigV4       `json:"awsSigV4,omitempty"`
+	GoogleIDToken  *Identity_GoogleIDToken  `json:"googleIdToken,omitempty"`
+	GitToken       *Identity_GitToken       `json:"gitToken,omitempty"`
+	VaultTransit   *Identity_VaultTransit   `json:"vaultTransit,omitempty"`
 	KubernetesAuth *Identity_KubernetesAuth `json:"kubernetes,omitempty"`
 	Anonymous      *Identity_Anonymous      `json:"anonymous,omitempty"`
 	Plain          *Identity_Plain          `json:"plain,omitempty"`
@@ -256,6 +331,16 @@ func (i *Identity) GetType() string {
 		return IdentityApiKey
 	} else if i.MTLS != nil {
 		return IdentityMTLS
+	} else if i.Spiffe != nil {
+		return IdentitySpiffe
+	} else if i.AwsSigV4 != nil {
+		return IdentityAwsSigV4
+	} else if i.GoogleIDToken != nil {
+		return IdentityGoogleIDToken
+	} else if i.GitToken != nil {
+		return IdentityGitToken
+	} else if i.VaultTransit != nil {
+		return IdentityVaultTransit
 	} else if i.KubernetesAuth != nil {
 		return IdentityKubernetesAuth
 	} else if i.Anonymous != nil {
@@ -269,32 +354,56 @@ func (i *Identity) GetType() string {
 
 type Identity_OAuth2Config struct {
 	// The full URL of the token introspection endpoint.
-	TokenIntrospectionUrl string `json:"tokenIntrospectionUrl"`
+	TokenIntrospectionUrl string `json:"tokenIntrospectionUrl,omitempty"`
 	// The token type hint for the token introspection.
 	// If omitted, it defaults to "access_token".
 	TokenTypeHint string `json:"tokenTypeHint,omitempty"`
 
 	// Reference to a Kubernetes secret in the same namespace, that stores client credentials to the OAuth2 server.
-	Credentials *k8score.LocalObjectReference `json:"credentialsRef"`
+	Credentials *k8score.LocalObjectReference `json:"credentialsRef,omitempty"`
+
+	// Reference to a cluster-wide IdentityProvider whose token introspection settings to use, instead of
+	// `tokenIntrospectionUrl`, `tokenTypeHint` and `credentialsRef`.
+	IdentityProviderRef *IdentityProviderReference `json:"identityProviderRef,omitempty"`
 }
 
 type Identity_OidcConfig struct {
 	// Endpoint of the OIDC issuer.
 	// Authorino will append to this value the well-known path to the OpenID Connect discovery endpoint (i.e. "/.well-known/openid-configuration"), used to automatically discover the OpenID Connect configuration, whose set of claims is expected to include (among others) the "jkws_uri" claim.
 	// The value must coincide with the value of  the "iss" (issuer) claim of the discovered OpenID Connect configuration.
-	Endpoint string `json:"endpoint"`
+	// The endpoint can be a template with placeholders resolved from the claims of the token, for multi-tenant identity providers (e.g. "https://login.microsoftonline.com/{tid}/v2.0"). In this case, `tenants` is required.
+	Endpoint string `json:"endpoint,omitempty"`
+	// Allowlist of tenants accepted when the `endpoint` is a template.
+	// The values resolved from the claims of the token for the placeholders of the template must be listed.
+	Tenants []string `json:"tenants,omitempty"`
 	// Decides how long to wait before refreshing the OIDC configuration (in seconds).
 	TTL int `json:"ttl,omitempty"`
+	// Reference to a cluster-wide IdentityProvider whose issuer URL and TTL to use, instead of `endpoint` and `ttl`.
+	IdentityProviderRef *IdentityProviderReference `json:"identityProviderRef,omitempty"`
+}
+
+// Reference by name to an IdentityProvider.
+type IdentityProviderReference struct {
+	// Name of the IdentityProvider.
+	Name string `json:"name"`
 }
 
 type Identity_APIKey struct {
 	// Label selector used by Authorino to match secrets from the cluster storing valid credentials to authenticate to this service
-	Selector *metav1.LabelSelector `json:"selector"`
+	// Required unless `vaultPath` is set.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
 
 	// Whether Authorino should look for API key secrets in all namespaces or only in the same namespace as the AuthConfig.
 	// Enabling this option in namespaced Authorino instances has no effect.
 	// +kubebuilder:default:=false
 	AllNamespaces bool `json:"allNamespaces,omitempty"`
+
+	// Path of a secret in HashiCorp Vault storing valid credentials to authenticate to this service, instead of
+	// Kubernetes secrets. Each key of the Vault secret is the name of an API key and the value, the API key.
+	// Requires Authorino to be configured to read secrets from Vault.
+	// +optional
+	VaultPath string `json:"vaultPath,omitempty"`
 }
 
 type Identity_MTLS struct {
@@ -307,6 +416,97 @@ type Identity_MTLS struct {
 	AllNamespaces bool `json:"allNamespaces,omitempty"`
 }
 
+type Identity_Spiffe struct {
+	// The SPIFFE trust domain of the workloads allowed to authenticate (e.g. "example.org").
+	TrustDomain string `json:"trustDomain"`
+	// URL of the SPIFFE bundle endpoint (https_web profile) that serves the trust bundle of the trust domain, e.g. the federation bundle endpoint of the SPIRE server.
+	BundleEndpointUrl string `json:"bundleEndpointUrl"`
+	// Decides how long to wait before refreshing the trust bundle (in seconds).
+	TTL int `json:"ttl,omitempty"`
+	// The list of audiences of which at least one must be claimed in the JWT-SVIDs.
+	// If omitted, Authorino will expect the host name of the requested protected service amongst the audiences.
+	Audiences []string `json:"audiences,omitempty"`
+}
+
+type Identity_AwsSigV4 struct {
+	// Label selector used by Authorino to match secrets from the cluster storing the AWS access keys trusted to sign the requests (`aws_access_key_id`, `aws_secret_access_key` and `aws_arn` entries).
+	// Required unless `sts` is set.
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+
+	// Whether Authorino should look for AWS access key secrets in all namespaces or only in the same namespace as the AuthConfig.
+	// Enabling this option in namespaced Authorino instances has no effect.
+	// +kubebuilder:default:=false
+	AllNamespaces bool `json:"allNamespaces,omitempty"`
+
+	// AWS region the requests must be signed for. If omitted, requests signed for any region are accepted.
+	Region string `json:"region,omitempty"`
+
+	// AWS service name the requests must be signed for. If omitted, requests signed for any service are accepted.
+	Service string `json:"service,omitempty"`
+
+	// Whether to accept requests whose payload is not signed, i.e. with the `x-amz-content-sha256` header set to `UNSIGNED-PAYLOAD`. Otherwise, the payload hash must match the body of the request.
+	// Presigned URLs are always accepted with unsigned payloads.
+	// +kubebuilder:default:=false
+	AllowUnsignedPayload bool `json:"allowUnsignedPayload,omitempty"`
+
+	// Settings to resolve the AWS IAM principals by AWS STS GetCallerIdentity, instead of verifying the signatures against access keys stored in secrets.
+	Sts *Identity_AwsSts `json:"sts,omitempty"`
+}
+
+type Identity_AwsSts struct {
+	// AWS regions whose regional STS endpoints are accepted in the presigned requests, besides the global endpoint (sts.amazonaws.com).
+	Regions []string `json:"regions,omitempty"`
+
+	// Value of the `x-k8s-aws-id` header that must be signed in the presigned requests, binding the tokens to this service.
+	Audience string `json:"audience,omitempty"`
+}
+
+type Identity_GoogleIDToken struct {
+	// The list of audiences of which at least one must be claimed in the ID tokens (e.g. the URL of the Cloud Run service or the audience of the Pub/Sub push subscription).
+	// +kubebuilder:validation:MinItems:=1
+	Audiences []string `json:"audiences"`
+
+	// Emails of the Google accounts (e.g. service accounts) allowed to authenticate.
+	// If `emails` or `domains` are set, the tokens must include a verified email, which must be listed in `emails` or belong to one of the `domains`.
+	Emails []string `json:"emails,omitempty"`
+
+	// Domains of the emails of the Google accounts allowed to authenticate (e.g. "my-project.iam.gserviceaccount.com").
+	// The hosted domain ("hd" claim) of Google Workspace accounts also matches.
+	Domains []string `json:"domains,omitempty"`
+}
+
+type Identity_GitToken struct {
+	// The provider of the tokens.
+	// +kubebuilder:validation:Enum:=github;gitlab
+	Provider string `json:"provider"`
+
+	// Base URL of a GitHub Enterprise Server or self-managed GitLab instance (e.g. "https://gitlab.example.com"). If omitted, Authorino will validate the tokens against github.com or gitlab.com.
+	Url string `json:"url,omitempty"`
+
+	// The list of audiences of which at least one must be claimed in the OpenID Connect tokens of CI jobs.
+	// If omitted, Authorino will expect the host name of the requested protected service amongst the audiences.
+	Audiences []string `json:"audiences,omitempty"`
+
+	// How long to cache the results of the validation of personal access tokens against the API of the provider (in seconds).
+	// +kubebuilder:default:=60
+	TTL int `json:"ttl,omitempty"`
+}
+
+type Identity_VaultTransit struct {
+	// Name of the key of the Transit secrets engine of Vault that verifies the signature of the tokens.
+	KeyName string `json:"keyName"`
+
+	// Path where the Transit secrets engine is mounted in Vault.
+	// +kubebuilder:default:=transit
+	Mount string `json:"mount,omitempty"`
+
+	// Issuer that must be claimed in the tokens. If omitted, the issuer of the tokens is not checked.
+	Issuer string `json:"issuer,omitempty"`
+
+	// The list of audiences of which at least one must be claimed in the tokens. If omitted, the audience of the tokens is not checked.
+	Audiences []string `json:"audiences,omitempty"`
+}
+
 type Identity_KubernetesAuth struct {
 	// The list of audiences (scopes) that must be claimed in a Kubernetes authentication token supplied in the request, and reviewed by Authorino.
 	// If omitted, Authorino will review tokens expecting the host name of the requested protected service amongst the audiences.
@@ -466,10 +666,29 @@ type Authorization struct {
 	// Omit it to avoid caching policy evaluation results for this config.
 	Cache *EvaluatorCaching `json:"cache,omitempty"`
 
+	// Weight of the verdict of the policy when combined with the verdicts of other policies.
+	// Only used with the "denyOverrides" and "permitOverrides" authorization strategies.
+	// +kubebuilder:default:=0
+	Weight int `json:"weight,omitempty"`
+
+	// Enforcement mode of the authorization policy.
+	// In "dryRun" mode, the verdict of the policy is recorded in the metrics and in the authorization JSON, but the policy never denies the request.
+	// +kubebuilder:validation:Enum:=enforce;dryRun
+	EnforcementMode string `json:"enforcementMode,omitempty"`
+
+	// Named values exported into the authorization JSON, under `auth.exports`, when the policy grants access.
+	// Values can be static or fetched from the authorization JSON, including the result of the policy itself.
+	Exports []JsonProperty `json:"exports,omitempty"`
+
 	OPA             *Authorization_OPA                 `json:"opa,omitempty"`
 	JSON            *Authorization_JSONPatternMatching `json:"json,omitempty"`
 	KubernetesAuthz *Authorization_KubernetesAuthz     `json:"kubernetes,omitempty"`
 	Authzed         *Authorization_Authzed             `json:"authzed,omitempty"`
+	Quota           *Authorization_Quota               `json:"quota,omitempty"`
+	RBAC            *Authorization_RBAC                `json:"rbac,omitempty"`
+	RequiredScopes  *Authorization_RequiredScopes      `json:"requiredScopes,omitempty"`
+	Wasm            *Authorization_Wasm                `json:"wasm,omitempty"`
+	Lua             *Authorization_Lua                 `json:"lua,omitempty"`
 }
 
 func (a *Authorization) GetType() string {
@@ -481,6 +700,16 @@ func (a *Authorization) GetType() string {
 		return AuthorizationKubernetesAuthz
 	} else if a.Authzed != nil {
 		return AuthorizationAuthzed
+	} else if a.Quota != nil {
+		return AuthorizationQuota
+	} else if a.RBAC != nil {
+		return AuthorizationRBAC
+	} else if a.RequiredScopes != nil {
+		return AuthorizationRequiredScopes
+	} else if a.Wasm != nil {
+		return AuthorizationWasm
+	} else if a.Lua != nil {
+		return AuthorizationLua
 	}
 	return TypeUnknown
 }
@@ -575,7 +804,164 @@ type AuthzedObject struct {
 	Kind StaticOrDynamicValue `json:"kind,omitempty"`
 }
 
-// +kubebuilder:validation:Enum:=httpHeader;envoyDynamicMetadata
+// Quota authorization
+// Requests are counted per key within a sliding window; requests beyond the limit are denied.
+type Authorization_Quota struct {
+	// Key that identifies the counter (e.g. the identity of the user or the requested resource).
+	// Each distinct resolved value is counted separately.
+	Key StaticOrDynamicValue `json:"key"`
+
+	// Maximum number of requests allowed for the same key within the window.
+	// +kubebuilder:validation:Minimum:=1
+	Limit int64 `json:"limit"`
+
+	// Length of the sliding window, in seconds.
+	// +kubebuilder:validation:Minimum:=1
+	Window int64 `json:"window"`
+
+	// Redis server that stores the counters.
+	// Omit it to keep the counters in memory, local to each Authorino instance.
+	// +optional
+	Redis *Authorization_Quota_Redis `json:"redis,omitempty"`
+}
+
+type Authorization_Quota_Redis struct {
+	// Address of the Redis server (format: <host>:<port>).
+	Address string `json:"address"`
+
+	// Reference to a Secret key whose value will be used by Authorino to authenticate with the Redis server.
+	Password *SecretKeyReference `json:"passwordRef,omitempty"`
+
+	// Number of the Redis logical database.
+	// +kubebuilder:default:=0
+	DB int `json:"db,omitempty"`
+}
+
+// Built-in role-based access control (RBAC) authorization
+// The request is authorized if at least one rule grants access to any of the roles of the identity, for the requested method and path.
+type Authorization_RBAC struct {
+	// Selector of the roles (or groups) of the identity in the authorization JSON.
+	// The selected value can be either an array of strings or a string of space-separated values.
+	// If omitted, it defaults to `auth.identity.roles`.
+	RolesSelector string `json:"rolesSelector,omitempty"`
+
+	// Role-to-permission mapping rules.
+	Rules []Authorization_RBAC_Rule `json:"rules,omitempty"`
+
+	// Reference to a ConfigMap key that stores additional rules, formatted as a YAML or JSON list.
+	// The rules are reloaded periodically, so changes to the ConfigMap take effect without reconciling the AuthConfig.
+	ConfigMap *Authorization_RBAC_ConfigMapRef `json:"configMapRef,omitempty"`
+}
+
+type Authorization_RBAC_Rule struct {
+	// Roles granted the permission. Use '*' for any role.
+	Roles []string `json:"roles"`
+
+	// HTTP methods allowed. Omit it or use '*' for any method.
+	Methods []string `json:"methods,omitempty"`
+
+	// Path patterns allowed.
+	// Use '*' to match a single path segment and '**', as the last segment of the pattern, to match any number of trailing segments.
+	Paths []string `json:"paths"`
+}
+
+type Authorization_RBAC_ConfigMapRef struct {
+	// The name of the ConfigMap in the same namespace of the AuthConfig.
+	Name string `json:"name"`
+
+	// The key of the ConfigMap to read the rules from.
+	Key string `json:"key"`
+
+	// Interval (in seconds) to reload the rules from the ConfigMap.
+	// +kubebuilder:default:=60
+	TTL int `json:"ttl,omitempty"`
+}
+
+// OAuth scope enforcement
+// The request is authorized if the token holds all the scopes listed in `allOf` and at least one of the scopes listed in `anyOf`, when provided.
+type Authorization_RequiredScopes struct {
+	// Selector of the scopes granted to the token in the authorization JSON.
+	// The selected value can be either an array of strings or a string of space-separated values.
+	// If omitted, the `scope` claim of the identity is used, falling back to the `scp` claim.
+	Selector string `json:"selector,omitempty"`
+
+	// Scopes that must all be granted to the token.
+	AllOf []string `json:"allOf,omitempty"`
+
+	// Scopes of which at least one must be granted to the token.
+	AnyOf []string `json:"anyOf,omitempty"`
+}
+
+// WebAssembly policy module
+// The module must export a `memory`, an `alloc(size i32) i32` function and an `evaluate(ptr i32, len i32) i64` function
+// that receives the authorization JSON and returns the pointer (upper 32 bits) and length (lower 32 bits) of the verdict,
+// a JSON object in the format {"allow":bool,"reason":string,"metadata":any}.
+// One of the following parameters is required: "image" or "configMapRef".
+type Authorization_Wasm struct {
+	// Reference to an OCI artifact holding the WebAssembly module (e.g. quay.io/org/policy:v1).
+	Image string `json:"image,omitempty"`
+
+	// Reference to a key of a Kubernetes ConfigMap in the namespace of the AuthConfig storing the WebAssembly module.
+	ConfigMap *ConfigMapKeyReference `json:"configMapRef,omitempty"`
+
+	// Maximum amount of fuel (roughly, number of instructions) the module can consume per evaluation.
+	// +kubebuilder:default:=10000000
+	MaxFuel int64 `json:"maxFuel,omitempty"`
+}
+
+// Lua authorization script
+// The script reads the authorization JSON from the `input` global variable and returns a boolean (`true` to allow the request),
+// optionally followed by a reason (string) when denying or an object (table) to expose in the authorization JSON when allowing.
+type Authorization_Lua struct {
+	// Lua script.
+	Script string `json:"script"`
+
+	// Maximum duration of the evaluation of the script per request, in milliseconds.
+	// +kubebuilder:default:=100
+	Timeout int `json:"timeout,omitempty"`
+}
+
+// Settings of the JSON Web Encryption (JWE) of a header value.
+type Response_Encryption struct {
+	// Reference to a Kubernetes secret that stores the public key of the recipient, formatted as PEM (public key or X.509 certificate).
+	// The name of the secret is set in the `kid` header of the JWE.
+	RecipientKeyRef SecretKeyReference `json:"recipientKeyRef"`
+	// Key management algorithm.
+	// Defaults to RSA-OAEP-256 for RSA keys and ECDH-ES+A256KW for EC keys.
+	// +kubebuilder:validation:Enum:=RSA-OAEP;RSA-OAEP-256;ECDH-ES;ECDH-ES+A128KW;ECDH-ES+A256KW
+	Algorithm string `json:"algorithm,omitempty"`
+	// Content encryption algorithm.
+	// +kubebuilder:validation:Enum:=A128GCM;A192GCM;A256GCM;A128CBC-HS256;A256CBC-HS512
+	// +kubebuilder:default:=A256GCM
+	ContentEncryption string `json:"contentEncryption,omitempty"`
+}
+
+// Compression of the value of an HTTP header
+type Response_Compression struct {
+	// Minimum size of the value, in bytes, to compress it.
+	// +kubebuilder:default:=1024
+	Threshold int `json:"threshold,omitempty"`
+}
+
+// Attributes of a cookie set in the response to the client.
+type Response_Cookie struct {
+	// Path attribute of the cookie.
+	Path string `json:"path,omitempty"`
+	// Domain attribute of the cookie.
+	Domain string `json:"domain,omitempty"`
+	// Lifetime of the cookie, in seconds (Max-Age attribute).
+	// Omit it for a session cookie; set a negative value to expire the cookie immediately.
+	MaxAge int `json:"maxAge,omitempty"`
+	// Whether the cookie is only sent over secure connections.
+	Secure bool `json:"secure,omitempty"`
+	// Whether the cookie is inaccessible to client-side scripts.
+	HttpOnly bool `json:"httpOnly,omitempty"`
+	// SameSite attribute of the cookie.
+	// +kubebuilder:validation:Enum:=Strict;Lax;None
+	SameSite string `json:"sameSite,omitempty"`
+}
+
+// +kubebuilder:validation:Enum:=httpHeader;envoyDynamicMetadata;httpResponseHeader;setCookie;queryParameter;grpcMetadata
 type Response_Wrapper string
 
 // Dynamic response to return to the client.
@@ -604,16 +990,30 @@ type Response struct {
 	Cache *EvaluatorCaching `json:"cache,omitempty"`
 
 	// How Authorino wraps the response.
-	// Use "httpHeader" (default) to wrap the response in an HTTP header; or "envoyDynamicMetadata" to wrap the response as Envoy Dynamic Metadata
+	// Use "httpHeader" (default) to wrap the response in an HTTP header; "envoyDynamicMetadata" to wrap the response as Envoy Dynamic Metadata;
+	// "httpResponseHeader" to wrap the response in an HTTP header added to the response to the client; "setCookie" to set the response as a
+	// cookie in the response to the client; "queryParameter" to set the response as a query parameter of the request forwarded upstream;
+	// or "grpcMetadata" to wrap the response as gRPC metadata of the request forwarded to a gRPC upstream (key lowercased; values of "-bin"
+	// keys base64-encoded)
 	// +kubebuilder:default:=httpHeader
 	Wrapper Response_Wrapper `json:"wrapper,omitempty"`
-	// The name of key used in the wrapped response (name of the HTTP header or property of the Envoy Dynamic Metadata JSON).
+	// The name of key used in the wrapped response (name of the HTTP header, property of the Envoy Dynamic Metadata JSON, name of the cookie, name of the query parameter or gRPC metadata key).
 	// If omitted, it will be set to the name of the configuration.
 	WrapperKey string `json:"wrapperKey,omitempty"`
+	// Attributes of the cookie, when the response is wrapped as "setCookie".
+	Cookie *Response_Cookie `json:"cookie,omitempty"`
+	// Encrypts the value of the HTTP header as a compact JSON Web Encryption (JWE) token, when the response is wrapped as "httpHeader" or "httpResponseHeader".
+	Encryption *Response_Encryption `json:"encryption,omitempty"`
+	// Compresses the value of the HTTP header with gzip and encodes it in base64, when larger than a given size, when the response is wrapped as "httpHeader" or "httpResponseHeader".
+	Compression *Response_Compression `json:"compression,omitempty"`
+	// Whether to append the value to the existing values of the HTTP header, instead of replacing them, when the response is wrapped as "httpHeader" or "httpResponseHeader".
+	// +kubebuilder:default:=false
+	Append bool `json:"append,omitempty"`
 
 	Wristband *Response_Wristband   `json:"wristband,omitempty"`
 	JSON      *Response_DynamicJSON `json:"json,omitempty"`
 	Plain     *Response_Plain       `json:"plain,omitempty"`
+	Signature *Response_Signature   `json:"signature,omitempty"`
 }
 
 func (r *Response) GetType() string {
@@ -623,6 +1023,8 @@ func (r *Response) GetType() string {
 		return ResponseDynamicJSON
 	} else if r.Plain != nil {
 		return ResponsePlain
+	} else if r.Signature != nil {
+		return ResponseSignature
 	}
 	return TypeUnknown
 }
@@ -647,17 +1049,66 @@ type Callback struct {
 	// If present, all conditions must match for the callback to be attempted; otherwise, the callback will be skipped.
 	Conditions []JSONPattern `json:"when,omitempty"`
 
-	HTTP *Metadata_GenericHTTP `json:"http"` // make this 'omitempty' if other alternate methods are added
+	// Maximum duration of the callback, in milliseconds.
+	// Callbacks are fired asynchronously, after the auth decision is returned.
+	// +kubebuilder:default:=5000
+	Timeout int `json:"timeout,omitempty"`
+
+	// Outcomes of the auth pipeline that trigger the callback (allow, deny, error).
+	// If omitted, the callback is triggered regardless of the outcome.
+	On []CallbackEvent `json:"on,omitempty"`
+
+	HTTP  *Metadata_GenericHTTP `json:"http,omitempty"`
+	Kafka *Callback_Kafka       `json:"kafka,omitempty"`
 }
 
 func (r *Callback) GetType() string {
 	if r.HTTP != nil {
 		return CallbackHTTP
+	} else if r.Kafka != nil {
+		return CallbackKafka
 	}
 	return TypeUnknown
 }
 
-// +kubebuilder:validation:Enum:=ES256;ES384;ES512;RS256;RS384;RS512
+// Kafka topic where to publish the records of the auth decisions
+type Callback_Kafka struct {
+	// Addresses of the Kafka brokers (format: <host>:<port>).
+	// +kubebuilder:validation:MinItems:=1
+	Brokers []string `json:"brokers"`
+
+	// Name of the Kafka topic.
+	Topic string `json:"topic"`
+
+	// Serialization of the records.
+	// +kubebuilder:validation:Enum:=json;protobuf
+	// +kubebuilder:default:=json
+	Serialization string `json:"serialization,omitempty"`
+
+	// Subject of the auth decision, also used as key of the records.
+	// Defaults to the 'sub' claim of the identity object.
+	Subject *StaticOrDynamicValue `json:"subject,omitempty"`
+
+	// Wraps the records in CloudEvents envelopes (JSON format).
+	// Requires JSON serialization.
+	CloudEvents *Callback_CloudEvents `json:"cloudEvents,omitempty"`
+}
+
+// Attributes of the CloudEvents envelope of the records of the auth decisions
+type Callback_CloudEvents struct {
+	// Type of the events.
+	// Defaults to "io.kuadrant.authorino.decision".
+	Type string `json:"type,omitempty"`
+
+	// Source of the events.
+	// Defaults to "authorino/<namespace>/<authconfig-name>".
+	Source string `json:"source,omitempty"`
+}
+
+// +kubebuilder:validation:Enum:=allow;deny;error
+type CallbackEvent string
+
+// +kubebuilder:validation:Enum:=ES256;ES384;ES512;RS256;RS384;RS512;EdDSA;HS256;HS384;HS512
 type SigningKeyAlgorithm string
 
 type SigningKeyRef struct {
@@ -667,6 +1118,37 @@ type SigningKeyRef struct {
 
 	// Algorithm to sign the wristband token using the signing key provided
 	Algorithm SigningKeyAlgorithm `json:"algorithm"`
+
+	// Key stored in a cloud key management service to sign the wristband token with, instead of a Kubernetes secret.
+	// The private key never leaves the service. The `kid` claim of the wristband token header is <name>/<key version>.
+	// +optional
+	KMS *KMSSigningKey `json:"kms,omitempty"`
+}
+
+// +kubebuilder:validation:Enum:=aws;gcp;azure
+type KMSProvider string
+
+// Settings of a signing key stored in a cloud key management service
+type KMSSigningKey struct {
+	// Key management service that stores the key: AWS KMS (aws), Google Cloud KMS (gcp) or Azure Key Vault (azure).
+	Provider KMSProvider `json:"provider"`
+
+	// Identifier of the key.
+	// AWS: key id, key ARN, alias name or alias ARN.
+	// GCP: resource name of the key (projects/*/locations/*/keyRings/*/cryptoKeys/*) or of a version of the key.
+	// Azure: URL of the key (https://{vault}.vault.azure.net/keys/{name}) or of a version of the key.
+	// Unless a version is pinned, new versions of the key are picked up as they are created.
+	KeyId string `json:"keyId"`
+
+	// AWS region of the key. Omit to use the region of the key ARN or of the environment of Authorino.
+	// +optional
+	Region string `json:"region,omitempty"`
+
+	// Reference to a Kubernetes secret in the same namespace that stores the credentials to call the key management service.
+	// AWS: accessKeyId, secretAccessKey and (optional) sessionToken. GCP: credentials.json. Azure: tenantId, clientId and clientSecret.
+	// Omit to use the credentials of the environment of Authorino (e.g. workload identity).
+	// +optional
+	CredentialsRef *k8score.LocalObjectReference `json:"credentialsRef,omitempty"`
 }
 
 type Response_Wristband struct {
@@ -677,8 +1159,14 @@ type Response_Wristband struct {
 	// Time span of the wristband token, in seconds.
 	TokenDuration *int64 `json:"tokenDuration,omitempty"`
 	// Reference by name to Kubernetes secrets and corresponding signing algorithms.
-	// The secrets must contain a `key.pem` entry whose value is the signing key formatted as PEM.
+	// The secrets must contain a `key.pem` entry whose value is the signing key formatted as PEM (EC, RSA or Ed25519 private key, matching the algorithm),
+	// except for the HMAC algorithms (HS256, HS384, HS512), whose secrets must contain a `key` entry with the shared secret.
 	SigningKeyRefs []*SigningKeyRef `json:"signingKeyRefs"`
+	// Interval of rotation of the signing key, in seconds.
+	// When set, the wristband tokens are signed with each of the keys listed in `signingKeyRefs`, in order, for one interval at a time.
+	// All the keys are always published in the JWKS of the wristband issuer, so tokens signed with any of them remain verifiable.
+	// Omit it to always sign the tokens with the first key.
+	SigningKeyRotationInterval int64 `json:"signingKeyRotationInterval,omitempty"`
 }
 
 type Response_DynamicJSON struct {
@@ -688,6 +1176,22 @@ type Response_DynamicJSON struct {
 
 type Response_Plain StaticOrDynamicValue
 
+// Signature of the request, for the upstream service to verify the request was authorized by Authorino
+type Response_Signature struct {
+	// Reference to the Kubernetes secret that stores the signing key.
+	// The secret must contain a `key` entry with the shared secret for the HMAC algorithms, or a `key.pem` entry with the
+	// private key formatted as PEM otherwise.
+	SigningKeyRef k8score.LocalObjectReference `json:"signingKeyRef"`
+
+	// Algorithm to sign the request.
+	// +kubebuilder:default:=HS256
+	Algorithm SigningKeyAlgorithm `json:"algorithm,omitempty"`
+
+	// Selectors of the attributes of the request to sign, fetched from the authorization JSON.
+	// Defaults to the method, host and path of the request.
+	Attributes []string `json:"attributes,omitempty"`
+}
+
 // +kubebuilder:validation:Minimum:=300
 // +kubebuilder:validation:Maximum:=599
 type DenyWith_Code int64
@@ -704,6 +1208,25 @@ type DenyWithSpec struct {
 
 	// HTTP response body to override the default denial body.
 	Body *StaticOrDynamicValue `json:"body,omitempty"`
+
+	// URL to redirect the client to (e.g. the authorization endpoint of an identity provider), set in the `Location` header of the denial response.
+	// Unless a custom `code` is specified, the status code of the denial response is set to 302 (Found).
+	RedirectTo *StaticOrDynamicValue `json:"redirectTo,omitempty"`
+
+	// Formats the body of the denial response as RFC 7807 problem details (application/problem+json).
+	// Ignored if a custom `body` is specified.
+	ProblemDetails *DenyWith_ProblemDetails `json:"problemDetails,omitempty"`
+}
+
+// RFC 7807 problem details of the denial response
+type DenyWith_ProblemDetails struct {
+	// URI reference that identifies the problem type.
+	// Defaults to "about:blank".
+	Type string `json:"type,omitempty"`
+
+	// Short, human-readable summary of the problem type.
+	// Defaults to the standard text of the HTTP status code.
+	Title string `json:"title,omitempty"`
 }
 
 type DenyWith struct {
@@ -816,6 +1339,9 @@ func (s AuthConfigSlice) Len() int {
 }
 
 func (s AuthConfigSlice) Less(i, j int) bool {
+	if s[i].Spec.Priority != s[j].Spec.Priority {
+		return s[i].Spec.Priority > s[j].Spec.Priority
+	}
 	return s[i].CreationTimestamp.Before(&s[j].CreationTimestamp)
 }
 
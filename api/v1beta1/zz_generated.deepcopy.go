@@ -181,6 +181,11 @@ func (in *AuthConfigSpec) DeepCopyInto(out *AuthConfigSpec) {
 			}
 		}
 	}
+	if in.QueryParametersToRemove != nil {
+		in, out := &in.QueryParametersToRemove, &out.QueryParametersToRemove
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Callbacks != nil {
 		in, out := &in.Callbacks, &out.Callbacks
 		*out = make([]*Callback, len(*in))
@@ -247,6 +252,13 @@ func (in *Authorization) DeepCopyInto(out *Authorization) {
 		*out = new(EvaluatorCaching)
 		**out = **in
 	}
+	if in.Exports != nil {
+		in, out := &in.Exports, &out.Exports
+		*out = make([]JsonProperty, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.OPA != nil {
 		in, out := &in.OPA, &out.OPA
 		*out = new(Authorization_OPA)
@@ -267,6 +279,31 @@ func (in *Authorization) DeepCopyInto(out *Authorization) {
 		*out = new(Authorization_Authzed)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Quota != nil {
+		in, out := &in.Quota, &out.Quota
+		*out = new(Authorization_Quota)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RBAC != nil {
+		in, out := &in.RBAC, &out.RBAC
+		*out = new(Authorization_RBAC)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RequiredScopes != nil {
+		in, out := &in.RequiredScopes, &out.RequiredScopes
+		*out = new(Authorization_RequiredScopes)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Wasm != nil {
+		in, out := &in.Wasm, &out.Wasm
+		*out = new(Authorization_Wasm)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Lua != nil {
+		in, out := &in.Lua, &out.Lua
+		*out = new(Authorization_Lua)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Authorization.
@@ -379,6 +416,21 @@ func (in *Authorization_KubernetesAuthz_ResourceAttributes) DeepCopy() *Authoriz
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Authorization_Lua) DeepCopyInto(out *Authorization_Lua) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Authorization_Lua.
+func (in *Authorization_Lua) DeepCopy() *Authorization_Lua {
+	if in == nil {
+		return nil
+	}
+	out := new(Authorization_Lua)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Authorization_OPA) DeepCopyInto(out *Authorization_OPA) {
 	*out = *in
@@ -395,6 +447,164 @@ func (in *Authorization_OPA) DeepCopy() *Authorization_OPA {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Authorization_Quota) DeepCopyInto(out *Authorization_Quota) {
+	*out = *in
+	out.Key = in.Key
+	if in.Redis != nil {
+		in, out := &in.Redis, &out.Redis
+		*out = new(Authorization_Quota_Redis)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Authorization_Quota.
+func (in *Authorization_Quota) DeepCopy() *Authorization_Quota {
+	if in == nil {
+		return nil
+	}
+	out := new(Authorization_Quota)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Authorization_Quota_Redis) DeepCopyInto(out *Authorization_Quota_Redis) {
+	*out = *in
+	if in.Password != nil {
+		in, out := &in.Password, &out.Password
+		*out = new(SecretKeyReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Authorization_Quota_Redis.
+func (in *Authorization_Quota_Redis) DeepCopy() *Authorization_Quota_Redis {
+	if in == nil {
+		return nil
+	}
+	out := new(Authorization_Quota_Redis)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Authorization_RBAC) DeepCopyInto(out *Authorization_RBAC) {
+	*out = *in
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]Authorization_RBAC_Rule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ConfigMap != nil {
+		in, out := &in.ConfigMap, &out.ConfigMap
+		*out = new(Authorization_RBAC_ConfigMapRef)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Authorization_RBAC.
+func (in *Authorization_RBAC) DeepCopy() *Authorization_RBAC {
+	if in == nil {
+		return nil
+	}
+	out := new(Authorization_RBAC)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Authorization_RBAC_ConfigMapRef) DeepCopyInto(out *Authorization_RBAC_ConfigMapRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Authorization_RBAC_ConfigMapRef.
+func (in *Authorization_RBAC_ConfigMapRef) DeepCopy() *Authorization_RBAC_ConfigMapRef {
+	if in == nil {
+		return nil
+	}
+	out := new(Authorization_RBAC_ConfigMapRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Authorization_RBAC_Rule) DeepCopyInto(out *Authorization_RBAC_Rule) {
+	*out = *in
+	if in.Roles != nil {
+		in, out := &in.Roles, &out.Roles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Methods != nil {
+		in, out := &in.Methods, &out.Methods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Paths != nil {
+		in, out := &in.Paths, &out.Paths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Authorization_RBAC_Rule.
+func (in *Authorization_RBAC_Rule) DeepCopy() *Authorization_RBAC_Rule {
+	if in == nil {
+		return nil
+	}
+	out := new(Authorization_RBAC_Rule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Authorization_RequiredScopes) DeepCopyInto(out *Authorization_RequiredScopes) {
+	*out = *in
+	if in.AllOf != nil {
+		in, out := &in.AllOf, &out.AllOf
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AnyOf != nil {
+		in, out := &in.AnyOf, &out.AnyOf
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Authorization_RequiredScopes.
+func (in *Authorization_RequiredScopes) DeepCopy() *Authorization_RequiredScopes {
+	if in == nil {
+		return nil
+	}
+	out := new(Authorization_RequiredScopes)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Authorization_Wasm) DeepCopyInto(out *Authorization_Wasm) {
+	*out = *in
+	if in.ConfigMap != nil {
+		in, out := &in.ConfigMap, &out.ConfigMap
+		*out = new(ConfigMapKeyReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Authorization_Wasm.
+func (in *Authorization_Wasm) DeepCopy() *Authorization_Wasm {
+	if in == nil {
+		return nil
+	}
+	out := new(Authorization_Wasm)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AuthzedObject) DeepCopyInto(out *AuthzedObject) {
 	*out = *in
@@ -422,11 +632,21 @@ func (in *Callback) DeepCopyInto(out *Callback) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.On != nil {
+		in, out := &in.On, &out.On
+		*out = make([]CallbackEvent, len(*in))
+		copy(*out, *in)
+	}
 	if in.HTTP != nil {
 		in, out := &in.HTTP, &out.HTTP
 		*out = new(Metadata_GenericHTTP)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Kafka != nil {
+		in, out := &in.Kafka, &out.Kafka
+		*out = new(Callback_Kafka)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Callback.
@@ -439,6 +659,51 @@ func (in *Callback) DeepCopy() *Callback {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Callback_CloudEvents) DeepCopyInto(out *Callback_CloudEvents) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Callback_CloudEvents.
+func (in *Callback_CloudEvents) DeepCopy() *Callback_CloudEvents {
+	if in == nil {
+		return nil
+	}
+	out := new(Callback_CloudEvents)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Callback_Kafka) DeepCopyInto(out *Callback_Kafka) {
+	*out = *in
+	if in.Brokers != nil {
+		in, out := &in.Brokers, &out.Brokers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Subject != nil {
+		in, out := &in.Subject, &out.Subject
+		*out = new(StaticOrDynamicValue)
+		**out = **in
+	}
+	if in.CloudEvents != nil {
+		in, out := &in.CloudEvents, &out.CloudEvents
+		*out = new(Callback_CloudEvents)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Callback_Kafka.
+func (in *Callback_Kafka) DeepCopy() *Callback_Kafka {
+	if in == nil {
+		return nil
+	}
+	out := new(Callback_Kafka)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Condition) DeepCopyInto(out *Condition) {
 	*out = *in
@@ -459,6 +724,21 @@ func (in *Condition) DeepCopy() *Condition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigMapKeyReference) DeepCopyInto(out *ConfigMapKeyReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigMapKeyReference.
+func (in *ConfigMapKeyReference) DeepCopy() *ConfigMapKeyReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigMapKeyReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Credentials) DeepCopyInto(out *Credentials) {
 	*out = *in
@@ -519,6 +799,16 @@ func (in *DenyWithSpec) DeepCopyInto(out *DenyWithSpec) {
 		*out = new(StaticOrDynamicValue)
 		**out = **in
 	}
+	if in.RedirectTo != nil {
+		in, out := &in.RedirectTo, &out.RedirectTo
+		*out = new(StaticOrDynamicValue)
+		**out = **in
+	}
+	if in.ProblemDetails != nil {
+		in, out := &in.ProblemDetails, &out.ProblemDetails
+		*out = new(DenyWith_ProblemDetails)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DenyWithSpec.
@@ -531,6 +821,21 @@ func (in *DenyWithSpec) DeepCopy() *DenyWithSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DenyWith_ProblemDetails) DeepCopyInto(out *DenyWith_ProblemDetails) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DenyWith_ProblemDetails.
+func (in *DenyWith_ProblemDetails) DeepCopy() *DenyWith_ProblemDetails {
+	if in == nil {
+		return nil
+	}
+	out := new(DenyWith_ProblemDetails)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EvaluatorCaching) DeepCopyInto(out *EvaluatorCaching) {
 	*out = *in
@@ -615,7 +920,7 @@ func (in *Identity) DeepCopyInto(out *Identity) {
 	if in.Oidc != nil {
 		in, out := &in.Oidc, &out.Oidc
 		*out = new(Identity_OidcConfig)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.APIKey != nil {
 		in, out := &in.APIKey, &out.APIKey
@@ -627,6 +932,31 @@ func (in *Identity) DeepCopyInto(out *Identity) {
 		*out = new(Identity_MTLS)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Spiffe != nil {
+		in, out := &in.Spiffe, &out.Spiffe
+		*out = new(Identity_Spiffe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AwsSigV4 != nil {
+		in, out := &in.AwsSigV4, &out.AwsSigV4
+		*out = new(Identity_AwsSigV4)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GoogleIDToken != nil {
+		in, out := &in.GoogleIDToken, &out.GoogleIDToken
+		*out = new(Identity_GoogleIDToken)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GitToken != nil {
+		in, out := &in.GitToken, &out.GitToken
+		*out = new(Identity_GitToken)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.VaultTransit != nil {
+		in, out := &in.VaultTransit, &out.VaultTransit
+		*out = new(Identity_VaultTransit)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.KubernetesAuth != nil {
 		in, out := &in.KubernetesAuth, &out.KubernetesAuth
 		*out = new(Identity_KubernetesAuth)
@@ -654,6 +984,21 @@ func (in *Identity) DeepCopy() *Identity {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IdentityProviderReference) DeepCopyInto(out *IdentityProviderReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IdentityProviderReference.
+func (in *IdentityProviderReference) DeepCopy() *IdentityProviderReference {
+	if in == nil {
+		return nil
+	}
+	out := new(IdentityProviderReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Identity_APIKey) DeepCopyInto(out *Identity_APIKey) {
 	*out = *in
@@ -689,6 +1034,101 @@ func (in *Identity_Anonymous) DeepCopy() *Identity_Anonymous {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Identity_AwsSigV4) DeepCopyInto(out *Identity_AwsSigV4) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Sts != nil {
+		in, out := &in.Sts, &out.Sts
+		*out = new(Identity_AwsSts)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Identity_AwsSigV4.
+func (in *Identity_AwsSigV4) DeepCopy() *Identity_AwsSigV4 {
+	if in == nil {
+		return nil
+	}
+	out := new(Identity_AwsSigV4)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Identity_AwsSts) DeepCopyInto(out *Identity_AwsSts) {
+	*out = *in
+	if in.Regions != nil {
+		in, out := &in.Regions, &out.Regions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Identity_AwsSts.
+func (in *Identity_AwsSts) DeepCopy() *Identity_AwsSts {
+	if in == nil {
+		return nil
+	}
+	out := new(Identity_AwsSts)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Identity_GitToken) DeepCopyInto(out *Identity_GitToken) {
+	*out = *in
+	if in.Audiences != nil {
+		in, out := &in.Audiences, &out.Audiences
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Identity_GitToken.
+func (in *Identity_GitToken) DeepCopy() *Identity_GitToken {
+	if in == nil {
+		return nil
+	}
+	out := new(Identity_GitToken)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Identity_GoogleIDToken) DeepCopyInto(out *Identity_GoogleIDToken) {
+	*out = *in
+	if in.Audiences != nil {
+		in, out := &in.Audiences, &out.Audiences
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Emails != nil {
+		in, out := &in.Emails, &out.Emails
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Domains != nil {
+		in, out := &in.Domains, &out.Domains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Identity_GoogleIDToken.
+func (in *Identity_GoogleIDToken) DeepCopy() *Identity_GoogleIDToken {
+	if in == nil {
+		return nil
+	}
+	out := new(Identity_GoogleIDToken)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Identity_KubernetesAuth) DeepCopyInto(out *Identity_KubernetesAuth) {
 	*out = *in
@@ -737,6 +1177,11 @@ func (in *Identity_OAuth2Config) DeepCopyInto(out *Identity_OAuth2Config) {
 		*out = new(v1.LocalObjectReference)
 		**out = **in
 	}
+	if in.IdentityProviderRef != nil {
+		in, out := &in.IdentityProviderRef, &out.IdentityProviderRef
+		*out = new(IdentityProviderReference)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Identity_OAuth2Config.
@@ -752,6 +1197,16 @@ func (in *Identity_OAuth2Config) DeepCopy() *Identity_OAuth2Config {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Identity_OidcConfig) DeepCopyInto(out *Identity_OidcConfig) {
 	*out = *in
+	if in.Tenants != nil {
+		in, out := &in.Tenants, &out.Tenants
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.IdentityProviderRef != nil {
+		in, out := &in.IdentityProviderRef, &out.IdentityProviderRef
+		*out = new(IdentityProviderReference)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Identity_OidcConfig.
@@ -779,6 +1234,46 @@ func (in *Identity_Plain) DeepCopy() *Identity_Plain {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Identity_Spiffe) DeepCopyInto(out *Identity_Spiffe) {
+	*out = *in
+	if in.Audiences != nil {
+		in, out := &in.Audiences, &out.Audiences
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Identity_Spiffe.
+func (in *Identity_Spiffe) DeepCopy() *Identity_Spiffe {
+	if in == nil {
+		return nil
+	}
+	out := new(Identity_Spiffe)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Identity_VaultTransit) DeepCopyInto(out *Identity_VaultTransit) {
+	*out = *in
+	if in.Audiences != nil {
+		in, out := &in.Audiences, &out.Audiences
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Identity_VaultTransit.
+func (in *Identity_VaultTransit) DeepCopy() *Identity_VaultTransit {
+	if in == nil {
+		return nil
+	}
+	out := new(Identity_VaultTransit)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *JSONPattern) DeepCopyInto(out *JSONPattern) {
 	*out = *in
@@ -876,6 +1371,26 @@ func (in *JsonProperty) DeepCopy() *JsonProperty {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KMSSigningKey) DeepCopyInto(out *KMSSigningKey) {
+	*out = *in
+	if in.CredentialsRef != nil {
+		in, out := &in.CredentialsRef, &out.CredentialsRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KMSSigningKey.
+func (in *KMSSigningKey) DeepCopy() *KMSSigningKey {
+	if in == nil {
+		return nil
+	}
+	out := new(KMSSigningKey)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Metadata) DeepCopyInto(out *Metadata) {
 	*out = *in
@@ -1051,6 +1566,21 @@ func (in *Response) DeepCopyInto(out *Response) {
 		*out = new(EvaluatorCaching)
 		**out = **in
 	}
+	if in.Cookie != nil {
+		in, out := &in.Cookie, &out.Cookie
+		*out = new(Response_Cookie)
+		**out = **in
+	}
+	if in.Encryption != nil {
+		in, out := &in.Encryption, &out.Encryption
+		*out = new(Response_Encryption)
+		**out = **in
+	}
+	if in.Compression != nil {
+		in, out := &in.Compression, &out.Compression
+		*out = new(Response_Compression)
+		**out = **in
+	}
 	if in.Wristband != nil {
 		in, out := &in.Wristband, &out.Wristband
 		*out = new(Response_Wristband)
@@ -1066,6 +1596,11 @@ func (in *Response) DeepCopyInto(out *Response) {
 		*out = new(Response_Plain)
 		**out = **in
 	}
+	if in.Signature != nil {
+		in, out := &in.Signature, &out.Signature
+		*out = new(Response_Signature)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Response.
@@ -1078,6 +1613,36 @@ func (in *Response) DeepCopy() *Response {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Response_Compression) DeepCopyInto(out *Response_Compression) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Response_Compression.
+func (in *Response_Compression) DeepCopy() *Response_Compression {
+	if in == nil {
+		return nil
+	}
+	out := new(Response_Compression)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Response_Cookie) DeepCopyInto(out *Response_Cookie) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Response_Cookie.
+func (in *Response_Cookie) DeepCopy() *Response_Cookie {
+	if in == nil {
+		return nil
+	}
+	out := new(Response_Cookie)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Response_DynamicJSON) DeepCopyInto(out *Response_DynamicJSON) {
 	*out = *in
@@ -1100,6 +1665,22 @@ func (in *Response_DynamicJSON) DeepCopy() *Response_DynamicJSON {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Response_Encryption) DeepCopyInto(out *Response_Encryption) {
+	*out = *in
+	out.RecipientKeyRef = in.RecipientKeyRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Response_Encryption.
+func (in *Response_Encryption) DeepCopy() *Response_Encryption {
+	if in == nil {
+		return nil
+	}
+	out := new(Response_Encryption)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Response_Plain) DeepCopyInto(out *Response_Plain) {
 	*out = *in
@@ -1116,6 +1697,27 @@ func (in *Response_Plain) DeepCopy() *Response_Plain {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Response_Signature) DeepCopyInto(out *Response_Signature) {
+	*out = *in
+	out.SigningKeyRef = in.SigningKeyRef
+	if in.Attributes != nil {
+		in, out := &in.Attributes, &out.Attributes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Response_Signature.
+func (in *Response_Signature) DeepCopy() *Response_Signature {
+	if in == nil {
+		return nil
+	}
+	out := new(Response_Signature)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Response_Wristband) DeepCopyInto(out *Response_Wristband) {
 	*out = *in
@@ -1138,7 +1740,7 @@ func (in *Response_Wristband) DeepCopyInto(out *Response_Wristband) {
 			if (*in)[i] != nil {
 				in, out := &(*in)[i], &(*out)[i]
 				*out = new(SigningKeyRef)
-				**out = **in
+				(*in).DeepCopyInto(*out)
 			}
 		}
 	}
@@ -1172,6 +1774,11 @@ func (in *SecretKeyReference) DeepCopy() *SecretKeyReference {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SigningKeyRef) DeepCopyInto(out *SigningKeyRef) {
 	*out = *in
+	if in.KMS != nil {
+		in, out := &in.KMS, &out.KMS
+		*out = new(KMSSigningKey)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SigningKeyRef.
@@ -52,11 +52,26 @@ func (src *AuthConfig) ConvertTo(dstRaw conversion.Hub) error {
 		authorization := convertAuthorizationTo(name, authorizationSrc)
 		dst.Spec.Authorization = append(dst.Spec.Authorization, authorization)
 	}
+	dst.Spec.AuthorizationStrategy = string(src.Spec.AuthorizationStrategy)
+	dst.Spec.Priority = src.Spec.Priority
+	dst.Spec.Metrics = src.Spec.Metrics
+	dst.Spec.LogLevel = src.Spec.LogLevel
 
 	// response
 	if src.Spec.Response != nil {
 		for name, responseSrc := range src.Spec.Response.Success.Headers {
 			response := convertSuccessResponseTo(name, responseSrc.SuccessResponseSpec, "httpHeader")
+			response.Encryption = convertHeaderEncryptionTo(responseSrc.Encryption)
+			response.Compression = convertHeaderCompressionTo(responseSrc.Compression)
+			response.Append = responseSrc.Append
+			dst.Spec.Response = append(dst.Spec.Response, response)
+		}
+
+		for name, responseSrc := range src.Spec.Response.Success.ResponseHeaders {
+			response := convertSuccessResponseTo(name, responseSrc.SuccessResponseSpec, "httpResponseHeader")
+			response.Encryption = convertHeaderEncryptionTo(responseSrc.Encryption)
+			response.Compression = convertHeaderCompressionTo(responseSrc.Compression)
+			response.Append = responseSrc.Append
 			dst.Spec.Response = append(dst.Spec.Response, response)
 		}
 
@@ -65,6 +80,24 @@ func (src *AuthConfig) ConvertTo(dstRaw conversion.Hub) error {
 			dst.Spec.Response = append(dst.Spec.Response, response)
 		}
 
+		for name, responseSrc := range src.Spec.Response.Success.Cookies {
+			response := convertSuccessResponseTo(name, responseSrc.SuccessResponseSpec, "setCookie")
+			response.Cookie = convertCookieAttributesTo(responseSrc.CookieAttributes)
+			dst.Spec.Response = append(dst.Spec.Response, response)
+		}
+
+		for name, responseSrc := range src.Spec.Response.Success.GrpcMetadata {
+			response := convertSuccessResponseTo(name, responseSrc, "grpcMetadata")
+			dst.Spec.Response = append(dst.Spec.Response, response)
+		}
+
+		for name, responseSrc := range src.Spec.Response.Success.QueryParameters {
+			response := convertSuccessResponseTo(name, responseSrc, "queryParameter")
+			dst.Spec.Response = append(dst.Spec.Response, response)
+		}
+		dst.Spec.DynamicMetadataNamespace = src.Spec.Response.Success.DynamicMetadataNamespace
+		dst.Spec.QueryParametersToRemove = src.Spec.Response.Success.QueryParametersToRemove
+
 		// denyWith
 		if src.Spec.Response.Unauthenticated != nil || src.Spec.Response.Unauthorized != nil {
 			dst.Spec.DenyWith = &v1beta1.DenyWith{}
@@ -142,12 +175,18 @@ func (dst *AuthConfig) ConvertFrom(srcRaw conversion.Hub) error {
 			dst.Spec.Authorization[name] = authorization
 		}
 	}
+	dst.Spec.AuthorizationStrategy = AuthorizationStrategy(src.Spec.AuthorizationStrategy)
+	dst.Spec.Priority = src.Spec.Priority
+	dst.Spec.Metrics = src.Spec.Metrics
+	dst.Spec.LogLevel = src.Spec.LogLevel
 
 	// response
 	denyWith := src.Spec.DenyWith
 
-	if denyWith != nil || len(src.Spec.Response) > 0 {
+	if denyWith != nil || len(src.Spec.Response) > 0 || src.Spec.DynamicMetadataNamespace != "" || len(src.Spec.QueryParametersToRemove) > 0 {
 		dst.Spec.Response = &ResponseSpec{}
+		dst.Spec.Response.Success.DynamicMetadataNamespace = src.Spec.DynamicMetadataNamespace
+		dst.Spec.Response.Success.QueryParametersToRemove = src.Spec.QueryParametersToRemove
 	}
 
 	if denyWith != nil && denyWith.Unauthenticated != nil {
@@ -168,6 +207,25 @@ func (dst *AuthConfig) ConvertFrom(srcRaw conversion.Hub) error {
 		name, response := convertSuccessResponseFrom(responseSrc)
 		dst.Spec.Response.Success.Headers[name] = HeaderSuccessResponseSpec{
 			SuccessResponseSpec: response,
+			Encryption:          convertHeaderEncryptionFrom(responseSrc.Encryption),
+			Compression:         convertHeaderCompressionFrom(responseSrc.Compression),
+			Append:              responseSrc.Append,
+		}
+	}
+
+	for _, responseSrc := range src.Spec.Response {
+		if responseSrc.Wrapper != "httpResponseHeader" {
+			continue
+		}
+		if dst.Spec.Response.Success.ResponseHeaders == nil {
+			dst.Spec.Response.Success.ResponseHeaders = make(map[string]HeaderSuccessResponseSpec)
+		}
+		name, response := convertSuccessResponseFrom(responseSrc)
+		dst.Spec.Response.Success.ResponseHeaders[name] = HeaderSuccessResponseSpec{
+			SuccessResponseSpec: response,
+			Encryption:          convertHeaderEncryptionFrom(responseSrc.Encryption),
+			Compression:         convertHeaderCompressionFrom(responseSrc.Compression),
+			Append:              responseSrc.Append,
 		}
 	}
 
@@ -182,6 +240,42 @@ func (dst *AuthConfig) ConvertFrom(srcRaw conversion.Hub) error {
 		dst.Spec.Response.Success.DynamicMetadata[name] = response
 	}
 
+	for _, responseSrc := range src.Spec.Response {
+		if responseSrc.Wrapper != "setCookie" {
+			continue
+		}
+		if dst.Spec.Response.Success.Cookies == nil {
+			dst.Spec.Response.Success.Cookies = make(map[string]CookieSuccessResponseSpec)
+		}
+		name, response := convertSuccessResponseFrom(responseSrc)
+		dst.Spec.Response.Success.Cookies[name] = CookieSuccessResponseSpec{
+			SuccessResponseSpec: response,
+			CookieAttributes:    convertCookieAttributesFrom(responseSrc.Cookie),
+		}
+	}
+
+	for _, responseSrc := range src.Spec.Response {
+		if responseSrc.Wrapper != "grpcMetadata" {
+			continue
+		}
+		if dst.Spec.Response.Success.GrpcMetadata == nil {
+			dst.Spec.Response.Success.GrpcMetadata = make(map[string]SuccessResponseSpec)
+		}
+		name, response := convertSuccessResponseFrom(responseSrc)
+		dst.Spec.Response.Success.GrpcMetadata[name] = response
+	}
+
+	for _, responseSrc := range src.Spec.Response {
+		if responseSrc.Wrapper != "queryParameter" {
+			continue
+		}
+		if dst.Spec.Response.Success.QueryParameters == nil {
+			dst.Spec.Response.Success.QueryParameters = make(map[string]SuccessResponseSpec)
+		}
+		name, response := convertSuccessResponseFrom(responseSrc)
+		dst.Spec.Response.Success.QueryParameters[name] = response
+	}
+
 	// callbacks
 	if src.Spec.Callbacks != nil {
 		dst.Spec.Callbacks = make(map[string]CallbackSpec, len(src.Spec.Callbacks))
@@ -370,6 +464,12 @@ func convertCredentialsTo(src Credentials) v1beta1.Credentials {
 	case CookieCredentials:
 		in = "cookie"
 		key = src.Cookie.Name
+	case WebSocketProtocolCredentials:
+		in = "websocket_protocol"
+		key = src.WebSocketProtocol.Prefix
+	case GRPCMetadataCredentials:
+		in = "grpc_metadata"
+		key = src.GRPCMetadata.Name
 	}
 	return v1beta1.Credentials{
 		In:          v1beta1.Credentials_In(in),
@@ -396,6 +496,14 @@ func convertCredentialsFrom(src v1beta1.Credentials) Credentials {
 		credentials.Cookie = &Named{
 			Name: src.KeySelector,
 		}
+	case "websocket_protocol":
+		credentials.WebSocketProtocol = &Prefixed{
+			Prefix: src.KeySelector,
+		}
+	case "grpc_metadata":
+		credentials.GRPCMetadata = &Named{
+			Name: src.KeySelector,
+		}
 	}
 	return credentials
 }
@@ -421,27 +529,37 @@ func convertAuthenticationTo(name string, src AuthenticationSpec) *v1beta1.Ident
 		Conditions:         utils.Map(src.Conditions, convertPatternExpressionOrRefTo),
 		Cache:              convertEvaluatorCachingTo(src.Cache),
 		Credentials:        convertCredentialsTo(src.Credentials),
+		StripCredentials:   src.StripCredentials,
 		ExtendedProperties: extendedProperties,
 	}
 
 	switch src.GetMethod() {
 	case ApiKeyAuthentication:
-		selector := *src.ApiKey.Selector
 		identity.APIKey = &v1beta1.Identity_APIKey{
-			Selector:      &selector,
+			Selector:      src.ApiKey.Selector.DeepCopy(),
 			AllNamespaces: src.ApiKey.AllNamespaces,
+			VaultPath:     src.ApiKey.VaultPath,
 		}
 	case JwtAuthentication:
 		identity.Oidc = &v1beta1.Identity_OidcConfig{
 			Endpoint: src.Jwt.IssuerUrl,
+			Tenants:  src.Jwt.Tenants,
 			TTL:      src.Jwt.TTL,
 		}
+		if ref := src.Jwt.IdentityProviderRef; ref != nil {
+			identity.Oidc.IdentityProviderRef = &v1beta1.IdentityProviderReference{Name: ref.Name}
+		}
 	case OAuth2TokenIntrospectionAuthentication:
-		credentials := *src.OAuth2TokenIntrospection.Credentials
 		identity.OAuth2 = &v1beta1.Identity_OAuth2Config{
 			TokenIntrospectionUrl: src.OAuth2TokenIntrospection.Url,
 			TokenTypeHint:         src.OAuth2TokenIntrospection.TokenTypeHint,
-			Credentials:           &credentials,
+		}
+		if src.OAuth2TokenIntrospection.Credentials != nil {
+			credentials := *src.OAuth2TokenIntrospection.Credentials
+			identity.OAuth2.Credentials = &credentials
+		}
+		if ref := src.OAuth2TokenIntrospection.IdentityProviderRef; ref != nil {
+			identity.OAuth2.IdentityProviderRef = &v1beta1.IdentityProviderReference{Name: ref.Name}
 		}
 	case KubernetesTokenReviewAuthentication:
 		identity.KubernetesAuth = &v1beta1.Identity_KubernetesAuth{
@@ -453,6 +571,50 @@ func convertAuthenticationTo(name string, src AuthenticationSpec) *v1beta1.Ident
 			Selector:      &selector,
 			AllNamespaces: src.X509ClientCertificate.AllNamespaces,
 		}
+	case SpiffeAuthentication:
+		identity.Spiffe = &v1beta1.Identity_Spiffe{
+			TrustDomain:       src.Spiffe.TrustDomain,
+			BundleEndpointUrl: src.Spiffe.BundleEndpointUrl,
+			TTL:               src.Spiffe.TTL,
+			Audiences:         src.Spiffe.Audiences,
+		}
+	case AwsSigV4Authentication:
+		identity.AwsSigV4 = &v1beta1.Identity_AwsSigV4{
+			AllNamespaces:        src.AwsSigV4.AllNamespaces,
+			Region:               src.AwsSigV4.Region,
+			Service:              src.AwsSigV4.Service,
+			AllowUnsignedPayload: src.AwsSigV4.AllowUnsignedPayload,
+		}
+		if src.AwsSigV4.Selector != nil {
+			selector := *src.AwsSigV4.Selector
+			identity.AwsSigV4.Selector = &selector
+		}
+		if sts := src.AwsSigV4.Sts; sts != nil {
+			identity.AwsSigV4.Sts = &v1beta1.Identity_AwsSts{
+				Regions:  sts.Regions,
+				Audience: sts.Audience,
+			}
+		}
+	case GoogleIDTokenAuthentication:
+		identity.GoogleIDToken = &v1beta1.Identity_GoogleIDToken{
+			Audiences: src.GoogleIDToken.Audiences,
+			Emails:    src.GoogleIDToken.Emails,
+			Domains:   src.GoogleIDToken.Domains,
+		}
+	case GitTokenAuthentication:
+		identity.GitToken = &v1beta1.Identity_GitToken{
+			Provider:  src.GitToken.Provider,
+			Url:       src.GitToken.Url,
+			Audiences: src.GitToken.Audiences,
+			TTL:       src.GitToken.TTL,
+		}
+	case VaultTransitAuthentication:
+		identity.VaultTransit = &v1beta1.Identity_VaultTransit{
+			KeyName:   src.VaultTransit.KeyName,
+			Mount:     src.VaultTransit.Mount,
+			Issuer:    src.VaultTransit.Issuer,
+			Audiences: src.VaultTransit.Audiences,
+		}
 	case PlainIdentityAuthentication:
 		selector := v1beta1.Identity_Plain(v1beta1.ValueFrom{
 			AuthJSON: src.Plain.Selector,
@@ -473,7 +635,8 @@ func convertAuthenticationFrom(src *v1beta1.Identity) (string, AuthenticationSpe
 			Conditions: utils.Map(src.Conditions, convertPatternExpressionOrRefFrom),
 			Cache:      convertEvaluatorCachingFrom(src.Cache),
 		},
-		Credentials: convertCredentialsFrom(src.Credentials),
+		Credentials:      convertCredentialsFrom(src.Credentials),
+		StripCredentials: src.StripCredentials,
 	}
 
 	var overrides []v1beta1.JsonProperty
@@ -500,22 +663,31 @@ func convertAuthenticationFrom(src *v1beta1.Identity) (string, AuthenticationSpe
 
 	switch src.GetType() {
 	case v1beta1.IdentityApiKey:
-		selector := *src.APIKey.Selector
 		authentication.ApiKey = &ApiKeyAuthenticationSpec{
-			Selector:      &selector,
+			Selector:      src.APIKey.Selector.DeepCopy(),
 			AllNamespaces: src.APIKey.AllNamespaces,
+			VaultPath:     src.APIKey.VaultPath,
 		}
 	case v1beta1.IdentityOidc:
 		authentication.Jwt = &JwtAuthenticationSpec{
 			IssuerUrl: src.Oidc.Endpoint,
+			Tenants:   src.Oidc.Tenants,
 			TTL:       src.Oidc.TTL,
 		}
+		if ref := src.Oidc.IdentityProviderRef; ref != nil {
+			authentication.Jwt.IdentityProviderRef = &IdentityProviderReference{Name: ref.Name}
+		}
 	case v1beta1.IdentityOAuth2:
-		credentials := *src.OAuth2.Credentials
 		authentication.OAuth2TokenIntrospection = &OAuth2TokenIntrospectionSpec{
 			Url:           src.OAuth2.TokenIntrospectionUrl,
 			TokenTypeHint: src.OAuth2.TokenTypeHint,
-			Credentials:   &credentials,
+		}
+		if src.OAuth2.Credentials != nil {
+			credentials := *src.OAuth2.Credentials
+			authentication.OAuth2TokenIntrospection.Credentials = &credentials
+		}
+		if ref := src.OAuth2.IdentityProviderRef; ref != nil {
+			authentication.OAuth2TokenIntrospection.IdentityProviderRef = &IdentityProviderReference{Name: ref.Name}
 		}
 	case v1beta1.IdentityKubernetesAuth:
 		authentication.KubernetesTokenReview = &KubernetesTokenReviewSpec{
@@ -527,6 +699,50 @@ func convertAuthenticationFrom(src *v1beta1.Identity) (string, AuthenticationSpe
 			Selector:      &selector,
 			AllNamespaces: src.MTLS.AllNamespaces,
 		}
+	case v1beta1.IdentitySpiffe:
+		authentication.Spiffe = &SpiffeAuthenticationSpec{
+			TrustDomain:       src.Spiffe.TrustDomain,
+			BundleEndpointUrl: src.Spiffe.BundleEndpointUrl,
+			TTL:               src.Spiffe.TTL,
+			Audiences:         src.Spiffe.Audiences,
+		}
+	case v1beta1.IdentityAwsSigV4:
+		authentication.AwsSigV4 = &AwsSigV4AuthenticationSpec{
+			AllNamespaces:        src.AwsSigV4.AllNamespaces,
+			Region:               src.AwsSigV4.Region,
+			Service:              src.AwsSigV4.Service,
+			AllowUnsignedPayload: src.AwsSigV4.AllowUnsignedPayload,
+		}
+		if src.AwsSigV4.Selector != nil {
+			selector := *src.AwsSigV4.Selector
+			authentication.AwsSigV4.Selector = &selector
+		}
+		if sts := src.AwsSigV4.Sts; sts != nil {
+			authentication.AwsSigV4.Sts = &AwsStsSpec{
+				Regions:  sts.Regions,
+				Audience: sts.Audience,
+			}
+		}
+	case v1beta1.IdentityGoogleIDToken:
+		authentication.GoogleIDToken = &GoogleIDTokenAuthenticationSpec{
+			Audiences: src.GoogleIDToken.Audiences,
+			Emails:    src.GoogleIDToken.Emails,
+			Domains:   src.GoogleIDToken.Domains,
+		}
+	case v1beta1.IdentityGitToken:
+		authentication.GitToken = &GitTokenAuthenticationSpec{
+			Provider:  src.GitToken.Provider,
+			Url:       src.GitToken.Url,
+			Audiences: src.GitToken.Audiences,
+			TTL:       src.GitToken.TTL,
+		}
+	case v1beta1.IdentityVaultTransit:
+		authentication.VaultTransit = &VaultTransitAuthenticationSpec{
+			KeyName:   src.VaultTransit.KeyName,
+			Mount:     src.VaultTransit.Mount,
+			Issuer:    src.VaultTransit.Issuer,
+			Audiences: src.VaultTransit.Audiences,
+		}
 	case v1beta1.IdentityPlain:
 		authentication.Plain = &PlainIdentitySpec{
 			Selector: src.Plain.AuthJSON,
@@ -707,13 +923,36 @@ func convertSecretKeyReferenceFrom(src *v1beta1.SecretKeyReference) *SecretKeyRe
 	}
 }
 
+func convertConfigMapKeyReferenceTo(src *ConfigMapKeyReference) *v1beta1.ConfigMapKeyReference {
+	if src == nil {
+		return nil
+	}
+	return &v1beta1.ConfigMapKeyReference{
+		Name: src.Name,
+		Key:  src.Key,
+	}
+}
+
+func convertConfigMapKeyReferenceFrom(src *v1beta1.ConfigMapKeyReference) *ConfigMapKeyReference {
+	if src == nil {
+		return nil
+	}
+	return &ConfigMapKeyReference{
+		Name: src.Name,
+		Key:  src.Key,
+	}
+}
+
 func convertAuthorizationTo(name string, src AuthorizationSpec) *v1beta1.Authorization {
 	authorization := &v1beta1.Authorization{
-		Name:       name,
-		Priority:   src.Priority,
-		Metrics:    src.Metrics,
-		Conditions: utils.Map(src.Conditions, convertPatternExpressionOrRefTo),
-		Cache:      convertEvaluatorCachingTo(src.Cache),
+		Name:            name,
+		Priority:        src.Priority,
+		Metrics:         src.Metrics,
+		Conditions:      utils.Map(src.Conditions, convertPatternExpressionOrRefTo),
+		Cache:           convertEvaluatorCachingTo(src.Cache),
+		Weight:          src.Weight,
+		EnforcementMode: string(src.EnforcementMode),
+		Exports:         convertNamedValuesOrSelectorsTo(src.Exports),
 	}
 
 	switch src.GetMethod() {
@@ -744,6 +983,36 @@ func convertAuthorizationTo(name string, src AuthorizationSpec) *v1beta1.Authori
 			Resource:     spiceDBObjectTo(src.SpiceDB.Resource),
 			Permission:   convertValueOrSelectorTo(src.SpiceDB.Permission),
 		}
+	case QuotaAuthorization:
+		authorization.Quota = &v1beta1.Authorization_Quota{
+			Key:    convertValueOrSelectorTo(src.Quota.Key),
+			Limit:  src.Quota.Limit,
+			Window: src.Quota.Window,
+			Redis:  convertQuotaRedisStorageTo(src.Quota.Redis),
+		}
+	case RbacAuthorization:
+		authorization.RBAC = &v1beta1.Authorization_RBAC{
+			RolesSelector: src.Rbac.RolesSelector,
+			Rules:         utils.Map(src.Rbac.Rules, convertRbacRuleTo),
+			ConfigMap:     convertRbacConfigMapReferenceTo(src.Rbac.ConfigMap),
+		}
+	case RequiredScopesAuthorization:
+		authorization.RequiredScopes = &v1beta1.Authorization_RequiredScopes{
+			Selector: src.RequiredScopes.Selector,
+			AllOf:    src.RequiredScopes.AllOf,
+			AnyOf:    src.RequiredScopes.AnyOf,
+		}
+	case WasmAuthorization:
+		authorization.Wasm = &v1beta1.Authorization_Wasm{
+			Image:     src.Wasm.Image,
+			ConfigMap: convertConfigMapKeyReferenceTo(src.Wasm.ConfigMap),
+			MaxFuel:   src.Wasm.MaxFuel,
+		}
+	case LuaAuthorization:
+		authorization.Lua = &v1beta1.Authorization_Lua{
+			Script:  src.Lua.Script,
+			Timeout: src.Lua.Timeout,
+		}
 	}
 
 	return authorization
@@ -757,6 +1026,9 @@ func convertAuthorizationFrom(src *v1beta1.Authorization) (string, Authorization
 			Conditions: utils.Map(src.Conditions, convertPatternExpressionOrRefFrom),
 			Cache:      convertEvaluatorCachingFrom(src.Cache),
 		},
+		Weight:          src.Weight,
+		EnforcementMode: EnforcementMode(src.EnforcementMode),
+		Exports:         convertNamedValuesOrSelectorsFrom(src.Exports),
 	}
 
 	switch src.GetType() {
@@ -785,6 +1057,36 @@ func convertAuthorizationFrom(src *v1beta1.Authorization) (string, Authorization
 			Resource:     spiceDBObjectFrom(src.Authzed.Resource),
 			Permission:   convertValueOrSelectorFrom(src.Authzed.Permission),
 		}
+	case v1beta1.AuthorizationQuota:
+		authorization.Quota = &QuotaAuthorizationSpec{
+			Key:    convertValueOrSelectorFrom(src.Quota.Key),
+			Limit:  src.Quota.Limit,
+			Window: src.Quota.Window,
+			Redis:  convertQuotaRedisStorageFrom(src.Quota.Redis),
+		}
+	case v1beta1.AuthorizationRBAC:
+		authorization.Rbac = &RbacAuthorizationSpec{
+			RolesSelector: src.RBAC.RolesSelector,
+			Rules:         utils.Map(src.RBAC.Rules, convertRbacRuleFrom),
+			ConfigMap:     convertRbacConfigMapReferenceFrom(src.RBAC.ConfigMap),
+		}
+	case v1beta1.AuthorizationRequiredScopes:
+		authorization.RequiredScopes = &RequiredScopesAuthorizationSpec{
+			Selector: src.RequiredScopes.Selector,
+			AllOf:    src.RequiredScopes.AllOf,
+			AnyOf:    src.RequiredScopes.AnyOf,
+		}
+	case v1beta1.AuthorizationWasm:
+		authorization.Wasm = &WasmAuthorizationSpec{
+			Image:     src.Wasm.Image,
+			ConfigMap: convertConfigMapKeyReferenceFrom(src.Wasm.ConfigMap),
+			MaxFuel:   src.Wasm.MaxFuel,
+		}
+	case v1beta1.AuthorizationLua:
+		authorization.Lua = &LuaAuthorizationSpec{
+			Script:  src.Lua.Script,
+			Timeout: src.Lua.Timeout,
+		}
 	}
 
 	return src.Name, authorization
@@ -864,6 +1166,66 @@ func spiceDBObjectFrom(src *v1beta1.AuthzedObject) *SpiceDBObject {
 	}
 }
 
+func convertQuotaRedisStorageTo(src *QuotaRedisStorageSpec) *v1beta1.Authorization_Quota_Redis {
+	if src == nil {
+		return nil
+	}
+	return &v1beta1.Authorization_Quota_Redis{
+		Address:  src.Address,
+		Password: convertSecretKeyReferenceTo(src.Password),
+		DB:       src.DB,
+	}
+}
+
+func convertQuotaRedisStorageFrom(src *v1beta1.Authorization_Quota_Redis) *QuotaRedisStorageSpec {
+	if src == nil {
+		return nil
+	}
+	return &QuotaRedisStorageSpec{
+		Address:  src.Address,
+		Password: convertSecretKeyReferenceFrom(src.Password),
+		DB:       src.DB,
+	}
+}
+
+func convertRbacRuleTo(src RbacRule) v1beta1.Authorization_RBAC_Rule {
+	return v1beta1.Authorization_RBAC_Rule{
+		Roles:   src.Roles,
+		Methods: src.Methods,
+		Paths:   src.Paths,
+	}
+}
+
+func convertRbacRuleFrom(src v1beta1.Authorization_RBAC_Rule) RbacRule {
+	return RbacRule{
+		Roles:   src.Roles,
+		Methods: src.Methods,
+		Paths:   src.Paths,
+	}
+}
+
+func convertRbacConfigMapReferenceTo(src *RbacConfigMapReference) *v1beta1.Authorization_RBAC_ConfigMapRef {
+	if src == nil {
+		return nil
+	}
+	return &v1beta1.Authorization_RBAC_ConfigMapRef{
+		Name: src.Name,
+		Key:  src.Key,
+		TTL:  src.TTL,
+	}
+}
+
+func convertRbacConfigMapReferenceFrom(src *v1beta1.Authorization_RBAC_ConfigMapRef) *RbacConfigMapReference {
+	if src == nil {
+		return nil
+	}
+	return &RbacConfigMapReference{
+		Name: src.Name,
+		Key:  src.Key,
+		TTL:  src.TTL,
+	}
+}
+
 func convertSuccessResponseTo(name string, src SuccessResponseSpec, wrapper string) *v1beta1.Response {
 	response := &v1beta1.Response{
 		Name:       name,
@@ -885,8 +1247,9 @@ func convertSuccessResponseTo(name string, src SuccessResponseSpec, wrapper stri
 		}
 	case WristbandAuthResponse:
 		response.Wristband = &v1beta1.Response_Wristband{
-			Issuer:       src.Wristband.Issuer,
-			CustomClaims: convertNamedValuesOrSelectorsTo(src.Wristband.CustomClaims),
+			Issuer:                     src.Wristband.Issuer,
+			CustomClaims:               convertNamedValuesOrSelectorsTo(src.Wristband.CustomClaims),
+			SigningKeyRotationInterval: src.Wristband.SigningKeyRotationInterval,
 		}
 		if src.Wristband.TokenDuration != nil {
 			duration := *src.Wristband.TokenDuration
@@ -900,8 +1263,22 @@ func convertSuccessResponseTo(name string, src SuccessResponseSpec, wrapper stri
 				Name:      keySrc.Name,
 				Algorithm: v1beta1.SigningKeyAlgorithm(keySrc.Algorithm),
 			}
+			if keySrc.KMS != nil {
+				key.KMS = &v1beta1.KMSSigningKey{
+					Provider:       v1beta1.KMSProvider(keySrc.KMS.Provider),
+					KeyId:          keySrc.KMS.KeyId,
+					Region:         keySrc.KMS.Region,
+					CredentialsRef: keySrc.KMS.CredentialsRef.DeepCopy(),
+				}
+			}
 			response.Wristband.SigningKeyRefs = append(response.Wristband.SigningKeyRefs, &key)
 		}
+	case SignatureAuthResponse:
+		response.Signature = &v1beta1.Response_Signature{
+			SigningKeyRef: src.Signature.SigningKeyRef,
+			Algorithm:     v1beta1.SigningKeyAlgorithm(src.Signature.Algorithm),
+			Attributes:    src.Signature.Attributes,
+		}
 	}
 
 	return response
@@ -928,8 +1305,9 @@ func convertSuccessResponseFrom(src *v1beta1.Response) (string, SuccessResponseS
 		}
 	case v1beta1.ResponseWristband:
 		response.Wristband = &WristbandAuthResponseSpec{
-			Issuer:       src.Wristband.Issuer,
-			CustomClaims: convertNamedValuesOrSelectorsFrom(src.Wristband.CustomClaims),
+			Issuer:                     src.Wristband.Issuer,
+			CustomClaims:               convertNamedValuesOrSelectorsFrom(src.Wristband.CustomClaims),
+			SigningKeyRotationInterval: src.Wristband.SigningKeyRotationInterval,
 		}
 		if src.Wristband.TokenDuration != nil {
 			duration := *src.Wristband.TokenDuration
@@ -943,35 +1321,130 @@ func convertSuccessResponseFrom(src *v1beta1.Response) (string, SuccessResponseS
 				Name:      keySrc.Name,
 				Algorithm: WristbandSigningKeyAlgorithm(keySrc.Algorithm),
 			}
+			if keySrc.KMS != nil {
+				key.KMS = &WristbandKMSSigningKey{
+					Provider:       KMSProvider(keySrc.KMS.Provider),
+					KeyId:          keySrc.KMS.KeyId,
+					Region:         keySrc.KMS.Region,
+					CredentialsRef: keySrc.KMS.CredentialsRef.DeepCopy(),
+				}
+			}
 			response.Wristband.SigningKeyRefs = append(response.Wristband.SigningKeyRefs, key)
 		}
+	case v1beta1.ResponseSignature:
+		response.Signature = &SignatureAuthResponseSpec{
+			SigningKeyRef: src.Signature.SigningKeyRef,
+			Algorithm:     WristbandSigningKeyAlgorithm(src.Signature.Algorithm),
+			Attributes:    src.Signature.Attributes,
+		}
 	}
 
 	return src.Name, response
 }
 
+func convertHeaderEncryptionTo(src *HeaderEncryptionSpec) *v1beta1.Response_Encryption {
+	if src == nil {
+		return nil
+	}
+	return &v1beta1.Response_Encryption{
+		RecipientKeyRef:   *convertSecretKeyReferenceTo(&src.RecipientKeyRef),
+		Algorithm:         string(src.Algorithm),
+		ContentEncryption: string(src.ContentEncryption),
+	}
+}
+
+func convertHeaderEncryptionFrom(src *v1beta1.Response_Encryption) *HeaderEncryptionSpec {
+	if src == nil {
+		return nil
+	}
+	return &HeaderEncryptionSpec{
+		RecipientKeyRef:   *convertSecretKeyReferenceFrom(&src.RecipientKeyRef),
+		Algorithm:         JweKeyAlgorithm(src.Algorithm),
+		ContentEncryption: JweContentEncryption(src.ContentEncryption),
+	}
+}
+
+func convertHeaderCompressionTo(src *HeaderCompressionSpec) *v1beta1.Response_Compression {
+	if src == nil {
+		return nil
+	}
+	return &v1beta1.Response_Compression{
+		Threshold: src.Threshold,
+	}
+}
+
+func convertHeaderCompressionFrom(src *v1beta1.Response_Compression) *HeaderCompressionSpec {
+	if src == nil {
+		return nil
+	}
+	return &HeaderCompressionSpec{
+		Threshold: src.Threshold,
+	}
+}
+
+func convertCookieAttributesTo(src CookieAttributes) *v1beta1.Response_Cookie {
+	return &v1beta1.Response_Cookie{
+		Path:     src.Path,
+		Domain:   src.Domain,
+		MaxAge:   src.MaxAge,
+		Secure:   src.Secure,
+		HttpOnly: src.HttpOnly,
+		SameSite: string(src.SameSite),
+	}
+}
+
+func convertCookieAttributesFrom(src *v1beta1.Response_Cookie) CookieAttributes {
+	if src == nil {
+		return CookieAttributes{}
+	}
+	return CookieAttributes{
+		Path:     src.Path,
+		Domain:   src.Domain,
+		MaxAge:   src.MaxAge,
+		Secure:   src.Secure,
+		HttpOnly: src.HttpOnly,
+		SameSite: CookieSameSite(src.SameSite),
+	}
+}
+
 func convertDenyWithSpecTo(src *DenyWithSpec) *v1beta1.DenyWithSpec {
 	if src == nil {
 		return nil
 	}
-	return &v1beta1.DenyWithSpec{
-		Code:    v1beta1.DenyWith_Code(src.Code),
-		Headers: convertNamedValuesOrSelectorsTo(src.Headers),
-		Message: convertPtrValueOrSelectorTo(src.Message),
-		Body:    convertPtrValueOrSelectorTo(src.Body),
+	denyWith := &v1beta1.DenyWithSpec{
+		Code:       v1beta1.DenyWith_Code(src.Code),
+		Headers:    convertNamedValuesOrSelectorsTo(src.Headers),
+		Message:    convertPtrValueOrSelectorTo(src.Message),
+		Body:       convertPtrValueOrSelectorTo(src.Body),
+		RedirectTo: convertPtrValueOrSelectorTo(src.RedirectTo),
+	}
+	if src.ProblemDetails != nil {
+		denyWith.ProblemDetails = &v1beta1.DenyWith_ProblemDetails{
+			Type:  src.ProblemDetails.Type,
+			Title: src.ProblemDetails.Title,
+		}
 	}
+	return denyWith
 }
 
 func convertDenyWithSpecFrom(src *v1beta1.DenyWithSpec) *DenyWithSpec {
 	if src == nil {
 		return nil
 	}
-	return &DenyWithSpec{
-		Code:    DenyWithCode(src.Code),
-		Headers: convertNamedValuesOrSelectorsFrom(src.Headers),
-		Message: convertPtrValueOrSelectorFrom(src.Message),
-		Body:    convertPtrValueOrSelectorFrom(src.Body),
+	denyWith := &DenyWithSpec{
+		Code:       DenyWithCode(src.Code),
+		Headers:    convertNamedValuesOrSelectorsFrom(src.Headers),
+		Message:    convertPtrValueOrSelectorFrom(src.Message),
+		Body:       convertPtrValueOrSelectorFrom(src.Body),
+		RedirectTo: convertPtrValueOrSelectorFrom(src.RedirectTo),
+	}
+	if src.ProblemDetails != nil {
+		denyWith.ProblemDetails = &ProblemDetailsSpec{
+			Type:  src.ProblemDetails.Type,
+			Title: src.ProblemDetails.Title,
+		}
 	}
+	return denyWith
 }
 
 func convertCallbackTo(name string, src CallbackSpec) *v1beta1.Callback {
@@ -980,11 +1453,26 @@ func convertCallbackTo(name string, src CallbackSpec) *v1beta1.Callback {
 		Priority:   src.Priority,
 		Metrics:    src.Metrics,
 		Conditions: utils.Map(src.Conditions, convertPatternExpressionOrRefTo),
+		Timeout:    src.Timeout,
+		On:         utils.Map(src.On, func(event CallbackEvent) v1beta1.CallbackEvent { return v1beta1.CallbackEvent(event) }),
 	}
 
 	switch src.GetMethod() {
 	case HttpCallback:
 		callback.HTTP = convertHttpEndpointSpecTo(src.Http)
+	case KafkaCallback:
+		callback.Kafka = &v1beta1.Callback_Kafka{
+			Brokers:       src.Kafka.Brokers,
+			Topic:         src.Kafka.Topic,
+			Serialization: string(src.Kafka.Serialization),
+			Subject:       convertPtrValueOrSelectorTo(src.Kafka.Subject),
+		}
+		if src.Kafka.CloudEvents != nil {
+			callback.Kafka.CloudEvents = &v1beta1.Callback_CloudEvents{
+				Type:   src.Kafka.CloudEvents.Type,
+				Source: src.Kafka.CloudEvents.Source,
+			}
+		}
 	}
 
 	return callback
@@ -997,11 +1485,26 @@ func convertCallbackFrom(src *v1beta1.Callback) (string, CallbackSpec) {
 			Metrics:    src.Metrics,
 			Conditions: utils.Map(src.Conditions, convertPatternExpressionOrRefFrom),
 		},
+		Timeout: src.Timeout,
+		On:      utils.Map(src.On, func(event v1beta1.CallbackEvent) CallbackEvent { return CallbackEvent(event) }),
 	}
 
 	switch src.GetType() {
 	case v1beta1.CallbackHTTP:
 		callback.Http = convertHttpEndpointSpecFrom(src.HTTP)
+	case v1beta1.CallbackKafka:
+		callback.Kafka = &KafkaCallbackSpec{
+			Brokers:       src.Kafka.Brokers,
+			Topic:         src.Kafka.Topic,
+			Serialization: KafkaSerialization(src.Kafka.Serialization),
+			Subject:       convertPtrValueOrSelectorFrom(src.Kafka.Subject),
+		}
+		if src.Kafka.CloudEvents != nil {
+			callback.Kafka.CloudEvents = &CloudEventsSpec{
+				Type:   src.Kafka.CloudEvents.Type,
+				Source: src.Kafka.CloudEvents.Source,
+			}
+		}
 	}
 
 	return src.Name, callback
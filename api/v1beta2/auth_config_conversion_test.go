@@ -23,6 +23,11 @@ func TestConvertTo(t *testing.T) {
 	sort.Slice(converted.Spec.Authorization, func(i, j int) bool {
 		return converted.Spec.Authorization[i].Name < converted.Spec.Authorization[j].Name
 	})
+	for idx := range converted.Spec.Authorization {
+		sort.Slice(converted.Spec.Authorization[idx].Exports, func(i, j int) bool {
+			return converted.Spec.Authorization[idx].Exports[i].Name < converted.Spec.Authorization[idx].Exports[j].Name
+		})
+	}
 	sort.Slice(converted.Spec.Response, func(i, j int) bool {
 		return converted.Spec.Response[i].Name < converted.Spec.Response[j].Name
 	})
@@ -99,7 +104,8 @@ func authConfig() *AuthConfig {
 								"admin"
 							]
 						}
-					}
+					},
+					"stripCredentials": true
 				},
 				"fromEnvoy": {
 					"credentials": {
@@ -244,6 +250,81 @@ func authConfig() *AuthConfig {
 						}
 					]
 				},
+				"luaScript": {
+					"lua": {
+						"script": "return input.auth.identity.role == \"admin\"",
+						"timeout": 50
+					}
+				},
+				"perUserQuota": {
+					"quota": {
+						"key": {
+							"selector": "auth.identity.sub"
+						},
+						"limit": 100,
+						"redis": {
+							"address": "redis.authorino.svc.cluster.local:6379",
+							"passwordRef": {
+								"key": "password",
+								"name": "redis"
+							}
+						},
+						"window": 3600
+					}
+				},
+				"rbac": {
+					"exports": {
+						"effectiveRole": {
+							"selector": "auth.authorization.rbac.roles.0"
+						},
+						"realm": {
+							"selector": "auth.identity.iss"
+						}
+					},
+					"rbac": {
+						"configMapRef": {
+							"key": "rules.yaml",
+							"name": "rbac-rules",
+							"ttl": 60
+						},
+						"rolesSelector": "auth.identity.realm_access.roles",
+						"rules": [
+							{
+								"paths": [
+									"/pets/**"
+								],
+								"roles": [
+									"admin"
+								]
+							},
+							{
+								"methods": [
+									"GET"
+								],
+								"paths": [
+									"/pets",
+									"/pets/*"
+								],
+								"roles": [
+									"*"
+								]
+							}
+						]
+					},
+					"weight": 1
+				},
+				"scopes": {
+					"enforcementMode": "dryRun",
+					"requiredScopes": {
+						"allOf": [
+							"pets:read"
+						],
+						"anyOf": [
+							"pets:admin",
+							"pets:write"
+						]
+					}
+				},
 				"simplePatternMatching": {
 					"patternMatching": {
 						"patterns": [
@@ -271,9 +352,45 @@ func authConfig() *AuthConfig {
 						"rego": "now = time.now_ns() / 1000000000\nallow = true\n"
 					},
 					"priority": 20
+				},
+				"wasm": {
+					"wasm": {
+						"configMapRef": {
+							"key": "policy.wasm",
+							"name": "wasm-policies"
+						},
+						"maxFuel": 5000000
+					}
 				}
 			},
+			"authorizationStrategy": "denyOverrides",
+			"priority": 10,
 			"callbacks": {
+				"auditLog": {
+					"kafka": {
+						"brokers": [
+							"kafka.kafka.svc.cluster.local:9092"
+						],
+						"serialization": "protobuf",
+						"subject": {
+							"selector": "auth.identity.username"
+						},
+						"topic": "auth-decisions"
+					}
+				},
+				"decisionEvents": {
+					"kafka": {
+						"brokers": [
+							"kafka.kafka.svc.cluster.local:9092"
+						],
+						"cloudEvents": {
+							"source": "talker-api",
+							"type": "com.example.auth.decision"
+						},
+						"serialization": "json",
+						"topic": "auth-events"
+					}
+				},
 				"telemetry": {
 					"http": {
 						"body": {
@@ -294,7 +411,12 @@ func authConfig() *AuthConfig {
 							"tokenUrl": "https://accounts.company.com/oauth2/v1/token"
 						},
 						"url": "http://telemetry.server"
-					}
+					},
+					"on": [
+						"allow",
+						"deny"
+					],
+					"timeout": 1000
 				}
 			},
 			"hosts": [
@@ -371,6 +493,19 @@ func authConfig() *AuthConfig {
 			},
 			"response": {
 				"success": {
+					"cookies": {
+						"session": {
+							"httpOnly": true,
+							"key": "",
+							"maxAge": 3600,
+							"path": "/",
+							"plain": {
+								"selector": "auth.identity.sub"
+							},
+							"sameSite": "Lax",
+							"secure": true
+						}
+					},
 					"dynamicMetadata": {
 						"username": {
 							"key": "",
@@ -379,6 +514,15 @@ func authConfig() *AuthConfig {
 							}
 						}
 					},
+					"dynamicMetadataNamespace": "authorino",
+					"grpcMetadata": {
+						"x-user-id": {
+							"key": "",
+							"plain": {
+								"selector": "auth.identity.sub"
+							}
+						}
+					},
 					"headers": {
 						"festival-wristband": {
 							"key": "x-wristband-token",
@@ -401,10 +545,21 @@ func authConfig() *AuthConfig {
 										"name": "wristband-signing-key"
 									}
 								],
+								"signingKeyRotationInterval": 86400,
 								"tokenDuration": 300
 							}
 						},
 						"x-auth-data": {
+							"compression": {
+								"threshold": 2048
+							},
+							"encryption": {
+								"contentEncryption": "A256GCM",
+								"recipientKeyRef": {
+									"key": "key.pem",
+									"name": "talker-api-public-key"
+								}
+							},
 							"json": {
 								"properties": {
 									"geo": {
@@ -425,12 +580,59 @@ func authConfig() *AuthConfig {
 							"plain": {
 								"value": "Authorino"
 							}
+						},
+						"x-authorino-signature": {
+							"key": "",
+							"signature": {
+								"algorithm": "HS256",
+								"attributes": [
+									"context.request.http.method",
+									"context.request.http.path",
+									"auth.identity.username"
+								],
+								"signingKeyRef": {
+									"name": "talker-api-signing-secret"
+								}
+							}
+						},
+						"x-forwarded-groups": {
+							"append": true,
+							"key": "X-Forwarded-Groups",
+							"plain": {
+								"selector": "auth.identity.metadata.annotations.groups"
+							}
+						}
+					},
+					"queryParameters": {
+						"tenant": {
+							"key": "tenant_id",
+							"plain": {
+								"selector": "auth.identity.metadata.annotations.tenant"
+							}
+						}
+					},
+					"queryParametersToRemove": [
+						"access_token"
+					],
+					"responseHeaders": {
+						"x-ratelimit-tier": {
+							"key": "X-RateLimit-Tier",
+							"plain": {
+								"selector": "auth.identity.metadata.annotations.tier"
+							}
 						}
 					}
 				},
 				"unauthenticated": {
 					"message": {
 						"value": "Authentication failed"
+					},
+					"problemDetails": {
+						"title": "Unauthenticated",
+						"type": "https://docs.kuadrant.io/problems/unauthenticated"
+					},
+					"redirectTo": {
+						"selector": "https://keycloak.authorino.svc.cluster.local:8080/realms/kuadrant/protocol/openid-connect/auth?client_id=talker-api&response_type=code&redirect_uri=https://{context.request.http.host}/callback&state={context.request.http.id}"
 					}
 				},
 				"unauthorized": {
@@ -582,6 +784,101 @@ func hubAuthConfig() *v1beta1.AuthConfig {
 						}
 					]
 				},
+				{
+					"lua": {
+						"script": "return input.auth.identity.role == \"admin\"",
+						"timeout": 50
+					},
+					"metrics": false,
+					"name": "luaScript",
+					"priority": 0
+				},
+				{
+					"metrics": false,
+					"name": "perUserQuota",
+					"priority": 0,
+					"quota": {
+						"key": {
+							"valueFrom": {
+								"authJSON": "auth.identity.sub"
+							}
+						},
+						"limit": 100,
+						"redis": {
+							"address": "redis.authorino.svc.cluster.local:6379",
+							"passwordRef": {
+								"key": "password",
+								"name": "redis"
+							}
+						},
+						"window": 3600
+					}
+				},
+				{
+					"exports": [
+						{
+							"name": "effectiveRole",
+							"valueFrom": {
+								"authJSON": "auth.authorization.rbac.roles.0"
+							}
+						},
+						{
+							"name": "realm",
+							"valueFrom": {
+								"authJSON": "auth.identity.iss"
+							}
+						}
+					],
+					"metrics": false,
+					"name": "rbac",
+					"priority": 0,
+					"rbac": {
+						"configMapRef": {
+							"key": "rules.yaml",
+							"name": "rbac-rules",
+							"ttl": 60
+						},
+						"rolesSelector": "auth.identity.realm_access.roles",
+						"rules": [
+							{
+								"paths": [
+									"/pets/**"
+								],
+								"roles": [
+									"admin"
+								]
+							},
+							{
+								"methods": [
+									"GET"
+								],
+								"paths": [
+									"/pets",
+									"/pets/*"
+								],
+								"roles": [
+									"*"
+								]
+							}
+						]
+					},
+					"weight": 1
+				},
+				{
+					"enforcementMode": "dryRun",
+					"metrics": false,
+					"name": "scopes",
+					"priority": 0,
+					"requiredScopes": {
+						"allOf": [
+							"pets:read"
+						],
+						"anyOf": [
+							"pets:admin",
+							"pets:write"
+						]
+					}
+				},
 				{
 					"json": {
 						"rules": [
@@ -614,9 +911,56 @@ func hubAuthConfig() *v1beta1.AuthConfig {
 						"inlineRego": "now = time.now_ns() / 1000000000\nallow = true\n"
 					},
 					"priority": 20
+				},
+				{
+					"metrics": false,
+					"name": "wasm",
+					"priority": 0,
+					"wasm": {
+						"configMapRef": {
+							"key": "policy.wasm",
+							"name": "wasm-policies"
+						},
+						"maxFuel": 5000000
+					}
 				}
 			],
+			"authorizationStrategy": "denyOverrides",
+			"priority": 10,
 			"callbacks": [
+				{
+					"kafka": {
+						"brokers": [
+							"kafka.kafka.svc.cluster.local:9092"
+						],
+						"serialization": "protobuf",
+						"subject": {
+							"valueFrom": {
+								"authJSON": "auth.identity.username"
+							}
+						},
+						"topic": "auth-decisions"
+					},
+					"metrics": false,
+					"name": "auditLog",
+					"priority": 0
+				},
+				{
+					"kafka": {
+						"brokers": [
+							"kafka.kafka.svc.cluster.local:9092"
+						],
+						"cloudEvents": {
+							"source": "talker-api",
+							"type": "com.example.auth.decision"
+						},
+						"serialization": "json",
+						"topic": "auth-events"
+					},
+					"metrics": false,
+					"name": "decisionEvents",
+					"priority": 0
+				},
 				{
 					"http": {
 						"body": {
@@ -643,7 +987,12 @@ func hubAuthConfig() *v1beta1.AuthConfig {
 					},
 					"metrics": false,
 					"name": "telemetry",
-					"priority": 0
+					"on": [
+						"allow",
+						"deny"
+					],
+					"priority": 0,
+					"timeout": 1000
 				}
 			],
 			"denyWith": {
@@ -651,6 +1000,15 @@ func hubAuthConfig() *v1beta1.AuthConfig {
 					"message": {
 						"value": "Authentication failed",
 						"valueFrom": {}
+					},
+					"problemDetails": {
+						"title": "Unauthenticated",
+						"type": "https://docs.kuadrant.io/problems/unauthenticated"
+					},
+					"redirectTo": {
+						"valueFrom": {
+							"authJSON": "https://keycloak.authorino.svc.cluster.local:8080/realms/kuadrant/protocol/openid-connect/auth?client_id=talker-api&response_type=code&redirect_uri=https://{context.request.http.host}/callback&state={context.request.http.id}"
+						}
 					}
 				},
 				"unauthorized": {
@@ -676,6 +1034,7 @@ func hubAuthConfig() *v1beta1.AuthConfig {
 					}
 				}
 			},
+			"dynamicMetadataNamespace": "authorino",
 			"hosts": [
 				"talker-api.127.0.0.1.nip.io",
 				"talker-api.default.svc.cluster.local"
@@ -717,7 +1076,8 @@ func hubAuthConfig() *v1beta1.AuthConfig {
 					],
 					"metrics": false,
 					"name": "apiKeyUsers",
-					"priority": 0
+					"priority": 0,
+					"stripCredentials": true
 				},
 				{
 					"credentials": {
@@ -904,6 +1264,9 @@ func hubAuthConfig() *v1beta1.AuthConfig {
 					}
 				]
 			},
+			"queryParametersToRemove": [
+				"access_token"
+			],
 			"response": [
 				{
 					"metrics": false,
@@ -939,9 +1302,41 @@ func hubAuthConfig() *v1beta1.AuthConfig {
 								"name": "wristband-signing-key"
 							}
 						],
+						"signingKeyRotationInterval": 86400,
 						"tokenDuration": 300
 					}
 				},
+				{
+					"cookie": {
+						"httpOnly": true,
+						"maxAge": 3600,
+						"path": "/",
+						"sameSite": "Lax",
+						"secure": true
+					},
+					"metrics": false,
+					"name": "session",
+					"plain": {
+						"valueFrom": {
+							"authJSON": "auth.identity.sub"
+						}
+					},
+					"priority": 0,
+					"wrapper": "setCookie",
+					"wrapperKey": ""
+				},
+				{
+					"metrics": false,
+					"name": "tenant",
+					"plain": {
+						"valueFrom": {
+							"authJSON": "auth.identity.metadata.annotations.tenant"
+						}
+					},
+					"priority": 0,
+					"wrapper": "queryParameter",
+					"wrapperKey": "tenant_id"
+				},
 				{
 					"metrics": false,
 					"name": "username",
@@ -955,6 +1350,16 @@ func hubAuthConfig() *v1beta1.AuthConfig {
 					"wrapperKey": ""
 				},
 				{
+					"compression": {
+						"threshold": 2048
+					},
+					"encryption": {
+						"contentEncryption": "A256GCM",
+						"recipientKeyRef": {
+							"key": "key.pem",
+							"name": "talker-api-public-key"
+						}
+					},
 					"json": {
 						"properties": [
 							{
@@ -993,6 +1398,61 @@ func hubAuthConfig() *v1beta1.AuthConfig {
 					"priority": 0,
 					"wrapper": "httpHeader",
 					"wrapperKey": ""
+				},
+				{
+					"metrics": false,
+					"name": "x-authorino-signature",
+					"priority": 0,
+					"signature": {
+						"algorithm": "HS256",
+						"attributes": [
+							"context.request.http.method",
+							"context.request.http.path",
+							"auth.identity.username"
+						],
+						"signingKeyRef": {
+							"name": "talker-api-signing-secret"
+						}
+					},
+					"wrapper": "httpHeader",
+					"wrapperKey": ""
+				},
+				{
+					"append": true,
+					"metrics": false,
+					"name": "x-forwarded-groups",
+					"plain": {
+						"valueFrom": {
+							"authJSON": "auth.identity.metadata.annotations.groups"
+						}
+					},
+					"priority": 0,
+					"wrapper": "httpHeader",
+					"wrapperKey": "X-Forwarded-Groups"
+				},
+				{
+					"metrics": false,
+					"name": "x-ratelimit-tier",
+					"plain": {
+						"valueFrom": {
+							"authJSON": "auth.identity.metadata.annotations.tier"
+						}
+					},
+					"priority": 0,
+					"wrapper": "httpResponseHeader",
+					"wrapperKey": "X-RateLimit-Tier"
+				},
+				{
+					"metrics": false,
+					"name": "x-user-id",
+					"plain": {
+						"valueFrom": {
+							"authJSON": "auth.identity.sub"
+						}
+					},
+					"priority": 0,
+					"wrapper": "grpcMetadata",
+					"wrapperKey": ""
 				}
 			],
 			"when": [
@@ -0,0 +1,44 @@
+package v1beta2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AuthConfigDefaultsSpec defines the configs merged into the AuthConfigs of a namespace
+type AuthConfigDefaultsSpec struct {
+	// Default authentication configs.
+	// Merged into the AuthConfigs that do not declare an authentication config with the same name.
+	// +optional
+	Authentication map[string]AuthenticationSpec `json:"authentication,omitempty"`
+
+	// Default response items.
+	// The denial status attributes and the success response items are merged into the AuthConfigs that do not declare
+	// them (or success response items with the same name).
+	// +optional
+	Response *ResponseSpec `json:"response,omitempty"`
+}
+
+// AuthConfigDefaults is the schema for Authorino's AuthConfigDefaults API.
+// Defaults merged at reconcile time into every AuthConfig of the namespace, so common configs (e.g. the OIDC
+// authentication of an organization) do not have to be repeated in each AuthConfig.
+// The AuthConfigs prevail over the defaults. When multiple AuthConfigDefaults exist in a namespace, they are merged in
+// the alphabetical order of their names, the first one prevailing.
+// +kubebuilder:object:root=true
+type AuthConfigDefaults struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec AuthConfigDefaultsSpec `json:"spec,omitempty"`
+}
+
+// AuthConfigDefaultsList contains a list of AuthConfigDefaults
+// +kubebuilder:object:root=true
+type AuthConfigDefaultsList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AuthConfigDefaults `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AuthConfigDefaults{}, &AuthConfigDefaultsList{})
+}
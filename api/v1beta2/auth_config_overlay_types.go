@@ -0,0 +1,69 @@
+package v1beta2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+)
+
+type OverlayPatchType string
+
+const (
+	// JSON merge patch (RFC 7386)
+	MergeOverlayPatchType OverlayPatchType = "merge"
+	// JSON patch (RFC 6902)
+	JSONOverlayPatchType OverlayPatchType = "json"
+)
+
+// AuthConfigOverlaySpec defines a patch to be applied to the spec of a set of AuthConfigs
+type AuthConfigOverlaySpec struct {
+	// Label selector of the AuthConfigs the overlay applies to.
+	// If omitted, the overlay applies to all AuthConfigs within the selected namespaces.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+
+	// Namespaces of the AuthConfigs the overlay applies to.
+	// If omitted, the overlay applies to AuthConfigs of any namespace.
+	// +optional
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// Type of the patch.
+	// Use "merge" for a JSON merge patch (RFC 7386) and "json" for a JSON patch (RFC 6902).
+	// +kubebuilder:validation:Enum:=merge;json
+	// +kubebuilder:default:=merge
+	// +optional
+	PatchType OverlayPatchType `json:"patchType,omitempty"`
+
+	// Patch to the spec of the AuthConfigs, expressed in the authorino.kuadrant.io/v1beta2 format.
+	// E.g. a JSON merge patch {"response":{"success":{"headers":{"x-audit":{"plain":{"value":"on"}}}}}} or a JSON patch
+	// [{"op":"add","path":"/callbacks/audit","value":{"http":{"url":"http://audit"}}}].
+	// Overlays cannot change the hosts of the AuthConfigs.
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Patch k8sruntime.RawExtension `json:"patch"`
+}
+
+// AuthConfigOverlay is the schema for Authorino's AuthConfigOverlay API.
+// Patch applied at reconcile time to the specs of the AuthConfigs it selects, so baseline evaluators can be enforced on
+// top of the AuthConfigs created by the owners of the applications.
+// When multiple overlays apply to an AuthConfig, they are applied in the alphabetical order of their names.
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Patch type",type=string,JSONPath=`.spec.patchType`,description="Type of the patch"
+type AuthConfigOverlay struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec AuthConfigOverlaySpec `json:"spec,omitempty"`
+}
+
+// AuthConfigOverlayList contains a list of AuthConfigOverlay
+// +kubebuilder:object:root=true
+type AuthConfigOverlayList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AuthConfigOverlay `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AuthConfigOverlay{}, &AuthConfigOverlayList{})
+}
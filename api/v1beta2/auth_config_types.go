@@ -30,6 +30,11 @@ const (
 	OAuth2TokenIntrospectionAuthentication
 	KubernetesTokenReviewAuthentication
 	X509ClientCertificateAuthentication
+	SpiffeAuthentication
+	AwsSigV4Authentication
+	GoogleIDTokenAuthentication
+	GitTokenAuthentication
+	VaultTransitAuthentication
 	PlainIdentityAuthentication
 	AnonymousAccessAuthentication
 
@@ -45,16 +50,28 @@ const (
 	OpaAuthorization
 	KubernetesSubjectAccessReviewAuthorization
 	SpiceDBAuthorization
+	QuotaAuthorization
+	RbacAuthorization
+	RequiredScopesAuthorization
+	WasmAuthorization
+	LuaAuthorization
 
 	// The following constants are used to identify the different methods of auth response.
 	UnknownAuthResponseMethod AuthResponseMethod = iota
 	PlainAuthResponse
 	JsonAuthResponse
 	WristbandAuthResponse
+	SignatureAuthResponse
 
 	// The following constants are used to identify the different methods of callback functions.
 	UnknownCallbackMethod CallbackMethod = iota
 	HttpCallback
+	KafkaCallback
+
+	// The following constants are used to identify the outcomes of the auth pipeline that can trigger callbacks.
+	AllowCallbackEvent CallbackEvent = "allow"
+	DenyCallbackEvent  CallbackEvent = "deny"
+	ErrorCallbackEvent CallbackEvent = "error"
 
 	// The following constants are used to identify the different types of credentials.
 	UnknownCredentialsType CredentialsType = iota
@@ -62,19 +79,36 @@ const (
 	CustomHeaderCredentials
 	QueryStringCredentials
 	CookieCredentials
+	WebSocketProtocolCredentials
+	GRPCMetadataCredentials
+
+	// The following constants are used to identify the different strategies to combine the verdicts of the authorization policies.
+	AllOfAuthorizationStrategy           AuthorizationStrategy = "allOf"
+	AnyOfAuthorizationStrategy           AuthorizationStrategy = "anyOf"
+	DenyOverridesAuthorizationStrategy   AuthorizationStrategy = "denyOverrides"
+	PermitOverridesAuthorizationStrategy AuthorizationStrategy = "permitOverrides"
+
+	// The following constants are used to identify the different enforcement modes of the authorization policies.
+	EnforceEnforcementMode EnforcementMode = "enforce"
+	DryRunEnforcementMode  EnforcementMode = "dryRun"
 
 	// Status conditions
-	StatusConditionAvailable StatusConditionType = "Available"
-	StatusConditionReady     StatusConditionType = "Ready"
+	StatusConditionAvailable           StatusConditionType = "Available"
+	StatusConditionReady               StatusConditionType = "Ready"
+	StatusConditionDependenciesHealthy StatusConditionType = "DependenciesHealthy"
 
 	// Status reasons
-	StatusReasonReconciling     string = "Reconciling"
-	StatusReasonReconciled      string = "Reconciled"
-	StatusReasonInvalidResource string = "Invalid"
-	StatusReasonHostsLinked     string = "HostsLinked"
-	StatusReasonHostsNotLinked  string = "HostsNotLinked"
-	StatusReasonCachingError    string = "CachingError"
-	StatusReasonUnknown         string = "Unknown"
+	StatusReasonReconciling             string = "Reconciling"
+	StatusReasonReconciled              string = "Reconciled"
+	StatusReasonInvalidResource         string = "Invalid"
+	StatusReasonHostsLinked             string = "HostsLinked"
+	StatusReasonHostsNotLinked          string = "HostsNotLinked"
+	StatusReasonCachingError            string = "CachingError"
+	StatusReasonPolicyTestsFailed       string = "PolicyTestsFailed"
+	StatusReasonPolicyCompilationFailed string = "PolicyCompilationFailed"
+	StatusReasonDependenciesHealthy     string = "DependenciesHealthy"
+	StatusReasonDependenciesUnhealthy   string = "DependenciesUnhealthy"
+	StatusReasonUnknown                 string = "Unknown"
 
 	EvaluatorDefaultCacheTTL = 60
 )
@@ -88,6 +122,15 @@ type CredentialsType int8
 
 type StatusConditionType string
 
+// +kubebuilder:validation:Enum:=allOf;anyOf;denyOverrides;permitOverrides
+type AuthorizationStrategy string
+
+// +kubebuilder:validation:Enum:=enforce;dryRun
+type EnforcementMode string
+
+// +kubebuilder:validation:Enum:=allow;deny;error
+type CallbackEvent string
+
 // AuthConfig is the schema for Authorino's AuthConfig API
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
@@ -112,6 +155,27 @@ type AuthConfigSpec struct {
 	// Authorino uses the requested host to lookup for the corresponding authentication/authorization configs to enforce.
 	Hosts []string `json:"hosts"`
 
+	// Precedence of the AuthConfig over others that claim the same host names (or host names covered by the same wildcard).
+	// When two AuthConfigs collide, the one with the highest priority is linked to the host; AuthConfigs with the same
+	// priority keep the one linked first.
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:default:=0
+	// +optional
+	Priority int `json:"priority,omitempty"`
+
+	// Whether to generate individual observability metrics for all the configs of the AuthConfig, regardless of the
+	// `metrics` field of each config.
+	// +optional
+	// +kubebuilder:default:=false
+	Metrics bool `json:"metrics,omitempty"`
+
+	// Log level of the auth pipelines evaluated with the AuthConfig, overriding the log level of the Authorino instance,
+	// so verbose logs can be enabled for the hosts of the AuthConfig only.
+	// Omit it to apply the log level of the Authorino instance.
+	// +optional
+	// +kubebuilder:validation:Enum:=debug;info;error
+	LogLevel string `json:"logLevel,omitempty"`
+
 	// Named sets of patterns that can be referred in `when` conditions and in pattern-matching authorization policy rules.
 	// +optional
 	NamedPatterns map[string]PatternExpressions `json:"patterns,omitempty"`
@@ -133,10 +197,17 @@ type AuthConfigSpec struct {
 	Metadata map[string]MetadataSpec `json:"metadata,omitempty"`
 
 	// Authorization policies.
-	// All policies MUST evaluate to "allowed = true" for the auth request be successful.
+	// By default, all policies MUST evaluate to "allowed = true" for the auth request be successful.
 	// +optional
 	Authorization map[string]AuthorizationSpec `json:"authorization,omitempty"`
 
+	// Strategy to combine the verdicts of the authorization policies.
+	// Possible values are: "allOf" (default; all policies must allow the request), "anyOf" (at least one policy must allow the request),
+	// "denyOverrides" (the verdicts of the policies with the highest weight prevail; a single denial among them denies the request)
+	// and "permitOverrides" (the verdicts of the policies with the highest weight prevail; a single permission among them allows the request).
+	// +optional
+	AuthorizationStrategy AuthorizationStrategy `json:"authorizationStrategy,omitempty"`
+
 	// Response items.
 	// Authorino builds custom responses to the client of the auth request.
 	// +optional
@@ -156,14 +227,14 @@ type PatternExpression struct {
 	// Authorino custom JSON path modifiers are also supported.
 	Selector string `json:"selector,omitempty"`
 	// The binary operator to be applied to the content fetched from the authorization JSON, for comparison with "value".
-	// Possible values are: "eq" (equal to), "neq" (not equal to), "incl" (includes; for arrays), "excl" (excludes; for arrays), "matches" (regex)
+	// Possible values are: "eq" (equal to), "neq" (not equal to), "incl" (includes; for arrays), "excl" (excludes; for arrays), "matches" (regex), "rar_type" (includes an authorization detail of the type), "rar_action" (includes an authorization detail that grants the action, optionally prefixed with the type and ":")
 	Operator PatternExpressionOperator `json:"operator,omitempty"`
 	// The value of reference for the comparison with the content fetched from the authorization JSON.
 	// If used with the "matches" operator, the value must compile to a valid Golang regex.
 	Value string `json:"value,omitempty"`
 }
 
-// +kubebuilder:validation:Enum:=eq;neq;incl;excl;matches
+// +kubebuilder:validation:Enum:=eq;neq;incl;excl;matches;rar_type;rar_action
 type PatternExpressionOperator string
 
 type PatternExpressionOrRef struct {
@@ -243,6 +314,12 @@ type AuthenticationSpec struct {
 	// +optional
 	Credentials Credentials `json:"credentials,omitempty"`
 
+	// Removes the credentials from the request before forwarding it upstream, when the identity is successfully verified by this config.
+	// Only credentials passed in the HTTP Authorization header, in a custom header, in a gRPC metadata key or in a WebSocket subprotocol can be stripped out.
+	// +optional
+	// +kubebuilder:default:=false
+	StripCredentials bool `json:"stripCredentials,omitempty"`
+
 	// Overrides the resolved identity object by setting the additional properties (claims) specified in this config,
 	// before appending the object to the authorization JSON.
 	// It requires the resolved identity object to always be a JSON object.
@@ -269,6 +346,16 @@ func (s *AuthenticationSpec) GetMethod() AuthenticationMethod {
 		return OAuth2TokenIntrospectionAuthentication
 	} else if s.X509ClientCertificate != nil {
 		return X509ClientCertificateAuthentication
+	} else if s.Spiffe != nil {
+		return SpiffeAuthentication
+	} else if s.AwsSigV4 != nil {
+		return AwsSigV4Authentication
+	} else if s.GoogleIDToken != nil {
+		return GoogleIDTokenAuthentication
+	} else if s.GitToken != nil {
+		return GitTokenAuthentication
+	} else if s.VaultTransit != nil {
+		return VaultTransitAuthentication
 	} else if s.KubernetesTokenReview != nil {
 		return KubernetesTokenReviewAuthentication
 	} else if s.Plain != nil {
@@ -284,6 +371,8 @@ type Credentials struct {
 	CustomHeader        *CustomHeader `json:"customHeader,omitempty"`
 	QueryString         *Named        `json:"queryString,omitempty"`
 	Cookie              *Named        `json:"cookie,omitempty"`
+	WebSocketProtocol   *Prefixed     `json:"webSocketProtocol,omitempty"`
+	GRPCMetadata        *Named        `json:"grpcMetadata,omitempty"`
 }
 
 func (c *Credentials) GetType() CredentialsType {
@@ -295,6 +384,10 @@ func (c *Credentials) GetType() CredentialsType {
 		return QueryStringCredentials
 	} else if c.Cookie != nil {
 		return CookieCredentials
+	} else if c.WebSocketProtocol != nil {
+		return WebSocketProtocolCredentials
+	} else if c.GRPCMetadata != nil {
+		return GRPCMetadataCredentials
 	}
 	return UnknownCredentialsType
 }
@@ -325,6 +418,24 @@ type AuthenticationMethodSpec struct {
 	// Authentication based on client X.509 certificates.
 	// The certificates presented by the clients must be signed by a trusted CA whose certificates are stored in Kubernetes secrets.
 	X509ClientCertificate *X509ClientCertificateAuthenticationSpec `json:"x509,omitempty"`
+	// Authentication based on SPIFFE Verifiable Identity Documents (SVIDs).
+	// X.509-SVIDs presented by the clients in mTLS connections and JWT-SVIDs supplied as credentials are verified against
+	// the trust bundle of the trust domain, fetched from a SPIFFE bundle endpoint.
+	Spiffe *SpiffeAuthenticationSpec `json:"spiffe,omitempty"`
+	// Authentication of requests signed with AWS Signature Version 4 (SigV4).
+	// The signatures are verified against AWS access keys stored in Kubernetes secrets, or the AWS IAM principals are
+	// resolved by AWS STS out of presigned GetCallerIdentity requests supplied as credentials.
+	AwsSigV4 *AwsSigV4AuthenticationSpec `json:"awsSigV4,omitempty"`
+	// Authentication based on ID tokens signed by Google, such as the ones of Google service accounts invoking Cloud Run
+	// services or pushing Pub/Sub messages.
+	GoogleIDToken *GoogleIDTokenAuthenticationSpec `json:"googleIdToken,omitempty"`
+	// Authentication based on GitHub and GitLab tokens.
+	// Personal access tokens are validated against the API of the provider, and OpenID Connect tokens of CI jobs
+	// (GitHub Actions and GitLab CI/CD ID tokens) are verified against the provider's OIDC issuer.
+	GitToken *GitTokenAuthenticationSpec `json:"gitToken,omitempty"`
+	// Authentication based on JWTs whose signatures are verified by a key of the Transit secrets engine of HashiCorp Vault.
+	// Authorino does not hold the public keys to verify the tokens, but calls the verify endpoint of Vault instead.
+	VaultTransit *VaultTransitAuthenticationSpec `json:"vaultTransit,omitempty"`
 	// Identity object extracted from the context.
 	// Use this method when authentication is performed beforehand by a proxy and the resulting object passed to Authorino as JSON in the auth request.
 	Plain *PlainIdentitySpec `json:"plain,omitempty"`
@@ -335,13 +446,21 @@ type AuthenticationMethodSpec struct {
 // Settings to select the API key Kubernetes secrets.
 type ApiKeyAuthenticationSpec struct {
 	// Label selector used by Authorino to match secrets from the cluster storing valid credentials to authenticate to this service
-	Selector *metav1.LabelSelector `json:"selector"`
+	// Required unless `vaultPath` is set.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
 
 	// Whether Authorino should look for API key secrets in all namespaces or only in the same namespace as the AuthConfig.
 	// Enabling this option in namespaced Authorino instances has no effect.
 	// +optional
 	// +kubebuilder:default:=false
 	AllNamespaces bool `json:"allNamespaces,omitempty"`
+
+	// Path of a secret in HashiCorp Vault storing valid credentials to authenticate to this service, instead of
+	// Kubernetes secrets. Each key of the Vault secret is the name of an API key and the value, the API key.
+	// Requires Authorino to be configured to read secrets from Vault.
+	// +optional
+	VaultPath string `json:"vaultPath,omitempty"`
 }
 
 // Settings to fetch the JSON Web Key Set (JWKS) for the JWT authentication.
@@ -351,19 +470,31 @@ type JwtAuthenticationSpec struct {
 	// (i.e. "/.well-known/openid-configuration") to this URL, to discover the OIDC configuration where to obtain
 	// the "jkws_uri" claim from.
 	// The value must coincide with the value of  the "iss" (issuer) claim of the discovered OpenID Connect configuration.
+	// The URL can be a template with placeholders resolved from the claims of the token, for multi-tenant identity
+	// providers (e.g. "https://login.microsoftonline.com/{tid}/v2.0"). In this case, `tenants` is required.
 	// +optional
 	IssuerUrl string `json:"issuerUrl"`
 
+	// Allowlist of tenants accepted when the `issuerUrl` is a template.
+	// The values resolved from the claims of the token for the placeholders of the template must be listed.
+	// +optional
+	Tenants []string `json:"tenants,omitempty"`
+
 	// Decides how long to wait before refreshing the JWKS (in seconds).
 	// If omitted, Authorino will never refresh the JWKS.
 	// +optional
 	TTL int `json:"ttl,omitempty"`
+
+	// Reference to a cluster-wide IdentityProvider whose issuer URL and TTL to use, instead of `issuerUrl` and `ttl`.
+	// +optional
+	IdentityProviderRef *IdentityProviderReference `json:"identityProviderRef,omitempty"`
 }
 
 // Settings to perform the OAuth2 token introspection request.
 type OAuth2TokenIntrospectionSpec struct {
 	// The full URL of the token introspection endpoint.
-	Url string `json:"endpoint"`
+	// +optional
+	Url string `json:"endpoint,omitempty"`
 
 	// The token type hint for the token introspection.
 	// If omitted, it defaults to "access_token".
@@ -371,7 +502,13 @@ type OAuth2TokenIntrospectionSpec struct {
 	TokenTypeHint string `json:"tokenTypeHint,omitempty"`
 
 	// Reference to a Kubernetes secret in the same namespace, that stores client credentials to the OAuth2 server.
-	Credentials *k8score.LocalObjectReference `json:"credentialsRef"`
+	// +optional
+	Credentials *k8score.LocalObjectReference `json:"credentialsRef,omitempty"`
+
+	// Reference to a cluster-wide IdentityProvider whose token introspection settings to use, instead of `endpoint`,
+	// `tokenTypeHint` and `credentialsRef`.
+	// +optional
+	IdentityProviderRef *IdentityProviderReference `json:"identityProviderRef,omitempty"`
 }
 
 // Parameters of the Kubernetes TokenReview request
@@ -395,6 +532,141 @@ type X509ClientCertificateAuthenticationSpec struct {
 	AllNamespaces bool `json:"allNamespaces,omitempty"`
 }
 
+// Settings to authenticate workloads by SPIFFE Verifiable Identity Documents (SVIDs).
+type SpiffeAuthenticationSpec struct {
+	// The SPIFFE trust domain of the workloads allowed to authenticate (e.g. "example.org").
+	TrustDomain string `json:"trustDomain"`
+
+	// URL of the SPIFFE bundle endpoint (https_web profile) that serves the trust bundle of the trust domain,
+	// e.g. the federation bundle endpoint of the SPIRE server.
+	BundleEndpointUrl string `json:"bundleEndpointUrl"`
+
+	// Decides how long to wait before refreshing the trust bundle (in seconds).
+	// If omitted, Authorino will never refresh the trust bundle.
+	// +optional
+	TTL int `json:"ttl,omitempty"`
+
+	// The list of audiences of which at least one must be claimed in the JWT-SVIDs.
+	// If omitted, Authorino will expect the host name of the requested protected service amongst the audiences.
+	// +optional
+	Audiences []string `json:"audiences,omitempty"`
+}
+
+// Settings to authenticate requests signed with AWS Signature Version 4 (SigV4).
+type AwsSigV4AuthenticationSpec struct {
+	// Label selector used by Authorino to match secrets from the cluster storing the AWS access keys trusted to sign the
+	// requests. The secrets must contain the `aws_access_key_id` and `aws_secret_access_key` entries, and the ARN of the
+	// IAM principal in the `aws_arn` entry.
+	// Required unless `sts` is set.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+
+	// Whether Authorino should look for AWS access key secrets in all namespaces or only in the same namespace as the AuthConfig.
+	// Enabling this option in namespaced Authorino instances has no effect.
+	// +optional
+	// +kubebuilder:default:=false
+	AllNamespaces bool `json:"allNamespaces,omitempty"`
+
+	// AWS region the requests must be signed for (e.g. "us-east-1").
+	// If omitted, requests signed for any region are accepted.
+	// +optional
+	Region string `json:"region,omitempty"`
+
+	// AWS service name the requests must be signed for (e.g. "s3").
+	// If omitted, requests signed for any service are accepted.
+	// +optional
+	Service string `json:"service,omitempty"`
+
+	// Whether to accept requests whose payload is not signed, i.e. with the `x-amz-content-sha256` header set to
+	// `UNSIGNED-PAYLOAD`. Otherwise, the payload hash must match the body of the request.
+	// Presigned URLs are always accepted with unsigned payloads.
+	// +optional
+	// +kubebuilder:default:=false
+	AllowUnsignedPayload bool `json:"allowUnsignedPayload,omitempty"`
+
+	// Settings to resolve the AWS IAM principals by AWS STS GetCallerIdentity, instead of verifying the signatures against
+	// access keys stored in secrets.
+	// +optional
+	Sts *AwsStsSpec `json:"sts,omitempty"`
+}
+
+// Settings to resolve AWS IAM principals by AWS STS GetCallerIdentity.
+// Clients supply a presigned GetCallerIdentity request as credentials, in the format of the tokens of the AWS IAM
+// Authenticator for Kubernetes, i.e. "k8s-aws-v1." followed by the presigned URL encoded in base64url.
+type AwsStsSpec struct {
+	// AWS regions whose regional STS endpoints are accepted in the presigned requests, besides the global endpoint
+	// (sts.amazonaws.com).
+	// +optional
+	Regions []string `json:"regions,omitempty"`
+
+	// Value of the `x-k8s-aws-id` header that must be signed in the presigned requests, binding the tokens to this service.
+	// +optional
+	Audience string `json:"audience,omitempty"`
+}
+
+// Settings to verify ID tokens signed by Google.
+type GoogleIDTokenAuthenticationSpec struct {
+	// The list of audiences of which at least one must be claimed in the ID tokens (e.g. the URL of the Cloud Run
+	// service or the audience of the Pub/Sub push subscription).
+	// +kubebuilder:validation:MinItems:=1
+	Audiences []string `json:"audiences"`
+
+	// Emails of the Google accounts (e.g. service accounts) allowed to authenticate.
+	// If `emails` or `domains` are set, the tokens must include a verified email, which must be listed in `emails` or
+	// belong to one of the `domains`.
+	// +optional
+	Emails []string `json:"emails,omitempty"`
+
+	// Domains of the emails of the Google accounts allowed to authenticate (e.g. "my-project.iam.gserviceaccount.com").
+	// The hosted domain ("hd" claim) of Google Workspace accounts also matches.
+	// +optional
+	Domains []string `json:"domains,omitempty"`
+}
+
+// Settings to validate GitHub and GitLab tokens.
+type GitTokenAuthenticationSpec struct {
+	// The provider of the tokens.
+	// +kubebuilder:validation:Enum:=github;gitlab
+	Provider string `json:"provider"`
+
+	// Base URL of a GitHub Enterprise Server or self-managed GitLab instance (e.g. "https://gitlab.example.com").
+	// If omitted, Authorino will validate the tokens against github.com or gitlab.com.
+	// +optional
+	Url string `json:"url,omitempty"`
+
+	// The list of audiences of which at least one must be claimed in the OpenID Connect tokens of CI jobs.
+	// If omitted, Authorino will expect the host name of the requested protected service amongst the audiences.
+	// +optional
+	Audiences []string `json:"audiences,omitempty"`
+
+	// How long to cache the results of the validation of personal access tokens against the API of the provider
+	// (in seconds).
+	// +optional
+	// +kubebuilder:default:=60
+	TTL int `json:"ttl,omitempty"`
+}
+
+// Settings to verify JWTs with a key of the Transit secrets engine of HashiCorp Vault.
+type VaultTransitAuthenticationSpec struct {
+	// Name of the key of the Transit secrets engine of Vault that verifies the signature of the tokens.
+	KeyName string `json:"keyName"`
+
+	// Path where the Transit secrets engine is mounted in Vault.
+	// +optional
+	// +kubebuilder:default:=transit
+	Mount string `json:"mount,omitempty"`
+
+	// Issuer that must be claimed in the tokens.
+	// If omitted, the issuer of the tokens is not checked.
+	// +optional
+	Issuer string `json:"issuer,omitempty"`
+
+	// The list of audiences of which at least one must be claimed in the tokens.
+	// If omitted, the audience of the tokens is not checked.
+	// +optional
+	Audiences []string `json:"audiences,omitempty"`
+}
+
 // Settings to extract the identity object from the context.
 type PlainIdentitySpec struct {
 	// Simple path selector to fetch content from the authorization JSON (e.g. 'request.method') or a string template with variables that resolve to patterns (e.g. "Hello, {auth.identity.name}!").
@@ -497,6 +769,15 @@ type SecretKeyReference struct {
 	Key string `json:"key"`
 }
 
+// Reference to a Kubernetes configmap
+type ConfigMapKeyReference struct {
+	// The name of the configmap in the namespace of the AuthConfig to select from.
+	Name string `json:"name"`
+
+	// The key of the configmap to select from. Must be a valid configmap key.
+	Key string `json:"key"`
+}
+
 // Settings for OAuth2 client authentication with the external service
 type OAuth2ClientAuthentication struct {
 	// Token endpoint URL of the OAuth2 resource server.
@@ -534,6 +815,22 @@ type UmaMetadataSpec struct {
 type AuthorizationSpec struct {
 	CommonEvaluatorSpec     `json:""`
 	AuthorizationMethodSpec `json:""`
+
+	// Weight of the verdict of the policy when combined with the verdicts of other policies.
+	// Only used with the "denyOverrides" and "permitOverrides" authorization strategies.
+	// +kubebuilder:default:=0
+	Weight int `json:"weight,omitempty"`
+
+	// Enforcement mode of the authorization policy.
+	// In "dryRun" mode, the verdict of the policy is recorded in the metrics and in the authorization JSON, but the policy never denies the request.
+	// +optional
+	EnforcementMode EnforcementMode `json:"enforcementMode,omitempty"`
+
+	// Named values exported into the authorization JSON, under `auth.exports`, when the policy grants access.
+	// Selectors are resolved after the evaluation of the policy, thus they can refer to its result at `auth.authorization.<name>`.
+	// Exported values can be referred by authorization policies evaluated afterwards (i.e. of lower priority) and by the response items.
+	// +optional
+	Exports NamedValuesOrSelectors `json:"exports,omitempty"`
 }
 
 func (s *AuthorizationSpec) GetMethod() AuthorizationMethod {
@@ -545,6 +842,16 @@ func (s *AuthorizationSpec) GetMethod() AuthorizationMethod {
 		return KubernetesSubjectAccessReviewAuthorization
 	} else if s.SpiceDB != nil {
 		return SpiceDBAuthorization
+	} else if s.Quota != nil {
+		return QuotaAuthorization
+	} else if s.Rbac != nil {
+		return RbacAuthorization
+	} else if s.RequiredScopes != nil {
+		return RequiredScopesAuthorization
+	} else if s.Wasm != nil {
+		return WasmAuthorization
+	} else if s.Lua != nil {
+		return LuaAuthorization
 	}
 	return UnknownAuthorizationMethod
 }
@@ -558,6 +865,16 @@ type AuthorizationMethodSpec struct {
 	KubernetesSubjectAccessReview *KubernetesSubjectAccessReviewAuthorizationSpec `json:"kubernetesSubjectAccessReview,omitempty"`
 	// Authorization decision delegated to external Authzed/SpiceDB server.
 	SpiceDB *SpiceDBAuthorizationSpec `json:"spicedb,omitempty"`
+	// Quota of requests enforced by counters kept in memory or in Redis.
+	Quota *QuotaAuthorizationSpec `json:"quota,omitempty"`
+	// Built-in role-based access control (RBAC) mapping roles of the identity to allowed methods and paths.
+	Rbac *RbacAuthorizationSpec `json:"rbac,omitempty"`
+	// Checks the OAuth scopes granted to the token against a set of required scopes.
+	RequiredScopes *RequiredScopesAuthorizationSpec `json:"requiredScopes,omitempty"`
+	// Policy implemented as a WebAssembly module.
+	Wasm *WasmAuthorizationSpec `json:"wasm,omitempty"`
+	// Lua script.
+	Lua *LuaAuthorizationSpec `json:"lua,omitempty"`
 }
 
 type PatternMatchingAuthorizationSpec struct {
@@ -652,6 +969,140 @@ type SpiceDBObject struct {
 	Kind ValueOrSelector `json:"kind,omitempty"`
 }
 
+// Settings of the quota authorization.
+// Requests are counted per key within a sliding window; requests beyond the limit are denied.
+type QuotaAuthorizationSpec struct {
+	// Value or selector of the key that identifies the counter (e.g. the identity of the user or the requested resource).
+	// Each distinct resolved value is counted separately.
+	Key ValueOrSelector `json:"key"`
+
+	// Maximum number of requests allowed for the same key within the window.
+	// +kubebuilder:validation:Minimum:=1
+	Limit int64 `json:"limit"`
+
+	// Length of the sliding window, in seconds.
+	// +kubebuilder:validation:Minimum:=1
+	Window int64 `json:"window"`
+
+	// Settings of the Redis server that stores the counters.
+	// Omit it to keep the counters in memory, local to each Authorino instance.
+	// +optional
+	Redis *QuotaRedisStorageSpec `json:"redis,omitempty"`
+}
+
+// Settings of the Redis storage of quota counters.
+type QuotaRedisStorageSpec struct {
+	// Address of the Redis server (format: <host>:<port>).
+	Address string `json:"address"`
+
+	// Reference to a Secret key whose value will be used by Authorino to authenticate with the Redis server.
+	// +optional
+	Password *SecretKeyReference `json:"passwordRef,omitempty"`
+
+	// Number of the Redis logical database.
+	// +kubebuilder:default:=0
+	DB int `json:"db,omitempty"`
+}
+
+// Settings of the built-in role-based access control (RBAC) authorization.
+// The request is authorized if at least one rule grants access to any of the roles of the identity, for the requested method and path.
+type RbacAuthorizationSpec struct {
+	// Selector of the roles (or groups) of the identity in the authorization JSON.
+	// The selected value can be either an array of strings or a string of space-separated values.
+	// If omitted, it defaults to `auth.identity.roles`.
+	// +optional
+	RolesSelector string `json:"rolesSelector,omitempty"`
+
+	// Role-to-permission mapping rules.
+	// +optional
+	Rules []RbacRule `json:"rules,omitempty"`
+
+	// Reference to a ConfigMap key that stores additional rules, formatted as a YAML or JSON list.
+	// The rules are reloaded periodically, so changes to the ConfigMap take effect without reconciling the AuthConfig.
+	// +optional
+	ConfigMap *RbacConfigMapReference `json:"configMapRef,omitempty"`
+}
+
+type RbacRule struct {
+	// Roles granted the permission. Use '*' for any role.
+	Roles []string `json:"roles"`
+
+	// HTTP methods allowed. Omit it or use '*' for any method.
+	// +optional
+	Methods []string `json:"methods,omitempty"`
+
+	// Path patterns allowed.
+	// Use '*' to match a single path segment and '**', as the last segment of the pattern, to match any number of trailing segments.
+	Paths []string `json:"paths"`
+}
+
+// Reference to a key of a ConfigMap in the same namespace of the AuthConfig.
+type RbacConfigMapReference struct {
+	// The name of the ConfigMap.
+	Name string `json:"name"`
+
+	// The key of the ConfigMap to read the rules from.
+	Key string `json:"key"`
+
+	// Interval (in seconds) to reload the rules from the ConfigMap.
+	// +kubebuilder:default:=60
+	TTL int `json:"ttl,omitempty"`
+}
+
+// Settings of the OAuth scope enforcement.
+// The request is authorized if the token holds all the scopes listed in `allOf` and at least one of the scopes listed in `anyOf`, when provided.
+type RequiredScopesAuthorizationSpec struct {
+	// Selector of the scopes granted to the token in the authorization JSON.
+	// The selected value can be either an array of strings or a string of space-separated values.
+	// If omitted, the `scope` claim of the identity is used, falling back to the `scp` claim.
+	// +optional
+	Selector string `json:"selector,omitempty"`
+
+	// Scopes that must all be granted to the token.
+	// +optional
+	AllOf []string `json:"allOf,omitempty"`
+
+	// Scopes of which at least one must be granted to the token.
+	// +optional
+	AnyOf []string `json:"anyOf,omitempty"`
+}
+
+// Settings of the WebAssembly policy module.
+// The module must export a `memory`, an `alloc(size i32) i32` function and an `evaluate(ptr i32, len i32) i64` function
+// that receives the authorization JSON and returns the pointer (upper 32 bits) and length (lower 32 bits) of the verdict,
+// a JSON object in the format {"allow":bool,"reason":string,"metadata":any}.
+// The module runs sandboxed, with no access to the host.
+type WasmAuthorizationSpec struct {
+	// Reference to an OCI artifact holding the WebAssembly module (e.g. quay.io/org/policy:v1).
+	// Only anonymous pulls are supported. Prefix with 'http://' for registries without TLS.
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Reference to a key of a Kubernetes ConfigMap in the namespace of the AuthConfig storing the WebAssembly module,
+	// preferably in `binaryData`.
+	// +optional
+	ConfigMap *ConfigMapKeyReference `json:"configMapRef,omitempty"`
+
+	// Maximum amount of fuel (roughly, number of instructions) the module can consume per evaluation.
+	// The request is denied if the module runs out of fuel.
+	// +kubebuilder:default:=10000000
+	MaxFuel int64 `json:"maxFuel,omitempty"`
+}
+
+// Settings of the Lua authorization script.
+// The script reads the authorization JSON from the `input` global variable and returns a boolean (`true` to allow the request),
+// optionally followed by a reason (string) when denying or an object (table) to expose in the authorization JSON when allowing.
+// Only the base, string, table and math libraries are available, without the functions to load code or access the host.
+type LuaAuthorizationSpec struct {
+	// Lua script.
+	Script string `json:"script"`
+
+	// Maximum duration of the evaluation of the script per request, in milliseconds.
+	// The request is denied if the script takes longer to finish.
+	// +kubebuilder:default:=100
+	Timeout int `json:"timeout,omitempty"`
+}
+
 // Settings of the custom auth response.
 type ResponseSpec struct {
 	// Customizations on the denial status attributes when the request is unauthenticated.
@@ -689,6 +1140,29 @@ type DenyWithSpec struct {
 
 	// HTTP response body to override the default denial body.
 	Body *ValueOrSelector `json:"body,omitempty"`
+
+	// URL to redirect the client to (e.g. the authorization endpoint of an identity provider), set in the `Location` header of the denial response.
+	// Unless a custom `code` is specified, the status code of the denial response is set to 302 (Found).
+	RedirectTo *ValueOrSelector `json:"redirectTo,omitempty"`
+
+	// Formats the body of the denial response as RFC 7807 problem details (application/problem+json), with the status
+	// code, the message of the denial as `detail` and the path of the request as `instance`.
+	// Ignored if a custom `body` is specified.
+	// +optional
+	ProblemDetails *ProblemDetailsSpec `json:"problemDetails,omitempty"`
+}
+
+// Settings of the RFC 7807 problem details of the denial response.
+type ProblemDetailsSpec struct {
+	// URI reference that identifies the problem type.
+	// If omitted, it defaults to "about:blank".
+	// +optional
+	Type string `json:"type,omitempty"`
+
+	// Short, human-readable summary of the problem type.
+	// If omitted, it defaults to the standard text of the HTTP status code (e.g. "Forbidden").
+	// +optional
+	Title string `json:"title,omitempty"`
 }
 
 // Settings of the custom success response.
@@ -697,14 +1171,118 @@ type WrappedSuccessResponseSpec struct {
 	// For integration of Authorino via proxy, the proxy must use these settings to inject data in the request.
 	Headers map[string]HeaderSuccessResponseSpec `json:"headers,omitempty"`
 
+	// Custom success response items wrapped as HTTP headers added to the response sent back to the client.
+	// For integration of Authorino via proxy, the proxy must add these headers to the response sent back downstream.
+	ResponseHeaders map[string]HeaderSuccessResponseSpec `json:"responseHeaders,omitempty"`
+
 	// Custom success response items wrapped as HTTP headers.
 	// For integration of Authorino via proxy, the proxy must use these settings to propagate dynamic metadata.
 	// See https://www.envoyproxy.io/docs/envoy/latest/configuration/advanced/well_known_dynamic_metadata
 	DynamicMetadata map[string]SuccessResponseSpec `json:"dynamicMetadata,omitempty"`
+
+	// Root property of the Dynamic Metadata under which the dynamic metadata items are emitted.
+	// If omitted, each item is emitted at the root of the Dynamic Metadata of the external authorization filter (e.g. `envoy.filters.http.ext_authz`).
+	// Only the items declared in `dynamicMetadata` are emitted.
+	// +optional
+	DynamicMetadataNamespace string `json:"dynamicMetadataNamespace,omitempty"`
+
+	// Custom success response items set as cookies in the response to the client (`Set-Cookie` headers).
+	// The key of each item is the name of the cookie. Values are URL-encoded.
+	// For integration of Authorino via proxy, the proxy must add these headers to the response sent back downstream.
+	Cookies map[string]CookieSuccessResponseSpec `json:"cookies,omitempty"`
+
+	// Custom success response items wrapped as gRPC metadata of the request forwarded to a gRPC upstream.
+	// Keys are lowercased. Values of binary metadata keys (suffixed with "-bin") are base64-encoded.
+	// For integration of Authorino via proxy, the proxy must use these settings to inject data in the request.
+	GrpcMetadata map[string]SuccessResponseSpec `json:"grpcMetadata,omitempty"`
+
+	// Custom success response items set as query parameters of the request forwarded upstream (e.g. for legacy upstreams
+	// that cannot read headers). The key of each item is the name of the query parameter. Existing values are replaced.
+	// For integration of Authorino via proxy, the proxy must use these settings to rewrite the path of the request.
+	QueryParameters map[string]SuccessResponseSpec `json:"queryParameters,omitempty"`
+
+	// Names of the query parameters to remove from the request forwarded upstream.
+	// +optional
+	QueryParametersToRemove []string `json:"queryParametersToRemove,omitempty"`
 }
 
 type HeaderSuccessResponseSpec struct {
 	SuccessResponseSpec `json:",omitempty"`
+
+	// Encrypts the value of the header as a compact JSON Web Encryption (JWE) token, so only the recipient can read it.
+	// +optional
+	Encryption *HeaderEncryptionSpec `json:"encryption,omitempty"`
+
+	// Compresses the value of the header with gzip and encodes it in base64, when larger than a given size, so large
+	// values (e.g. identity objects) fit within the limits of header size of the proxies.
+	// If the value is also encrypted, it is compressed before being encrypted.
+	// +optional
+	Compression *HeaderCompressionSpec `json:"compression,omitempty"`
+
+	// Whether to append the value to the existing values of the header (e.g. X-Forwarded-Groups), instead of replacing them.
+	// +optional
+	// +kubebuilder:default:=false
+	Append bool `json:"append,omitempty"`
+}
+
+// Settings of the compression of a header value.
+type HeaderCompressionSpec struct {
+	// Minimum size of the value, in bytes, to compress it. Smaller values are left uncompressed.
+	// +optional
+	// +kubebuilder:default:=1024
+	Threshold int `json:"threshold,omitempty"`
+}
+
+// +kubebuilder:validation:Enum:=RSA-OAEP;RSA-OAEP-256;ECDH-ES;ECDH-ES+A128KW;ECDH-ES+A256KW
+type JweKeyAlgorithm string
+
+// +kubebuilder:validation:Enum:=A128GCM;A192GCM;A256GCM;A128CBC-HS256;A256CBC-HS512
+type JweContentEncryption string
+
+// Settings of the JSON Web Encryption (JWE) of a header value.
+type HeaderEncryptionSpec struct {
+	// Reference to a Kubernetes secret that stores the public key of the recipient, formatted as PEM (public key or X.509 certificate).
+	// The name of the secret is set in the `kid` header of the JWE.
+	RecipientKeyRef SecretKeyReference `json:"recipientKeyRef"`
+
+	// Key management algorithm.
+	// Defaults to RSA-OAEP-256 for RSA keys and ECDH-ES+A256KW for EC keys.
+	// +optional
+	Algorithm JweKeyAlgorithm `json:"algorithm,omitempty"`
+
+	// Content encryption algorithm.
+	// +kubebuilder:default:=A256GCM
+	ContentEncryption JweContentEncryption `json:"contentEncryption,omitempty"`
+}
+
+type CookieSuccessResponseSpec struct {
+	SuccessResponseSpec `json:",omitempty"`
+	CookieAttributes    `json:""`
+}
+
+// +kubebuilder:validation:Enum:=Strict;Lax;None
+type CookieSameSite string
+
+// Attributes of a cookie set in the response to the client.
+type CookieAttributes struct {
+	// Path attribute of the cookie.
+	Path string `json:"path,omitempty"`
+
+	// Domain attribute of the cookie.
+	Domain string `json:"domain,omitempty"`
+
+	// Lifetime of the cookie, in seconds (Max-Age attribute).
+	// Omit it for a session cookie; set a negative value to expire the cookie immediately.
+	MaxAge int `json:"maxAge,omitempty"`
+
+	// Whether the cookie is only sent over secure connections.
+	Secure bool `json:"secure,omitempty"`
+
+	// Whether the cookie is inaccessible to client-side scripts.
+	HttpOnly bool `json:"httpOnly,omitempty"`
+
+	// SameSite attribute of the cookie.
+	SameSite CookieSameSite `json:"sameSite,omitempty"`
 }
 
 // Settings of the success custom response item.
@@ -724,6 +1302,8 @@ func (s *SuccessResponseSpec) GetMethod() AuthResponseMethod {
 		return JsonAuthResponse
 	} else if s.Wristband != nil {
 		return WristbandAuthResponse
+	} else if s.Signature != nil {
+		return SignatureAuthResponse
 	}
 	return UnknownAuthResponseMethod
 }
@@ -737,6 +1317,8 @@ type AuthResponseMethodSpec struct {
 	Json *JsonAuthResponseSpec `json:"json,omitempty"`
 	// Authorino Festival Wristband token
 	Wristband *WristbandAuthResponseSpec `json:"wristband,omitempty"`
+	// Signature of the request, for the upstream service to verify the request was authorized by Authorino
+	Signature *SignatureAuthResponseSpec `json:"signature,omitempty"`
 }
 
 // Static value or selector to set the plain custom response item.
@@ -757,8 +1339,14 @@ type WristbandAuthResponseSpec struct {
 	// Time span of the wristband token, in seconds.
 	TokenDuration *int64 `json:"tokenDuration,omitempty"`
 	// Reference by name to Kubernetes secrets and corresponding signing algorithms.
-	// The secrets must contain a `key.pem` entry whose value is the signing key formatted as PEM.
+	// The secrets must contain a `key.pem` entry whose value is the signing key formatted as PEM (EC, RSA or Ed25519 private key, matching the algorithm),
+	// except for the HMAC algorithms (HS256, HS384, HS512), whose secrets must contain a `key` entry with the shared secret.
 	SigningKeyRefs []*WristbandSigningKeyRef `json:"signingKeyRefs"`
+	// Interval of rotation of the signing key, in seconds.
+	// When set, the wristband tokens are signed with each of the keys listed in `signingKeyRefs`, in order, for one interval at a time.
+	// All the keys are always published in the JWKS of the wristband issuer, so tokens signed with any of them remain verifiable.
+	// Omit it to always sign the tokens with the first key.
+	SigningKeyRotationInterval int64 `json:"signingKeyRotationInterval,omitempty"`
 }
 
 type WristbandSigningKeyRef struct {
@@ -768,26 +1356,138 @@ type WristbandSigningKeyRef struct {
 
 	// Algorithm to sign the wristband token using the signing key provided
 	Algorithm WristbandSigningKeyAlgorithm `json:"algorithm"`
+
+	// Key stored in a cloud key management service to sign the wristband token with, instead of a Kubernetes secret.
+	// The private key never leaves the service. The `kid` claim of the wristband token header is <name>/<key version>.
+	// +optional
+	KMS *WristbandKMSSigningKey `json:"kms,omitempty"`
 }
 
-// +kubebuilder:validation:Enum:=ES256;ES384;ES512;RS256;RS384;RS512
+// +kubebuilder:validation:Enum:=aws;gcp;azure
+type KMSProvider string
+
+// Settings of a signing key stored in a cloud key management service
+type WristbandKMSSigningKey struct {
+	// Key management service that stores the key: AWS KMS (aws), Google Cloud KMS (gcp) or Azure Key Vault (azure).
+	Provider KMSProvider `json:"provider"`
+
+	// Identifier of the key.
+	// AWS: key id, key ARN, alias name or alias ARN.
+	// GCP: resource name of the key (projects/*/locations/*/keyRings/*/cryptoKeys/*) or of a version of the key.
+	// Azure: URL of the key (https://{vault}.vault.azure.net/keys/{name}) or of a version of the key.
+	// Unless a version is pinned, new versions of the key are picked up as they are created.
+	KeyId string `json:"keyId"`
+
+	// AWS region of the key. Omit to use the region of the key ARN or of the environment of Authorino.
+	// +optional
+	Region string `json:"region,omitempty"`
+
+	// Reference to a Kubernetes secret in the same namespace that stores the credentials to call the key management service.
+	// AWS: accessKeyId, secretAccessKey and (optional) sessionToken. GCP: credentials.json. Azure: tenantId, clientId and clientSecret.
+	// Omit to use the credentials of the environment of Authorino (e.g. workload identity).
+	// +optional
+	CredentialsRef *k8score.LocalObjectReference `json:"credentialsRef,omitempty"`
+}
+
+// +kubebuilder:validation:Enum:=ES256;ES384;ES512;RS256;RS384;RS512;EdDSA;HS256;HS384;HS512
 type WristbandSigningKeyAlgorithm string
 
+// Settings of the request signature custom response item.
+// The signature covers the time it was created (Unix time) followed by the values of the signed attributes, in order,
+// separated by line feeds. The response item is formatted as `keyid="…",algorithm="…",created=…,signature="…"`.
+type SignatureAuthResponseSpec struct {
+	// Reference to the Kubernetes secret that stores the signing key.
+	// The secret must contain a `key` entry with the shared secret for the HMAC algorithms (HS256, HS384, HS512), or a
+	// `key.pem` entry with the private key formatted as PEM (EC, RSA or Ed25519, matching the algorithm) otherwise.
+	// The name of the secret is set as `keyid` of the signature.
+	SigningKeyRef k8score.LocalObjectReference `json:"signingKeyRef"`
+
+	// Algorithm to sign the request.
+	// +optional
+	// +kubebuilder:default:=HS256
+	Algorithm WristbandSigningKeyAlgorithm `json:"algorithm,omitempty"`
+
+	// Selectors of the attributes of the request to sign, fetched from the authorization JSON.
+	// If omitted, it defaults to the method, host and path of the request.
+	// +optional
+	Attributes []string `json:"attributes,omitempty"`
+}
+
 type CallbackSpec struct {
 	CommonEvaluatorSpec `json:""`
-	CallbackMethodSpec  `json:""`
+
+	// Maximum duration of the callback, in milliseconds.
+	// Callbacks are fired after the auth decision is returned to the proxy, thus never adding up to the latency of the response.
+	// +optional
+	// +kubebuilder:default:=5000
+	Timeout int `json:"timeout,omitempty"`
+
+	// Outcomes of the auth pipeline that trigger the callback: "allow" (access granted), "deny" (access denied) and/or
+	// "error" (access denied because the auth pipeline could not be completed, e.g. due to a timeout).
+	// If omitted, the callback is triggered regardless of the outcome.
+	// Combine with `when` conditions to filter further, e.g. by `auth.denial.code`.
+	// +optional
+	On []CallbackEvent `json:"on,omitempty"`
+
+	CallbackMethodSpec `json:""`
 }
 
 func (s *CallbackSpec) GetMethod() CallbackMethod {
 	if s.Http != nil {
 		return HttpCallback
+	} else if s.Kafka != nil {
+		return KafkaCallback
 	}
 	return UnknownCallbackMethod
 }
 
 // Settings of the callback function.
 type CallbackMethodSpec struct {
-	Http *HttpEndpointSpec `json:"http"` // make this 'omitempty' if other alternate methods are added
+	Http  *HttpEndpointSpec  `json:"http,omitempty"`
+	Kafka *KafkaCallbackSpec `json:"kafka,omitempty"`
+}
+
+// +kubebuilder:validation:Enum:=json;protobuf
+type KafkaSerialization string
+
+// Settings of the Kafka topic where to publish the records of the auth decisions.
+// Each record includes the requested host, the subject, the verdict and the response code, and the time taken by the
+// evaluators of each phase of the auth pipeline.
+type KafkaCallbackSpec struct {
+	// Addresses of the Kafka brokers (format: <host>:<port>).
+	// +kubebuilder:validation:MinItems:=1
+	Brokers []string `json:"brokers"`
+
+	// Name of the Kafka topic.
+	Topic string `json:"topic"`
+
+	// Serialization of the records: "json" (default) or "protobuf" (google.protobuf.Struct).
+	// +optional
+	// +kubebuilder:default:=json
+	Serialization KafkaSerialization `json:"serialization,omitempty"`
+
+	// Subject of the auth decision, also used as key of the records.
+	// If omitted, it defaults to the 'sub' claim of the identity object (auth.identity.sub).
+	// +optional
+	Subject *ValueOrSelector `json:"subject,omitempty"`
+
+	// Wraps the records in CloudEvents envelopes (JSON format, structured content mode).
+	// Requires JSON serialization.
+	// +optional
+	CloudEvents *CloudEventsSpec `json:"cloudEvents,omitempty"`
+}
+
+// Attributes of the CloudEvents envelope of the records of the auth decisions.
+type CloudEventsSpec struct {
+	// Type of the events.
+	// If omitted, it defaults to "io.kuadrant.authorino.decision".
+	// +optional
+	Type string `json:"type,omitempty"`
+
+	// Source of the events.
+	// If omitted, it defaults to "authorino/<namespace>/<authconfig-name>".
+	// +optional
+	Source string `json:"source,omitempty"`
 }
 
 // AuthConfigStatus defines the observed state of AuthConfig
@@ -1,11 +1,406 @@
 package v1beta2
 
 import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/kuadrant/authorino/pkg/json"
+	"github.com/kuadrant/authorino/pkg/utils"
+
+	k8score "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// issuerUrlPlaceholder matches the placeholders of the issuer URL templates of multi-tenant identity providers
+var issuerUrlPlaceholder = regexp.MustCompile(`\{([^{}/]+)\}`)
+
+// +kubebuilder:webhook:path=/validate-authorino-kuadrant-io-v1beta2-authconfig,mutating=false,failurePolicy=fail,sideEffects=None,groups=authorino.kuadrant.io,resources=authconfigs,verbs=create;update,versions=v1beta2,name=vauthconfig.authorino.kuadrant.io,admissionReviewVersions=v1
+
 func (a *AuthConfig) SetupWebhookWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(a).
+		WithValidator(&AuthConfigValidator{Reader: mgr.GetAPIReader()}).
 		Complete()
 }
+
+// AuthConfigValidator rejects AuthConfigs that would otherwise fail to reconcile or to be linked to their hosts, i.e.
+// with malformed selectors, evaluators of unknown type, references to missing Secrets or hosts already taken by other
+// AuthConfigs.
+type AuthConfigValidator struct {
+	Reader client.Reader
+}
+
+func (v *AuthConfigValidator) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	return v.validate(ctx, obj.(*AuthConfig))
+}
+
+func (v *AuthConfigValidator) ValidateUpdate(ctx context.Context, _, newObj runtime.Object) error {
+	return v.validate(ctx, newObj.(*AuthConfig))
+}
+
+func (v *AuthConfigValidator) ValidateDelete(_ context.Context, _ runtime.Object) error {
+	return nil
+}
+
+func (v *AuthConfigValidator) validate(ctx context.Context, authConfig *AuthConfig) error {
+	specPath := field.NewPath("spec")
+
+	errs := validateHosts(authConfig, specPath.Child("hosts"))
+	errs = append(errs, validateMethods(authConfig, specPath)...)
+	errs = append(errs, validateSelectors(reflect.ValueOf(authConfig.Spec), specPath)...)
+
+	if len(errs) == 0 && v.Reader != nil {
+		errs = append(errs, v.validateSecretRefs(ctx, authConfig, specPath)...)
+		errs = append(errs, v.validateIdentityProviderRefs(ctx, authConfig, specPath)...)
+		errs = append(errs, v.validateHostCollisions(ctx, authConfig, specPath.Child("hosts"))...)
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+	return errors.NewInvalid(GroupVersion.WithKind("AuthConfig").GroupKind(), authConfig.Name, errs)
+}
+
+func validateHosts(authConfig *AuthConfig, path *field.Path) (errs field.ErrorList) {
+	hosts := map[string]bool{}
+	for i, host := range authConfig.Spec.Hosts {
+		if hosts[host] {
+			errs = append(errs, field.Duplicate(path.Index(i), host))
+		}
+		hosts[host] = true
+	}
+	return
+}
+
+func validateMethods(authConfig *AuthConfig, specPath *field.Path) (errs field.ErrorList) {
+	for _, name := range sortedKeys(authConfig.Spec.Authentication) {
+		spec := authConfig.Spec.Authentication[name]
+		if spec.GetMethod() == UnknownAuthenticationMethod {
+			errs = append(errs, unknownMethod(specPath.Child("authentication").Key(name)))
+		}
+		if oauth2 := spec.OAuth2TokenIntrospection; oauth2 != nil && oauth2.IdentityProviderRef == nil && (oauth2.Url == "" || oauth2.Credentials == nil) {
+			errs = append(errs, field.Required(specPath.Child("authentication").Key(name).Child("oauth2Introspection"), "endpoint and credentialsRef, or identityProviderRef"))
+		}
+		if apiKey := spec.ApiKey; apiKey != nil && apiKey.Selector == nil && apiKey.VaultPath == "" {
+			errs = append(errs, field.Required(specPath.Child("authentication").Key(name).Child("apiKey"), "selector or vaultPath"))
+		}
+		if jwt := spec.Jwt; jwt != nil && jwt.IdentityProviderRef == nil {
+			templated := issuerUrlPlaceholder.MatchString(jwt.IssuerUrl)
+			if templated && len(jwt.Tenants) == 0 {
+				errs = append(errs, field.Required(specPath.Child("authentication").Key(name).Child("jwt", "tenants"), "allowlist of tenants of the issuer url template"))
+			}
+			if !templated && len(jwt.Tenants) > 0 {
+				errs = append(errs, field.Invalid(specPath.Child("authentication").Key(name).Child("jwt", "issuerUrl"), jwt.IssuerUrl, "tenants require an issuer url template"))
+			}
+		}
+	}
+	for _, name := range sortedKeys(authConfig.Spec.Metadata) {
+		if spec := authConfig.Spec.Metadata[name]; spec.GetMethod() == UnknownMetadataMethod {
+			errs = append(errs, unknownMethod(specPath.Child("metadata").Key(name)))
+		}
+	}
+	for _, name := range sortedKeys(authConfig.Spec.Authorization) {
+		if spec := authConfig.Spec.Authorization[name]; spec.GetMethod() == UnknownAuthorizationMethod {
+			errs = append(errs, unknownMethod(specPath.Child("authorization").Key(name)))
+		}
+	}
+	if response := authConfig.Spec.Response; response != nil {
+		successPath := specPath.Child("response", "success")
+		checkSuccessResponse := func(path *field.Path, spec SuccessResponseSpec) {
+			if spec.GetMethod() == UnknownAuthResponseMethod {
+				errs = append(errs, unknownMethod(path))
+			}
+			if spec.Wristband != nil {
+				for i, signingKeyRef := range spec.Wristband.SigningKeyRefs {
+					if signingKeyRef != nil && signingKeyRef.KMS != nil && !kmsSigningKeyAlgorithm(signingKeyRef.Algorithm) {
+						errs = append(errs, field.Invalid(path.Child("wristband", "signingKeyRefs").Index(i).Child("algorithm"), signingKeyRef.Algorithm, "kms signing keys support ES* and RS* algorithms only"))
+					}
+				}
+			}
+		}
+		for _, name := range sortedKeys(response.Success.Headers) {
+			checkSuccessResponse(successPath.Child("headers").Key(name), response.Success.Headers[name].SuccessResponseSpec)
+		}
+		for _, name := range sortedKeys(response.Success.ResponseHeaders) {
+			checkSuccessResponse(successPath.Child("responseHeaders").Key(name), response.Success.ResponseHeaders[name].SuccessResponseSpec)
+		}
+		for _, name := range sortedKeys(response.Success.Cookies) {
+			checkSuccessResponse(successPath.Child("cookies").Key(name), response.Success.Cookies[name].SuccessResponseSpec)
+		}
+		for wrapper, items := range map[string]map[string]SuccessResponseSpec{
+			"dynamicMetadata": response.Success.DynamicMetadata,
+			"grpcMetadata":    response.Success.GrpcMetadata,
+			"queryParameters": response.Success.QueryParameters,
+		} {
+			for _, name := range sortedKeys(items) {
+				checkSuccessResponse(successPath.Child(wrapper).Key(name), items[name])
+			}
+		}
+	}
+	for _, name := range sortedKeys(authConfig.Spec.Callbacks) {
+		if spec := authConfig.Spec.Callbacks[name]; spec.GetMethod() == UnknownCallbackMethod {
+			errs = append(errs, unknownMethod(specPath.Child("callbacks").Key(name)))
+		}
+	}
+	return
+}
+
+func unknownMethod(path *field.Path) *field.Error {
+	return field.Required(path, "unknown or missing evaluator type")
+}
+
+// kmsSigningKeyAlgorithm tells whether a wristband signing algorithm is supported by the key management services,
+// i.e. neither shared secrets (HS*) nor EdDSA
+func kmsSigningKeyAlgorithm(algorithm WristbandSigningKeyAlgorithm) bool {
+	return strings.HasPrefix(string(algorithm), "ES") || strings.HasPrefix(string(algorithm), "RS")
+}
+
+var selectorFields = []string{"selector", "rolesSelector"}
+
+// validateSelectors walks the spec looking for selectors of the authorization JSON and checks each one is well formed
+func validateSelectors(value reflect.Value, path *field.Path) (errs field.ErrorList) {
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !value.IsNil() {
+			errs = append(errs, validateSelectors(value.Elem(), path)...)
+		}
+	case reflect.Slice:
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		for i := 0; i < value.Len(); i++ {
+			errs = append(errs, validateSelectors(value.Index(i), path.Index(i))...)
+		}
+	case reflect.Map:
+		keys := value.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, key := range keys {
+			errs = append(errs, validateSelectors(value.MapIndex(key), path.Key(key.String()))...)
+		}
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			structField := value.Type().Field(i)
+			if !structField.IsExported() {
+				continue
+			}
+			fieldValue := value.Field(i)
+			name := strings.Split(structField.Tag.Get("json"), ",")[0]
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				errs = append(errs, validateSelectors(fieldValue, path)...)
+				continue
+			}
+			fieldPath := path.Child(name)
+			switch {
+			case fieldValue.Kind() == reflect.String && utils.SliceContains(selectorFields, name):
+				if err := json.ValidateSelector(fieldValue.String()); err != nil {
+					errs = append(errs, field.Invalid(fieldPath, fieldValue.String(), err.Error()))
+				}
+			case value.Type() == reflect.TypeOf(SignatureAuthResponseSpec{}) && name == "attributes":
+				for j, attribute := range fieldValue.Interface().([]string) {
+					if err := json.ValidateSelector(attribute); err != nil {
+						errs = append(errs, field.Invalid(fieldPath.Index(j), attribute, err.Error()))
+					}
+				}
+			default:
+				errs = append(errs, validateSelectors(fieldValue, fieldPath)...)
+			}
+		}
+	}
+	return
+}
+
+// validateSecretRefs checks the Kubernetes Secrets referred in the spec exist in the namespace of the AuthConfig and
+// contain the referred keys
+func (v *AuthConfigValidator) validateSecretRefs(ctx context.Context, authConfig *AuthConfig, specPath *field.Path) (errs field.ErrorList) {
+	checkSecret := func(path *field.Path, name string, keys ...string) {
+		if strings.HasPrefix(name, "vault:") || strings.HasPrefix(name, "file:") {
+			return // read from vault or from the file system by the reconciler
+		}
+		secret := &k8score.Secret{}
+		if err := v.Reader.Get(ctx, types.NamespacedName{Namespace: authConfig.Namespace, Name: name}, secret); err != nil {
+			if errors.IsNotFound(err) {
+				errs = append(errs, field.NotFound(path, name))
+			} else {
+				errs = append(errs, field.InternalError(path, err))
+			}
+			return
+		}
+		for _, key := range keys {
+			if _, found := secret.Data[key]; !found {
+				errs = append(errs, field.Invalid(path, name, fmt.Sprintf("secret has no key %s", key)))
+			}
+		}
+	}
+	checkSecretKeyRef := func(path *field.Path, ref *SecretKeyReference) {
+		if ref != nil {
+			checkSecret(path, ref.Name, ref.Key)
+		}
+	}
+	checkHttpEndpoint := func(path *field.Path, http *HttpEndpointSpec) {
+		if http == nil {
+			return
+		}
+		checkSecretKeyRef(path.Child("sharedSecretRef"), http.SharedSecret)
+		if http.OAuth2 != nil {
+			checkSecretKeyRef(path.Child("oauth2", "clientSecretRef"), &http.OAuth2.ClientSecret)
+		}
+	}
+
+	for _, name := range sortedKeys(authConfig.Spec.Authentication) {
+		spec := authConfig.Spec.Authentication[name]
+		if spec.OAuth2TokenIntrospection != nil && spec.OAuth2TokenIntrospection.Credentials != nil {
+			checkSecret(specPath.Child("authentication").Key(name).Child("oauth2Introspection", "credentialsRef"), spec.OAuth2TokenIntrospection.Credentials.Name, "clientID", "clientSecret")
+		}
+	}
+	for _, name := range sortedKeys(authConfig.Spec.Metadata) {
+		spec := authConfig.Spec.Metadata[name]
+		path := specPath.Child("metadata").Key(name)
+		checkHttpEndpoint(path.Child("http"), spec.Http)
+		if spec.Uma != nil && spec.Uma.Credentials != nil {
+			checkSecret(path.Child("uma", "credentialsRef"), spec.Uma.Credentials.Name, "clientID", "clientSecret")
+		}
+	}
+	for _, name := range sortedKeys(authConfig.Spec.Authorization) {
+		spec := authConfig.Spec.Authorization[name]
+		path := specPath.Child("authorization").Key(name)
+		if spec.SpiceDB != nil {
+			checkSecretKeyRef(path.Child("spicedb", "sharedSecretRef"), spec.SpiceDB.SharedSecret)
+		}
+		if spec.Quota != nil && spec.Quota.Redis != nil {
+			checkSecretKeyRef(path.Child("quota", "redis", "passwordRef"), spec.Quota.Redis.Password)
+		}
+	}
+	if response := authConfig.Spec.Response; response != nil {
+		successPath := specPath.Child("response", "success")
+		checkSuccessResponse := func(path *field.Path, spec SuccessResponseSpec) {
+			if spec.Wristband != nil {
+				for i, signingKeyRef := range spec.Wristband.SigningKeyRefs {
+					switch {
+					case signingKeyRef == nil:
+					case signingKeyRef.KMS != nil:
+						if ref := signingKeyRef.KMS.CredentialsRef; ref != nil {
+							checkSecret(path.Child("wristband", "signingKeyRefs").Index(i).Child("kms", "credentialsRef"), ref.Name)
+						}
+					default:
+						checkSecret(path.Child("wristband", "signingKeyRefs").Index(i), signingKeyRef.Name)
+					}
+				}
+			}
+			if spec.Signature != nil {
+				checkSecret(path.Child("signature", "signingKeyRef"), spec.Signature.SigningKeyRef.Name)
+			}
+		}
+		for wrapper, items := range map[string]map[string]HeaderSuccessResponseSpec{
+			"headers":         response.Success.Headers,
+			"responseHeaders": response.Success.ResponseHeaders,
+		} {
+			for _, name := range sortedKeys(items) {
+				spec := items[name]
+				path := successPath.Child(wrapper).Key(name)
+				checkSuccessResponse(path, spec.SuccessResponseSpec)
+				if spec.Encryption != nil {
+					checkSecretKeyRef(path.Child("encryption", "recipientKeyRef"), &spec.Encryption.RecipientKeyRef)
+				}
+			}
+		}
+		for _, name := range sortedKeys(response.Success.Cookies) {
+			checkSuccessResponse(successPath.Child("cookies").Key(name), response.Success.Cookies[name].SuccessResponseSpec)
+		}
+		for wrapper, items := range map[string]map[string]SuccessResponseSpec{
+			"dynamicMetadata": response.Success.DynamicMetadata,
+			"grpcMetadata":    response.Success.GrpcMetadata,
+			"queryParameters": response.Success.QueryParameters,
+		} {
+			for _, name := range sortedKeys(items) {
+				checkSuccessResponse(successPath.Child(wrapper).Key(name), items[name])
+			}
+		}
+	}
+	for _, name := range sortedKeys(authConfig.Spec.Callbacks) {
+		checkHttpEndpoint(specPath.Child("callbacks").Key(name).Child("http"), authConfig.Spec.Callbacks[name].Http)
+	}
+	return
+}
+
+// validateHostCollisions checks the hosts of the AuthConfig are not already declared by other AuthConfigs, in which
+// case the reconciler would never link the hosts to the AuthConfig
+func (v *AuthConfigValidator) validateIdentityProviderRefs(ctx context.Context, authConfig *AuthConfig, specPath *field.Path) (errs field.ErrorList) {
+	getIdentityProvider := func(path *field.Path, ref *IdentityProviderReference) *IdentityProvider {
+		identityProvider := &IdentityProvider{}
+		if err := v.Reader.Get(ctx, types.NamespacedName{Name: ref.Name}, identityProvider); err != nil {
+			if errors.IsNotFound(err) {
+				errs = append(errs, field.NotFound(path, ref.Name))
+			} else {
+				errs = append(errs, field.InternalError(path, err))
+			}
+			return nil
+		}
+		return identityProvider
+	}
+
+	for _, name := range sortedKeys(authConfig.Spec.Authentication) {
+		spec := authConfig.Spec.Authentication[name]
+		path := specPath.Child("authentication").Key(name)
+		if spec.Jwt != nil && spec.Jwt.IdentityProviderRef != nil {
+			getIdentityProvider(path.Child("jwt", "identityProviderRef"), spec.Jwt.IdentityProviderRef)
+		}
+		if spec.OAuth2TokenIntrospection != nil && spec.OAuth2TokenIntrospection.IdentityProviderRef != nil {
+			refPath := path.Child("oauth2Introspection", "identityProviderRef")
+			ref := spec.OAuth2TokenIntrospection.IdentityProviderRef
+			if identityProvider := getIdentityProvider(refPath, ref); identityProvider != nil && identityProvider.Spec.TokenIntrospection == nil {
+				errs = append(errs, field.Invalid(refPath, ref.Name, "identity provider has no token introspection settings"))
+			}
+		}
+	}
+	return
+}
+
+func (v *AuthConfigValidator) validateHostCollisions(ctx context.Context, authConfig *AuthConfig, path *field.Path) (errs field.ErrorList) {
+	authConfigList := &AuthConfigList{}
+	if err := v.Reader.List(ctx, authConfigList); err != nil {
+		return field.ErrorList{field.InternalError(path, err)}
+	}
+
+	takenHosts := map[string]*AuthConfig{}
+	for i := range authConfigList.Items {
+		other := &authConfigList.Items[i]
+		if other.Namespace == authConfig.Namespace && other.Name == authConfig.Name {
+			continue
+		}
+		for _, host := range other.Spec.Hosts {
+			if taken, found := takenHosts[host]; !found || other.Spec.Priority > taken.Spec.Priority {
+				takenHosts[host] = other
+			}
+		}
+	}
+
+	for i, host := range authConfig.Spec.Hosts {
+		// a host taken by an authconfig with lower priority is to be taken over
+		if other, taken := takenHosts[host]; taken && other.Spec.Priority >= authConfig.Spec.Priority {
+			errs = append(errs, field.Invalid(path.Index(i), host, fmt.Sprintf("host already taken by authconfig %s/%s", other.Namespace, other.Name)))
+		}
+	}
+	return
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,230 @@
+package v1beta2
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/assert"
+	k8score "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newTestAuthConfigValidator(initObjs ...runtime.Object) *AuthConfigValidator {
+	scheme := runtime.NewScheme()
+	_ = AddToScheme(scheme)
+	_ = k8score.AddToScheme(scheme)
+	return &AuthConfigValidator{Reader: fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(initObjs...).Build()}
+}
+
+func newTestAuthConfigForValidation(name string, hosts ...string) *AuthConfig {
+	return &AuthConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "authorino"},
+		Spec: AuthConfigSpec{
+			Hosts: hosts,
+			Authentication: map[string]AuthenticationSpec{
+				"anonymous": {
+					AuthenticationMethodSpec: AuthenticationMethodSpec{AnonymousAccess: &AnonymousAccessSpec{}},
+				},
+			},
+			Authorization: map[string]AuthorizationSpec{
+				"admins": {
+					AuthorizationMethodSpec: AuthorizationMethodSpec{
+						PatternMatching: &PatternMatchingAuthorizationSpec{
+							Patterns: []PatternExpressionOrRef{
+								{PatternExpression: PatternExpression{Selector: "auth.identity.groups.@extract:{\"sep\":\",\"}", Operator: "incl", Value: "admin"}},
+							},
+						},
+					},
+				},
+			},
+			Response: &ResponseSpec{
+				Success: WrappedSuccessResponseSpec{
+					Headers: map[string]HeaderSuccessResponseSpec{
+						"x-signature": {
+							SuccessResponseSpec: SuccessResponseSpec{
+								AuthResponseMethodSpec: AuthResponseMethodSpec{
+									Signature: &SignatureAuthResponseSpec{
+										SigningKeyRef: k8score.LocalObjectReference{Name: "signing-key"},
+										Attributes:    []string{"context.request.http.path"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestValidateAuthConfig(t *testing.T) {
+	signingKey := &k8score.Secret{ObjectMeta: metav1.ObjectMeta{Name: "signing-key", Namespace: "authorino"}, Data: map[string][]byte{"key": []byte("s3cr3t")}}
+	validator := newTestAuthConfigValidator(signingKey)
+
+	authConfig := newTestAuthConfigForValidation("talker-api", "talker-api.io", "*.talker-api.io")
+	assert.NilError(t, validator.ValidateCreate(context.TODO(), authConfig))
+	assert.NilError(t, validator.ValidateUpdate(context.TODO(), authConfig, authConfig))
+}
+
+func TestValidateAuthConfigMalformedSpec(t *testing.T) {
+	validator := newTestAuthConfigValidator()
+
+	authConfig := newTestAuthConfigForValidation("talker-api", "talker-api.io", "talker-api.io")
+	authConfig.Spec.Authentication["unknown"] = AuthenticationSpec{}
+	authConfig.Spec.Authorization["admins"].PatternMatching.Patterns[0].Selector = "auth.identity.groups.@unknown"
+	authConfig.Spec.Response.Success.Headers["x-signature"].Signature.Attributes = []string{"context.request.http.headers.#(name"}
+
+	err := validator.ValidateCreate(context.TODO(), authConfig)
+	assert.Error(t, err, `AuthConfig.authorino.kuadrant.io "talker-api" is invalid: [`+
+		`spec.authentication[unknown]: Required value: unknown or missing evaluator type, `+
+		`spec.authorization[admins].patternMatching.patterns[0].selector: Invalid value: "auth.identity.groups.@unknown": unknown modifier '@unknown' in selector: auth.identity.groups.@unknown, `+
+		`spec.hosts[1]: Duplicate value: "talker-api.io", `+
+		`spec.response.success.headers[x-signature].signature.attributes[0]: Invalid value: "context.request.http.headers.#(name": missing ')' in selector: context.request.http.headers.#(name]`)
+}
+
+func TestValidateAuthConfigUnresolvableReferences(t *testing.T) {
+	other := newTestAuthConfigForValidation("other", "other.io", "talker-api.io")
+	other.Namespace = "other-namespace"
+	validator := newTestAuthConfigValidator(other)
+
+	authConfig := newTestAuthConfigForValidation("talker-api", "talker-api.io")
+	err := validator.ValidateCreate(context.TODO(), authConfig)
+	assert.Error(t, err, `AuthConfig.authorino.kuadrant.io "talker-api" is invalid: [`+
+		`spec.hosts[0]: Invalid value: "talker-api.io": host already taken by authconfig other-namespace/other, `+
+		`spec.response.success.headers[x-signature].signature.signingKeyRef: Not found: "signing-key"]`)
+
+	// the authconfig does not collide with itself
+	other.Namespace = "authorino"
+	other.Name = "talker-api"
+	signingKey := &k8score.Secret{ObjectMeta: metav1.ObjectMeta{Name: "signing-key", Namespace: "authorino"}}
+	validator = newTestAuthConfigValidator(other, signingKey)
+	assert.NilError(t, validator.ValidateUpdate(context.TODO(), other, authConfig))
+}
+
+func TestValidateAuthConfigHostTakenOverByPriority(t *testing.T) {
+	signingKey := &k8score.Secret{ObjectMeta: metav1.ObjectMeta{Name: "signing-key", Namespace: "authorino"}}
+	other := newTestAuthConfigForValidation("other", "talker-api.io")
+	other.Spec.Priority = 1
+	validator := newTestAuthConfigValidator(other, signingKey)
+
+	authConfig := newTestAuthConfigForValidation("talker-api", "talker-api.io")
+	authConfig.Spec.Priority = 1
+	err := validator.ValidateCreate(context.TODO(), authConfig)
+	assert.Error(t, err, `AuthConfig.authorino.kuadrant.io "talker-api" is invalid: spec.hosts[0]: Invalid value: "talker-api.io": host already taken by authconfig authorino/other`)
+
+	authConfig.Spec.Priority = 2
+	assert.NilError(t, validator.ValidateCreate(context.TODO(), authConfig))
+}
+
+func TestValidateAuthConfigIdentityProviderRefs(t *testing.T) {
+	signingKey := &k8score.Secret{ObjectMeta: metav1.ObjectMeta{Name: "signing-key", Namespace: "authorino"}}
+	identityProvider := &IdentityProvider{ObjectMeta: metav1.ObjectMeta{Name: "keycloak"}, Spec: IdentityProviderSpec{IssuerUrl: "http://keycloak/realms/kuadrant"}}
+	validator := newTestAuthConfigValidator(identityProvider, signingKey)
+
+	authConfig := newTestAuthConfigForValidation("talker-api", "talker-api.io")
+	authConfig.Spec.Authentication["jwt"] = AuthenticationSpec{
+		AuthenticationMethodSpec: AuthenticationMethodSpec{Jwt: &JwtAuthenticationSpec{IdentityProviderRef: &IdentityProviderReference{Name: "keycloak"}}},
+	}
+	assert.NilError(t, validator.ValidateCreate(context.TODO(), authConfig))
+
+	authConfig.Spec.Authentication["opaque"] = AuthenticationSpec{
+		AuthenticationMethodSpec: AuthenticationMethodSpec{OAuth2TokenIntrospection: &OAuth2TokenIntrospectionSpec{IdentityProviderRef: &IdentityProviderReference{Name: "keycloak"}}},
+	}
+	authConfig.Spec.Authentication["unknown-idp"] = AuthenticationSpec{
+		AuthenticationMethodSpec: AuthenticationMethodSpec{Jwt: &JwtAuthenticationSpec{IdentityProviderRef: &IdentityProviderReference{Name: "other"}}},
+	}
+	err := validator.ValidateCreate(context.TODO(), authConfig)
+	assert.Error(t, err, `AuthConfig.authorino.kuadrant.io "talker-api" is invalid: [`+
+		`spec.authentication[opaque].oauth2Introspection.identityProviderRef: Invalid value: "keycloak": identity provider has no token introspection settings, `+
+		`spec.authentication[unknown-idp].jwt.identityProviderRef: Not found: "other"]`)
+
+	delete(authConfig.Spec.Authentication, "unknown-idp")
+	authConfig.Spec.Authentication["opaque"] = AuthenticationSpec{
+		AuthenticationMethodSpec: AuthenticationMethodSpec{OAuth2TokenIntrospection: &OAuth2TokenIntrospectionSpec{Url: "http://keycloak/introspect"}},
+	}
+	err = validator.ValidateCreate(context.TODO(), authConfig)
+	assert.Error(t, err, `AuthConfig.authorino.kuadrant.io "talker-api" is invalid: spec.authentication[opaque].oauth2Introspection: Required value: endpoint and credentialsRef, or identityProviderRef`)
+}
+
+func TestValidateAuthConfigMultiTenantIssuerUrl(t *testing.T) {
+	signingKey := &k8score.Secret{ObjectMeta: metav1.ObjectMeta{Name: "signing-key", Namespace: "authorino"}}
+	validator := newTestAuthConfigValidator(signingKey)
+
+	authConfig := newTestAuthConfigForValidation("talker-api", "talker-api.io")
+	authConfig.Spec.Authentication["entra"] = AuthenticationSpec{
+		AuthenticationMethodSpec: AuthenticationMethodSpec{Jwt: &JwtAuthenticationSpec{IssuerUrl: "https://login.microsoftonline.com/{tid}/v2.0", Tenants: []string{"9188040d-6c67-4c5b-b112-36a304b66dad"}}},
+	}
+	assert.NilError(t, validator.ValidateCreate(context.TODO(), authConfig))
+
+	authConfig.Spec.Authentication["entra"].Jwt.Tenants = nil
+	authConfig.Spec.Authentication["keycloak"] = AuthenticationSpec{
+		AuthenticationMethodSpec: AuthenticationMethodSpec{Jwt: &JwtAuthenticationSpec{IssuerUrl: "http://keycloak/realms/kuadrant", Tenants: []string{"kuadrant"}}},
+	}
+	err := validator.ValidateCreate(context.TODO(), authConfig)
+	assert.Error(t, err, `AuthConfig.authorino.kuadrant.io "talker-api" is invalid: [`+
+		`spec.authentication[entra].jwt.tenants: Required value: allowlist of tenants of the issuer url template, `+
+		`spec.authentication[keycloak].jwt.issuerUrl: Invalid value: "http://keycloak/realms/kuadrant": tenants require an issuer url template]`)
+}
+
+func TestValidateAuthConfigExternalSecretRefs(t *testing.T) {
+	validator := newTestAuthConfigValidator()
+
+	// secrets read from vault or from the file system are not looked up in the cluster
+	authConfig := newTestAuthConfigForValidation("talker-api", "talker-api.io")
+	authConfig.Spec.Response.Success.Headers["x-signature"].Signature.SigningKeyRef.Name = "vault:secret/data/signing-key"
+	assert.NilError(t, validator.ValidateCreate(context.TODO(), authConfig))
+
+	authConfig.Spec.Response.Success.Headers["x-signature"].Signature.SigningKeyRef.Name = "file:/mnt/secrets/signing-key"
+	assert.NilError(t, validator.ValidateCreate(context.TODO(), authConfig))
+}
+
+func TestValidateAuthConfigApiKeySource(t *testing.T) {
+	signingKey := &k8score.Secret{ObjectMeta: metav1.ObjectMeta{Name: "signing-key", Namespace: "authorino"}}
+	validator := newTestAuthConfigValidator(signingKey)
+
+	authConfig := newTestAuthConfigForValidation("talker-api", "talker-api.io")
+	authConfig.Spec.Authentication["vault-api-keys"] = AuthenticationSpec{
+		AuthenticationMethodSpec: AuthenticationMethodSpec{ApiKey: &ApiKeyAuthenticationSpec{VaultPath: "secret/data/talker-api/api-keys"}},
+	}
+	assert.NilError(t, validator.ValidateCreate(context.TODO(), authConfig))
+
+	authConfig.Spec.Authentication["vault-api-keys"].ApiKey.VaultPath = ""
+	err := validator.ValidateCreate(context.TODO(), authConfig)
+	assert.Error(t, err, `AuthConfig.authorino.kuadrant.io "talker-api" is invalid: spec.authentication[vault-api-keys].apiKey: Required value: selector or vaultPath`)
+}
+
+func TestValidateAuthConfigKMSSigningKeys(t *testing.T) {
+	signingKey := &k8score.Secret{ObjectMeta: metav1.ObjectMeta{Name: "signing-key", Namespace: "authorino"}}
+	kmsCredentials := &k8score.Secret{ObjectMeta: metav1.ObjectMeta{Name: "kms-credentials", Namespace: "authorino"}}
+	validator := newTestAuthConfigValidator(signingKey, kmsCredentials)
+
+	authConfig := newTestAuthConfigForValidation("talker-api", "talker-api.io")
+	signingKeyRef := &WristbandSigningKeyRef{
+		Name:      "wristband-key",
+		Algorithm: "ES256",
+		KMS: &WristbandKMSSigningKey{
+			Provider:       "gcp",
+			KeyId:          "projects/talker-api/locations/global/keyRings/authorino/cryptoKeys/wristband",
+			CredentialsRef: &k8score.LocalObjectReference{Name: "kms-credentials"},
+		},
+	}
+	authConfig.Spec.Response.Success.DynamicMetadata = map[string]SuccessResponseSpec{
+		"wristband": {
+			AuthResponseMethodSpec: AuthResponseMethodSpec{
+				Wristband: &WristbandAuthResponseSpec{Issuer: "https://authorino/talker-api/wristband", SigningKeyRefs: []*WristbandSigningKeyRef{signingKeyRef}},
+			},
+		},
+	}
+	assert.NilError(t, validator.ValidateCreate(context.TODO(), authConfig))
+
+	signingKeyRef.Algorithm = "HS256"
+	err := validator.ValidateCreate(context.TODO(), authConfig)
+	assert.Error(t, err, `AuthConfig.authorino.kuadrant.io "talker-api" is invalid: spec.response.success.dynamicMetadata[wristband].wristband.signingKeyRefs[0].algorithm: Invalid value: "HS256": kms signing keys support ES* and RS* algorithms only`)
+
+	signingKeyRef.Algorithm = "ES256"
+	signingKeyRef.KMS.CredentialsRef.Name = "missing"
+	err = validator.ValidateCreate(context.TODO(), authConfig)
+	assert.Error(t, err, `AuthConfig.authorino.kuadrant.io "talker-api" is invalid: spec.response.success.dynamicMetadata[wristband].wristband.signingKeyRefs[0].kms.credentialsRef: Not found: "missing"`)
+}
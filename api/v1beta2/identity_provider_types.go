@@ -0,0 +1,71 @@
+package v1beta2
+
+import (
+	k8score "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// IdentityProviderSpec defines the settings of an identity provider that can be shared among AuthConfigs
+type IdentityProviderSpec struct {
+	// URL of the issuer of the JWTs.
+	// Authorino will append the path to the OpenID Connect Well-Known Discovery endpoint
+	// (i.e. "/.well-known/openid-configuration") to this URL, to discover the OIDC configuration where to obtain
+	// the "jkws_uri" claim from.
+	// The value must coincide with the value of  the "iss" (issuer) claim of the discovered OpenID Connect configuration.
+	// +optional
+	IssuerUrl string `json:"issuerUrl,omitempty"`
+
+	// Decides how long to wait before refreshing the JWKS (in seconds).
+	// If omitted, Authorino will never refresh the JWKS.
+	// +optional
+	TTL int `json:"ttl,omitempty"`
+
+	// Settings to perform OAuth2 token introspection requests to the identity provider.
+	// +optional
+	TokenIntrospection *IdentityProviderTokenIntrospectionSpec `json:"tokenIntrospection,omitempty"`
+}
+
+// Settings to perform OAuth2 token introspection requests to an identity provider.
+type IdentityProviderTokenIntrospectionSpec struct {
+	// The full URL of the token introspection endpoint.
+	Url string `json:"endpoint"`
+
+	// The token type hint for the token introspection.
+	// If omitted, it defaults to "access_token".
+	// +optional
+	TokenTypeHint string `json:"tokenTypeHint,omitempty"`
+
+	// Reference to a Kubernetes secret that stores client credentials to the OAuth2 server.
+	Credentials *k8score.SecretReference `json:"credentialsRef"`
+}
+
+// Reference by name to an IdentityProvider.
+type IdentityProviderReference struct {
+	// Name of the IdentityProvider.
+	Name string `json:"name"`
+}
+
+// IdentityProvider is the schema for Authorino's IdentityProvider API.
+// Settings of an identity provider (issuer, token introspection endpoint, credentials) shared by all the AuthConfigs
+// that refer to it by name.
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Issuer",type=string,JSONPath=`.spec.issuerUrl`,description="URL of the issuer"
+type IdentityProvider struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec IdentityProviderSpec `json:"spec,omitempty"`
+}
+
+// IdentityProviderList contains a list of IdentityProvider
+// +kubebuilder:object:root=true
+type IdentityProviderList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []IdentityProvider `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&IdentityProvider{}, &IdentityProviderList{})
+}
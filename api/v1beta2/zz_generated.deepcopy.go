@@ -89,6 +89,91 @@ func (in *AuthConfig) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuthConfigDefaults) DeepCopyInto(out *AuthConfigDefaults) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuthConfigDefaults.
+func (in *AuthConfigDefaults) DeepCopy() *AuthConfigDefaults {
+	if in == nil {
+		return nil
+	}
+	out := new(AuthConfigDefaults)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AuthConfigDefaults) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuthConfigDefaultsList) DeepCopyInto(out *AuthConfigDefaultsList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]AuthConfigDefaults, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuthConfigDefaultsList.
+func (in *AuthConfigDefaultsList) DeepCopy() *AuthConfigDefaultsList {
+	if in == nil {
+		return nil
+	}
+	out := new(AuthConfigDefaultsList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AuthConfigDefaultsList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuthConfigDefaultsSpec) DeepCopyInto(out *AuthConfigDefaultsSpec) {
+	*out = *in
+	if in.Authentication != nil {
+		in, out := &in.Authentication, &out.Authentication
+		*out = make(map[string]AuthenticationSpec, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.Response != nil {
+		in, out := &in.Response, &out.Response
+		*out = new(ResponseSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuthConfigDefaultsSpec.
+func (in *AuthConfigDefaultsSpec) DeepCopy() *AuthConfigDefaultsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AuthConfigDefaultsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AuthConfigList) DeepCopyInto(out *AuthConfigList) {
 	*out = *in
@@ -121,6 +206,90 @@ func (in *AuthConfigList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuthConfigOverlay) DeepCopyInto(out *AuthConfigOverlay) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuthConfigOverlay.
+func (in *AuthConfigOverlay) DeepCopy() *AuthConfigOverlay {
+	if in == nil {
+		return nil
+	}
+	out := new(AuthConfigOverlay)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AuthConfigOverlay) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuthConfigOverlayList) DeepCopyInto(out *AuthConfigOverlayList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]AuthConfigOverlay, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuthConfigOverlayList.
+func (in *AuthConfigOverlayList) DeepCopy() *AuthConfigOverlayList {
+	if in == nil {
+		return nil
+	}
+	out := new(AuthConfigOverlayList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AuthConfigOverlayList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuthConfigOverlaySpec) DeepCopyInto(out *AuthConfigOverlaySpec) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.Patch.DeepCopyInto(&out.Patch)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuthConfigOverlaySpec.
+func (in *AuthConfigOverlaySpec) DeepCopy() *AuthConfigOverlaySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AuthConfigOverlaySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AuthConfigSpec) DeepCopyInto(out *AuthConfigSpec) {
 	*out = *in
@@ -298,6 +467,11 @@ func (in *AuthResponseMethodSpec) DeepCopyInto(out *AuthResponseMethodSpec) {
 		*out = new(WristbandAuthResponseSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Signature != nil {
+		in, out := &in.Signature, &out.Signature
+		*out = new(SignatureAuthResponseSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuthResponseMethodSpec.
@@ -321,7 +495,7 @@ func (in *AuthenticationMethodSpec) DeepCopyInto(out *AuthenticationMethodSpec)
 	if in.Jwt != nil {
 		in, out := &in.Jwt, &out.Jwt
 		*out = new(JwtAuthenticationSpec)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.OAuth2TokenIntrospection != nil {
 		in, out := &in.OAuth2TokenIntrospection, &out.OAuth2TokenIntrospection
@@ -338,6 +512,31 @@ func (in *AuthenticationMethodSpec) DeepCopyInto(out *AuthenticationMethodSpec)
 		*out = new(X509ClientCertificateAuthenticationSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Spiffe != nil {
+		in, out := &in.Spiffe, &out.Spiffe
+		*out = new(SpiffeAuthenticationSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AwsSigV4 != nil {
+		in, out := &in.AwsSigV4, &out.AwsSigV4
+		*out = new(AwsSigV4AuthenticationSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GoogleIDToken != nil {
+		in, out := &in.GoogleIDToken, &out.GoogleIDToken
+		*out = new(GoogleIDTokenAuthenticationSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GitToken != nil {
+		in, out := &in.GitToken, &out.GitToken
+		*out = new(GitTokenAuthenticationSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.VaultTransit != nil {
+		in, out := &in.VaultTransit, &out.VaultTransit
+		*out = new(VaultTransitAuthenticationSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Plain != nil {
 		in, out := &in.Plain, &out.Plain
 		*out = new(PlainIdentitySpec)
@@ -415,6 +614,31 @@ func (in *AuthorizationMethodSpec) DeepCopyInto(out *AuthorizationMethodSpec) {
 		*out = new(SpiceDBAuthorizationSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Quota != nil {
+		in, out := &in.Quota, &out.Quota
+		*out = new(QuotaAuthorizationSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Rbac != nil {
+		in, out := &in.Rbac, &out.Rbac
+		*out = new(RbacAuthorizationSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RequiredScopes != nil {
+		in, out := &in.RequiredScopes, &out.RequiredScopes
+		*out = new(RequiredScopesAuthorizationSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Wasm != nil {
+		in, out := &in.Wasm, &out.Wasm
+		*out = new(WasmAuthorizationSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Lua != nil {
+		in, out := &in.Lua, &out.Lua
+		*out = new(LuaAuthorizationSpec)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuthorizationMethodSpec.
@@ -432,6 +656,13 @@ func (in *AuthorizationSpec) DeepCopyInto(out *AuthorizationSpec) {
 	*out = *in
 	in.CommonEvaluatorSpec.DeepCopyInto(&out.CommonEvaluatorSpec)
 	in.AuthorizationMethodSpec.DeepCopyInto(&out.AuthorizationMethodSpec)
+	if in.Exports != nil {
+		in, out := &in.Exports, &out.Exports
+		*out = make(NamedValuesOrSelectors, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuthorizationSpec.
@@ -444,6 +675,51 @@ func (in *AuthorizationSpec) DeepCopy() *AuthorizationSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AwsSigV4AuthenticationSpec) DeepCopyInto(out *AwsSigV4AuthenticationSpec) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Sts != nil {
+		in, out := &in.Sts, &out.Sts
+		*out = new(AwsStsSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AwsSigV4AuthenticationSpec.
+func (in *AwsSigV4AuthenticationSpec) DeepCopy() *AwsSigV4AuthenticationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AwsSigV4AuthenticationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AwsStsSpec) DeepCopyInto(out *AwsStsSpec) {
+	*out = *in
+	if in.Regions != nil {
+		in, out := &in.Regions, &out.Regions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AwsStsSpec.
+func (in *AwsStsSpec) DeepCopy() *AwsStsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AwsStsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CallbackMethodSpec) DeepCopyInto(out *CallbackMethodSpec) {
 	*out = *in
@@ -452,6 +728,11 @@ func (in *CallbackMethodSpec) DeepCopyInto(out *CallbackMethodSpec) {
 		*out = new(HttpEndpointSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Kafka != nil {
+		in, out := &in.Kafka, &out.Kafka
+		*out = new(KafkaCallbackSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CallbackMethodSpec.
@@ -468,6 +749,11 @@ func (in *CallbackMethodSpec) DeepCopy() *CallbackMethodSpec {
 func (in *CallbackSpec) DeepCopyInto(out *CallbackSpec) {
 	*out = *in
 	in.CommonEvaluatorSpec.DeepCopyInto(&out.CommonEvaluatorSpec)
+	if in.On != nil {
+		in, out := &in.On, &out.On
+		*out = make([]CallbackEvent, len(*in))
+		copy(*out, *in)
+	}
 	in.CallbackMethodSpec.DeepCopyInto(&out.CallbackMethodSpec)
 }
 
@@ -481,6 +767,21 @@ func (in *CallbackSpec) DeepCopy() *CallbackSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudEventsSpec) DeepCopyInto(out *CloudEventsSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudEventsSpec.
+func (in *CloudEventsSpec) DeepCopy() *CloudEventsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudEventsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CommonEvaluatorSpec) DeepCopyInto(out *CommonEvaluatorSpec) {
 	*out = *in
@@ -508,6 +809,53 @@ func (in *CommonEvaluatorSpec) DeepCopy() *CommonEvaluatorSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigMapKeyReference) DeepCopyInto(out *ConfigMapKeyReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigMapKeyReference.
+func (in *ConfigMapKeyReference) DeepCopy() *ConfigMapKeyReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigMapKeyReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CookieAttributes) DeepCopyInto(out *CookieAttributes) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CookieAttributes.
+func (in *CookieAttributes) DeepCopy() *CookieAttributes {
+	if in == nil {
+		return nil
+	}
+	out := new(CookieAttributes)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CookieSuccessResponseSpec) DeepCopyInto(out *CookieSuccessResponseSpec) {
+	*out = *in
+	in.SuccessResponseSpec.DeepCopyInto(&out.SuccessResponseSpec)
+	out.CookieAttributes = in.CookieAttributes
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CookieSuccessResponseSpec.
+func (in *CookieSuccessResponseSpec) DeepCopy() *CookieSuccessResponseSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CookieSuccessResponseSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Credentials) DeepCopyInto(out *Credentials) {
 	*out = *in
@@ -531,6 +879,16 @@ func (in *Credentials) DeepCopyInto(out *Credentials) {
 		*out = new(Named)
 		**out = **in
 	}
+	if in.WebSocketProtocol != nil {
+		in, out := &in.WebSocketProtocol, &out.WebSocketProtocol
+		*out = new(Prefixed)
+		**out = **in
+	}
+	if in.GRPCMetadata != nil {
+		in, out := &in.GRPCMetadata, &out.GRPCMetadata
+		*out = new(Named)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Credentials.
@@ -579,6 +937,16 @@ func (in *DenyWithSpec) DeepCopyInto(out *DenyWithSpec) {
 		*out = new(ValueOrSelector)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.RedirectTo != nil {
+		in, out := &in.RedirectTo, &out.RedirectTo
+		*out = new(ValueOrSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ProblemDetails != nil {
+		in, out := &in.ProblemDetails, &out.ProblemDetails
+		*out = new(ProblemDetailsSpec)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DenyWithSpec.
@@ -648,10 +1016,101 @@ func (in *ExternalOpaPolicy) DeepCopy() *ExternalOpaPolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitTokenAuthenticationSpec) DeepCopyInto(out *GitTokenAuthenticationSpec) {
+	*out = *in
+	if in.Audiences != nil {
+		in, out := &in.Audiences, &out.Audiences
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitTokenAuthenticationSpec.
+func (in *GitTokenAuthenticationSpec) DeepCopy() *GitTokenAuthenticationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GitTokenAuthenticationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GoogleIDTokenAuthenticationSpec) DeepCopyInto(out *GoogleIDTokenAuthenticationSpec) {
+	*out = *in
+	if in.Audiences != nil {
+		in, out := &in.Audiences, &out.Audiences
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Emails != nil {
+		in, out := &in.Emails, &out.Emails
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Domains != nil {
+		in, out := &in.Domains, &out.Domains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GoogleIDTokenAuthenticationSpec.
+func (in *GoogleIDTokenAuthenticationSpec) DeepCopy() *GoogleIDTokenAuthenticationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GoogleIDTokenAuthenticationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HeaderCompressionSpec) DeepCopyInto(out *HeaderCompressionSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HeaderCompressionSpec.
+func (in *HeaderCompressionSpec) DeepCopy() *HeaderCompressionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HeaderCompressionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HeaderEncryptionSpec) DeepCopyInto(out *HeaderEncryptionSpec) {
+	*out = *in
+	out.RecipientKeyRef = in.RecipientKeyRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HeaderEncryptionSpec.
+func (in *HeaderEncryptionSpec) DeepCopy() *HeaderEncryptionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HeaderEncryptionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HeaderSuccessResponseSpec) DeepCopyInto(out *HeaderSuccessResponseSpec) {
 	*out = *in
 	in.SuccessResponseSpec.DeepCopyInto(&out.SuccessResponseSpec)
+	if in.Encryption != nil {
+		in, out := &in.Encryption, &out.Encryption
+		*out = new(HeaderEncryptionSpec)
+		**out = **in
+	}
+	if in.Compression != nil {
+		in, out := &in.Compression, &out.Compression
+		*out = new(HeaderCompressionSpec)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HeaderSuccessResponseSpec.
@@ -696,20 +1155,133 @@ func (in *HttpEndpointSpec) DeepCopyInto(out *HttpEndpointSpec) {
 		*out = new(SecretKeyReference)
 		**out = **in
 	}
-	if in.OAuth2 != nil {
-		in, out := &in.OAuth2, &out.OAuth2
-		*out = new(OAuth2ClientAuthentication)
-		(*in).DeepCopyInto(*out)
-	}
-	in.Credentials.DeepCopyInto(&out.Credentials)
+	if in.OAuth2 != nil {
+		in, out := &in.OAuth2, &out.OAuth2
+		*out = new(OAuth2ClientAuthentication)
+		(*in).DeepCopyInto(*out)
+	}
+	in.Credentials.DeepCopyInto(&out.Credentials)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HttpEndpointSpec.
+func (in *HttpEndpointSpec) DeepCopy() *HttpEndpointSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HttpEndpointSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IdentityProvider) DeepCopyInto(out *IdentityProvider) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IdentityProvider.
+func (in *IdentityProvider) DeepCopy() *IdentityProvider {
+	if in == nil {
+		return nil
+	}
+	out := new(IdentityProvider)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *IdentityProvider) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IdentityProviderList) DeepCopyInto(out *IdentityProviderList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]IdentityProvider, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IdentityProviderList.
+func (in *IdentityProviderList) DeepCopy() *IdentityProviderList {
+	if in == nil {
+		return nil
+	}
+	out := new(IdentityProviderList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *IdentityProviderList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IdentityProviderReference) DeepCopyInto(out *IdentityProviderReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IdentityProviderReference.
+func (in *IdentityProviderReference) DeepCopy() *IdentityProviderReference {
+	if in == nil {
+		return nil
+	}
+	out := new(IdentityProviderReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IdentityProviderSpec) DeepCopyInto(out *IdentityProviderSpec) {
+	*out = *in
+	if in.TokenIntrospection != nil {
+		in, out := &in.TokenIntrospection, &out.TokenIntrospection
+		*out = new(IdentityProviderTokenIntrospectionSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IdentityProviderSpec.
+func (in *IdentityProviderSpec) DeepCopy() *IdentityProviderSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(IdentityProviderSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IdentityProviderTokenIntrospectionSpec) DeepCopyInto(out *IdentityProviderTokenIntrospectionSpec) {
+	*out = *in
+	if in.Credentials != nil {
+		in, out := &in.Credentials, &out.Credentials
+		*out = new(corev1.SecretReference)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HttpEndpointSpec.
-func (in *HttpEndpointSpec) DeepCopy() *HttpEndpointSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IdentityProviderTokenIntrospectionSpec.
+func (in *IdentityProviderTokenIntrospectionSpec) DeepCopy() *IdentityProviderTokenIntrospectionSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(HttpEndpointSpec)
+	out := new(IdentityProviderTokenIntrospectionSpec)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -739,6 +1311,16 @@ func (in *JsonAuthResponseSpec) DeepCopy() *JsonAuthResponseSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *JwtAuthenticationSpec) DeepCopyInto(out *JwtAuthenticationSpec) {
 	*out = *in
+	if in.Tenants != nil {
+		in, out := &in.Tenants, &out.Tenants
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.IdentityProviderRef != nil {
+		in, out := &in.IdentityProviderRef, &out.IdentityProviderRef
+		*out = new(IdentityProviderReference)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JwtAuthenticationSpec.
@@ -751,6 +1333,36 @@ func (in *JwtAuthenticationSpec) DeepCopy() *JwtAuthenticationSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaCallbackSpec) DeepCopyInto(out *KafkaCallbackSpec) {
+	*out = *in
+	if in.Brokers != nil {
+		in, out := &in.Brokers, &out.Brokers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Subject != nil {
+		in, out := &in.Subject, &out.Subject
+		*out = new(ValueOrSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CloudEvents != nil {
+		in, out := &in.CloudEvents, &out.CloudEvents
+		*out = new(CloudEventsSpec)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaCallbackSpec.
+func (in *KafkaCallbackSpec) DeepCopy() *KafkaCallbackSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaCallbackSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KubernetesSubjectAccessReviewAuthorizationSpec) DeepCopyInto(out *KubernetesSubjectAccessReviewAuthorizationSpec) {
 	*out = *in
@@ -822,6 +1434,21 @@ func (in *KubernetesTokenReviewSpec) DeepCopy() *KubernetesTokenReviewSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LuaAuthorizationSpec) DeepCopyInto(out *LuaAuthorizationSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LuaAuthorizationSpec.
+func (in *LuaAuthorizationSpec) DeepCopy() *LuaAuthorizationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LuaAuthorizationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MetadataMethodSpec) DeepCopyInto(out *MetadataMethodSpec) {
 	*out = *in
@@ -946,6 +1573,11 @@ func (in *OAuth2TokenIntrospectionSpec) DeepCopyInto(out *OAuth2TokenIntrospecti
 		*out = new(corev1.LocalObjectReference)
 		**out = **in
 	}
+	if in.IdentityProviderRef != nil {
+		in, out := &in.IdentityProviderRef, &out.IdentityProviderRef
+		*out = new(IdentityProviderReference)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OAuth2TokenIntrospectionSpec.
@@ -1126,6 +1758,159 @@ func (in *Prefixed) DeepCopy() *Prefixed {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProblemDetailsSpec) DeepCopyInto(out *ProblemDetailsSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProblemDetailsSpec.
+func (in *ProblemDetailsSpec) DeepCopy() *ProblemDetailsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ProblemDetailsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuotaAuthorizationSpec) DeepCopyInto(out *QuotaAuthorizationSpec) {
+	*out = *in
+	in.Key.DeepCopyInto(&out.Key)
+	if in.Redis != nil {
+		in, out := &in.Redis, &out.Redis
+		*out = new(QuotaRedisStorageSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaAuthorizationSpec.
+func (in *QuotaAuthorizationSpec) DeepCopy() *QuotaAuthorizationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(QuotaAuthorizationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuotaRedisStorageSpec) DeepCopyInto(out *QuotaRedisStorageSpec) {
+	*out = *in
+	if in.Password != nil {
+		in, out := &in.Password, &out.Password
+		*out = new(SecretKeyReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaRedisStorageSpec.
+func (in *QuotaRedisStorageSpec) DeepCopy() *QuotaRedisStorageSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(QuotaRedisStorageSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RbacAuthorizationSpec) DeepCopyInto(out *RbacAuthorizationSpec) {
+	*out = *in
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]RbacRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ConfigMap != nil {
+		in, out := &in.ConfigMap, &out.ConfigMap
+		*out = new(RbacConfigMapReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RbacAuthorizationSpec.
+func (in *RbacAuthorizationSpec) DeepCopy() *RbacAuthorizationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RbacAuthorizationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RbacConfigMapReference) DeepCopyInto(out *RbacConfigMapReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RbacConfigMapReference.
+func (in *RbacConfigMapReference) DeepCopy() *RbacConfigMapReference {
+	if in == nil {
+		return nil
+	}
+	out := new(RbacConfigMapReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RbacRule) DeepCopyInto(out *RbacRule) {
+	*out = *in
+	if in.Roles != nil {
+		in, out := &in.Roles, &out.Roles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Methods != nil {
+		in, out := &in.Methods, &out.Methods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Paths != nil {
+		in, out := &in.Paths, &out.Paths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RbacRule.
+func (in *RbacRule) DeepCopy() *RbacRule {
+	if in == nil {
+		return nil
+	}
+	out := new(RbacRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RequiredScopesAuthorizationSpec) DeepCopyInto(out *RequiredScopesAuthorizationSpec) {
+	*out = *in
+	if in.AllOf != nil {
+		in, out := &in.AllOf, &out.AllOf
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AnyOf != nil {
+		in, out := &in.AnyOf, &out.AnyOf
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RequiredScopesAuthorizationSpec.
+func (in *RequiredScopesAuthorizationSpec) DeepCopy() *RequiredScopesAuthorizationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RequiredScopesAuthorizationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResponseSpec) DeepCopyInto(out *ResponseSpec) {
 	*out = *in
@@ -1167,6 +1952,27 @@ func (in *SecretKeyReference) DeepCopy() *SecretKeyReference {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SignatureAuthResponseSpec) DeepCopyInto(out *SignatureAuthResponseSpec) {
+	*out = *in
+	out.SigningKeyRef = in.SigningKeyRef
+	if in.Attributes != nil {
+		in, out := &in.Attributes, &out.Attributes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SignatureAuthResponseSpec.
+func (in *SignatureAuthResponseSpec) DeepCopy() *SignatureAuthResponseSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SignatureAuthResponseSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SpiceDBAuthorizationSpec) DeepCopyInto(out *SpiceDBAuthorizationSpec) {
 	*out = *in
@@ -1215,6 +2021,26 @@ func (in *SpiceDBObject) DeepCopy() *SpiceDBObject {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpiffeAuthenticationSpec) DeepCopyInto(out *SpiffeAuthenticationSpec) {
+	*out = *in
+	if in.Audiences != nil {
+		in, out := &in.Audiences, &out.Audiences
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpiffeAuthenticationSpec.
+func (in *SpiffeAuthenticationSpec) DeepCopy() *SpiffeAuthenticationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SpiffeAuthenticationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SuccessResponseSpec) DeepCopyInto(out *SuccessResponseSpec) {
 	*out = *in
@@ -1299,6 +2125,46 @@ func (in *ValueOrSelector) DeepCopy() *ValueOrSelector {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultTransitAuthenticationSpec) DeepCopyInto(out *VaultTransitAuthenticationSpec) {
+	*out = *in
+	if in.Audiences != nil {
+		in, out := &in.Audiences, &out.Audiences
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultTransitAuthenticationSpec.
+func (in *VaultTransitAuthenticationSpec) DeepCopy() *VaultTransitAuthenticationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultTransitAuthenticationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WasmAuthorizationSpec) DeepCopyInto(out *WasmAuthorizationSpec) {
+	*out = *in
+	if in.ConfigMap != nil {
+		in, out := &in.ConfigMap, &out.ConfigMap
+		*out = new(ConfigMapKeyReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WasmAuthorizationSpec.
+func (in *WasmAuthorizationSpec) DeepCopy() *WasmAuthorizationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WasmAuthorizationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WrappedSuccessResponseSpec) DeepCopyInto(out *WrappedSuccessResponseSpec) {
 	*out = *in
@@ -1309,6 +2175,13 @@ func (in *WrappedSuccessResponseSpec) DeepCopyInto(out *WrappedSuccessResponseSp
 			(*out)[key] = *val.DeepCopy()
 		}
 	}
+	if in.ResponseHeaders != nil {
+		in, out := &in.ResponseHeaders, &out.ResponseHeaders
+		*out = make(map[string]HeaderSuccessResponseSpec, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
 	if in.DynamicMetadata != nil {
 		in, out := &in.DynamicMetadata, &out.DynamicMetadata
 		*out = make(map[string]SuccessResponseSpec, len(*in))
@@ -1316,6 +2189,32 @@ func (in *WrappedSuccessResponseSpec) DeepCopyInto(out *WrappedSuccessResponseSp
 			(*out)[key] = *val.DeepCopy()
 		}
 	}
+	if in.Cookies != nil {
+		in, out := &in.Cookies, &out.Cookies
+		*out = make(map[string]CookieSuccessResponseSpec, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.GrpcMetadata != nil {
+		in, out := &in.GrpcMetadata, &out.GrpcMetadata
+		*out = make(map[string]SuccessResponseSpec, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.QueryParameters != nil {
+		in, out := &in.QueryParameters, &out.QueryParameters
+		*out = make(map[string]SuccessResponseSpec, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.QueryParametersToRemove != nil {
+		in, out := &in.QueryParametersToRemove, &out.QueryParametersToRemove
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WrappedSuccessResponseSpec.
@@ -1350,7 +2249,7 @@ func (in *WristbandAuthResponseSpec) DeepCopyInto(out *WristbandAuthResponseSpec
 			if (*in)[i] != nil {
 				in, out := &(*in)[i], &(*out)[i]
 				*out = new(WristbandSigningKeyRef)
-				**out = **in
+				(*in).DeepCopyInto(*out)
 			}
 		}
 	}
@@ -1366,9 +2265,34 @@ func (in *WristbandAuthResponseSpec) DeepCopy() *WristbandAuthResponseSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WristbandKMSSigningKey) DeepCopyInto(out *WristbandKMSSigningKey) {
+	*out = *in
+	if in.CredentialsRef != nil {
+		in, out := &in.CredentialsRef, &out.CredentialsRef
+		*out = new(corev1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WristbandKMSSigningKey.
+func (in *WristbandKMSSigningKey) DeepCopy() *WristbandKMSSigningKey {
+	if in == nil {
+		return nil
+	}
+	out := new(WristbandKMSSigningKey)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WristbandSigningKeyRef) DeepCopyInto(out *WristbandSigningKeyRef) {
 	*out = *in
+	if in.KMS != nil {
+		in, out := &in.KMS, &out.KMS
+		*out = new(WristbandKMSSigningKey)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WristbandSigningKeyRef.
@@ -18,23 +18,32 @@ package controllers
 
 import (
 	"context"
+	goerrors "errors"
 	"fmt"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	api "github.com/kuadrant/authorino/api/v1beta1"
+	"github.com/kuadrant/authorino/api/v1beta2"
 	"github.com/kuadrant/authorino/pkg/auth"
 	"github.com/kuadrant/authorino/pkg/evaluators"
 	authorization_evaluators "github.com/kuadrant/authorino/pkg/evaluators/authorization"
+	callback_evaluators "github.com/kuadrant/authorino/pkg/evaluators/callbacks"
 	identity_evaluators "github.com/kuadrant/authorino/pkg/evaluators/identity"
 	metadata_evaluators "github.com/kuadrant/authorino/pkg/evaluators/metadata"
 	response_evaluators "github.com/kuadrant/authorino/pkg/evaluators/response"
 	"github.com/kuadrant/authorino/pkg/index"
 	"github.com/kuadrant/authorino/pkg/json"
 	"github.com/kuadrant/authorino/pkg/jsonexp"
+	"github.com/kuadrant/authorino/pkg/kms"
 	"github.com/kuadrant/authorino/pkg/log"
+	"github.com/kuadrant/authorino/pkg/metrics"
 	"github.com/kuadrant/authorino/pkg/oauth2"
 	"github.com/kuadrant/authorino/pkg/utils"
+	"github.com/kuadrant/authorino/pkg/vault"
 
 	"github.com/go-logr/logr"
 	"gopkg.in/square/go-jose.v2"
@@ -44,15 +53,24 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 const (
 	failedToCleanConfig = "failed to clean up all asynchronous workers"
 
 	AuthConfigsReadyzSubpath = "authconfigs"
+
+	// ConfigDrainTimeout is how long a config replaced or removed from the index waits at most for the requests in
+	// flight evaluated with it to finish before it is cleaned
+	ConfigDrainTimeout = time.Minute
 )
 
 // AuthConfigReconciler reconciles an AuthConfig object
@@ -65,13 +83,46 @@ type AuthConfigReconciler struct {
 	StatusReport                *StatusReportMap
 	LabelSelector               labels.Selector
 	Namespace                   string
-
-	indexBootstrap sync.Mutex
+	Recorder                    record.EventRecorder
+	Revisions                   *RevisionHistory
+	IdentityProviders           bool
+	Overlays                    bool
+	Defaults                    bool
+	DependencyHealth            *DependencyHealthChecker
+	Snapshot                    *IndexSnapshot
+	Vault                       *vault.Client
+	VaultRefreshInterval        time.Duration
+	SecretFiles                 *SecretFiles
+
+	indexBootstrap  sync.Mutex
+	warmResourceIds map[string]struct{}
+	requeue         chan event.GenericEvent
+	effective       map[string]api.AuthConfig
+	effectiveMu     sync.RWMutex
+	purgeRequests   map[string]string
+	purgeRequestsMu sync.Mutex
 }
 
 // +kubebuilder:rbac:groups=authorino.kuadrant.io,resources=authconfigs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=authorino.kuadrant.io,resources=identityproviders,verbs=get;list;watch
+// +kubebuilder:rbac:groups=authorino.kuadrant.io,resources=authconfigoverlays,verbs=get;list;watch
+// +kubebuilder:rbac:groups=authorino.kuadrant.io,resources=authconfigdefaults,verbs=get;list;watch
+
+func (r *AuthConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	metrics.ReportTimedMetric(reconcileDurationMetric, func() {
+		result, err = r.reconcile(ctx, req)
+	}, req.Namespace, req.Name)
+
+	if err != nil {
+		metrics.ReportMetric(reconcileErrorsMetric, req.Namespace, req.Name)
+	}
 
-func (r *AuthConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	return
+}
+
+func (r *AuthConfigReconciler) reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	if err := r.bootstrapIndex(ctx); err != nil {
 		r.Logger.Error(err, "failed to bootstrap the index")
 	}
@@ -79,53 +130,147 @@ func (r *AuthConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	resourceId := req.String()
 	logger := r.Logger.WithValues("authconfig", resourceId)
 	reportReconciled := true
+	reconciledMessage := ""
 
 	r.StatusReport.Set(resourceId, api.StatusReasonReconciling, "", []string{})
 
-	var linkedHosts, looseHosts []string
+	// records the leases of the vault secrets read to build the config, to read them again before they expire
+	ctx = withVaultLeases(ctx)
+	// records the secret files read to build the config, to build it again when they change
+	ctx = withSecretFileDigests(ctx)
+
+	var linkedHosts []string
+	var looseHosts map[string]string
 
 	authConfig := api.AuthConfig{}
 	if err := r.Get(ctx, req.NamespacedName, &authConfig); err != nil && !errors.IsNotFound(err) {
 		// could not get the resource but not because of a 404 Not found (some error must have happened)
 		return ctrl.Result{}, err
-	} else if errors.IsNotFound(err) || !Watched(&authConfig.ObjectMeta, r.LabelSelector) {
+	} else if errors.IsNotFound(err) || !Watched(&authConfig.ObjectMeta, r.LabelSelector) || !r.InScope(req.Namespace) {
 		// could not find the resource: 404 Not found (resource must have been deleted)
-		// or the resource misses required labels (i.e. not to be watched by this controller)
-
-		// clean all async workers of the config, i.e. shuts down channels and goroutines
-		if err := r.cleanConfigs(resourceId, ctx); err != nil {
-			logger.Error(err, failedToCleanConfig)
-		}
+		// or the resource misses required labels or is out of the watched namespace (i.e. not to be watched by this controller)
+		indexedAuthConfig := r.indexedAuthConfig(resourceId)
 
 		// delete related authconfigs from the index.
 		r.Index.Delete(resourceId)
+
+		// clean all async workers of the config, i.e. shuts down channels and goroutines
+		r.cleanConfigsWhenIdle(indexedAuthConfig, logger)
 		r.StatusReport.Clear(resourceId)
+		if r.Revisions != nil {
+			r.Revisions.Clear(resourceId)
+		}
+		if r.DependencyHealth != nil {
+			r.DependencyHealth.Clear(resourceId)
+		}
+		if r.Snapshot != nil {
+			r.Snapshot.Clear(resourceId)
+		}
+		if r.SecretFiles != nil {
+			r.SecretFiles.Clear(resourceId)
+		}
+		r.clearEffective(resourceId)
+		r.clearPurgeRequests(resourceId)
+		r.reconcileLooseResources(resourceId)
 		reportReconciled = false
 		logger.Info("resource de-indexed")
 	} else {
 		// resource found and it is to be watched by this controller
 		// we need to either create it or update it in the index
 
-		// clean all async workers of the config, i.e. shuts down channels and goroutines
-		if err := r.cleanConfigs(resourceId, ctx); err != nil {
-			logger.Error(err, failedToCleanConfig)
+		// a previous revision of the resource is requested to be indexed instead of the current one
+		rollbackTo, rollback := authConfig.Annotations[RollbackToAnnotation]
+		rollback = rollback && rollbackTo != ""
+		if rollback {
+			revision, err := r.revision(resourceId, rollbackTo)
+			if err != nil {
+				r.StatusReport.Set(resourceId, api.StatusReasonInvalidResource, err.Error(), []string{})
+				logger.Error(err, "failed to roll back")
+				return ctrl.Result{}, nil
+			}
+			authConfig.Spec = revision.Spec
+			authConfig.Generation = revision.Generation
+			reconciledMessage = fmt.Sprintf("rolled back to revision %d", revision.Generation)
+			logger.Info("rolling back", "revision", revision.Generation)
+		}
+
+		// defaults of the namespace not declared in the resource
+		defaultedAuthConfig, defaults, err := r.applyDefaults(ctx, &authConfig)
+		if err != nil {
+			logger.Error(err, "failed to apply defaults")
+			return ctrl.Result{}, err
+		}
+		if len(defaults) > 0 {
+			logger.V(1).Info("defaults applied", "defaults", defaults)
 		}
 
-		translatedAuthConfig, err := r.translateAuthConfig(log.IntoContext(ctx, logger), &authConfig)
+		// patches enforced by the platform on top of the resource
+		effectiveAuthConfig, overlays, err := r.applyOverlays(ctx, defaultedAuthConfig)
 		if err != nil {
 			r.StatusReport.Set(resourceId, api.StatusReasonInvalidResource, err.Error(), []string{})
+			logger.Error(err, "failed to apply overlays")
+			return ctrl.Result{}, nil // the resource is reconciled again when the overlays change
+		}
+		if len(overlays) > 0 {
+			logger.V(1).Info("overlays applied", "overlays", overlays)
+		}
+
+		// the config currently indexed keeps serving requests while the new one is built off to the side
+		indexedAuthConfig := r.indexedAuthConfig(resourceId)
+
+		translatedAuthConfig, err := r.translateAuthConfig(log.IntoContext(ctx, logger), effectiveAuthConfig)
+		if err != nil {
+			reason := api.StatusReasonInvalidResource
+			var policyTestsErr *authorization_evaluators.OPAPolicyTestsError
+			var policyCompilationErr *authorization_evaluators.PolicyCompilationError
+			if goerrors.As(err, &policyTestsErr) {
+				reason = api.StatusReasonPolicyTestsFailed
+			} else if goerrors.As(err, &policyCompilationErr) {
+				reason = api.StatusReasonPolicyCompilationFailed
+			}
+			r.StatusReport.Set(resourceId, reason, err.Error(), []string{})
 			return ctrl.Result{}, err
 		}
 
+		// the current revision of the resource is rolled out to a share of the requests only, while the stable revision
+		// keeps serving the rest
+		var stableAuthConfig *api.AuthConfig
+		if canaryWeight, canary := authConfig.Annotations[CanaryWeightAnnotation]; canary && !rollback {
+			var weight int
+			stableAuthConfig, weight, err = r.stableRevision(resourceId, &authConfig, canaryWeight)
+			if err == nil && stableAuthConfig != nil {
+				var stableConfig *evaluators.AuthConfig
+				if stableConfig, err = r.translateStableRevision(log.IntoContext(ctx, logger), stableAuthConfig); err == nil {
+					stableConfig.Canary = translatedAuthConfig
+					stableConfig.CanaryWeight = weight
+					translatedAuthConfig = stableConfig
+					reconciledMessage = fmt.Sprintf("revision %d serving %d%% of the requests (stable revision %d)", authConfig.Generation, weight, stableAuthConfig.Generation)
+					logger.Info("canary", "revision", authConfig.Generation, "weight", weight, "stable", stableAuthConfig.Generation)
+				}
+			}
+			if err != nil {
+				if err := r.cleanConfigs(translatedAuthConfig, ctx); err != nil {
+					logger.Error(err, failedToCleanConfig)
+				}
+				r.StatusReport.Set(resourceId, api.StatusReasonInvalidResource, err.Error(), []string{})
+				logger.Error(err, "failed to set up canary")
+				return ctrl.Result{}, nil
+			}
+		}
+
 		// delete unused hosts from the index
-		for _, host := range utils.SubtractSlice(r.Index.FindKeys(resourceId), authConfig.Spec.Hosts) {
+		unusedHosts := utils.SubtractSlice(r.Index.FindKeys(resourceId), authConfig.Spec.Hosts)
+		for _, host := range unusedHosts {
 			r.Index.DeleteKey(resourceId, host)
 		}
+		if len(unusedHosts) > 0 {
+			r.reconcileLooseResources(resourceId)
+		}
 
 		linkedHosts, looseHosts, err = r.addToIndex(log.IntoContext(ctx, logger), req.Namespace, resourceId, translatedAuthConfig, authConfig.Spec.Hosts)
 
 		if len(looseHosts) > 0 {
-			r.StatusReport.Set(resourceId, api.StatusReasonHostsNotLinked, "one or more hosts are not linked to the resource", linkedHosts)
+			r.StatusReport.Set(resourceId, api.StatusReasonHostsNotLinked, r.hostsNotLinkedMessage(&authConfig, looseHosts), linkedHosts)
 			reportReconciled = false
 		}
 
@@ -133,6 +278,40 @@ func (r *AuthConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			r.StatusReport.Set(resourceId, api.StatusReasonCachingError, err.Error(), linkedHosts)
 			return ctrl.Result{}, err
 		}
+
+		// the new config has replaced the previous one for all hosts; clean all async workers of the previous config,
+		// i.e. shuts down channels and goroutines, once the requests still being evaluated with it are finished
+		r.cleanConfigsWhenIdle(indexedAuthConfig, logger)
+
+		// the caches of the new config are empty, but the external backend shared by the replicas may still hold the
+		// results cached with the previous one
+		if r.purgeCacheRequested(resourceId, &authConfig) {
+			prefix := authConfig.Annotations[PurgeCachePrefixAnnotation]
+			if purged, err := translatedAuthConfig.PurgeCache(prefix); err != nil {
+				logger.Error(err, "failed to purge the evaluator caches", "prefix", prefix)
+			} else {
+				logger.Info("evaluator cache purged", "prefix", prefix, "purged", purged)
+			}
+		}
+
+		// canary revisions are recorded only once promoted, i.e. reconciled without the canary annotation
+		if r.Revisions != nil && !rollback && stableAuthConfig == nil {
+			r.Revisions.Add(resourceId, authConfig)
+		}
+
+		if r.DependencyHealth != nil {
+			r.DependencyHealth.Set(resourceId, dependenciesOf(translatedAuthConfig))
+		}
+
+		if r.Snapshot != nil {
+			r.Snapshot.Set(resourceId, *effectiveAuthConfig)
+		}
+
+		if r.SecretFiles != nil {
+			r.SecretFiles.Set(resourceId, secretFileDigestsFromContext(ctx).get())
+		}
+
+		r.setEffective(resourceId, *effectiveAuthConfig)
 	}
 
 	if len(linkedHosts) > 0 {
@@ -140,22 +319,151 @@ func (r *AuthConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	}
 
 	if reportReconciled {
-		r.StatusReport.Set(resourceId, api.StatusReasonReconciled, "", linkedHosts)
+		r.StatusReport.Set(resourceId, api.StatusReasonReconciled, reconciledMessage, linkedHosts)
+	}
+
+	return r.vaultRenewal(ctx), nil
+}
+
+// revision returns a previous successfully reconciled revision of a resource, by generation
+func (r *AuthConfigReconciler) revision(resourceId, generation string) (*api.AuthConfig, error) {
+	if r.Revisions == nil {
+		return nil, fmt.Errorf("revision history is disabled")
+	}
+	g, err := strconv.ParseInt(generation, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid revision %q: must be the generation of the resource", generation)
+	}
+	revision, found := r.Revisions.Get(resourceId, g)
+	if !found {
+		return nil, fmt.Errorf("revision %d not found (available revisions: %v)", g, r.Revisions.Generations(resourceId))
+	}
+	return &revision, nil
+}
+
+// stableRevision returns the last revision of a resource reconciled without canary, to keep serving the requests not
+// routed to the current revision, and the percentage of the requests to route to the current revision.
+// There is no stable revision if the current revision is the last one reconciled without canary or the history of the
+// resource is empty.
+func (r *AuthConfigReconciler) stableRevision(resourceId string, authConfig *api.AuthConfig, canaryWeight string) (*api.AuthConfig, int, error) {
+	weight, err := strconv.Atoi(canaryWeight)
+	if err != nil || weight < 0 || weight > 100 {
+		return nil, 0, fmt.Errorf("invalid canary weight %q: must be a percentage between 0 and 100", canaryWeight)
+	}
+	if r.Revisions == nil {
+		return nil, 0, fmt.Errorf("revision history is disabled")
+	}
+	revision, found := r.Revisions.Latest(resourceId)
+	if !found || revision.Generation == authConfig.Generation {
+		return nil, weight, nil
 	}
+	return &revision, weight, nil
+}
 
-	return ctrl.Result{}, nil
+// translateStableRevision builds the config of the stable revision of a resource under canary, with the current
+// defaults and overlays applied
+func (r *AuthConfigReconciler) translateStableRevision(ctx context.Context, revision *api.AuthConfig) (*evaluators.AuthConfig, error) {
+	defaultedRevision, _, err := r.applyDefaults(ctx, revision)
+	if err != nil {
+		return nil, err
+	}
+	effectiveRevision, _, err := r.applyOverlays(ctx, defaultedRevision)
+	if err != nil {
+		return nil, err
+	}
+	translatedRevision, err := r.translateAuthConfig(ctx, effectiveRevision)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build stable revision %d: %v", revision.Generation, err)
+	}
+	return translatedRevision, nil
 }
 
-func (r *AuthConfigReconciler) cleanConfigs(resourceId string, ctx context.Context) error {
-	if hosts := r.Index.FindKeys(resourceId); len(hosts) > 0 {
-		// no need to clean for all the hosts as the config should be the same
-		if authConfig := r.Index.Get(hosts[0]); authConfig != nil {
-			return authConfig.Clean(ctx)
+// identityProvider fetches a cluster-wide IdentityProvider referred in the resource
+func (r *AuthConfigReconciler) identityProvider(ctx context.Context, name string) (*v1beta2.IdentityProvider, error) {
+	if !r.IdentityProviders {
+		return nil, fmt.Errorf("cannot refer to identity provider %s: identity providers are disabled", name)
+	}
+	identityProvider := &v1beta2.IdentityProvider{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: name}, identityProvider); err != nil {
+		return nil, err
+	}
+	return identityProvider, nil
+}
+
+// authConfigsReferringTo maps an IdentityProvider to the requests to reconcile the resources that refer to it
+func (r *AuthConfigReconciler) authConfigsReferringTo(object client.Object) []reconcile.Request {
+	authConfigList := api.AuthConfigList{}
+	listOptions := []client.ListOption{}
+	if r.LabelSelector != nil {
+		listOptions = append(listOptions, client.MatchingLabelsSelector{Selector: r.LabelSelector})
+	}
+	if !r.ClusterWide() {
+		listOptions = append(listOptions, client.InNamespace(r.Namespace))
+	}
+	if err := r.List(context.Background(), &authConfigList, listOptions...); err != nil {
+		r.Logger.Error(err, "failed to list resources referring to identity provider", "identityprovider", object.GetName())
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for _, authConfig := range authConfigList.Items {
+		for _, identity := range authConfig.Spec.Identity {
+			if identityProviderRef(identity) == object.GetName() {
+				requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: authConfig.Namespace, Name: authConfig.Name}})
+				break
+			}
+		}
+	}
+	return requests
+}
+
+func identityProviderRef(identity *api.Identity) string {
+	switch {
+	case identity.Oidc != nil && identity.Oidc.IdentityProviderRef != nil:
+		return identity.Oidc.IdentityProviderRef.Name
+	case identity.OAuth2 != nil && identity.OAuth2.IdentityProviderRef != nil:
+		return identity.OAuth2.IdentityProviderRef.Name
+	default:
+		return ""
+	}
+}
+
+// indexedAuthConfig returns the config currently indexed for a resource, if any
+func (r *AuthConfigReconciler) indexedAuthConfig(resourceId string) *evaluators.AuthConfig {
+	for _, host := range r.Index.FindKeys(resourceId) {
+		// no need to check all the hosts as the config should be the same
+		if id, found := r.Index.FindId(host); found && id == resourceId {
+			return r.Index.Get(host)
 		}
 	}
 	return nil
 }
 
+func (r *AuthConfigReconciler) cleanConfigs(authConfig *evaluators.AuthConfig, ctx context.Context) error {
+	if authConfig == nil {
+		return nil
+	}
+	return authConfig.Clean(ctx)
+}
+
+// cleanConfigsWhenIdle cleans a config no longer in the index in the background, once the requests in flight evaluated
+// with it are finished, or after ConfigDrainTimeout at most
+func (r *AuthConfigReconciler) cleanConfigsWhenIdle(authConfig *evaluators.AuthConfig, logger logr.Logger) {
+	if authConfig == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), ConfigDrainTimeout)
+		defer cancel()
+		if err := authConfig.InFlight.Wait(ctx); err != nil {
+			logger.Info("requests still in flight, cleaning up the config anyway", "timeout", ConfigDrainTimeout)
+		}
+		if err := r.cleanConfigs(authConfig, log.IntoContext(context.Background(), logger)); err != nil {
+			logger.Error(err, failedToCleanConfig)
+		}
+	}()
+}
+
 func (r *AuthConfigReconciler) translateAuthConfig(ctx context.Context, authConfig *api.AuthConfig) (*evaluators.AuthConfig, error) {
 	var ctxWithLogger context.Context
 
@@ -181,12 +489,18 @@ func (r *AuthConfigReconciler) translateAuthConfig(ctx context.Context, authConf
 			}, property.Overwrite)
 		}
 
+		conditions, err := buildJSONExpression(authConfig, identity.Conditions, jsonexp.All)
+		if err != nil {
+			return nil, err
+		}
+
 		translatedIdentity := &evaluators.IdentityConfig{
 			Name:               identity.Name,
 			Priority:           identity.Priority,
-			Conditions:         buildJSONExpression(authConfig, identity.Conditions, jsonexp.All),
+			Conditions:         conditions,
 			ExtendedProperties: extendedProperties,
-			Metrics:            identity.Metrics,
+			Metrics:            identity.Metrics || authConfig.Spec.Metrics,
+			StripCredentials:   identity.StripCredentials,
 		}
 
 		if identity.Cache != nil {
@@ -195,6 +509,7 @@ func (r *AuthConfigReconciler) translateAuthConfig(ctx context.Context, authConf
 				ttl = api.EvaluatorDefaultCacheTTL
 			}
 			translatedIdentity.Cache = evaluators.NewEvaluatorCache(
+				evaluatorCacheId(authConfig, "identity", identity.Name),
 				*getJsonFromStaticDynamic(&identity.Cache.Key),
 				ttl,
 			)
@@ -206,29 +521,82 @@ func (r *AuthConfigReconciler) translateAuthConfig(ctx context.Context, authConf
 		// oauth2
 		case api.IdentityOAuth2:
 			oauth2Identity := identity.OAuth2
+			tokenIntrospectionUrl := oauth2Identity.TokenIntrospectionUrl
+			tokenTypeHint := oauth2Identity.TokenTypeHint
+			credentials := types.NamespacedName{Namespace: authConfig.Namespace}
+			if oauth2Identity.Credentials != nil {
+				credentials.Name = oauth2Identity.Credentials.Name
+			}
+
+			if ref := oauth2Identity.IdentityProviderRef; ref != nil {
+				identityProvider, err := r.identityProvider(ctx, ref.Name)
+				if err != nil {
+					return nil, err
+				}
+				tokenIntrospection := identityProvider.Spec.TokenIntrospection
+				if tokenIntrospection == nil {
+					return nil, fmt.Errorf("identity provider %s has no token introspection settings", ref.Name)
+				}
+				tokenIntrospectionUrl = tokenIntrospection.Url
+				tokenTypeHint = tokenIntrospection.TokenTypeHint
+				credentials = types.NamespacedName{}
+				if tokenIntrospection.Credentials != nil {
+					credentials = types.NamespacedName{Namespace: tokenIntrospection.Credentials.Namespace, Name: tokenIntrospection.Credentials.Name}
+				}
+			}
+
+			if credentials.Name == "" {
+				return nil, fmt.Errorf("missing credentials of the token introspection endpoint")
+			}
 
 			secret := &v1.Secret{}
-			if err := r.Client.Get(ctx, types.NamespacedName{
-				Namespace: authConfig.Namespace,
-				Name:      oauth2Identity.Credentials.Name},
-				secret); err != nil {
+			if err := r.getSecret(ctx, credentials, secret); err != nil {
 				return nil, err // TODO: Review this error, perhaps we don't need to return an error, just reenqueue.
 			}
+			clientID, clientSecret, err := oauth2ClientCredentials(secret)
+			if err != nil {
+				return nil, err
+			}
 
 			translatedIdentity.OAuth2 = identity_evaluators.NewOAuth2Identity(
-				oauth2Identity.TokenIntrospectionUrl,
-				oauth2Identity.TokenTypeHint,
-				string(secret.Data["clientID"]),
-				string(secret.Data["clientSecret"]),
+				tokenIntrospectionUrl,
+				tokenTypeHint,
+				clientID,
+				clientSecret,
 				authCred,
 			)
 
 		// oidc
 		case api.IdentityOidc:
-			translatedIdentity.OIDC = identity_evaluators.NewOIDC(identity.Oidc.Endpoint, authCred, identity.Oidc.TTL, ctxWithLogger)
+			issuerUrl, ttl := identity.Oidc.Endpoint, identity.Oidc.TTL
+			if ref := identity.Oidc.IdentityProviderRef; ref != nil {
+				identityProvider, err := r.identityProvider(ctx, ref.Name)
+				if err != nil {
+					return nil, err
+				}
+				issuerUrl, ttl = identityProvider.Spec.IssuerUrl, identityProvider.Spec.TTL
+			}
+			if tenants := identity.Oidc.Tenants; len(tenants) > 0 {
+				translatedIdentity.OIDC = identity_evaluators.NewMultiTenantOIDC(issuerUrl, tenants, authCred, ttl, ctxWithLogger)
+			} else {
+				translatedIdentity.OIDC = identity_evaluators.NewOIDC(issuerUrl, authCred, ttl, ctxWithLogger)
+			}
 
 		// apiKey
 		case api.IdentityApiKey:
+			if path := identity.APIKey.VaultPath; path != "" {
+				data, err := r.readVaultSecret(ctx, path)
+				if err != nil {
+					return nil, err
+				}
+				// each key of the vault secret is an api key
+				secrets := make([]v1.Secret, 0, len(data))
+				for name, value := range data {
+					secrets = append(secrets, v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name}, Data: map[string][]byte{"api_key": value}})
+				}
+				translatedIdentity.APIKey = identity_evaluators.NewApiKeyIdentityFromSecrets(identity.Name, secrets, authConfig.Namespace, authCred)
+				break
+			}
 			namespace := authConfig.Namespace
 			if identity.APIKey.AllNamespaces && r.ClusterWide() {
 				namespace = ""
@@ -251,6 +619,50 @@ func (r *AuthConfigReconciler) translateAuthConfig(ctx context.Context, authConf
 			}
 			translatedIdentity.MTLS = identity_evaluators.NewMTLSIdentity(identity.Name, selector, namespace, r.Client, ctxWithLogger)
 
+		// SPIFFE
+		case api.IdentitySpiffe:
+			translatedIdentity.Spiffe = identity_evaluators.NewSpiffeIdentity(identity.Spiffe.TrustDomain, identity.Spiffe.BundleEndpointUrl, identity.Spiffe.Audiences, authCred, identity.Spiffe.TTL, ctxWithLogger)
+
+		// AWS SigV4
+		case api.IdentityAwsSigV4:
+			if sts := identity.AwsSigV4.Sts; sts != nil {
+				translatedIdentity.AwsSigV4 = identity_evaluators.NewAwsStsIdentity(identity.Name, sts.Regions, sts.Audience, authCred)
+				break
+			}
+			if identity.AwsSigV4.Selector == nil {
+				return nil, fmt.Errorf("missing selector of the aws access key secrets")
+			}
+			namespace := authConfig.Namespace
+			if identity.AwsSigV4.AllNamespaces && r.ClusterWide() {
+				namespace = ""
+			}
+			selector, err := metav1.LabelSelectorAsSelector(identity.AwsSigV4.Selector)
+			if err != nil {
+				return nil, err
+			}
+			translatedIdentity.AwsSigV4 = identity_evaluators.NewAwsSigV4Identity(identity.Name, selector, namespace, identity.AwsSigV4.Region, identity.AwsSigV4.Service, authCred, r.Client, ctxWithLogger)
+			translatedIdentity.AwsSigV4.AllowUnsignedPayload = identity.AwsSigV4.AllowUnsignedPayload
+
+		// Google ID token
+		case api.IdentityGoogleIDToken:
+			translatedIdentity.GoogleIDToken = identity_evaluators.NewGoogleIDToken(identity.GoogleIDToken.Audiences, identity.GoogleIDToken.Emails, identity.GoogleIDToken.Domains, authCred)
+
+		// GitHub/GitLab token
+		case api.IdentityGitToken:
+			gitToken, err := identity_evaluators.NewGitToken(identity.GitToken.Provider, identity.GitToken.Url, identity.GitToken.Audiences, identity.GitToken.TTL, authCred)
+			if err != nil {
+				return nil, err
+			}
+			translatedIdentity.GitToken = gitToken
+
+		// Vault Transit
+		case api.IdentityVaultTransit:
+			vaultTransit, err := identity_evaluators.NewVaultTransit(r.Vault, identity.VaultTransit.Mount, identity.VaultTransit.KeyName, identity.VaultTransit.Issuer, identity.VaultTransit.Audiences, authCred)
+			if err != nil {
+				return nil, err
+			}
+			translatedIdentity.VaultTransit = vaultTransit
+
 		// kubernetes auth
 		case api.IdentityKubernetesAuth:
 			if k8sAuthConfig, err := identity_evaluators.NewKubernetesAuthIdentity(authCred, identity.KubernetesAuth.Audiences); err != nil {
@@ -276,11 +688,16 @@ func (r *AuthConfigReconciler) translateAuthConfig(ctx context.Context, authConf
 	interfacedMetadataConfigs := make([]auth.AuthConfigEvaluator, 0)
 
 	for _, metadata := range authConfig.Spec.Metadata {
+		conditions, err := buildJSONExpression(authConfig, metadata.Conditions, jsonexp.All)
+		if err != nil {
+			return nil, err
+		}
+
 		translatedMetadata := &evaluators.MetadataConfig{
 			Name:       metadata.Name,
 			Priority:   metadata.Priority,
-			Conditions: buildJSONExpression(authConfig, metadata.Conditions, jsonexp.All),
-			Metrics:    metadata.Metrics,
+			Conditions: conditions,
+			Metrics:    metadata.Metrics || authConfig.Spec.Metrics,
 		}
 
 		if metadata.Cache != nil {
@@ -289,6 +706,7 @@ func (r *AuthConfigReconciler) translateAuthConfig(ctx context.Context, authConf
 				ttl = api.EvaluatorDefaultCacheTTL
 			}
 			translatedMetadata.Cache = evaluators.NewEvaluatorCache(
+				evaluatorCacheId(authConfig, "metadata", metadata.Name),
 				*getJsonFromStaticDynamic(&metadata.Cache.Key),
 				ttl,
 			)
@@ -298,17 +716,21 @@ func (r *AuthConfigReconciler) translateAuthConfig(ctx context.Context, authConf
 		// uma
 		case api.MetadataUma:
 			secret := &v1.Secret{}
-			if err := r.Client.Get(ctx, types.NamespacedName{
+			if err := r.getSecret(ctx, types.NamespacedName{
 				Namespace: authConfig.Namespace,
 				Name:      metadata.UMA.Credentials.Name},
 				secret); err != nil {
 				return nil, err // TODO: Review this error, perhaps we don't need to return an error, just reenqueue.
 			}
+			clientID, clientSecret, err := oauth2ClientCredentials(secret)
+			if err != nil {
+				return nil, err
+			}
 
 			if uma, err := metadata_evaluators.NewUMAMetadata(
 				metadata.UMA.Endpoint,
-				string(secret.Data["clientID"]),
-				string(secret.Data["clientSecret"]),
+				clientID,
+				clientSecret,
 			); err != nil {
 				return nil, err
 			} else {
@@ -343,12 +765,29 @@ func (r *AuthConfigReconciler) translateAuthConfig(ctx context.Context, authConf
 	interfacedAuthorizationConfigs := make([]auth.AuthConfigEvaluator, 0)
 	ctxWithLogger = log.IntoContext(ctx, log.FromContext(ctx).WithName("authorization"))
 
-	for index, authorization := range authConfig.Spec.Authorization {
+	for _, authorization := range authConfig.Spec.Authorization {
+		conditions, err := buildJSONExpression(authConfig, authorization.Conditions, jsonexp.All)
+		if err != nil {
+			return nil, err
+		}
+
 		translatedAuthorization := &evaluators.AuthorizationConfig{
 			Name:       authorization.Name,
 			Priority:   authorization.Priority,
-			Conditions: buildJSONExpression(authConfig, authorization.Conditions, jsonexp.All),
-			Metrics:    authorization.Metrics,
+			Conditions: conditions,
+			Metrics:    authorization.Metrics || authConfig.Spec.Metrics,
+			Weight:     authorization.Weight,
+			DryRun:     authorization.EnforcementMode == api.EnforcementModeDryRun,
+		}
+
+		for _, export := range authorization.Exports {
+			translatedAuthorization.Exports = append(translatedAuthorization.Exports, json.JSONProperty{
+				Name: export.Name,
+				Value: json.JSONValue{
+					Static:  export.Value,
+					Pattern: export.ValueFrom.AuthJSON,
+				},
+			})
 		}
 
 		if authorization.Cache != nil {
@@ -357,6 +796,7 @@ func (r *AuthConfigReconciler) translateAuthConfig(ctx context.Context, authConf
 				ttl = api.EvaluatorDefaultCacheTTL
 			}
 			translatedAuthorization.Cache = evaluators.NewEvaluatorCache(
+				evaluatorCacheId(authConfig, "authorization", authorization.Name),
 				*getJsonFromStaticDynamic(&authorization.Cache.Key),
 				ttl,
 			)
@@ -372,13 +812,17 @@ func (r *AuthConfigReconciler) translateAuthConfig(ctx context.Context, authConf
 			var sharedSecret string
 
 			if externalRegistry.SharedSecret != nil {
-				if err := r.Client.Get(ctx, types.NamespacedName{
+				if err := r.getSecret(ctx, types.NamespacedName{
 					Namespace: authConfig.Namespace,
 					Name:      externalRegistry.SharedSecret.Name},
 					secret); err != nil {
 					return nil, err // TODO: Review this error, perhaps we don't need to return an error, just reenqueue.
 				}
-				sharedSecret = string(secret.Data[externalRegistry.SharedSecret.Key])
+				value, err := secretKeyValue(secret, externalRegistry.SharedSecret.Key)
+				if err != nil {
+					return nil, err
+				}
+				sharedSecret = string(value)
 			}
 
 			externalSource := &authorization_evaluators.OPAExternalSource{
@@ -389,15 +833,19 @@ func (r *AuthConfigReconciler) translateAuthConfig(ctx context.Context, authConf
 			}
 
 			var err error
-			translatedAuthorization.OPA, err = authorization_evaluators.NewOPAAuthorization(policyName, opa.InlineRego, externalSource, opa.AllValues, index, ctxWithLogger)
+			translatedAuthorization.OPA, err = authorization_evaluators.NewOPAAuthorization(policyName, opa.InlineRego, externalSource, opa.AllValues, ctxWithLogger)
 			if err != nil {
 				return nil, err
 			}
 
 		// json
 		case api.AuthorizationJSONPatternMatching:
+			rules, err := buildJSONExpression(authConfig, authorization.JSON.Rules, jsonexp.All)
+			if err != nil {
+				return nil, err
+			}
 			translatedAuthorization.JSON = &authorization_evaluators.JSONPatternMatching{
-				Rules: buildJSONExpression(authConfig, authorization.JSON.Rules, jsonexp.All),
+				Rules: rules,
 			}
 
 		case api.AuthorizationKubernetesAuthz:
@@ -429,10 +877,14 @@ func (r *AuthConfigReconciler) translateAuthConfig(ctx context.Context, authConf
 			secret := &v1.Secret{}
 			var sharedSecret string
 			if secretRef := authzed.SharedSecret; secretRef != nil {
-				if err := r.Client.Get(ctx, types.NamespacedName{Namespace: authConfig.Namespace, Name: secretRef.Name}, secret); err != nil {
+				if err := r.getSecret(ctx, types.NamespacedName{Namespace: authConfig.Namespace, Name: secretRef.Name}, secret); err != nil {
 					return nil, err // TODO: Review this error, perhaps we don't need to return an error, just reenqueue.
 				}
-				sharedSecret = string(secret.Data[secretRef.Key])
+				value, err := secretKeyValue(secret, secretRef.Key)
+				if err != nil {
+					return nil, err
+				}
+				sharedSecret = string(value)
 			}
 
 			translatedAuthzed := &authorization_evaluators.Authzed{
@@ -446,6 +898,98 @@ func (r *AuthConfigReconciler) translateAuthConfig(ctx context.Context, authConf
 
 			translatedAuthorization.Authzed = translatedAuthzed
 
+		case api.AuthorizationQuota:
+			quota := authorization.Quota
+			policyName := authConfig.GetNamespace() + "/" + authConfig.GetName() + "/" + authorization.Name
+
+			var store authorization_evaluators.QuotaCounterStore
+			if redis := quota.Redis; redis != nil {
+				var password string
+				if secretRef := redis.Password; secretRef != nil {
+					secret := &v1.Secret{}
+					if err := r.getSecret(ctx, types.NamespacedName{Namespace: authConfig.Namespace, Name: secretRef.Name}, secret); err != nil {
+						return nil, err // TODO: Review this error, perhaps we don't need to return an error, just reenqueue.
+					}
+					value, err := secretKeyValue(secret, secretRef.Key)
+					if err != nil {
+						return nil, err
+					}
+					password = string(value)
+				}
+				store = authorization_evaluators.NewRedisQuotaCounterStore(redis.Address, password, redis.DB)
+			}
+
+			var err error
+			translatedAuthorization.Quota, err = authorization_evaluators.NewQuotaAuthorization(
+				policyName,
+				*getJsonFromStaticDynamic(&quota.Key),
+				quota.Limit,
+				time.Duration(quota.Window)*time.Second,
+				store,
+			)
+			if err != nil {
+				return nil, err
+			}
+
+		case api.AuthorizationRBAC:
+			rbac := authorization.RBAC
+			policyName := authConfig.GetNamespace() + "/" + authConfig.GetName() + "/" + authorization.Name
+
+			rules := make([]authorization_evaluators.RBACRule, 0, len(rbac.Rules))
+			for _, rule := range rbac.Rules {
+				rules = append(rules, authorization_evaluators.RBACRule{
+					Roles:   rule.Roles,
+					Methods: rule.Methods,
+					Paths:   rule.Paths,
+				})
+			}
+
+			var configMapSource *authorization_evaluators.RBACConfigMapSource
+			if configMapRef := rbac.ConfigMap; configMapRef != nil {
+				configMapSource = authorization_evaluators.NewRBACConfigMapSource(configMapRef.Name, authConfig.Namespace, configMapRef.Key, configMapRef.TTL, r.Client)
+			}
+
+			var err error
+			translatedAuthorization.RBAC, err = authorization_evaluators.NewRBACAuthorization(policyName, rbac.RolesSelector, rules, configMapSource, ctxWithLogger)
+			if err != nil {
+				return nil, err
+			}
+
+		case api.AuthorizationRequiredScopes:
+			translatedAuthorization.RequiredScopes = &authorization_evaluators.RequiredScopes{
+				Selector: authorization.RequiredScopes.Selector,
+				AllOf:    authorization.RequiredScopes.AllOf,
+				AnyOf:    authorization.RequiredScopes.AnyOf,
+			}
+
+		case api.AuthorizationWasm:
+			wasm := authorization.Wasm
+			policyName := authConfig.GetNamespace() + "/" + authConfig.GetName() + "/" + authorization.Name
+
+			var source authorization_evaluators.WasmModuleSource
+			if configMapRef := wasm.ConfigMap; configMapRef != nil {
+				source = authorization_evaluators.NewWasmConfigMapModuleSource(configMapRef.Name, authConfig.Namespace, configMapRef.Key, r.Client)
+			} else if wasm.Image != "" {
+				source = &authorization_evaluators.WasmOCIModuleSource{Reference: wasm.Image}
+			} else {
+				return nil, fmt.Errorf("missing source of the wasm module of authorization %s", authorization.Name)
+			}
+
+			var err error
+			translatedAuthorization.Wasm, err = authorization_evaluators.NewWasmAuthorization(policyName, source, uint64(wasm.MaxFuel), ctxWithLogger)
+			if err != nil {
+				return nil, err
+			}
+
+		case api.AuthorizationLua:
+			policyName := authConfig.GetNamespace() + "/" + authConfig.GetName() + "/" + authorization.Name
+
+			var err error
+			translatedAuthorization.Lua, err = authorization_evaluators.NewLuaAuthorization(policyName, authorization.Lua.Script, authorization.Lua.Timeout)
+			if err != nil {
+				return nil, err
+			}
+
 		case api.TypeUnknown:
 			return nil, fmt.Errorf("unknown authorization type %v", authorization)
 		}
@@ -456,14 +1000,68 @@ func (r *AuthConfigReconciler) translateAuthConfig(ctx context.Context, authConf
 	interfacedResponseConfigs := make([]auth.AuthConfigEvaluator, 0)
 
 	for _, response := range authConfig.Spec.Response {
+		conditions, err := buildJSONExpression(authConfig, response.Conditions, jsonexp.All)
+		if err != nil {
+			return nil, err
+		}
+
 		translatedResponse := evaluators.NewResponseConfig(
 			response.Name,
 			response.Priority,
-			buildJSONExpression(authConfig, response.Conditions, jsonexp.All),
+			conditions,
 			string(response.Wrapper),
 			response.WrapperKey,
-			response.Metrics,
+			response.Metrics || authConfig.Spec.Metrics,
 		)
+		translatedResponse.Append = response.Append
+
+		if translatedResponse.Wrapper == evaluators.GRPC_METADATA_WRAPPER {
+			key, err := evaluators.GrpcMetadataKey(translatedResponse.WrapperKey)
+			if err != nil {
+				return nil, err
+			}
+			translatedResponse.WrapperKey = key
+		}
+
+		if compression := response.Compression; compression != nil {
+			translatedResponse.Compression = response_evaluators.NewGzipCompression(compression.Threshold)
+		}
+
+		if cookie := response.Cookie; cookie != nil {
+			translatedResponse.Cookie = &evaluators.CookieAttributes{
+				Path:     cookie.Path,
+				Domain:   cookie.Domain,
+				MaxAge:   cookie.MaxAge,
+				Secure:   cookie.Secure,
+				HttpOnly: cookie.HttpOnly,
+				SameSite: cookie.SameSite,
+			}
+		}
+
+		if encryption := response.Encryption; encryption != nil {
+			secret := &v1.Secret{}
+			secretName := types.NamespacedName{
+				Namespace: authConfig.Namespace,
+				Name:      encryption.RecipientKeyRef.Name,
+			}
+			if err := r.getSecret(ctx, secretName, secret); err != nil {
+				return nil, err
+			}
+			recipientKey, err := secretKeyValue(secret, encryption.RecipientKeyRef.Key)
+			if err != nil {
+				return nil, err
+			}
+			jweEncryption, err := response_evaluators.NewJWEEncryption(
+				encryption.RecipientKeyRef.Name,
+				recipientKey,
+				encryption.Algorithm,
+				encryption.ContentEncryption,
+			)
+			if err != nil {
+				return nil, err
+			}
+			translatedResponse.Encryption = jweEncryption
+		}
 
 		if response.Cache != nil {
 			ttl := response.Cache.TTL
@@ -471,6 +1069,7 @@ func (r *AuthConfigReconciler) translateAuthConfig(ctx context.Context, authConf
 				ttl = api.EvaluatorDefaultCacheTTL
 			}
 			translatedResponse.Cache = evaluators.NewEvaluatorCache(
+				evaluatorCacheId(authConfig, "response", response.Name),
 				*getJsonFromStaticDynamic(&response.Cache.Key),
 				ttl,
 			)
@@ -483,18 +1082,29 @@ func (r *AuthConfigReconciler) translateAuthConfig(ctx context.Context, authConf
 			signingKeys := make([]jose.JSONWebKey, 0)
 
 			for _, signingKeyRef := range wristband.SigningKeyRefs {
+				if signingKeyRef.KMS != nil {
+					if signingKey, err := r.kmsSigningKey(ctx, authConfig.Namespace, signingKeyRef); err != nil {
+						return nil, err
+					} else {
+						signingKeys = append(signingKeys, *signingKey)
+					}
+					continue
+				}
+
 				secret := &v1.Secret{}
 				secretName := types.NamespacedName{
 					Namespace: authConfig.Namespace,
 					Name:      signingKeyRef.Name,
 				}
-				if err := r.Client.Get(ctx, secretName, secret); err != nil {
+				if err := r.getSecret(ctx, secretName, secret); err != nil {
 					return nil, err // TODO: Review this error, perhaps we don't need to return an error, just reenqueue.
+				} else if keyValue, err := secretKeyValue(secret, response_evaluators.SigningKeySecretKey(string(signingKeyRef.Algorithm))); err != nil {
+					return nil, err
 				} else {
 					if signingKey, err := response_evaluators.NewSigningKey(
 						signingKeyRef.Name,
 						string(signingKeyRef.Algorithm),
-						secret.Data["key.pem"],
+						keyValue,
 					); err != nil {
 						return nil, err
 					} else {
@@ -522,6 +1132,7 @@ func (r *AuthConfigReconciler) translateAuthConfig(ctx context.Context, authConf
 			); err != nil {
 				return nil, err
 			} else {
+				authorinoWristband.SigningKeyRotationInterval = wristband.SigningKeyRotationInterval
 				translatedResponse.Wristband = authorinoWristband
 			}
 
@@ -550,6 +1161,41 @@ func (r *AuthConfigReconciler) translateAuthConfig(ctx context.Context, authConf
 				},
 			}
 
+		// signature
+		case api.ResponseSignature:
+			signature := response.Signature
+			secret := &v1.Secret{}
+			secretName := types.NamespacedName{
+				Namespace: authConfig.Namespace,
+				Name:      signature.SigningKeyRef.Name,
+			}
+			if err := r.getSecret(ctx, secretName, secret); err != nil {
+				return nil, err
+			}
+			algorithm := string(signature.Algorithm)
+			if algorithm == "" {
+				algorithm = response_evaluators.DEFAULT_SIGNATURE_ALGORITHM
+			}
+			keyValue, err := secretKeyValue(secret, response_evaluators.SigningKeySecretKey(algorithm))
+			if err != nil {
+				return nil, err
+			}
+			signingKey, err := response_evaluators.NewSigningKey(
+				signature.SigningKeyRef.Name,
+				algorithm,
+				keyValue,
+			)
+			if err != nil {
+				return nil, err
+			}
+			attributes := make([]json.JSONValue, 0, len(signature.Attributes))
+			for _, selector := range signature.Attributes {
+				attributes = append(attributes, json.JSONValue{Pattern: selector})
+			}
+			if translatedResponse.Signature, err = response_evaluators.NewRequestSignature(signingKey, attributes); err != nil {
+				return nil, err
+			}
+
 		case api.TypeUnknown:
 			return nil, fmt.Errorf("unknown response type %v", response)
 		}
@@ -560,11 +1206,18 @@ func (r *AuthConfigReconciler) translateAuthConfig(ctx context.Context, authConf
 	interfacedCallbackConfigs := make([]auth.AuthConfigEvaluator, 0)
 
 	for _, callback := range authConfig.Spec.Callbacks {
+		conditions, err := buildJSONExpression(authConfig, callback.Conditions, jsonexp.All)
+		if err != nil {
+			return nil, err
+		}
+
 		translatedCallback := &evaluators.CallbackConfig{
 			Name:       callback.Name,
 			Priority:   callback.Priority,
-			Conditions: buildJSONExpression(authConfig, callback.Conditions, jsonexp.All),
-			Metrics:    callback.Metrics,
+			Conditions: conditions,
+			Metrics:    callback.Metrics || authConfig.Spec.Metrics,
+			Timeout:    time.Duration(callback.Timeout) * time.Millisecond,
+			Events:     utils.Map(callback.On, func(event api.CallbackEvent) string { return string(event) }),
 		}
 
 		switch callback.GetType() {
@@ -576,6 +1229,19 @@ func (r *AuthConfigReconciler) translateAuthConfig(ctx context.Context, authConf
 			}
 			translatedCallback.HTTP = ev
 
+		// kafka
+		case api.CallbackKafka:
+			kafka := callback.Kafka
+			var cloudEvents *callback_evaluators.CloudEventsEnvelope
+			if kafka.CloudEvents != nil {
+				cloudEvents = callback_evaluators.NewCloudEventsEnvelope(kafka.CloudEvents.Type, kafka.CloudEvents.Source, fmt.Sprintf("%s/%s", authConfig.Namespace, authConfig.Name))
+			}
+			ev, err := callback_evaluators.NewKafkaPublisher(kafka.Brokers, kafka.Topic, kafka.Serialization, getJsonFromStaticDynamic(kafka.Subject), cloudEvents, nil)
+			if err != nil {
+				return nil, err
+			}
+			translatedCallback.Kafka = ev
+
 		case api.TypeUnknown:
 			return nil, fmt.Errorf("unknown callback type %v", callback)
 		}
@@ -583,14 +1249,31 @@ func (r *AuthConfigReconciler) translateAuthConfig(ctx context.Context, authConf
 		interfacedCallbackConfigs = append(interfacedCallbackConfigs, translatedCallback)
 	}
 
+	conditions, err := buildJSONExpression(authConfig, authConfig.Spec.Conditions, jsonexp.All)
+	if err != nil {
+		return nil, err
+	}
+
 	translatedAuthConfig := &evaluators.AuthConfig{
-		Conditions:           buildJSONExpression(authConfig, authConfig.Spec.Conditions, jsonexp.All),
-		IdentityConfigs:      interfacedIdentityConfigs,
-		MetadataConfigs:      interfacedMetadataConfigs,
-		AuthorizationConfigs: interfacedAuthorizationConfigs,
-		ResponseConfigs:      interfacedResponseConfigs,
-		CallbackConfigs:      interfacedCallbackConfigs,
-		Labels:               map[string]string{"namespace": authConfig.Namespace, "name": authConfig.Name},
+		Conditions:               conditions,
+		IdentityConfigs:          interfacedIdentityConfigs,
+		MetadataConfigs:          interfacedMetadataConfigs,
+		AuthorizationConfigs:     interfacedAuthorizationConfigs,
+		AuthorizationStrategy:    authConfig.Spec.AuthorizationStrategy,
+		ResponseConfigs:          interfacedResponseConfigs,
+		DynamicMetadataNamespace: authConfig.Spec.DynamicMetadataNamespace,
+		QueryParametersToRemove:  authConfig.Spec.QueryParametersToRemove,
+		CallbackConfigs:          interfacedCallbackConfigs,
+		Labels:                   map[string]string{"namespace": authConfig.Namespace, "name": authConfig.Name, "generation": strconv.FormatInt(authConfig.Generation, 10), "priority": strconv.Itoa(authConfig.Spec.Priority)},
+	}
+
+	// log level
+	if authConfig.Spec.LogLevel != "" {
+		logLevel, err := log.ParseLogLevel(authConfig.Spec.LogLevel)
+		if err != nil {
+			return nil, fmt.Errorf("invalid log level: %w", err)
+		}
+		translatedAuthConfig.LogLevel = &logLevel
 	}
 
 	// denyWith
@@ -599,19 +1282,38 @@ func (r *AuthConfigReconciler) translateAuthConfig(ctx context.Context, authConf
 		translatedAuthConfig.Unauthorized = buildAuthorinoDenyWithValues(denyWith.Unauthorized)
 	}
 
+	// selectors are parsed once here, so the evaluation of the requests does only the lookups
+	if err := json.CompileJSONValues(translatedAuthConfig); err != nil {
+		return nil, err
+	}
+
 	return translatedAuthConfig, nil
 }
 
-func (r *AuthConfigReconciler) addToIndex(ctx context.Context, resourceNamespace, resourceId string, authConfig *evaluators.AuthConfig, hosts []string) (linkedHosts, looseHosts []string, err error) {
+// addToIndex links the hosts to the resource in the index, except the ones already taken by other resources.
+// The hosts not linked are returned mapped to the id of the resource that has taken them.
+func (r *AuthConfigReconciler) addToIndex(ctx context.Context, resourceNamespace, resourceId string, authConfig *evaluators.AuthConfig, hosts []string) (linkedHosts []string, looseHosts map[string]string, err error) {
 	logger := log.FromContext(ctx)
 	linkedHosts = []string{}
-	looseHosts = []string{}
+	looseHosts = map[string]string{}
+	priority, _ := strconv.Atoi(authConfig.Labels["priority"])
+
+	// tracks the requests evaluated with the config (including the canary revision, if any), so it is cleaned only
+	// once they are finished when replaced or removed from the index
+	if authConfig.InFlight == nil {
+		authConfig.InFlight = evaluators.NewInFlightRequests()
+		if authConfig.Canary != nil {
+			authConfig.Canary.InFlight = authConfig.InFlight
+		}
+	}
+	displacedResourceIds := []string{}
 
 	for _, host := range hosts {
 		// check for host name collision between resources
-		if r.hostTaken(host, resourceId) {
-			looseHosts = append(looseHosts, host)
-			logger.Info("host already taken", "host", host)
+		indexedResourceId, taken := r.hostTaken(host, resourceId)
+		if taken && !r.outranks(priority, indexedResourceId) {
+			looseHosts[host] = indexedResourceId
+			logger.Info("host already taken", "host", host, "authconfig", indexedResourceId)
 			continue
 		}
 
@@ -620,15 +1322,89 @@ func (r *AuthConfigReconciler) addToIndex(ctx context.Context, resourceNamespace
 			return
 		}
 
+		// the host was taken over from a resource with lower priority
+		if taken && utils.SliceContains(r.Index.FindKeys(indexedResourceId), host) {
+			r.Index.DeleteKey(indexedResourceId, host)
+			displacedResourceIds = append(displacedResourceIds, indexedResourceId)
+			logger.Info("host taken over", "host", host, "authconfig", indexedResourceId)
+		}
+
 		linkedHosts = append(linkedHosts, host)
 	}
 
+	// the displaced resources have to report the hosts they lost
+	r.reconcileLater(displacedResourceIds...)
+
 	return
 }
 
-func (r *AuthConfigReconciler) hostTaken(host, resourceId string) bool {
+// hostsNotLinkedMessage describes the hosts that could not be linked to the resource due to collisions with other
+// resources, and emits a warning event on the resource for each of them
+func (r *AuthConfigReconciler) hostsNotLinkedMessage(authConfig *api.AuthConfig, looseHosts map[string]string) string {
+	collisions := make([]string, 0, len(looseHosts))
+	for _, host := range authConfig.Spec.Hosts {
+		indexedResourceId, loose := looseHosts[host]
+		if !loose {
+			continue
+		}
+		if authConfig.Spec.Priority > 0 {
+			collisions = append(collisions, fmt.Sprintf("%s (already taken by %s with priority %d)", host, indexedResourceId, r.indexedPriority(indexedResourceId)))
+		} else {
+			collisions = append(collisions, fmt.Sprintf("%s (already taken by %s)", host, indexedResourceId))
+		}
+		if r.Recorder != nil {
+			r.Recorder.Eventf(authConfig, v1.EventTypeWarning, api.StatusReasonHostsNotLinked, "Host %s already taken by authconfig %s", host, indexedResourceId)
+		}
+	}
+	return "one or more hosts are not linked to the resource: " + strings.Join(collisions, ", ")
+}
+
+func (r *AuthConfigReconciler) hostTaken(host, resourceId string) (indexedResourceId string, taken bool) {
 	indexedResourceId, found := r.Index.FindId(host)
-	return found && indexedResourceId != resourceId && !r.supersedeHostSubset(host, indexedResourceId)
+	return indexedResourceId, found && indexedResourceId != resourceId && !r.supersedeHostSubset(host, indexedResourceId)
+}
+
+// outranks tells whether a resource with a given priority takes precedence over one already indexed
+func (r *AuthConfigReconciler) outranks(priority int, indexedResourceId string) bool {
+	return priority > 0 && priority > r.indexedPriority(indexedResourceId)
+}
+
+func (r *AuthConfigReconciler) indexedPriority(resourceId string) int {
+	authConfig := r.indexedAuthConfig(resourceId)
+	if authConfig == nil {
+		return 0
+	}
+	priority, _ := strconv.Atoi(authConfig.Labels["priority"])
+	return priority
+}
+
+// reconcileLater enqueues resources to be reconciled again, e.g. because the hosts they claim have changed hands
+func (r *AuthConfigReconciler) reconcileLater(resourceIds ...string) {
+	if r.requeue == nil {
+		return
+	}
+	for _, resourceId := range resourceIds {
+		namespace, name, found := strings.Cut(resourceId, "/")
+		if !found || strings.Contains(namespace, ":") {
+			continue // not an authconfig custom resource (e.g. read from a configmap)
+		}
+		authConfig := &api.AuthConfig{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+		go func() {
+			r.requeue <- event.GenericEvent{Object: authConfig}
+		}()
+	}
+}
+
+// reconcileLooseResources enqueues the resources that failed to link hosts for being taken by others, so they get
+// another chance after hosts are released
+func (r *AuthConfigReconciler) reconcileLooseResources(releasedBy string) {
+	resourceIds := []string{}
+	for resourceId, status := range r.StatusReport.ReadAll() {
+		if resourceId != releasedBy && status.Reason == api.StatusReasonHostsNotLinked {
+			resourceIds = append(resourceIds, resourceId)
+		}
+	}
+	r.reconcileLater(resourceIds...)
 }
 
 func (r *AuthConfigReconciler) supersedeHostSubset(host, supersetResourceId string) bool {
@@ -639,16 +1415,18 @@ func (r *AuthConfigReconciler) bootstrapIndex(ctx context.Context) error {
 	r.indexBootstrap.Lock()
 	defer r.indexBootstrap.Unlock()
 
+	if r.warmResourceIds != nil {
+		if err := r.pruneWarmIndex(ctx); err != nil {
+			return err
+		}
+	}
+
 	if !r.Index.Empty() {
 		return nil
 	}
 
-	authConfigList := api.AuthConfigList{}
-	listOptions := []client.ListOption{}
-	if r.LabelSelector != nil {
-		listOptions = append(listOptions, client.MatchingLabelsSelector{Selector: r.LabelSelector})
-	}
-	if err := r.List(ctx, &authConfigList, listOptions...); err != nil {
+	authConfigList, err := r.listAuthConfigs(ctx)
+	if err != nil {
 		return err
 	}
 
@@ -692,14 +1470,142 @@ func (r *AuthConfigReconciler) bootstrapIndex(ctx context.Context) error {
 	return nil
 }
 
+func (r *AuthConfigReconciler) listAuthConfigs(ctx context.Context) (*api.AuthConfigList, error) {
+	authConfigList := &api.AuthConfigList{}
+	listOptions := []client.ListOption{}
+	if r.LabelSelector != nil {
+		listOptions = append(listOptions, client.MatchingLabelsSelector{Selector: r.LabelSelector})
+	}
+	if !r.ClusterWide() {
+		listOptions = append(listOptions, client.InNamespace(r.Namespace))
+	}
+	if err := r.List(ctx, authConfigList, listOptions...); err != nil {
+		return nil, err
+	}
+	return authConfigList, nil
+}
+
+// WarmUpIndex builds the index out of the last snapshot, before the caches of the manager are synced, so a restarting
+// instance serves the last known good configs while the resources are reconciled again.
+// The client must not depend on the caches of the manager, since they are not started yet.
+func (r *AuthConfigReconciler) WarmUpIndex(ctx context.Context, directClient client.Client) error {
+	if r.Snapshot == nil {
+		return nil
+	}
+
+	authConfigs, err := r.Snapshot.Load(ctx)
+	if err != nil {
+		return err
+	}
+	if len(authConfigs) == 0 {
+		return nil
+	}
+
+	r.indexBootstrap.Lock()
+	defer r.indexBootstrap.Unlock()
+
+	logger := r.Logger.WithName("warmup")
+	logger.Info("building the index from snapshot", "count", len(authConfigs))
+
+	warmReconciler := &AuthConfigReconciler{
+		Client:                      directClient,
+		Logger:                      logger,
+		Index:                       r.Index,
+		AllowSupersedingHostSubsets: r.AllowSupersedingHostSubsets,
+		LabelSelector:               r.LabelSelector,
+		Namespace:                   r.Namespace,
+		IdentityProviders:           r.IdentityProviders,
+		Vault:                       r.Vault,
+		SecretFiles:                 r.SecretFiles,
+	}
+
+	sort.Sort(api.AuthConfigSlice(authConfigs))
+
+	r.warmResourceIds = make(map[string]struct{}, len(authConfigs))
+	for i := range authConfigs {
+		authConfig := &authConfigs[i]
+		if !Watched(&authConfig.ObjectMeta, r.LabelSelector) || !r.InScope(authConfig.Namespace) {
+			continue
+		}
+
+		resourceId := types.NamespacedName{Namespace: authConfig.Namespace, Name: authConfig.Name}.String()
+		resourceCtx := log.IntoContext(ctx, logger.WithValues("authconfig", resourceId))
+
+		translatedAuthConfig, err := warmReconciler.translateAuthConfig(resourceCtx, authConfig)
+		if err != nil {
+			logger.Error(err, "failed to build config from snapshot", "authconfig", resourceId)
+			continue
+		}
+		if _, _, err := warmReconciler.addToIndex(resourceCtx, authConfig.Namespace, resourceId, translatedAuthConfig, authConfig.Spec.Hosts); err != nil {
+			return err
+		}
+		r.setEffective(resourceId, *authConfig)
+		r.warmResourceIds[resourceId] = struct{}{}
+	}
+
+	return nil
+}
+
+// pruneWarmIndex drops from the index the configs built from the snapshot whose resources no longer exist, since no
+// event will ever be reconciled for them
+func (r *AuthConfigReconciler) pruneWarmIndex(ctx context.Context) error {
+	authConfigList, err := r.listAuthConfigs(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, authConfig := range authConfigList.Items {
+		delete(r.warmResourceIds, types.NamespacedName{Namespace: authConfig.Namespace, Name: authConfig.Name}.String())
+	}
+
+	for resourceId := range r.warmResourceIds {
+		indexedAuthConfig := r.indexedAuthConfig(resourceId)
+		r.Index.Delete(resourceId)
+		r.cleanConfigsWhenIdle(indexedAuthConfig, r.Logger)
+		if r.Snapshot != nil {
+			r.Snapshot.Clear(resourceId)
+		}
+		if r.SecretFiles != nil {
+			r.SecretFiles.Clear(resourceId)
+		}
+		r.clearEffective(resourceId)
+		r.Logger.Info("resource de-indexed", "authconfig", resourceId, "reason", "not found after warm up")
+	}
+
+	r.warmResourceIds = nil
+	return nil
+}
+
 func (r *AuthConfigReconciler) ClusterWide() bool {
 	return r.Namespace == ""
 }
 
+// InScope tells whether resources of a given namespace are within the space watched by the reconciler
+func (r *AuthConfigReconciler) InScope(namespace string) bool {
+	return r.ClusterWide() || namespace == r.Namespace
+}
+
 func (r *AuthConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	if err := registerIndexMetrics(r.Index); err != nil {
+		return err
+	}
+	r.requeue = make(chan event.GenericEvent)
+	controller := ctrl.NewControllerManagedBy(mgr).
 		For(&api.AuthConfig{}, builder.WithPredicates(LabelSelectorPredicate(r.LabelSelector))).
-		Complete(r)
+		Watches(&source.Channel{Source: r.requeue}, &handler.EnqueueRequestForObject{})
+	if r.SecretFiles != nil {
+		controller = controller.Watches(&source.Channel{Source: r.SecretFiles.Changes()}, &handler.EnqueueRequestForObject{})
+	}
+	if r.IdentityProviders {
+		controller = controller.Watches(&source.Kind{Type: &v1beta2.IdentityProvider{}}, handler.EnqueueRequestsFromMapFunc(r.authConfigsReferringTo))
+	}
+	if r.Overlays {
+		controller = controller.Watches(&source.Kind{Type: &v1beta2.AuthConfigOverlay{}}, handler.EnqueueRequestsFromMapFunc(r.authConfigsOverlaidBy))
+	}
+	if r.Defaults {
+		controller = controller.Watches(&source.Kind{Type: &v1beta2.AuthConfigDefaults{}}, handler.EnqueueRequestsFromMapFunc(r.authConfigsDefaultedBy))
+	}
+	return controller.Complete(r)
 }
 
 func (r *AuthConfigReconciler) Ready(includes, _ []string, _ bool) error {
@@ -723,10 +1629,14 @@ func (r *AuthConfigReconciler) buildGenericHttpEvaluator(ctx context.Context, ht
 	if sharedSecretRef := http.SharedSecret; sharedSecretRef != nil {
 		secret := &v1.Secret{}
 		if sharedSecretRef != nil {
-			if err := r.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: sharedSecretRef.Name}, secret); err != nil {
+			if err := r.getSecret(ctx, types.NamespacedName{Namespace: namespace, Name: sharedSecretRef.Name}, secret); err != nil {
 				return nil, err // TODO: Review this error, perhaps we don't need to return an error, just reenqueue.
 			}
-			sharedSecret = string(secret.Data[sharedSecretRef.Key])
+			value, err := secretKeyValue(secret, sharedSecretRef.Key)
+			if err != nil {
+				return nil, err
+			}
+			sharedSecret = string(value)
 		}
 	}
 
@@ -734,11 +1644,14 @@ func (r *AuthConfigReconciler) buildGenericHttpEvaluator(ctx context.Context, ht
 	oauth2TokenForceFetch := false
 	if oauth2Config := http.OAuth2; oauth2Config != nil {
 		secret := &v1.Secret{}
-		if err := r.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: oauth2Config.ClientSecret.Name}, secret); err != nil {
+		if err := r.getSecret(ctx, types.NamespacedName{Namespace: namespace, Name: oauth2Config.ClientSecret.Name}, secret); err != nil {
 			return nil, err // TODO: Review this error, perhaps we don't need to return an error, just reenqueue.
 		}
-		clientSecret := string(secret.Data[oauth2Config.ClientSecret.Key])
-		oauth2ClientCredentialsConfig = oauth2.NewClientCredentialsConfig(oauth2Config.TokenUrl, oauth2Config.ClientId, clientSecret, oauth2Config.Scopes, oauth2Config.ExtraParams)
+		clientSecret, err := secretKeyValue(secret, oauth2Config.ClientSecret.Key)
+		if err != nil {
+			return nil, err
+		}
+		oauth2ClientCredentialsConfig = oauth2.NewClientCredentialsConfig(oauth2Config.TokenUrl, oauth2Config.ClientId, string(clientSecret), oauth2Config.Scopes, oauth2Config.ExtraParams)
 		oauth2TokenForceFetch = oauth2Config.Cache != nil && !*oauth2Config.Cache
 	}
 
@@ -802,18 +1715,90 @@ func findIdentityConfigByName(identityConfigs []evaluators.IdentityConfig, name
 	return nil, fmt.Errorf("missing identity config %v", name)
 }
 
-func buildJSONExpression(authConfig *api.AuthConfig, patterns []api.JSONPattern, op func(...jsonexp.Expression) jsonexp.Expression) jsonexp.Expression {
+// getSecret reads a secret from the Kubernetes API, or from an external source if the name of the secret is prefixed
+// with "vault:" (path in Vault) or "file:" (path in the file system)
+func (r *AuthConfigReconciler) getSecret(ctx context.Context, key types.NamespacedName, secret *v1.Secret) error {
+	var data map[string][]byte
+	var err error
+	if path, fromVault := strings.CutPrefix(key.Name, VaultSecretPrefix); fromVault {
+		data, err = r.readVaultSecret(ctx, path)
+	} else if path, fromFile := strings.CutPrefix(key.Name, SecretFilePrefix); fromFile {
+		data, err = r.readSecretFile(ctx, path)
+	} else {
+		return r.Client.Get(ctx, key, secret)
+	}
+	if err != nil {
+		return err
+	}
+	secret.Name = key.Name
+	secret.Namespace = key.Namespace
+	secret.Data = data
+	return nil
+}
+
+// secretKeyValue reads the value of a key of a Secret, failing if the key is missing, so a wrong reference is caught in
+// reconciliation-time instead of producing an evaluator that fails on every request
+func secretKeyValue(secret *v1.Secret, key string) ([]byte, error) {
+	value, found := secret.Data[key]
+	if !found {
+		return nil, fmt.Errorf("missing key %s in secret %s/%s", key, secret.Namespace, secret.Name)
+	}
+	return value, nil
+}
+
+// oauth2ClientCredentials reads the 'clientID' and 'clientSecret' keys of a Secret
+func oauth2ClientCredentials(secret *v1.Secret) (clientID, clientSecret string, err error) {
+	id, err := secretKeyValue(secret, "clientID")
+	if err != nil {
+		return "", "", err
+	}
+	s, err := secretKeyValue(secret, "clientSecret")
+	if err != nil {
+		return "", "", err
+	}
+	return string(id), string(s), nil
+}
+
+// kmsSigningKey builds a wristband signing key backed by a key management service, reading the credentials to call the
+// service from the referred Secret, if any
+func (r *AuthConfigReconciler) kmsSigningKey(ctx context.Context, namespace string, signingKeyRef *api.SigningKeyRef) (*jose.JSONWebKey, error) {
+	var credentials map[string][]byte
+	if ref := signingKeyRef.KMS.CredentialsRef; ref != nil {
+		secret := &v1.Secret{}
+		if err := r.getSecret(ctx, types.NamespacedName{Namespace: namespace, Name: ref.Name}, secret); err != nil {
+			return nil, err
+		}
+		credentials = secret.Data
+	}
+
+	signer, err := kms.NewSigner(ctx, string(signingKeyRef.KMS.Provider), signingKeyRef.KMS.KeyId, signingKeyRef.KMS.Region, string(signingKeyRef.Algorithm), credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	return response_evaluators.NewKMSSigningKey(ctx, signingKeyRef.Name, string(signingKeyRef.Algorithm), signer)
+}
+
+func buildJSONExpression(authConfig *api.AuthConfig, patterns []api.JSONPattern, op func(...jsonexp.Expression) jsonexp.Expression) (jsonexp.Expression, error) {
 	var expression []jsonexp.Expression
 	for _, pattern := range patterns {
 		// patterns or refs
-		expression = append(expression, buildJSONExpressionPatterns(authConfig, pattern)...)
+		expressionPatterns, err := buildJSONExpressionPatterns(authConfig, pattern)
+		if err != nil {
+			return nil, err
+		}
+		expression = append(expression, expressionPatterns...)
 		// all
 		if len(pattern.All) > 0 {
 			p := make([]api.JSONPattern, len(pattern.All))
 			for i, ptn := range pattern.All {
 				p[i] = ptn.JSONPattern
 			}
-			expression = append(expression, buildJSONExpression(authConfig, p, jsonexp.All))
+			allExpression, err := buildJSONExpression(authConfig, p, jsonexp.All)
+			if err != nil {
+				return nil, err
+			}
+			expression = append(expression, allExpression)
 		}
 		// any
 		if len(pattern.Any) > 0 {
@@ -821,13 +1806,17 @@ func buildJSONExpression(authConfig *api.AuthConfig, patterns []api.JSONPattern,
 			for i, ptn := range pattern.Any {
 				p[i] = ptn.JSONPattern
 			}
-			expression = append(expression, buildJSONExpression(authConfig, p, jsonexp.Any))
+			anyExpression, err := buildJSONExpression(authConfig, p, jsonexp.Any)
+			if err != nil {
+				return nil, err
+			}
+			expression = append(expression, anyExpression)
 		}
 	}
-	return op(expression...)
+	return op(expression...), nil
 }
 
-func buildJSONExpressionPatterns(authConfig *api.AuthConfig, pattern api.JSONPattern) []jsonexp.Expression {
+func buildJSONExpressionPatterns(authConfig *api.AuthConfig, pattern api.JSONPattern) ([]jsonexp.Expression, error) {
 	expressionsToAdd := api.JSONPatternExpressions{}
 
 	if expressionsByRef, found := authConfig.Spec.Patterns[pattern.JSONPatternName]; found {
@@ -838,17 +1827,16 @@ func buildJSONExpressionPatterns(authConfig *api.AuthConfig, pattern api.JSONPat
 
 	expressions := make([]jsonexp.Expression, len(expressionsToAdd))
 	for i, expression := range expressionsToAdd {
-		expressions[i] = buildJSONExpressionPattern(expression)
+		var err error
+		if expressions[i], err = buildJSONExpressionPattern(expression); err != nil {
+			return nil, err
+		}
 	}
-	return expressions
+	return expressions, nil
 }
 
-func buildJSONExpressionPattern(expression api.JSONPatternExpression) jsonexp.Expression {
-	return jsonexp.Pattern{
-		Selector: expression.Selector,
-		Operator: jsonexp.OperatorFromString(string(expression.Operator)),
-		Value:    expression.Value,
-	}
+func buildJSONExpressionPattern(expression api.JSONPatternExpression) (jsonexp.Expression, error) {
+	return jsonexp.NewPattern(expression.Selector, jsonexp.OperatorFromString(string(expression.Operator)), expression.Value)
 }
 
 func buildAuthorinoDenyWithValues(denyWithSpec *api.DenyWithSpec) *evaluators.DenyWithValues {
@@ -861,12 +1849,28 @@ func buildAuthorinoDenyWithValues(denyWithSpec *api.DenyWithSpec) *evaluators.De
 		headers = append(headers, json.JSONProperty{Name: header.Name, Value: json.JSONValue{Static: header.Value, Pattern: header.ValueFrom.AuthJSON}})
 	}
 
-	return &evaluators.DenyWithValues{
-		Code:    int32(denyWithSpec.Code),
-		Message: getJsonFromStaticDynamic(denyWithSpec.Message),
-		Headers: headers,
-		Body:    getJsonFromStaticDynamic(denyWithSpec.Body),
+	denyWith := &evaluators.DenyWithValues{
+		Code:       int32(denyWithSpec.Code),
+		Message:    getJsonFromStaticDynamic(denyWithSpec.Message),
+		Headers:    headers,
+		Body:       getJsonFromStaticDynamic(denyWithSpec.Body),
+		RedirectTo: getJsonFromStaticDynamic(denyWithSpec.RedirectTo),
 	}
+
+	if problemDetails := denyWithSpec.ProblemDetails; problemDetails != nil {
+		denyWith.ProblemDetails = &evaluators.ProblemDetails{
+			Type:  problemDetails.Type,
+			Title: problemDetails.Title,
+		}
+	}
+
+	return denyWith
+}
+
+// evaluatorCacheId identifies the cache of an evaluator among the caches of all the AuthConfigs, so the replicas share
+// the entries of the same evaluator in the external cache backend
+func evaluatorCacheId(authConfig *api.AuthConfig, phase, name string) string {
+	return fmt.Sprintf("%s/%s/%s/%s", authConfig.Namespace, authConfig.Name, phase, name)
 }
 
 func getJsonFromStaticDynamic(value *api.StaticOrDynamicValue) *json.JSONValue {
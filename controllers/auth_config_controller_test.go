@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	api "github.com/kuadrant/authorino/api/v1beta1"
+	"github.com/kuadrant/authorino/api/v1beta2"
 	"github.com/kuadrant/authorino/pkg/evaluators"
 	"github.com/kuadrant/authorino/pkg/httptest"
 	"github.com/kuadrant/authorino/pkg/index"
@@ -14,12 +15,14 @@ import (
 	"github.com/kuadrant/authorino/pkg/log"
 
 	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"gotest.tools/assert"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -138,6 +141,7 @@ func newTestOAuthClientSecret() v1.Secret {
 func newTestK8sClient(initObjs ...runtime.Object) client.WithWatch {
 	scheme := runtime.NewScheme()
 	_ = api.AddToScheme(scheme)
+	_ = v1beta2.AddToScheme(scheme)
 	_ = v1.AddToScheme(scheme)
 	return fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(initObjs...).Build()
 }
@@ -169,17 +173,131 @@ func TestReconcileAuthConfigOk(t *testing.T) {
 	idConfig, _ := config.IdentityConfigs[0].(*evaluators.IdentityConfig)
 	assert.Equal(t, idConfig.ExtendedProperties[0].Name, "source")
 	// TODO(@guicassolato): assert other fields of the AuthConfig
+
+	assert.Equal(t, countIndexedHosts(authConfigIndex), len(authConfig.Spec.Hosts))
+	assert.Check(t, testutil.CollectAndCount(reconcileDurationMetric) > 0)
+}
+
+func TestReconcileAuthConfigWithMetrics(t *testing.T) {
+	authConfigIndex := index.NewIndex()
+	authConfig := newTestAuthConfig(map[string]string{})
+	authConfig.Spec.Metrics = true
+	secret := newTestOAuthClientSecret()
+	client := newTestK8sClient(&authConfig, &secret)
+	reconciler := newTestAuthConfigReconciler(client, authConfigIndex)
+
+	_, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Name: authConfig.Name, Namespace: authConfig.Namespace}})
+	assert.NilError(t, err)
+
+	config := authConfigIndex.Get("echo-api")
+	assert.Check(t, config != nil)
+	for _, identityConfig := range config.IdentityConfigs {
+		assert.Check(t, identityConfig.(*evaluators.IdentityConfig).Metrics)
+	}
+	for _, metadataConfig := range config.MetadataConfigs {
+		assert.Check(t, metadataConfig.(*evaluators.MetadataConfig).Metrics)
+	}
+	for _, authorizationConfig := range config.AuthorizationConfigs {
+		assert.Check(t, authorizationConfig.(*evaluators.AuthorizationConfig).Metrics)
+	}
+}
+
+func TestReconcileAuthConfigWithLogLevel(t *testing.T) {
+	authConfigIndex := index.NewIndex()
+	authConfig := newTestAuthConfig(map[string]string{})
+	authConfig.Spec.LogLevel = "debug"
+	secret := newTestOAuthClientSecret()
+	client := newTestK8sClient(&authConfig, &secret)
+	reconciler := newTestAuthConfigReconciler(client, authConfigIndex)
+
+	_, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Name: authConfig.Name, Namespace: authConfig.Namespace}})
+	assert.NilError(t, err)
+
+	config := authConfigIndex.Get("echo-api")
+	assert.Check(t, config != nil)
+	assert.Check(t, config.LogLevel != nil)
+	assert.Equal(t, int(*config.LogLevel), -1)
 }
 
 func TestMissingRequiredSecret(t *testing.T) {
 	authConfig := newTestAuthConfig(map[string]string{})
 	client := newTestK8sClient(&authConfig)
 	reconciler := newTestAuthConfigReconciler(client, index.NewIndex())
+	failures := testutil.ToFloat64(reconcileErrorsMetric.WithLabelValues(authConfig.Namespace, authConfig.Name))
 
 	result, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Name: authConfig.Name, Namespace: authConfig.Namespace}})
 
 	assert.Check(t, errors.IsNotFound(err))    // Error should be "secret" not found.
 	assert.DeepEqual(t, result, ctrl.Result{}) // Result should be empty
+	assert.Equal(t, testutil.ToFloat64(reconcileErrorsMetric.WithLabelValues(authConfig.Namespace, authConfig.Name)), failures+1)
+}
+
+func TestReconcileAuthConfigWithFailingPolicyTests(t *testing.T) {
+	authConfig := newTestAuthConfig(map[string]string{})
+	authConfig.Spec.Authorization[0].OPA.InlineRego += `
+			test_allow_post { allow with input as {"context": {"request": {"http": {"method": "POST", "path": "/allow"}}}} }`
+	secret := newTestOAuthClientSecret()
+	client := newTestK8sClient(&authConfig, &secret)
+	reconciler := newTestAuthConfigReconciler(client, index.NewIndex())
+
+	resourceId := authConfig.Namespace + "/" + authConfig.Name
+	_, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Name: authConfig.Name, Namespace: authConfig.Namespace}})
+
+	assert.ErrorContains(t, err, "policy tests failed: test_allow_post: fail")
+	report, _ := reconciler.StatusReport.Get(resourceId)
+	assert.Equal(t, report.Reason, api.StatusReasonPolicyTestsFailed)
+}
+
+func TestReconcileAuthConfigWithPolicyNotCompiling(t *testing.T) {
+	authConfig := newTestAuthConfig(map[string]string{})
+	authConfig.Spec.Authorization[0].OPA.InlineRego = `allow { input.context.request.http.method == }`
+	secret := newTestOAuthClientSecret()
+	client := newTestK8sClient(&authConfig, &secret)
+	authConfigIndex := index.NewIndex()
+	reconciler := newTestAuthConfigReconciler(client, authConfigIndex)
+
+	resourceId := authConfig.Namespace + "/" + authConfig.Name
+	_, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Name: authConfig.Name, Namespace: authConfig.Namespace}})
+
+	assert.ErrorContains(t, err, "failed to compile policy authorino/auth-config-1/main-policy")
+	report, _ := reconciler.StatusReport.Get(resourceId)
+	assert.Equal(t, report.Reason, api.StatusReasonPolicyCompilationFailed)
+	assert.Check(t, authConfigIndex.Get("echo-api") == nil)
+}
+
+func TestReconcileAuthConfigWithInvalidPattern(t *testing.T) {
+	authConfig := newTestAuthConfig(map[string]string{})
+	authConfig.Spec.Authorization[1].JSON.Rules[0].JSONPatternExpression = api.JSONPatternExpression{
+		Selector: "context.request.http.path",
+		Operator: "matches",
+		Value:    "^/pets/(",
+	}
+	secret := newTestOAuthClientSecret()
+	client := newTestK8sClient(&authConfig, &secret)
+	authConfigIndex := index.NewIndex()
+	reconciler := newTestAuthConfigReconciler(client, authConfigIndex)
+
+	resourceId := authConfig.Namespace + "/" + authConfig.Name
+	_, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Name: authConfig.Name, Namespace: authConfig.Namespace}})
+
+	assert.ErrorContains(t, err, "invalid regular expression in pattern context.request.http.path matches ^/pets/(")
+	report, _ := reconciler.StatusReport.Get(resourceId)
+	assert.Equal(t, report.Reason, api.StatusReasonInvalidResource)
+	assert.Check(t, authConfigIndex.Get("echo-api") == nil)
+}
+
+func TestReconcileAuthConfigWithMissingSecretKey(t *testing.T) {
+	authConfig := newTestAuthConfig(map[string]string{})
+	secret := newTestOAuthClientSecret()
+	delete(secret.Data, "clientSecret")
+	client := newTestK8sClient(&authConfig, &secret)
+	authConfigIndex := index.NewIndex()
+	reconciler := newTestAuthConfigReconciler(client, authConfigIndex)
+
+	_, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Name: authConfig.Name, Namespace: authConfig.Namespace}})
+
+	assert.ErrorContains(t, err, "missing key clientSecret in secret authorino/secret")
+	assert.Check(t, authConfigIndex.Get("echo-api") == nil)
 }
 
 func TestAuthConfigNotFound(t *testing.T) {
@@ -226,6 +344,230 @@ func TestRemoveHostFromAuthConfig(t *testing.T) {
 	assert.Check(t, config == nil)
 }
 
+func TestUpdateAuthConfig(t *testing.T) {
+	authConfigIndex := index.NewIndex()
+	authConfig := newTestAuthConfig(map[string]string{})
+	authConfigName := types.NamespacedName{Name: authConfig.Name, Namespace: authConfig.Namespace}
+	secret := newTestOAuthClientSecret()
+	client := newTestK8sClient(&authConfig, &secret)
+	reconciler := newTestAuthConfigReconciler(client, authConfigIndex)
+
+	_, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: authConfigName})
+	assert.NilError(t, err)
+	previousConfig := authConfigIndex.Get("echo-api")
+	assert.Check(t, previousConfig != nil)
+
+	// invalid update: the previous config keeps serving requests
+	authConfig.Spec.Metadata[1].UMA.Credentials.Name = "missing-secret"
+	_ = client.Update(context.Background(), &authConfig)
+	_, err = reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: authConfigName})
+	assert.Check(t, errors.IsNotFound(err))
+	assert.Check(t, authConfigIndex.Get("echo-api") == previousConfig)
+
+	// valid update: the new config replaces the previous one as a whole
+	authConfig.Spec.Metadata = authConfig.Spec.Metadata[:1]
+	_ = client.Update(context.Background(), &authConfig)
+	_, err = reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: authConfigName})
+	assert.NilError(t, err)
+	config := authConfigIndex.Get("echo-api")
+	assert.Check(t, config != previousConfig)
+	assert.Equal(t, len(config.MetadataConfigs), 1)
+	assert.Equal(t, len(previousConfig.MetadataConfigs), 2)
+
+	// the requests evaluated with each config are tracked apart, so the previous config is cleaned once they are over
+	assert.Check(t, config.InFlight != nil)
+	assert.Check(t, config.InFlight != previousConfig.InFlight)
+}
+
+func TestRollbackAuthConfig(t *testing.T) {
+	authConfigIndex := index.NewIndex()
+	authConfig := newTestAuthConfig(map[string]string{})
+	authConfig.Generation = 1
+	authConfigName := types.NamespacedName{Name: authConfig.Name, Namespace: authConfig.Namespace}
+	secret := newTestOAuthClientSecret()
+	client := newTestK8sClient(&authConfig, &secret)
+	reconciler := newTestAuthConfigReconciler(client, authConfigIndex)
+	reconciler.Revisions = NewRevisionHistory(3)
+
+	_, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: authConfigName})
+	assert.NilError(t, err)
+
+	// new revision
+	authConfig.Generation = 2
+	authConfig.Spec.Hosts = []string{"other-api"}
+	authConfig.Spec.Metadata = authConfig.Spec.Metadata[:1]
+	_ = client.Update(context.Background(), &authConfig)
+	_, err = reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: authConfigName})
+	assert.NilError(t, err)
+	assert.Check(t, authConfigIndex.Get("echo-api") == nil)
+	assert.Equal(t, len(authConfigIndex.Get("other-api").MetadataConfigs), 1)
+	assert.DeepEqual(t, reconciler.Revisions.Generations(authConfigName.String()), []int64{1, 2})
+
+	// rollback to a revision not in the history
+	authConfig.Annotations = map[string]string{RollbackToAnnotation: "7"}
+	_ = client.Update(context.Background(), &authConfig)
+	_, err = reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: authConfigName})
+	assert.NilError(t, err)
+	status, _ := reconciler.StatusReport.Get(authConfigName.String())
+	assert.Equal(t, status.Reason, api.StatusReasonInvalidResource)
+	assert.Equal(t, status.Message, "revision 7 not found (available revisions: [1 2])")
+	assert.Check(t, authConfigIndex.Get("other-api") != nil)
+
+	// rollback to a previous revision
+	authConfig.Annotations = map[string]string{RollbackToAnnotation: "1"}
+	_ = client.Update(context.Background(), &authConfig)
+	_, err = reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: authConfigName})
+	assert.NilError(t, err)
+	assert.Check(t, authConfigIndex.Get("other-api") == nil)
+	assert.Equal(t, len(authConfigIndex.Get("echo-api").MetadataConfigs), 2)
+	status, _ = reconciler.StatusReport.Get(authConfigName.String())
+	assert.Equal(t, status.Reason, api.StatusReasonReconciled)
+	assert.Equal(t, status.Message, "rolled back to revision 1")
+	assert.DeepEqual(t, reconciler.Revisions.Generations(authConfigName.String()), []int64{1, 2})
+
+	// back to the current revision
+	authConfig.Annotations = nil
+	_ = client.Update(context.Background(), &authConfig)
+	_, err = reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: authConfigName})
+	assert.NilError(t, err)
+	assert.Check(t, authConfigIndex.Get("echo-api") == nil)
+	assert.Check(t, authConfigIndex.Get("other-api") != nil)
+}
+
+func TestCanaryAuthConfig(t *testing.T) {
+	authConfigIndex := index.NewIndex()
+	authConfig := newTestAuthConfig(map[string]string{})
+	authConfig.Generation = 1
+	authConfigName := types.NamespacedName{Name: authConfig.Name, Namespace: authConfig.Namespace}
+	secret := newTestOAuthClientSecret()
+	client := newTestK8sClient(&authConfig, &secret)
+	reconciler := newTestAuthConfigReconciler(client, authConfigIndex)
+	reconciler.Revisions = NewRevisionHistory(3)
+
+	_, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: authConfigName})
+	assert.NilError(t, err)
+
+	// new revision under canary
+	authConfig.Generation = 2
+	authConfig.Annotations = map[string]string{CanaryWeightAnnotation: "10"}
+	authConfig.Spec.Metadata = authConfig.Spec.Metadata[:1]
+	_ = client.Update(context.Background(), &authConfig)
+	_, err = reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: authConfigName})
+	assert.NilError(t, err)
+	config := authConfigIndex.Get("echo-api")
+	assert.Equal(t, config.Labels["generation"], "1")
+	assert.Equal(t, len(config.MetadataConfigs), 2)
+	assert.Check(t, config.Canary != nil)
+	assert.Equal(t, config.Canary.Labels["generation"], "2")
+	assert.Equal(t, len(config.Canary.MetadataConfigs), 1)
+	assert.Equal(t, config.CanaryWeight, 10)
+	status, _ := reconciler.StatusReport.Get(authConfigName.String())
+	assert.Equal(t, status.Reason, api.StatusReasonReconciled)
+	assert.Equal(t, status.Message, "revision 2 serving 10% of the requests (stable revision 1)")
+	assert.DeepEqual(t, reconciler.Revisions.Generations(authConfigName.String()), []int64{1})
+
+	// invalid weight
+	authConfig.Annotations = map[string]string{CanaryWeightAnnotation: "150"}
+	_ = client.Update(context.Background(), &authConfig)
+	_, err = reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: authConfigName})
+	assert.NilError(t, err)
+	status, _ = reconciler.StatusReport.Get(authConfigName.String())
+	assert.Equal(t, status.Reason, api.StatusReasonInvalidResource)
+	assert.Equal(t, status.Message, `invalid canary weight "150": must be a percentage between 0 and 100`)
+	assert.Equal(t, authConfigIndex.Get("echo-api").CanaryWeight, 10)
+
+	// promoted
+	authConfig.Annotations = nil
+	_ = client.Update(context.Background(), &authConfig)
+	_, err = reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: authConfigName})
+	assert.NilError(t, err)
+	config = authConfigIndex.Get("echo-api")
+	assert.Equal(t, config.Labels["generation"], "2")
+	assert.Check(t, config.Canary == nil)
+	assert.DeepEqual(t, reconciler.Revisions.Generations(authConfigName.String()), []int64{1, 2})
+
+	// no revision other than the current one to keep serving the requests
+	authConfig.Annotations = map[string]string{CanaryWeightAnnotation: "10"}
+	_ = client.Update(context.Background(), &authConfig)
+	_, err = reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: authConfigName})
+	assert.NilError(t, err)
+	config = authConfigIndex.Get("echo-api")
+	assert.Equal(t, config.Labels["generation"], "2")
+	assert.Check(t, config.Canary == nil)
+}
+
+func TestAuthConfigPriority(t *testing.T) {
+	authConfigIndex := index.NewIndex()
+	lowPriority := newTestAuthConfig(map[string]string{})
+	lowPriorityName := types.NamespacedName{Name: lowPriority.Name, Namespace: lowPriority.Namespace}
+	highPriority := newTestAuthConfig(map[string]string{})
+	highPriority.Name = "auth-config-2"
+	highPriority.Spec.Priority = 10
+	highPriorityName := types.NamespacedName{Name: highPriority.Name, Namespace: highPriority.Namespace}
+	secret := newTestOAuthClientSecret()
+	client := newTestK8sClient(&lowPriority, &highPriority, &secret)
+	reconciler := newTestAuthConfigReconciler(client, authConfigIndex)
+
+	_, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: lowPriorityName})
+	assert.NilError(t, err)
+	id, _ := authConfigIndex.FindId("echo-api")
+	assert.Equal(t, id, lowPriorityName.String())
+
+	// the host is taken over by the authconfig with higher priority, regardless of the reconciliation order
+	_, err = reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: highPriorityName})
+	assert.NilError(t, err)
+	id, _ = authConfigIndex.FindId("echo-api")
+	assert.Equal(t, id, highPriorityName.String())
+	assert.Equal(t, len(authConfigIndex.FindKeys(lowPriorityName.String())), 0)
+	status, _ := reconciler.StatusReport.Get(highPriorityName.String())
+	assert.Equal(t, status.Reason, api.StatusReasonReconciled)
+
+	_, err = reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: lowPriorityName})
+	assert.NilError(t, err)
+	id, _ = authConfigIndex.FindId("echo-api")
+	assert.Equal(t, id, highPriorityName.String())
+	status, _ = reconciler.StatusReport.Get(lowPriorityName.String())
+	assert.Equal(t, status.Reason, api.StatusReasonHostsNotLinked)
+
+	// an authconfig with priority cannot take over a host from another one with the same or higher priority
+	lowPriority.Spec.Priority = 10
+	_ = client.Update(context.Background(), &lowPriority)
+	_, err = reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: lowPriorityName})
+	assert.NilError(t, err)
+	status, _ = reconciler.StatusReport.Get(lowPriorityName.String())
+	assert.Equal(t, status.Reason, api.StatusReasonHostsNotLinked)
+	assert.Equal(t, status.Message, "one or more hosts are not linked to the resource: echo-api (already taken by authorino/auth-config-2 with priority 10)")
+
+	// the host is released
+	_ = client.Delete(context.Background(), &highPriority)
+	_, err = reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: highPriorityName})
+	assert.NilError(t, err)
+	_, err = reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: lowPriorityName})
+	assert.NilError(t, err)
+	id, _ = authConfigIndex.FindId("echo-api")
+	assert.Equal(t, id, lowPriorityName.String())
+}
+
+func TestRevisionHistory(t *testing.T) {
+	history := NewRevisionHistory(2)
+	for _, generation := range []int64{1, 2, 2, 3} {
+		history.Add("ns/authconfig", api.AuthConfig{ObjectMeta: metav1.ObjectMeta{Generation: generation}})
+	}
+	assert.DeepEqual(t, history.Generations("ns/authconfig"), []int64{2, 3})
+	_, found := history.Get("ns/authconfig", 1)
+	assert.Check(t, !found)
+	revision, found := history.Get("ns/authconfig", 2)
+	assert.Check(t, found)
+	assert.Equal(t, revision.Generation, int64(2))
+
+	history.Clear("ns/authconfig")
+	assert.Equal(t, len(history.Generations("ns/authconfig")), 0)
+
+	disabled := NewRevisionHistory(0)
+	disabled.Add("ns/authconfig", api.AuthConfig{ObjectMeta: metav1.ObjectMeta{Generation: 1}})
+	assert.Equal(t, len(disabled.Generations("ns/authconfig")), 0)
+}
+
 func TestTranslateAuthConfig(t *testing.T) {
 	// TODO
 }
@@ -241,6 +583,8 @@ func TestPreventHostCollisionExactMatches(t *testing.T) {
 	secret := newTestOAuthClientSecret()
 	client := newTestK8sClient(&authConfig, &secret)
 	reconciler := newTestAuthConfigReconciler(client, indexMock)
+	recorder := record.NewFakeRecorder(10)
+	reconciler.Recorder = recorder
 
 	indexMock.EXPECT().Empty().Return(false)                                                                              // simulate index not empty, so it skips bootstraping
 	indexMock.EXPECT().FindKeys(authConfigName.String()).Return([]string{}).AnyTimes()                                    // simulate no prexisting hosts linked to the authconfig to be reconciled
@@ -254,6 +598,15 @@ func TestPreventHostCollisionExactMatches(t *testing.T) {
 
 	assert.DeepEqual(t, result, ctrl.Result{})
 	assert.NilError(t, err)
+
+	report, _ := reconciler.StatusReport.Get(authConfigName.String())
+	assert.Equal(t, report.Reason, api.StatusReasonHostsNotLinked)
+	assert.Equal(t, report.Message, "one or more hosts are not linked to the resource: echo-api (already taken by other-namespace/other-auth-config-with-same-host), other.io (already taken by authorino/other-auth-config-same-ns)")
+	assert.DeepEqual(t, report.LinkedHosts, []string{"yet-another.io"})
+
+	assert.Equal(t, len(recorder.Events), 2)
+	assert.Equal(t, <-recorder.Events, "Warning HostsNotLinked Host echo-api already taken by authconfig other-namespace/other-auth-config-with-same-host")
+	assert.Equal(t, <-recorder.Events, "Warning HostsNotLinked Host other.io already taken by authconfig authorino/other-auth-config-same-ns")
 }
 
 func TestPreventHostCollisionAllowSupersedingHostSubsets(t *testing.T) {
@@ -325,6 +678,31 @@ func TestMissingWatchedAuthConfigLabels(t *testing.T) {
 	assert.DeepEqual(t, result, ctrl.Result{}) // Result should be empty
 }
 
+func TestAuthConfigOutOfWatchedNamespace(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+	indexMock := mock_index.NewMockIndex(mockController)
+
+	authConfig := newTestAuthConfig(map[string]string{})
+	authConfigName := types.NamespacedName{Name: authConfig.Name, Namespace: authConfig.Namespace}
+	secret := newTestOAuthClientSecret()
+	client := newTestK8sClient(&authConfig, &secret)
+	reconciler := newTestAuthConfigReconciler(client, indexMock)
+	reconciler.Namespace = "other-namespace"
+
+	indexMock.EXPECT().Empty().Return(false)
+	indexMock.EXPECT().FindKeys(authConfigName.String()).Return([]string{}).AnyTimes()
+	indexMock.EXPECT().Delete(authConfigName.String())
+
+	result, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: authConfigName})
+
+	assert.DeepEqual(t, result, ctrl.Result{})
+	assert.NilError(t, err)
+	assert.Check(t, !reconciler.ClusterWide())
+	assert.Check(t, reconciler.InScope("other-namespace"))
+	assert.Check(t, !reconciler.InScope(authConfig.Namespace))
+}
+
 func TestMatchingAuthConfigLabels(t *testing.T) {
 	mockController := gomock.NewController(t)
 	defer mockController.Finish()
@@ -370,6 +748,49 @@ func TestUnmatchingAuthConfigLabels(t *testing.T) {
 	assert.DeepEqual(t, result, ctrl.Result{}) // Result should be empty
 }
 
+func TestIdentityProviderRefs(t *testing.T) {
+	identityProvider := &v1beta2.IdentityProvider{
+		ObjectMeta: metav1.ObjectMeta{Name: "keycloak"},
+		Spec: v1beta2.IdentityProviderSpec{
+			IssuerUrl: "http://127.0.0.1:9001/auth/realms/demo",
+			TokenIntrospection: &v1beta2.IdentityProviderTokenIntrospectionSpec{
+				Url:         "http://127.0.0.1:9001/auth/realms/demo/protocol/openid-connect/token/introspect",
+				Credentials: &v1.SecretReference{Namespace: "keycloak", Name: "oauth2-client"},
+			},
+		},
+	}
+	credentials := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "keycloak", Name: "oauth2-client"},
+		Data:       map[string][]byte{"clientID": []byte("authorino"), "clientSecret": []byte("s3cr3t")},
+	}
+	authConfig := newTestAuthConfig(map[string]string{})
+	authConfig.Spec.Identity = []*api.Identity{
+		{Name: "jwt", Oidc: &api.Identity_OidcConfig{IdentityProviderRef: &api.IdentityProviderReference{Name: "keycloak"}}},
+		{Name: "opaque", OAuth2: &api.Identity_OAuth2Config{IdentityProviderRef: &api.IdentityProviderReference{Name: "keycloak"}}},
+	}
+	authConfig.Spec.Metadata = nil
+	reconciler := newTestAuthConfigReconciler(newTestK8sClient(identityProvider, credentials), index.NewIndex())
+
+	_, err := reconciler.translateAuthConfig(context.TODO(), &authConfig)
+	assert.ErrorContains(t, err, "identity providers are disabled")
+
+	reconciler.IdentityProviders = true
+	config, err := reconciler.translateAuthConfig(context.TODO(), &authConfig)
+	assert.NilError(t, err)
+	assert.Equal(t, config.IdentityConfigs[0].(*evaluators.IdentityConfig).OIDC.Endpoint, "http://127.0.0.1:9001/auth/realms/demo")
+	oauth2 := config.IdentityConfigs[1].(*evaluators.IdentityConfig).OAuth2
+	assert.Equal(t, oauth2.TokenIntrospectionUrl, "http://127.0.0.1:9001/auth/realms/demo/protocol/openid-connect/token/introspect")
+	assert.Equal(t, oauth2.ClientID, "authorino")
+	assert.Equal(t, oauth2.ClientSecret, "s3cr3t")
+
+	// mapping of the identity provider to the resources that refer to it
+	_ = reconciler.Create(context.TODO(), &authConfig)
+	requests := reconciler.authConfigsReferringTo(identityProvider)
+	assert.DeepEqual(t, requests, []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: authConfig.Namespace, Name: authConfig.Name}}})
+	identityProvider.Name = "other"
+	assert.Equal(t, len(reconciler.authConfigsReferringTo(identityProvider)), 0)
+}
+
 func TestEmptyAuthConfigIdentitiesDefaultsToAnonymousAccess(t *testing.T) {
 	r := &AuthConfigReconciler{}
 	config, err := r.translateAuthConfig(context.TODO(), &api.AuthConfig{
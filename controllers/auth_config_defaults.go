@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"context"
+	"sort"
+
+	api "github.com/kuadrant/authorino/api/v1beta1"
+	"github.com/kuadrant/authorino/api/v1beta2"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// applyDefaults returns the AuthConfig with the AuthConfigDefaults of its namespace merged into its spec, in the
+// alphabetical order of the names of the defaults.
+// The resource is returned untouched if there are no defaults in the namespace.
+func (r *AuthConfigReconciler) applyDefaults(ctx context.Context, authConfig *api.AuthConfig) (*api.AuthConfig, []string, error) {
+	if !r.Defaults {
+		return authConfig, nil, nil
+	}
+
+	defaultsList := &v1beta2.AuthConfigDefaultsList{}
+	if err := r.List(ctx, defaultsList, client.InNamespace(authConfig.Namespace)); err != nil {
+		return nil, nil, err
+	}
+	if len(defaultsList.Items) == 0 {
+		return authConfig, nil, nil
+	}
+	defaults := defaultsList.Items
+	sort.Slice(defaults, func(i, j int) bool { return defaults[i].Name < defaults[j].Name })
+
+	// defaults are expressed in the format of the v1beta2 api
+	converted := &v1beta2.AuthConfig{}
+	if err := converted.ConvertFrom(authConfig.DeepCopy()); err != nil {
+		return nil, nil, err
+	}
+
+	names := make([]string, len(defaults))
+	for i, d := range defaults {
+		mergeDefaults(&converted.Spec, d.DeepCopy().Spec)
+		names[i] = d.Name
+	}
+
+	effective := &api.AuthConfig{}
+	if err := converted.ConvertTo(effective); err != nil {
+		return nil, nil, err
+	}
+	return effective, names, nil
+}
+
+// mergeDefaults sets into the spec of an AuthConfig the defaults it does not declare
+func mergeDefaults(spec *v1beta2.AuthConfigSpec, defaults v1beta2.AuthConfigDefaultsSpec) {
+	for name, authentication := range defaults.Authentication {
+		if spec.Authentication == nil {
+			spec.Authentication = map[string]v1beta2.AuthenticationSpec{}
+		}
+		if _, declared := spec.Authentication[name]; !declared {
+			spec.Authentication[name] = authentication
+		}
+	}
+
+	if defaults.Response == nil {
+		return
+	}
+	if spec.Response == nil {
+		spec.Response = &v1beta2.ResponseSpec{}
+	}
+	response := spec.Response
+	if response.Unauthenticated == nil {
+		response.Unauthenticated = defaults.Response.Unauthenticated
+	}
+	if response.Unauthorized == nil {
+		response.Unauthorized = defaults.Response.Unauthorized
+	}
+	success := defaults.Response.Success
+	response.Success.Headers = mergeMissing(response.Success.Headers, success.Headers)
+	response.Success.ResponseHeaders = mergeMissing(response.Success.ResponseHeaders, success.ResponseHeaders)
+	response.Success.DynamicMetadata = mergeMissing(response.Success.DynamicMetadata, success.DynamicMetadata)
+	if response.Success.DynamicMetadataNamespace == "" {
+		response.Success.DynamicMetadataNamespace = success.DynamicMetadataNamespace
+	}
+}
+
+func mergeMissing[T any](m, defaults map[string]T) map[string]T {
+	for key, value := range defaults {
+		if m == nil {
+			m = map[string]T{}
+		}
+		if _, found := m[key]; !found {
+			m[key] = value
+		}
+	}
+	return m
+}
+
+// authConfigsDefaultedBy maps an AuthConfigDefaults to the requests to reconcile the resources of its namespace
+func (r *AuthConfigReconciler) authConfigsDefaultedBy(object client.Object) []reconcile.Request {
+	authConfigList, err := r.listAuthConfigs(context.Background())
+	if err != nil {
+		r.Logger.Error(err, "failed to list resources of the namespace of defaults", "defaults", client.ObjectKeyFromObject(object).String())
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for _, authConfig := range authConfigList.Items {
+		if authConfig.Namespace == object.GetNamespace() {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: authConfig.Namespace, Name: authConfig.Name}})
+		}
+	}
+	return requests
+}
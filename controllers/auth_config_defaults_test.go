@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	api "github.com/kuadrant/authorino/api/v1beta1"
+	"github.com/kuadrant/authorino/api/v1beta2"
+	"github.com/kuadrant/authorino/pkg/evaluators"
+	"github.com/kuadrant/authorino/pkg/index"
+
+	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestReconcileAuthConfigWithDefaults(t *testing.T) {
+	org := &v1beta2.AuthConfigDefaults{
+		ObjectMeta: metav1.ObjectMeta{Name: "org", Namespace: "authorino"},
+		Spec: v1beta2.AuthConfigDefaultsSpec{
+			Authentication: map[string]v1beta2.AuthenticationSpec{
+				"keycloak": { // declared in the authconfig
+					AuthenticationMethodSpec: v1beta2.AuthenticationMethodSpec{AnonymousAccess: &v1beta2.AnonymousAccessSpec{}},
+				},
+				"anonymous": {
+					AuthenticationMethodSpec: v1beta2.AuthenticationMethodSpec{AnonymousAccess: &v1beta2.AnonymousAccessSpec{}},
+				},
+			},
+			Response: &v1beta2.ResponseSpec{
+				Unauthenticated: &v1beta2.DenyWithSpec{Code: 302},
+				Success: v1beta2.WrappedSuccessResponseSpec{
+					Headers: map[string]v1beta2.HeaderSuccessResponseSpec{
+						"x-org": {SuccessResponseSpec: v1beta2.SuccessResponseSpec{AuthResponseMethodSpec: v1beta2.AuthResponseMethodSpec{Plain: &v1beta2.PlainAuthResponseSpec{Value: runtime.RawExtension{Raw: []byte(`"acme"`)}}}}},
+					},
+				},
+			},
+		},
+	}
+	team := &v1beta2.AuthConfigDefaults{
+		ObjectMeta: metav1.ObjectMeta{Name: "team", Namespace: "authorino"},
+		Spec: v1beta2.AuthConfigDefaultsSpec{
+			Response: &v1beta2.ResponseSpec{
+				Unauthenticated: &v1beta2.DenyWithSpec{Code: 401}, // org defaults prevail
+				Unauthorized:    &v1beta2.DenyWithSpec{Code: 404},
+			},
+		},
+	}
+	otherNamespace := &v1beta2.AuthConfigDefaults{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "other"},
+		Spec: v1beta2.AuthConfigDefaultsSpec{
+			Authentication: map[string]v1beta2.AuthenticationSpec{
+				"other": {
+					AuthenticationMethodSpec: v1beta2.AuthenticationMethodSpec{AnonymousAccess: &v1beta2.AnonymousAccessSpec{}},
+				},
+			},
+		},
+	}
+	authConfig := newTestAuthConfig(map[string]string{})
+	secret := newTestOAuthClientSecret()
+	authConfigIndex := index.NewIndex()
+	reconciler := newTestAuthConfigReconciler(newTestK8sClient(&authConfig, &secret, org, team, otherNamespace), authConfigIndex)
+	reconciler.Defaults = true
+
+	effective, defaults, err := reconciler.applyDefaults(context.TODO(), &authConfig)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, defaults, []string{"org", "team"})
+	assert.Equal(t, len(effective.Spec.Identity), 2)
+	for _, identity := range effective.Spec.Identity {
+		switch identity.Name {
+		case "keycloak":
+			assert.Check(t, identity.Oidc != nil)
+		case "anonymous":
+			assert.Check(t, identity.Anonymous != nil)
+		default:
+			t.Errorf("unexpected identity %s", identity.Name)
+		}
+	}
+	assert.Equal(t, len(effective.Spec.Response), 1)
+	assert.Equal(t, effective.Spec.Response[0].Name, "x-org")
+	assert.Equal(t, effective.Spec.DenyWith.Unauthenticated.Code, api.DenyWith_Code(302))
+	assert.Equal(t, effective.Spec.DenyWith.Unauthorized.Code, api.DenyWith_Code(404))
+	assert.Equal(t, len(authConfig.Spec.Identity), 1) // the resource is not modified
+
+	_, err = reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: authConfig.Namespace, Name: authConfig.Name}})
+	assert.NilError(t, err)
+	config := authConfigIndex.Get("echo-api")
+	assert.Check(t, config != nil)
+	assert.Equal(t, len(config.IdentityConfigs), 2)
+	assert.Equal(t, len(config.ResponseConfigs), 1)
+	assert.Equal(t, config.ResponseConfigs[0].(*evaluators.ResponseConfig).Name, "x-org")
+	assert.Equal(t, config.Unauthenticated.Code, int32(302))
+
+	// mapping of the defaults to the resources of the namespace
+	requests := reconciler.authConfigsDefaultedBy(team)
+	assert.DeepEqual(t, requests, []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: authConfig.Namespace, Name: authConfig.Name}}})
+	assert.Equal(t, len(reconciler.authConfigsDefaultedBy(otherNamespace)), 0)
+
+	// disabled
+	reconciler.Defaults = false
+	effective, defaults, err = reconciler.applyDefaults(context.TODO(), &authConfig)
+	assert.NilError(t, err)
+	assert.Equal(t, len(defaults), 0)
+	assert.Equal(t, effective, &authConfig)
+}
@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	api "github.com/kuadrant/authorino/api/v1beta1"
+	"github.com/kuadrant/authorino/api/v1beta2"
+
+	authnv1 "k8s.io/api/authentication/v1"
+	authzv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// EffectiveAuthConfig returns the effective config of a resource last reconciled successfully, i.e. the resource as
+// defaulted by the API server and with the defaults of the namespace and the overlays applied, in the format of the
+// v1beta2 api
+func (r *AuthConfigReconciler) EffectiveAuthConfig(resourceId string) (*v1beta2.AuthConfig, bool) {
+	r.effectiveMu.RLock()
+	authConfig, found := r.effective[resourceId]
+	r.effectiveMu.RUnlock()
+
+	if !found {
+		return nil, false
+	}
+	converted, err := toV1beta2(authConfig)
+	if err != nil {
+		r.Logger.Error(err, "failed to convert effective config", "authconfig", resourceId)
+		return nil, false
+	}
+	return converted, true
+}
+
+// DryRun reconciles a candidate AuthConfig without changing either the cluster or the index: the resource is
+// submitted to the API server in dry-run mode to be defaulted and validated, the defaults of the namespace and the
+// overlays are applied and the config is built, but not indexed.
+// The caller, identified by its Kubernetes token, must be allowed to create the AuthConfig in its namespace, so
+// the dry-run cannot be used to make Authorino read secrets or reach endpoints on behalf of whoever cannot.
+// It returns the effective config and the hosts that would not be linked to the resource for being taken by others.
+func (r *AuthConfigReconciler) DryRun(ctx context.Context, candidate *api.AuthConfig, callerToken string) (*v1beta2.AuthConfig, map[string]string, error) {
+	authConfig := candidate.DeepCopy()
+	if authConfig.Namespace == "" {
+		authConfig.Namespace = r.Namespace
+	}
+	if !Watched(&authConfig.ObjectMeta, r.LabelSelector) || !r.InScope(authConfig.Namespace) {
+		return nil, nil, fmt.Errorf("authconfig %s/%s is out of the scope of this instance", authConfig.Namespace, authConfig.Name)
+	}
+
+	if err := r.authorizeDryRun(ctx, authConfig, callerToken); err != nil {
+		return nil, nil, err
+	}
+
+	if err := r.admit(ctx, authConfig); err != nil {
+		return nil, nil, err
+	}
+
+	defaultedAuthConfig, _, err := r.applyDefaults(ctx, authConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	effectiveAuthConfig, _, err := r.applyOverlays(ctx, defaultedAuthConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	translatedAuthConfig, err := r.translateAuthConfig(ctx, effectiveAuthConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	// shuts down the async workers started while building the config
+	if err := r.cleanConfigs(translatedAuthConfig, ctx); err != nil {
+		r.Logger.Error(err, failedToCleanConfig)
+	}
+
+	resourceId := types.NamespacedName{Namespace: authConfig.Namespace, Name: authConfig.Name}.String()
+	looseHosts := map[string]string{}
+	for _, host := range effectiveAuthConfig.Spec.Hosts {
+		if indexedResourceId, taken := r.hostTaken(host, resourceId); taken && !r.outranks(effectiveAuthConfig.Spec.Priority, indexedResourceId) {
+			looseHosts[host] = indexedResourceId
+		}
+	}
+
+	converted, err := toV1beta2(strippedAuthConfig(*effectiveAuthConfig))
+	if err != nil {
+		return nil, nil, err
+	}
+	return converted, looseHosts, nil
+}
+
+// authorizeDryRun reviews the token of the caller of a dry-run and checks whether the user it belongs to may create
+// the AuthConfig in its namespace
+func (r *AuthConfigReconciler) authorizeDryRun(ctx context.Context, authConfig *api.AuthConfig, callerToken string) error {
+	resource := schema.GroupResource{Group: api.GroupVersion.Group, Resource: "authconfigs"}
+
+	if callerToken == "" {
+		return errors.NewUnauthorized("missing kubernetes token of the caller")
+	}
+	tokenReview := &authnv1.TokenReview{Spec: authnv1.TokenReviewSpec{Token: callerToken}}
+	if err := r.Create(ctx, tokenReview); err != nil {
+		return err
+	}
+	if !tokenReview.Status.Authenticated {
+		return errors.NewUnauthorized("invalid kubernetes token of the caller")
+	}
+
+	user := tokenReview.Status.User
+	extra := make(map[string]authzv1.ExtraValue, len(user.Extra))
+	for key, value := range user.Extra {
+		extra[key] = authzv1.ExtraValue(value)
+	}
+	accessReview := &authzv1.SubjectAccessReview{
+		Spec: authzv1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			UID:    user.UID,
+			Groups: user.Groups,
+			Extra:  extra,
+			ResourceAttributes: &authzv1.ResourceAttributes{
+				Namespace: authConfig.Namespace,
+				Verb:      "create",
+				Group:     resource.Group,
+				Resource:  resource.Resource,
+				Name:      authConfig.Name,
+			},
+		},
+	}
+	if err := r.Create(ctx, accessReview); err != nil {
+		return err
+	}
+	if !accessReview.Status.Allowed {
+		return errors.NewForbidden(resource, authConfig.Name, fmt.Errorf("user %s cannot create authconfigs in the namespace %s", user.Username, authConfig.Namespace))
+	}
+	return nil
+}
+
+// admit submits a resource to the API server in dry-run mode, so it is defaulted and validated (including by the
+// webhooks) as it would be when created, or updated if it exists already
+func (r *AuthConfigReconciler) admit(ctx context.Context, authConfig *api.AuthConfig) error {
+	authConfig.ResourceVersion = ""
+	authConfig.UID = ""
+
+	err := r.Create(ctx, authConfig, client.DryRunAll)
+	if !errors.IsAlreadyExists(err) {
+		return err
+	}
+	existing := &api.AuthConfig{}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(authConfig), existing); err != nil {
+		return err
+	}
+	authConfig.ResourceVersion = existing.ResourceVersion
+	return r.Update(ctx, authConfig, client.DryRunAll)
+}
+
+func (r *AuthConfigReconciler) setEffective(resourceId string, authConfig api.AuthConfig) {
+	r.effectiveMu.Lock()
+	defer r.effectiveMu.Unlock()
+
+	if r.effective == nil {
+		r.effective = make(map[string]api.AuthConfig)
+	}
+	r.effective[resourceId] = strippedAuthConfig(authConfig)
+}
+
+func (r *AuthConfigReconciler) clearEffective(resourceId string) {
+	r.effectiveMu.Lock()
+	defer r.effectiveMu.Unlock()
+
+	delete(r.effective, resourceId)
+}
+
+func toV1beta2(authConfig api.AuthConfig) (*v1beta2.AuthConfig, error) {
+	converted := &v1beta2.AuthConfig{}
+	if err := converted.ConvertFrom(&authConfig); err != nil {
+		return nil, err
+	}
+	converted.Kind = "AuthConfig"
+	converted.APIVersion = v1beta2.GroupVersion.String()
+	return converted, nil
+}
@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kuadrant/authorino/api/v1beta2"
+	"github.com/kuadrant/authorino/pkg/index"
+
+	"gotest.tools/assert"
+	authnv1 "k8s.io/api/authentication/v1"
+	authzv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// accessReviewingClient answers the token and subject access reviews sent to the api server, authenticating "token"
+// as "john", who may create authconfigs in the allowed namespaces only
+type accessReviewingClient struct {
+	client.WithWatch
+	allowedNamespaces []string
+}
+
+func (c *accessReviewingClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	switch review := obj.(type) {
+	case *authnv1.TokenReview:
+		if review.Spec.Token == "token" {
+			review.Status = authnv1.TokenReviewStatus{Authenticated: true, User: authnv1.UserInfo{Username: "john"}}
+		}
+		return nil
+	case *authzv1.SubjectAccessReview:
+		attrs := review.Spec.ResourceAttributes
+		for _, namespace := range c.allowedNamespaces {
+			if review.Spec.User == "john" && attrs.Verb == "create" && attrs.Resource == "authconfigs" && attrs.Namespace == namespace {
+				review.Status.Allowed = true
+			}
+		}
+		return nil
+	}
+	return c.WithWatch.Create(ctx, obj, opts...)
+}
+
+func TestEffectiveAuthConfig(t *testing.T) {
+	authConfig := newTestAuthConfig(map[string]string{})
+	secret := newTestOAuthClientSecret()
+	reconciler := newTestAuthConfigReconciler(newTestK8sClient(&authConfig, &secret), index.NewIndex())
+	resourceName := types.NamespacedName{Namespace: authConfig.Namespace, Name: authConfig.Name}
+
+	_, found := reconciler.EffectiveAuthConfig(resourceName.String())
+	assert.Check(t, !found)
+
+	_, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: resourceName})
+	assert.NilError(t, err)
+
+	effective, found := reconciler.EffectiveAuthConfig(resourceName.String())
+	assert.Check(t, found)
+	assert.Equal(t, effective.APIVersion, v1beta2.GroupVersion.String())
+	assert.Equal(t, effective.Kind, "AuthConfig")
+	assert.Equal(t, effective.Namespace, "authorino")
+	assert.Equal(t, effective.Name, "auth-config-1")
+	assert.DeepEqual(t, effective.Spec.Hosts, []string{"echo-api"})
+	assert.Equal(t, len(effective.Spec.Authentication), 1)
+	assert.Equal(t, len(effective.Spec.Authorization), 2)
+
+	// deleted
+	_ = reconciler.Delete(context.TODO(), &authConfig)
+	_, err = reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: resourceName})
+	assert.NilError(t, err)
+	_, found = reconciler.EffectiveAuthConfig(resourceName.String())
+	assert.Check(t, !found)
+}
+
+func TestDryRunAuthConfig(t *testing.T) {
+	authConfig := newTestAuthConfig(map[string]string{})
+	secret := newTestOAuthClientSecret()
+	authConfigIndex := index.NewIndex()
+	reconciler := newTestAuthConfigReconciler(&accessReviewingClient{WithWatch: newTestK8sClient(&authConfig, &secret), allowedNamespaces: []string{"authorino"}}, authConfigIndex)
+
+	_, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: authConfig.Namespace, Name: authConfig.Name}})
+	assert.NilError(t, err)
+
+	candidate := newTestAuthConfig(map[string]string{})
+	candidate.Name = "auth-config-2"
+	candidate.Spec.Hosts = []string{"echo-api", "other-api"}
+
+	effective, looseHosts, err := reconciler.DryRun(context.TODO(), &candidate, "token")
+	assert.NilError(t, err)
+	assert.Equal(t, effective.Name, "auth-config-2")
+	assert.DeepEqual(t, effective.Spec.Hosts, []string{"echo-api", "other-api"})
+	assert.DeepEqual(t, looseHosts, map[string]string{"echo-api": "authorino/auth-config-1"})
+	assert.Check(t, authConfigIndex.Get("other-api") == nil) // not indexed
+	assert.DeepEqual(t, authConfigIndex.ListIds(), []string{"authorino/auth-config-1"})
+
+	// invalid
+	candidate.Spec.Metadata[1].UMA.Credentials.Name = "missing"
+	_, _, err = reconciler.DryRun(context.TODO(), &candidate, "token")
+	assert.ErrorContains(t, err, "not found")
+
+	// out of scope
+	reconciler.Namespace = "other"
+	_, _, err = reconciler.DryRun(context.TODO(), &candidate, "token")
+	assert.Error(t, err, "authconfig authorino/auth-config-2 is out of the scope of this instance")
+	reconciler.Namespace = ""
+
+	// caller not authenticated
+	_, _, err = reconciler.DryRun(context.TODO(), &candidate, "")
+	assert.Check(t, errors.IsUnauthorized(err))
+	_, _, err = reconciler.DryRun(context.TODO(), &candidate, "other")
+	assert.Check(t, errors.IsUnauthorized(err))
+
+	// caller not allowed to create authconfigs in the namespace
+	candidate.Namespace = "other"
+	_, _, err = reconciler.DryRun(context.TODO(), &candidate, "token")
+	assert.Check(t, errors.IsForbidden(err))
+}
@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	api "github.com/kuadrant/authorino/api/v1beta1"
+	"github.com/kuadrant/authorino/api/v1beta2"
+	"github.com/kuadrant/authorino/pkg/utils"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// applyOverlays returns the effective AuthConfig, i.e. the resource with the patches of all the AuthConfigOverlays that
+// select it applied to its spec, in the alphabetical order of the names of the overlays.
+// The resource is returned untouched if no overlay selects it.
+func (r *AuthConfigReconciler) applyOverlays(ctx context.Context, authConfig *api.AuthConfig) (*api.AuthConfig, []string, error) {
+	if !r.Overlays {
+		return authConfig, nil, nil
+	}
+
+	overlayList := &v1beta2.AuthConfigOverlayList{}
+	if err := r.List(ctx, overlayList); err != nil {
+		return nil, nil, err
+	}
+
+	var overlays []v1beta2.AuthConfigOverlay
+	for _, overlay := range overlayList.Items {
+		selected, err := overlaySelects(&overlay, authConfig)
+		if err != nil {
+			return nil, nil, err
+		}
+		if selected {
+			overlays = append(overlays, overlay)
+		}
+	}
+	if len(overlays) == 0 {
+		return authConfig, nil, nil
+	}
+	sort.Slice(overlays, func(i, j int) bool { return overlays[i].Name < overlays[j].Name })
+
+	// patches are expressed in the format of the v1beta2 api
+	converted := &v1beta2.AuthConfig{}
+	if err := converted.ConvertFrom(authConfig.DeepCopy()); err != nil {
+		return nil, nil, err
+	}
+	spec, err := json.Marshal(converted.Spec)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	names := make([]string, len(overlays))
+	for i, overlay := range overlays {
+		if spec, err = patchSpec(spec, overlay.Spec); err != nil {
+			return nil, nil, fmt.Errorf("failed to apply overlay %s: %w", overlay.Name, err)
+		}
+		var patched struct {
+			Hosts []string `json:"hosts"`
+		}
+		if err := json.Unmarshal(spec, &patched); err != nil {
+			return nil, nil, fmt.Errorf("failed to apply overlay %s: %w", overlay.Name, err)
+		}
+		if !reflect.DeepEqual(patched.Hosts, converted.Spec.Hosts) {
+			return nil, nil, fmt.Errorf("failed to apply overlay %s: overlays cannot change the hosts", overlay.Name)
+		}
+		names[i] = overlay.Name
+	}
+
+	overlaid := converted.DeepCopy()
+	overlaid.Spec = v1beta2.AuthConfigSpec{}
+	if err := json.Unmarshal(spec, &overlaid.Spec); err != nil {
+		return nil, nil, fmt.Errorf("invalid spec after applying overlays %v: %w", names, err)
+	}
+	effective := &api.AuthConfig{}
+	if err := overlaid.ConvertTo(effective); err != nil {
+		return nil, nil, err
+	}
+	return effective, names, nil
+}
+
+func patchSpec(spec []byte, overlay v1beta2.AuthConfigOverlaySpec) ([]byte, error) {
+	switch overlay.PatchType {
+	case v1beta2.MergeOverlayPatchType, "":
+		return jsonpatch.MergePatch(spec, overlay.Patch.Raw)
+	case v1beta2.JSONOverlayPatchType:
+		patch, err := jsonpatch.DecodePatch(overlay.Patch.Raw)
+		if err != nil {
+			return nil, err
+		}
+		return patch.Apply(spec)
+	default:
+		return nil, fmt.Errorf("unsupported patch type %s", overlay.PatchType)
+	}
+}
+
+// overlaySelects tells whether an AuthConfigOverlay applies to an AuthConfig
+func overlaySelects(overlay *v1beta2.AuthConfigOverlay, authConfig *api.AuthConfig) (bool, error) {
+	if len(overlay.Spec.Namespaces) > 0 && !utils.SliceContains(overlay.Spec.Namespaces, authConfig.Namespace) {
+		return false, nil
+	}
+	if overlay.Spec.Selector == nil {
+		return true, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(overlay.Spec.Selector)
+	if err != nil {
+		return false, fmt.Errorf("invalid selector in overlay %s: %w", overlay.Name, err)
+	}
+	return selector.Matches(labels.Set(authConfig.Labels)), nil
+}
+
+// authConfigsOverlaidBy maps an AuthConfigOverlay to the requests to reconcile the resources it selects.
+// On updates, both the old and the new versions of the overlay are mapped, so resources no longer selected are
+// reconciled as well.
+func (r *AuthConfigReconciler) authConfigsOverlaidBy(object client.Object) []reconcile.Request {
+	overlay, ok := object.(*v1beta2.AuthConfigOverlay)
+	if !ok {
+		return nil
+	}
+
+	authConfigList, err := r.listAuthConfigs(context.Background())
+	if err != nil {
+		r.Logger.Error(err, "failed to list resources selected by overlay", "overlay", overlay.Name)
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for i := range authConfigList.Items {
+		authConfig := &authConfigList.Items[i]
+		if selected, err := overlaySelects(overlay, authConfig); selected || err != nil {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: authConfig.Namespace, Name: authConfig.Name}})
+		}
+	}
+	return requests
+}
@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	api "github.com/kuadrant/authorino/api/v1beta1"
+	"github.com/kuadrant/authorino/api/v1beta2"
+	"github.com/kuadrant/authorino/pkg/index"
+
+	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestReconcileAuthConfigWithOverlays(t *testing.T) {
+	audit := &v1beta2.AuthConfigOverlay{
+		ObjectMeta: metav1.ObjectMeta{Name: "audit"},
+		Spec: v1beta2.AuthConfigOverlaySpec{
+			Selector:  &metav1.LabelSelector{MatchLabels: map[string]string{"team": "apps"}},
+			PatchType: v1beta2.MergeOverlayPatchType,
+			Patch:     runtime.RawExtension{Raw: []byte(`{"callbacks":{"audit":{"http":{"url":"http://audit"}}}}`)},
+		},
+	}
+	noUserInfo := &v1beta2.AuthConfigOverlay{
+		ObjectMeta: metav1.ObjectMeta{Name: "no-userinfo"},
+		Spec: v1beta2.AuthConfigOverlaySpec{
+			Namespaces: []string{"authorino"},
+			PatchType:  v1beta2.JSONOverlayPatchType,
+			Patch:      runtime.RawExtension{Raw: []byte(`[{"op":"remove","path":"/metadata/userinfo"}]`)},
+		},
+	}
+	otherNamespace := &v1beta2.AuthConfigOverlay{
+		ObjectMeta: metav1.ObjectMeta{Name: "other-namespace"},
+		Spec: v1beta2.AuthConfigOverlaySpec{
+			Namespaces: []string{"other"},
+			Patch:      runtime.RawExtension{Raw: []byte(`{"authorization":null}`)},
+		},
+	}
+	authConfig := newTestAuthConfig(map[string]string{"team": "apps"})
+	secret := newTestOAuthClientSecret()
+	authConfigIndex := index.NewIndex()
+	reconciler := newTestAuthConfigReconciler(newTestK8sClient(&authConfig, &secret, audit, noUserInfo, otherNamespace), authConfigIndex)
+	reconciler.Overlays = true
+
+	effective, overlays, err := reconciler.applyOverlays(context.TODO(), &authConfig)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, overlays, []string{"audit", "no-userinfo"})
+	assert.Equal(t, len(effective.Spec.Callbacks), 1)
+	assert.Equal(t, effective.Spec.Callbacks[0].Name, "audit")
+	assert.Equal(t, effective.Spec.Callbacks[0].HTTP.Endpoint, "http://audit")
+	assert.Equal(t, len(effective.Spec.Metadata), 1)
+	assert.Equal(t, effective.Spec.Metadata[0].Name, "resource-data")
+	assert.Equal(t, len(effective.Spec.Authorization), 2)
+	assert.Equal(t, len(authConfig.Spec.Callbacks), 0) // the resource is not modified
+
+	_, err = reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: authConfig.Namespace, Name: authConfig.Name}})
+	assert.NilError(t, err)
+	config := authConfigIndex.Get("echo-api")
+	assert.Check(t, config != nil)
+	assert.Equal(t, len(config.CallbackConfigs), 1)
+	assert.Equal(t, len(config.MetadataConfigs), 1)
+
+	// mapping of the overlays to the resources they select
+	requests := reconciler.authConfigsOverlaidBy(audit)
+	assert.DeepEqual(t, requests, []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: authConfig.Namespace, Name: authConfig.Name}}})
+	assert.Equal(t, len(reconciler.authConfigsOverlaidBy(otherNamespace)), 0)
+
+	// disabled
+	reconciler.Overlays = false
+	effective, overlays, err = reconciler.applyOverlays(context.TODO(), &authConfig)
+	assert.NilError(t, err)
+	assert.Equal(t, len(overlays), 0)
+	assert.Equal(t, effective, &authConfig)
+}
+
+func TestReconcileAuthConfigWithInvalidOverlay(t *testing.T) {
+	overlay := &v1beta2.AuthConfigOverlay{
+		ObjectMeta: metav1.ObjectMeta{Name: "steal-hosts"},
+		Spec: v1beta2.AuthConfigOverlaySpec{
+			Patch: runtime.RawExtension{Raw: []byte(`{"hosts":["other-api"]}`)},
+		},
+	}
+	authConfig := newTestAuthConfig(map[string]string{})
+	secret := newTestOAuthClientSecret()
+	authConfigIndex := index.NewIndex()
+	reconciler := newTestAuthConfigReconciler(newTestK8sClient(&authConfig, &secret, overlay), authConfigIndex)
+	reconciler.Overlays = true
+	resourceName := types.NamespacedName{Namespace: authConfig.Namespace, Name: authConfig.Name}
+
+	_, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: resourceName})
+	assert.NilError(t, err)
+	assert.Check(t, authConfigIndex.Get("echo-api") == nil)
+	assert.Check(t, authConfigIndex.Get("other-api") == nil)
+	status, _ := reconciler.StatusReport.Get(resourceName.String())
+	assert.Equal(t, status.Reason, api.StatusReasonInvalidResource)
+	assert.Equal(t, status.Message, "failed to apply overlay steal-hosts: overlays cannot change the hosts")
+}
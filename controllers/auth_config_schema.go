@@ -0,0 +1,254 @@
+package controllers
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/kuadrant/authorino/install/crd"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+var (
+	authConfigSchemaOnce sync.Once
+	authConfigSchema     *AuthConfigSchema
+	authConfigSchemaErr  error
+)
+
+// getAuthConfigSchema returns the schema of the AuthConfig CRD embedded in the binary
+func getAuthConfigSchema() (*AuthConfigSchema, error) {
+	authConfigSchemaOnce.Do(func() {
+		authConfigSchema, authConfigSchemaErr = NewAuthConfigSchema(crd.AuthConfigs)
+	})
+	return authConfigSchema, authConfigSchemaErr
+}
+
+func NewAuthConfigSchema(crdYAML []byte) (*AuthConfigSchema, error) {
+	crdJSON, err := utilyaml.ToJSON(crdYAML)
+	if err != nil {
+		return nil, err
+	}
+
+	definition := &apiextensionsv1.CustomResourceDefinition{}
+	if err := json.Unmarshal(crdJSON, definition); err != nil {
+		return nil, err
+	}
+
+	schema := &AuthConfigSchema{versions: make(map[string]*apiextensionsv1.JSONSchemaProps)}
+	for _, version := range definition.Spec.Versions {
+		if version.Schema != nil && version.Schema.OpenAPIV3Schema != nil {
+			schema.versions[definition.Spec.Group+"/"+version.Name] = version.Schema.OpenAPIV3Schema
+		}
+	}
+	return schema, nil
+}
+
+// AuthConfigSchema defaults and validates AuthConfig documents against the OpenAPI schemas of the versions of the CRD,
+// as the Kubernetes API server does for the AuthConfig custom resources.
+// Only the structural subset of OpenAPI used by the CRD is supported: types, properties, items, required fields, enums,
+// bounds of numbers, lengths of strings, arrays and objects, patterns and defaults. Unknown fields are left for the
+// decoder to drop.
+type AuthConfigSchema struct {
+	versions map[string]*apiextensionsv1.JSONSchemaProps
+}
+
+// Apply sets the default values of the fields missing in an AuthConfig document (decoded as unstructured JSON) and
+// returns the violations of the schema of its API version
+func (s *AuthConfigSchema) Apply(obj map[string]interface{}) field.ErrorList {
+	apiVersion, _ := obj["apiVersion"].(string)
+	schema, ok := s.versions[apiVersion]
+	if !ok {
+		return field.ErrorList{field.NotSupported(field.NewPath("apiVersion"), apiVersion, s.apiVersions())}
+	}
+	return applySchema(nil, obj, schema)
+}
+
+func (s *AuthConfigSchema) apiVersions() []string {
+	apiVersions := make([]string, 0, len(s.versions))
+	for apiVersion := range s.versions {
+		apiVersions = append(apiVersions, apiVersion)
+	}
+	sort.Strings(apiVersions)
+	return apiVersions
+}
+
+func applySchema(path *field.Path, value interface{}, schema *apiextensionsv1.JSONSchemaProps) (errs field.ErrorList) {
+	if value == nil {
+		if !schema.Nullable && schema.Type != "" {
+			errs = append(errs, field.Invalid(path, value, "must not be null"))
+		}
+		return
+	}
+
+	if !schemaTypeMatches(value, schema) {
+		if schema.XIntOrString {
+			return append(errs, field.Invalid(path, value, "must be an integer or a string"))
+		}
+		return append(errs, field.Invalid(path, value, fmt.Sprintf("must be of type %s", schema.Type)))
+	}
+
+	if len(schema.Enum) > 0 && !schemaEnumContains(schema.Enum, value) {
+		return append(errs, field.NotSupported(path, value, schemaEnumValues(schema.Enum)))
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if schema.MinProperties != nil && int64(len(v)) < *schema.MinProperties {
+			errs = append(errs, field.Invalid(path, value, fmt.Sprintf("must have at least %d properties", *schema.MinProperties)))
+		}
+		if schema.MaxProperties != nil && int64(len(v)) > *schema.MaxProperties {
+			errs = append(errs, field.TooMany(path, len(v), int(*schema.MaxProperties)))
+		}
+
+		for _, name := range sortedPropertyNames(schema.Properties) {
+			property := schema.Properties[name]
+			item, found := v[name]
+			// as the api server, drops the nulls of non-nullable fields without defaults
+			if (!found || item == nil && !property.Nullable) && property.Default != nil {
+				var defaultValue interface{}
+				if err := json.Unmarshal(property.Default.Raw, &defaultValue); err == nil {
+					v[name], item, found = defaultValue, defaultValue, true
+				}
+			} else if found && item == nil && !property.Nullable {
+				delete(v, name)
+				found = false
+			}
+			if found {
+				errs = append(errs, applySchema(path.Child(name), item, &property)...)
+			}
+		}
+		for _, name := range schema.Required {
+			if _, found := v[name]; !found {
+				errs = append(errs, field.Required(path.Child(name), ""))
+			}
+		}
+		if additional := schema.AdditionalProperties; additional != nil && additional.Schema != nil {
+			names := make([]string, 0, len(v))
+			for name := range v {
+				if _, known := schema.Properties[name]; !known {
+					names = append(names, name)
+				}
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				errs = append(errs, applySchema(path.Key(name), v[name], additional.Schema)...)
+			}
+		}
+
+	case []interface{}:
+		if schema.MinItems != nil && int64(len(v)) < *schema.MinItems {
+			errs = append(errs, field.Invalid(path, value, fmt.Sprintf("must have at least %d items", *schema.MinItems)))
+		}
+		if schema.MaxItems != nil && int64(len(v)) > *schema.MaxItems {
+			errs = append(errs, field.TooMany(path, len(v), int(*schema.MaxItems)))
+		}
+		if schema.Items != nil && schema.Items.Schema != nil {
+			for i, item := range v {
+				errs = append(errs, applySchema(path.Index(i), item, schema.Items.Schema)...)
+			}
+		}
+
+	case string:
+		if schema.MinLength != nil && int64(len(v)) < *schema.MinLength {
+			errs = append(errs, field.Invalid(path, value, fmt.Sprintf("must be at least %d characters long", *schema.MinLength)))
+		}
+		if schema.MaxLength != nil && int64(len(v)) > *schema.MaxLength {
+			errs = append(errs, field.TooLong(path, value, int(*schema.MaxLength)))
+		}
+		if schema.Pattern != "" {
+			if pattern, err := regexp.Compile(schema.Pattern); err == nil && !pattern.MatchString(v) {
+				errs = append(errs, field.Invalid(path, value, fmt.Sprintf("must match the pattern %s", schema.Pattern)))
+			}
+		}
+
+	case int64, float64:
+		number := toFloat64(v)
+		if minimum := schema.Minimum; minimum != nil && (number < *minimum || schema.ExclusiveMinimum && number == *minimum) {
+			errs = append(errs, field.Invalid(path, value, fmt.Sprintf("must be greater than or equal to %v", *minimum)))
+		}
+		if maximum := schema.Maximum; maximum != nil && (number > *maximum || schema.ExclusiveMaximum && number == *maximum) {
+			errs = append(errs, field.Invalid(path, value, fmt.Sprintf("must be less than or equal to %v", *maximum)))
+		}
+	}
+
+	return
+}
+
+func schemaTypeMatches(value interface{}, schema *apiextensionsv1.JSONSchemaProps) bool {
+	if schema.XIntOrString {
+		switch value.(type) {
+		case int64, string:
+			return true
+		}
+		return false
+	}
+
+	switch schema.Type {
+	case "":
+		return true
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "integer":
+		_, ok := value.(int64)
+		return ok
+	case "number":
+		switch value.(type) {
+		case int64, float64:
+			return true
+		}
+	}
+	return false
+}
+
+func schemaEnumContains(enum []apiextensionsv1.JSON, value interface{}) bool {
+	for _, item := range enum {
+		var enumValue interface{}
+		if err := json.Unmarshal(item.Raw, &enumValue); err == nil && reflect.DeepEqual(enumValue, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func schemaEnumValues(enum []apiextensionsv1.JSON) []string {
+	values := make([]string, 0, len(enum))
+	for _, item := range enum {
+		values = append(values, string(item.Raw))
+	}
+	return values
+}
+
+func sortedPropertyNames(properties map[string]apiextensionsv1.JSONSchemaProps) []string {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func toFloat64(number interface{}) float64 {
+	switch n := number.(type) {
+	case int64:
+		return float64(n)
+	case float64:
+		return n
+	}
+	return 0
+}
@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	"k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const testAuthConfigCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: authconfigs.authorino.kuadrant.io
+spec:
+  group: authorino.kuadrant.io
+  versions:
+  - name: v1beta2
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            required: [hosts]
+            properties:
+              hosts:
+                type: array
+                minItems: 1
+                items:
+                  type: string
+              strategy:
+                type: string
+                enum: [allOf, anyOf]
+                default: allOf
+              port:
+                x-kubernetes-int-or-string: true
+              rules:
+                type: object
+                additionalProperties:
+                  type: object
+                  properties:
+                    weight:
+                      type: integer
+                      maximum: 10
+                    ttl:
+                      type: integer
+                      default: 60
+`
+
+func TestAuthConfigSchema(t *testing.T) {
+	schema, err := NewAuthConfigSchema([]byte(testAuthConfigCRD))
+	assert.NilError(t, err)
+
+	apply := func(doc string) (map[string]interface{}, field.ErrorList) {
+		var obj map[string]interface{}
+		assert.NilError(t, json.Unmarshal([]byte(doc), &obj))
+		return obj, schema.Apply(obj)
+	}
+
+	obj, errs := apply(`{"apiVersion":"authorino.kuadrant.io/v1beta2","spec":{"hosts":["a.io"],"port":"http","rules":{"r1":{"weight":1}}}}`)
+	assert.Equal(t, len(errs), 0)
+	assert.DeepEqual(t, obj["spec"], map[string]interface{}{
+		"hosts":    []interface{}{"a.io"},
+		"port":     "http",
+		"strategy": "allOf",
+		"rules":    map[string]interface{}{"r1": map[string]interface{}{"weight": int64(1), "ttl": int64(60)}},
+	})
+
+	// nulls of non-nullable fields are dropped or defaulted
+	obj, errs = apply(`{"apiVersion":"authorino.kuadrant.io/v1beta2","spec":{"hosts":["a.io"],"port":null,"strategy":null}}`)
+	assert.Equal(t, len(errs), 0)
+	assert.DeepEqual(t, obj["spec"], map[string]interface{}{"hosts": []interface{}{"a.io"}, "strategy": "allOf"})
+
+	_, errs = apply(`{"apiVersion":"authorino.kuadrant.io/v1beta2","spec":{"hosts":[],"strategy":"oneOf","port":1.5,"rules":{"r1":{"weight":11,"ttl":"1m"}}}}`)
+	assert.Equal(t, errs.ToAggregate().Error(), `[spec.hosts: Invalid value: []interface {}{}: must have at least 1 items, spec.port: Invalid value: 1.5: must be an integer or a string, spec.rules[r1].ttl: Invalid value: "1m": must be of type integer, spec.rules[r1].weight: Invalid value: 11: must be less than or equal to 10, spec.strategy: Unsupported value: "oneOf": supported values: "\"allOf\"", "\"anyOf\""]`)
+
+	_, errs = apply(`{"apiVersion":"authorino.kuadrant.io/v1beta1","spec":{}}`)
+	assert.Equal(t, errs.ToAggregate().Error(), `apiVersion: Unsupported value: "authorino.kuadrant.io/v1beta1": supported values: "authorino.kuadrant.io/v1beta2"`)
+}
@@ -18,6 +18,8 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 // AuthConfigStatusUpdater updates the status of a newly reconciled auth config
@@ -26,6 +28,8 @@ type AuthConfigStatusUpdater struct {
 	Logger        logr.Logger
 	StatusReport  *StatusReportMap
 	LabelSelector labels.Selector
+	// DependencyHealth, if set, tells the health of the external dependencies of the auth configs
+	DependencyHealth *DependencyHealthChecker
 }
 
 // +kubebuilder:rbac:groups=authorino.kuadrant.io,resources=authconfigs/status,verbs=get;update;patch
@@ -74,6 +78,13 @@ func (u *AuthConfigStatusUpdater) updateAuthConfigStatus(ctx context.Context, re
 	ready := len(looseHosts) == 0 && reason == api.StatusReasonReconciled
 	changed = updateStatusReady(authConfig, ready, reason, message) || changed
 
+	// dependencies
+	if u.DependencyHealth != nil {
+		if health, probed := u.DependencyHealth.Get(resourceId); probed {
+			changed = updateStatusDependencies(authConfig, health) || changed
+		}
+	}
+
 	// summary
 	changed = updateStatusSummary(authConfig, linkedHosts) || changed
 
@@ -100,9 +111,12 @@ func (u *AuthConfigStatusUpdater) updateAuthConfigStatus(ctx context.Context, re
 }
 
 func (u *AuthConfigStatusUpdater) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&api.AuthConfig{}, builder.WithPredicates(LabelSelectorPredicate(u.LabelSelector))).
-		Complete(u)
+	controller := ctrl.NewControllerManagedBy(mgr).
+		For(&api.AuthConfig{}, builder.WithPredicates(LabelSelectorPredicate(u.LabelSelector)))
+	if u.DependencyHealth != nil {
+		controller = controller.Watches(&source.Channel{Source: u.DependencyHealth.Changes()}, &handler.EnqueueRequestForObject{})
+	}
+	return controller.Complete(u)
 }
 
 func updateStatusConditions(currentConditions []api.Condition, newCondition api.Condition) ([]api.Condition, bool) {
@@ -159,7 +173,6 @@ func updateStatusReady(authConfig *api.AuthConfig, ready bool, reason, message s
 	if ready {
 		status = k8score.ConditionTrue
 		reason = api.StatusReasonReconciled
-		message = ""
 	} else if reason == "" {
 		reason = api.StatusReasonUnknown
 	}
@@ -174,6 +187,31 @@ func updateStatusReady(authConfig *api.AuthConfig, ready bool, reason, message s
 	return
 }
 
+func updateStatusDependencies(authConfig *api.AuthConfig, health []DependencyHealth) (changed bool) {
+	status := k8score.ConditionTrue
+	reason := api.StatusReasonDependenciesHealthy
+	var unhealthy []string
+
+	for _, h := range health {
+		if !h.Healthy {
+			unhealthy = append(unhealthy, fmt.Sprintf("%s: %s", h.Dependency.String(), h.Message))
+		}
+	}
+	if len(unhealthy) > 0 {
+		status = k8score.ConditionFalse
+		reason = api.StatusReasonDependenciesUnhealthy
+	}
+
+	authConfig.Status.Conditions, changed = updateStatusConditions(authConfig.Status.Conditions, api.Condition{
+		Type:    api.StatusConditionDependenciesHealthy,
+		Status:  status,
+		Reason:  reason,
+		Message: utils.CapitalizeString(strings.Join(unhealthy, "; ")),
+	})
+
+	return
+}
+
 func updateStatusSummary(authConfig *api.AuthConfig, newLinkedHosts []string) (changed bool) {
 	current := authConfig.Status.Summary
 
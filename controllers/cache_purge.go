@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	api "github.com/kuadrant/authorino/api/v1beta1"
+)
+
+// PurgeCacheAnnotation is the annotation that tells the reconciler to purge the cached results of the evaluators of an
+// AuthConfig, including the entries in the external backend shared by the replicas, whenever its value changes (e.g.
+// set to the current time)
+const PurgeCacheAnnotation = "authorino.kuadrant.io/purge-cache"
+
+// PurgeCachePrefixAnnotation is the annotation that limits the purges requested with PurgeCacheAnnotation to the cache
+// entries whose keys start with its value
+const PurgeCachePrefixAnnotation = "authorino.kuadrant.io/purge-cache-prefix"
+
+// purgeCacheRequested records the value of the purge annotation of an AuthConfig and tells whether it changed since
+// the last reconciliation of the resource.
+// The first reconciliation of a resource does not purge, as the in-memory caches of a config are always built empty.
+func (r *AuthConfigReconciler) purgeCacheRequested(resourceId string, authConfig *api.AuthConfig) bool {
+	purge := authConfig.Annotations[PurgeCacheAnnotation]
+
+	r.purgeRequestsMu.Lock()
+	defer r.purgeRequestsMu.Unlock()
+
+	if r.purgeRequests == nil {
+		r.purgeRequests = make(map[string]string)
+	}
+	previous, reconciled := r.purgeRequests[resourceId]
+	r.purgeRequests[resourceId] = purge
+	return reconciled && purge != "" && purge != previous
+}
+
+func (r *AuthConfigReconciler) clearPurgeRequests(resourceId string) {
+	r.purgeRequestsMu.Lock()
+	defer r.purgeRequestsMu.Unlock()
+	delete(r.purgeRequests, resourceId)
+}
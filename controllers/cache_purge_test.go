@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/kuadrant/authorino/pkg/index"
+
+	"gotest.tools/assert"
+)
+
+func TestPurgeCacheRequested(t *testing.T) {
+	reconciler := newTestAuthConfigReconciler(newTestK8sClient(), index.NewIndex())
+	authConfig := newTestAuthConfig(map[string]string{})
+	resourceId := "authorino/auth-config-1"
+
+	// first reconciliation, the caches are built empty
+	authConfig.Annotations = map[string]string{PurgeCacheAnnotation: "1"}
+	assert.Check(t, !reconciler.purgeCacheRequested(resourceId, &authConfig))
+	// annotation unchanged
+	assert.Check(t, !reconciler.purgeCacheRequested(resourceId, &authConfig))
+	// annotation changed
+	authConfig.Annotations = map[string]string{PurgeCacheAnnotation: "2", PurgeCachePrefixAnnotation: "john"}
+	assert.Check(t, reconciler.purgeCacheRequested(resourceId, &authConfig))
+	assert.Check(t, !reconciler.purgeCacheRequested(resourceId, &authConfig))
+	// annotation removed
+	authConfig.Annotations = nil
+	assert.Check(t, !reconciler.purgeCacheRequested(resourceId, &authConfig))
+	// annotation set back
+	authConfig.Annotations = map[string]string{PurgeCacheAnnotation: "2"}
+	assert.Check(t, reconciler.purgeCacheRequested(resourceId, &authConfig))
+
+	// resource deleted and created again
+	reconciler.clearPurgeRequests(resourceId)
+	authConfig.Annotations = map[string]string{PurgeCacheAnnotation: "3"}
+	assert.Check(t, !reconciler.purgeCacheRequested(resourceId, &authConfig))
+}
@@ -0,0 +1,270 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	api "github.com/kuadrant/authorino/api/v1beta1"
+	"github.com/kuadrant/authorino/api/v1beta2"
+	"github.com/kuadrant/authorino/pkg/log"
+	"github.com/kuadrant/authorino/pkg/utils"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/json"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// AuthConfigsConfigMapAnnotation is the annotation that opts a ConfigMap in to have its entries read as AuthConfigs
+const AuthConfigsConfigMapAnnotation = "authorino.kuadrant.io/authconfigs"
+
+// ConfigMapReconciler reconciles AuthConfigs embedded in k8s ConfigMap objects.
+// Each entry of an annotated ConfigMap holds an AuthConfig document (YAML or JSON; v1beta1 or v1beta2), indexed as if
+// the AuthConfig was created in the namespace of the ConfigMap.
+type ConfigMapReconciler struct {
+	client.Client
+	Logger      logr.Logger
+	Scheme      *runtime.Scheme
+	AuthConfigs *AuthConfigReconciler
+	Namespace   string
+
+	indexed map[types.NamespacedName][]string
+	mutex   sync.Mutex
+}
+
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;
+
+func (r *ConfigMapReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := r.Logger.WithValues("configmap", req.NamespacedName)
+
+	configMap := v1.ConfigMap{}
+	if err := r.Get(ctx, req.NamespacedName, &configMap); err != nil && !errors.IsNotFound(err) {
+		// could not get the resource but not because of a 404 Not found, some error must have happened
+		return ctrl.Result{}, err
+	} else if errors.IsNotFound(err) || !HasAuthConfigs(&configMap) || !r.InScope(req.Namespace) {
+		// could not find the resource (404 Not found, resource must have been deleted)
+		// or the resource is no longer to be watched (annotation removed or out of the watched namespace)
+		// => de-index all authconfigs previously read from the configmap
+		configMap = v1.ConfigMap{}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	resourceIds := []string{}
+	keys := make([]string, 0, len(configMap.Data))
+	for key := range configMap.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		resourceId := configMapAuthConfigId(req.NamespacedName, key)
+		entryLogger := logger.WithValues("key", key, "authconfig", resourceId)
+
+		authConfig, err := r.decode([]byte(configMap.Data[key]))
+		if err != nil {
+			entryLogger.Error(err, "invalid authconfig")
+			continue
+		}
+		authConfig.Namespace = configMap.Namespace
+
+		if !Watched(&authConfig.ObjectMeta, r.AuthConfigs.LabelSelector) {
+			continue
+		}
+
+		if err := r.index(log.IntoContext(ctx, entryLogger), resourceId, authConfig); err != nil {
+			entryLogger.Error(err, "failed to index authconfig")
+		}
+		resourceIds = append(resourceIds, resourceId)
+	}
+
+	// delete from the index the authconfigs no longer in the configmap
+	for _, resourceId := range utils.SubtractSlice(r.indexed[req.NamespacedName], resourceIds) {
+		r.deindex(ctx, resourceId)
+		logger.Info("resource de-indexed", "authconfig", resourceId)
+	}
+
+	if r.indexed == nil {
+		r.indexed = map[types.NamespacedName][]string{}
+	}
+	if len(resourceIds) > 0 {
+		r.indexed[req.NamespacedName] = resourceIds
+	} else {
+		delete(r.indexed, req.NamespacedName)
+	}
+
+	logger.Info("resource reconciled")
+	return ctrl.Result{}, nil
+}
+
+func (r *ConfigMapReconciler) ClusterWide() bool {
+	return r.Namespace == ""
+}
+
+// InScope tells whether resources of a given namespace are within the space watched by the reconciler
+func (r *ConfigMapReconciler) InScope(namespace string) bool {
+	return r.ClusterWide() || namespace == r.Namespace
+}
+
+func (r *ConfigMapReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return newController(mgr).
+		For(&v1.ConfigMap{}, builder.WithPredicates(authConfigsConfigMapPredicate())).
+		Complete(r)
+}
+
+// decode reads an AuthConfig document and converts it to the version the reconciler works with.
+// As the AuthConfig custom resources, the document is defaulted and validated against the schema of the CRD and checked
+// by the admission webhook (except for the checks that look up other resources in the cluster).
+func (r *ConfigMapReconciler) decode(data []byte) (*api.AuthConfig, error) {
+	data, err := defaultAndValidateAuthConfigDocument(data)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, _, err := serializer.NewCodecFactory(r.Scheme).UniversalDeserializer().Decode(data, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var authConfig *api.AuthConfig
+	var authConfigV1beta2 *v1beta2.AuthConfig
+
+	switch decoded := obj.(type) {
+	case *api.AuthConfig:
+		authConfig = decoded
+		authConfigV1beta2 = &v1beta2.AuthConfig{}
+		if err := authConfigV1beta2.ConvertFrom(decoded); err != nil {
+			return nil, err
+		}
+	case *v1beta2.AuthConfig:
+		authConfigV1beta2 = decoded
+		authConfig = &api.AuthConfig{}
+		if err := decoded.ConvertTo(authConfig); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported kind: %s", obj.GetObjectKind().GroupVersionKind().String())
+	}
+
+	if err := (&v1beta2.AuthConfigValidator{}).ValidateCreate(context.Background(), authConfigV1beta2); err != nil {
+		return nil, err
+	}
+
+	return authConfig, nil
+}
+
+// defaultAndValidateAuthConfigDocument sets the default values of the fields of an AuthConfig document (YAML or JSON)
+// and validates it against the schema of the CRD, returning the defaulted document in JSON
+func defaultAndValidateAuthConfigDocument(data []byte) ([]byte, error) {
+	schema, err := getAuthConfigSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err = utilyaml.ToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var obj map[string]interface{}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	}
+
+	if kind, _ := obj["kind"].(string); kind != "AuthConfig" {
+		return nil, fmt.Errorf("unsupported kind: %s", kind)
+	}
+
+	if errs := schema.Apply(obj); len(errs) > 0 {
+		name, _, _ := unstructured.NestedString(obj, "metadata", "name")
+		return nil, errors.NewInvalid(v1beta2.GroupVersion.WithKind("AuthConfig").GroupKind(), name, errs)
+	}
+
+	return json.Marshal(obj)
+}
+
+// index builds the config and swaps it into the index in place of the one currently indexed for the resource, if any
+func (r *ConfigMapReconciler) index(ctx context.Context, resourceId string, authConfig *api.AuthConfig) error {
+	indexedAuthConfig := r.AuthConfigs.indexedAuthConfig(resourceId)
+
+	translatedAuthConfig, err := r.AuthConfigs.translateAuthConfig(ctx, authConfig)
+	if err != nil {
+		return err
+	}
+
+	unusedHosts := utils.SubtractSlice(r.AuthConfigs.Index.FindKeys(resourceId), authConfig.Spec.Hosts)
+	for _, host := range unusedHosts {
+		r.AuthConfigs.Index.DeleteKey(resourceId, host)
+	}
+	if len(unusedHosts) > 0 {
+		r.AuthConfigs.reconcileLooseResources(resourceId)
+	}
+
+	_, looseHosts, err := r.AuthConfigs.addToIndex(ctx, authConfig.Namespace, resourceId, translatedAuthConfig, authConfig.Spec.Hosts)
+	if err != nil {
+		return err
+	}
+	for host, indexedResourceId := range looseHosts {
+		log.FromContext(ctx).Info("host not linked", "host", host, "reason", fmt.Sprintf("already taken by %s", indexedResourceId))
+	}
+
+	if err := r.AuthConfigs.cleanConfigs(indexedAuthConfig, ctx); err != nil {
+		log.FromContext(ctx).Error(err, failedToCleanConfig)
+	}
+	return nil
+}
+
+func (r *ConfigMapReconciler) deindex(ctx context.Context, resourceId string) {
+	indexedAuthConfig := r.AuthConfigs.indexedAuthConfig(resourceId)
+	r.AuthConfigs.Index.Delete(resourceId)
+	r.AuthConfigs.reconcileLooseResources(resourceId)
+	if err := r.AuthConfigs.cleanConfigs(indexedAuthConfig, ctx); err != nil {
+		r.Logger.Error(err, failedToCleanConfig, "authconfig", resourceId)
+	}
+}
+
+// HasAuthConfigs tells whether a ConfigMap is annotated to have its entries read as AuthConfigs
+func HasAuthConfigs(configMap *v1.ConfigMap) bool {
+	return configMap.GetAnnotations()[AuthConfigsConfigMapAnnotation] == "true"
+}
+
+func authConfigsConfigMapPredicate() predicate.Funcs {
+	filter := func(object client.Object) bool {
+		configMap, ok := object.(*v1.ConfigMap)
+		return ok && HasAuthConfigs(configMap)
+	}
+
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return filter(e.Object)
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return filter(e.ObjectNew) || filter(e.ObjectOld)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return filter(e.Object)
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return filter(e.Object)
+		},
+	}
+}
+
+// configMapAuthConfigId returns the id under which an AuthConfig read from an entry of a ConfigMap is indexed, kept
+// apart from the ids of AuthConfig custom resources
+func configMapAuthConfigId(configMap types.NamespacedName, key string) string {
+	return fmt.Sprintf("configmap:%s/%s", configMap.String(), key)
+}
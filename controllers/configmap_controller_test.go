@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	api "github.com/kuadrant/authorino/api/v1beta1"
+	"github.com/kuadrant/authorino/api/v1beta2"
+	"github.com/kuadrant/authorino/pkg/index"
+	"github.com/kuadrant/authorino/pkg/log"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	testConfigMapAuthConfigV1beta2 = `apiVersion: authorino.kuadrant.io/v1beta2
+kind: AuthConfig
+metadata:
+  name: talker-api
+spec:
+  hosts:
+  - talker-api.io
+  authentication:
+    anonymous:
+      anonymous: {}
+`
+	testConfigMapAuthConfigV1beta1 = `{"apiVersion":"authorino.kuadrant.io/v1beta1","kind":"AuthConfig","metadata":{"name":"echo-api"},"spec":{"hosts":["echo-api.io"],"identity":[{"name":"anonymous","anonymous":{}}]}}`
+)
+
+func newTestConfigMapReconciler(configMap *v1.ConfigMap, i index.Index) *ConfigMapReconciler {
+	scheme := runtime.NewScheme()
+	_ = api.AddToScheme(scheme)
+	_ = v1beta2.AddToScheme(scheme)
+	_ = v1.AddToScheme(scheme)
+	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(configMap).Build()
+	return &ConfigMapReconciler{
+		Client:      client,
+		Logger:      log.WithName("test").WithName("configmapreconciler"),
+		Scheme:      scheme,
+		AuthConfigs: newTestAuthConfigReconciler(client, i),
+	}
+}
+
+func TestReconcileAuthConfigsFromConfigMap(t *testing.T) {
+	authConfigIndex := index.NewIndex()
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "bootstrap",
+			Namespace:   "authorino",
+			Annotations: map[string]string{AuthConfigsConfigMapAnnotation: "true"},
+		},
+		Data: map[string]string{
+			"talker-api.yaml": testConfigMapAuthConfigV1beta2,
+			"echo-api.json":   testConfigMapAuthConfigV1beta1,
+			"invalid.yaml":    "not an authconfig",
+		},
+	}
+	reconciler := newTestConfigMapReconciler(configMap, authConfigIndex)
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: configMap.Name, Namespace: configMap.Namespace}}
+
+	result, err := reconciler.Reconcile(context.TODO(), request)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, result, ctrl.Result{})
+
+	id, found := authConfigIndex.FindId("talker-api.io")
+	assert.Check(t, found)
+	assert.Equal(t, id, "configmap:authorino/bootstrap/talker-api.yaml")
+	assert.Equal(t, authConfigIndex.Get("talker-api.io").Labels["namespace"], "authorino")
+	id, found = authConfigIndex.FindId("echo-api.io")
+	assert.Check(t, found)
+	assert.Equal(t, id, "configmap:authorino/bootstrap/echo-api.json")
+
+	// entry removed
+	delete(configMap.Data, "echo-api.json")
+	assert.NilError(t, reconciler.Update(context.TODO(), configMap))
+	_, err = reconciler.Reconcile(context.TODO(), request)
+	assert.NilError(t, err)
+	_, found = authConfigIndex.FindId("talker-api.io")
+	assert.Check(t, found)
+	_, found = authConfigIndex.FindId("echo-api.io")
+	assert.Check(t, !found)
+
+	// annotation removed
+	configMap.Annotations = nil
+	assert.NilError(t, reconciler.Update(context.TODO(), configMap))
+	_, err = reconciler.Reconcile(context.TODO(), request)
+	assert.NilError(t, err)
+	assert.Check(t, authConfigIndex.Empty())
+}
+
+func TestDecodeAuthConfigFromConfigMap(t *testing.T) {
+	reconciler := newTestConfigMapReconciler(&v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "bootstrap", Namespace: "authorino"}}, index.NewIndex())
+
+	// defaulted as by the api server
+	authConfig, err := reconciler.decode([]byte(`apiVersion: authorino.kuadrant.io/v1beta2
+kind: AuthConfig
+metadata:
+  name: talker-api
+spec:
+  hosts:
+  - talker-api.io
+  authorization:
+    lua-checks:
+      lua:
+        script: return true
+`))
+	assert.NilError(t, err)
+	assert.Equal(t, authConfig.Spec.Authorization[0].Lua.Timeout, 100)
+
+	// out of the bounds of the schema
+	_, err = reconciler.decode([]byte(`apiVersion: authorino.kuadrant.io/v1beta2
+kind: AuthConfig
+metadata:
+  name: talker-api
+spec:
+  hosts:
+  - talker-api.io
+  authorization:
+    quota:
+      quota:
+        key:
+          selector: auth.identity.sub
+        limit: 10
+        window: 0
+`))
+	assert.Error(t, err, `AuthConfig.authorino.kuadrant.io "talker-api" is invalid: spec.authorization[quota].quota.window: Invalid value: 0: must be greater than or equal to 1`)
+
+	// missing required fields
+	_, err = reconciler.decode([]byte(`{"apiVersion":"authorino.kuadrant.io/v1beta2","kind":"AuthConfig","metadata":{"name":"talker-api"},"spec":{}}`))
+	assert.Error(t, err, `AuthConfig.authorino.kuadrant.io "talker-api" is invalid: spec.hosts: Required value`)
+
+	// rejected by the checks of the admission webhook
+	_, err = reconciler.decode([]byte(`{"apiVersion":"authorino.kuadrant.io/v1beta2","kind":"AuthConfig","metadata":{"name":"talker-api"},"spec":{"hosts":["talker-api.io","talker-api.io"]}}`))
+	assert.ErrorContains(t, err, `spec.hosts[1]: Duplicate value: "talker-api.io"`)
+}
@@ -0,0 +1,287 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	api "github.com/kuadrant/authorino/api/v1beta1"
+	"github.com/kuadrant/authorino/pkg/evaluators"
+	"github.com/kuadrant/authorino/pkg/utils"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+const (
+	DependencyKindOIDC                = "oidc"
+	DependencyKindOAuth2Introspection = "oauth2Introspection"
+	DependencyKindUMA                 = "uma"
+	DependencyKindHTTP                = "http"
+	DependencyKindOPA                 = "opa"
+
+	DefaultDependencyHealthCheckTimeout = 5 * time.Second
+)
+
+// Dependency is an external service an AuthConfig relies upon, probed in the background
+type Dependency struct {
+	Evaluator string
+	Kind      string
+	Url       string
+}
+
+func (d Dependency) String() string {
+	return fmt.Sprintf("%s %s (%s)", d.Kind, d.Evaluator, d.Url)
+}
+
+// DependencyHealth is the outcome of the last probe of a dependency
+type DependencyHealth struct {
+	Dependency
+	Healthy bool
+	Message string
+}
+
+func NewDependencyHealthChecker(interval time.Duration, logger logr.Logger) *DependencyHealthChecker {
+	return &DependencyHealthChecker{
+		Logger:       logger,
+		Interval:     interval,
+		Timeout:      DefaultDependencyHealthCheckTimeout,
+		dependencies: make(map[string][]Dependency),
+		health:       make(map[string][]DependencyHealth),
+		changes:      make(chan event.GenericEvent),
+	}
+}
+
+// DependencyHealthChecker periodically probes the external dependencies of the reconciled AuthConfigs (OpenID Connect
+// and UMA discovery endpoints, token introspection and metadata endpoints, OPA policy registries).
+// Every time the health of the dependencies of an AuthConfig changes, an event is sent to the Changes channel, so the
+// status of the resource can be updated.
+type DependencyHealthChecker struct {
+	Logger   logr.Logger
+	Interval time.Duration
+	Timeout  time.Duration
+
+	dependencies map[string][]Dependency
+	health       map[string][]DependencyHealth
+	changes      chan event.GenericEvent
+	mu           sync.RWMutex
+}
+
+// Set records the dependencies of a reconciled AuthConfig, to be probed from the next round on
+func (c *DependencyHealthChecker) Set(id string, dependencies []Dependency) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if reflect.DeepEqual(c.dependencies[id], dependencies) {
+		return
+	}
+	if len(dependencies) == 0 {
+		delete(c.dependencies, id)
+	} else {
+		c.dependencies[id] = dependencies
+	}
+	delete(c.health, id)
+	dependencyHealthMetric.DeletePartialMatch(dependencyHealthMetricLabels(id))
+}
+
+// Clear stops probing the dependencies of an AuthConfig
+func (c *DependencyHealthChecker) Clear(id string) {
+	c.Set(id, nil)
+}
+
+// Get returns the health of the dependencies of an AuthConfig, if probed already
+func (c *DependencyHealthChecker) Get(id string) (health []DependencyHealth, found bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	health, found = c.health[id]
+	return
+}
+
+// Changes is the channel of events telling the AuthConfigs whose health of the dependencies changed
+func (c *DependencyHealthChecker) Changes() <-chan event.GenericEvent {
+	return c.changes
+}
+
+// Start probes the dependencies on every interval until the context is done
+func (c *DependencyHealthChecker) Start(ctx context.Context) error {
+	if c.Interval <= 0 {
+		return fmt.Errorf("interval must be greater than zero")
+	}
+
+	ticker := time.NewTicker(c.Interval)
+	defer ticker.Stop()
+
+	for {
+		c.probeAll(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (c *DependencyHealthChecker) probeAll(ctx context.Context) {
+	c.mu.RLock()
+	dependencies := make(map[string][]Dependency, len(c.dependencies))
+	for id, deps := range c.dependencies {
+		dependencies[id] = deps
+	}
+	c.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for id, deps := range dependencies {
+		wg.Add(1)
+		go func(id string, deps []Dependency) {
+			defer wg.Done()
+			c.update(id, deps, c.probeDependencies(ctx, deps))
+		}(id, deps)
+	}
+	wg.Wait()
+}
+
+func (c *DependencyHealthChecker) probeDependencies(ctx context.Context, dependencies []Dependency) []DependencyHealth {
+	health := make([]DependencyHealth, len(dependencies))
+	for i, dependency := range dependencies {
+		health[i] = DependencyHealth{Dependency: dependency, Healthy: true}
+		if err := c.probe(ctx, dependency); err != nil {
+			health[i].Healthy = false
+			health[i].Message = err.Error()
+		}
+	}
+	return health
+}
+
+// probe checks if the dependency is reachable. Discovery endpoints must respond successfully; any other endpoint is
+// considered healthy unless the request fails or the response is a server error, since a plain GET request without
+// credentials is not what the endpoint would expect in request-time.
+func (c *DependencyHealthChecker) probe(ctx context.Context, dependency Dependency) error {
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, dependency.Url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch dependency.Kind {
+	case DependencyKindOIDC, DependencyKindUMA:
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("unexpected status %d", resp.StatusCode)
+		}
+	default:
+		if resp.StatusCode >= 500 {
+			return fmt.Errorf("unexpected status %d", resp.StatusCode)
+		}
+	}
+	return nil
+}
+
+func (c *DependencyHealthChecker) update(id string, dependencies []Dependency, health []DependencyHealth) {
+	c.mu.Lock()
+	if !reflect.DeepEqual(c.dependencies[id], dependencies) {
+		c.mu.Unlock()
+		return // the dependencies changed or were cleared while probing
+	}
+	changed := !reflect.DeepEqual(c.health[id], health)
+	c.health[id] = health
+	c.mu.Unlock()
+
+	labels := dependencyHealthMetricLabels(id)
+	for _, h := range health {
+		value := 0.0
+		if h.Healthy {
+			value = 1.0
+		}
+		dependencyHealthMetric.WithLabelValues(labels["namespace"], labels["authconfig"], h.Evaluator, h.Kind).Set(value)
+	}
+
+	if !changed {
+		return
+	}
+	for _, h := range health {
+		if !h.Healthy {
+			c.Logger.Info("dependency unhealthy", "authconfig", id, "dependency", h.Dependency.String(), "reason", h.Message)
+		}
+	}
+
+	namespace, name, found := strings.Cut(id, "/")
+	if !found || strings.Contains(namespace, ":") {
+		return // not an authconfig custom resource (e.g. read from a configmap)
+	}
+	authConfig := &api.AuthConfig{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+	go func() {
+		c.changes <- event.GenericEvent{Object: authConfig}
+	}()
+}
+
+func dependencyHealthMetricLabels(id string) prometheus.Labels {
+	namespace, name, _ := strings.Cut(id, "/")
+	return prometheus.Labels{"namespace": namespace, "authconfig": name}
+}
+
+// dependenciesOf lists the external services the evaluators of a config rely upon.
+// Metadata endpoints whose URLs are resolved in request-time are skipped.
+func dependenciesOf(config *evaluators.AuthConfig) []Dependency {
+	var dependencies []Dependency
+
+	for _, c := range config.IdentityConfigs {
+		identity, ok := c.(*evaluators.IdentityConfig)
+		if !ok {
+			continue
+		}
+		if identity.OIDC != nil {
+			dependencies = append(dependencies, Dependency{Evaluator: identity.Name, Kind: DependencyKindOIDC, Url: strings.TrimSuffix(identity.OIDC.Endpoint, "/") + "/.well-known/openid-configuration"})
+		}
+		if identity.OAuth2 != nil {
+			dependencies = append(dependencies, Dependency{Evaluator: identity.Name, Kind: DependencyKindOAuth2Introspection, Url: identity.OAuth2.TokenIntrospectionUrl})
+		}
+	}
+
+	for _, c := range config.MetadataConfigs {
+		metadata, ok := c.(*evaluators.MetadataConfig)
+		if !ok {
+			continue
+		}
+		if metadata.UMA != nil {
+			dependencies = append(dependencies, Dependency{Evaluator: metadata.Name, Kind: DependencyKindUMA, Url: strings.TrimSuffix(metadata.UMA.Endpoint, "/") + "/.well-known/uma2-configuration"})
+		}
+		if metadata.GenericHTTP != nil && !strings.Contains(metadata.GenericHTTP.Endpoint, "{") {
+			dependencies = append(dependencies, Dependency{Evaluator: metadata.Name, Kind: DependencyKindHTTP, Url: metadata.GenericHTTP.Endpoint})
+		}
+	}
+
+	for _, c := range config.AuthorizationConfigs {
+		authorization, ok := c.(*evaluators.AuthorizationConfig)
+		if !ok {
+			continue
+		}
+		if opa := authorization.OPA; opa != nil && opa.ExternalSource != nil && opa.ExternalSource.Endpoint != "" {
+			dependencies = append(dependencies, Dependency{Evaluator: authorization.Name, Kind: DependencyKindOPA, Url: opa.ExternalSource.Endpoint})
+		}
+	}
+
+	// the dependencies of a canary revision are probed as well, once each
+	if config.Canary != nil {
+		for _, dependency := range dependenciesOf(config.Canary) {
+			if !utils.SliceContains(dependencies, dependency) {
+				dependencies = append(dependencies, dependency)
+			}
+		}
+	}
+
+	return dependencies
+}
@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	api "github.com/kuadrant/authorino/api/v1beta1"
+	"github.com/kuadrant/authorino/pkg/index"
+	"github.com/kuadrant/authorino/pkg/log"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/assert"
+	k8score "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestDependencyHealthChecker(t *testing.T) {
+	authConfig := newTestAuthConfig(map[string]string{})
+	secret := newTestOAuthClientSecret()
+	client := newTestK8sClient(&authConfig, &secret)
+	dependencyHealth := NewDependencyHealthChecker(time.Minute, log.WithName("test").WithName("dependencyhealth"))
+	reconciler := newTestAuthConfigReconciler(client, index.NewIndex())
+	reconciler.DependencyHealth = dependencyHealth
+	resourceName := types.NamespacedName{Namespace: authConfig.Namespace, Name: authConfig.Name}
+
+	_, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: resourceName})
+	assert.NilError(t, err)
+
+	_, probed := dependencyHealth.Get(resourceName.String())
+	assert.Check(t, !probed)
+
+	dependencyHealth.probeAll(context.Background())
+
+	health, probed := dependencyHealth.Get(resourceName.String())
+	assert.Check(t, probed)
+	assert.DeepEqual(t, health, []DependencyHealth{
+		{Dependency: Dependency{Evaluator: "keycloak", Kind: DependencyKindOIDC, Url: "http://127.0.0.1:9001/auth/realms/demo/.well-known/openid-configuration"}, Healthy: true},
+		{Dependency: Dependency{Evaluator: "resource-data", Kind: DependencyKindUMA, Url: "http://127.0.0.1:9001/auth/realms/demo/.well-known/uma2-configuration"}, Healthy: true},
+	})
+	assert.Equal(t, testutil.ToFloat64(dependencyHealthMetric.WithLabelValues("authorino", "auth-config-1", "keycloak", DependencyKindOIDC)), 1.0)
+
+	changed := <-dependencyHealth.Changes()
+	assert.Equal(t, changed.Object.GetNamespace(), "authorino")
+	assert.Equal(t, changed.Object.GetName(), "auth-config-1")
+
+	// dependency not reachable
+	unreachable := Dependency{Evaluator: "opa", Kind: DependencyKindOPA, Url: "http://127.0.0.1:9009/policy.rego"}
+	dependencyHealth.Set(resourceName.String(), []Dependency{unreachable})
+	dependencyHealth.probeAll(context.Background())
+
+	health, _ = dependencyHealth.Get(resourceName.String())
+	assert.Equal(t, len(health), 1)
+	assert.Check(t, !health[0].Healthy)
+	assert.Check(t, strings.Contains(health[0].Message, "connection refused"))
+	assert.Equal(t, testutil.ToFloat64(dependencyHealthMetric.WithLabelValues("authorino", "auth-config-1", "opa", DependencyKindOPA)), 0.0)
+
+	// discovery endpoint not found
+	notFound := Dependency{Evaluator: "keycloak", Kind: DependencyKindOIDC, Url: "http://127.0.0.1:9001/auth/realms/other/.well-known/openid-configuration"}
+	health = dependencyHealth.probeDependencies(context.Background(), []Dependency{notFound})
+	assert.Check(t, !health[0].Healthy)
+	assert.Equal(t, health[0].Message, "unexpected status 404")
+
+	// cleared
+	dependencyHealth.Clear(resourceName.String())
+	_, probed = dependencyHealth.Get(resourceName.String())
+	assert.Check(t, !probed)
+}
+
+func TestAuthConfigStatusUpdater_DependenciesHealth(t *testing.T) {
+	authConfig := mockStatusUpdateAuthConfig()
+	resourceName := types.NamespacedName{Namespace: authConfig.Namespace, Name: authConfig.Name}
+	client := newTestK8sClient(&authConfig)
+	reconciler := mockStatusUpdaterReconciler(client)
+	reconciler.DependencyHealth = NewDependencyHealthChecker(time.Minute, log.WithName("test").WithName("dependencyhealth"))
+	reconciler.StatusReport.Set(resourceName.String(), api.StatusReasonReconciled, "", []string{"echo-api"})
+
+	dependencies := []Dependency{{Evaluator: "keycloak", Kind: DependencyKindOIDC, Url: "http://keycloak/.well-known/openid-configuration"}}
+	reconciler.DependencyHealth.Set(resourceName.String(), dependencies)
+	reconciler.DependencyHealth.update(resourceName.String(), dependencies, []DependencyHealth{{Dependency: dependencies[0], Healthy: false, Message: "unexpected status 503"}})
+
+	result, err := reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: resourceName})
+	assert.Equal(t, result, ctrl.Result{})
+	assert.NilError(t, err)
+
+	authConfigCheck := api.AuthConfig{}
+	_ = client.Get(context.TODO(), resourceName, &authConfigCheck)
+	assert.Check(t, authConfigCheck.Status.Ready()) // unhealthy dependencies do not affect the readiness
+	condition := dependenciesHealthyCondition(authConfigCheck.Status)
+	assert.Equal(t, condition.Status, k8score.ConditionFalse)
+	assert.Equal(t, condition.Reason, api.StatusReasonDependenciesUnhealthy)
+	assert.Equal(t, condition.Message, "Oidc keycloak (http://keycloak/.well-known/openid-configuration): unexpected status 503")
+}
+
+func dependenciesHealthyCondition(status api.AuthConfigStatus) api.Condition {
+	for _, condition := range status.Conditions {
+		if condition.Type == api.StatusConditionDependenciesHealthy {
+			return condition
+		}
+	}
+	return api.Condition{}
+}
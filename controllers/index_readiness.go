@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	api "github.com/kuadrant/authorino/api/v1beta1"
+
+	"github.com/go-logr/logr"
+)
+
+const (
+	IndexReadyzSubpath = "index"
+
+	defaultIndexReadinessInterval = time.Second
+)
+
+// CacheSyncer waits for the caches of the informers to sync, e.g. the cache of a manager
+type CacheSyncer interface {
+	WaitForCacheSync(ctx context.Context) bool
+}
+
+func NewIndexReadiness(cache CacheSyncer, authConfigs *AuthConfigReconciler, waitForDependencies bool, logger logr.Logger) *IndexReadiness {
+	return &IndexReadiness{
+		Cache:               cache,
+		AuthConfigs:         authConfigs,
+		WaitForDependencies: waitForDependencies,
+		Interval:            defaultIndexReadinessInterval,
+		Logger:              logger,
+		reason:              "caches not synced",
+	}
+}
+
+// IndexReadiness tells whether the index is ready to serve auth requests, i.e. after the caches of the informers have
+// synced and all the AuthConfigs that existed when the caches synced have been reconciled once. Until then, a replica
+// would deny the requests for hosts not yet in the index with NOT_FOUND.
+// If WaitForDependencies is true, the AuthConfigs must also have been reconciled successfully, i.e. with the external
+// dependencies fetched while building the configs (e.g. OpenID Connect and UMA discovery documents, OPA policies from
+// external registries) preloaded. AuthConfigs that fail to reconcile keep the replica not ready.
+// Once ready, the replica stays ready.
+type IndexReadiness struct {
+	Cache               CacheSyncer
+	AuthConfigs         *AuthConfigReconciler
+	WaitForDependencies bool
+	// Interval to check the AuthConfigs pending reconciliation
+	Interval time.Duration
+	Logger   logr.Logger
+
+	ready  bool
+	reason string
+	mu     sync.RWMutex
+}
+
+// Start waits for the caches to sync and for the AuthConfigs to be reconciled, and then marks the index as ready
+func (r *IndexReadiness) Start(ctx context.Context) error {
+	if !r.Cache.WaitForCacheSync(ctx) {
+		return fmt.Errorf("failed to wait for the caches to sync")
+	}
+	r.Logger.V(1).Info("caches synced")
+
+	var pending map[string]struct{}
+
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		authConfigList, err := r.AuthConfigs.listAuthConfigs(ctx)
+		if err != nil {
+			r.Logger.Error(err, "failed to list authconfigs")
+		} else {
+			listed := make(map[string]struct{}, len(authConfigList.Items))
+			for _, authConfig := range authConfigList.Items {
+				listed[authConfig.Namespace+"/"+authConfig.Name] = struct{}{}
+			}
+			if pending == nil {
+				pending = listed
+			}
+			for id := range pending {
+				// authconfigs deleted in the meantime are not waited for
+				if _, found := listed[id]; !found || r.reconciled(id) {
+					delete(pending, id)
+				}
+			}
+			if len(pending) == 0 {
+				r.setReady()
+				r.Logger.Info("index ready")
+				return nil
+			}
+			r.setNotReady(fmt.Sprintf("%d authconfigs pending reconciliation", len(pending)))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection tells the manager the index is checked by all replicas
+func (r *IndexReadiness) NeedLeaderElection() bool {
+	return false
+}
+
+// impl:health.Observable
+
+func (r *IndexReadiness) Ready(_, _ []string, _ bool) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.ready {
+		return nil
+	}
+	return fmt.Errorf("index is not ready: %s", r.reason)
+}
+
+func (r *IndexReadiness) reconciled(id string) bool {
+	status, found := r.AuthConfigs.StatusReport.Get(id)
+	if !found {
+		return false
+	}
+	switch status.Reason {
+	case api.StatusReasonReconciling:
+		return false
+	case api.StatusReasonReconciled, api.StatusReasonHostsNotLinked:
+		return true
+	default:
+		return !r.WaitForDependencies
+	}
+}
+
+func (r *IndexReadiness) setReady() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ready = true
+	r.reason = ""
+}
+
+func (r *IndexReadiness) setNotReady(reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reason = reason
+}
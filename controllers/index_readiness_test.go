@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	api "github.com/kuadrant/authorino/api/v1beta1"
+	"github.com/kuadrant/authorino/pkg/index"
+	"github.com/kuadrant/authorino/pkg/log"
+
+	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeCacheSyncer struct {
+	synced chan struct{}
+}
+
+func (c *fakeCacheSyncer) WaitForCacheSync(ctx context.Context) bool {
+	select {
+	case <-c.synced:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func TestIndexReadiness(t *testing.T) {
+	authConfig := newTestAuthConfig(map[string]string{})
+	other := newTestAuthConfig(map[string]string{})
+	other.ObjectMeta = metav1.ObjectMeta{Name: "auth-config-2", Namespace: "authorino"}
+	reconciler := newTestAuthConfigReconciler(newTestK8sClient(&authConfig, &other), index.NewIndex())
+
+	cache := &fakeCacheSyncer{synced: make(chan struct{})}
+	readiness := NewIndexReadiness(cache, reconciler, true, log.WithName("test"))
+	readiness.Interval = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error)
+	go func() { done <- readiness.Start(ctx) }()
+
+	assert.Error(t, readiness.Ready(nil, nil, false), "index is not ready: caches not synced")
+
+	close(cache.synced)
+	reconciler.StatusReport.Set("authorino/auth-config-1", api.StatusReasonReconciled, "", []string{"echo-api"})
+	reconciler.StatusReport.Set("authorino/auth-config-2", api.StatusReasonReconciling, "", []string{})
+	time.Sleep(50 * time.Millisecond)
+	assert.Error(t, readiness.Ready(nil, nil, false), "index is not ready: 1 authconfigs pending reconciliation")
+
+	// failed to fetch the external dependencies
+	reconciler.StatusReport.Set("authorino/auth-config-2", api.StatusReasonCachingError, "", []string{})
+	time.Sleep(50 * time.Millisecond)
+	assert.Check(t, readiness.Ready(nil, nil, false) != nil)
+
+	reconciler.StatusReport.Set("authorino/auth-config-2", api.StatusReasonReconciled, "", []string{"echo-api"})
+	select {
+	case err := <-done:
+		assert.NilError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("index not ready")
+	}
+	assert.NilError(t, readiness.Ready(nil, nil, false))
+}
+
+func TestIndexReadinessWithoutDependencies(t *testing.T) {
+	authConfig := newTestAuthConfig(map[string]string{})
+	reconciler := newTestAuthConfigReconciler(newTestK8sClient(&authConfig), index.NewIndex())
+	reconciler.StatusReport.Set("authorino/auth-config-1", api.StatusReasonInvalidResource, "", []string{})
+
+	cache := &fakeCacheSyncer{synced: make(chan struct{})}
+	close(cache.synced)
+	readiness := NewIndexReadiness(cache, reconciler, false, log.WithName("test"))
+
+	assert.NilError(t, readiness.Start(context.Background()))
+	assert.NilError(t, readiness.Ready(nil, nil, false))
+}
@@ -0,0 +1,251 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	api "github.com/kuadrant/authorino/api/v1beta1"
+
+	"github.com/go-logr/logr"
+	k8score "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// IndexSnapshotConfigMapKey is the key of the ConfigMap that stores the snapshot of the index
+	IndexSnapshotConfigMapKey = "authconfigs.json"
+
+	DefaultIndexSnapshotInterval = 30 // seconds
+)
+
+// IndexSnapshotStore persists the snapshots of the index
+type IndexSnapshotStore interface {
+	// Load returns the last snapshot saved, or nil if there is none
+	Load(ctx context.Context) ([]byte, error)
+	Save(ctx context.Context, snapshot []byte) error
+}
+
+// NewFileIndexSnapshotStore returns a store that keeps the snapshot in a file in the file system
+func NewFileIndexSnapshotStore(path string) IndexSnapshotStore {
+	return &fileIndexSnapshotStore{path: path}
+}
+
+type fileIndexSnapshotStore struct {
+	path string
+}
+
+func (s *fileIndexSnapshotStore) Load(_ context.Context) ([]byte, error) {
+	snapshot, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return snapshot, err
+}
+
+// Save writes the snapshot to a temporary file, then renames it, so a crash while writing does not corrupt the
+// previous snapshot
+func (s *fileIndexSnapshotStore) Save(_ context.Context, snapshot []byte) error {
+	file, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(snapshot); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), s.path)
+}
+
+// NewConfigMapIndexSnapshotStore returns a store that keeps the snapshot in a Kubernetes ConfigMap
+func NewConfigMapIndexSnapshotStore(k8sClient client.Client, namespace, name string) IndexSnapshotStore {
+	return &configMapIndexSnapshotStore{
+		client: k8sClient,
+		name:   types.NamespacedName{Namespace: namespace, Name: name},
+	}
+}
+
+type configMapIndexSnapshotStore struct {
+	client client.Client
+	name   types.NamespacedName
+}
+
+func (s *configMapIndexSnapshotStore) Load(ctx context.Context) ([]byte, error) {
+	configMap := &k8score.ConfigMap{}
+	if err := s.client.Get(ctx, s.name, configMap); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if snapshot, found := configMap.Data[IndexSnapshotConfigMapKey]; found {
+		return []byte(snapshot), nil
+	}
+	return nil, nil
+}
+
+func (s *configMapIndexSnapshotStore) Save(ctx context.Context, snapshot []byte) error {
+	configMap := &k8score.ConfigMap{}
+	if err := s.client.Get(ctx, s.name, configMap); err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		configMap = &k8score.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: s.name.Namespace, Name: s.name.Name},
+			Data:       map[string]string{IndexSnapshotConfigMapKey: string(snapshot)},
+		}
+		return s.client.Create(ctx, configMap)
+	}
+	if configMap.Data == nil {
+		configMap.Data = map[string]string{}
+	}
+	configMap.Data[IndexSnapshotConfigMapKey] = string(snapshot)
+	return s.client.Update(ctx, configMap)
+}
+
+func NewIndexSnapshot(store IndexSnapshotStore, interval time.Duration, logger logr.Logger) *IndexSnapshot {
+	return &IndexSnapshot{
+		Store:       store,
+		Interval:    interval,
+		Logger:      logger,
+		authConfigs: make(map[string]api.AuthConfig),
+	}
+}
+
+// IndexSnapshot keeps track of the last successfully reconciled revision of each AuthConfig and periodically saves them
+// to a store, so a restarting instance can build the index out of the last known good configs, instead of serving
+// placeholder configs until all resources are reconciled again.
+// Only the specs of the AuthConfigs are saved; Secrets referred in the specs are read again when the index is warmed up.
+type IndexSnapshot struct {
+	Store    IndexSnapshotStore
+	Interval time.Duration
+	Logger   logr.Logger
+
+	authConfigs map[string]api.AuthConfig
+	dirty       bool
+	mu          sync.Mutex
+}
+
+// Set records the revision of an AuthConfig that is indexed
+func (s *IndexSnapshot) Set(id string, authConfig api.AuthConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.authConfigs[id] = strippedAuthConfig(authConfig)
+	s.dirty = true
+}
+
+// Clear removes an AuthConfig from the snapshot
+func (s *IndexSnapshot) Clear(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, found := s.authConfigs[id]; found {
+		delete(s.authConfigs, id)
+		s.dirty = true
+	}
+}
+
+// Load reads the last snapshot from the store
+func (s *IndexSnapshot) Load(ctx context.Context) ([]api.AuthConfig, error) {
+	data, err := s.Store.Load(ctx)
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	var authConfigs []api.AuthConfig
+	if err := json.Unmarshal(data, &authConfigs); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, authConfig := range authConfigs {
+		s.authConfigs[types.NamespacedName{Namespace: authConfig.Namespace, Name: authConfig.Name}.String()] = authConfig
+	}
+	return authConfigs, nil
+}
+
+// Save writes the snapshot to the store, if anything changed since the last time it was saved
+func (s *IndexSnapshot) Save(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.dirty {
+		s.mu.Unlock()
+		return nil
+	}
+	ids := make([]string, 0, len(s.authConfigs))
+	for id := range s.authConfigs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	authConfigs := make([]api.AuthConfig, len(ids))
+	for i, id := range ids {
+		authConfigs[i] = s.authConfigs[id]
+	}
+	s.dirty = false
+	s.mu.Unlock()
+
+	data, err := json.Marshal(authConfigs)
+	if err == nil {
+		err = s.Store.Save(ctx, data)
+	}
+	if err != nil {
+		s.mu.Lock()
+		s.dirty = true
+		s.mu.Unlock()
+		return err
+	}
+	s.Logger.V(1).Info("index snapshot saved", "count", len(authConfigs))
+	return nil
+}
+
+// Start saves the snapshot on every interval, and one last time when the context is done
+func (s *IndexSnapshot) Start(ctx context.Context) error {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Save(ctx); err != nil {
+				s.Logger.Error(err, "failed to save index snapshot")
+			}
+		case <-ctx.Done():
+			if err := s.Save(context.Background()); err != nil {
+				s.Logger.Error(err, "failed to save index snapshot")
+			}
+			return nil
+		}
+	}
+}
+
+// strippedAuthConfig returns a copy of an AuthConfig with only the metadata required to rebuild its config and no status
+func strippedAuthConfig(authConfig api.AuthConfig) api.AuthConfig {
+	return api.AuthConfig{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "AuthConfig",
+			APIVersion: api.GroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         authConfig.Namespace,
+			Name:              authConfig.Name,
+			Labels:            authConfig.Labels,
+			Generation:        authConfig.Generation,
+			CreationTimestamp: authConfig.CreationTimestamp,
+		},
+		Spec: *authConfig.Spec.DeepCopy(),
+	}
+}
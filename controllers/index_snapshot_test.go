@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/index"
+	"github.com/kuadrant/authorino/pkg/log"
+
+	"gotest.tools/assert"
+	k8score "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestIndexSnapshotFileStore(t *testing.T) {
+	store := NewFileIndexSnapshotStore(filepath.Join(t.TempDir(), "snapshot.json"))
+	snapshot := NewIndexSnapshot(store, time.Minute, log.WithName("test").WithName("snapshot"))
+
+	authConfigs, err := snapshot.Load(context.TODO())
+	assert.NilError(t, err)
+	assert.Equal(t, len(authConfigs), 0)
+
+	authConfig := newTestAuthConfig(map[string]string{"app": "echo"})
+	authConfig.Generation = 3
+	snapshot.Set("authorino/auth-config-1", authConfig)
+	assert.NilError(t, snapshot.Save(context.TODO()))
+
+	authConfigs, err = NewIndexSnapshot(store, time.Minute, log.WithName("test").WithName("snapshot")).Load(context.TODO())
+	assert.NilError(t, err)
+	assert.Equal(t, len(authConfigs), 1)
+	assert.Equal(t, authConfigs[0].Namespace, "authorino")
+	assert.Equal(t, authConfigs[0].Name, "auth-config-1")
+	assert.Equal(t, authConfigs[0].Generation, int64(3))
+	assert.DeepEqual(t, authConfigs[0].Labels, map[string]string{"app": "echo"})
+	assert.DeepEqual(t, authConfigs[0].Spec.Hosts, []string{"echo-api"})
+
+	snapshot.Clear("authorino/auth-config-1")
+	assert.NilError(t, snapshot.Save(context.TODO()))
+	authConfigs, err = snapshot.Load(context.TODO())
+	assert.NilError(t, err)
+	assert.Equal(t, len(authConfigs), 0)
+}
+
+func TestIndexSnapshotConfigMapStore(t *testing.T) {
+	client := newTestK8sClient()
+	store := NewConfigMapIndexSnapshotStore(client, "authorino", "authorino-index-snapshot")
+
+	data, err := store.Load(context.TODO())
+	assert.NilError(t, err)
+	assert.Check(t, data == nil)
+
+	assert.NilError(t, store.Save(context.TODO(), []byte(`[]`)))
+	assert.NilError(t, store.Save(context.TODO(), []byte(`[{}]`)))
+
+	configMap := &k8score.ConfigMap{}
+	assert.NilError(t, client.Get(context.TODO(), types.NamespacedName{Namespace: "authorino", Name: "authorino-index-snapshot"}, configMap))
+	assert.Equal(t, configMap.Data[IndexSnapshotConfigMapKey], `[{}]`)
+
+	data, err = store.Load(context.TODO())
+	assert.NilError(t, err)
+	assert.Equal(t, string(data), `[{}]`)
+}
+
+func TestWarmUpIndex(t *testing.T) {
+	authConfig := newTestAuthConfig(map[string]string{})
+	secret := newTestOAuthClientSecret()
+
+	store := NewFileIndexSnapshotStore(filepath.Join(t.TempDir(), "snapshot.json"))
+	snapshot := NewIndexSnapshot(store, time.Minute, log.WithName("test").WithName("snapshot"))
+	snapshot.Set("authorino/auth-config-1", authConfig)
+	assert.NilError(t, snapshot.Save(context.TODO()))
+
+	// restart
+	authConfigIndex := index.NewIndex()
+	client := newTestK8sClient(&secret) // the authconfig was deleted while the instance was down
+	reconciler := newTestAuthConfigReconciler(client, authConfigIndex)
+	reconciler.Snapshot = NewIndexSnapshot(store, time.Minute, log.WithName("test").WithName("snapshot"))
+
+	assert.NilError(t, reconciler.WarmUpIndex(context.TODO(), client))
+	config := authConfigIndex.Get("echo-api")
+	assert.Check(t, config != nil)
+	assert.Equal(t, config.Labels["name"], "auth-config-1")
+	assert.Equal(t, len(config.AuthorizationConfigs), 2) // not the deny-all placeholder
+
+	// first reconciliation after the caches are synced
+	_, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "authorino", Name: "other"}})
+	assert.NilError(t, err)
+	assert.Check(t, authConfigIndex.Get("echo-api") == nil)
+	assert.NilError(t, reconciler.Snapshot.Save(context.TODO()))
+	authConfigs, _ := reconciler.Snapshot.Load(context.TODO())
+	assert.Equal(t, len(authConfigs), 0)
+}
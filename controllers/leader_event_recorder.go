@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+// NewLeaderEventRecorder returns an event recorder that only emits events while the replica is the elected leader.
+// All replicas of an instance reconcile the same resources; gating the events on the leadership prevents the same
+// event from being emitted once per replica.
+func NewLeaderEventRecorder(recorder record.EventRecorder, elected <-chan struct{}) record.EventRecorder {
+	return &leaderEventRecorder{
+		recorder: recorder,
+		elected:  elected,
+	}
+}
+
+type leaderEventRecorder struct {
+	recorder record.EventRecorder
+	elected  <-chan struct{}
+}
+
+func (r *leaderEventRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	if r.leader() {
+		r.recorder.Event(object, eventtype, reason, message)
+	}
+}
+
+func (r *leaderEventRecorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	if r.leader() {
+		r.recorder.Eventf(object, eventtype, reason, messageFmt, args...)
+	}
+}
+
+func (r *leaderEventRecorder) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
+	if r.leader() {
+		r.recorder.AnnotatedEventf(object, annotations, eventtype, reason, messageFmt, args...)
+	}
+}
+
+func (r *leaderEventRecorder) leader() bool {
+	select {
+	case <-r.elected:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,25 @@
+package controllers
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	k8score "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestLeaderEventRecorder(t *testing.T) {
+	fakeRecorder := record.NewFakeRecorder(10)
+	elected := make(chan struct{})
+	recorder := NewLeaderEventRecorder(fakeRecorder, elected)
+	authConfig := newTestAuthConfig(map[string]string{})
+
+	recorder.Eventf(&authConfig, k8score.EventTypeWarning, "HostsNotLinked", "Host %s already taken", "echo-api")
+	assert.Equal(t, len(fakeRecorder.Events), 0)
+
+	close(elected)
+
+	recorder.Eventf(&authConfig, k8score.EventTypeWarning, "HostsNotLinked", "Host %s already taken", "echo-api")
+	assert.Equal(t, len(fakeRecorder.Events), 1)
+	assert.Equal(t, <-fakeRecorder.Events, "Warning HostsNotLinked Host echo-api already taken")
+}
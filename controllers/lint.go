@@ -0,0 +1,243 @@
+package controllers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	goerrors "errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	api "github.com/kuadrant/authorino/api/v1beta1"
+	"github.com/kuadrant/authorino/api/v1beta2"
+	"github.com/kuadrant/authorino/pkg/log"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type LintSeverity string
+
+const (
+	LintSeverityError   LintSeverity = "error"
+	LintSeverityWarning LintSeverity = "warning"
+)
+
+// LintFinding is an issue found in an AuthConfig, or in a document that could not be read
+type LintFinding struct {
+	Source   string       `json:"source"`
+	Resource string       `json:"resource,omitempty"`
+	Severity LintSeverity `json:"severity"`
+	Field    string       `json:"field,omitempty"`
+	Message  string       `json:"message"`
+}
+
+func (f LintFinding) String() string {
+	location := f.Source
+	if f.Resource != "" {
+		location += ": " + f.Resource
+	}
+	if f.Field != "" {
+		return fmt.Sprintf("%s: %s: %s: %s", location, f.Severity, f.Field, f.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", location, f.Severity, f.Message)
+}
+
+// Linter checks AuthConfigs out of the cluster, validating them as the admission webhook does and building them as the
+// reconciler does, so broken configs are caught before they are applied.
+// References to Secrets and IdentityProviders are resolved among the documents linted together; references not found
+// are reported as warnings, since the resources may exist in the cluster, and the AuthConfigs that hold them are not
+// built.
+type Linter struct {
+	Scheme *runtime.Scheme
+	// Namespace of the documents that do not specify one
+	Namespace string
+	// Build tells whether to build the configs as the reconciler does, which involves fetching the discovery documents
+	// of some external services (e.g. OpenID Connect and UMA)
+	Build bool
+}
+
+type lintedAuthConfig struct {
+	source     string
+	authConfig *v1beta2.AuthConfig
+}
+
+// Lint checks the AuthConfigs of a set of sources (e.g. files) of YAML or JSON documents, by source name.
+// Documents of kinds other than AuthConfig, Secret and IdentityProvider are ignored.
+func (l *Linter) Lint(ctx context.Context, sources map[string][]byte) []LintFinding {
+	var findings []LintFinding
+	var authConfigs []lintedAuthConfig
+	var objects []runtime.Object
+
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	decoder := serializer.NewCodecFactory(l.Scheme).UniversalDeserializer()
+	declared := map[string]string{}
+	for _, name := range names {
+		reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(sources[name])))
+		for i := 1; ; i++ {
+			document, err := reader.Read()
+			if goerrors.Is(err, io.EOF) {
+				break
+			}
+			source := fmt.Sprintf("%s#%d", name, i)
+			if err != nil {
+				findings = append(findings, LintFinding{Source: source, Severity: LintSeverityError, Message: err.Error()})
+				break
+			}
+			if len(bytes.TrimSpace(document)) == 0 || isYAMLComment(document) {
+				continue
+			}
+
+			obj, _, err := decoder.Decode(document, nil, nil)
+			if err != nil {
+				if runtime.IsNotRegisteredError(err) {
+					continue
+				}
+				findings = append(findings, LintFinding{Source: source, Severity: LintSeverityError, Message: err.Error()})
+				continue
+			}
+			meta, ok := obj.(metav1.Object)
+			if !ok {
+				continue
+			}
+			if meta.GetNamespace() == "" {
+				meta.SetNamespace(l.Namespace)
+			}
+			switch obj.(type) {
+			case *api.AuthConfig, *v1beta2.AuthConfig, *v1.Secret, *v1beta2.IdentityProvider:
+				kind := obj.GetObjectKind().GroupVersionKind().GroupKind().String()
+				if other, duplicate := declared[kind+" "+resourceName(meta)]; duplicate {
+					findings = append(findings, LintFinding{Source: source, Resource: resourceName(meta), Severity: LintSeverityError, Message: fmt.Sprintf("%s already declared in %s", kind, other)})
+					continue
+				}
+				declared[kind+" "+resourceName(meta)] = source
+			}
+
+			switch o := obj.(type) {
+			case *api.AuthConfig:
+				converted := &v1beta2.AuthConfig{}
+				if err := converted.ConvertFrom(o); err != nil {
+					findings = append(findings, LintFinding{Source: source, Resource: resourceName(o), Severity: LintSeverityError, Message: err.Error()})
+					continue
+				}
+				authConfigs = append(authConfigs, lintedAuthConfig{source: source, authConfig: converted})
+			case *v1beta2.AuthConfig:
+				authConfigs = append(authConfigs, lintedAuthConfig{source: source, authConfig: o})
+			case *v1.Secret:
+				// the api server would merge the string data into the data of the secret
+				for key, value := range o.StringData {
+					if o.Data == nil {
+						o.Data = map[string][]byte{}
+					}
+					o.Data[key] = []byte(value)
+				}
+				objects = append(objects, o)
+			case *v1beta2.IdentityProvider:
+				objects = append(objects, o)
+			}
+		}
+	}
+
+	for i, linted := range authConfigs {
+		// hosts collide with the ones of the authconfigs declared before only, as if the documents were applied in order
+		declaredBefore := make([]runtime.Object, 0, len(objects)+i)
+		declaredBefore = append(declaredBefore, objects...)
+		for _, other := range authConfigs[:i] {
+			declaredBefore = append(declaredBefore, other.authConfig)
+		}
+		reader := fake.NewClientBuilder().WithScheme(l.Scheme).WithRuntimeObjects(declaredBefore...).Build()
+		validator := &v1beta2.AuthConfigValidator{Reader: reader}
+
+		authConfigFindings := l.validate(ctx, validator, linted)
+		if len(authConfigFindings) == 0 && l.Build {
+			authConfigFindings = l.build(ctx, reader, linted)
+		}
+		findings = append(findings, authConfigFindings...)
+	}
+
+	return findings
+}
+
+// validate runs the checks of the admission webhook
+func (l *Linter) validate(ctx context.Context, validator *v1beta2.AuthConfigValidator, linted lintedAuthConfig) (findings []LintFinding) {
+	err := validator.ValidateCreate(ctx, linted.authConfig)
+	if err == nil {
+		return nil
+	}
+
+	var status errors.APIStatus
+	if !goerrors.As(err, &status) || status.Status().Details == nil {
+		return []LintFinding{{Source: linted.source, Resource: resourceName(linted.authConfig), Severity: LintSeverityError, Message: err.Error()}}
+	}
+	for _, cause := range status.Status().Details.Causes {
+		severity := LintSeverityError
+		if cause.Type == metav1.CauseTypeFieldValueNotFound {
+			severity = LintSeverityWarning
+		}
+		findings = append(findings, LintFinding{
+			Source:   linted.source,
+			Resource: resourceName(linted.authConfig),
+			Severity: severity,
+			Field:    cause.Field,
+			Message:  cause.Message,
+		})
+	}
+	return
+}
+
+// build builds the config as the reconciler does, e.g. compiling the Rego policies and running their tests
+func (l *Linter) build(ctx context.Context, reader client.Client, linted lintedAuthConfig) []LintFinding {
+	authConfig := &api.AuthConfig{}
+	if err := linted.authConfig.ConvertTo(authConfig); err != nil {
+		return []LintFinding{{Source: linted.source, Resource: resourceName(linted.authConfig), Severity: LintSeverityError, Message: err.Error()}}
+	}
+
+	reconciler := &AuthConfigReconciler{
+		Client:            reader,
+		Logger:            log.WithName("lint"),
+		IdentityProviders: true,
+	}
+	translatedAuthConfig, err := reconciler.translateAuthConfig(log.IntoContext(ctx, reconciler.Logger), authConfig)
+	if err != nil {
+		return []LintFinding{{Source: linted.source, Resource: resourceName(linted.authConfig), Severity: LintSeverityError, Message: err.Error()}}
+	}
+	// shuts down the async workers started while building the config
+	_ = reconciler.cleanConfigs(translatedAuthConfig, ctx)
+	return nil
+}
+
+// LintFailed tells whether any of the findings is an error
+func LintFailed(findings []LintFinding) bool {
+	for _, finding := range findings {
+		if finding.Severity == LintSeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+func resourceName(obj metav1.Object) string {
+	return fmt.Sprintf("%s/%s", obj.GetNamespace(), obj.GetName())
+}
+
+func isYAMLComment(document []byte) bool {
+	for _, line := range strings.Split(string(document), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	api "github.com/kuadrant/authorino/api/v1beta1"
+	"github.com/kuadrant/authorino/api/v1beta2"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const lintTestAuthConfigs = `
+apiVersion: authorino.kuadrant.io/v1beta2
+kind: AuthConfig
+metadata:
+  name: valid
+spec:
+  hosts:
+  - valid.io
+  authentication:
+    "anonymous":
+      anonymous: {}
+  authorization:
+    "rego":
+      opa:
+        rego: allow = true
+---
+# invalid selector and duplicate host
+apiVersion: authorino.kuadrant.io/v1beta2
+kind: AuthConfig
+metadata:
+  name: invalid
+  namespace: apps
+spec:
+  hosts:
+  - invalid.io
+  - invalid.io
+  authorization:
+    "rules":
+      patternMatching:
+        patterns:
+        - selector: request.headers.x-user|@case:upper)
+          operator: eq
+          value: admin
+---
+apiVersion: authorino.kuadrant.io/v1beta2
+kind: AuthConfig
+metadata:
+  name: broken-policy
+spec:
+  hosts:
+  - broken.io
+  authorization:
+    "rego":
+      opa:
+        rego: allow = {
+`
+
+const lintTestReferences = `
+apiVersion: authorino.kuadrant.io/v1beta1
+kind: AuthConfig
+metadata:
+  name: references
+spec:
+  hosts:
+  - valid.io
+  metadata:
+  - name: uma
+    uma:
+      endpoint: http://127.0.0.1:9001/auth/realms/demo
+      credentialsRef:
+        name: missing
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: api-key
+stringData:
+  api_key: secret
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ignored
+`
+
+func TestLint(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = api.AddToScheme(scheme)
+	_ = v1beta2.AddToScheme(scheme)
+	_ = v1.AddToScheme(scheme)
+
+	linter := &Linter{Scheme: scheme, Namespace: "default", Build: true}
+	findings := linter.Lint(context.TODO(), map[string][]byte{
+		"authconfigs.yaml": []byte(lintTestAuthConfigs),
+		"references.yaml":  []byte(lintTestReferences),
+		"malformed.yaml":   []byte("kind: [AuthConfig"),
+	})
+
+	assert.Equal(t, len(findings), 6)
+
+	assert.Equal(t, findings[0].Source, "malformed.yaml#1")
+	assert.Equal(t, findings[0].Severity, LintSeverityError)
+
+	// authconfigs.yaml#1 is valid
+	assert.Equal(t, findings[1].Source, "authconfigs.yaml#2")
+	assert.Equal(t, findings[1].Resource, "apps/invalid")
+	assert.Equal(t, findings[1].Field, "spec.authorization[rules].patternMatching.patterns[0].selector")
+	assert.Equal(t, findings[1].Severity, LintSeverityError)
+	assert.Equal(t, findings[2].Field, "spec.hosts[1]")
+	assert.Equal(t, findings[2].Severity, LintSeverityError)
+
+	assert.Equal(t, findings[3].Source, "authconfigs.yaml#3")
+	assert.Equal(t, findings[3].Resource, "default/broken-policy")
+	assert.Equal(t, findings[3].Severity, LintSeverityError)
+	assert.Equal(t, findings[3].Field, "")
+	assert.Check(t, findings[3].Message != "")
+
+	// missing secret and host collision
+	assert.Equal(t, findings[4].Source, "references.yaml#1")
+	assert.Equal(t, findings[4].Resource, "default/references")
+	assert.Equal(t, findings[4].Field, "spec.hosts[0]")
+	assert.Equal(t, findings[4].Severity, LintSeverityError)
+	assert.Equal(t, findings[4].Message, `Invalid value: "valid.io": host already taken by authconfig default/valid`)
+	assert.Equal(t, findings[5].Field, "spec.metadata[uma].uma.credentialsRef")
+	assert.Equal(t, findings[5].Severity, LintSeverityWarning)
+	assert.Equal(t, findings[5].String(), `references.yaml#1: default/references: warning: spec.metadata[uma].uma.credentialsRef: Not found: "missing"`)
+
+	assert.Check(t, LintFailed(findings))
+	assert.Check(t, !LintFailed(findings[5:]))
+}
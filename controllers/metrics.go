@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"github.com/kuadrant/authorino/pkg/index"
+	"github.com/kuadrant/authorino/pkg/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	reconcileDurationMetric = metrics.NewAuthConfigDurationMetric("authconfig_reconcile_duration_seconds", "Length of time per reconciliation of authconfig (in seconds).")
+	reconcileErrorsMetric   = metrics.NewAuthConfigCounterMetric("authconfig_reconcile_errors_total", "Total number of failed reconciliations of authconfig.")
+	dependencyHealthMetric  = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "authconfig_dependency_up",
+		Help: "Whether the last probe of an external dependency of authconfig succeeded (1) or not (0).",
+	}, []string{"namespace", "authconfig", "evaluator", "kind"})
+)
+
+func init() {
+	// exported along with the other metrics of the controller-runtime (i.e. at the /metrics endpoint)
+	ctrlmetrics.Registry.MustRegister(
+		reconcileDurationMetric,
+		reconcileErrorsMetric,
+		dependencyHealthMetric,
+	)
+}
+
+// registerIndexMetrics exports the number of authconfigs and hosts in the index, read from the index at every scrape
+func registerIndexMetrics(i index.Index) error {
+	authConfigsMetric := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "authconfig_index_authconfigs",
+		Help: "Number of authconfigs in the index.",
+	}, func() float64 {
+		return float64(len(i.ListIds()))
+	})
+
+	hostsMetric := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "authconfig_index_hosts",
+		Help: "Number of hosts linked to authconfigs in the index.",
+	}, func() float64 {
+		return float64(countIndexedHosts(i))
+	})
+
+	for _, metric := range []prometheus.Collector{authConfigsMetric, hostsMetric} {
+		if err := ctrlmetrics.Registry.Register(metric); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func countIndexedHosts(i index.Index) int {
+	hosts := 0
+	for _, id := range i.ListIds() {
+		hosts += len(i.FindKeys(id))
+	}
+	return hosts
+}
@@ -0,0 +1,239 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	api "github.com/kuadrant/authorino/api/v1beta1"
+	"github.com/kuadrant/authorino/pkg/log"
+	"github.com/kuadrant/authorino/pkg/utils"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const DefaultRemoteClusterRetryInterval = 30 * time.Second
+
+// RemoteClusterReconciler reconciles the AuthConfigs of a remote cluster into the index of the local instance, so a
+// centralized gateway can front services of multiple clusters.
+// The AuthConfigs are indexed under ids prefixed with the name of the cluster, apart from the ones of the local cluster,
+// and the configs are labeled with the name of the cluster of origin. The Secrets referred in the AuthConfigs are read
+// from the remote cluster as well.
+// The status of the AuthConfigs in the remote cluster is not updated.
+type RemoteClusterReconciler struct {
+	Name                string
+	Config              *rest.Config
+	Options             ctrl.Options
+	Logger              logr.Logger
+	AuthConfigs         *AuthConfigReconciler
+	SecretLabelSelector labels.Selector
+	RetryInterval       time.Duration
+
+	client  client.Client
+	indexed map[string]struct{}
+	mutex   sync.Mutex
+}
+
+// Start watches the remote cluster until the context is done, trying to connect again on failures.
+// The configs already indexed keep being served while the remote cluster is unreachable.
+func (r *RemoteClusterReconciler) Start(ctx context.Context) error {
+	for {
+		err := r.run(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		r.Logger.Error(err, "failed to watch remote cluster", "retryIn", r.RetryInterval.String())
+
+		select {
+		case <-time.After(r.RetryInterval):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// run starts a manager with its own caches of the remote cluster
+func (r *RemoteClusterReconciler) run(ctx context.Context) error {
+	mgr, err := ctrl.NewManager(r.Config, r.Options)
+	if err != nil {
+		return err
+	}
+	r.client = mgr.GetClient()
+
+	if err := r.SetupWithManager(mgr); err != nil {
+		return err
+	}
+	if err := (&SecretReconciler{
+		Client:        mgr.GetClient(),
+		Logger:        r.Logger.WithName("secret"),
+		Scheme:        mgr.GetScheme(),
+		Index:         r.AuthConfigs.Index,
+		LabelSelector: r.SecretLabelSelector,
+		Namespace:     r.AuthConfigs.Namespace,
+		Cluster:       r.Name,
+	}).SetupWithManager(mgr); err != nil {
+		return err
+	}
+
+	// resources deleted while disconnected from the remote cluster are not reported by the watch
+	if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		if !mgr.GetCache().WaitForCacheSync(ctx) {
+			return nil
+		}
+		if err := r.pruneIndex(ctx); err != nil {
+			r.Logger.Error(err, "failed to prune the index")
+		}
+		return nil
+	})); err != nil {
+		return err
+	}
+
+	r.Logger.Info("watching remote cluster", "host", r.Config.Host)
+	return mgr.Start(ctx)
+}
+
+func (r *RemoteClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	resourceId := remoteClusterAuthConfigId(r.Name, req.NamespacedName)
+	logger := r.Logger.WithValues("authconfig", resourceId)
+
+	authConfig := api.AuthConfig{}
+	if err := r.client.Get(ctx, req.NamespacedName, &authConfig); err != nil && !errors.IsNotFound(err) {
+		// could not get the resource but not because of a 404 Not found (some error must have happened)
+		return ctrl.Result{}, err
+	} else if errors.IsNotFound(err) || !Watched(&authConfig.ObjectMeta, r.AuthConfigs.LabelSelector) || !r.AuthConfigs.InScope(req.Namespace) {
+		// could not find the resource (404 Not found, resource must have been deleted)
+		// or the resource is no longer to be watched (labels no longer match or out of the watched namespace)
+		r.deindex(ctx, resourceId)
+		logger.Info("resource de-indexed")
+		return ctrl.Result{}, nil
+	}
+
+	if err := r.index(log.IntoContext(ctx, logger), resourceId, &authConfig); err != nil {
+		logger.Error(err, "failed to index authconfig")
+		return ctrl.Result{}, err
+	}
+
+	logger.Info("resource reconciled")
+	return ctrl.Result{}, nil
+}
+
+func (r *RemoteClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("authconfig-"+r.Name).
+		For(&api.AuthConfig{}, builder.WithPredicates(LabelSelectorPredicate(r.AuthConfigs.LabelSelector))).
+		Complete(r)
+}
+
+// index builds the config out of the resource and the Secrets of the remote cluster and swaps it into the index in
+// place of the one currently indexed for the resource, if any
+func (r *RemoteClusterReconciler) index(ctx context.Context, resourceId string, authConfig *api.AuthConfig) error {
+	remote := &AuthConfigReconciler{
+		Client:            r.client,
+		Logger:            r.Logger,
+		Index:             r.AuthConfigs.Index,
+		Namespace:         r.AuthConfigs.Namespace,
+		IdentityProviders: r.AuthConfigs.IdentityProviders,
+		Vault:             r.AuthConfigs.Vault,
+		SecretFiles:       r.AuthConfigs.SecretFiles,
+	}
+
+	translatedAuthConfig, err := remote.translateAuthConfig(ctx, authConfig)
+	if err != nil {
+		return err
+	}
+	translatedAuthConfig.Labels["cluster"] = r.Name
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	indexedAuthConfig := r.AuthConfigs.indexedAuthConfig(resourceId)
+
+	unusedHosts := utils.SubtractSlice(r.AuthConfigs.Index.FindKeys(resourceId), authConfig.Spec.Hosts)
+	for _, host := range unusedHosts {
+		r.AuthConfigs.Index.DeleteKey(resourceId, host)
+	}
+	if len(unusedHosts) > 0 {
+		r.AuthConfigs.reconcileLooseResources(resourceId)
+	}
+
+	_, looseHosts, err := r.AuthConfigs.addToIndex(ctx, authConfig.Namespace, resourceId, translatedAuthConfig, authConfig.Spec.Hosts)
+	if err != nil {
+		return err
+	}
+	for host, indexedResourceId := range looseHosts {
+		log.FromContext(ctx).Info("host not linked", "host", host, "reason", fmt.Sprintf("already taken by %s", indexedResourceId))
+	}
+
+	if err := r.AuthConfigs.cleanConfigs(indexedAuthConfig, ctx); err != nil {
+		log.FromContext(ctx).Error(err, failedToCleanConfig)
+	}
+
+	if r.indexed == nil {
+		r.indexed = map[string]struct{}{}
+	}
+	r.indexed[resourceId] = struct{}{}
+	return nil
+}
+
+func (r *RemoteClusterReconciler) deindex(ctx context.Context, resourceId string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	indexedAuthConfig := r.AuthConfigs.indexedAuthConfig(resourceId)
+	r.AuthConfigs.Index.Delete(resourceId)
+	r.AuthConfigs.reconcileLooseResources(resourceId)
+	if err := r.AuthConfigs.cleanConfigs(indexedAuthConfig, ctx); err != nil {
+		r.Logger.Error(err, failedToCleanConfig, "authconfig", resourceId)
+	}
+	delete(r.indexed, resourceId)
+}
+
+// pruneIndex drops from the index the configs of the remote cluster whose resources no longer exist
+func (r *RemoteClusterReconciler) pruneIndex(ctx context.Context) error {
+	authConfigList := &api.AuthConfigList{}
+	listOptions := []client.ListOption{}
+	if r.AuthConfigs.LabelSelector != nil {
+		listOptions = append(listOptions, client.MatchingLabelsSelector{Selector: r.AuthConfigs.LabelSelector})
+	}
+	if !r.AuthConfigs.ClusterWide() {
+		listOptions = append(listOptions, client.InNamespace(r.AuthConfigs.Namespace))
+	}
+	if err := r.client.List(ctx, authConfigList, listOptions...); err != nil {
+		return err
+	}
+
+	existing := make(map[string]struct{}, len(authConfigList.Items))
+	for _, authConfig := range authConfigList.Items {
+		existing[remoteClusterAuthConfigId(r.Name, types.NamespacedName{Namespace: authConfig.Namespace, Name: authConfig.Name})] = struct{}{}
+	}
+
+	r.mutex.Lock()
+	stale := []string{}
+	for resourceId := range r.indexed {
+		if _, found := existing[resourceId]; !found {
+			stale = append(stale, resourceId)
+		}
+	}
+	r.mutex.Unlock()
+
+	for _, resourceId := range stale {
+		r.deindex(ctx, resourceId)
+		r.Logger.Info("resource de-indexed", "authconfig", resourceId, "reason", "not found after reconnecting")
+	}
+	return nil
+}
+
+// remoteClusterAuthConfigId returns the id under which an AuthConfig of a remote cluster is indexed, kept apart from
+// the ids of the AuthConfigs of the local cluster
+func remoteClusterAuthConfigId(cluster string, authConfig types.NamespacedName) string {
+	return fmt.Sprintf("cluster:%s:%s", cluster, authConfig.String())
+}
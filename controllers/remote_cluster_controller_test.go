@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kuadrant/authorino/pkg/index"
+	"github.com/kuadrant/authorino/pkg/log"
+
+	"gotest.tools/assert"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestRemoteClusterReconciler(t *testing.T) {
+	authConfigIndex := index.NewIndex()
+
+	// local cluster
+	localAuthConfig := newTestAuthConfig(map[string]string{})
+	localAuthConfig.Spec.Hosts = []string{"local-api"}
+	localSecret := newTestOAuthClientSecret()
+	local := newTestAuthConfigReconciler(newTestK8sClient(&localAuthConfig, &localSecret), authConfigIndex)
+	_, err := local.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: localAuthConfig.Namespace, Name: localAuthConfig.Name}})
+	assert.NilError(t, err)
+
+	// remote cluster
+	remoteAuthConfig := newTestAuthConfig(map[string]string{})
+	remoteAuthConfig.Spec.Hosts = []string{"echo-api", "local-api"}
+	remoteSecret := newTestOAuthClientSecret()
+	remoteClient := newTestK8sClient(&remoteAuthConfig, &remoteSecret)
+	reconciler := &RemoteClusterReconciler{
+		Name:        "east",
+		Logger:      log.WithName("test").WithName("remotecluster"),
+		AuthConfigs: local,
+		client:      remoteClient,
+	}
+	resourceName := types.NamespacedName{Namespace: remoteAuthConfig.Namespace, Name: remoteAuthConfig.Name}
+
+	_, err = reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: resourceName})
+	assert.NilError(t, err)
+
+	id, found := authConfigIndex.FindId("echo-api")
+	assert.Check(t, found)
+	assert.Equal(t, id, "cluster:east:authorino/auth-config-1")
+	config := authConfigIndex.Get("echo-api")
+	assert.Equal(t, config.Labels["cluster"], "east")
+	assert.Equal(t, config.Labels["namespace"], "authorino")
+	assert.Equal(t, config.Labels["name"], "auth-config-1")
+
+	id, _ = authConfigIndex.FindId("local-api")
+	assert.Equal(t, id, "authorino/auth-config-1") // host already taken by the local authconfig
+	assert.Check(t, authConfigIndex.Get("local-api").Labels["cluster"] == "")
+
+	// deleted while disconnected
+	_ = remoteClient.Delete(context.TODO(), &remoteAuthConfig)
+	assert.NilError(t, reconciler.pruneIndex(context.TODO()))
+	assert.Check(t, authConfigIndex.Get("echo-api") == nil)
+	assert.Check(t, authConfigIndex.Get("local-api") != nil)
+	assert.Equal(t, len(reconciler.indexed), 0)
+
+	// deleted
+	remoteAuthConfig.ResourceVersion = ""
+	assert.NilError(t, remoteClient.Create(context.TODO(), &remoteAuthConfig))
+	_, err = reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: resourceName})
+	assert.NilError(t, err)
+	assert.Check(t, authConfigIndex.Get("echo-api") != nil)
+	_ = remoteClient.Delete(context.TODO(), &remoteAuthConfig)
+	_, err = reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: resourceName})
+	assert.NilError(t, err)
+	assert.Check(t, authConfigIndex.Get("echo-api") == nil)
+}
@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"sync"
+
+	api "github.com/kuadrant/authorino/api/v1beta1"
+)
+
+// RollbackToAnnotation is the annotation that tells the reconciler to index a previous revision of an AuthConfig
+// (identified by the generation of the resource) instead of the current one
+const RollbackToAnnotation = "authorino.kuadrant.io/rollback-to"
+
+// CanaryWeightAnnotation is the annotation that tells the reconciler to roll out the current revision of an AuthConfig
+// to a percentage of the requests only, while the last revision reconciled without the annotation keeps serving the
+// rest of the requests
+const CanaryWeightAnnotation = "authorino.kuadrant.io/canary-weight"
+
+func NewRevisionHistory(limit int) *RevisionHistory {
+	return &RevisionHistory{
+		limit:     limit,
+		revisions: make(map[string][]api.AuthConfig),
+	}
+}
+
+// RevisionHistory keeps in memory the last successfully reconciled revisions of each AuthConfig
+type RevisionHistory struct {
+	limit     int
+	revisions map[string][]api.AuthConfig
+	mu        sync.RWMutex
+}
+
+// Add records a revision of an AuthConfig, dropping the oldest one if the limit is exceeded.
+// Recording the same generation twice only keeps the latest.
+func (h *RevisionHistory) Add(id string, authConfig api.AuthConfig) {
+	if h.limit <= 0 {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	revisions := h.revisions[id]
+	if l := len(revisions); l > 0 && revisions[l-1].Generation == authConfig.Generation {
+		revisions = revisions[:l-1]
+	}
+	revisions = append(revisions, *authConfig.DeepCopy())
+	if len(revisions) > h.limit {
+		revisions = revisions[len(revisions)-h.limit:]
+	}
+	h.revisions[id] = revisions
+}
+
+// Get returns the revision of an AuthConfig whose generation is the one requested
+func (h *RevisionHistory) Get(id string, generation int64) (authConfig api.AuthConfig, found bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, revision := range h.revisions[id] {
+		if revision.Generation == generation {
+			return *revision.DeepCopy(), true
+		}
+	}
+	return
+}
+
+// Latest returns the most recent revision recorded of an AuthConfig
+func (h *RevisionHistory) Latest(id string) (authConfig api.AuthConfig, found bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if revisions := h.revisions[id]; len(revisions) > 0 {
+		return *revisions[len(revisions)-1].DeepCopy(), true
+	}
+	return
+}
+
+// Generations returns the generations of the revisions recorded for an AuthConfig, from the oldest to the newest
+func (h *RevisionHistory) Generations(id string) []int64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	generations := make([]int64, len(h.revisions[id]))
+	for i, revision := range h.revisions[id] {
+		generations[i] = revision.Generation
+	}
+	return generations
+}
+
+func (h *RevisionHistory) Clear(id string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.revisions, id)
+}
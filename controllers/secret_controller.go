@@ -33,6 +33,9 @@ type SecretReconciler struct {
 	Index         index.Index
 	LabelSelector labels.Selector
 	Namespace     string
+	// Name of the remote cluster the Secrets are read from, in which case only the AuthConfigs of that cluster are
+	// refreshed; empty for the local cluster
+	Cluster string
 }
 
 // +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;
@@ -44,9 +47,9 @@ func (r *SecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 	if err := r.Client.Get(ctx, req.NamespacedName, &secret); err != nil && !errors.IsNotFound(err) {
 		// could not get the resource but not because of a 404 Not found, some error must have happened
 		return ctrl.Result{}, err
-	} else if errors.IsNotFound(err) || !Watched(&secret.ObjectMeta, r.LabelSelector) {
+	} else if errors.IsNotFound(err) || !Watched(&secret.ObjectMeta, r.LabelSelector) || !r.InScope(req.Namespace) {
 		// could not find the resource (404 Not found, resource must have been deleted)
-		// or the resource is no longer to be watched (labels no longer match)
+		// or the resource is no longer to be watched (labels no longer match or out of the watched namespace)
 		// => delete the K8s Secret-based identity from all AuthConfigs
 		r.eachAuthConfigsWithK8sSecretBasedIdentity(func(authConfig *evaluators.AuthConfig) {
 			r.revokeK8sSecretBasedIdentity(ctx, authConfig, req.NamespacedName)
@@ -66,6 +69,11 @@ func (r *SecretReconciler) ClusterWide() bool {
 	return r.Namespace == ""
 }
 
+// InScope tells whether resources of a given namespace are within the space watched by the reconciler
+func (r *SecretReconciler) InScope(namespace string) bool {
+	return r.ClusterWide() || namespace == r.Namespace
+}
+
 func (r *SecretReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return newController(mgr).
 		For(&v1.Secret{}, builder.WithPredicates(LabelSelectorPredicate(r.LabelSelector))).
@@ -82,6 +90,9 @@ func (r *SecretReconciler) getAuthConfigsWithK8sSecretBasedIdentity() authConfig
 	authConfigs := make(authConfigSet)
 	var s struct{}
 	for _, authConfig := range r.Index.List() {
+		if authConfig.Labels["cluster"] != r.Cluster {
+			continue
+		}
 		for _, identityEvaluator := range authConfig.IdentityConfigs {
 			if _, ok := identityEvaluator.(auth.K8sSecretBasedIdentityConfigEvaluator); ok {
 				authConfigs[authConfig] = s
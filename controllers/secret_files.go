@@ -0,0 +1,272 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	api "github.com/kuadrant/authorino/api/v1beta1"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+const (
+	// SecretFilePrefix is the prefix of the names of secrets referred in the AuthConfigs to be read from a path in the
+	// file system instead of from the Kubernetes API, e.g. "file:/mnt/secrets-store/oidc"
+	SecretFilePrefix = "file:"
+
+	DefaultSecretFilesWatchInterval = 10 // seconds
+)
+
+func NewSecretFiles(dirs []string, interval time.Duration, logger logr.Logger) *SecretFiles {
+	cleanDirs := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		if dir = strings.TrimSpace(dir); dir != "" {
+			cleanDirs = append(cleanDirs, filepath.Clean(dir))
+		}
+	}
+	return &SecretFiles{
+		Dirs:     cleanDirs,
+		Interval: interval,
+		Logger:   logger,
+		digests:  make(map[string]map[string]string),
+		changes:  make(chan event.GenericEvent),
+	}
+}
+
+// SecretFiles reads the secrets referred in the AuthConfigs by path in the file system, e.g. mounted with the Secrets
+// Store CSI driver or as projected volumes, from within a set of allowed directories.
+// The path is either a directory, whose files are the keys of the secret, or a file, whose name is the key.
+// The files read to build each AuthConfig are checked for changes on every interval; whenever their content changes,
+// an event is sent to the Changes channel, so the resource is reconciled again.
+type SecretFiles struct {
+	Dirs     []string
+	Interval time.Duration
+	Logger   logr.Logger
+
+	digests map[string]map[string]string // digests of the files by path, by authconfig
+	changes chan event.GenericEvent
+	mutex   sync.RWMutex
+}
+
+// Read reads the secret at a path, failing if the path is not within the allowed directories
+func (s *SecretFiles) Read(path string) (map[string][]byte, error) {
+	path = filepath.Clean(path)
+	if !s.allowed(path) {
+		return nil, fmt.Errorf("failed to read secret file %s: path not allowed", path)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret file %s: %v", path, err)
+	}
+
+	if !info.IsDir() {
+		value, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read secret file %s: %v", path, err)
+		}
+		return map[string][]byte{filepath.Base(path): value}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret file %s: %v", path, err)
+	}
+	data := make(map[string][]byte, len(entries))
+	for _, entry := range entries {
+		// skips the hidden entries of atomic writers (e.g. '..data' of projected volumes)
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		file := filepath.Join(path, name)
+		if info, err := os.Stat(file); err != nil || info.IsDir() {
+			continue
+		}
+		value, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read secret file %s: %v", file, err)
+		}
+		data[name] = value
+	}
+	return data, nil
+}
+
+func (s *SecretFiles) allowed(path string) bool {
+	if !filepath.IsAbs(path) {
+		return false
+	}
+	for _, dir := range s.Dirs {
+		if path == dir || strings.HasPrefix(path, dir+string(filepath.Separator)) || dir == string(filepath.Separator) {
+			return true
+		}
+	}
+	return false
+}
+
+// Set records the digests of the secret files read to build an AuthConfig, to be checked from the next round on
+func (s *SecretFiles) Set(id string, digests map[string]string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(digests) == 0 {
+		delete(s.digests, id)
+	} else {
+		s.digests[id] = digests
+	}
+}
+
+// Clear stops checking the secret files of an AuthConfig
+func (s *SecretFiles) Clear(id string) {
+	s.Set(id, nil)
+}
+
+// Changes is the channel of events telling the AuthConfigs whose secret files changed
+func (s *SecretFiles) Changes() <-chan event.GenericEvent {
+	return s.changes
+}
+
+// Start checks the secret files for changes on every interval until the context is done
+func (s *SecretFiles) Start(ctx context.Context) error {
+	if s.Interval <= 0 {
+		return fmt.Errorf("interval must be greater than zero")
+	}
+
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.checkAll()
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// NeedLeaderElection tells the manager the secret files are checked by all replicas
+func (s *SecretFiles) NeedLeaderElection() bool {
+	return false
+}
+
+func (s *SecretFiles) checkAll() {
+	s.mutex.RLock()
+	digests := make(map[string]map[string]string, len(s.digests))
+	for id, d := range s.digests {
+		digests[id] = d
+	}
+	s.mutex.RUnlock()
+
+	for id, previous := range digests {
+		current := make(map[string]string, len(previous))
+		for path := range previous {
+			// a file that can no longer be read counts as changed, so the error is reported by the reconciliation
+			data, _ := s.Read(path)
+			current[path] = secretFileDigest(data)
+		}
+		if !reflect.DeepEqual(previous, current) {
+			s.changed(id, previous, current)
+		}
+	}
+}
+
+func (s *SecretFiles) changed(id string, previous, current map[string]string) {
+	s.mutex.Lock()
+	if !reflect.DeepEqual(s.digests[id], previous) {
+		s.mutex.Unlock()
+		return // the files changed or were cleared while checking
+	}
+	s.digests[id] = current
+	s.mutex.Unlock()
+
+	s.Logger.Info("secret files changed", "authconfig", id)
+
+	namespace, name, found := strings.Cut(id, "/")
+	if !found || strings.Contains(namespace, ":") {
+		return // not an authconfig custom resource (e.g. read from a configmap)
+	}
+	authConfig := &api.AuthConfig{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+	go func() {
+		s.changes <- event.GenericEvent{Object: authConfig}
+	}()
+}
+
+// secretFileDigest returns a digest of the data of a secret read from the file system
+func secretFileDigest(data map[string][]byte) string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	hash := sha256.New()
+	for _, key := range keys {
+		hash.Write([]byte(key))
+		hash.Write([]byte{0})
+		hash.Write(data[key])
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+type secretFileDigestsKey struct{}
+
+// secretFileDigests records the digests of the secret files read while building a config
+type secretFileDigests struct {
+	mutex   sync.Mutex
+	digests map[string]string
+}
+
+func withSecretFileDigests(ctx context.Context) context.Context {
+	return context.WithValue(ctx, secretFileDigestsKey{}, &secretFileDigests{digests: make(map[string]string)})
+}
+
+func secretFileDigestsFromContext(ctx context.Context) *secretFileDigests {
+	digests, _ := ctx.Value(secretFileDigestsKey{}).(*secretFileDigests)
+	return digests
+}
+
+func (d *secretFileDigests) add(path string, data map[string][]byte) {
+	if d == nil {
+		return
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.digests[filepath.Clean(path)] = secretFileDigest(data)
+}
+
+func (d *secretFileDigests) get() map[string]string {
+	if d == nil {
+		return nil
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	digests := make(map[string]string, len(d.digests))
+	for path, digest := range d.digests {
+		digests[path] = digest
+	}
+	return digests
+}
+
+func (r *AuthConfigReconciler) readSecretFile(ctx context.Context, path string) (map[string][]byte, error) {
+	if r.SecretFiles == nil {
+		return nil, fmt.Errorf("failed to read secret file %s: secret files are not enabled", path)
+	}
+	data, err := r.SecretFiles.Read(path)
+	if err != nil {
+		return nil, err
+	}
+	secretFileDigestsFromContext(ctx).add(path, data)
+	return data, nil
+}
@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	// VaultSecretPrefix is the prefix of the names of secrets referred in the AuthConfigs to be read from a path in
+	// Vault instead of from the Kubernetes API, e.g. "vault:secret/data/authorino/oidc"
+	VaultSecretPrefix = "vault:"
+
+	DefaultVaultRefreshInterval = 300 // seconds
+)
+
+type vaultLeasesKey struct{}
+
+// vaultLeases records the shortest lease of the Vault secrets read while building a config
+type vaultLeases struct {
+	mutex    sync.Mutex
+	used     bool
+	shortest time.Duration
+}
+
+func withVaultLeases(ctx context.Context) context.Context {
+	return context.WithValue(ctx, vaultLeasesKey{}, &vaultLeases{})
+}
+
+func vaultLeasesFromContext(ctx context.Context) *vaultLeases {
+	leases, _ := ctx.Value(vaultLeasesKey{}).(*vaultLeases)
+	return leases
+}
+
+func (l *vaultLeases) add(lease time.Duration) {
+	if l == nil {
+		return
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.used = true
+	if lease > 0 && (l.shortest == 0 || lease < l.shortest) {
+		l.shortest = lease
+	}
+}
+
+func (r *AuthConfigReconciler) readVaultSecret(ctx context.Context, path string) (map[string][]byte, error) {
+	if r.Vault == nil {
+		return nil, fmt.Errorf("failed to read vault secret %s: vault is not configured", path)
+	}
+	secret, err := r.Vault.Read(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	vaultLeasesFromContext(ctx).add(secret.LeaseDuration)
+	return secret.Data, nil
+}
+
+// vaultRenewal returns the result of a reconciliation that re-reads the Vault secrets used in the config before the
+// shortest of their leases expires, or periodically otherwise, so rotated secrets are picked up
+func (r *AuthConfigReconciler) vaultRenewal(ctx context.Context) ctrl.Result {
+	leases := vaultLeasesFromContext(ctx)
+	if leases == nil || !leases.used {
+		return ctrl.Result{}
+	}
+	interval := r.VaultRefreshInterval
+	if interval <= 0 {
+		interval = DefaultVaultRefreshInterval * time.Second
+	}
+	if leases.shortest > 0 && leases.shortest < interval {
+		interval = leases.shortest
+	}
+	return ctrl.Result{RequeueAfter: interval}
+}
@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/vault"
+
+	"gotest.tools/assert"
+	k8score "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func newTestVaultClient(t *testing.T) *vault.Client {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/auth/kubernetes/login", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"auth":{"client_token":"s.token","lease_duration":3600}}`))
+	})
+	mux.HandleFunc("/v1/secret/data/oidc", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"lease_duration":0,"data":{"data":{"clientID":"authorino","clientSecret":"s3cr3t"},"metadata":{"version":1}}}`))
+	})
+	mux.HandleFunc("/v1/database/creds/authorino", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"lease_duration":60,"data":{"username":"authorino","password":"p4ssw0rd"}}`))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	assert.NilError(t, os.WriteFile(tokenFile, []byte("sa-token"), 0600))
+	return vault.NewClient(server.URL, "", "authorino", tokenFile)
+}
+
+func TestGetSecret(t *testing.T) {
+	k8sSecret := &k8score.Secret{ObjectMeta: metav1.ObjectMeta{Name: "oidc", Namespace: "authorino"}, Data: map[string][]byte{"clientID": []byte("k8s")}}
+	reconciler := &AuthConfigReconciler{Client: newTestK8sClient(k8sSecret), Vault: newTestVaultClient(t), VaultRefreshInterval: time.Minute * 5}
+
+	ctx := withVaultLeases(context.TODO())
+
+	secret := &k8score.Secret{}
+	assert.NilError(t, reconciler.getSecret(ctx, types.NamespacedName{Namespace: "authorino", Name: "oidc"}, secret))
+	assert.Equal(t, string(secret.Data["clientID"]), "k8s")
+	assert.DeepEqual(t, reconciler.vaultRenewal(ctx).RequeueAfter, time.Duration(0))
+
+	secret = &k8score.Secret{}
+	assert.NilError(t, reconciler.getSecret(ctx, types.NamespacedName{Namespace: "authorino", Name: "vault:secret/data/oidc"}, secret))
+	assert.Equal(t, string(secret.Data["clientID"]), "authorino")
+	assert.Equal(t, string(secret.Data["clientSecret"]), "s3cr3t")
+	assert.DeepEqual(t, reconciler.vaultRenewal(ctx).RequeueAfter, time.Minute*5)
+
+	// shorter lease
+	secret = &k8score.Secret{}
+	assert.NilError(t, reconciler.getSecret(ctx, types.NamespacedName{Namespace: "authorino", Name: "vault:database/creds/authorino"}, secret))
+	assert.Equal(t, string(secret.Data["password"]), "p4ssw0rd")
+	assert.DeepEqual(t, reconciler.vaultRenewal(ctx).RequeueAfter, time.Minute)
+}
+
+func TestGetSecretVaultNotConfigured(t *testing.T) {
+	reconciler := &AuthConfigReconciler{Client: newTestK8sClient()}
+	err := reconciler.getSecret(context.TODO(), types.NamespacedName{Namespace: "authorino", Name: "vault:secret/data/oidc"}, &k8score.Secret{})
+	assert.Error(t, err, "failed to read vault secret secret/data/oidc: vault is not configured")
+}
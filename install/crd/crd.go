@@ -0,0 +1,9 @@
+// Package crd embeds the CustomResourceDefinitions generated for the Authorino APIs
+package crd
+
+import _ "embed"
+
+// AuthConfigs is the CustomResourceDefinition of the AuthConfig resources, in YAML
+//
+//go:embed authorino.kuadrant.io_authconfigs.yaml
+var AuthConfigs []byte
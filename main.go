@@ -21,16 +21,26 @@ import (
 	"crypto/sha256"
 	"crypto/tls"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	v1beta1 "github.com/kuadrant/authorino/api/v1beta1"
 	v1beta2 "github.com/kuadrant/authorino/api/v1beta2"
 	"github.com/kuadrant/authorino/controllers"
+	"github.com/kuadrant/authorino/pkg/audit"
 	"github.com/kuadrant/authorino/pkg/evaluators"
+	authorization_evaluators "github.com/kuadrant/authorino/pkg/evaluators/authorization"
+	response_evaluators "github.com/kuadrant/authorino/pkg/evaluators/response"
 	"github.com/kuadrant/authorino/pkg/health"
 	"github.com/kuadrant/authorino/pkg/index"
 	"github.com/kuadrant/authorino/pkg/log"
@@ -38,8 +48,10 @@ import (
 	"github.com/kuadrant/authorino/pkg/service"
 	"github.com/kuadrant/authorino/pkg/trace"
 	"github.com/kuadrant/authorino/pkg/utils"
+	"github.com/kuadrant/authorino/pkg/vault"
 
 	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	envoy_ext_proc "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
 	"github.com/go-logr/logr"
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -49,22 +61,33 @@ import (
 	otel_http "go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel"
 	otel_propagation "go.opentelemetry.io/otel/propagation"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/reflection"
+	jose "gopkg.in/square/go-jose.v2"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
+	"k8s.io/client-go/tools/clientcmd"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	// +kubebuilder:scaffold:imports
 )
 
 const (
 	gRPCMaxConcurrentStreams = 10000
+	gRPCKeepaliveMinTime     = 300 // default enforced by grpc-go
 	leaderElectionIDSuffix   = "authorino.kuadrant.io"
+
+	http2MaxConcurrentStreams         = 10000
+	http2MinUploadBufferPerStream     = 64 << 10
+	http2MaxUploadBufferPerConnection = 16 << 20
 )
 
 var (
@@ -90,6 +113,7 @@ type telemetryOptions struct {
 	tracingServiceEndpoint string
 	tracingServiceInsecure bool
 	tracingServiceTags     []string
+	tracingSamplingRatio   float64
 }
 
 type commonServerOptions struct {
@@ -105,19 +129,115 @@ type authServerOptions struct {
 	watchedAuthConfigLabelSelector string
 	watchedSecretLabelSelector     string
 	allowSupersedingHostSubsets    bool
+	configMapAuthConfigsEnabled    bool
+	remoteClusters                 string
+	revisionHistoryLimit           int
+	identityProvidersEnabled       bool
+	overlaysEnabled                bool
+	defaultsEnabled                bool
+	dependencyHealthCheckInterval  int
+	readinessWaitForDependencies   bool
+	indexSnapshotFile              string
+	indexSnapshotConfigMap         string
+	indexSnapshotInterval          int
 	timeout                        int
+	phaseTimeouts                  phaseTimeoutOptions
+	shutdownGracePeriod            int
+	maxConcurrentRequests          int
+	maxQueuedRequests              int
+	deduplicateRequests            bool
+	maxConcurrentEvaluators        int
+	maxPipelineEvaluators          int
+	httpClient                     httpClientOptions
 	extAuthGRPCPort                int
+	grpc                           grpcServerOptions
 	extAuthHTTPPort                int
+	extAuthHTTP2MaxStreams         int
+	nginxAuthRequest               service.OriginalRequestHeaders
 	tlsCertPath                    string
 	tlsCertKeyPath                 string
 	oidcHTTPPort                   int
 	oidcTLSCertPath                string
 	oidcTLSCertKeyPath             string
+	edgeAuth                       edgeAuthOptions
+	vault                          vaultOptions
+	secretFilesDirs                string
+	secretFilesWatchInterval       int
+	adminHTTPPort                  int
+	adminHTTPToken                 string
+	adminProfilingEnabled          bool
 	evaluatorCacheSize             int
+	evaluatorCacheRedisURL         string
+	evaluatorCacheInvalidation     string
 	deepMetricsEnabled             bool
+	debugSamplingRatio             float64
 	webhookServicePort             int
 	enableLeaderElection           bool
 	maxHttpRequestBodySize         int64
+	opaDecisionLogsURL             string
+	opaDecisionLogsFile            string
+	opaDecisionLogsFlushInterval   int
+	auditLogSink                   string
+	auditLogFile                   string
+	auditLogFileMaxSize            int
+	auditLogFileMaxBackups         int
+	auditLogURL                    string
+	auditLogFlushInterval          int
+	runtimeSettingsFile            string
+}
+
+type grpcServerOptions struct {
+	maxConcurrentStreams         int
+	maxConnectionIdle            int
+	maxConnectionAge             int
+	maxConnectionAgeGrace        int
+	keepaliveTime                int
+	keepaliveTimeout             int
+	keepaliveMinTime             int
+	keepalivePermitWithoutStream bool
+}
+
+type phaseTimeoutOptions struct {
+	identity      int
+	metadata      int
+	authorization int
+	response      int
+}
+
+type httpClientOptions struct {
+	maxIdleConns           int
+	maxIdleConnsPerHost    int
+	idleConnTimeout        int
+	dialTimeout            int
+	tlsHandshakeTimeout    int
+	maxConcurrentPerHost   int
+	maxQueuedPerHost       int
+	hostConcurrencyLimits  string
+	circuitBreakerFailures int
+	circuitBreakerOpen     int
+}
+
+type edgeAuthOptions struct {
+	port               int
+	url                string
+	issuerURL          string
+	clientID           string
+	clientSecret       string
+	scopes             string
+	signingKey         string
+	signingAlgorithm   string
+	sessionDuration    int64
+	cookieName         string
+	cookieDomain       string
+	introspectionToken string
+}
+
+type vaultOptions struct {
+	address         string
+	authMount       string
+	role            string
+	tokenFile       string
+	refreshInterval int
 }
 
 type webhookServerOptions struct {
@@ -125,23 +245,34 @@ type webhookServerOptions struct {
 	port int
 }
 
+type lintOptions struct {
+	log       logOptions
+	namespace string
+	output    string
+	build     bool
+}
+
 type keyAuthServerOptions struct{}
 type keyWebhookServerOptions struct{}
+type keyLintOptions struct{}
 
 func main() {
 	authServerOpts := &authServerOptions{}
 	webhookServerOpts := &webhookServerOptions{}
+	lintOpts := &lintOptions{}
 
 	cmdRoot := rootCmd()
 
 	cmdRoot.AddCommand(
 		authServerCmd(authServerOpts),
 		webhookServerCmd(webhookServerOpts),
+		lintCmd(lintOpts),
 		versionCmd(),
 	)
 
 	ctx := context.WithValue(context.TODO(), keyAuthServerOptions{}, authServerOpts)
 	ctx = context.WithValue(ctx, keyWebhookServerOptions{}, webhookServerOpts)
+	ctx = context.WithValue(ctx, keyLintOptions{}, lintOpts)
 
 	if err := cmdRoot.ExecuteContext(ctx); err != nil {
 		fmt.Println("error: ", err)
@@ -167,19 +298,97 @@ func authServerCmd(opts *authServerOptions) *cobra.Command {
 	cmd.PersistentFlags().StringVar(&opts.watchedAuthConfigLabelSelector, "auth-config-label-selector", utils.EnvVar("AUTH_CONFIG_LABEL_SELECTOR", ""), "Kubernetes label selector to filter AuthConfig resources to watch")
 	cmd.PersistentFlags().StringVar(&opts.watchedSecretLabelSelector, "secret-label-selector", utils.EnvVar("SECRET_LABEL_SELECTOR", "authorino.kuadrant.io/managed-by=authorino"), "Kubernetes label selector to filter Secret resources to watch")
 	cmd.PersistentFlags().BoolVar(&opts.allowSupersedingHostSubsets, "allow-superseding-host-subsets", false, "Enable AuthConfigs to supersede strict host subsets of supersets already taken")
+	cmd.PersistentFlags().BoolVar(&opts.configMapAuthConfigsEnabled, "configmap-authconfigs-enabled", utils.EnvVar("CONFIGMAP_AUTHCONFIGS_ENABLED", false), "Enable reading AuthConfigs embedded in ConfigMaps annotated with '"+controllers.AuthConfigsConfigMapAnnotation+"=true'")
+	cmd.PersistentFlags().StringVar(&opts.remoteClusters, "remote-clusters", utils.EnvVar("REMOTE_CLUSTERS", ""), "Comma-separated list of remote clusters to watch AuthConfigs from as well, in the format name=/path/to/kubeconfig (e.g. east=/etc/clusters/east/kubeconfig)")
+	cmd.PersistentFlags().IntVar(&opts.revisionHistoryLimit, "authconfig-revision-history-limit", utils.EnvVar("AUTHCONFIG_REVISION_HISTORY_LIMIT", 3), "Number of successfully reconciled revisions of each AuthConfig kept in memory to roll back to - disabled if 0")
+	cmd.PersistentFlags().BoolVar(&opts.identityProvidersEnabled, "identity-providers-enabled", utils.EnvVar("IDENTITY_PROVIDERS_ENABLED", false), "Enable AuthConfigs to refer to cluster-wide IdentityProvider resources (requires the IdentityProvider CRD)")
+	cmd.PersistentFlags().BoolVar(&opts.overlaysEnabled, "overlays-enabled", utils.EnvVar("OVERLAYS_ENABLED", false), "Enable cluster-wide AuthConfigOverlay resources to patch the AuthConfigs at reconcile time (requires the AuthConfigOverlay CRD)")
+	cmd.PersistentFlags().BoolVar(&opts.defaultsEnabled, "authconfig-defaults-enabled", utils.EnvVar("AUTHCONFIG_DEFAULTS_ENABLED", false), "Enable AuthConfigDefaults resources to provide default configs merged into the AuthConfigs of their namespaces (requires the AuthConfigDefaults CRD)")
+	cmd.PersistentFlags().BoolVar(&opts.readinessWaitForDependencies, "readiness-wait-for-dependencies", utils.EnvVar("READINESS_WAIT_FOR_DEPENDENCIES", false), "Report ready only after all AuthConfigs have been reconciled successfully, i.e. with their external dependencies (e.g. OIDC discovery documents, OPA external policies) preloaded")
+	cmd.PersistentFlags().IntVar(&opts.dependencyHealthCheckInterval, "dependency-health-check-interval", utils.EnvVar("DEPENDENCY_HEALTH_CHECK_INTERVAL", 0), "Interval to probe the external dependencies of the AuthConfigs (e.g. OIDC discovery endpoints, metadata endpoints, OPA registries) - in seconds - disabled if 0")
+	cmd.PersistentFlags().StringVar(&opts.indexSnapshotFile, "index-snapshot-file", utils.EnvVar("INDEX_SNAPSHOT_FILE", ""), "Path to a file in the file system to save snapshots of the reconciled AuthConfigs to, for building the index on restarts before the resources are reconciled - disabled if empty")
+	cmd.PersistentFlags().StringVar(&opts.indexSnapshotConfigMap, "index-snapshot-configmap", utils.EnvVar("INDEX_SNAPSHOT_CONFIGMAP", ""), "Kubernetes ConfigMap (in the format namespace/name) to save snapshots of the reconciled AuthConfigs to, for building the index on restarts before the resources are reconciled - disabled if empty")
+	cmd.PersistentFlags().IntVar(&opts.indexSnapshotInterval, "index-snapshot-interval", utils.EnvVar("INDEX_SNAPSHOT_INTERVAL", controllers.DefaultIndexSnapshotInterval), "Interval to save the snapshots of the reconciled AuthConfigs - in seconds")
 	cmd.PersistentFlags().IntVar(&opts.timeout, "timeout", utils.EnvVar("TIMEOUT", 0), "Server timeout - in milliseconds")
+	cmd.PersistentFlags().IntVar(&opts.phaseTimeouts.identity, "identity-phase-timeout", utils.EnvVar("IDENTITY_PHASE_TIMEOUT", 0), "Time budget of the identity verification phase of the auth pipeline - in milliseconds - bound to the server timeout only if 0")
+	cmd.PersistentFlags().IntVar(&opts.phaseTimeouts.metadata, "metadata-phase-timeout", utils.EnvVar("METADATA_PHASE_TIMEOUT", 0), "Time budget of the external metadata phase of the auth pipeline - in milliseconds - bound to the server timeout only if 0")
+	cmd.PersistentFlags().IntVar(&opts.phaseTimeouts.authorization, "authorization-phase-timeout", utils.EnvVar("AUTHORIZATION_PHASE_TIMEOUT", 0), "Time budget of the authorization phase of the auth pipeline - in milliseconds - bound to the server timeout only if 0")
+	cmd.PersistentFlags().IntVar(&opts.phaseTimeouts.response, "response-phase-timeout", utils.EnvVar("RESPONSE_PHASE_TIMEOUT", 0), "Time budget of the response phase of the auth pipeline - in milliseconds - bound to the server timeout only if 0")
+	cmd.PersistentFlags().IntVar(&opts.shutdownGracePeriod, "shutdown-grace-period", utils.EnvVar("SHUTDOWN_GRACE_PERIOD", 20), "Maximum time to drain the authorization requests in-flight on shutdown - in seconds")
+	cmd.PersistentFlags().IntVar(&opts.maxConcurrentRequests, "max-concurrent-requests", utils.EnvVar("MAX_CONCURRENT_REQUESTS", 0), "Maximum number of authorization requests evaluated concurrently, beyond which the requests wait in a queue - unlimited if 0")
+	cmd.PersistentFlags().IntVar(&opts.maxQueuedRequests, "max-queued-requests", utils.EnvVar("MAX_QUEUED_REQUESTS", 1000), "Maximum number of authorization requests waiting to be evaluated when the limit of concurrent requests is reached, beyond which the requests are denied with RESOURCE_EXHAUSTED")
+	cmd.PersistentFlags().BoolVar(&opts.deduplicateRequests, "deduplicate-requests", utils.EnvVar("DEDUPLICATE_REQUESTS", false), "Make identical authorization requests received concurrently (e.g. retries) share the response of a single evaluation, except for AuthConfigs with stateful evaluators")
+	cmd.PersistentFlags().IntVar(&opts.maxConcurrentEvaluators, "max-concurrent-evaluators", utils.EnvVar("MAX_CONCURRENT_EVALUATORS", 0), "Maximum number of evaluators running concurrently across all authorization requests - unlimited if 0")
+	cmd.PersistentFlags().IntVar(&opts.maxPipelineEvaluators, "max-pipeline-evaluators", utils.EnvVar("MAX_PIPELINE_EVALUATORS", 0), "Maximum number of evaluators of an authorization request running concurrently - unlimited if 0")
+	cmd.PersistentFlags().IntVar(&opts.httpClient.maxIdleConns, "http-client-max-idle-conns", utils.EnvVar("HTTP_CLIENT_MAX_IDLE_CONNS", trace.DefaultHTTPMaxIdleConns), "Maximum number of idle connections kept open across all hosts by the outbound HTTP requests of the evaluators")
+	cmd.PersistentFlags().IntVar(&opts.httpClient.maxIdleConnsPerHost, "http-client-max-idle-conns-per-host", utils.EnvVar("HTTP_CLIENT_MAX_IDLE_CONNS_PER_HOST", trace.DefaultHTTPMaxIdleConnsPerHost), "Maximum number of idle connections kept open per host by the outbound HTTP requests of the evaluators")
+	cmd.PersistentFlags().IntVar(&opts.httpClient.idleConnTimeout, "http-client-idle-conn-timeout", utils.EnvVar("HTTP_CLIENT_IDLE_CONN_TIMEOUT", int(trace.DefaultHTTPIdleConnTimeout.Seconds())), "Time an idle connection of the outbound HTTP requests of the evaluators is kept open - in seconds")
+	cmd.PersistentFlags().IntVar(&opts.httpClient.dialTimeout, "http-client-dial-timeout", utils.EnvVar("HTTP_CLIENT_DIAL_TIMEOUT", int(trace.DefaultHTTPDialTimeout.Seconds())), "Timeout to establish the connections of the outbound HTTP requests of the evaluators - in seconds")
+	cmd.PersistentFlags().IntVar(&opts.httpClient.tlsHandshakeTimeout, "http-client-tls-handshake-timeout", utils.EnvVar("HTTP_CLIENT_TLS_HANDSHAKE_TIMEOUT", int(trace.DefaultHTTPTLSHandshakeTimeout.Seconds())), "Timeout of the TLS handshakes of the outbound HTTP requests of the evaluators - in seconds")
+	cmd.PersistentFlags().IntVar(&opts.httpClient.maxConcurrentPerHost, "http-client-max-concurrent-requests-per-host", utils.EnvVar("HTTP_CLIENT_MAX_CONCURRENT_REQUESTS_PER_HOST", 0), "Maximum number of outbound HTTP requests of the evaluators in-flight per host - unlimited if 0")
+	cmd.PersistentFlags().IntVar(&opts.httpClient.maxQueuedPerHost, "http-client-max-queued-requests-per-host", utils.EnvVar("HTTP_CLIENT_MAX_QUEUED_REQUESTS_PER_HOST", 100), "Maximum number of outbound HTTP requests of the evaluators waiting for a slot per host, when the host has reached the maximum number of requests in-flight; requests beyond fail right away")
+	cmd.PersistentFlags().StringVar(&opts.httpClient.hostConcurrencyLimits, "http-client-host-concurrency-limits", utils.EnvVar("HTTP_CLIENT_HOST_CONCURRENCY_LIMITS", ""), "Comma-separated list of maximum numbers of outbound HTTP requests of the evaluators in-flight to specific hosts, overriding --http-client-max-concurrent-requests-per-host, in the format host=limit (e.g. idp.example.com=50)")
+	cmd.PersistentFlags().IntVar(&opts.httpClient.circuitBreakerFailures, "http-client-circuit-breaker-failure-threshold", utils.EnvVar("HTTP_CLIENT_CIRCUIT_BREAKER_FAILURE_THRESHOLD", 0), "Number of consecutive failed outbound HTTP requests of the evaluators to a host (connection errors or 5xx responses) that opens the circuit breaker of the host, failing the requests to the host right away - disabled if 0")
+	cmd.PersistentFlags().IntVar(&opts.httpClient.circuitBreakerOpen, "http-client-circuit-breaker-open-duration", utils.EnvVar("HTTP_CLIENT_CIRCUIT_BREAKER_OPEN_DURATION", int(trace.DefaultCircuitBreakerOpenDuration.Seconds())), "Time the circuit breaker of a host stays open before letting an outbound HTTP request through to probe the host - in seconds")
 	cmd.PersistentFlags().IntVar(&opts.extAuthGRPCPort, "ext-auth-grpc-port", utils.EnvVar("EXT_AUTH_GRPC_PORT", 50051), "Port number of authorization server - gRPC interface")
+	cmd.PersistentFlags().IntVar(&opts.grpc.maxConcurrentStreams, "grpc-max-concurrent-streams", utils.EnvVar("GRPC_MAX_CONCURRENT_STREAMS", gRPCMaxConcurrentStreams), "Maximum number of concurrent streams (i.e. authorization requests) per connection to the gRPC interface of the authorization server")
+	cmd.PersistentFlags().IntVar(&opts.grpc.maxConnectionIdle, "grpc-max-connection-idle", utils.EnvVar("GRPC_MAX_CONNECTION_IDLE", 0), "Time after which an idle connection to the gRPC interface of the authorization server is closed - in seconds - infinite if 0")
+	cmd.PersistentFlags().IntVar(&opts.grpc.maxConnectionAge, "grpc-max-connection-age", utils.EnvVar("GRPC_MAX_CONNECTION_AGE", 0), "Maximum age of a connection to the gRPC interface of the authorization server before the client is asked to reconnect, e.g. to rebalance the load across replicas - in seconds - infinite if 0")
+	cmd.PersistentFlags().IntVar(&opts.grpc.maxConnectionAgeGrace, "grpc-max-connection-age-grace", utils.EnvVar("GRPC_MAX_CONNECTION_AGE_GRACE", 0), "Time for the authorization requests in-flight to complete after a connection to the gRPC interface of the authorization server reaches the max age, before the connection is forcibly closed - in seconds - infinite if 0")
+	cmd.PersistentFlags().IntVar(&opts.grpc.keepaliveTime, "grpc-keepalive-time", utils.EnvVar("GRPC_KEEPALIVE_TIME", 0), "Time after which the gRPC interface of the authorization server pings an inactive client to check the connection is alive - in seconds - 2 hours if 0")
+	cmd.PersistentFlags().IntVar(&opts.grpc.keepaliveTimeout, "grpc-keepalive-timeout", utils.EnvVar("GRPC_KEEPALIVE_TIMEOUT", 0), "Time to wait for the response of a keepalive ping before closing the connection to the gRPC interface of the authorization server - in seconds - 20 seconds if 0")
+	cmd.PersistentFlags().IntVar(&opts.grpc.keepaliveMinTime, "grpc-keepalive-min-time", utils.EnvVar("GRPC_KEEPALIVE_MIN_TIME", gRPCKeepaliveMinTime), "Minimum time between the keepalive pings sent by the clients to the gRPC interface of the authorization server, beyond which the connection is closed - in seconds")
+	cmd.PersistentFlags().BoolVar(&opts.grpc.keepalivePermitWithoutStream, "grpc-keepalive-permit-without-stream", utils.EnvVar("GRPC_KEEPALIVE_PERMIT_WITHOUT_STREAM", false), "Allow the clients to send keepalive pings to the gRPC interface of the authorization server even when there are no authorization requests in-flight")
 	cmd.PersistentFlags().IntVar(&opts.extAuthHTTPPort, "ext-auth-http-port", utils.EnvVar("EXT_AUTH_HTTP_PORT", 5001), "Port number of authorization server - raw HTTP interface")
+	cmd.PersistentFlags().IntVar(&opts.extAuthHTTP2MaxStreams, "ext-auth-http2-max-concurrent-streams", utils.EnvVar("EXT_AUTH_HTTP2_MAX_CONCURRENT_STREAMS", http2MaxConcurrentStreams), "Maximum number of concurrent streams (i.e. authorization requests) per HTTP/2 connection to the raw HTTP interface of the authorization server")
+	cmd.PersistentFlags().StringVar(&opts.nginxAuthRequest.URI, "nginx-original-uri-header", utils.EnvVar("NGINX_ORIGINAL_URI_HEADER", "X-Original-URI"), "HTTP header of the NGINX auth_request subrequests to the raw HTTP interface of the authorization server that carries the URI (or absolute URL) of the original request")
+	cmd.PersistentFlags().StringVar(&opts.nginxAuthRequest.Method, "nginx-original-method-header", utils.EnvVar("NGINX_ORIGINAL_METHOD_HEADER", "X-Original-Method"), "HTTP header of the NGINX auth_request subrequests to the raw HTTP interface of the authorization server that carries the method of the original request")
+	cmd.PersistentFlags().StringVar(&opts.nginxAuthRequest.Host, "nginx-original-host-header", utils.EnvVar("NGINX_ORIGINAL_HOST_HEADER", "X-Forwarded-Host"), "HTTP header of the NGINX auth_request subrequests to the raw HTTP interface of the authorization server that carries the host of the original request")
 	cmd.PersistentFlags().StringVar(&opts.tlsCertPath, "tls-cert", utils.EnvVar("TLS_CERT", ""), "Path to the public TLS server certificate file in the file system - authorization server")
 	cmd.PersistentFlags().StringVar(&opts.tlsCertKeyPath, "tls-cert-key", utils.EnvVar("TLS_CERT_KEY", ""), "Path to the private TLS server certificate key file in the file system - authorization server")
 	cmd.PersistentFlags().IntVar(&opts.oidcHTTPPort, "oidc-http-port", utils.EnvVar("OIDC_HTTP_PORT", 8083), "Port number of OIDC Discovery server for Festival Wristband tokens")
 	cmd.PersistentFlags().StringVar(&opts.oidcTLSCertPath, "oidc-tls-cert", utils.EnvVar("OIDC_TLS_CERT", ""), "Path to the public TLS server certificate file in the file system - Festival Wristband OIDC Discovery server")
 	cmd.PersistentFlags().StringVar(&opts.oidcTLSCertKeyPath, "oidc-tls-cert-key", utils.EnvVar("OIDC_TLS_CERT_KEY", ""), "Path to the private TLS server certificate key file in the file system - Festival Wristband OIDC Discovery server")
+	cmd.PersistentFlags().IntVar(&opts.edgeAuth.port, "edge-auth-http-port", utils.EnvVar("EDGE_AUTH_HTTP_PORT", 0), "Port number of the edge authentication server, i.e. the OpenID Connect relying party that signs the users in and issues sessions as Festival Wristband cookies - disabled if 0")
+	cmd.PersistentFlags().StringVar(&opts.edgeAuth.url, "edge-auth-url", utils.EnvVar("EDGE_AUTH_URL", ""), "Public URL of the edge authentication server, issuer of the sessions - the redirect URL registered at the OpenID Connect provider is <url>/callback")
+	cmd.PersistentFlags().StringVar(&opts.edgeAuth.issuerURL, "edge-auth-oidc-issuer-url", utils.EnvVar("EDGE_AUTH_OIDC_ISSUER_URL", ""), "Issuer URL of the OpenID Connect provider the users sign in at - edge authentication server")
+	cmd.PersistentFlags().StringVar(&opts.edgeAuth.clientID, "edge-auth-client-id", utils.EnvVar("EDGE_AUTH_CLIENT_ID", ""), "Client ID of the edge authentication server at the OpenID Connect provider")
+	cmd.PersistentFlags().StringVar(&opts.edgeAuth.clientSecret, "edge-auth-client-secret", utils.EnvVar("EDGE_AUTH_CLIENT_SECRET", ""), "Client secret of the edge authentication server at the OpenID Connect provider")
+	cmd.PersistentFlags().StringVar(&opts.edgeAuth.scopes, "edge-auth-scopes", utils.EnvVar("EDGE_AUTH_SCOPES", "openid,email,profile"), "Comma-separated list of scopes requested to the OpenID Connect provider when signing the users in - edge authentication server")
+	cmd.PersistentFlags().StringVar(&opts.edgeAuth.signingKey, "edge-auth-signing-key", utils.EnvVar("EDGE_AUTH_SIGNING_KEY", ""), "Path to the private key file (PEM) in the file system to sign the sessions with, or to the shared secret file for HMAC algorithms - edge authentication server")
+	cmd.PersistentFlags().StringVar(&opts.edgeAuth.signingAlgorithm, "edge-auth-signing-key-algorithm", utils.EnvVar("EDGE_AUTH_SIGNING_KEY_ALGORITHM", "ES256"), "Algorithm of the signing key of the sessions - edge authentication server")
+	cmd.PersistentFlags().Int64Var(&opts.edgeAuth.sessionDuration, "edge-auth-session-duration", utils.EnvVar("EDGE_AUTH_SESSION_DURATION", int64(3600)), "Lifetime of the sessions issued by the edge authentication server - in seconds")
+	cmd.PersistentFlags().StringVar(&opts.edgeAuth.cookieName, "edge-auth-cookie-name", utils.EnvVar("EDGE_AUTH_COOKIE_NAME", "authorino-session"), "Name of the cookie that stores the sessions issued by the edge authentication server")
+	cmd.PersistentFlags().StringVar(&opts.edgeAuth.cookieDomain, "edge-auth-cookie-domain", utils.EnvVar("EDGE_AUTH_COOKIE_DOMAIN", ""), "Domain of the session cookies, shared by the protected apps - only redirects to hosts within the domain are allowed - host-only cookies if empty")
+	cmd.PersistentFlags().StringVar(&opts.edgeAuth.introspectionToken, "edge-auth-introspection-token", utils.EnvVar("EDGE_AUTH_INTROSPECTION_TOKEN", ""), "Bearer token required in the requests to the session introspection endpoint of the edge authentication server - if empty, the endpoint is only served to clients on the loopback interface")
+	cmd.PersistentFlags().StringVar(&opts.vault.address, "vault-address", utils.EnvVar("VAULT_ADDRESS", ""), "Address of the HashiCorp Vault server to read the secrets referred in the AuthConfigs as 'vault:<path>' from - disabled if empty")
+	cmd.PersistentFlags().StringVar(&opts.vault.authMount, "vault-auth-mount", utils.EnvVar("VAULT_AUTH_MOUNT", vault.DefaultAuthMount), "Mount path of the Kubernetes auth method in Vault")
+	cmd.PersistentFlags().StringVar(&opts.vault.role, "vault-role", utils.EnvVar("VAULT_ROLE", "authorino"), "Role of the Kubernetes auth method in Vault to log in with")
+	cmd.PersistentFlags().StringVar(&opts.vault.tokenFile, "vault-token-file", utils.EnvVar("VAULT_TOKEN_FILE", vault.DefaultTokenFile), "Path to the service account token file in the file system to log in to Vault with")
+	cmd.PersistentFlags().IntVar(&opts.vault.refreshInterval, "vault-refresh-interval", utils.EnvVar("VAULT_REFRESH_INTERVAL", controllers.DefaultVaultRefreshInterval), "Interval to read again the Vault secrets referred in the AuthConfigs, unless their leases are shorter - in seconds")
+	cmd.PersistentFlags().StringVar(&opts.secretFilesDirs, "secret-files-dirs", utils.EnvVar("SECRET_FILES_DIRS", ""), "Comma-separated list of directories in the file system (e.g. mounted with the Secrets Store CSI driver or as projected volumes) to read the secrets referred in the AuthConfigs as 'file:<path>' from - disabled if empty")
+	cmd.PersistentFlags().IntVar(&opts.secretFilesWatchInterval, "secret-files-watch-interval", utils.EnvVar("SECRET_FILES_WATCH_INTERVAL", controllers.DefaultSecretFilesWatchInterval), "Interval to check the secret files referred in the AuthConfigs for changes - in seconds")
+	cmd.PersistentFlags().IntVar(&opts.adminHTTPPort, "admin-http-port", utils.EnvVar("ADMIN_HTTP_PORT", 0), "Port number of the admin server (e.g. to purge evaluator caches) - disabled if 0")
+	cmd.PersistentFlags().StringVar(&opts.adminHTTPToken, "admin-http-token", utils.EnvVar("ADMIN_HTTP_TOKEN", ""), "Bearer token required in the requests to the admin server - if empty, the admin server only listens on the loopback interface")
+	cmd.PersistentFlags().BoolVar(&opts.adminProfilingEnabled, "admin-profiling-enabled", utils.EnvVar("ADMIN_PROFILING_ENABLED", false), "Enable the runtime profiling endpoints (pprof) of the admin server, served to clients on the loopback interface only")
 	cmd.PersistentFlags().IntVar(&opts.evaluatorCacheSize, "evaluator-cache-size", utils.EnvVar("EVALUATOR_CACHE_SIZE", 1), "Cache size of each Authorino evaluator if enabled in the AuthConfig - in megabytes")
+	cmd.PersistentFlags().StringVar(&opts.evaluatorCacheRedisURL, "evaluator-cache-redis-url", utils.EnvVar("EVALUATOR_CACHE_REDIS_URL", ""), "URL of a Redis server to use as external backend of the evaluator caches, shared by the replicas behind their in-memory caches (e.g. redis://redis:6379/0) - disabled if empty")
+	cmd.PersistentFlags().StringVar(&opts.evaluatorCacheInvalidation, "evaluator-cache-invalidation-channel", utils.EnvVar("EVALUATOR_CACHE_INVALIDATION_CHANNEL", evaluators.DefaultCacheInvalidationChannel), "Pub/sub channel of the external cache backend through which purges of the evaluator caches issued on one replica clear the in-memory caches of the other replicas")
 	cmd.PersistentFlags().BoolVar(&opts.deepMetricsEnabled, "deep-metrics-enabled", utils.EnvVar("DEEP_METRICS_ENABLED", false), "Enable deep metrics at the level of each evaluator when requested in the AuthConfig, exported by the metrics server")
+	cmd.PersistentFlags().Float64Var(&opts.debugSamplingRatio, "debug-sampling-ratio", utils.EnvVar("DEBUG_SAMPLING_RATIO", 0.0), "Ratio of the authorization requests promoted to debug logging, including the authorization JSON, regardless of the log level - between 0 (none) and 1 (all)")
 	cmd.PersistentFlags().IntVar(&opts.webhookServicePort, "webhook-service-port", 9443, "Port number of the webhook server")
 	cmd.PersistentFlags().BoolVar(&opts.enableLeaderElection, "enable-leader-election", false, "Enable leader election for status updater - ensures only one instance of Authorino tries to update the status of reconciled resources")
 	cmd.PersistentFlags().Int64Var(&opts.maxHttpRequestBodySize, "max-http-request-body-size", utils.EnvVar("MAX_HTTP_REQUEST_BODY_SIZE", int64(8192)), "Maximum size of the body of requests accepted in the raw HTTP interface of the authorization server - in bytes")
+	cmd.PersistentFlags().StringVar(&opts.opaDecisionLogsURL, "opa-decision-logs-url", utils.EnvVar("OPA_DECISION_LOGS_URL", ""), "Endpoint URL of the service to send decision logs of the OPA policies to, in the format of OPA decision logs - disabled if empty")
+	cmd.PersistentFlags().StringVar(&opts.opaDecisionLogsFile, "opa-decision-logs-file", utils.EnvVar("OPA_DECISION_LOGS_FILE", ""), "Path to a file in the file system to write decision logs of the OPA policies to, one JSON entry per line - disabled if empty")
+	cmd.PersistentFlags().IntVar(&opts.opaDecisionLogsFlushInterval, "opa-decision-logs-flush-interval", utils.EnvVar("OPA_DECISION_LOGS_FLUSH_INTERVAL", authorization_evaluators.DefaultOPADecisionLogsFlushInterval), "Interval to send the buffered decision logs of the OPA policies to the decision logs service - in seconds")
+	cmd.PersistentFlags().StringVar(&opts.auditLogSink, "audit-log-sink", utils.EnvVar("AUDIT_LOG_SINK", ""), "Sink of the audit records of the auth decisions, one structured record per decision - one of: stdout, file, http - disabled if empty")
+	cmd.PersistentFlags().StringVar(&opts.auditLogFile, "audit-log-file", utils.EnvVar("AUDIT_LOG_FILE", ""), "Path to a file in the file system to write the audit records to, one JSON entry per line - required if the audit log sink is 'file'")
+	cmd.PersistentFlags().IntVar(&opts.auditLogFileMaxSize, "audit-log-file-max-size", utils.EnvVar("AUDIT_LOG_FILE_MAX_SIZE", audit.DefaultFileMaxSize), "Maximum size of the audit log file before it gets rotated - in megabytes")
+	cmd.PersistentFlags().IntVar(&opts.auditLogFileMaxBackups, "audit-log-file-max-backups", utils.EnvVar("AUDIT_LOG_FILE_MAX_BACKUPS", audit.DefaultFileMaxBackups), "Maximum number of rotated audit log files to retain")
+	cmd.PersistentFlags().StringVar(&opts.auditLogURL, "audit-log-url", utils.EnvVar("AUDIT_LOG_URL", ""), "Endpoint URL of the service to send the audit records to, in batches of JSON arrays - required if the audit log sink is 'http'")
+	cmd.PersistentFlags().IntVar(&opts.auditLogFlushInterval, "audit-log-flush-interval", utils.EnvVar("AUDIT_LOG_FLUSH_INTERVAL", audit.DefaultHTTPFlushInterval), "Interval to send the buffered audit records to the audit log service - in seconds")
+	cmd.PersistentFlags().StringVar(&opts.runtimeSettingsFile, "runtime-settings-file", utils.EnvVar("RUNTIME_SETTINGS_FILE", ""), "Path to a file in the file system of environment variables (LOG_LEVEL, DEEP_METRICS_ENABLED, TRACING_SAMPLING_RATIO, TIMEOUT, DEBUG_SAMPLING_RATIO) to reload the runtime settings from on SIGHUP or on request to the admin server - disabled if empty")
 	registerCommonServerOptions(cmd, &opts.commonServerOptions)
 
 	return cmd
@@ -198,6 +407,24 @@ func webhookServerCmd(opts *webhookServerOptions) *cobra.Command {
 	return cmd
 }
 
+func lintCmd(opts *lintOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lint [file or directory]...",
+		Short: "Checks AuthConfigs before they are applied to the cluster",
+		Long:  "Validates and builds the AuthConfigs of YAML/JSON files as Authorino would, printing the findings. Secrets and IdentityProviders referred in the AuthConfigs are looked up among the files. Exits with non-zero status if any error is found.",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  runLint,
+	}
+
+	cmd.PersistentFlags().StringVar(&opts.namespace, "namespace", "default", "Namespace of the resources that do not specify one")
+	cmd.PersistentFlags().StringVar(&opts.output, "output", "text", "Output format of the findings - text or json")
+	cmd.PersistentFlags().BoolVar(&opts.build, "build", true, "Build the AuthConfigs as the reconciler does, which involves fetching the discovery documents of some external services (e.g. OpenID Connect, UMA) - disable for offline checks")
+	cmd.PersistentFlags().StringVar(&opts.log.level, "log-level", "error", "Log level")
+	cmd.PersistentFlags().StringVar(&opts.log.mode, "log-mode", "production", "Log mode")
+
+	return cmd
+}
+
 func versionCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "version",
@@ -214,6 +441,7 @@ func registerCommonServerOptions(cmd *cobra.Command, opts *commonServerOptions)
 	cmd.PersistentFlags().StringVar(&opts.telemetry.tracingServiceEndpoint, "tracing-service-endpoint", "", "Endpoint URL of the tracing exporter service - use either 'rpc://' or 'http://' scheme")
 	cmd.PersistentFlags().BoolVar(&opts.telemetry.tracingServiceInsecure, "tracing-service-insecure", false, "Disable TLS for the tracing service connection")
 	cmd.PersistentFlags().StringArrayVar(&opts.telemetry.tracingServiceTags, "tracing-service-tag", []string{}, "Fixed key=value tag to add to emitted traces")
+	cmd.PersistentFlags().Float64Var(&opts.telemetry.tracingSamplingRatio, "tracing-sampling-ratio", utils.EnvVar("TRACING_SAMPLING_RATIO", 1.0), "Ratio of the traces sampled, between 0 (none) and 1 (all) - traces whose parent span is sampled are always sampled")
 }
 
 func runAuthorizationServer(cmd *cobra.Command, _ []string) {
@@ -223,18 +451,56 @@ func runAuthorizationServer(cmd *cobra.Command, _ []string) {
 
 	// global options
 	evaluators.EvaluatorCacheSize = opts.evaluatorCacheSize
+	setupExternalCache(*opts)
 	metrics.DeepMetricsEnabled = opts.deepMetricsEnabled
+	service.LimitEvaluatorConcurrency(opts.maxConcurrentEvaluators, opts.maxPipelineEvaluators)
+	service.PipelinePhaseTimeouts = service.PhaseTimeouts{
+		Identity:      timeoutMs(opts.phaseTimeouts.identity),
+		Metadata:      timeoutMs(opts.phaseTimeouts.metadata),
+		Authorization: timeoutMs(opts.phaseTimeouts.authorization),
+		Response:      timeoutMs(opts.phaseTimeouts.response),
+	}
+	hostConcurrencyLimits, err := trace.ParseHostConcurrencyLimits(opts.httpClient.hostConcurrencyLimits)
+	if err != nil {
+		logger.Error(err, "invalid outbound http client options")
+		os.Exit(1)
+	}
+	trace.ConfigureHTTPTransport(trace.HTTPTransportOptions{
+		MaxIdleConns:                   opts.httpClient.maxIdleConns,
+		MaxIdleConnsPerHost:            opts.httpClient.maxIdleConnsPerHost,
+		IdleConnTimeout:                time.Duration(opts.httpClient.idleConnTimeout) * time.Second,
+		DialTimeout:                    time.Duration(opts.httpClient.dialTimeout) * time.Second,
+		TLSHandshakeTimeout:            time.Duration(opts.httpClient.tlsHandshakeTimeout) * time.Second,
+		MaxConcurrentRequestsPerHost:   opts.httpClient.maxConcurrentPerHost,
+		HostConcurrencyLimits:          hostConcurrencyLimits,
+		MaxQueuedRequestsPerHost:       opts.httpClient.maxQueuedPerHost,
+		CircuitBreakerFailureThreshold: opts.httpClient.circuitBreakerFailures,
+		CircuitBreakerOpenDuration:     time.Duration(opts.httpClient.circuitBreakerOpen) * time.Second,
+	})
+	setupOPADecisionLogs(*opts)
+	setupAuditLog(*opts)
 
 	// creates the index of authconfigs
 	index := index.NewIndex()
 
 	// starts authorization server
-	startExtAuthServerGRPC(index, *opts)
-	startExtAuthServerHTTP(index, *opts)
+	authService := service.NewAuthService(index, timeoutMs(opts.timeout), opts.maxHttpRequestBodySize)
+	authService.DebugSamplingRatio = opts.debugSamplingRatio
+	authService.NginxAuthRequest = opts.nginxAuthRequest
+	authService.LimitConcurrency(opts.maxConcurrentRequests, opts.maxQueuedRequests)
+	authService.DeduplicateRequests(opts.deduplicateRequests)
+	shutdownGRPC := startExtAuthServerGRPC(authService, *opts)
+	shutdownHTTP := startExtAuthServerHTTP(authService, *opts)
+
+	// sets up the reload of the runtime settings
+	runtimeSettings := setupRuntimeSettings(authService, *opts)
 
 	// starts the oidc discovery server
 	startOIDCServer(index, *opts)
 
+	// starts the edge authentication server
+	startEdgeAuthServer(opts.edgeAuth)
+
 	baseManagerOptions := ctrl.Options{
 		Scheme:                 scheme,
 		Port:                   opts.webhookServicePort,
@@ -253,9 +519,73 @@ func runAuthorizationServer(cmd *cobra.Command, _ []string) {
 		os.Exit(1)
 	}
 
+	// sets up the status update manager
+	// all replicas reconcile the resources and serve auth requests, but only the leader updates the status and emits events
+	leaderElectionId := sha256.Sum256([]byte(opts.watchedAuthConfigLabelSelector))
+	statusUpdaterOptions := baseManagerOptions
+	statusUpdaterOptions.MetricsBindAddress = "0"     // disabled so it does not clash with the reconciliation manager
+	statusUpdaterOptions.HealthProbeBindAddress = "0" // disabled so it does not clash with the reconciliation manager
+	statusUpdaterOptions.LeaderElection = opts.enableLeaderElection
+	statusUpdaterOptions.LeaderElectionID = fmt.Sprintf("%v.%v", hex.EncodeToString(leaderElectionId[:4]), leaderElectionIDSuffix)
+	statusUpdateManager, err := setupManager(statusUpdaterOptions)
+	if err != nil {
+		logger.Error(err, "failed to setup status update manager")
+		os.Exit(1)
+	}
+
 	statusReport := controllers.NewStatusReportMap()
 	controllerLogger := log.WithName("controller-runtime").WithName("manager").WithName("controller")
 
+	// sets up the health checks of the external dependencies of the authconfigs, run by the leader only
+	var dependencyHealth *controllers.DependencyHealthChecker
+	if opts.dependencyHealthCheckInterval > 0 {
+		dependencyHealth = controllers.NewDependencyHealthChecker(time.Duration(opts.dependencyHealthCheckInterval)*time.Second, controllerLogger.WithName("authconfig").WithName("dependencyhealth"))
+		if err := statusUpdateManager.Add(dependencyHealth); err != nil {
+			logger.Error(err, "failed to setup dependency health checks")
+			os.Exit(1)
+		}
+	}
+
+	// sets up the snapshots of the index
+	// snapshots saved to a file are saved by every replica, whereas snapshots saved to a configmap are saved by the leader only
+	var indexSnapshot *controllers.IndexSnapshot
+	var directClient client.Client
+	if opts.indexSnapshotFile != "" || opts.indexSnapshotConfigMap != "" {
+		if directClient, err = client.New(mgr.GetConfig(), client.Options{Scheme: mgr.GetScheme()}); err != nil {
+			logger.Error(err, "failed to setup index snapshots")
+			os.Exit(1)
+		}
+		snapshotLogger := log.WithName("index").WithName("snapshot")
+		snapshotInterval := time.Duration(opts.indexSnapshotInterval) * time.Second
+		if opts.indexSnapshotFile != "" {
+			indexSnapshot = controllers.NewIndexSnapshot(controllers.NewFileIndexSnapshotStore(opts.indexSnapshotFile), snapshotInterval, snapshotLogger)
+			err = mgr.Add(indexSnapshot)
+		} else {
+			namespace, name, _ := strings.Cut(opts.indexSnapshotConfigMap, "/")
+			indexSnapshot = controllers.NewIndexSnapshot(controllers.NewConfigMapIndexSnapshotStore(directClient, namespace, name), snapshotInterval, snapshotLogger)
+			err = statusUpdateManager.Add(indexSnapshot)
+		}
+		if err != nil {
+			logger.Error(err, "failed to setup index snapshots")
+			os.Exit(1)
+		}
+	}
+
+	var vaultClient *vault.Client
+	if opts.vault.address != "" {
+		vaultClient = vault.NewClient(opts.vault.address, opts.vault.authMount, opts.vault.role, opts.vault.tokenFile)
+	}
+
+	// sets up the watcher of the secret files referred in the authconfigs, run by every replica
+	var secretFiles *controllers.SecretFiles
+	if opts.secretFilesDirs != "" {
+		secretFiles = controllers.NewSecretFiles(strings.Split(opts.secretFilesDirs, ","), time.Duration(opts.secretFilesWatchInterval)*time.Second, controllerLogger.WithName("authconfig").WithName("secretfiles"))
+		if err := mgr.Add(secretFiles); err != nil {
+			logger.Error(err, "failed to setup secret files watcher")
+			os.Exit(1)
+		}
+	}
+
 	// sets up the authconfig reconciler
 	authConfigReconciler := &controllers.AuthConfigReconciler{
 		Client:                      mgr.GetClient(),
@@ -266,6 +596,16 @@ func runAuthorizationServer(cmd *cobra.Command, _ []string) {
 		Scheme:                      mgr.GetScheme(),
 		LabelSelector:               controllers.ToLabelSelector(opts.watchedAuthConfigLabelSelector),
 		Namespace:                   opts.watchNamespace,
+		Recorder:                    controllers.NewLeaderEventRecorder(statusUpdateManager.GetEventRecorderFor("authorino"), statusUpdateManager.Elected()),
+		Revisions:                   controllers.NewRevisionHistory(opts.revisionHistoryLimit),
+		IdentityProviders:           opts.identityProvidersEnabled,
+		Overlays:                    opts.overlaysEnabled,
+		Defaults:                    opts.defaultsEnabled,
+		DependencyHealth:            dependencyHealth,
+		Snapshot:                    indexSnapshot,
+		Vault:                       vaultClient,
+		VaultRefreshInterval:        time.Duration(opts.vault.refreshInterval) * time.Second,
+		SecretFiles:                 secretFiles,
 	}
 	if err = authConfigReconciler.SetupWithManager(mgr); err != nil {
 		logger.Error(err, "failed to setup controller", "controller", "authconfig")
@@ -279,6 +619,21 @@ func runAuthorizationServer(cmd *cobra.Command, _ []string) {
 		os.Exit(1)
 	}
 
+	// index readiness check
+	indexReadiness := controllers.NewIndexReadiness(mgr.GetCache(), authConfigReconciler, opts.readinessWaitForDependencies, controllerLogger.WithName("authconfig").WithName("readiness"))
+	if err := mgr.Add(indexReadiness); err != nil {
+		logger.Error(err, "failed to setup index readiness check")
+		os.Exit(1)
+	}
+	indexReadinessCheck := health.NewHandler(controllers.IndexReadyzSubpath, health.Observe(indexReadiness))
+	if err := mgr.AddReadyzCheck(controllers.IndexReadyzSubpath, indexReadinessCheck.HandleReadyzCheck); err != nil {
+		logger.Error(err, "failed to setup index readiness check")
+		os.Exit(1)
+	}
+
+	// starts the admin server
+	startAdminServer(index, authConfigReconciler, runtimeSettings, *opts)
+
 	// sets up the secret reconciler
 	if err = (&controllers.SecretReconciler{
 		Client:        mgr.GetClient(),
@@ -292,6 +647,67 @@ func runAuthorizationServer(cmd *cobra.Command, _ []string) {
 		os.Exit(1)
 	}
 
+	// sets up the configmap reconciler
+	if opts.configMapAuthConfigsEnabled {
+		if err = (&controllers.ConfigMapReconciler{
+			Client:      mgr.GetClient(),
+			Logger:      controllerLogger.WithName("configmap"),
+			Scheme:      mgr.GetScheme(),
+			AuthConfigs: authConfigReconciler,
+			Namespace:   opts.watchNamespace,
+		}).SetupWithManager(mgr); err != nil {
+			logger.Error(err, "failed to setup controller", "controller", "configmap")
+			os.Exit(1)
+		}
+	}
+
+	// sets up the reconcilers of the remote clusters
+	// each remote cluster is watched by its own manager, so an unreachable cluster does not affect the others
+	if opts.remoteClusters != "" {
+		for _, remoteCluster := range strings.Split(opts.remoteClusters, ",") {
+			name, kubeconfig, found := strings.Cut(strings.TrimSpace(remoteCluster), "=")
+			if !found || name == "" || kubeconfig == "" {
+				logger.Error(fmt.Errorf("invalid remote cluster %q", remoteCluster), "failed to setup controller", "controller", "remotecluster")
+				os.Exit(1)
+			}
+			remoteConfig, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+			if err != nil {
+				logger.Error(err, "failed to load the kubeconfig of remote cluster", "cluster", name)
+				os.Exit(1)
+			}
+			remoteClusterOptions := baseManagerOptions
+			remoteClusterOptions.MetricsBindAddress = "0"     // disabled so it does not clash with the reconciliation manager
+			remoteClusterOptions.HealthProbeBindAddress = "0" // disabled so it does not clash with the reconciliation manager
+			if err = mgr.Add(&controllers.RemoteClusterReconciler{
+				Name:                name,
+				Config:              remoteConfig,
+				Options:             remoteClusterOptions,
+				Logger:              controllerLogger.WithName("remotecluster").WithName(name),
+				AuthConfigs:         authConfigReconciler,
+				SecretLabelSelector: controllers.ToLabelSelector(opts.watchedSecretLabelSelector),
+				RetryInterval:       controllers.DefaultRemoteClusterRetryInterval,
+			}); err != nil {
+				logger.Error(err, "failed to setup controller", "controller", "remotecluster", "cluster", name)
+				os.Exit(1)
+			}
+		}
+	}
+
+	// listens to the invalidations of the evaluator caches issued by the other replicas
+	if evaluators.ExternalCache != nil {
+		if err := mgr.Add(evaluators.ExternalCache); err != nil {
+			logger.Error(err, "failed to setup external cache backend")
+			os.Exit(1)
+		}
+	}
+
+	// builds the index out of the last snapshot, if any, so the last known good configs are served while the resources are reconciled
+	if indexSnapshot != nil {
+		if err := authConfigReconciler.WarmUpIndex(context.Background(), directClient); err != nil {
+			logger.Error(err, "failed to build the index from snapshot")
+		}
+	}
+
 	// starts the reconciliation manager
 	signalHandler := ctrl.SetupSignalHandler()
 	logger.Info("starting reconciliation manager")
@@ -302,25 +718,13 @@ func runAuthorizationServer(cmd *cobra.Command, _ []string) {
 		}
 	}()
 
-	// sets up the status update manager
-	leaderElectionId := sha256.Sum256([]byte(opts.watchedAuthConfigLabelSelector))
-	statusUpdaterOptions := baseManagerOptions
-	statusUpdaterOptions.MetricsBindAddress = "0"     // disabled so it does not clash with the reconciliation manager
-	statusUpdaterOptions.HealthProbeBindAddress = "0" // disabled so it does not clash with the reconciliation manager
-	statusUpdaterOptions.LeaderElection = opts.enableLeaderElection
-	statusUpdaterOptions.LeaderElectionID = fmt.Sprintf("%v.%v", hex.EncodeToString(leaderElectionId[:4]), leaderElectionIDSuffix)
-	statusUpdateManager, err := setupManager(statusUpdaterOptions)
-	if err != nil {
-		logger.Error(err, "failed to setup status update manager")
-		os.Exit(1)
-	}
-
 	// sets up the authconfig status update controller
 	if err = (&controllers.AuthConfigStatusUpdater{
-		Client:        statusUpdateManager.GetClient(),
-		Logger:        controllerLogger.WithName("authconfig").WithName("statusupdater"),
-		StatusReport:  statusReport,
-		LabelSelector: controllers.ToLabelSelector(opts.watchedAuthConfigLabelSelector),
+		Client:           statusUpdateManager.GetClient(),
+		Logger:           controllerLogger.WithName("authconfig").WithName("statusupdater"),
+		StatusReport:     statusReport,
+		LabelSelector:    controllers.ToLabelSelector(opts.watchedAuthConfigLabelSelector),
+		DependencyHealth: dependencyHealth,
 	}).SetupWithManager(statusUpdateManager); err != nil {
 		logger.Error(err, "failed to create controller", "controller", "authconfigstatusupdate")
 	}
@@ -331,6 +735,49 @@ func runAuthorizationServer(cmd *cobra.Command, _ []string) {
 		logger.Error(err, "failed to start status update manager")
 		os.Exit(1)
 	}
+
+	shutdownAuthorizationServer(time.Duration(opts.shutdownGracePeriod)*time.Second, shutdownGRPC, shutdownHTTP)
+}
+
+// shutdownAuthorizationServer stops accepting new authorization requests, drains the ones in-flight (including the
+// callbacks of the auth pipelines) up to the grace period, and flushes the buffers of the audit and decision logs
+func shutdownAuthorizationServer(gracePeriod time.Duration, servers ...shutdownFunc) {
+	logger.Info("shutting down authorization server", "gracePeriod", gracePeriod.String())
+
+	ctx, cancel := context.WithTimeout(context.Background(), gracePeriod)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, shutdown := range servers {
+		if shutdown == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(shutdown shutdownFunc) {
+			defer wg.Done()
+			if err := shutdown(ctx); err != nil {
+				logger.Error(err, "failed to drain authorization requests in-flight")
+			}
+		}(shutdown)
+	}
+	wg.Wait()
+
+	if err := service.DrainCallbacks(ctx); err != nil {
+		logger.Error(err, "failed to drain callbacks in-flight")
+	}
+
+	if sink, ok := audit.Records.(interface{ Stop() error }); ok {
+		if err := sink.Stop(); err != nil {
+			logger.Error(err, "failed to flush audit log")
+		}
+	}
+	if sink, ok := authorization_evaluators.OPADecisionLogs.(interface{ Stop() error }); ok {
+		if err := sink.Stop(); err != nil {
+			logger.Error(err, "failed to flush opa decision logs")
+		}
+	}
+
+	logger.Info("authorization server shut down")
 }
 
 func runWebhookServer(cmd *cobra.Command, _ []string) {
@@ -385,8 +832,8 @@ func setup(cmd *cobra.Command, log logOptions, telemetry telemetryOptions) {
 }
 
 func setupLogger(opts logOptions) {
-	logOpts := log.Options{Level: log.ToLogLevel(opts.level), Mode: log.ToLogMode(opts.mode)}
-	logger = log.NewLogger(logOpts).WithName("authorino")
+	logOpts := log.Options{Level: log.ToLogLevel(opts.level), Mode: log.ToLogMode(opts.mode), Name: "authorino"}
+	logger = log.NewLogger(logOpts)
 	log.SetLogger(logger, logOpts)
 }
 
@@ -395,6 +842,7 @@ func setupTelemetryServices(opts telemetryOptions) {
 	otel.SetLogger(telemetryLogger)
 	otel.SetErrorHandler(&trace.ErrorHandler{Logger: telemetryLogger})
 
+	trace.SetSamplingRatio(opts.tracingSamplingRatio)
 	if opts.tracingServiceEndpoint != "" {
 		tp, err := trace.CreateTraceProvider(trace.Config{
 			Endpoint: opts.tracingServiceEndpoint,
@@ -433,7 +881,10 @@ func setupManager(options ctrl.Options) (ctrl.Manager, error) {
 	return mgr, nil
 }
 
-func startExtAuthServerGRPC(authConfigIndex index.Index, opts authServerOptions) {
+// shutdownFunc stops a server gracefully, draining the requests in-flight until the context is done
+type shutdownFunc func(ctx context.Context) error
+
+func startExtAuthServerGRPC(authService *service.AuthService, opts authServerOptions) shutdownFunc {
 	lis, err := listen(opts.extAuthGRPCPort)
 
 	if err != nil {
@@ -443,11 +894,22 @@ func startExtAuthServerGRPC(authConfigIndex index.Index, opts authServerOptions)
 
 	if lis == nil {
 		logger.Info("disabling grpc auth service")
-		return
+		return nil
 	}
 
 	grpcServerOpts := []grpc.ServerOption{
-		grpc.MaxConcurrentStreams(gRPCMaxConcurrentStreams),
+		grpc.MaxConcurrentStreams(uint32(opts.grpc.maxConcurrentStreams)),
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			MaxConnectionIdle:     time.Duration(opts.grpc.maxConnectionIdle) * time.Second,
+			MaxConnectionAge:      time.Duration(opts.grpc.maxConnectionAge) * time.Second,
+			MaxConnectionAgeGrace: time.Duration(opts.grpc.maxConnectionAgeGrace) * time.Second,
+			Time:                  time.Duration(opts.grpc.keepaliveTime) * time.Second,
+			Timeout:               time.Duration(opts.grpc.keepaliveTimeout) * time.Second,
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             time.Duration(opts.grpc.keepaliveMinTime) * time.Second,
+			PermitWithoutStream: opts.grpc.keepalivePermitWithoutStream,
+		}),
 		grpc.ChainStreamInterceptor(grpc_prometheus.StreamServerInterceptor, otel_grpc.StreamServerInterceptor()),
 		grpc.ChainUnaryInterceptor(grpc_prometheus.UnaryServerInterceptor, otel_grpc.UnaryServerInterceptor()),
 	}
@@ -471,8 +933,10 @@ func startExtAuthServerGRPC(authConfigIndex index.Index, opts authServerOptions)
 	grpcServer := grpc.NewServer(grpcServerOpts...)
 	reflection.Register(grpcServer)
 
-	envoy_auth.RegisterAuthorizationServer(grpcServer, &service.AuthService{Index: authConfigIndex, Timeout: timeoutMs(opts.timeout)})
-	healthpb.RegisterHealthServer(grpcServer, &service.HealthService{})
+	healthService := &service.HealthService{}
+	envoy_auth.RegisterAuthorizationServer(grpcServer, authService)
+	envoy_ext_proc.RegisterExternalProcessorServer(grpcServer, &service.ExtProcService{AuthService: authService})
+	healthpb.RegisterHealthServer(grpcServer, healthService)
 	grpc_prometheus.Register(grpcServer)
 	grpc_prometheus.EnableHandlingTimeHistogram()
 
@@ -484,18 +948,192 @@ func startExtAuthServerGRPC(authConfigIndex index.Index, opts authServerOptions)
 			os.Exit(1)
 		}
 	}()
+
+	return func(ctx context.Context) error {
+		healthService.Shutdown()
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+			return nil
+		case <-ctx.Done():
+			grpcServer.Stop()
+			return ctx.Err()
+		}
+	}
 }
 
-func startExtAuthServerHTTP(authConfigIndex index.Index, opts authServerOptions) {
-	startHTTPService("auth", opts.extAuthHTTPPort, service.HTTPAuthorizationBasePath, opts.tlsCertPath, opts.tlsCertKeyPath, service.NewAuthService(authConfigIndex, timeoutMs(opts.timeout), opts.maxHttpRequestBodySize))
+func startExtAuthServerHTTP(authService *service.AuthService, opts authServerOptions) shutdownFunc {
+	// proxies multiplex the authorization requests over HTTP/2 connections, either over tls or cleartext (h2c)
+	perStreamBuffer := opts.maxHttpRequestBodySize
+	if perStreamBuffer < http2MinUploadBufferPerStream {
+		perStreamBuffer = http2MinUploadBufferPerStream
+	}
+	http2Server := &http2.Server{
+		MaxConcurrentStreams:         uint32(opts.extAuthHTTP2MaxStreams),
+		MaxUploadBufferPerStream:     int32(perStreamBuffer),
+		MaxUploadBufferPerConnection: http2MaxUploadBufferPerConnection,
+	}
+	// the auth service routes the requests itself, so the paths forwarded by the ext_authz http filter of envoy are
+	// not cleaned nor redirected by a mux
+	return startHTTPService("auth", opts.extAuthHTTPPort, "", opts.tlsCertPath, opts.tlsCertKeyPath, authService, http2Server)
 }
 
 func startOIDCServer(authConfigIndex index.Index, opts authServerOptions) {
-	startHTTPService("oidc", opts.oidcHTTPPort, service.OIDCBasePath, opts.oidcTLSCertPath, opts.oidcTLSCertKeyPath, &service.OidcService{Index: authConfigIndex})
+	startHTTPService("oidc", opts.oidcHTTPPort, service.OIDCBasePath, opts.oidcTLSCertPath, opts.oidcTLSCertKeyPath, &service.OidcService{Index: authConfigIndex}, nil)
+}
+
+func startEdgeAuthServer(opts edgeAuthOptions) {
+	if opts.port == 0 {
+		return
+	}
+
+	keyFile, err := os.ReadFile(opts.signingKey)
+	if err != nil {
+		logger.Error(err, "failed to read the signing key of the edge authentication server")
+		os.Exit(1)
+	}
+	signingKey, err := response_evaluators.NewSigningKey("edge-auth", opts.signingAlgorithm, keyFile)
+	if err != nil {
+		logger.Error(err, "failed to read the signing key of the edge authentication server")
+		os.Exit(1)
+	}
+	wristband, err := response_evaluators.NewWristbandConfig(opts.url, nil, &opts.sessionDuration, []jose.JSONWebKey{*signingKey})
+	if err != nil {
+		logger.Error(err, "failed to setup the edge authentication server")
+		os.Exit(1)
+	}
+
+	startHTTPService("edge auth", opts.port, service.EdgeAuthBasePath, "", "", &service.EdgeAuthService{
+		ProviderURL:        opts.issuerURL,
+		ClientID:           opts.clientID,
+		ClientSecret:       opts.clientSecret,
+		Scopes:             strings.Split(opts.scopes, ","),
+		Wristband:          wristband,
+		CookieName:         opts.cookieName,
+		CookieDomain:       opts.cookieDomain,
+		IntrospectionToken: opts.introspectionToken,
+	}, nil)
+}
+
+func setupOPADecisionLogs(opts authServerOptions) {
+	switch {
+	case opts.opaDecisionLogsURL != "":
+		sink, err := authorization_evaluators.NewOPADecisionLogHTTPSink(log.IntoContext(context.Background(), logger), opts.opaDecisionLogsURL, opts.opaDecisionLogsFlushInterval, authorization_evaluators.DefaultOPADecisionLogsBatchSize)
+		if err != nil {
+			logger.Error(err, "failed to setup opa decision logs")
+			os.Exit(1)
+		}
+		authorization_evaluators.OPADecisionLogs = sink
+	case opts.opaDecisionLogsFile != "":
+		sink, err := authorization_evaluators.NewOPADecisionLogFileSink(opts.opaDecisionLogsFile)
+		if err != nil {
+			logger.Error(err, "failed to setup opa decision logs")
+			os.Exit(1)
+		}
+		authorization_evaluators.OPADecisionLogs = sink
+	}
+}
+
+func setupExternalCache(opts authServerOptions) {
+	if opts.evaluatorCacheRedisURL == "" {
+		return
+	}
+	store, err := evaluators.NewRedisCacheStore(opts.evaluatorCacheRedisURL)
+	if err != nil {
+		logger.Error(err, "failed to setup external cache backend")
+		os.Exit(1)
+	}
+	evaluators.ExternalCache = evaluators.NewExternalCacheBackend(store, opts.evaluatorCacheInvalidation)
+}
+
+func setupAuditLog(opts authServerOptions) {
+	switch opts.auditLogSink {
+	case "":
+	case audit.SinkStdout:
+		audit.Records = audit.NewStdoutSink()
+	case audit.SinkFile:
+		if opts.auditLogFile == "" {
+			logger.Error(fmt.Errorf("missing audit log file"), "failed to setup audit log")
+			os.Exit(1)
+		}
+		audit.Records = audit.NewFileSink(opts.auditLogFile, opts.auditLogFileMaxSize, opts.auditLogFileMaxBackups)
+	case audit.SinkHTTP:
+		if opts.auditLogURL == "" {
+			logger.Error(fmt.Errorf("missing audit log url"), "failed to setup audit log")
+			os.Exit(1)
+		}
+		sink, err := audit.NewHTTPSink(log.IntoContext(context.Background(), logger), opts.auditLogURL, opts.auditLogFlushInterval, audit.DefaultHTTPBatchSize)
+		if err != nil {
+			logger.Error(err, "failed to setup audit log")
+			os.Exit(1)
+		}
+		audit.Records = sink
+	default:
+		logger.Error(fmt.Errorf("unsupported audit log sink: %s", opts.auditLogSink), "failed to setup audit log")
+		os.Exit(1)
+	}
 }
 
-func startHTTPService(name string, port int, basePath, tlsCertPath, tlsCertKeyPath string, handler http.Handler) {
-	lis, err := listen(port)
+func startAdminServer(authConfigIndex index.Index, authConfigs service.ReconciledAuthConfigs, runtimeSettings *service.RuntimeSettingsReloader, opts authServerOptions) {
+	// without a token, the admin server is only reachable from the loopback interface
+	host := ""
+	if opts.adminHTTPToken == "" && opts.adminHTTPPort != 0 {
+		host = "127.0.0.1"
+		logger.Info("admin server token not set, binding the admin server to the loopback interface only", "host", host)
+	}
+	startHTTPServiceOn("admin", host, opts.adminHTTPPort, service.AdminBasePath, "", "", &service.AdminService{Index: authConfigIndex, AuthConfigs: authConfigs, Settings: runtimeSettings, Token: opts.adminHTTPToken, Profiling: opts.adminProfilingEnabled}, nil)
+}
+
+// setupRuntimeSettings applies the runtime settings of the file, if any, and reloads them on SIGHUP
+func setupRuntimeSettings(authService *service.AuthService, opts authServerOptions) *service.RuntimeSettingsReloader {
+	logLevel := log.ToLogLevel(opts.log.level)
+	reloader := &service.RuntimeSettingsReloader{
+		Defaults: service.RuntimeSettings{
+			LogLevel:             logLevel.String(),
+			DeepMetricsEnabled:   opts.deepMetricsEnabled,
+			TracingSamplingRatio: opts.telemetry.tracingSamplingRatio,
+			Timeout:              opts.timeout,
+			DebugSamplingRatio:   opts.debugSamplingRatio,
+		},
+		File:         opts.runtimeSettingsFile,
+		AuthServices: []*service.AuthService{authService},
+		Logger:       logger.WithName("settings"),
+	}
+	if _, err := reloader.Reload(); err != nil {
+		logger.Error(err, "failed to load runtime settings")
+		os.Exit(1)
+	}
+
+	if opts.runtimeSettingsFile == "" {
+		return reloader
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	go func() {
+		for range signals {
+			if _, err := reloader.Reload(); err != nil {
+				logger.Error(err, "failed to reload runtime settings")
+			}
+		}
+	}()
+	return reloader
+}
+
+// startHTTPService starts an http service on a port, serving the handler under the base path.
+// If an http2 server is provided, the service is also served over HTTP/2, with the settings of the http2 server, either
+// negotiated over tls or in cleartext (h2c).
+func startHTTPService(name string, port int, basePath, tlsCertPath, tlsCertKeyPath string, handler http.Handler, http2Server *http2.Server) shutdownFunc {
+	return startHTTPServiceOn(name, "", port, basePath, tlsCertPath, tlsCertKeyPath, handler, http2Server)
+}
+
+// startHTTPServiceOn starts an http service like startHTTPService, listening only on the given host.
+// An empty host listens on all interfaces.
+func startHTTPServiceOn(name, host string, port int, basePath, tlsCertPath, tlsCertKeyPath string, handler http.Handler, http2Server *http2.Server) shutdownFunc {
+	lis, err := listenOn(host, port)
 
 	if err != nil {
 		logger.Error(err, fmt.Sprintf("failed to obtain port for the http %s service", name))
@@ -504,43 +1142,69 @@ func startHTTPService(name string, port int, basePath, tlsCertPath, tlsCertKeyPa
 
 	if lis == nil {
 		logger.Info(fmt.Sprintf("disabling http %s service", name))
-		return
+		return nil
 	}
 
-	http.Handle(basePath, otel_http.NewHandler(handler, name))
+	// each service gets its own mux so the handlers are only reachable on their own port
+	// an empty base path serves all paths with the handler
+	var mux http.Handler = otel_http.NewHandler(handler, name)
+	if basePath != "" {
+		serveMux := http.NewServeMux()
+		serveMux.Handle(basePath, mux)
+		mux = serveMux
+	}
 
 	tlsEnabled := tlsCertPath != "" && tlsCertKeyPath != ""
+	server := &http.Server{Handler: mux}
+
+	if tlsEnabled {
+		server.TLSConfig = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			ClientAuth: tls.RequestClientCert,
+		}
+	}
+
+	if http2Server != nil {
+		if tlsEnabled {
+			if err := http2.ConfigureServer(server, http2Server); err != nil {
+				logger.Error(err, fmt.Sprintf("failed to configure http2 for the http %s service", name))
+				os.Exit(1)
+			}
+		} else {
+			server.Handler = h2c.NewHandler(mux, http2Server)
+		}
+	}
 
 	go func() {
 		var err error
 
-		logger.Info(fmt.Sprintf("starting http %s service", name), "port", port, "tls", tlsEnabled)
+		logger.Info(fmt.Sprintf("starting http %s service", name), "host", host, "port", port, "tls", tlsEnabled, "http2", http2Server != nil)
 
 		if tlsEnabled {
-			server := &http.Server{
-				TLSConfig: &tls.Config{
-					MinVersion: tls.VersionTLS12,
-					ClientAuth: tls.RequestClientCert,
-				},
-			}
 			err = server.ServeTLS(lis, tlsCertPath, tlsCertKeyPath)
 		} else {
-			err = http.Serve(lis, nil)
+			err = server.Serve(lis)
 		}
 
-		if err != nil {
+		if err != nil && err != http.ErrServerClosed {
 			logger.Error(err, fmt.Sprintf("failed to start http %s service", name))
 			os.Exit(1)
 		}
 	}()
+
+	return server.Shutdown
 }
 
 func listen(port int) (net.Listener, error) {
+	return listenOn("", port)
+}
+
+func listenOn(host string, port int) (net.Listener, error) {
 	if port == 0 {
 		return nil, nil
 	}
 
-	if lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port)); err != nil {
+	if lis, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port))); err != nil {
 		return nil, err
 	} else {
 		return lis, nil
@@ -560,6 +1224,66 @@ func timeoutMs(timeout int) time.Duration {
 	return time.Duration(timeout) * time.Millisecond
 }
 
+func runLint(cmd *cobra.Command, args []string) error {
+	opts := cmd.Context().Value(keyLintOptions{}).(*lintOptions)
+
+	if opts.output != "text" && opts.output != "json" {
+		return fmt.Errorf("unsupported output format: %s", opts.output)
+	}
+
+	setupLogger(opts.log)
+
+	sources := map[string][]byte{}
+	for _, arg := range args {
+		files := []string{arg}
+		if info, err := os.Stat(arg); err != nil {
+			return err
+		} else if info.IsDir() {
+			entries, err := os.ReadDir(arg)
+			if err != nil {
+				return err
+			}
+			files = nil
+			for _, entry := range entries {
+				if ext := filepath.Ext(entry.Name()); !entry.IsDir() && (ext == ".yaml" || ext == ".yml" || ext == ".json") {
+					files = append(files, filepath.Join(arg, entry.Name()))
+				}
+			}
+		}
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			sources[file] = data
+		}
+	}
+
+	linter := &controllers.Linter{Scheme: scheme, Namespace: opts.namespace, Build: opts.build}
+	findings := linter.Lint(cmd.Context(), sources)
+
+	if opts.output == "json" {
+		if findings == nil {
+			findings = []controllers.LintFinding{}
+		}
+		encoder := json.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(findings); err != nil {
+			return err
+		}
+	} else {
+		for _, finding := range findings {
+			fmt.Fprintln(cmd.OutOrStdout(), finding.String())
+		}
+	}
+
+	if controllers.LintFailed(findings) {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("errors found in the authconfigs")
+	}
+	return nil
+}
+
 func printVersion(_ *cobra.Command, _ []string) {
 	fmt.Println("Authorino", version)
 }
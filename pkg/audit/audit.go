@@ -0,0 +1,210 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/log"
+	"github.com/kuadrant/authorino/pkg/workers"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	SinkStdout = "stdout"
+	SinkFile   = "file"
+	SinkHTTP   = "http"
+
+	DefaultFileMaxSize       = 100
+	DefaultFileMaxBackups    = 3
+	DefaultHTTPFlushInterval = 10
+	DefaultHTTPBatchSize     = 100
+
+	msg_auditWriteError = "failed to write audit records"
+	msg_auditSendError  = "failed to send audit records"
+)
+
+// Records is the sink of the audit records of all auth decisions; auditing is disabled if nil
+var Records Logger
+
+// Record is the audit record of an auth decision
+type Record struct {
+	Timestamp  time.Time              `json:"timestamp"`
+	RequestId  string                 `json:"requestId"`
+	Host       string                 `json:"host"`
+	AuthConfig string                 `json:"authConfig,omitempty"`
+	Subject    string                 `json:"subject,omitempty"`
+	Verdict    string                 `json:"verdict"`
+	Code       string                 `json:"code"`
+	Reason     string                 `json:"reason,omitempty"`
+	Timings    map[string]interface{} `json:"timings,omitempty"`
+	// Deduplicated tells whether the decision was shared with an identical request evaluated concurrently
+	Deduplicated bool `json:"deduplicated,omitempty"`
+}
+
+type Logger interface {
+	Log(Record)
+}
+
+// NewStdoutSink returns a sink that writes the audit records to the standard output, one JSON entry per line,
+// independently of the logger of the service
+func NewStdoutSink() *WriterSink {
+	return NewWriterSink(os.Stdout)
+}
+
+// NewFileSink returns a sink that appends the audit records to a file, one JSON entry per line.
+// The file is rotated when reaching the max size (in megabytes), keeping up to the max number of backups.
+func NewFileSink(path string, maxSize, maxBackups int) *WriterSink {
+	if maxSize <= 0 {
+		maxSize = DefaultFileMaxSize
+	}
+	if maxBackups < 0 {
+		maxBackups = DefaultFileMaxBackups
+	}
+	return NewWriterSink(&lumberjack.Logger{
+		Filename:   path,
+		MaxSize:    maxSize,
+		MaxBackups: maxBackups,
+	})
+}
+
+// NewWriterSink returns a sink that writes the audit records to a writer, one JSON entry per line
+func NewWriterSink(writer io.Writer) *WriterSink {
+	return &WriterSink{
+		writer: writer,
+		logger: log.WithName("audit"),
+	}
+}
+
+type WriterSink struct {
+	writer io.Writer
+	logger log.Logger
+	mu     sync.Mutex
+}
+
+func (s *WriterSink) Log(record Record) {
+	entry, err := json.Marshal(record)
+	if err != nil {
+		s.logger.Error(err, msg_auditWriteError)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.writer.Write(append(entry, '\n')); err != nil {
+		s.logger.Error(err, msg_auditWriteError)
+	}
+}
+
+// Stop closes the underlying writer, if closable
+func (s *WriterSink) Stop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if closer, ok := s.writer.(io.Closer); ok && s.writer != os.Stdout {
+		return closer.Close()
+	}
+	return nil
+}
+
+// NewHTTPSink returns a sink that sends the audit records in batches to an HTTP endpoint, as JSON arrays of entries.
+// The batches are sent at every flush interval (in seconds) or whenever reaching the batch size.
+func NewHTTPSink(ctx context.Context, endpoint string, flushInterval, batchSize int) (*HTTPSink, error) {
+	if flushInterval <= 0 {
+		flushInterval = DefaultHTTPFlushInterval
+	}
+	if batchSize <= 0 {
+		batchSize = DefaultHTTPBatchSize
+	}
+
+	s := &HTTPSink{
+		Endpoint:  endpoint,
+		BatchSize: batchSize,
+		client:    &http.Client{Timeout: 10 * time.Second},
+		logger:    log.FromContext(ctx).WithName("audit"),
+	}
+
+	var err error
+	if s.flusher, err = workers.StartWorker(ctx, flushInterval, s.flush); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+type HTTPSink struct {
+	Endpoint  string
+	BatchSize int
+
+	client  *http.Client
+	logger  log.Logger
+	flusher workers.Worker
+	buffer  []Record
+	mu      sync.Mutex
+}
+
+func (s *HTTPSink) Log(record Record) {
+	s.mu.Lock()
+	s.buffer = append(s.buffer, record)
+	full := len(s.buffer) >= s.BatchSize
+	s.mu.Unlock()
+
+	if full {
+		go s.flush()
+	}
+}
+
+// Stop sends the audit records still in the buffer and stops flushing periodically
+func (s *HTTPSink) Stop() error {
+	s.flush()
+	return s.flusher.Stop()
+}
+
+func (s *HTTPSink) flush() {
+	s.mu.Lock()
+	batch := s.buffer
+	s.buffer = nil
+	s.mu.Unlock()
+
+	if len(batch) == 0 {
+		return
+	}
+
+	if err := s.send(batch); err != nil {
+		s.logger.Error(err, msg_auditSendError, "endpoint", s.Endpoint, "dropped", len(batch))
+	} else {
+		s.logger.V(1).Info("audit records sent", "endpoint", s.Endpoint, "count", len(batch))
+	}
+}
+
+func (s *HTTPSink) send(batch []Record) error {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	gohttptest "net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func newTestRecord(requestId string) Record {
+	return Record{
+		Timestamp: time.Now().UTC(),
+		RequestId: requestId,
+		Host:      "myapp.io",
+		Subject:   "john",
+		Verdict:   "allow",
+		Code:      "OK",
+		Timings:   map[string]interface{}{"identity": map[string]float64{"keycloak": 1.5}},
+	}
+}
+
+func TestWriterSink(t *testing.T) {
+	var buffer bytes.Buffer
+	sink := NewWriterSink(&buffer)
+
+	sink.Log(newTestRecord("1"))
+
+	var entry map[string]interface{}
+	assert.NilError(t, json.Unmarshal(bytes.TrimSuffix(buffer.Bytes(), []byte("\n")), &entry))
+	assert.Equal(t, entry["requestId"], "1")
+	assert.Equal(t, entry["host"], "myapp.io")
+	assert.Equal(t, entry["subject"], "john")
+	assert.Equal(t, entry["verdict"], "allow")
+	assert.Equal(t, entry["timings"].(map[string]interface{})["identity"].(map[string]interface{})["keycloak"], 1.5)
+	_, reason := entry["reason"]
+	assert.Check(t, !reason)
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink := NewFileSink(path, 1, 1)
+	defer sink.Stop()
+
+	sink.Log(newTestRecord("1"))
+	sink.Log(newTestRecord("2"))
+
+	file, err := os.Open(path)
+	assert.NilError(t, err)
+	defer file.Close()
+
+	var ids []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry Record
+		assert.NilError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		ids = append(ids, entry.RequestId)
+	}
+	assert.DeepEqual(t, ids, []string{"1", "2"})
+}
+
+func TestHTTPSink(t *testing.T) {
+	received := make(chan []Record, 1)
+	server := gohttptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, req.Header.Get("Content-Type"), "application/json")
+		var batch []Record
+		assert.NilError(t, json.NewDecoder(req.Body).Decode(&batch))
+		received <- batch
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sink, err := NewHTTPSink(context.TODO(), server.URL+"/audit", 3600, 2)
+	assert.NilError(t, err)
+	defer sink.Stop()
+
+	sink.Log(newTestRecord("1"))
+	sink.Log(newTestRecord("2")) // reaches the batch size
+
+	select {
+	case batch := <-received:
+		assert.Equal(t, len(batch), 2)
+		assert.Equal(t, batch[0].RequestId, "1")
+		assert.Equal(t, batch[1].RequestId, "2")
+	case <-time.After(5 * time.Second):
+		t.Fatal("audit records not sent")
+	}
+}
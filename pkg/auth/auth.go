@@ -65,6 +65,7 @@ type WristbandIssuer interface {
 	GetIssuer() string
 	OpenIDConfig() (string, error)
 	JWKS() (string, error)
+	Introspect(wristband string) (string, error)
 }
 
 type ResponseConfigEvaluator interface {
@@ -84,6 +85,22 @@ type AuthResult struct {
 	Message string `json:"message,omitempty"`
 	// Headers are other HTTP headers to inject in the response
 	Headers []map[string]string `json:"headers,omitempty"`
+	// ResponseHeaders are HTTP headers to add to the response sent back to the client (e.g. `Set-Cookie`), when the auth
+	// check succeeds
+	ResponseHeaders []map[string]string `json:"responseHeaders,omitempty"`
+	// HeadersToRemove are HTTP headers to remove from the request forwarded upstream, when the auth check succeeds
+	HeadersToRemove []string `json:"headersToRemove,omitempty"`
+	// HeadersToAppend are the HTTP headers to inject in the request forwarded upstream whose values are appended to the
+	// existing ones, instead of replacing them
+	HeadersToAppend []string `json:"headersToAppend,omitempty"`
+	// ResponseHeadersToAppend are the HTTP headers to add to the response sent back to the client whose values are
+	// appended to the existing ones, instead of replacing them
+	ResponseHeadersToAppend []string `json:"responseHeadersToAppend,omitempty"`
+	// QueryParametersToSet are query parameters to set in the request forwarded upstream, when the auth check succeeds
+	QueryParametersToSet map[string]string `json:"queryParametersToSet,omitempty"`
+	// QueryParametersToRemove are query parameters to remove from the request forwarded upstream, when the auth check
+	// succeeds
+	QueryParametersToRemove []string `json:"queryParametersToRemove,omitempty"`
 	// Metadata are Envoy dynamic metadata content
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
 	// Body in the response of the request
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"io"
 	"net/http"
@@ -16,8 +17,12 @@ const (
 	inAuthHeader   = "authorization_header"
 	inCookieHeader = "cookie"
 	inQuery        = "query"
+	inWebSocket    = "websocket_protocol"
+	inGRPCMetadata = "grpc_metadata"
 
-	defaultKeySelector = "Bearer"
+	defaultKeySelector             = "Bearer"
+	defaultWebSocketProtocolPrefix = "bearer."
+	webSocketProtocolHeader        = "sec-websocket-protocol"
 
 	credentialNotFoundMsg             = "credential not found"
 	credentialNotFoundInHeaderMsg     = "the credential was not found in the request header"
@@ -39,12 +44,16 @@ type AuthCredentials interface {
 // NewAuthCredential creates a new instance of AuthCredential
 func NewAuthCredential(selector string, location string) *AuthCredential {
 	var keySelector, in string
-	if keySelector = selector; keySelector == "" {
-		keySelector = defaultKeySelector
-	}
 	if in = location; in == "" {
 		in = inAuthHeader
 	}
+	if keySelector = selector; keySelector == "" {
+		if in == inWebSocket {
+			keySelector = defaultWebSocketProtocolPrefix
+		} else {
+			keySelector = defaultKeySelector
+		}
+	}
 
 	return &AuthCredential{
 		keySelector,
@@ -69,6 +78,10 @@ func (c *AuthCredential) GetCredentialsFromReq(httpReq *envoy_auth.AttributeCont
 		return getFromCookieHeader(httpReq.GetHeaders(), c.KeySelector)
 	case inQuery:
 		return getCredFromQuery(httpReq.GetPath(), c.KeySelector)
+	case inWebSocket:
+		return getCredFromWebSocketProtocol(httpReq.GetHeaders(), c.KeySelector)
+	case inGRPCMetadata:
+		return getCredFromGRPCMetadata(httpReq.GetHeaders(), c.KeySelector)
 	default:
 		return "", fmt.Errorf(credentialLocationNotSupportedMsg)
 	}
@@ -113,6 +126,13 @@ func (c *AuthCredential) BuildRequestWithCredentials(ctx context.Context, endpoi
 			req.Header.Set(c.KeySelector, credentialValue)
 		case inCookieHeader:
 			req.Header.Set("Cookie", c.KeySelector+"="+credentialValue)
+		case inWebSocket:
+			req.Header.Set("Sec-WebSocket-Protocol", c.KeySelector+credentialValue)
+		case inGRPCMetadata:
+			if isBinaryGRPCMetadataKey(c.KeySelector) {
+				credentialValue = base64.RawStdEncoding.EncodeToString([]byte(credentialValue))
+			}
+			req.Header.Set(c.KeySelector, credentialValue)
 		case inQuery:
 			// already done
 		default:
@@ -122,6 +142,40 @@ func (c *AuthCredential) BuildRequestWithCredentials(ctx context.Context, endpoi
 	}
 }
 
+// GetCredentialsHeaderName returns the name (lowercase) of the HTTP request header that carries the credentials, or an
+// empty string if the credentials are not passed in a header of their own (e.g. cookie or query string parameter)
+func GetCredentialsHeaderName(c AuthCredentials) string {
+	switch c.GetCredentialsIn() {
+	case inAuthHeader:
+		return "authorization"
+	case inCustomHeader, inGRPCMetadata:
+		return strings.ToLower(c.GetCredentialsKeySelector())
+	default:
+		return ""
+	}
+}
+
+// StripCredentialsFromHeader returns the name and the new value of the request header that carries the credentials
+// among other values, so the credentials can be stripped out while the other values are kept in the request forwarded
+// upstream. An empty value means no other values are left, i.e. the header can be removed altogether.
+// Returns false if the credentials are not passed among other values in a header.
+func StripCredentialsFromHeader(c AuthCredentials, headers map[string]string) (string, string, bool) {
+	if c.GetCredentialsIn() != inWebSocket {
+		return "", "", false
+	}
+	header, ok := headers[webSocketProtocolHeader]
+	if !ok {
+		return "", "", false
+	}
+	var protocols []string
+	for _, protocol := range strings.Split(header, ",") {
+		if protocol = strings.TrimSpace(protocol); protocol != "" && !strings.HasPrefix(protocol, c.GetCredentialsKeySelector()) {
+			protocols = append(protocols, protocol)
+		}
+	}
+	return webSocketProtocolHeader, strings.Join(protocols, ", "), true
+}
+
 func getCredFromCustomHeader(headers map[string]string, keyName string) (string, error) {
 	cred, ok := headers[strings.ToLower(keyName)]
 	if !ok {
@@ -168,3 +222,41 @@ func getCredFromQuery(path string, keyName string) (string, error) {
 	}
 	return matches[regex.SubexpIndex(credValue)], nil
 }
+
+// getCredFromWebSocketProtocol reads the credentials from the subprotocols of a WebSocket handshake, which is the only
+// header browsers let the clients set in the handshake requests. The credentials are passed in the value of a
+// subprotocol that starts with the prefix (e.g. `Sec-WebSocket-Protocol: chat, bearer.<token>`).
+func getCredFromWebSocketProtocol(headers map[string]string, prefix string) (string, error) {
+	header, ok := headers[webSocketProtocolHeader]
+	if !ok {
+		return "", errNotFound
+	}
+	for _, protocol := range strings.Split(header, ",") {
+		if protocol = strings.TrimSpace(protocol); strings.HasPrefix(protocol, prefix) {
+			return strings.TrimPrefix(protocol, prefix), nil
+		}
+	}
+	return "", errNotFound
+}
+
+// getCredFromGRPCMetadata reads the credentials from a gRPC metadata key, i.e. an HTTP/2 header of the gRPC request.
+// Values of binary keys (suffixed with `-bin`) are base64-decoded, as they are encoded on the wire.
+func getCredFromGRPCMetadata(headers map[string]string, key string) (string, error) {
+	value, ok := headers[strings.ToLower(key)]
+	if !ok {
+		return "", errNotFound
+	}
+	if !isBinaryGRPCMetadataKey(key) {
+		return value, nil
+	}
+	// binary values may be sent either padded or not
+	decoded, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil {
+		return "", errNotFound
+	}
+	return string(decoded), nil
+}
+
+func isBinaryGRPCMetadataKey(key string) bool {
+	return strings.HasSuffix(strings.ToLower(key), "-bin")
+}
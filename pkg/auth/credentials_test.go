@@ -214,6 +214,49 @@ func TestGetCredentialsFromQueryFail(t *testing.T) {
 	assert.Error(t, err, "credential not found")
 }
 
+func TestGetCredentialsFromWebSocketProtocolSuccess(t *testing.T) {
+	var httpReq = envoyServiceAuthV3.AttributeContext_HttpRequest{
+		Headers: map[string]string{"sec-websocket-protocol": "chat, bearer.HumanInstrumentality"},
+	}
+
+	authCredentials := NewAuthCredential("", "websocket_protocol")
+	cred, err := authCredentials.GetCredentialsFromReq(&httpReq)
+
+	assert.NilError(t, err)
+	assert.Equal(t, cred, "HumanInstrumentality")
+}
+
+func TestGetCredentialsFromWebSocketProtocolFail(t *testing.T) {
+	var httpReq = envoyServiceAuthV3.AttributeContext_HttpRequest{
+		Headers: map[string]string{"sec-websocket-protocol": "chat"},
+	}
+
+	authCredentials := NewAuthCredential("bearer.", "websocket_protocol")
+	_, err := authCredentials.GetCredentialsFromReq(&httpReq)
+
+	assert.Error(t, err, "credential not found")
+}
+
+func TestGetCredentialsFromGRPCMetadata(t *testing.T) {
+	var httpReq = envoyServiceAuthV3.AttributeContext_HttpRequest{
+		Headers: map[string]string{
+			"x-api-key":       "HumanInstrumentality",
+			"x-api-token-bin": "SHVtYW5JbnN0cnVtZW50YWxpdHk=",
+		},
+	}
+
+	cred, err := NewAuthCredential("X-API-KEY", "grpc_metadata").GetCredentialsFromReq(&httpReq)
+	assert.NilError(t, err)
+	assert.Equal(t, cred, "HumanInstrumentality")
+
+	cred, err = NewAuthCredential("x-api-token-bin", "grpc_metadata").GetCredentialsFromReq(&httpReq)
+	assert.NilError(t, err)
+	assert.Equal(t, cred, "HumanInstrumentality")
+
+	_, err = NewAuthCredential("x-missing", "grpc_metadata").GetCredentialsFromReq(&httpReq)
+	assert.Error(t, err, "credential not found")
+}
+
 func TestBuildRequestWithCredentials(t *testing.T) {
 	creds := NewAuthCredential("", "")
 	req, err := creds.BuildRequestWithCredentials(context.TODO(), "http://example.com", "GET", "123", nil)
@@ -230,3 +273,30 @@ func TestBuildRequestWithCredentialsEmpty(t *testing.T) {
 	assert.NilError(t, err)
 	assert.Equal(t, len(req.Header.Values("Authorization")), 0)
 }
+
+func TestGetCredentialsHeaderName(t *testing.T) {
+	assert.Equal(t, GetCredentialsHeaderName(NewAuthCredential("Bearer", "authorization_header")), "authorization")
+	assert.Equal(t, GetCredentialsHeaderName(NewAuthCredential("X-API-KEY", "custom_header")), "x-api-key")
+	assert.Equal(t, GetCredentialsHeaderName(NewAuthCredential("session", "cookie")), "")
+	assert.Equal(t, GetCredentialsHeaderName(NewAuthCredential("api_key", "query")), "")
+	assert.Equal(t, GetCredentialsHeaderName(NewAuthCredential("X-API-Token-Bin", "grpc_metadata")), "x-api-token-bin")
+}
+
+func TestStripCredentialsFromHeader(t *testing.T) {
+	creds := NewAuthCredential("bearer.", "websocket_protocol")
+
+	header, value, found := StripCredentialsFromHeader(creds, map[string]string{"sec-websocket-protocol": "chat, bearer.HumanInstrumentality, superchat"})
+	assert.Check(t, found)
+	assert.Equal(t, header, "sec-websocket-protocol")
+	assert.Equal(t, value, "chat, superchat")
+
+	_, value, found = StripCredentialsFromHeader(creds, map[string]string{"sec-websocket-protocol": "bearer.HumanInstrumentality"})
+	assert.Check(t, found)
+	assert.Equal(t, value, "")
+
+	_, _, found = StripCredentialsFromHeader(creds, map[string]string{})
+	assert.Check(t, !found)
+
+	_, _, found = StripCredentialsFromHeader(NewAuthCredential("X-API-KEY", "custom_header"), map[string]string{"x-api-key": "HumanInstrumentality"})
+	assert.Check(t, !found)
+}
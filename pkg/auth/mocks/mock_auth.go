@@ -527,6 +527,21 @@ func (mr *MockWristbandIssuerMockRecorder) GetIssuer() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIssuer", reflect.TypeOf((*MockWristbandIssuer)(nil).GetIssuer))
 }
 
+// Introspect mocks base method.
+func (m *MockWristbandIssuer) Introspect(arg0 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Introspect", arg0)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Introspect indicates an expected call of Introspect.
+func (mr *MockWristbandIssuerMockRecorder) Introspect(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Introspect", reflect.TypeOf((*MockWristbandIssuer)(nil).Introspect), arg0)
+}
+
 // JWKS mocks base method.
 func (m *MockWristbandIssuer) JWKS() (string, error) {
 	m.ctrl.T.Helper()
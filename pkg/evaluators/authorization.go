@@ -6,6 +6,7 @@ import (
 
 	"github.com/kuadrant/authorino/pkg/auth"
 	"github.com/kuadrant/authorino/pkg/evaluators/authorization"
+	"github.com/kuadrant/authorino/pkg/json"
 	"github.com/kuadrant/authorino/pkg/jsonexp"
 	"github.com/kuadrant/authorino/pkg/log"
 )
@@ -15,6 +16,16 @@ const (
 	authorizationJSON       = "AUTHORIZATION_JSON"
 	authorizationKubernetes = "AUTHORIZATION_KUBERNETES"
 	authorizationAuthzed    = "AUTHORIZATION_AUTHZED"
+	authorizationQuota      = "AUTHORIZATION_QUOTA"
+	authorizationRBAC       = "AUTHORIZATION_RBAC"
+	authorizationScopes     = "AUTHORIZATION_REQUIREDSCOPES"
+	authorizationWasm       = "AUTHORIZATION_WASM"
+	authorizationLua        = "AUTHORIZATION_LUA"
+
+	AllOfAuthorizationStrategy           = "allOf"
+	AnyOfAuthorizationStrategy           = "anyOf"
+	DenyOverridesAuthorizationStrategy   = "denyOverrides"
+	PermitOverridesAuthorizationStrategy = "permitOverrides"
 )
 
 type AuthorizationConfig struct {
@@ -23,11 +34,19 @@ type AuthorizationConfig struct {
 	Conditions jsonexp.Expression `yaml:"conditions"`
 	Metrics    bool               `yaml:"metrics"`
 	Cache      EvaluatorCache
+	Weight     int                 `yaml:"weight"`
+	DryRun     bool                `yaml:"dryRun"`
+	Exports    []json.JSONProperty `yaml:"exports"`
 
 	OPA             *authorization.OPA                 `yaml:"opa,omitempty"`
 	JSON            *authorization.JSONPatternMatching `yaml:"json,omitempty"`
 	KubernetesAuthz *authorization.KubernetesAuthz     `yaml:"kubernetes,omitempty"`
 	Authzed         *authorization.Authzed             `yaml:"authzed,omitempty"`
+	Quota           *authorization.Quota               `yaml:"quota,omitempty"`
+	RBAC            *authorization.RBAC                `yaml:"rbac,omitempty"`
+	RequiredScopes  *authorization.RequiredScopes      `yaml:"requiredScopes,omitempty"`
+	Wasm            *authorization.Wasm                `yaml:"wasm,omitempty"`
+	Lua             *authorization.Lua                 `yaml:"lua,omitempty"`
 }
 
 func (config *AuthorizationConfig) GetAuthConfigEvaluator() auth.AuthConfigEvaluator {
@@ -40,6 +59,16 @@ func (config *AuthorizationConfig) GetAuthConfigEvaluator() auth.AuthConfigEvalu
 		return config.KubernetesAuthz
 	case authorizationAuthzed:
 		return config.Authzed
+	case authorizationQuota:
+		return config.Quota
+	case authorizationRBAC:
+		return config.RBAC
+	case authorizationScopes:
+		return config.RequiredScopes
+	case authorizationWasm:
+		return config.Wasm
+	case authorizationLua:
+		return config.Lua
 	default:
 		return nil
 	}
@@ -95,6 +124,16 @@ func (config *AuthorizationConfig) GetType() string {
 		return authorizationKubernetes
 	case config.Authzed != nil:
 		return authorizationAuthzed
+	case config.Quota != nil:
+		return authorizationQuota
+	case config.RBAC != nil:
+		return authorizationRBAC
+	case config.RequiredScopes != nil:
+		return authorizationScopes
+	case config.Wasm != nil:
+		return authorizationWasm
+	case config.Lua != nil:
+		return authorizationLua
 	default:
 		return ""
 	}
@@ -133,6 +172,10 @@ func (config *AuthorizationConfig) getCleaner() auth.AuthConfigCleaner {
 	switch {
 	case config.OPA != nil:
 		return config.OPA
+	case config.Quota != nil:
+		return config.Quota
+	case config.RBAC != nil:
+		return config.RBAC
 	default:
 		return nil
 	}
@@ -1,5 +1,21 @@
 package authorization
 
+import "fmt"
+
 const (
 	unauthorizedErrorMsg = "Unauthorized"
 )
+
+// PolicyCompilationError is the error of an authorization policy that does not compile
+type PolicyCompilationError struct {
+	Policy string
+	Err    error
+}
+
+func (e *PolicyCompilationError) Error() string {
+	return fmt.Sprintf("failed to compile policy %s: %v", e.Policy, e.Err)
+}
+
+func (e *PolicyCompilationError) Unwrap() error {
+	return e.Err
+}
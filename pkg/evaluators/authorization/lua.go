@@ -0,0 +1,272 @@
+package authorization
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/auth"
+
+	lua "github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/parse"
+)
+
+const (
+	DefaultLuaTimeout  = 100 // milliseconds
+	DefaultLuaMaxSteps = 1000000
+
+	luaInputGlobal      = "input"
+	luaCallStackSize    = 256
+	luaRegistrySize     = 1024
+	luaRegistryMaxSize  = 1024 * 64
+	luaRegistryGrowStep = 32
+	luaMaxOutputValues  = 10000
+)
+
+// unsafe functions of the base library, removed from the environment of the scripts
+var luaUnsafeGlobals = []string{"dofile", "loadfile", "load", "loadstring", "require", "module", "collectgarbage", "print"}
+
+// functions of the string and table libraries that allocate arbitrarily large strings in a single step, removed from the
+// environment of the scripts
+var luaUnboundedFunctions = map[string][]string{
+	lua.StringLibName: {"rep"},
+	lua.TabLibName:    {"concat"},
+}
+
+var errLuaMaxSteps = errors.New("maximum number of steps exceeded")
+
+// NewLuaAuthorization compiles a Lua script that decides on the authorization of the request.
+// The script reads the authorization JSON from the `input` global variable and returns a boolean (true to allow the
+// request), optionally followed by either a reason (string) when denying or an object (table) to be exposed in the
+// authorization JSON when allowing. Only the base (except unsafe functions), string, table and math libraries are
+// available (except the functions that allocate unbounded strings), and every evaluation is aborted if it takes longer
+// than the timeout (in milliseconds) or executes more than DefaultLuaMaxSteps instructions.
+func NewLuaAuthorization(policyName, script string, timeout int) (*Lua, error) {
+	chunk, err := parse.Parse(strings.NewReader(script), policyName)
+	if err != nil {
+		return nil, &PolicyCompilationError{Policy: policyName, Err: err}
+	}
+
+	proto, err := lua.Compile(chunk, policyName)
+	if err != nil {
+		return nil, &PolicyCompilationError{Policy: policyName, Err: err}
+	}
+
+	if timeout <= 0 {
+		timeout = DefaultLuaTimeout
+	}
+
+	return &Lua{
+		Script:   script,
+		Timeout:  time.Duration(timeout) * time.Millisecond,
+		MaxSteps: DefaultLuaMaxSteps,
+		proto:    proto,
+	}, nil
+}
+
+type Lua struct {
+	Script   string
+	Timeout  time.Duration
+	MaxSteps int64
+
+	proto *lua.FunctionProto
+}
+
+func (l *Lua) Call(pipeline auth.AuthPipeline, ctx context.Context) (interface{}, error) {
+	var input interface{}
+	if err := json.Unmarshal([]byte(pipeline.GetAuthorizationJSON()), &input); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, l.Timeout)
+	defer cancel()
+	ctx = newLuaStepContext(ctx, l.MaxSteps)
+
+	state := newLuaState(ctx)
+	defer state.Close()
+
+	state.SetGlobal(luaInputGlobal, toLuaValue(state, input))
+
+	state.Push(state.NewFunctionFromProto(l.proto))
+	if err := state.PCall(0, lua.MultRet, nil); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("lua script aborted: %v", ctx.Err())
+		}
+		return nil, err
+	}
+
+	if state.GetTop() == 0 || !lua.LVAsBool(state.Get(1)) {
+		if state.GetTop() > 1 {
+			if reason, ok := state.Get(2).(lua.LString); ok && reason != "" {
+				return nil, errors.New(string(reason))
+			}
+		}
+		return nil, errors.New(unauthorizedErrorMsg)
+	}
+
+	if state.GetTop() > 1 {
+		count := 0
+		obj, err := fromLuaValue(state.Get(2), make(map[*lua.LTable]bool), &count)
+		if err != nil {
+			return nil, fmt.Errorf("invalid output of the lua script: %v", err)
+		}
+		if obj != nil {
+			return obj, nil
+		}
+	}
+	return true, nil
+}
+
+// newLuaState returns a sandboxed Lua state, limited in size of the call stack and of the registry
+func newLuaState(ctx context.Context) *lua.LState {
+	state := lua.NewState(lua.Options{
+		SkipOpenLibs:     true,
+		CallStackSize:    luaCallStackSize,
+		RegistrySize:     luaRegistrySize,
+		RegistryMaxSize:  luaRegistryMaxSize,
+		RegistryGrowStep: luaRegistryGrowStep,
+	})
+
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		state.Push(state.NewFunction(lib.open))
+		state.Push(lua.LString(lib.name))
+		state.Call(1, 0)
+
+		if table, ok := state.GetGlobal(lib.name).(*lua.LTable); ok {
+			for _, name := range luaUnboundedFunctions[lib.name] {
+				table.RawSetString(name, lua.LNil)
+			}
+		}
+	}
+
+	for _, name := range luaUnsafeGlobals {
+		state.SetGlobal(name, lua.LNil)
+	}
+
+	state.SetContext(ctx)
+	return state
+}
+
+func toLuaValue(state *lua.LState, value interface{}) lua.LValue {
+	switch v := value.(type) {
+	case nil:
+		return lua.LNil
+	case bool:
+		return lua.LBool(v)
+	case float64:
+		return lua.LNumber(v)
+	case string:
+		return lua.LString(v)
+	case []interface{}:
+		table := state.CreateTable(len(v), 0)
+		for _, item := range v {
+			table.Append(toLuaValue(state, item))
+		}
+		return table
+	case map[string]interface{}:
+		table := state.CreateTable(0, len(v))
+		for key, item := range v {
+			table.RawSetString(key, toLuaValue(state, item))
+		}
+		return table
+	default:
+		return lua.LString(fmt.Sprintf("%v", v))
+	}
+}
+
+// fromLuaValue converts a Lua value to a Go value, failing on tables that contain themselves or if the number of
+// values converted (counted across the calls) exceeds luaMaxOutputValues
+func fromLuaValue(value lua.LValue, visiting map[*lua.LTable]bool, count *int) (interface{}, error) {
+	if *count++; *count > luaMaxOutputValues {
+		return nil, fmt.Errorf("more than %d values", luaMaxOutputValues)
+	}
+
+	switch v := value.(type) {
+	case lua.LBool:
+		return bool(v), nil
+	case lua.LNumber:
+		if f := float64(v); f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+			return int64(f), nil
+		}
+		return float64(v), nil
+	case lua.LString:
+		return string(v), nil
+	case *lua.LTable:
+		if visiting[v] {
+			return nil, fmt.Errorf("table contains itself")
+		}
+		visiting[v] = true
+		defer delete(visiting, v)
+
+		// tables with consecutive integer keys starting at 1 are arrays; all other tables are objects
+		if length := v.Len(); length > 0 {
+			array := make([]interface{}, 0, length)
+			for i := 1; i <= length; i++ {
+				item, err := fromLuaValue(v.RawGetInt(i), visiting, count)
+				if err != nil {
+					return nil, err
+				}
+				array = append(array, item)
+			}
+			return array, nil
+		}
+		obj := make(map[string]interface{})
+		var err error
+		v.ForEach(func(key, item lua.LValue) {
+			if err == nil {
+				obj[key.String()], err = fromLuaValue(item, visiting, count)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+		return obj, nil
+	default:
+		return nil, nil
+	}
+}
+
+// luaStepContext is a context that is also done after a maximum number of steps of the Lua VM, which checks whether the
+// context is done before executing every instruction, i.e. every call to Done counts as one step
+type luaStepContext struct {
+	context.Context
+	steps    int64
+	maxSteps int64
+}
+
+var luaStepsExceeded = func() chan struct{} {
+	done := make(chan struct{})
+	close(done)
+	return done
+}()
+
+func newLuaStepContext(parent context.Context, maxSteps int64) *luaStepContext {
+	return &luaStepContext{Context: parent, maxSteps: maxSteps}
+}
+
+func (c *luaStepContext) Done() <-chan struct{} {
+	if atomic.AddInt64(&c.steps, 1) > c.maxSteps {
+		return luaStepsExceeded
+	}
+	return c.Context.Done()
+}
+
+func (c *luaStepContext) Err() error {
+	if atomic.LoadInt64(&c.steps) > c.maxSteps {
+		return errLuaMaxSteps
+	}
+	return c.Context.Err()
+}
@@ -0,0 +1,137 @@
+package authorization
+
+import (
+	"context"
+	"testing"
+
+	mock_auth "github.com/kuadrant/authorino/pkg/auth/mocks"
+
+	. "github.com/golang/mock/gomock"
+	"gotest.tools/assert"
+)
+
+const luaTestScript = `
+local identity = input.auth.identity
+if input.context.request.http.method == "DELETE" and identity.role ~= "admin" then
+  return false, "only admins can delete"
+end
+for _, group in ipairs(identity.groups) do
+  if group == "blocked" then
+    return false
+  end
+end
+return true, { effectiveRole = string.upper(identity.role), groups = #identity.groups }
+`
+
+func TestLuaAuthorization(t *testing.T) {
+	ctrl := NewController(t)
+	defer ctrl.Finish()
+
+	l, err := NewLuaAuthorization("ns/authconfig/lua", luaTestScript, 0)
+	assert.NilError(t, err)
+	assert.Equal(t, l.Timeout.Milliseconds(), int64(DefaultLuaTimeout))
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{"context":{"request":{"http":{"method":"GET"}}},"auth":{"identity":{"role":"member","groups":["a","b"]}}}`)
+	obj, err := l.Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, obj, map[string]interface{}{"effectiveRole": "MEMBER", "groups": int64(2)})
+
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{"context":{"request":{"http":{"method":"DELETE"}}},"auth":{"identity":{"role":"member","groups":[]}}}`)
+	_, err = l.Call(pipelineMock, context.TODO())
+	assert.Error(t, err, "only admins can delete")
+
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{"context":{"request":{"http":{"method":"GET"}}},"auth":{"identity":{"role":"admin","groups":["blocked"]}}}`)
+	_, err = l.Call(pipelineMock, context.TODO())
+	assert.Error(t, err, unauthorizedErrorMsg)
+}
+
+func TestLuaAuthorizationTimeout(t *testing.T) {
+	ctrl := NewController(t)
+	defer ctrl.Finish()
+
+	l, err := NewLuaAuthorization("ns/authconfig/lua", `while true do end`, 10)
+	assert.NilError(t, err)
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{}`)
+
+	_, err = l.Call(pipelineMock, context.TODO())
+	assert.ErrorContains(t, err, "lua script aborted")
+}
+
+func TestLuaAuthorizationSandbox(t *testing.T) {
+	ctrl := NewController(t)
+	defer ctrl.Finish()
+
+	l, err := NewLuaAuthorization("ns/authconfig/lua", `return os == nil and io == nil and dofile == nil and require == nil`, 0)
+	assert.NilError(t, err)
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{}`)
+
+	_, err = l.Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+}
+
+func TestLuaAuthorizationInvalidScript(t *testing.T) {
+	_, err := NewLuaAuthorization("ns/authconfig/lua", `return (`, 0)
+	assert.Check(t, err != nil)
+	_, ok := err.(*PolicyCompilationError)
+	assert.Check(t, ok)
+}
+
+func TestLuaAuthorizationMaxSteps(t *testing.T) {
+	ctrl := NewController(t)
+	defer ctrl.Finish()
+
+	l, err := NewLuaAuthorization("ns/authconfig/lua", `while true do end`, 60000)
+	assert.NilError(t, err)
+	l.MaxSteps = 1000
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{}`)
+
+	_, err = l.Call(pipelineMock, context.TODO())
+	assert.Error(t, err, "lua script aborted: "+errLuaMaxSteps.Error())
+}
+
+func TestLuaAuthorizationUnboundedFunctions(t *testing.T) {
+	ctrl := NewController(t)
+	defer ctrl.Finish()
+
+	l, err := NewLuaAuthorization("ns/authconfig/lua", `return string.rep == nil and table.concat == nil and string.upper ~= nil and table.insert ~= nil`, 0)
+	assert.NilError(t, err)
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{}`)
+
+	obj, err := l.Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+	assert.Equal(t, obj, true)
+}
+
+func TestLuaAuthorizationInvalidOutput(t *testing.T) {
+	ctrl := NewController(t)
+	defer ctrl.Finish()
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{}`).Times(3)
+
+	cyclic, err := NewLuaAuthorization("ns/authconfig/lua", `local t = {} t.self = t return true, t`, 0)
+	assert.NilError(t, err)
+	_, err = cyclic.Call(pipelineMock, context.TODO())
+	assert.Error(t, err, "invalid output of the lua script: table contains itself")
+
+	large, err := NewLuaAuthorization("ns/authconfig/lua", `local t = {} for i = 1, 20000 do t[i] = i end return true, t`, 0)
+	assert.NilError(t, err)
+	_, err = large.Call(pipelineMock, context.TODO())
+	assert.Error(t, err, "invalid output of the lua script: more than 10000 values")
+
+	shared, err := NewLuaAuthorization("ns/authconfig/lua", `local s = { "x" } return true, { a = s, b = s }`, 0)
+	assert.NilError(t, err)
+	obj, err := shared.Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, obj, map[string]interface{}{"a": []interface{}{"x"}, "b": []interface{}{"x"}})
+}
@@ -10,16 +10,17 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/kuadrant/authorino/pkg/auth"
 	"github.com/kuadrant/authorino/pkg/log"
+	"github.com/kuadrant/authorino/pkg/trace"
 	"github.com/kuadrant/authorino/pkg/workers"
 
+	"github.com/google/uuid"
 	opaParser "github.com/open-policy-agent/opa/ast"
 	"github.com/open-policy-agent/opa/rego"
-
-	"go.opentelemetry.io/otel"
-	otel_propagation "go.opentelemetry.io/otel/propagation"
+	"github.com/open-policy-agent/opa/tester"
 )
 
 const (
@@ -28,9 +29,11 @@ default allow = false
 %s`
 	policyUIDHashSeparator = "|"
 	allowQuery             = "allow"
+	testRulePrefix         = "test_"
 
 	msg_opaPolicyInvalidResponseError        = "invalid response from policy evaluation"
 	msg_OpaPolicyPrecompileError             = "failed to precompile policy"
+	msg_OpaPolicyTestsFailed                 = "policy tests failed"
 	msg_opaPolicyDownloadError               = "failed to download policy from external registry"
 	msg_opaPolicyRefreshFromRegistryError    = "failed to refresh policy from external registry"
 	msg_opaPolicyRefreshFromRegistrySkipped  = "external policy unchanged"
@@ -38,7 +41,7 @@ default allow = false
 	msg_opaPolicyRefreshFromRegistryDisabled = "auto-refresh of external policy disabled"
 )
 
-func NewOPAAuthorization(policyName string, rego string, externalSource *OPAExternalSource, allValues bool, nonce int, ctx context.Context) (*OPA, error) {
+func NewOPAAuthorization(policyName string, rego string, externalSource *OPAExternalSource, allValues bool, ctx context.Context) (*OPA, error) {
 	logger := log.FromContext(ctx).WithName("opa")
 
 	pullFromRegistry := rego == "" && externalSource != nil && externalSource.Endpoint != ""
@@ -55,8 +58,8 @@ func NewOPAAuthorization(policyName string, rego string, externalSource *OPAExte
 	o := &OPA{
 		ExternalSource: externalSource,
 		AllValues:      allValues,
+		DecisionLogger: OPADecisionLogs,
 		policyName:     policyName,
-		policyUID:      generatePolicyUID(policyName, rego, nonce),
 		opaContext:     context.TODO(),
 	}
 
@@ -74,11 +77,13 @@ type OPA struct {
 	Rego           string `yaml:"rego"`
 	ExternalSource *OPAExternalSource
 	AllValues      bool
+	DecisionLogger OPADecisionLogger
 
-	opaContext context.Context
-	policy     *rego.PreparedEvalQuery
-	policyName string
-	policyUID  string
+	opaContext     context.Context
+	policy         *rego.PreparedEvalQuery
+	policyName     string
+	policyKey      string
+	policyRevision string
 
 	mu sync.RWMutex
 }
@@ -92,8 +97,17 @@ func (opa *OPA) Call(pipeline auth.AuthPipeline, ctx context.Context) (interface
 		return false, err
 	} else {
 		options := rego.EvalInput(authJSON)
+		start := time.Now()
 		results, err := opa.policy.Eval(opa.opaContext, options)
 
+		if opa.DecisionLogger != nil {
+			var result interface{}
+			if len(results) > 0 {
+				result = results[0].Bindings
+			}
+			opa.DecisionLogger.Log(newOPADecisionLog(decisionIdFor(pipeline), opa.policyName, opa.policyRevision, authJSON, result, err, time.Since(start)))
+		}
+
 		if err != nil {
 			return nil, err
 		} else if len(results) == 0 {
@@ -106,8 +120,15 @@ func (opa *OPA) Call(pipeline auth.AuthPipeline, ctx context.Context) (interface
 	}
 }
 
-// Clean ensures the goroutine started by ExternalSource.setupRefresher is cleaned up
+// Clean releases the compiled policy and ensures the goroutine started by ExternalSource.setupRefresher is cleaned up
 func (opa *OPA) Clean(_ context.Context) error {
+	opa.mu.Lock()
+	if opa.policyKey != "" {
+		compiledRegoPolicies.release(opa.policyKey)
+		opa.policyKey = ""
+	}
+	opa.mu.Unlock()
+
 	if opa.ExternalSource == nil {
 		return nil
 	}
@@ -115,40 +136,64 @@ func (opa *OPA) Clean(_ context.Context) error {
 	return opa.ExternalSource.cleanupRefresher()
 }
 
-func (opa *OPA) updateRego(rego string, ctx context.Context, force bool) (bool, error) {
+func (opa *OPA) updateRego(source string, ctx context.Context, force bool) (bool, error) {
 	opa.mu.Lock()
 	defer opa.mu.Unlock()
 
-	newRego := cleanUpRegoDocument(rego)
+	newRego := cleanUpRegoDocument(source)
 	currentRego := opa.Rego
 
 	if !force && hash(newRego) == hash(currentRego) {
 		return false, nil
 	}
 
+	// policies are compiled in a package named after the hash of the source, so the same compiled policy can be shared
+	// by all the evaluators with an identical policy
+	key := regoPolicyKey(newRego, opa.AllValues)
+	policy, err := compiledRegoPolicies.acquire(key, func() (*rego.PreparedEvalQuery, error) {
+		if err := runPolicyTests(ctx, key, newRego); err != nil {
+			log.FromContext(ctx).Error(err, msg_OpaPolicyTestsFailed, "policy", opa.policyName)
+			return nil, err
+		}
+		if policy, err := precompilePolicy(opa.opaContext, key, newRego, opa.AllValues); err != nil {
+			log.FromContext(ctx).Error(err, msg_OpaPolicyPrecompileError, "policy", opa.policyName)
+			return nil, &PolicyCompilationError{Policy: opa.policyName, Err: err}
+		} else {
+			return policy, nil
+		}
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if opa.policyKey != "" {
+		compiledRegoPolicies.release(opa.policyKey)
+	}
 	opa.Rego = newRego
+	opa.policy = policy
+	opa.policyKey = key
+	opa.policyRevision = hash(opa.Rego)
+	return true, nil
+}
 
-	if policy, err := precompilePolicy(opa.opaContext, opa.policyUID, opa.Rego, opa.AllValues); err != nil {
-		opa.Rego = currentRego
-		log.FromContext(ctx).Error(err, msg_OpaPolicyPrecompileError, "policy", opa.policyName)
-		return false, err
-	} else {
-		opa.policy = policy
-		return true, nil
+// decisionIdFor returns the id of the request, so the decision logs can be correlated with the logs of the proxy,
+// or otherwise a random id
+func decisionIdFor(pipeline auth.AuthPipeline) string {
+	if id := pipeline.GetHttp().GetId(); id != "" {
+		return id
 	}
+	return uuid.NewString()
 }
 
 func precompilePolicy(ctx context.Context, policyUID, policyRego string, allValues bool) (*rego.PreparedEvalQuery, error) {
-	policyName := fmt.Sprintf(`authorino.authz["%s"]`, policyUID)
-	policyContent := fmt.Sprintf(policyTemplate, policyName, policyRego)
-	policyFileName := policyUID + ".rego"
+	policyName := policyPackageName(policyUID)
 	queryTemplate := `%s = object.get(data.` + policyName + `, "%s", null)`
 
 	var module *opaParser.Module
 	queries := []string{fmt.Sprintf(queryTemplate, allowQuery, allowQuery)}
 	var err error
 
-	if module, err = opaParser.ParseModule(policyFileName, policyContent); err != nil {
+	if module, err = parsePolicy(policyUID, policyRego); err != nil {
 		return nil, err
 	}
 
@@ -156,6 +201,9 @@ func precompilePolicy(ctx context.Context, policyUID, policyRego string, allValu
 		rules := map[string]interface{}{allowQuery: nil}
 		for _, rule := range module.Rules {
 			name := string(rule.Head.Name)
+			if strings.HasPrefix(name, testRulePrefix) {
+				continue
+			}
 			if _, found := rules[name]; !found {
 				queries = append(queries, fmt.Sprintf(queryTemplate, name, name))
 				rules[name] = nil
@@ -175,15 +223,69 @@ func precompilePolicy(ctx context.Context, policyUID, policyRego string, allValu
 	}
 }
 
+// runPolicyTests runs the tests declared in the Rego document along with the policy, i.e. the rules whose names start
+// with 'test_', the same way as `opa test` would do
+func runPolicyTests(ctx context.Context, policyUID, policyRego string) error {
+	module, err := parsePolicy(policyUID, policyRego)
+	if err != nil {
+		return nil // parsing errors are reported when precompiling the policy
+	}
+
+	hasTests := false
+	for _, rule := range module.Rules {
+		if strings.HasPrefix(string(rule.Head.Name), testRulePrefix) {
+			hasTests = true
+			break
+		}
+	}
+	if !hasTests {
+		return nil
+	}
+
+	results, err := tester.NewRunner().Run(ctx, map[string]*opaParser.Module{policyUID + ".rego": module})
+	if err != nil {
+		return err
+	}
+
+	var failures []string
+	for result := range results {
+		switch {
+		case result.Error != nil:
+			failures = append(failures, fmt.Sprintf("%s: %v", result.Name, result.Error))
+		case result.Fail:
+			failures = append(failures, fmt.Sprintf("%s: fail", result.Name))
+		}
+	}
+	if len(failures) > 0 {
+		return &OPAPolicyTestsError{Failures: failures}
+	}
+
+	return nil
+}
+
+// OPAPolicyTestsError is the error of a Rego policy whose tests do not pass
+type OPAPolicyTestsError struct {
+	Failures []string
+}
+
+func (e *OPAPolicyTestsError) Error() string {
+	return fmt.Sprintf("%s: %s", msg_OpaPolicyTestsFailed, strings.Join(e.Failures, "; "))
+}
+
+func parsePolicy(policyUID, policyRego string) (*opaParser.Module, error) {
+	policyContent := fmt.Sprintf(policyTemplate, policyPackageName(policyUID), policyRego)
+	return opaParser.ParseModule(policyUID+".rego", policyContent)
+}
+
+func policyPackageName(policyUID string) string {
+	return fmt.Sprintf(`authorino.authz["%s"]`, policyUID)
+}
+
 func cleanUpRegoDocument(rego string) string {
 	r, _ := regexp.Compile(`(\s)*package.*[;\n]+`)
 	return r.ReplaceAllString(rego, "")
 }
 
-func generatePolicyUID(policyName string, policyContent string, nonce int) string {
-	return hash(fmt.Sprint(nonce) + policyUIDHashSeparator + policyName + policyUIDHashSeparator + policyContent)
-}
-
 func hash(s string) string {
 	data := []byte(s)
 	return fmt.Sprintf("%x", sha256.Sum256(data))
@@ -211,9 +313,7 @@ func (ext *OPAExternalSource) downloadRegoDataFromUrl() (string, error) {
 		return "", err
 	}
 
-	otel.GetTextMapPropagator().Inject(req.Context(), otel_propagation.HeaderCarrier(req.Header))
-
-	if resp, err := http.DefaultClient.Do(req); err != nil {
+	if resp, err := trace.HTTPClient.Do(req); err != nil {
 		return "", fmt.Errorf("failed to fetch Rego config: %v", err)
 	} else {
 		defer resp.Body.Close()
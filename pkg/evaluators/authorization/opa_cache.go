@@ -0,0 +1,83 @@
+package authorization
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/open-policy-agent/opa/rego"
+)
+
+// compiledRegoPolicies is the process-wide cache of compiled Rego policies, shared by all OPA evaluators, so identical
+// policies (e.g. the same policy copied in many AuthConfigs) compile only once and the memory does not grow with the
+// number of copies
+var compiledRegoPolicies = newRegoPolicyCache()
+
+// regoPolicyCache holds compiled Rego policies keyed by the hash of their source.
+// Each policy is counted the references of the evaluators using it, and dropped from the cache when the last reference
+// is released.
+// Policies compile outside the lock of the cache, so compiling a large policy does not hold the acquisition of the
+// others; concurrent acquisitions of a policy being compiled wait for the same compilation.
+type regoPolicyCache struct {
+	policies map[string]*cachedRegoPolicy
+	mu       sync.Mutex
+}
+
+type cachedRegoPolicy struct {
+	policy *rego.PreparedEvalQuery
+	err    error
+	// closed once the policy is compiled
+	compiled chan struct{}
+	refs     int
+}
+
+func newRegoPolicyCache() *regoPolicyCache {
+	return &regoPolicyCache{policies: make(map[string]*cachedRegoPolicy)}
+}
+
+// acquire returns the compiled policy cached for a key, or otherwise compiles and caches it.
+// Every successful call must be matched by a call to release once the policy is no longer in use.
+func (c *regoPolicyCache) acquire(key string, compile func() (*rego.PreparedEvalQuery, error)) (*rego.PreparedEvalQuery, error) {
+	c.mu.Lock()
+	if cached, found := c.policies[key]; found {
+		cached.refs++
+		c.mu.Unlock()
+		// failed compilations are dropped from the cache along with the references of the acquisitions waiting for them
+		<-cached.compiled
+		return cached.policy, cached.err
+	}
+	cached := &cachedRegoPolicy{compiled: make(chan struct{}), refs: 1}
+	c.policies[key] = cached
+	c.mu.Unlock()
+
+	cached.policy, cached.err = compile()
+	if cached.err != nil {
+		c.mu.Lock()
+		delete(c.policies, key)
+		c.mu.Unlock()
+	}
+	close(cached.compiled)
+	return cached.policy, cached.err
+}
+
+// release drops a reference to the policy cached for a key
+func (c *regoPolicyCache) release(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cached, found := c.policies[key]; found {
+		if cached.refs--; cached.refs <= 0 {
+			delete(c.policies, key)
+		}
+	}
+}
+
+func (c *regoPolicyCache) size() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.policies)
+}
+
+// regoPolicyKey identifies a compiled policy by its source and the queries it is compiled with
+func regoPolicyKey(rego string, allValues bool) string {
+	return hash(strconv.FormatBool(allValues) + policyUIDHashSeparator + rego)
+}
@@ -0,0 +1,203 @@
+package authorization
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/log"
+	"github.com/kuadrant/authorino/pkg/workers"
+)
+
+const (
+	opaDecisionLogsBundleName = "authorino"
+	opaDecisionLogsPathPrefix = "authorino/authz/"
+
+	DefaultOPADecisionLogsFlushInterval = 10
+	DefaultOPADecisionLogsBatchSize     = 100
+
+	msg_opaDecisionLogsWriteError = "failed to write opa decision logs"
+	msg_opaDecisionLogsSendError  = "failed to send opa decision logs"
+)
+
+// OPADecisionLogs is the sink of the decision logs of all OPA policies; decision logs are disabled if nil
+var OPADecisionLogs OPADecisionLogger
+
+// OPADecisionLog is a decision log entry in the format of the decision logs of Open Policy Agent
+// https://www.openpolicyagent.org/docs/latest/management-decision-logs
+type OPADecisionLog struct {
+	Labels     map[string]string               `json:"labels"`
+	DecisionID string                          `json:"decision_id"`
+	Bundles    map[string]OPADecisionLogBundle `json:"bundles,omitempty"`
+	Path       string                          `json:"path"`
+	Input      interface{}                     `json:"input"`
+	Result     interface{}                     `json:"result,omitempty"`
+	Error      string                          `json:"error,omitempty"`
+	Timestamp  time.Time                       `json:"timestamp"`
+	Metrics    map[string]interface{}          `json:"metrics,omitempty"`
+}
+
+type OPADecisionLogBundle struct {
+	Revision string `json:"revision"`
+}
+
+type OPADecisionLogger interface {
+	Log(OPADecisionLog)
+}
+
+func newOPADecisionLog(decisionID, policyName, revision string, input, result interface{}, err error, evalDuration time.Duration) OPADecisionLog {
+	decisionLog := OPADecisionLog{
+		Labels:     map[string]string{"id": opaDecisionLogsBundleName, "policy": policyName},
+		DecisionID: decisionID,
+		Bundles:    map[string]OPADecisionLogBundle{opaDecisionLogsBundleName: {Revision: revision}},
+		Path:       opaDecisionLogsPathPrefix + policyName,
+		Input:      input,
+		Result:     result,
+		Timestamp:  time.Now().UTC(),
+		Metrics:    map[string]interface{}{"timer_rego_query_eval_ns": evalDuration.Nanoseconds()},
+	}
+	if err != nil {
+		decisionLog.Error = err.Error()
+	}
+	return decisionLog
+}
+
+// NewOPADecisionLogFileSink returns a sink that appends the decision logs to a file, one JSON entry per line
+func NewOPADecisionLogFileSink(path string) (*OPADecisionLogFileSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &OPADecisionLogFileSink{
+		writer: file,
+		logger: log.WithName("opa").WithName("decisionlogs"),
+	}, nil
+}
+
+type OPADecisionLogFileSink struct {
+	writer io.Writer
+	logger log.Logger
+	mu     sync.Mutex
+}
+
+func (s *OPADecisionLogFileSink) Log(decisionLog OPADecisionLog) {
+	entry, err := json.Marshal(decisionLog)
+	if err != nil {
+		s.logger.Error(err, msg_opaDecisionLogsWriteError)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.writer.Write(append(entry, '\n')); err != nil {
+		s.logger.Error(err, msg_opaDecisionLogsWriteError)
+	}
+}
+
+// NewOPADecisionLogHTTPSink returns a sink that sends the decision logs in batches to an HTTP endpoint, the same way OPA
+// uploads decision logs to a remote service, i.e. as gzip-compressed JSON arrays of entries.
+// The batches are sent at every flush interval (in seconds) or whenever reaching the batch size.
+func NewOPADecisionLogHTTPSink(ctx context.Context, endpoint string, flushInterval, batchSize int) (*OPADecisionLogHTTPSink, error) {
+	if flushInterval <= 0 {
+		flushInterval = DefaultOPADecisionLogsFlushInterval
+	}
+	if batchSize <= 0 {
+		batchSize = DefaultOPADecisionLogsBatchSize
+	}
+
+	s := &OPADecisionLogHTTPSink{
+		Endpoint:  endpoint,
+		BatchSize: batchSize,
+		client:    &http.Client{Timeout: 10 * time.Second},
+		logger:    log.FromContext(ctx).WithName("opa").WithName("decisionlogs"),
+	}
+
+	var err error
+	if s.flusher, err = workers.StartWorker(ctx, flushInterval, s.flush); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+type OPADecisionLogHTTPSink struct {
+	Endpoint  string
+	BatchSize int
+
+	client  *http.Client
+	logger  log.Logger
+	flusher workers.Worker
+	buffer  []OPADecisionLog
+	mu      sync.Mutex
+}
+
+func (s *OPADecisionLogHTTPSink) Log(decisionLog OPADecisionLog) {
+	s.mu.Lock()
+	s.buffer = append(s.buffer, decisionLog)
+	full := len(s.buffer) >= s.BatchSize
+	s.mu.Unlock()
+
+	if full {
+		go s.flush()
+	}
+}
+
+// Stop sends the decision logs still in the buffer and stops flushing periodically
+func (s *OPADecisionLogHTTPSink) Stop() error {
+	s.flush()
+	return s.flusher.Stop()
+}
+
+func (s *OPADecisionLogHTTPSink) flush() {
+	s.mu.Lock()
+	batch := s.buffer
+	s.buffer = nil
+	s.mu.Unlock()
+
+	if len(batch) == 0 {
+		return
+	}
+
+	if err := s.send(batch); err != nil {
+		s.logger.Error(err, msg_opaDecisionLogsSendError, "endpoint", s.Endpoint, "dropped", len(batch))
+	} else {
+		s.logger.V(1).Info("opa decision logs sent", "endpoint", s.Endpoint, "count", len(batch))
+	}
+}
+
+func (s *OPADecisionLogHTTPSink) send(batch []OPADecisionLog) error {
+	var body bytes.Buffer
+	gzipWriter := gzip.NewWriter(&body)
+	if err := json.NewEncoder(gzipWriter).Encode(batch); err != nil {
+		return err
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.Endpoint, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package authorization
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"net/http"
+	gohttptest "net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	mock_auth "github.com/kuadrant/authorino/pkg/auth/mocks"
+
+	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"github.com/golang/mock/gomock"
+	"gotest.tools/assert"
+)
+
+type opaDecisionLogsMock struct {
+	logs []OPADecisionLog
+}
+
+func (l *opaDecisionLogsMock) Log(decisionLog OPADecisionLog) {
+	l.logs = append(l.logs, decisionLog)
+}
+
+func TestOPADecisionLogs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	opa, err := NewOPAAuthorization("ns/authconfig/opa", opaInlineRegoDataMock, &OPAExternalSource{}, false, context.TODO())
+	assert.NilError(t, err)
+
+	decisionLogs := &opaDecisionLogsMock{}
+	opa.DecisionLogger = decisionLogs
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{"context":{"request":{"http":{"method":"GET","path":"/deny"}}}}`)
+	pipelineMock.EXPECT().GetHttp().Return(&envoy_auth.AttributeContext_HttpRequest{Id: "req-123"})
+
+	_, err = opa.Call(pipelineMock, context.TODO())
+	assert.Error(t, err, unauthorizedErrorMsg)
+
+	assert.Equal(t, len(decisionLogs.logs), 1)
+	decisionLog := decisionLogs.logs[0]
+	assert.Equal(t, decisionLog.DecisionID, "req-123")
+	assert.Equal(t, decisionLog.Path, "authorino/authz/ns/authconfig/opa")
+	assert.Equal(t, decisionLog.Labels["policy"], "ns/authconfig/opa")
+	assert.Equal(t, decisionLog.Bundles["authorino"].Revision, hash(opa.Rego))
+	assert.Equal(t, decisionLog.Error, "")
+	result, _ := json.Marshal(decisionLog.Result)
+	assert.Equal(t, string(result), `{"allow":false}`)
+}
+
+func TestOPADecisionLogFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "decisions.log")
+	sink, err := NewOPADecisionLogFileSink(path)
+	assert.NilError(t, err)
+
+	sink.Log(newOPADecisionLog("1", "ns/authconfig/opa", "abc", map[string]interface{}{"a": 1}, map[string]interface{}{"allow": true}, nil, time.Millisecond))
+	sink.Log(newOPADecisionLog("2", "ns/authconfig/opa", "abc", map[string]interface{}{"a": 2}, nil, nil, time.Millisecond))
+
+	file, err := os.Open(path)
+	assert.NilError(t, err)
+	defer file.Close()
+
+	var ids []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		assert.NilError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		ids = append(ids, entry["decision_id"].(string))
+	}
+	assert.DeepEqual(t, ids, []string{"1", "2"})
+}
+
+func TestOPADecisionLogHTTPSink(t *testing.T) {
+	received := make(chan []OPADecisionLog, 1)
+	server := gohttptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, req.Header.Get("Content-Encoding"), "gzip")
+		body, err := gzip.NewReader(req.Body)
+		assert.NilError(t, err)
+		var batch []OPADecisionLog
+		assert.NilError(t, json.NewDecoder(body).Decode(&batch))
+		received <- batch
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sink, err := NewOPADecisionLogHTTPSink(context.TODO(), server.URL+"/logs", 3600, 2)
+	assert.NilError(t, err)
+	defer sink.Stop()
+
+	sink.Log(newOPADecisionLog("1", "ns/authconfig/opa", "abc", nil, nil, nil, time.Millisecond))
+	sink.Log(newOPADecisionLog("2", "ns/authconfig/opa", "abc", nil, nil, nil, time.Millisecond)) // reaches the batch size
+
+	select {
+	case batch := <-received:
+		assert.Equal(t, len(batch), 2)
+		assert.Equal(t, batch[0].DecisionID, "1")
+		assert.Equal(t, batch[1].DecisionID, "2")
+	case <-time.After(5 * time.Second):
+		t.Fatal("decision logs not sent")
+	}
+}
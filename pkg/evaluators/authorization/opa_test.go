@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -28,7 +30,7 @@ const (
 )
 
 func TestOPAInlineRego(t *testing.T) {
-	opa, err := NewOPAAuthorization("test-opa", opaInlineRegoDataMock, &OPAExternalSource{}, false, 0, context.TODO())
+	opa, err := NewOPAAuthorization("test-opa", opaInlineRegoDataMock, &OPAExternalSource{}, false, context.TODO())
 
 	assert.NilError(t, err)
 	assertOPAAuthorization(t, opa)
@@ -47,7 +49,7 @@ func TestOPAExternalUrl(t *testing.T) {
 		AuthCredentials: auth.NewAuthCredential("", ""),
 	}
 
-	opa, err := NewOPAAuthorization("test-opa", "", externalSource, false, 0, context.TODO())
+	opa, err := NewOPAAuthorization("test-opa", "", externalSource, false, context.TODO())
 
 	assert.NilError(t, err)
 	assertOPAAuthorization(t, opa)
@@ -66,7 +68,7 @@ func TestOPAInlineRegoAndExternalUrl(t *testing.T) {
 		AuthCredentials: auth.NewAuthCredential("", ""),
 	}
 
-	opa, err := NewOPAAuthorization("test-opa", opaInlineRegoDataMock, externalSource, false, 0, context.TODO())
+	opa, err := NewOPAAuthorization("test-opa", opaInlineRegoDataMock, externalSource, false, context.TODO())
 
 	assert.NilError(t, err)
 	assertOPAAuthorization(t, opa)
@@ -74,7 +76,7 @@ func TestOPAInlineRegoAndExternalUrl(t *testing.T) {
 
 func TestOPAWithPackageInRego(t *testing.T) {
 	inlineRego := fmt.Sprintf("package my-rego-123\n%s", opaInlineRegoDataMock)
-	opa, err := NewOPAAuthorization("test-opa", inlineRego, &OPAExternalSource{}, false, 0, context.TODO())
+	opa, err := NewOPAAuthorization("test-opa", inlineRego, &OPAExternalSource{}, false, context.TODO())
 
 	assert.NilError(t, err)
 	assert.Assert(t, !strings.Contains(opa.Rego, "package"))
@@ -97,7 +99,7 @@ func TestOPAExternalUrlJsonResponse(t *testing.T) {
 		AuthCredentials: auth.NewAuthCredential("", ""),
 	}
 
-	opa, err := NewOPAAuthorization("test-opa", "", externalSource, false, 0, context.TODO())
+	opa, err := NewOPAAuthorization("test-opa", "", externalSource, false, context.TODO())
 
 	assert.NilError(t, err)
 	assert.Assert(t, !strings.Contains(opa.Rego, "package"))
@@ -118,7 +120,7 @@ func TestOPAExternalUrlMissingContentType(t *testing.T) {
 		AuthCredentials: auth.NewAuthCredential("", ""),
 	}
 
-	opa, err := NewOPAAuthorization("test-opa", "", externalSource, false, 0, context.TODO())
+	opa, err := NewOPAAuthorization("test-opa", "", externalSource, false, context.TODO())
 
 	assert.NilError(t, err)
 	assertOPAAuthorization(t, opa)
@@ -147,7 +149,7 @@ func TestOPAExternalUrlWithTTL(t *testing.T) {
 		TTL:             3,
 	}
 
-	opa, err := NewOPAAuthorization("test-opa", "", externalSource, false, 0, context.TODO())
+	opa, err := NewOPAAuthorization("test-opa", "", externalSource, false, context.TODO())
 	defer opa.Clean(context.Background())
 
 	assert.NilError(t, err)
@@ -163,7 +165,7 @@ func TestOPAClean(t *testing.T) {
 	defer ctrl.Finish()
 
 	refresher := mock_workers.NewMockWorker(ctrl)
-	opa, _ := NewOPAAuthorization("test-opa", "", nil, false, 0, context.TODO())
+	opa, _ := NewOPAAuthorization("test-opa", "", nil, false, context.TODO())
 	opa.ExternalSource = &OPAExternalSource{
 		Endpoint:        "http://" + opaExtHttpServerMockAddr + "/rego",
 		AuthCredentials: auth.NewAuthCredential("", ""),
@@ -174,6 +176,82 @@ func TestOPAClean(t *testing.T) {
 	assert.NilError(t, err)
 }
 
+func TestOPASharedCompiledPolicy(t *testing.T) {
+	const inlineRego = `allow { input.context.request.http.method == "PATCH" }`
+	cached := compiledRegoPolicies.size()
+
+	opa1, err := NewOPAAuthorization("ns-1/authconfig/opa", inlineRego, &OPAExternalSource{}, false, context.TODO())
+	assert.NilError(t, err)
+	opa2, err := NewOPAAuthorization("ns-2/authconfig/opa", inlineRego, &OPAExternalSource{}, false, context.TODO())
+	assert.NilError(t, err)
+	opa3, err := NewOPAAuthorization("ns-3/authconfig/opa", inlineRego, &OPAExternalSource{}, true, context.TODO())
+	assert.NilError(t, err)
+
+	assert.Check(t, opa1.policy == opa2.policy)
+	assert.Check(t, opa1.policy != opa3.policy) // compiled with other queries
+	assert.Equal(t, compiledRegoPolicies.size(), cached+2)
+
+	assert.NilError(t, opa1.Clean(context.TODO()))
+	assert.Equal(t, compiledRegoPolicies.size(), cached+2)
+	assert.NilError(t, opa2.Clean(context.TODO()))
+	assert.NilError(t, opa3.Clean(context.TODO()))
+	assert.Equal(t, compiledRegoPolicies.size(), cached)
+}
+
+func TestRegoPolicyCacheConcurrentCompilation(t *testing.T) {
+	cache := newRegoPolicyCache()
+
+	var compilations int32
+	release := make(chan struct{})
+	slowPolicy := &rego.PreparedEvalQuery{}
+	compileSlow := func() (*rego.PreparedEvalQuery, error) {
+		atomic.AddInt32(&compilations, 1)
+		<-release
+		return slowPolicy, nil
+	}
+
+	var waitGroup sync.WaitGroup
+	policies := make(chan *rego.PreparedEvalQuery, 2)
+	for i := 0; i < 2; i++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			policy, err := cache.acquire("slow", compileSlow)
+			assert.Check(t, err == nil)
+			policies <- policy
+		}()
+	}
+
+	// other policies are acquired while the slow one compiles
+	for atomic.LoadInt32(&compilations) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	fastPolicy := &rego.PreparedEvalQuery{}
+	policy, err := cache.acquire("fast", func() (*rego.PreparedEvalQuery, error) { return fastPolicy, nil })
+	assert.NilError(t, err)
+	assert.Check(t, policy == fastPolicy)
+
+	close(release)
+	waitGroup.Wait()
+	close(policies)
+	for policy := range policies {
+		assert.Check(t, policy == slowPolicy)
+	}
+	assert.Equal(t, atomic.LoadInt32(&compilations), int32(1))
+	assert.Equal(t, cache.size(), 2)
+
+	// failed compilations are not cached
+	_, err = cache.acquire("invalid", func() (*rego.PreparedEvalQuery, error) { return nil, fmt.Errorf("invalid policy") })
+	assert.Error(t, err, "invalid policy")
+	assert.Equal(t, cache.size(), 2)
+
+	cache.release("slow")
+	assert.Equal(t, cache.size(), 2)
+	cache.release("slow")
+	cache.release("fast")
+	assert.Equal(t, cache.size(), 0)
+}
+
 func TestOPAAllValues(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -181,7 +259,7 @@ func TestOPAAllValues(t *testing.T) {
 	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
 	pipelineMock.EXPECT().GetAuthorizationJSON().Return(opaAuthDataMock("/allow", "GET")).Times(1)
 
-	opa, _ := NewOPAAuthorization("test-opa", opaInlineRegoDataMock, &OPAExternalSource{}, true, 0, context.TODO())
+	opa, _ := NewOPAAuthorization("test-opa", opaInlineRegoDataMock, &OPAExternalSource{}, true, context.TODO())
 
 	results, err := opa.Call(pipelineMock, nil)
 	resultSet, _ := results.(rego.Vars)
@@ -204,7 +282,7 @@ func TestOPANonBooleanAllowed(t *testing.T) {
 	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
 	pipelineMock.EXPECT().GetAuthorizationJSON().Return(opaAuthDataMock("/allow", "GET")).Times(1)
 
-	opa, _ := NewOPAAuthorization("test-opa", `allow = "foo"`, &OPAExternalSource{}, false, 0, context.TODO())
+	opa, _ := NewOPAAuthorization("test-opa", `allow = "foo"`, &OPAExternalSource{}, false, context.TODO())
 
 	results, err := opa.Call(pipelineMock, nil)
 	resultSet, _ := results.(rego.Vars)
@@ -214,6 +292,44 @@ func TestOPANonBooleanAllowed(t *testing.T) {
 	assert.ErrorContains(t, err, "Unauthorized")
 }
 
+func TestOPAPolicyTests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inlineRego := opaInlineRegoDataMock + `
+		test_allow_get { allow with input as {"context": {"request": {"http": {"method": "GET", "path": "/allow"}}}} }
+		test_deny_post { not allow with input as {"context": {"request": {"http": {"method": "POST", "path": "/allow"}}}} }`
+
+	opa, err := NewOPAAuthorization("test-opa", inlineRego, &OPAExternalSource{}, true, context.TODO())
+	assert.NilError(t, err)
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(opaAuthDataMock("/allow", "GET"))
+
+	results, err := opa.Call(pipelineMock, nil)
+	assert.NilError(t, err)
+	_, testRuleFound := results.(rego.Vars)["test_allow_get"]
+	assert.Assert(t, !testRuleFound)
+}
+
+func TestOPAPolicyTestsFailing(t *testing.T) {
+	inlineRego := opaInlineRegoDataMock + `
+		test_allow_get { allow with input as {"context": {"request": {"http": {"method": "GET", "path": "/allow"}}}} }
+		test_allow_post { allow with input as {"context": {"request": {"http": {"method": "POST", "path": "/allow"}}}} }`
+
+	_, err := NewOPAAuthorization("test-opa", inlineRego, &OPAExternalSource{}, false, context.TODO())
+	assert.Error(t, err, "policy tests failed: test_allow_post: fail")
+	_, ok := err.(*OPAPolicyTestsError)
+	assert.Assert(t, ok)
+}
+
+func TestOPAInvalidRego(t *testing.T) {
+	_, err := NewOPAAuthorization("test-opa", `allow { input.context.request.http.method == }`, &OPAExternalSource{}, false, context.TODO())
+	assert.ErrorContains(t, err, "failed to compile policy test-opa")
+	_, ok := err.(*PolicyCompilationError)
+	assert.Assert(t, ok)
+}
+
 func assertOPAAuthorization(t *testing.T, opa *OPA) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -267,7 +383,7 @@ func BenchmarkOPAAuthz(b *testing.B) {
 
 	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
 	pipelineMock.EXPECT().GetAuthorizationJSON().Return(opaAuthDataMock("/allow", "GET")).MinTimes(1)
-	opa, _ := NewOPAAuthorization("test-opa", opaInlineRegoDataMock, &OPAExternalSource{}, false, 0, context.TODO())
+	opa, _ := NewOPAAuthorization("test-opa", opaInlineRegoDataMock, &OPAExternalSource{}, false, context.TODO())
 
 	var err error
 	b.ResetTimer()
@@ -0,0 +1,204 @@
+package authorization
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/auth"
+	"github.com/kuadrant/authorino/pkg/json"
+)
+
+const (
+	quotaKeySeparator = "|"
+
+	msg_quotaExceeded = "quota exceeded"
+)
+
+// QuotaCounterStore keeps the counters of requests of the quota authorization.
+// Counters are bucketed in fixed windows; the sliding window is estimated out of the current and the previous buckets.
+type QuotaCounterStore interface {
+	// Hit counts one request for the key in the bucket of the given window and returns the number of requests
+	// counted for the key in the current bucket (including this one) and in the previous bucket.
+	Hit(ctx context.Context, key string, window int64, windowLength time.Duration) (current, previous int64, err error)
+}
+
+// NewQuotaAuthorization returns a quota authorization policy.
+// Without a store, the counters are kept in memory, in a store shared by all the policies with the same name (i.e.
+// the same AuthConfig and rule), so the counters survive the reconciliation of the AuthConfig.
+// Fails if the window is not positive or the limit is negative, closing the store, if any.
+func NewQuotaAuthorization(policyName string, key json.JSONValue, limit int64, window time.Duration, store QuotaCounterStore) (*Quota, error) {
+	if window <= 0 || limit < 0 {
+		if closer, ok := store.(io.Closer); ok {
+			_ = closer.Close()
+		}
+		return nil, fmt.Errorf("invalid quota %s: the window must be positive and the limit must not be negative", policyName)
+	}
+
+	quota := &Quota{
+		Key:        key,
+		Limit:      limit,
+		Window:     window,
+		Store:      store,
+		policyName: policyName,
+		now:        time.Now,
+	}
+
+	if store == nil {
+		quota.Store = inMemoryQuotaCounterStores.acquire(policyName)
+		quota.release = func() { inMemoryQuotaCounterStores.release(policyName) }
+	}
+
+	return quota, nil
+}
+
+// Quota authorizes requests while the number of requests counted for the same key within a sliding window does not
+// exceed the limit.
+type Quota struct {
+	Key    json.JSONValue
+	Limit  int64
+	Window time.Duration
+	Store  QuotaCounterStore
+
+	policyName  string
+	now         func() time.Time
+	release     func()
+	releaseOnce sync.Once
+}
+
+type quotaStatus struct {
+	Limit     int64 `json:"limit"`
+	Remaining int64 `json:"remaining"`
+	Reset     int64 `json:"reset"`
+}
+
+func (q *Quota) Call(pipeline auth.AuthPipeline, ctx context.Context) (interface{}, error) {
+	key := q.policyName + quotaKeySeparator + fmt.Sprintf("%v", q.Key.ResolveFor(pipeline.GetAuthorizationJSON()))
+
+	now := q.now()
+	window := now.UnixNano() / int64(q.Window)
+	elapsed := time.Duration(now.UnixNano() % int64(q.Window))
+
+	current, previous, err := q.Store.Hit(ctx, key, window, q.Window)
+	if err != nil {
+		return nil, err
+	}
+
+	// weighs the previous bucket by how much of it still overlaps with the sliding window
+	overlap := 1 - float64(elapsed)/float64(q.Window)
+	count := int64(math.Floor(float64(previous)*overlap)) + current
+
+	if count > q.Limit {
+		return nil, fmt.Errorf(msg_quotaExceeded)
+	}
+
+	return &quotaStatus{
+		Limit:     q.Limit,
+		Remaining: q.Limit - count,
+		Reset:     int64((q.Window - elapsed).Seconds()),
+	}, nil
+}
+
+// Clean releases the resources held by the store of counters, if any.
+// Shared in-memory stores are dropped only when no longer used by any policy.
+func (q *Quota) Clean(_ context.Context) error {
+	if q.release != nil {
+		q.releaseOnce.Do(q.release)
+		return nil
+	}
+	if closer, ok := q.Store.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// inMemoryQuotaCounterStores are the in-memory stores of counters of the quota policies, by policy name
+var inMemoryQuotaCounterStores = &sharedQuotaCounterStores{stores: make(map[string]*sharedQuotaCounterStore)}
+
+type sharedQuotaCounterStores struct {
+	stores map[string]*sharedQuotaCounterStore
+	mu     sync.Mutex
+}
+
+type sharedQuotaCounterStore struct {
+	QuotaCounterStore
+	refs int
+}
+
+// acquire returns the in-memory store of counters of a policy, created if missing
+func (s *sharedQuotaCounterStores) acquire(policyName string) QuotaCounterStore {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	store, ok := s.stores[policyName]
+	if !ok {
+		store = &sharedQuotaCounterStore{QuotaCounterStore: NewInMemoryQuotaCounterStore()}
+		s.stores[policyName] = store
+	}
+	store.refs++
+	return store.QuotaCounterStore
+}
+
+// release drops the in-memory store of counters of a policy once no longer used
+func (s *sharedQuotaCounterStores) release(policyName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	store, ok := s.stores[policyName]
+	if !ok {
+		return
+	}
+	if store.refs--; store.refs <= 0 {
+		delete(s.stores, policyName)
+	}
+}
+
+// NewInMemoryQuotaCounterStore returns a store of quota counters local to the Authorino instance
+func NewInMemoryQuotaCounterStore() QuotaCounterStore {
+	return &inMemoryQuotaCounterStore{
+		buckets: make(map[string]map[int64]int64),
+	}
+}
+
+type inMemoryQuotaCounterStore struct {
+	buckets   map[string]map[int64]int64
+	lastPrune int64
+	mu        sync.Mutex
+}
+
+func (s *inMemoryQuotaCounterStore) Hit(_ context.Context, key string, window int64, _ time.Duration) (current, previous int64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if window > s.lastPrune {
+		s.prune(window)
+	}
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = make(map[int64]int64)
+		s.buckets[key] = bucket
+	}
+
+	bucket[window]++
+
+	return bucket[window], bucket[window-1], nil
+}
+
+// prune drops the buckets that no longer count for the sliding window
+func (s *inMemoryQuotaCounterStore) prune(window int64) {
+	for key, bucket := range s.buckets {
+		for w := range bucket {
+			if w < window-1 {
+				delete(bucket, w)
+			}
+		}
+		if len(bucket) == 0 {
+			delete(s.buckets, key)
+		}
+	}
+	s.lastPrune = window
+}
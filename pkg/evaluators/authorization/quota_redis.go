@@ -0,0 +1,51 @@
+package authorization
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const redisQuotaKeyPrefix = "authorino:quota:"
+
+// NewRedisQuotaCounterStore returns a store of quota counters kept in a Redis server, shared among Authorino instances.
+// Connections are pooled, established lazily and re-established after failures by the Redis client.
+func NewRedisQuotaCounterStore(address, password string, db int) QuotaCounterStore {
+	return &redisQuotaCounterStore{
+		client: redis.NewClient(&redis.Options{
+			Addr:     address,
+			Password: password,
+			DB:       db,
+		}),
+	}
+}
+
+type redisQuotaCounterStore struct {
+	client *redis.Client
+}
+
+func (s *redisQuotaCounterStore) Hit(ctx context.Context, key string, window int64, windowLength time.Duration) (current, previous int64, err error) {
+	currentKey := redisQuotaKeyPrefix + key + quotaKeySeparator + strconv.FormatInt(window, 10)
+	previousKey := redisQuotaKeyPrefix + key + quotaKeySeparator + strconv.FormatInt(window-1, 10)
+
+	pipe := s.client.Pipeline()
+	incr := pipe.Incr(ctx, currentKey)
+	pipe.PExpire(ctx, currentKey, 2*windowLength)
+	get := pipe.Get(ctx, previousKey)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return 0, 0, err
+	}
+
+	if previous, err = get.Int64(); err != nil && err != redis.Nil {
+		return 0, 0, err
+	}
+
+	return incr.Val(), previous, nil
+}
+
+// Close closes the connections to the Redis server
+func (s *redisQuotaCounterStore) Close() error {
+	return s.client.Close()
+}
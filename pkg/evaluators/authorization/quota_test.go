@@ -0,0 +1,258 @@
+package authorization
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	mock_auth "github.com/kuadrant/authorino/pkg/auth/mocks"
+	"github.com/kuadrant/authorino/pkg/json"
+
+	. "github.com/golang/mock/gomock"
+	"gotest.tools/assert"
+)
+
+func TestQuotaWithinLimit(t *testing.T) {
+	ctrl := NewController(t)
+	defer ctrl.Finish()
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{"auth":{"identity":{"sub":"john"}}}`).AnyTimes()
+
+	quota, err := NewQuotaAuthorization("ns/authconfig/quota", json.JSONValue{Pattern: "auth.identity.sub"}, 2, time.Minute, nil)
+	assert.NilError(t, err)
+	defer quota.Clean(context.TODO())
+	quota.now = func() time.Time { return time.Unix(600, 0) }
+
+	obj, err := quota.Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+	status, _ := obj.(*quotaStatus)
+	assert.Equal(t, status.Limit, int64(2))
+	assert.Equal(t, status.Remaining, int64(1))
+	assert.Equal(t, status.Reset, int64(60))
+
+	_, err = quota.Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+
+	_, err = quota.Call(pipelineMock, context.TODO())
+	assert.Error(t, err, msg_quotaExceeded)
+}
+
+func TestQuotaPerKey(t *testing.T) {
+	ctrl := NewController(t)
+	defer ctrl.Finish()
+
+	quota, err := NewQuotaAuthorization("ns/authconfig/quota", json.JSONValue{Pattern: "auth.identity.sub"}, 1, time.Minute, nil)
+	assert.NilError(t, err)
+	defer quota.Clean(context.TODO())
+	quota.now = func() time.Time { return time.Unix(600, 0) }
+
+	john := mock_auth.NewMockAuthPipeline(ctrl)
+	john.EXPECT().GetAuthorizationJSON().Return(`{"auth":{"identity":{"sub":"john"}}}`).AnyTimes()
+	jane := mock_auth.NewMockAuthPipeline(ctrl)
+	jane.EXPECT().GetAuthorizationJSON().Return(`{"auth":{"identity":{"sub":"jane"}}}`).AnyTimes()
+
+	_, err = quota.Call(john, context.TODO())
+	assert.NilError(t, err)
+	_, err = quota.Call(jane, context.TODO())
+	assert.NilError(t, err)
+	_, err = quota.Call(john, context.TODO())
+	assert.Error(t, err, msg_quotaExceeded)
+}
+
+func TestQuotaSlidingWindow(t *testing.T) {
+	ctrl := NewController(t)
+	defer ctrl.Finish()
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{"auth":{"identity":{"sub":"john"}}}`).AnyTimes()
+
+	quota, err := NewQuotaAuthorization("ns/authconfig/quota", json.JSONValue{Pattern: "auth.identity.sub"}, 4, time.Minute, nil)
+	assert.NilError(t, err)
+	defer quota.Clean(context.TODO())
+
+	// 4 requests at the end of the first window
+	quota.now = func() time.Time { return time.Unix(659, 0) }
+	for i := 0; i < 4; i++ {
+		_, err := quota.Call(pipelineMock, context.TODO())
+		assert.NilError(t, err)
+	}
+
+	// the previous window still weighs 3/4 of the sliding window
+	quota.now = func() time.Time { return time.Unix(675, 0) }
+	_, err = quota.Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+	_, err = quota.Call(pipelineMock, context.TODO())
+	assert.Error(t, err, msg_quotaExceeded)
+
+	// the previous window no longer counts
+	quota.now = func() time.Time { return time.Unix(780, 0) }
+	_, err = quota.Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+}
+
+func TestQuotaCountersSurviveReconciliation(t *testing.T) {
+	ctrl := NewController(t)
+	defer ctrl.Finish()
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{"auth":{"identity":{"sub":"john"}}}`).AnyTimes()
+	now := func() time.Time { return time.Unix(600, 0) }
+
+	quota, err := NewQuotaAuthorization("ns/authconfig/quota", json.JSONValue{Pattern: "auth.identity.sub"}, 1, time.Minute, nil)
+	assert.NilError(t, err)
+	quota.now = now
+	_, err = quota.Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+
+	// the authconfig is reconciled: the new config is built before the previous one is cleaned
+	reconciled, err := NewQuotaAuthorization("ns/authconfig/quota", json.JSONValue{Pattern: "auth.identity.sub"}, 1, time.Minute, nil)
+	assert.NilError(t, err)
+	reconciled.now = now
+	assert.NilError(t, quota.Clean(context.TODO()))
+	assert.NilError(t, quota.Clean(context.TODO())) // no-op
+	_, err = reconciled.Call(pipelineMock, context.TODO())
+	assert.Error(t, err, msg_quotaExceeded)
+
+	// other rule
+	other, err := NewQuotaAuthorization("ns/authconfig/other", json.JSONValue{Pattern: "auth.identity.sub"}, 1, time.Minute, nil)
+	assert.NilError(t, err)
+	other.now = now
+	_, err = other.Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+	assert.NilError(t, other.Clean(context.TODO()))
+
+	// the authconfig is deleted
+	assert.NilError(t, reconciled.Clean(context.TODO()))
+	assert.Equal(t, len(inMemoryQuotaCounterStores.stores), 0)
+}
+
+func TestQuotaInvalidSettings(t *testing.T) {
+	_, err := NewQuotaAuthorization("ns/authconfig/quota", json.JSONValue{Pattern: "auth.identity.sub"}, 1, 0, nil)
+	assert.Error(t, err, "invalid quota ns/authconfig/quota: the window must be positive and the limit must not be negative")
+
+	_, err = NewQuotaAuthorization("ns/authconfig/quota", json.JSONValue{Pattern: "auth.identity.sub"}, -1, time.Minute, nil)
+	assert.Error(t, err, "invalid quota ns/authconfig/quota: the window must be positive and the limit must not be negative")
+
+	assert.Equal(t, len(inMemoryQuotaCounterStores.stores), 0)
+}
+
+func TestInMemoryQuotaCounterStorePrunesOldBuckets(t *testing.T) {
+	store := NewInMemoryQuotaCounterStore().(*inMemoryQuotaCounterStore)
+
+	_, _, _ = store.Hit(context.TODO(), "a", 1, time.Minute)
+	_, _, _ = store.Hit(context.TODO(), "b", 2, time.Minute)
+	assert.Equal(t, len(store.buckets), 2)
+
+	_, _, _ = store.Hit(context.TODO(), "b", 3, time.Minute)
+	assert.Equal(t, len(store.buckets), 1)
+	assert.Equal(t, len(store.buckets["b"]), 2)
+}
+
+func TestRedisQuotaCounterStore(t *testing.T) {
+	redis := newFakeRedisServer(t, "s3cr3t")
+	defer redis.Close()
+
+	store := NewRedisQuotaCounterStore(redis.Addr().String(), "s3cr3t", 0)
+	defer store.(io.Closer).Close()
+
+	current, previous, err := store.Hit(context.TODO(), "john", 10, time.Minute)
+	assert.NilError(t, err)
+	assert.Equal(t, current, int64(1))
+	assert.Equal(t, previous, int64(0))
+
+	current, previous, err = store.Hit(context.TODO(), "john", 11, time.Minute)
+	assert.NilError(t, err)
+	assert.Equal(t, current, int64(1))
+	assert.Equal(t, previous, int64(1))
+
+	unauthenticated := NewRedisQuotaCounterStore(redis.Addr().String(), "wrong", 0)
+	defer unauthenticated.(io.Closer).Close()
+	_, _, err = unauthenticated.Hit(context.TODO(), "john", 11, time.Minute)
+	assert.ErrorContains(t, err, "WRONGPASS")
+}
+
+// fakeRedisServer speaks just enough of the Redis protocol for the quota counter store
+type fakeRedisServer struct {
+	net.Listener
+	password string
+	data     map[string]int64
+	mu       sync.Mutex
+}
+
+func newFakeRedisServer(t *testing.T, password string) *fakeRedisServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &fakeRedisServer{Listener: listener, password: password, data: map[string]int64{}}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+func (s *fakeRedisServer) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	authenticated := s.password == ""
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		args := make([]string, n)
+		for i := range args {
+			_, _ = reader.ReadString('\n')
+			arg, _ := reader.ReadString('\n')
+			args[i] = strings.TrimSuffix(arg, "\r\n")
+		}
+
+		var reply string
+		s.mu.Lock()
+		switch args[0] = strings.ToUpper(args[0]); {
+		case args[0] == "AUTH":
+			if args[1] == s.password {
+				authenticated = true
+				reply = "+OK\r\n"
+			} else {
+				reply = "-WRONGPASS invalid password\r\n"
+			}
+		case !authenticated:
+			reply = "-NOAUTH Authentication required\r\n"
+		case args[0] == "INCR":
+			s.data[args[1]]++
+			reply = fmt.Sprintf(":%d\r\n", s.data[args[1]])
+		case args[0] == "PEXPIRE":
+			reply = ":1\r\n"
+		case args[0] == "GET":
+			if value, ok := s.data[args[1]]; ok {
+				v := strconv.FormatInt(value, 10)
+				reply = fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+			} else {
+				reply = "$-1\r\n"
+			}
+		default:
+			reply = "-ERR unknown command\r\n"
+		}
+		s.mu.Unlock()
+
+		if _, err := conn.Write([]byte(reply)); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package authorization
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/kuadrant/authorino/pkg/auth"
+	"github.com/kuadrant/authorino/pkg/log"
+	"github.com/kuadrant/authorino/pkg/workers"
+
+	"github.com/tidwall/gjson"
+	k8s "k8s.io/api/core/v1"
+	k8s_types "k8s.io/apimachinery/pkg/types"
+	k8s_yaml "k8s.io/apimachinery/pkg/util/yaml"
+	k8s_client "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	rbacWildcard          = "*"
+	rbacRecursiveWildcard = "**"
+
+	DefaultRBACRolesSelector = "auth.identity.roles"
+
+	msg_rbacRulesLoadError                    = "failed to load rbac rules from configmap"
+	msg_rbacRulesRefreshSuccess               = "rbac rules updated from configmap"
+	msg_rbacRulesRefreshFromConfigMapDisabled = "auto-refresh of rbac rules disabled"
+)
+
+// RBACRule grants access to the requests whose method and path match the rule, for any of the roles of the rule
+type RBACRule struct {
+	Roles   []string `json:"roles"`
+	Methods []string `json:"methods,omitempty"`
+	Paths   []string `json:"paths"`
+}
+
+func NewRBACAuthorization(policyName string, rolesSelector string, rules []RBACRule, configMapSource *RBACConfigMapSource, ctx context.Context) (*RBAC, error) {
+	logger := log.FromContext(ctx).WithName("rbac")
+
+	if rolesSelector == "" {
+		rolesSelector = DefaultRBACRolesSelector
+	}
+
+	r := &RBAC{
+		RolesSelector:   rolesSelector,
+		Rules:           rules,
+		ConfigMapSource: configMapSource,
+		policyName:      policyName,
+	}
+
+	if configMapSource != nil {
+		sourcedRules, err := configMapSource.loadRules(ctx)
+		if err != nil {
+			logger.Error(err, msg_rbacRulesLoadError, "policy", policyName, "configmap", configMapSource.Name)
+			return nil, err
+		}
+		r.sourcedRules = sourcedRules
+		configMapSource.setupRefresher(log.IntoContext(ctx, logger), r)
+	}
+
+	return r, nil
+}
+
+// RBAC authorizes requests based on a declarative mapping of roles of the identity to allowed methods and paths
+type RBAC struct {
+	RolesSelector   string
+	Rules           []RBACRule
+	ConfigMapSource *RBACConfigMapSource
+
+	policyName   string
+	sourcedRules []RBACRule
+	mu           sync.RWMutex
+}
+
+func (r *RBAC) Call(pipeline auth.AuthPipeline, _ context.Context) (interface{}, error) {
+	roles := stringListFrom(gjson.Get(pipeline.GetAuthorizationJSON(), r.RolesSelector))
+
+	request := pipeline.GetHttp()
+	method := request.GetMethod()
+	path := strings.SplitN(request.GetPath(), "?", 2)[0]
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, rules := range [][]RBACRule{r.Rules, r.sourcedRules} {
+		for _, rule := range rules {
+			if rule.grants(roles, method, path) {
+				return rule, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf(unauthorizedErrorMsg)
+}
+
+// Clean ensures the goroutine started by ConfigMapSource.setupRefresher is cleaned up
+func (r *RBAC) Clean(_ context.Context) error {
+	if r.ConfigMapSource == nil {
+		return nil
+	}
+
+	return r.ConfigMapSource.cleanupRefresher()
+}
+
+func (r *RBAC) updateSourcedRules(rules []RBACRule) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sourcedRules = rules
+}
+
+func (rule RBACRule) grants(roles []string, method, path string) bool {
+	return rule.matchesRoles(roles) && rule.matchesMethod(method) && rule.matchesPath(path)
+}
+
+func (rule RBACRule) matchesRoles(roles []string) bool {
+	for _, ruleRole := range rule.Roles {
+		if ruleRole == rbacWildcard {
+			return true
+		}
+		for _, role := range roles {
+			if role == ruleRole {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (rule RBACRule) matchesMethod(method string) bool {
+	if len(rule.Methods) == 0 {
+		return true
+	}
+	for _, ruleMethod := range rule.Methods {
+		if ruleMethod == rbacWildcard || strings.EqualFold(ruleMethod, method) {
+			return true
+		}
+	}
+	return false
+}
+
+func (rule RBACRule) matchesPath(path string) bool {
+	for _, pattern := range rule.Paths {
+		if matchRBACPath(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchRBACPath matches a request path against a pattern, segment by segment.
+// '*' matches exactly one segment; '**' as the last segment of the pattern matches any number of trailing segments.
+func matchRBACPath(pattern, path string) bool {
+	patternSegments := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+
+	for i, patternSegment := range patternSegments {
+		if patternSegment == rbacRecursiveWildcard && i == len(patternSegments)-1 {
+			return true
+		}
+		if i >= len(pathSegments) {
+			return false
+		}
+		if patternSegment != rbacWildcard && patternSegment != pathSegments[i] {
+			return false
+		}
+	}
+
+	return len(patternSegments) == len(pathSegments)
+}
+
+// stringListFrom reads a list of values out of either an array of strings or a string of space-separated values
+func stringListFrom(value gjson.Result) []string {
+	if value.IsArray() {
+		var values []string
+		for _, v := range value.Array() {
+			values = append(values, v.String())
+		}
+		return values
+	}
+	return strings.Fields(value.String())
+}
+
+func NewRBACConfigMapSource(name, namespace, key string, ttl int, k8sClient k8s_client.Reader) *RBACConfigMapSource {
+	return &RBACConfigMapSource{
+		Name:      name,
+		Namespace: namespace,
+		Key:       key,
+		TTL:       ttl,
+		k8sClient: k8sClient,
+	}
+}
+
+// RBACConfigMapSource is a key of a Kubernetes ConfigMap that stores RBAC rules, formatted as a YAML or JSON list
+type RBACConfigMapSource struct {
+	Name      string
+	Namespace string
+	Key       string
+	TTL       int
+
+	k8sClient k8s_client.Reader
+	refresher workers.Worker
+}
+
+func (src *RBACConfigMapSource) loadRules(ctx context.Context) ([]RBACRule, error) {
+	configMap := &k8s.ConfigMap{}
+	if err := src.k8sClient.Get(ctx, k8s_types.NamespacedName{Namespace: src.Namespace, Name: src.Name}, configMap); err != nil {
+		return nil, err
+	}
+
+	data, ok := configMap.Data[src.Key]
+	if !ok {
+		return nil, fmt.Errorf("key %s not found in configmap %s/%s", src.Key, src.Namespace, src.Name)
+	}
+
+	var rules []RBACRule
+	if err := k8s_yaml.NewYAMLOrJSONDecoder(strings.NewReader(data), 4096).Decode(&rules); err != nil {
+		return nil, fmt.Errorf("invalid rbac rules in configmap %s/%s: %v", src.Namespace, src.Name, err)
+	}
+
+	return rules, nil
+}
+
+func (src *RBACConfigMapSource) setupRefresher(ctx context.Context, r *RBAC) {
+	logger := log.FromContext(ctx).WithValues("policy", r.policyName, "configmap", src.Name)
+
+	var startErr error
+
+	src.refresher, startErr = workers.StartWorker(ctx, src.TTL, func() {
+		if rules, err := src.loadRules(ctx); err == nil {
+			r.updateSourcedRules(rules)
+			logger.V(1).Info(msg_rbacRulesRefreshSuccess)
+		} else {
+			logger.Error(err, msg_rbacRulesLoadError)
+		}
+	})
+
+	if startErr != nil {
+		logger.V(1).Info(msg_rbacRulesRefreshFromConfigMapDisabled, "reason", startErr)
+	}
+}
+
+func (src *RBACConfigMapSource) cleanupRefresher() error {
+	if src.refresher == nil {
+		return nil
+	}
+	return src.refresher.Stop()
+}
@@ -0,0 +1,103 @@
+package authorization
+
+import (
+	"context"
+	"testing"
+
+	mock_auth "github.com/kuadrant/authorino/pkg/auth/mocks"
+
+	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	. "github.com/golang/mock/gomock"
+	"gotest.tools/assert"
+	k8s "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8s_runtime "k8s.io/apimachinery/pkg/runtime"
+	k8s_fake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRBAC(t *testing.T) {
+	ctrl := NewController(t)
+	defer ctrl.Finish()
+
+	rbac, err := NewRBACAuthorization("ns/authconfig/rbac", "", []RBACRule{
+		{Roles: []string{"admin"}, Paths: []string{"/pets/**"}},
+		{Roles: []string{"*"}, Methods: []string{"GET"}, Paths: []string{"/pets", "/pets/*"}},
+	}, nil, context.TODO())
+	assert.NilError(t, err)
+
+	testCases := []struct {
+		roles  string
+		method string
+		path   string
+		allow  bool
+	}{
+		{`["admin"]`, "DELETE", "/pets/123/photos", true},
+		{`["admin"]`, "GET", "/stores", false},
+		{`["member"]`, "GET", "/pets", true},
+		{`["member"]`, "GET", "/pets/123?details=true", true},
+		{`["member"]`, "GET", "/pets/123/photos", false},
+		{`["member"]`, "POST", "/pets", false},
+		{`"member admin"`, "POST", "/pets", true},
+		{`[]`, "GET", "/pets", true},
+	}
+
+	for _, tc := range testCases {
+		pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+		pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{"auth":{"identity":{"roles":` + tc.roles + `}}}`)
+		pipelineMock.EXPECT().GetHttp().Return(&envoy_auth.AttributeContext_HttpRequest{Method: tc.method, Path: tc.path})
+
+		_, err := rbac.Call(pipelineMock, context.TODO())
+		if tc.allow {
+			assert.NilError(t, err, "%s %s %s", tc.roles, tc.method, tc.path)
+		} else {
+			assert.Error(t, err, unauthorizedErrorMsg, "%s %s %s", tc.roles, tc.method, tc.path)
+		}
+	}
+}
+
+func TestRBACRulesFromConfigMap(t *testing.T) {
+	ctrl := NewController(t)
+	defer ctrl.Finish()
+
+	configMap := &k8s.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "rbac-rules", Namespace: "ns"},
+		Data: map[string]string{
+			"rules.yaml": `- roles: [editor]
+  methods: [PUT]
+  paths: ["/pets/*"]`,
+		},
+	}
+	scheme := k8s_runtime.NewScheme()
+	_ = k8s.AddToScheme(scheme)
+	k8sClient := k8s_fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(configMap).Build()
+
+	source := NewRBACConfigMapSource("rbac-rules", "ns", "rules.yaml", 0, k8sClient)
+	rbac, err := NewRBACAuthorization("ns/authconfig/rbac", "auth.identity.groups", nil, source, context.TODO())
+	assert.NilError(t, err)
+	defer rbac.Clean(context.TODO())
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{"auth":{"identity":{"groups":["editor"]}}}`).AnyTimes()
+	pipelineMock.EXPECT().GetHttp().Return(&envoy_auth.AttributeContext_HttpRequest{Method: "PUT", Path: "/pets/123"}).AnyTimes()
+
+	_, err = rbac.Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+
+	rbac.updateSourcedRules(nil)
+	_, err = rbac.Call(pipelineMock, context.TODO())
+	assert.Error(t, err, unauthorizedErrorMsg)
+
+	_, err = NewRBACAuthorization("ns/authconfig/rbac", "", nil, NewRBACConfigMapSource("missing", "ns", "rules.yaml", 0, k8sClient), context.TODO())
+	assert.ErrorContains(t, err, "not found")
+}
+
+func TestMatchRBACPath(t *testing.T) {
+	assert.Check(t, matchRBACPath("/", "/"))
+	assert.Check(t, matchRBACPath("/**", "/"))
+	assert.Check(t, matchRBACPath("/**", "/pets/123"))
+	assert.Check(t, matchRBACPath("/pets/*/photos", "/pets/123/photos"))
+	assert.Check(t, !matchRBACPath("/pets/*/photos", "/pets/photos"))
+	assert.Check(t, !matchRBACPath("/pets/*", "/pets"))
+	assert.Check(t, matchRBACPath("/pets/**", "/pets"))
+	assert.Check(t, !matchRBACPath("/pets", "/pets/123"))
+}
@@ -0,0 +1,67 @@
+package authorization
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kuadrant/authorino/pkg/auth"
+
+	"github.com/tidwall/gjson"
+)
+
+const msg_insufficientScope = "insufficient scope"
+
+var defaultScopeSelectors = []string{"auth.identity.scope", "auth.identity.scp"}
+
+// RequiredScopes authorizes requests whose token holds all the scopes listed in AllOf and at least one of the scopes
+// listed in AnyOf, when provided
+type RequiredScopes struct {
+	Selector string
+	AllOf    []string
+	AnyOf    []string
+}
+
+func (r *RequiredScopes) Call(pipeline auth.AuthPipeline, _ context.Context) (interface{}, error) {
+	granted := make(map[string]bool)
+	for _, scope := range r.grantedScopes(pipeline.GetAuthorizationJSON()) {
+		granted[scope] = true
+	}
+
+	var missing []string
+	for _, scope := range r.AllOf {
+		if !granted[scope] {
+			missing = append(missing, scope)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%s: missing %s", msg_insufficientScope, strings.Join(missing, " "))
+	}
+
+	if len(r.AnyOf) > 0 {
+		anyGranted := false
+		for _, scope := range r.AnyOf {
+			if granted[scope] {
+				anyGranted = true
+				break
+			}
+		}
+		if !anyGranted {
+			return nil, fmt.Errorf("%s: requires any of %s", msg_insufficientScope, strings.Join(r.AnyOf, " "))
+		}
+	}
+
+	return true, nil
+}
+
+func (r *RequiredScopes) grantedScopes(authJSON string) []string {
+	if r.Selector != "" {
+		return stringListFrom(gjson.Get(authJSON, r.Selector))
+	}
+	for _, selector := range defaultScopeSelectors {
+		if value := gjson.Get(authJSON, selector); value.Exists() {
+			return stringListFrom(value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package authorization
+
+import (
+	"context"
+	"testing"
+
+	mock_auth "github.com/kuadrant/authorino/pkg/auth/mocks"
+
+	. "github.com/golang/mock/gomock"
+	"gotest.tools/assert"
+)
+
+func TestRequiredScopesAllOf(t *testing.T) {
+	ctrl := NewController(t)
+	defer ctrl.Finish()
+
+	requiredScopes := &RequiredScopes{AllOf: []string{"pets:read", "pets:write"}}
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{"auth":{"identity":{"scope":"openid pets:read pets:write"}}}`)
+	_, err := requiredScopes.Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{"auth":{"identity":{"scope":"openid pets:read"}}}`)
+	_, err = requiredScopes.Call(pipelineMock, context.TODO())
+	assert.Error(t, err, "insufficient scope: missing pets:write")
+}
+
+func TestRequiredScopesAnyOf(t *testing.T) {
+	ctrl := NewController(t)
+	defer ctrl.Finish()
+
+	requiredScopes := &RequiredScopes{AllOf: []string{"pets:read"}, AnyOf: []string{"pets:write", "pets:admin"}}
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{"auth":{"identity":{"scp":["pets:read","pets:admin"]}}}`)
+	_, err := requiredScopes.Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{"auth":{"identity":{"scp":["pets:read"]}}}`)
+	_, err = requiredScopes.Call(pipelineMock, context.TODO())
+	assert.Error(t, err, "insufficient scope: requires any of pets:write pets:admin")
+}
+
+func TestRequiredScopesWithSelector(t *testing.T) {
+	ctrl := NewController(t)
+	defer ctrl.Finish()
+
+	requiredScopes := &RequiredScopes{Selector: "auth.metadata.introspection.scope", AllOf: []string{"pets:read"}}
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{"auth":{"identity":{"scope":"pets:read"},"metadata":{"introspection":{"scope":"openid"}}}}`)
+	_, err := requiredScopes.Call(pipelineMock, context.TODO())
+	assert.Error(t, err, "insufficient scope: missing pets:read")
+}
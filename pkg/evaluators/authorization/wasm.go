@@ -0,0 +1,345 @@
+package authorization
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/kuadrant/authorino/pkg/auth"
+	"github.com/kuadrant/authorino/pkg/log"
+	"github.com/kuadrant/authorino/pkg/trace"
+
+	k8s "k8s.io/api/core/v1"
+	k8s_types "k8s.io/apimachinery/pkg/types"
+	k8s_client "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	wasmMemoryExport   = "memory"
+	wasmAllocExport    = "alloc"
+	wasmEvaluateExport = "evaluate"
+
+	DefaultWasmMaxFuel uint64 = 10000000
+	// WasmMaxMemoryPages is the maximum size of the memory of the wasm modules, in pages of 64 KiB (i.e. 16 MiB)
+	WasmMaxMemoryPages uint64 = 256
+
+	msg_wasmModuleLoadError = "failed to load wasm module"
+)
+
+// WasmModuleSource is where the bytes of a WebAssembly module are loaded from
+type WasmModuleSource interface {
+	Load(ctx context.Context) ([]byte, error)
+}
+
+// NewWasmAuthorization compiles the WebAssembly module loaded from the source.
+// The module must export a memory named "memory", whose declared maximum size does not exceed WasmMaxMemoryPages, and
+// the following functions:
+// - alloc(size i32) i32: allocates a buffer of a given size in the memory, returning a pointer to it;
+// - evaluate(ptr i32, len i32) i64: evaluates the policy for the authorization JSON stored in the memory at a given
+// pointer and length, returning the pointer (upper 32 bits) and the length (lower 32 bits) of the verdict,
+// a JSON object in the format {"allow":bool,"reason":string,"metadata":any}.
+func NewWasmAuthorization(policyName string, source WasmModuleSource, maxFuel uint64, ctx context.Context) (*Wasm, error) {
+	logger := log.FromContext(ctx).WithName("wasm")
+
+	moduleBytes, err := source.Load(ctx)
+	if err != nil {
+		logger.Error(err, msg_wasmModuleLoadError, "policy", policyName)
+		return nil, err
+	}
+
+	if maxFuel == 0 {
+		maxFuel = DefaultWasmMaxFuel
+	}
+
+	module, err := compileWasmModule(moduleBytes)
+	if err != nil {
+		logger.Error(err, msg_wasmModuleLoadError, "policy", policyName)
+		return nil, &PolicyCompilationError{Policy: policyName, Err: err}
+	}
+
+	return &Wasm{
+		MaxFuel:    maxFuel,
+		policyName: policyName,
+		module:     module,
+	}, nil
+}
+
+// wasmModule is a compiled WebAssembly module, implemented by the runtime the binary is built with
+type wasmModule interface {
+	// evaluate runs the policy in a fresh instance of the module for a given input, consuming at most a given amount of
+	// fuel, and returns the verdict
+	evaluate(input []byte, maxFuel uint64) ([]byte, error)
+}
+
+// Wasm evaluates authorization policies implemented as WebAssembly modules.
+// Every evaluation runs in a fresh instance of the module, with no imports (i.e. no access to the host), limited fuel
+// and limited memory.
+type Wasm struct {
+	MaxFuel uint64
+
+	policyName string
+	module     wasmModule
+}
+
+type wasmVerdict struct {
+	Allow    bool        `json:"allow"`
+	Reason   string      `json:"reason,omitempty"`
+	Metadata interface{} `json:"metadata,omitempty"`
+}
+
+func (w *Wasm) Call(pipeline auth.AuthPipeline, _ context.Context) (interface{}, error) {
+	output, err := w.module.evaluate([]byte(pipeline.GetAuthorizationJSON()), w.MaxFuel)
+	if err != nil {
+		return nil, err
+	}
+
+	var verdict wasmVerdict
+	if err := json.Unmarshal(output, &verdict); err != nil {
+		return nil, fmt.Errorf("invalid verdict from wasm module: %v", err)
+	}
+
+	if !verdict.Allow {
+		if verdict.Reason != "" {
+			return nil, errors.New(verdict.Reason)
+		}
+		return nil, errors.New(unauthorizedErrorMsg)
+	}
+
+	if verdict.Metadata == nil {
+		return true, nil
+	}
+	return verdict.Metadata, nil
+}
+
+func NewWasmConfigMapModuleSource(name, namespace, key string, k8sClient k8s_client.Reader) *WasmConfigMapModuleSource {
+	return &WasmConfigMapModuleSource{
+		Name:      name,
+		Namespace: namespace,
+		Key:       key,
+		k8sClient: k8sClient,
+	}
+}
+
+// WasmConfigMapModuleSource is a key of a Kubernetes ConfigMap that stores a WebAssembly module, in the binary data of
+// the ConfigMap
+type WasmConfigMapModuleSource struct {
+	Name      string
+	Namespace string
+	Key       string
+
+	k8sClient k8s_client.Reader
+}
+
+func (src *WasmConfigMapModuleSource) Load(ctx context.Context) ([]byte, error) {
+	configMap := &k8s.ConfigMap{}
+	if err := src.k8sClient.Get(ctx, k8s_types.NamespacedName{Namespace: src.Namespace, Name: src.Name}, configMap); err != nil {
+		return nil, err
+	}
+
+	if data, ok := configMap.BinaryData[src.Key]; ok {
+		return data, nil
+	}
+	if data, ok := configMap.Data[src.Key]; ok {
+		return []byte(data), nil
+	}
+	return nil, fmt.Errorf("key %s not found in configmap %s/%s", src.Key, src.Namespace, src.Name)
+}
+
+var wasmOCILayerMediaTypes = []string{
+	"application/vnd.wasm.content.layer.v1+wasm",
+	"application/vnd.module.wasm.content.layer.v1+wasm",
+}
+
+// WasmOCIModuleSource is a reference to a WebAssembly module stored in an OCI registry (e.g. quay.io/org/policy:v1).
+// Only anonymous pulls are supported. The reference can be prefixed with 'http://' for registries without TLS.
+type WasmOCIModuleSource struct {
+	Reference string
+}
+
+func (src *WasmOCIModuleSource) Load(ctx context.Context) ([]byte, error) {
+	registry, repository, tag, err := parseOCIReference(src.Reference)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &ociRegistryClient{registry: registry, repository: repository}
+
+	manifestBytes, err := client.get(ctx, "manifests/"+tag, "application/vnd.oci.image.manifest.v1+json, application/vnd.docker.distribution.manifest.v2+json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch manifest of %s: %v", src.Reference, err)
+	}
+
+	var manifest struct {
+		Layers []struct {
+			MediaType string `json:"mediaType"`
+			Digest    string `json:"digest"`
+		} `json:"layers"`
+	}
+	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest of %s: %v", src.Reference, err)
+	}
+
+	digest := ""
+	for _, layer := range manifest.Layers {
+		for _, mediaType := range wasmOCILayerMediaTypes {
+			if layer.MediaType == mediaType {
+				digest = layer.Digest
+			}
+		}
+	}
+	if digest == "" && len(manifest.Layers) == 1 {
+		digest = manifest.Layers[0].Digest
+	}
+	if digest == "" {
+		return nil, fmt.Errorf("no wasm layer found in %s", src.Reference)
+	}
+
+	blob, err := client.get(ctx, "blobs/"+digest, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch wasm layer of %s: %v", src.Reference, err)
+	}
+
+	if sum := sha256.Sum256(blob); digest != "sha256:"+hex.EncodeToString(sum[:]) {
+		return nil, fmt.Errorf("digest mismatch of the wasm layer of %s", src.Reference)
+	}
+
+	return blob, nil
+}
+
+// parseOCIReference splits an OCI reference into the base URL of the registry, the repository and the tag (or digest)
+func parseOCIReference(reference string) (string, string, string, error) {
+	scheme := "https"
+	if strings.HasPrefix(reference, "http://") {
+		scheme = "http"
+	}
+	reference = strings.TrimPrefix(strings.TrimPrefix(reference, "http://"), "https://")
+
+	parts := strings.SplitN(reference, "/", 2)
+	registry := "registry-1.docker.io"
+	repository := reference
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		registry, repository = parts[0], parts[1]
+	} else if len(parts) == 1 {
+		repository = "library/" + reference
+	}
+
+	tag := "latest"
+	if i := strings.Index(repository, "@"); i >= 0 {
+		repository, tag = repository[:i], repository[i+1:]
+	} else if i := strings.LastIndex(repository, ":"); i >= 0 {
+		repository, tag = repository[:i], repository[i+1:]
+	}
+
+	if repository == "" || tag == "" {
+		return "", "", "", fmt.Errorf("invalid oci reference: %s", reference)
+	}
+
+	return scheme + "://" + registry, repository, tag, nil
+}
+
+// ociRegistryClient fetches content from an OCI registry, requesting an anonymous token if challenged to
+type ociRegistryClient struct {
+	registry   string
+	repository string
+	token      string
+}
+
+func (c *ociRegistryClient) get(ctx context.Context, path, accept string) ([]byte, error) {
+	endpoint := fmt.Sprintf("%s/v2/%s/%s", c.registry, c.repository, path)
+
+	resp, err := c.do(ctx, endpoint, accept)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized && c.token == "" {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		if c.token, err = fetchOCIRegistryToken(ctx, challenge); err != nil {
+			return nil, err
+		}
+		if resp, err = c.do(ctx, endpoint, accept); err != nil {
+			return nil, err
+		}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func (c *ociRegistryClient) do(ctx context.Context, endpoint, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return trace.HTTPClient.Do(req)
+}
+
+// fetchOCIRegistryToken requests an anonymous token to the authorization service announced by the registry in a
+// 'WWW-Authenticate: Bearer realm="…",service="…",scope="…"' challenge
+func fetchOCIRegistryToken(ctx context.Context, challenge string) (string, error) {
+	if !strings.HasPrefix(challenge, "Bearer ") {
+		return "", fmt.Errorf("unsupported registry authentication challenge: %s", challenge)
+	}
+
+	params := make(map[string]string)
+	for _, param := range strings.Split(strings.TrimPrefix(challenge, "Bearer "), ",") {
+		if kv := strings.SplitN(strings.TrimSpace(param), "=", 2); len(kv) == 2 {
+			params[kv[0]] = strings.Trim(kv[1], `"`)
+		}
+	}
+
+	realm, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return "", fmt.Errorf("invalid registry authentication challenge: %s", challenge)
+	}
+	query := realm.Query()
+	for _, name := range []string{"service", "scope"} {
+		if value := params[name]; value != "" {
+			query.Set(name, value)
+		}
+	}
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := trace.HTTPClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to obtain registry token: %s", resp.Status)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", err
+	}
+	if token.Token != "" {
+		return token.Token, nil
+	}
+	return token.AccessToken, nil
+}
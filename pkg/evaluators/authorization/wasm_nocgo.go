@@ -0,0 +1,10 @@
+//go:build !cgo
+
+package authorization
+
+import "fmt"
+
+// compileWasmModule rejects all modules, as the wasm runtime requires cgo
+func compileWasmModule(_ []byte) (wasmModule, error) {
+	return nil, fmt.Errorf("wasm policies are not supported by this build of authorino (built without cgo)")
+}
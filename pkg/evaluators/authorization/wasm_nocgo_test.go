@@ -0,0 +1,15 @@
+//go:build !cgo
+
+package authorization
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestWasmAuthorizationWithoutCgo(t *testing.T) {
+	_, err := NewWasmAuthorization("ns/authconfig/wasm", &wasmModuleSourceMock{wasmEmptyModule}, 0, context.TODO())
+	assert.Error(t, err, "failed to compile policy ns/authconfig/wasm: wasm policies are not supported by this build of authorino (built without cgo)")
+}
@@ -0,0 +1,109 @@
+package authorization
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	gohttptest "net/http/httptest"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+	k8s "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8s_runtime "k8s.io/apimachinery/pkg/runtime"
+	k8s_fake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// wasmEmptyModule is the binary of an empty wasm module
+var wasmEmptyModule = []byte("\x00asm\x01\x00\x00\x00")
+
+type wasmModuleSourceMock struct {
+	module []byte
+}
+
+func (s *wasmModuleSourceMock) Load(_ context.Context) ([]byte, error) {
+	return s.module, nil
+}
+
+func TestWasmConfigMapModuleSource(t *testing.T) {
+	module := wasmEmptyModule
+
+	configMap := &k8s.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "wasm-policies", Namespace: "ns"},
+		BinaryData: map[string][]byte{"policy.wasm": module},
+	}
+	scheme := k8s_runtime.NewScheme()
+	_ = k8s.AddToScheme(scheme)
+	k8sClient := k8s_fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(configMap).Build()
+
+	loaded, err := NewWasmConfigMapModuleSource("wasm-policies", "ns", "policy.wasm", k8sClient).Load(context.TODO())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, loaded, module)
+
+	_, err = NewWasmConfigMapModuleSource("wasm-policies", "ns", "other.wasm", k8sClient).Load(context.TODO())
+	assert.Error(t, err, "key other.wasm not found in configmap ns/wasm-policies")
+}
+
+func TestWasmOCIModuleSource(t *testing.T) {
+	module := wasmEmptyModule
+	sum := sha256.Sum256(module)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+
+	var registryURL string
+	registry := gohttptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/token" {
+			assert.Equal(t, req.URL.Query().Get("scope"), "repository:org/policy:pull")
+			_, _ = w.Write([]byte(`{"token":"anonymous"}`))
+			return
+		}
+		if req.Header.Get("Authorization") != "Bearer anonymous" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry",scope="repository:org/policy:pull"`, registryURL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch req.URL.Path {
+		case "/v2/org/policy/manifests/v1":
+			_, _ = w.Write([]byte(fmt.Sprintf(`{"schemaVersion":2,"layers":[{"mediaType":"application/vnd.wasm.content.layer.v1+wasm","digest":"%s","size":%d}]}`, digest, len(module))))
+		case "/v2/org/policy/blobs/" + digest:
+			_, _ = w.Write(module)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer registry.Close()
+	registryURL = registry.URL
+
+	reference := registry.URL + "/org/policy:v1" // http://127.0.0.1:<port>/org/policy:v1
+	loaded, err := (&WasmOCIModuleSource{Reference: reference}).Load(context.TODO())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, loaded, module)
+
+	_, err = (&WasmOCIModuleSource{Reference: strings.Replace(reference, ":v1", ":v2", 1)}).Load(context.TODO())
+	assert.ErrorContains(t, err, "404")
+}
+
+func TestParseOCIReference(t *testing.T) {
+	testCases := []struct {
+		reference  string
+		registry   string
+		repository string
+		tag        string
+	}{
+		{"quay.io/org/policy:v1", "https://quay.io", "org/policy", "v1"},
+		{"quay.io/org/policy", "https://quay.io", "org/policy", "latest"},
+		{"http://localhost:5000/policy@sha256:abc", "http://localhost:5000", "policy", "sha256:abc"},
+		{"org/policy:v1", "https://registry-1.docker.io", "org/policy", "v1"},
+		{"policy", "https://registry-1.docker.io", "library/policy", "latest"},
+	}
+
+	for _, tc := range testCases {
+		registry, repository, tag, err := parseOCIReference(tc.reference)
+		assert.NilError(t, err, tc.reference)
+		assert.Equal(t, registry, tc.registry, tc.reference)
+		assert.Equal(t, repository, tc.repository, tc.reference)
+		assert.Equal(t, tag, tc.tag, tc.reference)
+	}
+}
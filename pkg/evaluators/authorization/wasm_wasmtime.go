@@ -0,0 +1,129 @@
+//go:build cgo
+
+package authorization
+
+import (
+	"fmt"
+
+	"github.com/bytecodealliance/wasmtime-go/v3"
+)
+
+// compileWasmModule compiles a WebAssembly module with wasmtime, checking its exports against the ABI of the policies.
+// The features that would let the module bypass the limits of memory (memory64, multiple memories, growable tables)
+// are disabled.
+func compileWasmModule(moduleBytes []byte) (wasmModule, error) {
+	config := wasmtime.NewConfig()
+	config.SetConsumeFuel(true)
+	config.SetWasmMemory64(false)
+	config.SetWasmMultiMemory(false)
+	config.SetWasmReferenceTypes(false)
+	engine := wasmtime.NewEngineWithConfig(config)
+
+	module, err := wasmtime.NewModule(engine, moduleBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateWasmModuleExports(module); err != nil {
+		return nil, err
+	}
+
+	return &wasmtimeModule{engine: engine, module: module}, nil
+}
+
+type wasmtimeModule struct {
+	engine *wasmtime.Engine
+	module *wasmtime.Module
+}
+
+func (m *wasmtimeModule) evaluate(input []byte, maxFuel uint64) ([]byte, error) {
+	store := wasmtime.NewStore(m.engine)
+	if err := store.AddFuel(maxFuel); err != nil {
+		return nil, err
+	}
+
+	instance, err := wasmtime.NewInstance(store, m.module, []wasmtime.AsExtern{})
+	if err != nil {
+		return nil, err
+	}
+
+	var memory *wasmtime.Memory
+	if export := instance.GetExport(store, wasmMemoryExport); export != nil {
+		memory = export.Memory()
+	}
+	alloc := instance.GetFunc(store, wasmAllocExport)
+	evaluate := instance.GetFunc(store, wasmEvaluateExport)
+	if memory == nil || alloc == nil || evaluate == nil {
+		return nil, fmt.Errorf("wasm module does not implement the policy interface")
+	}
+
+	ptr, err := alloc.Call(store, int32(len(input)))
+	if err != nil {
+		return nil, err
+	}
+	inputPtr, _ := ptr.(int32)
+	data := memory.UnsafeData(store)
+	if inputPtr < 0 || int(inputPtr)+len(input) > len(data) {
+		return nil, fmt.Errorf("wasm module allocated an invalid buffer")
+	}
+	copy(data[inputPtr:], input)
+
+	result, err := evaluate.Call(store, inputPtr, int32(len(input)))
+	if err != nil {
+		return nil, err
+	}
+	packed, _ := result.(int64)
+	outputPtr, outputLen := uint64(packed)>>32, uint64(packed)&0xffffffff
+
+	data = memory.UnsafeData(store) // the memory may have grown
+	if outputPtr+outputLen > uint64(len(data)) {
+		return nil, fmt.Errorf("wasm module returned an invalid verdict")
+	}
+
+	output := make([]byte, outputLen)
+	copy(output, data[outputPtr:outputPtr+outputLen])
+	return output, nil
+}
+
+// validateWasmModuleExports checks the kinds and the signatures of the exports required by the ABI of the policies,
+// and that the memory cannot grow beyond WasmMaxMemoryPages
+func validateWasmModuleExports(module *wasmtime.Module) error {
+	exports := make(map[string]*wasmtime.ExternType)
+	for _, export := range module.Exports() {
+		exports[export.Name()] = export.Type()
+	}
+
+	for _, name := range []string{wasmMemoryExport, wasmAllocExport, wasmEvaluateExport} {
+		if exports[name] == nil {
+			return fmt.Errorf("wasm module does not export '%s'", name)
+		}
+	}
+
+	memory := exports[wasmMemoryExport].MemoryType()
+	if memory == nil {
+		return fmt.Errorf("wasm module export '%s' is not a memory", wasmMemoryExport)
+	}
+	if hasMax, max := memory.Maximum(); !hasMax || max > WasmMaxMemoryPages {
+		return fmt.Errorf("wasm module memory must declare a maximum size of at most %d pages", WasmMaxMemoryPages)
+	}
+
+	if err := validateWasmFuncExport(exports[wasmAllocExport], wasmAllocExport, []wasmtime.ValKind{wasmtime.KindI32}, wasmtime.KindI32); err != nil {
+		return err
+	}
+	return validateWasmFuncExport(exports[wasmEvaluateExport], wasmEvaluateExport, []wasmtime.ValKind{wasmtime.KindI32, wasmtime.KindI32}, wasmtime.KindI64)
+}
+
+func validateWasmFuncExport(export *wasmtime.ExternType, name string, params []wasmtime.ValKind, result wasmtime.ValKind) error {
+	invalid := fmt.Errorf("wasm module export '%s' is not a function %v -> %v", name, params, result)
+
+	funcType := export.FuncType()
+	if funcType == nil || len(funcType.Params()) != len(params) || len(funcType.Results()) != 1 || funcType.Results()[0].Kind() != result {
+		return invalid
+	}
+	for i, param := range funcType.Params() {
+		if param.Kind() != params[i] {
+			return invalid
+		}
+	}
+	return nil
+}
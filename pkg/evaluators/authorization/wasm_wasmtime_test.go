@@ -0,0 +1,163 @@
+//go:build cgo
+
+package authorization
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+
+	mock_auth "github.com/kuadrant/authorino/pkg/auth/mocks"
+
+	"github.com/bytecodealliance/wasmtime-go/v3"
+	. "github.com/golang/mock/gomock"
+	"gotest.tools/assert"
+)
+
+// wasmVerdictModule returns a module that echoes back a constant verdict
+func wasmVerdictModule(t *testing.T, verdict string) []byte {
+	wat := fmt.Sprintf(`(module
+  (memory (export "memory") 1 16)
+  (global $next (mut i32) (i32.const 1024))
+  (data (i32.const 0) %s)
+  (func (export "alloc") (param $size i32) (result i32)
+    (local $ptr i32)
+    (local.set $ptr (global.get $next))
+    (global.set $next (i32.add (global.get $next) (local.get $size)))
+    (local.get $ptr))
+  (func (export "evaluate") (param $ptr i32) (param $len i32) (result i64)
+    (i64.const %d)))`, strconv.Quote(verdict), len(verdict))
+	module, err := wasmtime.Wat2Wasm(wat)
+	assert.NilError(t, err)
+	return module
+}
+
+const wasmInfiniteLoopModule = `(module
+  (memory (export "memory") 1 16)
+  (func (export "alloc") (param $size i32) (result i32)
+    (i32.const 0))
+  (func (export "evaluate") (param $ptr i32) (param $len i32) (result i64)
+    (loop $forever (br $forever))
+    (i64.const 0)))`
+
+func TestWasmAuthorization(t *testing.T) {
+	ctrl := NewController(t)
+	defer ctrl.Finish()
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{"context":{"request":{"http":{"method":"GET"}}}}`).AnyTimes()
+
+	allow, err := NewWasmAuthorization("ns/authconfig/wasm", &wasmModuleSourceMock{wasmVerdictModule(t, `{"allow":true,"metadata":{"tier":"gold"}}`)}, 0, context.TODO())
+	assert.NilError(t, err)
+	assert.Equal(t, allow.MaxFuel, DefaultWasmMaxFuel)
+	obj, err := allow.Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, obj, map[string]interface{}{"tier": "gold"})
+
+	deny, err := NewWasmAuthorization("ns/authconfig/wasm", &wasmModuleSourceMock{wasmVerdictModule(t, `{"allow":false,"reason":"not a gold member"}`)}, 0, context.TODO())
+	assert.NilError(t, err)
+	_, err = deny.Call(pipelineMock, context.TODO())
+	assert.Error(t, err, "not a gold member")
+
+	denyWithoutReason, err := NewWasmAuthorization("ns/authconfig/wasm", &wasmModuleSourceMock{wasmVerdictModule(t, `{"allow":false}`)}, 0, context.TODO())
+	assert.NilError(t, err)
+	_, err = denyWithoutReason.Call(pipelineMock, context.TODO())
+	assert.Error(t, err, unauthorizedErrorMsg)
+}
+
+func TestWasmAuthorizationOutOfFuel(t *testing.T) {
+	ctrl := NewController(t)
+	defer ctrl.Finish()
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{}`)
+
+	module, err := wasmtime.Wat2Wasm(wasmInfiniteLoopModule)
+	assert.NilError(t, err)
+
+	wasm, err := NewWasmAuthorization("ns/authconfig/wasm", &wasmModuleSourceMock{module}, 1000, context.TODO())
+	assert.NilError(t, err)
+	_, err = wasm.Call(pipelineMock, context.TODO())
+	assert.ErrorContains(t, err, "fuel")
+}
+
+func TestWasmAuthorizationMissingExports(t *testing.T) {
+	module, err := wasmtime.Wat2Wasm(`(module (memory (export "memory") 1 16))`)
+	assert.NilError(t, err)
+
+	_, err = NewWasmAuthorization("ns/authconfig/wasm", &wasmModuleSourceMock{module}, 0, context.TODO())
+	assert.Error(t, err, "failed to compile policy ns/authconfig/wasm: wasm module does not export 'alloc'")
+}
+
+func TestWasmAuthorizationInvalidExports(t *testing.T) {
+	testCases := []struct {
+		wat string
+		err string
+	}{
+		{
+			`(module
+  (func (export "memory"))
+  (func (export "alloc") (param i32) (result i32) (i32.const 0))
+  (func (export "evaluate") (param i32 i32) (result i64) (i64.const 0)))`,
+			"wasm module export 'memory' is not a memory",
+		},
+		{
+			`(module
+  (memory (export "memory") 1 16)
+  (global (export "alloc") i32 (i32.const 0))
+  (func (export "evaluate") (param i32 i32) (result i64) (i64.const 0)))`,
+			"wasm module export 'alloc' is not a function [i32] -> i32",
+		},
+		{
+			`(module
+  (memory (export "memory") 1 16)
+  (func (export "alloc") (param i32) (result i32) (i32.const 0))
+  (func (export "evaluate") (param i32) (result i32) (i32.const 0)))`,
+			"wasm module export 'evaluate' is not a function [i32 i32] -> i64",
+		},
+		{
+			`(module
+  (memory (export "memory") 1)
+  (func (export "alloc") (param i32) (result i32) (i32.const 0))
+  (func (export "evaluate") (param i32 i32) (result i64) (i64.const 0)))`,
+			"wasm module memory must declare a maximum size of at most 256 pages",
+		},
+		{
+			`(module
+  (memory (export "memory") 1 65536)
+  (func (export "alloc") (param i32) (result i32) (i32.const 0))
+  (func (export "evaluate") (param i32 i32) (result i64) (i64.const 0)))`,
+			"wasm module memory must declare a maximum size of at most 256 pages",
+		},
+	}
+
+	for _, tc := range testCases {
+		module, err := wasmtime.Wat2Wasm(tc.wat)
+		assert.NilError(t, err)
+
+		_, err = NewWasmAuthorization("ns/authconfig/wasm", &wasmModuleSourceMock{module}, 0, context.TODO())
+		assert.Error(t, err, "failed to compile policy ns/authconfig/wasm: "+tc.err)
+	}
+}
+
+func TestWasmAuthorizationMemoryGrowth(t *testing.T) {
+	ctrl := NewController(t)
+	defer ctrl.Finish()
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{}`)
+
+	// tries to grow the memory by 1 GiB, returning the verdict at the address the growth would start (-1 if failed)
+	module, err := wasmtime.Wat2Wasm(`(module
+  (memory (export "memory") 1 16)
+  (func (export "alloc") (param i32) (result i32) (i32.const 0))
+  (func (export "evaluate") (param i32 i32) (result i64)
+    (i64.extend_i32_u (memory.grow (i32.const 16384)))))`)
+	assert.NilError(t, err)
+
+	wasm, err := NewWasmAuthorization("ns/authconfig/wasm", &wasmModuleSourceMock{module}, 0, context.TODO())
+	assert.NilError(t, err)
+	_, err = wasm.Call(pipelineMock, context.TODO())
+	assert.Error(t, err, "wasm module returned an invalid verdict")
+}
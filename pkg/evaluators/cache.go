@@ -2,6 +2,8 @@ package evaluators
 
 import (
 	gojson "encoding/json"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/kuadrant/authorino/pkg/json"
@@ -17,51 +19,152 @@ type EvaluatorCache interface {
 	Get(key interface{}) (interface{}, error)
 	Set(key, value interface{}) error
 	ResolveKeyFor(authJSON string) interface{}
+	Purge(prefix string) (int, error)
 	Shutdown() error
 }
 
-func NewEvaluatorCache(keyTemplate json.JSONValue, ttl int) EvaluatorCache {
+// NewEvaluatorCache returns a cache of the results of an evaluator.
+// The id identifies the evaluator in the external cache backend, if enabled, so the replicas share the entries of
+// the same evaluator.
+func NewEvaluatorCache(id string, keyTemplate json.JSONValue, ttl int) EvaluatorCache {
 	duration := time.Duration(ttl) * time.Second
 	cacheClient := freecache.NewCache(EvaluatorCacheSize * 1024 * 1024)
 	cacheStore := cache_store.NewFreecache(cacheClient, &cache_store.Options{Expiration: duration})
 	c := &evaluatorCache{
+		id:          id,
 		keyTemplate: keyTemplate,
+		ttl:         duration,
+		client:      cacheClient,
 		store:       gocache.New(cacheStore),
+		external:    ExternalCache,
+	}
+	if c.external != nil {
+		c.external.register(c)
 	}
 	return c
 }
 
-// evaluatorCache caches JSON values (objects, arrays, strings, etc)
+// evaluatorCache caches JSON values (objects, arrays, strings, etc) in memory and, if enabled, in the external cache
+// backend shared by the replicas
 type evaluatorCache struct {
+	id          string
 	keyTemplate json.JSONValue
+	ttl         time.Duration
+	client      *freecache.Cache
 	store       *gocache.Cache
+	external    *ExternalCacheBackend
 }
 
+// Get reads the entry of a key from memory or, if missing, from the external backend.
+// Entries are keyed by the string representation of the keys (see cacheKeyString), in memory as in the external
+// backend, so keys that are not strings (e.g. objects resolved from the authorization JSON) can still be matched by
+// prefix when purging; gocache would otherwise store them by their md5 hashes.
 func (c *evaluatorCache) Get(key interface{}) (interface{}, error) {
-	if valueAsBytes, ttl, _ := c.store.GetWithTTL(key); valueAsBytes != nil && ttl > 0 {
-		var value interface{}
-		if err := gojson.Unmarshal(valueAsBytes.([]byte), &value); err != nil {
+	key = cacheKeyString(key)
+	valueAsBytes, ttl, _ := c.store.GetWithTTL(key)
+	if valueAsBytes == nil || ttl <= 0 {
+		if c.external == nil {
+			return nil, nil
+		}
+		var err error
+		if valueAsBytes, ttl, err = c.external.get(c.id, key); err != nil || valueAsBytes == nil {
 			return nil, err
-		} else {
-			return value, nil
 		}
+		// warms up the in-memory layer for the remaining time to live of the external entry
+		_ = c.store.Set(key, valueAsBytes, &cache_store.Options{Expiration: ttl})
 	}
 
-	return nil, nil
+	var value interface{}
+	if err := gojson.Unmarshal(valueAsBytes.([]byte), &value); err != nil {
+		return nil, err
+	}
+	return value, nil
 }
 
 func (c *evaluatorCache) Set(key, value interface{}) error {
-	if valueAsBytes, err := gojson.Marshal(value); err != nil {
+	key = cacheKeyString(key)
+	valueAsBytes, err := gojson.Marshal(value)
+	if err != nil {
 		return err
-	} else {
-		return c.store.Set(key, valueAsBytes, nil)
 	}
+	if err := c.store.Set(key, valueAsBytes, nil); err != nil {
+		return err
+	}
+	if c.external != nil {
+		return c.external.set(c.id, key, valueAsBytes, c.ttl)
+	}
+	return nil
 }
 
 func (c *evaluatorCache) ResolveKeyFor(authJSON string) interface{} {
 	return c.keyTemplate.ResolveFor(authJSON)
 }
 
+// Purge deletes the cached entries whose keys start with the given prefix, or all the entries if the prefix is empty.
+// Keys that are not strings are matched by their JSON representation, e.g. the prefix `{"sub":"john"` matches the
+// entries keyed by objects such as `{"sub":"john","tenant":"acme"}` (object keys are marshalled in sorted order).
+// With the external cache backend enabled, the entries are deleted from the external backend as well and the other
+// replicas are told to purge their in-memory entries.
+// It returns the number of entries deleted.
+func (c *evaluatorCache) Purge(prefix string) (int, error) {
+	purged, err := c.purgeLocal(prefix)
+	if err != nil {
+		return purged, err
+	}
+
+	if c.external != nil {
+		n, err := c.external.purge(c.id, prefix)
+		if n > purged {
+			purged = n
+		}
+		return purged, err
+	}
+
+	return purged, nil
+}
+
+// purgeLocal deletes the in-memory entries whose keys start with the given prefix, or all the entries if the prefix is
+// empty
+func (c *evaluatorCache) purgeLocal(prefix string) (int, error) {
+	if prefix == "" {
+		count := int(c.client.EntryCount())
+		return count, c.store.Clear()
+	}
+
+	var keys [][]byte
+	iterator := c.client.NewIterator()
+	for entry := iterator.Next(); entry != nil; entry = iterator.Next() {
+		if strings.HasPrefix(string(entry.Key), prefix) {
+			keys = append(keys, entry.Key)
+		}
+	}
+
+	purged := 0
+	for _, key := range keys {
+		if c.client.Del(key) {
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+// Shutdown clears the in-memory entries; the entries in the external cache backend expire on their own, as they may
+// still be used by other replicas
 func (c *evaluatorCache) Shutdown() error {
+	if c.external != nil {
+		c.external.unregister(c)
+	}
 	return c.store.Clear()
 }
+
+// cacheKeyString returns the representation of a cache key in the external cache backend
+func cacheKeyString(key interface{}) string {
+	if s, ok := key.(string); ok {
+		return s
+	}
+	if b, err := gojson.Marshal(key); err == nil {
+		return string(b)
+	}
+	return fmt.Sprintf("%v", key)
+}
@@ -0,0 +1,171 @@
+package evaluators
+
+import (
+	"context"
+	gojson "encoding/json"
+	"sync"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/log"
+
+	"github.com/google/uuid"
+)
+
+const (
+	externalCacheKeyPrefix          = "authorino:cache:"
+	DefaultCacheInvalidationChannel = "authorino:cache:invalidation"
+	externalCacheTimeout            = time.Second
+	// purges scan the keys of the store, which may take way longer than reading and writing single entries
+	externalCachePurgeTimeout = 30 * time.Second
+)
+
+// ExternalCache is the external backend of the evaluator caches shared by the replicas; disabled if nil.
+// Must be set before any evaluator cache is built.
+var ExternalCache *ExternalCacheBackend
+
+// ExternalCacheStore is a store of cache entries shared by the replicas, with a pub/sub channel between them
+type ExternalCacheStore interface {
+	// Get returns the value of an entry and its remaining time to live, or nil if the entry does not exist
+	Get(ctx context.Context, key string) ([]byte, time.Duration, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// SetIfAbsent sets an entry only if it does not exist, atomically, and tells whether the entry was set
+	SetIfAbsent(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+	// DeletePrefix deletes the entries whose keys start with the given prefix and returns the number of entries deleted
+	DeletePrefix(ctx context.Context, prefix string) (int, error)
+	Publish(ctx context.Context, channel string, message []byte) error
+	// Subscribe delivers the messages published to a channel until the context is done
+	Subscribe(ctx context.Context, channel string) <-chan []byte
+	Close() error
+}
+
+// cacheInvalidation is the message published to the other replicas when the entries of an evaluator cache are purged
+type cacheInvalidation struct {
+	Origin string `json:"origin"`
+	Cache  string `json:"cache"`
+	Prefix string `json:"prefix,omitempty"`
+}
+
+// ExternalCacheBackend layers the in-memory evaluator caches of a replica on top of an external store shared by all
+// the replicas.
+// Purges issued on one replica (e.g. to revoke the cached identity of a user) are published to the invalidation channel,
+// so the other replicas clear their in-memory layers right away instead of serving the revoked entries until they
+// expire.
+type ExternalCacheBackend struct {
+	store   ExternalCacheStore
+	channel string
+	origin  string
+
+	caches map[string]map[*evaluatorCache]struct{}
+	mu     sync.RWMutex
+}
+
+func NewExternalCacheBackend(store ExternalCacheStore, invalidationChannel string) *ExternalCacheBackend {
+	if invalidationChannel == "" {
+		invalidationChannel = DefaultCacheInvalidationChannel
+	}
+	return &ExternalCacheBackend{
+		store:   store,
+		channel: invalidationChannel,
+		origin:  uuid.NewString(),
+		caches:  make(map[string]map[*evaluatorCache]struct{}),
+	}
+}
+
+// Start listens to the invalidation channel, purging the in-memory layers of the evaluator caches as told by the other
+// replicas, until the context is done
+func (b *ExternalCacheBackend) Start(ctx context.Context) error {
+	logger := log.WithName("cache").WithName("invalidation")
+	messages := b.store.Subscribe(ctx, b.channel)
+	for {
+		select {
+		case <-ctx.Done():
+			return b.store.Close()
+		case message, ok := <-messages:
+			if !ok {
+				return nil
+			}
+			var invalidation cacheInvalidation
+			if err := gojson.Unmarshal(message, &invalidation); err != nil {
+				logger.Error(err, "invalid cache invalidation message")
+				continue
+			}
+			if invalidation.Origin == b.origin {
+				continue
+			}
+			purged := b.purgeLocal(invalidation.Cache, invalidation.Prefix)
+			logger.V(1).Info("evaluator cache purged", "cache", invalidation.Cache, "prefix", invalidation.Prefix, "purged", purged)
+		}
+	}
+}
+
+func (b *ExternalCacheBackend) register(c *evaluatorCache) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.caches[c.id] == nil {
+		b.caches[c.id] = make(map[*evaluatorCache]struct{})
+	}
+	b.caches[c.id][c] = struct{}{}
+}
+
+func (b *ExternalCacheBackend) unregister(c *evaluatorCache) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.caches[c.id], c)
+	if len(b.caches[c.id]) == 0 {
+		delete(b.caches, c.id)
+	}
+}
+
+func (b *ExternalCacheBackend) get(id string, key interface{}) ([]byte, time.Duration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), externalCacheTimeout)
+	defer cancel()
+	return b.store.Get(ctx, externalCacheKey(id, cacheKeyString(key)))
+}
+
+func (b *ExternalCacheBackend) set(id string, key interface{}, value []byte, ttl time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), externalCacheTimeout)
+	defer cancel()
+	return b.store.Set(ctx, externalCacheKey(id, cacheKeyString(key)), value, ttl)
+}
+
+func (b *ExternalCacheBackend) setIfAbsent(id, key string, value []byte, ttl time.Duration) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), externalCacheTimeout)
+	defer cancel()
+	return b.store.SetIfAbsent(ctx, externalCacheKey(id, key), value, ttl)
+}
+
+// purge deletes the external entries of an evaluator cache whose keys start with the given prefix and tells the other
+// replicas to purge their in-memory entries
+func (b *ExternalCacheBackend) purge(id, prefix string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), externalCachePurgeTimeout)
+	defer cancel()
+
+	purged, err := b.store.DeletePrefix(ctx, externalCacheKey(id, prefix))
+	if err != nil {
+		return purged, err
+	}
+
+	message, _ := gojson.Marshal(cacheInvalidation{Origin: b.origin, Cache: id, Prefix: prefix})
+	return purged, b.store.Publish(ctx, b.channel, message)
+}
+
+// purgeLocal purges the in-memory entries of the evaluator caches with a given id
+func (b *ExternalCacheBackend) purgeLocal(id, prefix string) int {
+	b.mu.RLock()
+	caches := make([]*evaluatorCache, 0, len(b.caches[id]))
+	for c := range b.caches[id] {
+		caches = append(caches, c)
+	}
+	b.mu.RUnlock()
+
+	purged := 0
+	for _, c := range caches {
+		n, _ := c.purgeLocal(prefix)
+		purged += n
+	}
+	return purged
+}
+
+func externalCacheKey(id, key string) string {
+	return externalCacheKeyPrefix + id + ":" + key
+}
@@ -0,0 +1,171 @@
+package evaluators
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/json"
+
+	"gotest.tools/assert"
+)
+
+// externalCacheStoreMock is an in-memory external cache store shared by the replicas of a test
+type externalCacheStoreMock struct {
+	entries     map[string][]byte
+	subscribers []chan []byte
+	// deadline of the context of the last purge
+	purgeDeadline time.Time
+	mu            sync.Mutex
+}
+
+func newExternalCacheStoreMock() *externalCacheStoreMock {
+	return &externalCacheStoreMock{entries: make(map[string][]byte)}
+}
+
+func (s *externalCacheStoreMock) Get(_ context.Context, key string) ([]byte, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if value, ok := s.entries[key]; ok {
+		return value, time.Minute, nil
+	}
+	return nil, 0, nil
+}
+
+func (s *externalCacheStoreMock) Set(_ context.Context, key string, value []byte, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = value
+	return nil
+}
+
+func (s *externalCacheStoreMock) SetIfAbsent(_ context.Context, key string, value []byte, _ time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, found := s.entries[key]; found {
+		return false, nil
+	}
+	s.entries[key] = value
+	return true, nil
+}
+
+func (s *externalCacheStoreMock) DeletePrefix(ctx context.Context, prefix string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.purgeDeadline, _ = ctx.Deadline()
+	deleted := 0
+	for key := range s.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.entries, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (s *externalCacheStoreMock) Publish(_ context.Context, _ string, message []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, subscriber := range s.subscribers {
+		subscriber <- message
+	}
+	return nil
+}
+
+func (s *externalCacheStoreMock) Subscribe(_ context.Context, _ string) <-chan []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	subscriber := make(chan []byte, 10)
+	s.subscribers = append(s.subscribers, subscriber)
+	return subscriber
+}
+
+func (s *externalCacheStoreMock) Close() error {
+	return nil
+}
+
+func (s *externalCacheStoreMock) waitForSubscribers(n int) {
+	for {
+		s.mu.Lock()
+		subscribed := len(s.subscribers)
+		s.mu.Unlock()
+		if subscribed >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func newTestReplicaCache(t *testing.T, store ExternalCacheStore) EvaluatorCache {
+	backend := NewExternalCacheBackend(store, "")
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go func() { _ = backend.Start(ctx) }()
+
+	ExternalCache = backend
+	defer func() { ExternalCache = nil }()
+	EvaluatorCacheSize = 1
+	return NewEvaluatorCache("ns/authconfig/identity/jwt", json.JSONValue{Pattern: "auth.identity.sub"}, 60)
+}
+
+func TestExternalCacheSharedBetweenReplicas(t *testing.T) {
+	store := newExternalCacheStoreMock()
+	replica1 := newTestReplicaCache(t, store)
+	replica2 := newTestReplicaCache(t, store)
+
+	assert.NilError(t, replica1.Set("john", map[string]interface{}{"sub": "john"}))
+
+	value, err := replica2.Get("john")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, value, map[string]interface{}{"sub": "john"})
+}
+
+func TestExternalCacheInvalidation(t *testing.T) {
+	store := newExternalCacheStoreMock()
+	replica1 := newTestReplicaCache(t, store)
+	replica2 := newTestReplicaCache(t, store)
+	store.waitForSubscribers(2)
+
+	assert.NilError(t, replica1.Set("john", "revoked-soon"))
+	assert.NilError(t, replica1.Set("jane", "kept"))
+	_, _ = replica2.Get("john") // warms up the in-memory cache of the other replica
+	_, _ = replica2.Get("jane")
+
+	purged, err := replica1.Purge("jo")
+	assert.NilError(t, err)
+	assert.Equal(t, purged, 1)
+	// the purge is not bound to the timeout of reading and writing single entries
+	assert.Check(t, time.Until(store.purgeDeadline) > externalCacheTimeout)
+
+	// the in-memory cache of the other replica is purged upon the invalidation message
+	deadline := time.Now().Add(time.Second)
+	for replica2.(*evaluatorCache).client.EntryCount() > 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	value, _ := replica2.Get("john")
+	assert.Check(t, value == nil)
+	value, _ = replica2.Get("jane")
+	assert.Equal(t, value, "kept")
+}
+
+func TestEvaluatorCachePurgeNonStringKeys(t *testing.T) {
+	cache := NewEvaluatorCache("test", json.JSONValue{}, 60)
+	defer cache.Shutdown()
+
+	assert.NilError(t, cache.Set(map[string]interface{}{"sub": "john", "tenant": "acme"}, "john's"))
+	assert.NilError(t, cache.Set(map[string]interface{}{"sub": "jane", "tenant": "acme"}, "jane's"))
+	assert.NilError(t, cache.Set([]interface{}{"john", "GET"}, "john's GET"))
+
+	purged, err := cache.Purge(`{"sub":"john"`)
+	assert.NilError(t, err)
+	assert.Equal(t, purged, 1)
+
+	value, _ := cache.Get(map[string]interface{}{"sub": "john", "tenant": "acme"})
+	assert.Check(t, value == nil)
+	value, _ = cache.Get(map[string]interface{}{"sub": "jane", "tenant": "acme"})
+	assert.Equal(t, value, "jane's")
+	value, _ = cache.Get([]interface{}{"john", "GET"})
+	assert.Equal(t, value, "john's GET")
+}
@@ -0,0 +1,120 @@
+package evaluators
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// number of keys scanned per call to the SCAN command, and deleted per call to the UNLINK command
+const redisScanCount = 1000
+
+// NewRedisCacheStore returns an external store of the evaluator caches kept in a Redis server, whose pub/sub channels
+// carry the invalidation messages between the replicas
+func NewRedisCacheStore(redisURL string) (ExternalCacheStore, error) {
+	options, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, err
+	}
+	return &redisCacheStore{client: redis.NewClient(options)}, nil
+}
+
+type redisCacheStore struct {
+	client *redis.Client
+}
+
+func (s *redisCacheStore) Get(ctx context.Context, key string) ([]byte, time.Duration, error) {
+	pipe := s.client.Pipeline()
+	get := pipe.Get(ctx, key)
+	ttl := pipe.PTTL(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		if err == redis.Nil {
+			return nil, 0, nil
+		}
+		return nil, 0, err
+	}
+	value, _ := get.Bytes()
+	if ttl.Val() <= 0 {
+		return nil, 0, nil
+	}
+	return value, ttl.Val(), nil
+}
+
+func (s *redisCacheStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return s.client.Set(ctx, key, value, ttl).Err()
+}
+
+func (s *redisCacheStore) SetIfAbsent(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	return s.client.SetNX(ctx, key, value, ttl).Result()
+}
+
+// DeletePrefix deletes the keys that match the prefix page by page, as they are scanned, instead of one by one
+func (s *redisCacheStore) DeletePrefix(ctx context.Context, prefix string) (int, error) {
+	pattern := redisGlobEscape(prefix) + "*"
+	deleted := 0
+	var cursor uint64
+	for {
+		keys, next, err := s.client.Scan(ctx, cursor, pattern, redisScanCount).Result()
+		if err != nil {
+			return deleted, err
+		}
+		if len(keys) > 0 {
+			n, err := s.client.Unlink(ctx, keys...).Result()
+			if err != nil {
+				return deleted, err
+			}
+			deleted += int(n)
+		}
+		if cursor = next; cursor == 0 {
+			return deleted, nil
+		}
+	}
+}
+
+func (s *redisCacheStore) Publish(ctx context.Context, channel string, message []byte) error {
+	return s.client.Publish(ctx, channel, message).Err()
+}
+
+func (s *redisCacheStore) Subscribe(ctx context.Context, channel string) <-chan []byte {
+	messages := make(chan []byte)
+	pubsub := s.client.Subscribe(ctx, channel)
+	go func() {
+		defer close(messages)
+		defer pubsub.Close()
+		ch := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case message, ok := <-ch:
+				if !ok {
+					return
+				}
+				select {
+				case messages <- []byte(message.Payload):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return messages
+}
+
+func (s *redisCacheStore) Close() error {
+	return s.client.Close()
+}
+
+// redisGlobEscape escapes the special characters of the glob-style patterns of the SCAN command
+func redisGlobEscape(s string) string {
+	escaped := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '*', '?', '[', ']', '\\':
+			escaped = append(escaped, '\\')
+		}
+		escaped = append(escaped, s[i])
+	}
+	return string(escaped)
+}
@@ -3,14 +3,27 @@ package evaluators
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/kuadrant/authorino/pkg/auth"
+	"github.com/kuadrant/authorino/pkg/evaluators/callbacks"
 	"github.com/kuadrant/authorino/pkg/evaluators/metadata"
 	"github.com/kuadrant/authorino/pkg/jsonexp"
 	"github.com/kuadrant/authorino/pkg/log"
+	"github.com/kuadrant/authorino/pkg/utils"
 )
 
-const callbackHTTP = "CALLBACK_HTTP"
+const (
+	callbackHTTP  = "CALLBACK_HTTP"
+	callbackKafka = "CALLBACK_KAFKA"
+
+	DefaultCallbackTimeout = 5000 // milliseconds
+
+	// outcomes of the auth pipeline that can trigger callbacks
+	CallbackEventAllow = "allow"
+	CallbackEventDeny  = "deny"
+	CallbackEventError = "error"
+)
 
 func NewCallbackConfig(name string, priority int, conditions jsonexp.Expression, metricsEnabled bool) *CallbackConfig {
 	callbackConfig := CallbackConfig{
@@ -28,19 +41,30 @@ type CallbackConfig struct {
 	Priority   int                `yaml:"priority"`
 	Conditions jsonexp.Expression `yaml:"conditions"`
 	Metrics    bool               `yaml:"metrics"`
+	Timeout    time.Duration      `yaml:"timeout"`
+	Events     []string           `yaml:"events"`
 
-	HTTP *metadata.GenericHttp `yaml:"http,omitempty"`
+	HTTP  *metadata.GenericHttp `yaml:"http,omitempty"`
+	Kafka *callbacks.Kafka      `yaml:"kafka,omitempty"`
 }
 
 func (config *CallbackConfig) GetAuthConfigEvaluator() auth.AuthConfigEvaluator {
 	switch config.GetType() {
 	case callbackHTTP:
 		return config.HTTP
+	case callbackKafka:
+		return config.Kafka
 	default:
 		return nil
 	}
 }
 
+// TriggeredBy tells whether the callback must be fired for a given outcome of the auth pipeline.
+// Callbacks that do not filter by event are fired for every outcome.
+func (config *CallbackConfig) TriggeredBy(event string) bool {
+	return len(config.Events) == 0 || utils.SliceContains(config.Events, event)
+}
+
 // impl:AuthConfigEvaluator
 
 func (config *CallbackConfig) Call(pipeline auth.AuthPipeline, ctx context.Context) (interface{}, error) {
@@ -49,6 +73,13 @@ func (config *CallbackConfig) Call(pipeline auth.AuthPipeline, ctx context.Conte
 	} else {
 		logger := log.FromContext(ctx).WithName("callback")
 
+		timeout := config.Timeout
+		if timeout <= 0 {
+			timeout = DefaultCallbackTimeout * time.Millisecond
+		}
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
 		obj, err := evaluator.Call(pipeline, log.IntoContext(ctx, logger))
 
 		return obj, err
@@ -67,6 +98,8 @@ func (config *CallbackConfig) GetType() string {
 	switch {
 	case config.HTTP != nil:
 		return callbackHTTP
+	case config.Kafka != nil:
+		return callbackKafka
 	default:
 		return ""
 	}
@@ -89,3 +122,14 @@ func (config *CallbackConfig) GetConditions() jsonexp.Expression {
 func (config *CallbackConfig) MetricsEnabled() bool {
 	return config.Metrics
 }
+
+// impl:AuthConfigCleaner
+
+func (config *CallbackConfig) Clean(ctx context.Context) error {
+	if config.Kafka != nil {
+		logger := log.FromContext(ctx).WithName("callback")
+		return config.Kafka.Clean(log.IntoContext(ctx, logger))
+	}
+	// it is ok for there to be no clean method as not all config types need it
+	return nil
+}
@@ -0,0 +1,57 @@
+package callbacks
+
+import (
+	"github.com/google/uuid"
+)
+
+const (
+	CloudEventsSpecVersion = "1.0"
+	CloudEventsContentType = "application/cloudevents+json"
+	DefaultCloudEventsType = "io.kuadrant.authorino.decision"
+)
+
+// NewCloudEventsEnvelope builds the settings to wrap the records of the auth decisions in CloudEvents (JSON format).
+// The type of the events defaults to "io.kuadrant.authorino.decision"; the source, to "authorino/<authconfig-id>".
+func NewCloudEventsEnvelope(eventType, source, authConfigID string) *CloudEventsEnvelope {
+	if eventType == "" {
+		eventType = DefaultCloudEventsType
+	}
+	if source == "" {
+		source = "authorino/" + authConfigID
+	}
+	return &CloudEventsEnvelope{
+		Type:   eventType,
+		Source: source,
+	}
+}
+
+type CloudEventsEnvelope struct {
+	Type   string
+	Source string
+}
+
+// CloudEvent is an auth decision record wrapped in a CloudEvents v1.0 envelope
+type CloudEvent struct {
+	SpecVersion     string         `json:"specversion"`
+	ID              string         `json:"id"`
+	Type            string         `json:"type"`
+	Source          string         `json:"source"`
+	Subject         string         `json:"subject,omitempty"`
+	Time            string         `json:"time"`
+	DataContentType string         `json:"datacontenttype"`
+	Data            DecisionRecord `json:"data"`
+}
+
+// Wrap returns the record of the auth decision in a CloudEvents envelope
+func (e *CloudEventsEnvelope) Wrap(record DecisionRecord) CloudEvent {
+	return CloudEvent{
+		SpecVersion:     CloudEventsSpecVersion,
+		ID:              uuid.NewString(),
+		Type:            e.Type,
+		Source:          e.Source,
+		Subject:         record.Subject,
+		Time:            record.Time,
+		DataContentType: "application/json",
+		Data:            record,
+	}
+}
@@ -0,0 +1,40 @@
+package callbacks
+
+import (
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/json"
+
+	"github.com/tidwall/gjson"
+)
+
+// DecisionRecord is the record of an auth decision published by the callbacks
+type DecisionRecord struct {
+	Time    string                 `json:"time"`
+	Host    string                 `json:"host"`
+	Subject string                 `json:"subject,omitempty"`
+	Verdict string                 `json:"verdict"`
+	Code    string                 `json:"code"`
+	Timings map[string]interface{} `json:"timings,omitempty"`
+}
+
+// NewDecisionRecord builds the record of the auth decision out of the authorization JSON.
+// It requires the decision to be already set in the authorization JSON, i.e. only available to the callbacks.
+func NewDecisionRecord(authJSON string, subject json.JSONValue) DecisionRecord {
+	record := DecisionRecord{
+		Time:    time.Now().UTC().Format(time.RFC3339Nano),
+		Host:    gjson.Get(authJSON, "context.request.http.host").String(),
+		Verdict: gjson.Get(authJSON, "auth.decision.verdict").String(),
+		Code:    gjson.Get(authJSON, "auth.decision.code").String(),
+	}
+
+	if value, _ := json.StringifyJSON(subject.ResolveFor(authJSON)); value != "" {
+		record.Subject = value
+	}
+
+	if timings, ok := gjson.Get(authJSON, "auth.decision.timings").Value().(map[string]interface{}); ok && len(timings) > 0 {
+		record.Timings = timings
+	}
+
+	return record
+}
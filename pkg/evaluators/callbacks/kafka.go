@@ -0,0 +1,133 @@
+package callbacks
+
+import (
+	"context"
+	gojson "encoding/json"
+	"fmt"
+
+	"github.com/kuadrant/authorino/pkg/auth"
+	"github.com/kuadrant/authorino/pkg/json"
+
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	KafkaJSONSerialization     = "json"
+	KafkaProtobufSerialization = "protobuf"
+
+	DefaultKafkaSubjectSelector = "auth.identity.sub"
+)
+
+// KafkaWriter publishes messages to a Kafka topic
+type KafkaWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// NewKafkaPublisher builds a callback that publishes records of the auth decisions to a Kafka topic.
+// Records are serialized either as JSON or as protobuf (google.protobuf.Struct), and keyed by the subject of the
+// decision, so records of a same subject land in the same partition.
+// If omitted, the subject is resolved from the `sub` claim of the identity object.
+// Optionally, the records can be wrapped in CloudEvents envelopes (structured content mode), which requires JSON
+// serialization.
+func NewKafkaPublisher(brokers []string, topic string, serialization string, subject *json.JSONValue, cloudEvents *CloudEventsEnvelope, writer KafkaWriter) (*Kafka, error) {
+	switch serialization {
+	case "":
+		serialization = KafkaJSONSerialization
+	case KafkaJSONSerialization, KafkaProtobufSerialization:
+	default:
+		return nil, fmt.Errorf("unsupported serialization: %s", serialization)
+	}
+
+	if cloudEvents != nil && serialization != KafkaJSONSerialization {
+		return nil, fmt.Errorf("cloudevents envelope requires json serialization")
+	}
+
+	if subject == nil {
+		subject = &json.JSONValue{Pattern: DefaultKafkaSubjectSelector}
+	}
+
+	if writer == nil {
+		if len(brokers) == 0 {
+			return nil, fmt.Errorf("missing kafka brokers")
+		}
+		writer = &kafka.Writer{
+			Addr:     kafka.TCP(brokers...),
+			Topic:    topic,
+			Balancer: &kafka.Hash{},
+		}
+	}
+
+	return &Kafka{
+		Brokers:       brokers,
+		Topic:         topic,
+		Serialization: serialization,
+		Subject:       *subject,
+		CloudEvents:   cloudEvents,
+		writer:        writer,
+	}, nil
+}
+
+type Kafka struct {
+	Brokers       []string
+	Topic         string
+	Serialization string
+	Subject       json.JSONValue
+	CloudEvents   *CloudEventsEnvelope
+
+	writer KafkaWriter
+}
+
+func (k *Kafka) Call(pipeline auth.AuthPipeline, ctx context.Context) (interface{}, error) {
+	authJSON := pipeline.GetAuthorizationJSON()
+	record := NewDecisionRecord(authJSON, k.Subject)
+
+	message := kafka.Message{Key: []byte(record.Subject)}
+
+	var payload interface{} = record
+	if k.CloudEvents != nil {
+		payload = k.CloudEvents.Wrap(record)
+		message.Headers = []kafka.Header{{Key: "content-type", Value: []byte(CloudEventsContentType)}}
+	}
+
+	value, err := k.serialize(payload)
+	if err != nil {
+		return nil, err
+	}
+	message.Value = value
+
+	if err := k.writer.WriteMessages(ctx, message); err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+// impl:AuthConfigCleaner
+
+func (k *Kafka) Clean(_ context.Context) error {
+	return k.writer.Close()
+}
+
+func (k *Kafka) serialize(record interface{}) ([]byte, error) {
+	value, err := gojson.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+
+	if k.Serialization != KafkaProtobufSerialization {
+		return value, nil
+	}
+
+	var obj map[string]interface{}
+	if err := gojson.Unmarshal(value, &obj); err != nil {
+		return nil, err
+	}
+	message, err := structpb.NewStruct(obj)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(message)
+}
@@ -0,0 +1,122 @@
+package callbacks
+
+import (
+	"context"
+	gojson "encoding/json"
+	"testing"
+
+	mock_auth "github.com/kuadrant/authorino/pkg/auth/mocks"
+	"github.com/kuadrant/authorino/pkg/json"
+
+	. "github.com/golang/mock/gomock"
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"gotest.tools/assert"
+)
+
+const decisionAuthJSON = `{"context":{"request":{"http":{"host":"talker-api"}}},"auth":{"identity":{"sub":"john","username":"jdoe"},"decision":{"verdict":"deny","code":"PERMISSION_DENIED","timings":{"identity":{"jwt":1.5},"authorization":{"rbac":0.25}}}}}`
+
+type kafkaWriterMock struct {
+	messages []kafka.Message
+	closed   bool
+}
+
+func (w *kafkaWriterMock) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	w.messages = append(w.messages, msgs...)
+	return nil
+}
+
+func (w *kafkaWriterMock) Close() error {
+	w.closed = true
+	return nil
+}
+
+func TestKafkaPublisher(t *testing.T) {
+	ctrl := NewController(t)
+	defer ctrl.Finish()
+
+	writer := &kafkaWriterMock{}
+	publisher, err := NewKafkaPublisher([]string{"kafka:9092"}, "auth-decisions", "", nil, nil, writer)
+	assert.NilError(t, err)
+	assert.Equal(t, publisher.Serialization, KafkaJSONSerialization)
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(decisionAuthJSON)
+
+	_, err = publisher.Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+	assert.Equal(t, len(writer.messages), 1)
+	assert.Equal(t, string(writer.messages[0].Key), "john")
+
+	var record map[string]interface{}
+	assert.NilError(t, gojson.Unmarshal(writer.messages[0].Value, &record))
+	assert.Equal(t, record["host"], "talker-api")
+	assert.Equal(t, record["subject"], "john")
+	assert.Equal(t, record["verdict"], "deny")
+	assert.Equal(t, record["code"], "PERMISSION_DENIED")
+	assert.DeepEqual(t, record["timings"], map[string]interface{}{"identity": map[string]interface{}{"jwt": 1.5}, "authorization": map[string]interface{}{"rbac": 0.25}})
+
+	assert.NilError(t, publisher.Clean(context.TODO()))
+	assert.Check(t, writer.closed)
+}
+
+func TestKafkaPublisherWithProtobufSerialization(t *testing.T) {
+	ctrl := NewController(t)
+	defer ctrl.Finish()
+
+	writer := &kafkaWriterMock{}
+	publisher, err := NewKafkaPublisher([]string{"kafka:9092"}, "auth-decisions", KafkaProtobufSerialization, &json.JSONValue{Pattern: "auth.identity.username"}, nil, writer)
+	assert.NilError(t, err)
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(decisionAuthJSON)
+
+	_, err = publisher.Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+	assert.Equal(t, string(writer.messages[0].Key), "jdoe")
+
+	record := &structpb.Struct{}
+	assert.NilError(t, proto.Unmarshal(writer.messages[0].Value, record))
+	assert.Equal(t, record.Fields["subject"].GetStringValue(), "jdoe")
+	assert.Equal(t, record.Fields["verdict"].GetStringValue(), "deny")
+}
+
+func TestKafkaPublisherInvalidSettings(t *testing.T) {
+	_, err := NewKafkaPublisher([]string{"kafka:9092"}, "auth-decisions", "avro", nil, nil, nil)
+	assert.Error(t, err, "unsupported serialization: avro")
+
+	_, err = NewKafkaPublisher(nil, "auth-decisions", "", nil, nil, nil)
+	assert.Error(t, err, "missing kafka brokers")
+
+	_, err = NewKafkaPublisher([]string{"kafka:9092"}, "auth-decisions", KafkaProtobufSerialization, nil, NewCloudEventsEnvelope("", "", "ns/authconfig"), nil)
+	assert.Error(t, err, "cloudevents envelope requires json serialization")
+}
+
+func TestKafkaPublisherWithCloudEvents(t *testing.T) {
+	ctrl := NewController(t)
+	defer ctrl.Finish()
+
+	writer := &kafkaWriterMock{}
+	publisher, err := NewKafkaPublisher([]string{"kafka:9092"}, "auth-decisions", "", nil, NewCloudEventsEnvelope("", "", "ns/authconfig"), writer)
+	assert.NilError(t, err)
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(decisionAuthJSON)
+
+	_, err = publisher.Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+	assert.Equal(t, string(writer.messages[0].Key), "john")
+	assert.DeepEqual(t, writer.messages[0].Headers, []kafka.Header{{Key: "content-type", Value: []byte("application/cloudevents+json")}})
+
+	var event map[string]interface{}
+	assert.NilError(t, gojson.Unmarshal(writer.messages[0].Value, &event))
+	assert.Equal(t, event["specversion"], "1.0")
+	assert.Equal(t, event["type"], "io.kuadrant.authorino.decision")
+	assert.Equal(t, event["source"], "authorino/ns/authconfig")
+	assert.Equal(t, event["subject"], "john")
+	assert.Equal(t, event["datacontenttype"], "application/json")
+	assert.Check(t, event["id"] != "")
+	assert.Equal(t, event["time"], event["data"].(map[string]interface{})["time"])
+	assert.Equal(t, event["data"].(map[string]interface{})["verdict"], "deny")
+}
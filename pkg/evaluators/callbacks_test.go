@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/kuadrant/authorino/pkg/auth"
 	mock_auth "github.com/kuadrant/authorino/pkg/auth/mocks"
@@ -47,3 +48,44 @@ func TestCallbacks(t *testing.T) {
 	assert.Equal(t, fmt.Sprintf("%s", obj), "OK")
 	assert.Check(t, called)
 }
+
+func TestCallbacksTimeout(t *testing.T) {
+	extHttpCallbackServer := httptest.NewHttpServerMock(testCallbackServerHost, map[string]httptest.HttpServerMockResponseFunc{
+		"/callback": func() httptest.HttpServerMockResponse {
+			time.Sleep(100 * time.Millisecond)
+			return httptest.NewHttpServerMockResponseFuncPlain("OK")()
+		},
+	})
+	defer extHttpCallbackServer.Close()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	callbackConfig := CallbackConfig{
+		Name:    "test",
+		Timeout: 10 * time.Millisecond,
+		HTTP: &metadata.GenericHttp{
+			Endpoint:        fmt.Sprintf("http://%s/callback", testCallbackServerHost),
+			Method:          "GET",
+			AuthCredentials: auth.NewAuthCredential("", "authorization_header"),
+		},
+	}
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{}`)
+
+	_, err := callbackConfig.Call(pipelineMock, context.TODO())
+	assert.ErrorContains(t, err, "context deadline exceeded")
+}
+
+func TestCallbacksTriggeredBy(t *testing.T) {
+	callbackConfig := CallbackConfig{Name: "test"}
+	assert.Check(t, callbackConfig.TriggeredBy(CallbackEventAllow))
+	assert.Check(t, callbackConfig.TriggeredBy(CallbackEventDeny))
+	assert.Check(t, callbackConfig.TriggeredBy(CallbackEventError))
+
+	callbackConfig.Events = []string{CallbackEventDeny, CallbackEventError}
+	assert.Check(t, !callbackConfig.TriggeredBy(CallbackEventAllow))
+	assert.Check(t, callbackConfig.TriggeredBy(CallbackEventDeny))
+	assert.Check(t, callbackConfig.TriggeredBy(CallbackEventError))
+}
@@ -2,12 +2,17 @@ package evaluators
 
 import (
 	"context"
+	gojson "encoding/json"
 	"fmt"
+	"hash/fnv"
+	"net/http"
 	"sync"
 
 	"github.com/kuadrant/authorino/pkg/auth"
+	"github.com/kuadrant/authorino/pkg/evaluators/identity"
 	"github.com/kuadrant/authorino/pkg/json"
 	"github.com/kuadrant/authorino/pkg/jsonexp"
+	"github.com/kuadrant/authorino/pkg/log"
 
 	multierror "github.com/hashicorp/go-multierror"
 )
@@ -23,9 +28,56 @@ type AuthConfig struct {
 	ResponseConfigs      []auth.AuthConfigEvaluator `yaml:"response,omitempty"`
 	CallbackConfigs      []auth.AuthConfigEvaluator `yaml:"callbacks,omitempty"`
 
+	// AuthorizationStrategy is how the verdicts of the AuthorizationConfigs are combined; defaults to AllOfAuthorizationStrategy
+	AuthorizationStrategy string `yaml:"authorizationStrategy,omitempty"`
+
+	// DynamicMetadataNamespace is the root property under which the response objects wrapped as Envoy Dynamic Metadata are emitted
+	DynamicMetadataNamespace string `yaml:"dynamicMetadataNamespace,omitempty"`
+
+	// QueryParametersToRemove are the query parameters to remove from the request forwarded upstream on success
+	QueryParametersToRemove []string `yaml:"queryParametersToRemove,omitempty"`
+
+	// Canary is a new revision of the config rolled out to a share of the requests only, while the config itself keeps
+	// serving the rest; CanaryWeight is the percentage of the requests evaluated with the canary
+	Canary       *AuthConfig
+	CanaryWeight int
+
+	// LogLevel overrides the log level of the auth pipelines evaluated with the config; the log level of the instance
+	// applies if nil
+	LogLevel *log.LogLevel
+
+	// InFlight tracks the requests being evaluated with the config (shared by its copies), so the config can be cleaned
+	// once they are finished; requests are not tracked if nil
+	InFlight *InFlightRequests
+
 	DenyWith
 }
 
+// Stateful tells whether evaluating the config changes state, thus identical requests cannot share the result of a single
+// evaluation, e.g. proofs of possession remembered against replays, quotas consumed and callbacks fired.
+// Nil configs are stateless.
+func (config *AuthConfig) Stateful() bool {
+	if config == nil {
+		return false
+	}
+	if len(config.CallbackConfigs) > 0 {
+		return true
+	}
+	for _, evaluator := range config.IdentityConfigs {
+		if identityConfig, ok := evaluator.(*IdentityConfig); ok {
+			if _, bound := identityConfig.GetAuthConfigEvaluator().(identity.BoundTokenVerifier); bound {
+				return true
+			}
+		}
+	}
+	for _, evaluator := range config.AuthorizationConfigs {
+		if authorizationConfig, ok := evaluator.(*AuthorizationConfig); ok && authorizationConfig.Quota != nil {
+			return true
+		}
+	}
+	return config.Canary.Stateful()
+}
+
 func (config *AuthConfig) GetChallengeHeaders() []map[string]string {
 	challengeHeaders := make([]map[string]string, 0)
 
@@ -39,13 +91,23 @@ func (config *AuthConfig) GetChallengeHeaders() []map[string]string {
 	return challengeHeaders
 }
 
+// Version returns the version of the config a request is evaluated with, and whether it is the canary.
+// The requests are split between the versions by the hash of their ids, so a request is always evaluated with the
+// same version.
+func (config *AuthConfig) Version(requestId string) (*AuthConfig, bool) {
+	if config.Canary == nil {
+		return config, false
+	}
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(requestId))
+	if int(hash.Sum32()%100) < config.CanaryWeight {
+		return config.Canary, true
+	}
+	return config, false
+}
+
 func (config *AuthConfig) Clean(ctx context.Context) error {
-	evaluators := []auth.AuthConfigEvaluator{}
-	evaluators = append(evaluators, config.IdentityConfigs...)
-	evaluators = append(evaluators, config.MetadataConfigs...)
-	evaluators = append(evaluators, config.AuthorizationConfigs...)
-	evaluators = append(evaluators, config.ResponseConfigs...)
-	evaluators = append(evaluators, config.CallbackConfigs...)
+	evaluators := config.evaluators()
 
 	var errors error
 	var wait sync.WaitGroup
@@ -67,14 +129,158 @@ func (config *AuthConfig) Clean(ctx context.Context) error {
 	return errors
 }
 
+// PurgeCache deletes the cached results of the evaluators whose cache keys start with the given prefix, or all the
+// cached results if the prefix is empty. It returns the number of cache entries deleted.
+func (config *AuthConfig) PurgeCache(prefix string) (int, error) {
+	var purged int
+	var errors error
+
+	for _, evaluator := range config.evaluators() {
+		var cache EvaluatorCache
+		switch e := evaluator.(type) {
+		case *IdentityConfig:
+			cache = e.Cache
+		case *MetadataConfig:
+			cache = e.Cache
+		case *AuthorizationConfig:
+			cache = e.Cache
+		case *ResponseConfig:
+			cache = e.Cache
+		}
+		if cache == nil {
+			continue
+		}
+		n, err := cache.Purge(prefix)
+		if err != nil {
+			errors = multierror.Append(errors, err)
+		}
+		purged += n
+	}
+
+	return purged, errors
+}
+
+func (config *AuthConfig) evaluators() []auth.AuthConfigEvaluator {
+	evaluators := []auth.AuthConfigEvaluator{}
+	evaluators = append(evaluators, config.IdentityConfigs...)
+	evaluators = append(evaluators, config.MetadataConfigs...)
+	evaluators = append(evaluators, config.AuthorizationConfigs...)
+	evaluators = append(evaluators, config.ResponseConfigs...)
+	evaluators = append(evaluators, config.CallbackConfigs...)
+	if config.Canary != nil {
+		evaluators = append(evaluators, config.Canary.evaluators()...)
+	}
+	return evaluators
+}
+
 type DenyWith struct {
 	Unauthenticated *DenyWithValues
 	Unauthorized    *DenyWithValues
 }
 
 type DenyWithValues struct {
-	Code    int32
-	Message *json.JSONValue
-	Headers []json.JSONProperty
-	Body    *json.JSONValue
+	Code           int32
+	Message        *json.JSONValue
+	Headers        []json.JSONProperty
+	Body           *json.JSONValue
+	RedirectTo     *json.JSONValue
+	ProblemDetails *ProblemDetails
+}
+
+const (
+	ProblemDetailsContentType = "application/problem+json"
+	DefaultProblemDetailsType = "about:blank"
+)
+
+// ProblemDetails formats the body of the denial responses as RFC 7807 problem details (application/problem+json)
+type ProblemDetails struct {
+	Type  string
+	Title string
+}
+
+type problemDetailsObject struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// Build returns the problem details object of a denial, serialized as JSON.
+// The type defaults to "about:blank" and the title, to the standard text of the HTTP status code.
+func (p *ProblemDetails) Build(status int, detail, instance string) string {
+	problem := problemDetailsObject{
+		Type:     p.Type,
+		Title:    p.Title,
+		Status:   status,
+		Detail:   detail,
+		Instance: instance,
+	}
+	if problem.Type == "" {
+		problem.Type = DefaultProblemDetailsType
+	}
+	if problem.Title == "" {
+		problem.Title = http.StatusText(status)
+	}
+	body, _ := gojson.Marshal(problem)
+	return string(body)
+}
+
+func NewInFlightRequests() *InFlightRequests {
+	return &InFlightRequests{}
+}
+
+// InFlightRequests counts the requests in flight evaluated with a config
+type InFlightRequests struct {
+	count int
+	idle  chan struct{} // closed when the count drops to zero
+	mu    sync.Mutex
+}
+
+// Add counts one request in flight and returns the function to call when the request is finished.
+// Nil counters count nothing.
+func (r *InFlightRequests) Add() (done func()) {
+	if r == nil {
+		return func() {}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.count == 0 {
+		r.idle = make(chan struct{})
+	}
+	r.count++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			if r.count--; r.count == 0 {
+				close(r.idle)
+			}
+		})
+	}
+}
+
+// Wait blocks until there are no requests in flight or the context is done
+func (r *InFlightRequests) Wait(ctx context.Context) error {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	if r.count == 0 {
+		r.mu.Unlock()
+		return nil
+	}
+	idle := r.idle
+	r.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
@@ -2,10 +2,14 @@ package evaluators
 
 import (
 	"context"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/kuadrant/authorino/pkg/auth"
 	mock_auth "github.com/kuadrant/authorino/pkg/auth/mocks"
+	"github.com/kuadrant/authorino/pkg/evaluators/authorization"
+	"github.com/kuadrant/authorino/pkg/evaluators/identity"
 
 	"github.com/golang/mock/gomock"
 	"gotest.tools/assert"
@@ -51,3 +55,111 @@ func TestCleanConfig(t *testing.T) {
 		assert.Check(t, ev.cleaned)
 	}
 }
+
+func TestConfigVersion(t *testing.T) {
+	canary := &AuthConfig{Labels: map[string]string{"generation": "2"}}
+	config := &AuthConfig{Labels: map[string]string{"generation": "1"}}
+
+	version, isCanary := config.Version("request-1")
+	assert.Equal(t, version, config)
+	assert.Check(t, !isCanary)
+
+	config.Canary = canary
+	config.CanaryWeight = 25
+
+	var canaries int
+	for i := 0; i < 1000; i++ {
+		requestId := fmt.Sprintf("request-%d", i)
+		version, isCanary := config.Version(requestId)
+		if isCanary {
+			assert.Equal(t, version, canary)
+			canaries++
+		} else {
+			assert.Equal(t, version, config)
+		}
+		// always the same version for the same request
+		again, _ := config.Version(requestId)
+		assert.Equal(t, again, version)
+	}
+	assert.Check(t, canaries > 150 && canaries < 350)
+
+	config.CanaryWeight = 0
+	_, isCanary = config.Version("request-1")
+	assert.Check(t, !isCanary)
+
+	config.CanaryWeight = 100
+	_, isCanary = config.Version("request-1")
+	assert.Check(t, isCanary)
+}
+
+func TestCleanConfigWithCanary(t *testing.T) {
+	ev1 := &authConfigEvaluatorCleanerMock{}
+	ev2 := &authConfigEvaluatorCleanerMock{}
+
+	config := AuthConfig{
+		IdentityConfigs: []auth.AuthConfigEvaluator{ev1},
+		Canary: &AuthConfig{
+			IdentityConfigs: []auth.AuthConfigEvaluator{ev2},
+		},
+	}
+
+	err := config.Clean(context.Background())
+	assert.NilError(t, err)
+	assert.Check(t, ev1.cleaned)
+	assert.Check(t, ev2.cleaned)
+}
+
+func TestInFlightRequests(t *testing.T) {
+	inFlight := NewInFlightRequests()
+	assert.NilError(t, inFlight.Wait(context.Background()))
+
+	request1 := inFlight.Add()
+	request2 := inFlight.Add()
+
+	idle := make(chan error)
+	go func() {
+		idle <- inFlight.Wait(context.Background())
+	}()
+
+	request1()
+	request1() // no-op
+	select {
+	case <-idle:
+		t.Fatal("idle with requests in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	request2()
+	assert.NilError(t, <-idle)
+
+	// the wait is bounded by the context
+	_ = inFlight.Add()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Error(t, inFlight.Wait(ctx), context.DeadlineExceeded.Error())
+
+	// requests are not tracked
+	var untracked *InFlightRequests
+	untracked.Add()()
+	assert.NilError(t, untracked.Wait(context.Background()))
+}
+
+func TestConfigStateful(t *testing.T) {
+	var nilConfig *AuthConfig
+	assert.Check(t, !nilConfig.Stateful())
+
+	stateless := &AuthConfig{
+		IdentityConfigs:      []auth.AuthConfigEvaluator{&IdentityConfig{Name: "api-key", APIKey: &identity.APIKey{}}},
+		AuthorizationConfigs: []auth.AuthConfigEvaluator{&AuthorizationConfig{Name: "rbac", RBAC: &authorization.RBAC{}}},
+	}
+	assert.Check(t, !stateless.Stateful())
+
+	// proofs of possession remembered against replays
+	assert.Check(t, (&AuthConfig{IdentityConfigs: []auth.AuthConfigEvaluator{&IdentityConfig{Name: "jwt", OIDC: &identity.OIDC{}}}}).Stateful())
+	// quotas
+	assert.Check(t, (&AuthConfig{AuthorizationConfigs: []auth.AuthConfigEvaluator{&AuthorizationConfig{Name: "quota", Quota: &authorization.Quota{}}}}).Stateful())
+	// callbacks
+	assert.Check(t, (&AuthConfig{CallbackConfigs: []auth.AuthConfigEvaluator{&CallbackConfig{Name: "webhook"}}}).Stateful())
+	// canary
+	assert.Check(t, (&AuthConfig{Canary: &AuthConfig{CallbackConfigs: []auth.AuthConfigEvaluator{&CallbackConfig{Name: "webhook"}}}}).Stateful())
+}
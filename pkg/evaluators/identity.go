@@ -4,6 +4,7 @@ import (
 	"context"
 	gojson "encoding/json"
 	"fmt"
+	"sync"
 
 	"github.com/kuadrant/authorino/pkg/auth"
 	"github.com/kuadrant/authorino/pkg/evaluators/identity"
@@ -16,14 +17,19 @@ import (
 )
 
 const (
-	identityOAuth2     = "IDENTITY_OAUTH2"
-	identityOIDC       = "IDENTITY_OIDC"
-	identityMTLS       = "IDENTITY_MTLS"
-	identityHMAC       = "IDENTITY_HMAC"
-	identityAPIKey     = "IDENTITY_APIKEY"
-	identityKubernetes = "IDENTITY_KUBERNETES"
-	identityPlain      = "IDENTITY_PLAIN"
-	identityNoop       = "IDENTITY_NOOP"
+	identityOAuth2       = "IDENTITY_OAUTH2"
+	identityOIDC         = "IDENTITY_OIDC"
+	identityMTLS         = "IDENTITY_MTLS"
+	identitySpiffe       = "IDENTITY_SPIFFE"
+	identityAwsSigV4     = "IDENTITY_AWS_SIGV4"
+	identityGoogle       = "IDENTITY_GOOGLE_ID_TOKEN"
+	identityGitToken     = "IDENTITY_GIT_TOKEN"
+	identityVaultTransit = "IDENTITY_VAULT_TRANSIT"
+	identityHMAC         = "IDENTITY_HMAC"
+	identityAPIKey       = "IDENTITY_APIKEY"
+	identityKubernetes   = "IDENTITY_KUBERNETES"
+	identityPlain        = "IDENTITY_PLAIN"
+	identityNoop         = "IDENTITY_NOOP"
 )
 
 type IdentityConfig struct {
@@ -33,9 +39,17 @@ type IdentityConfig struct {
 	Metrics    bool               `yaml:"metrics"`
 	Cache      EvaluatorCache
 
+	// StripCredentials tells whether the credentials must be removed from the request forwarded upstream
+	StripCredentials bool `yaml:"stripCredentials"`
+
 	OAuth2         *identity.OAuth2         `yaml:"oauth2,omitempty"`
 	OIDC           *identity.OIDC           `yaml:"oidc,omitempty"`
 	MTLS           *identity.MTLS           `yaml:"mtls,omitempty"`
+	Spiffe         *identity.Spiffe         `yaml:"spiffe,omitempty"`
+	AwsSigV4       *identity.AwsSigV4       `yaml:"awsSigV4,omitempty"`
+	GoogleIDToken  *identity.GoogleIDToken  `yaml:"googleIdToken,omitempty"`
+	GitToken       *identity.GitToken       `yaml:"gitToken,omitempty"`
+	VaultTransit   *identity.VaultTransit   `yaml:"vaultTransit,omitempty"`
 	HMAC           *identity.HMAC           `yaml:"hmac,omitempty"`
 	APIKey         *identity.APIKey         `yaml:"apiKey,omitempty"`
 	KubernetesAuth *identity.KubernetesAuth `yaml:"kubernetes,omitempty"`
@@ -53,6 +67,16 @@ func (config *IdentityConfig) GetAuthConfigEvaluator() auth.AuthConfigEvaluator
 		return config.OIDC
 	case identityMTLS:
 		return config.MTLS
+	case identitySpiffe:
+		return config.Spiffe
+	case identityAwsSigV4:
+		return config.AwsSigV4
+	case identityGoogle:
+		return config.GoogleIDToken
+	case identityGitToken:
+		return config.GitToken
+	case identityVaultTransit:
+		return config.VaultTransit
 	case identityHMAC:
 		return config.HMAC
 	case identityAPIKey:
@@ -88,11 +112,19 @@ func (config *IdentityConfig) Call(pipeline auth.AuthPipeline, ctx context.Conte
 			if cachedObj, err := cache.Get(cacheKey); err != nil {
 				logger.V(1).Error(err, "failed to retrieve data from the cache")
 			} else if cachedObj != nil {
+				if err := verifyTokenBinding(evaluator, pipeline, cachedObj); err != nil {
+					return nil, err
+				}
 				return cachedObj, nil
 			}
 		}
 
 		obj, err := evaluator.Call(pipeline, log.IntoContext(ctx, logger))
+		if err == nil {
+			if err := verifyTokenBinding(evaluator, pipeline, obj); err != nil {
+				return nil, err
+			}
+		}
 
 		if err == nil && cacheKey != nil {
 			if err := cache.Set(cacheKey, obj); err != nil {
@@ -104,6 +136,29 @@ func (config *IdentityConfig) Call(pipeline auth.AuthPipeline, ctx context.Conte
 	}
 }
 
+// verifyTokenBinding verifies the proof of possession of sender-constrained tokens, on every request, whether the
+// identity was read from the cache or not
+func verifyTokenBinding(evaluator auth.AuthConfigEvaluator, pipeline auth.AuthPipeline, obj interface{}) error {
+	if verifier, ok := evaluator.(identity.BoundTokenVerifier); ok {
+		return verifier.VerifyBinding(pipeline, obj, getProofReplayCache())
+	}
+	return nil
+}
+
+var (
+	proofReplays     *proofReplayCache
+	proofReplaysOnce sync.Once
+)
+
+// getProofReplayCache returns the cache of the proofs of possession already presented, shared by all AuthConfigs and,
+// with the external cache backend enabled, by all replicas
+func getProofReplayCache() identity.ProofReplayCache {
+	proofReplaysOnce.Do(func() {
+		proofReplays = newProofReplayCache(identity.ProofReplayWindow, ExternalCache)
+	})
+	return proofReplays
+}
+
 // impl:NamedEvaluator
 
 func (config *IdentityConfig) GetName() string {
@@ -120,6 +175,16 @@ func (config *IdentityConfig) GetType() string {
 		return identityOIDC
 	case config.MTLS != nil:
 		return identityMTLS
+	case config.Spiffe != nil:
+		return identitySpiffe
+	case config.AwsSigV4 != nil:
+		return identityAwsSigV4
+	case config.GoogleIDToken != nil:
+		return identityGoogle
+	case config.GitToken != nil:
+		return identityGitToken
+	case config.VaultTransit != nil:
+		return identityVaultTransit
 	case config.HMAC != nil:
 		return identityHMAC
 	case config.APIKey != nil:
@@ -162,6 +227,8 @@ func (config *IdentityConfig) getCleaner() auth.AuthConfigCleaner {
 	switch {
 	case config.OIDC != nil:
 		return config.OIDC
+	case config.Spiffe != nil:
+		return config.Spiffe
 	default:
 		return nil
 	}
@@ -179,6 +246,44 @@ func (config *IdentityConfig) GetAuthCredentials() auth.AuthCredentials {
 	return creds
 }
 
+// GetCredentialsHeadersToRemove returns the names of the request headers that carry the credentials verified by the
+// identity config, if the credentials are meant to be stripped out from the request forwarded upstream
+func (config *IdentityConfig) GetCredentialsHeadersToRemove() []string {
+	if !config.StripCredentials {
+		return nil
+	}
+	creds, ok := config.GetAuthConfigEvaluator().(auth.AuthCredentials)
+	if !ok {
+		return nil
+	}
+	if header := auth.GetCredentialsHeaderName(creds); header != "" {
+		return []string{header}
+	}
+	return nil
+}
+
+// GetCredentialsHeadersToRewrite returns the request headers that carry the credentials verified by the identity
+// config among other values (i.e. WebSocket subprotocols), with the credentials stripped out, if the credentials are
+// meant to be stripped out from the request forwarded upstream.
+// Headers left with no other values are returned as headers to remove instead.
+func (config *IdentityConfig) GetCredentialsHeadersToRewrite(headers map[string]string) (map[string]string, []string) {
+	if !config.StripCredentials {
+		return nil, nil
+	}
+	creds, ok := config.GetAuthConfigEvaluator().(auth.AuthCredentials)
+	if !ok {
+		return nil, nil
+	}
+	header, value, found := auth.StripCredentialsFromHeader(creds, headers)
+	if !found {
+		return nil, nil
+	}
+	if value == "" {
+		return nil, []string{header}
+	}
+	return map[string]string{header: value}, nil
+}
+
 func (config *IdentityConfig) ResolveExtendedProperties(pipeline auth.AuthPipeline) (interface{}, error) {
 	_, resolvedIdentityObj := pipeline.GetResolvedIdentity()
 
@@ -213,6 +318,8 @@ func (config *IdentityConfig) AddK8sSecretBasedIdentity(ctx context.Context, new
 		ev = config.MTLS
 	case identityAPIKey:
 		ev = config.APIKey
+	case identityAwsSigV4:
+		ev = config.AwsSigV4
 	default:
 		return
 	}
@@ -228,6 +335,8 @@ func (config *IdentityConfig) RevokeK8sSecretBasedIdentity(ctx context.Context,
 		ev = config.MTLS
 	case identityAPIKey:
 		ev = config.APIKey
+	case identityAwsSigV4:
+		ev = config.AwsSigV4
 	default:
 		return
 	}
@@ -243,6 +352,8 @@ func (config *IdentityConfig) GetK8sSecretLabelSelectors() labels.Selector {
 		ev = config.MTLS
 	case identityAPIKey:
 		ev = config.APIKey
+	case identityAwsSigV4:
+		ev = config.AwsSigV4
 	default:
 		return nil
 	}
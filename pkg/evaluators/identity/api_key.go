@@ -47,6 +47,22 @@ func NewApiKeyIdentity(name string, labelSelectors k8s_labels.Selector, namespac
 	return apiKey
 }
 
+// NewApiKeyIdentityFromSecrets returns an API key evaluator that trusts a fixed set of secrets (e.g. read from Vault)
+// instead of the Kubernetes secrets matching a label selector
+func NewApiKeyIdentityFromSecrets(name string, secrets []k8s.Secret, namespace string, authCred auth.AuthCredentials) *APIKey {
+	apiKey := &APIKey{
+		AuthCredentials: authCred,
+		Name:            name,
+		LabelSelectors:  k8s_labels.Nothing(),
+		Namespace:       namespace,
+		secrets:         make(map[string]k8s.Secret),
+	}
+	for _, secret := range secrets {
+		apiKey.appendK8sSecretBasedIdentity(secret)
+	}
+	return apiKey
+}
+
 // loadSecrets will load the matching k8s secrets from the cluster to the cache of trusted API keys
 func (a *APIKey) loadSecrets(ctx context.Context) error {
 	opts := []k8s_client.ListOption{k8s_client.MatchingLabelsSelector{Selector: a.LabelSelectors}}
@@ -111,6 +127,8 @@ func (a *APIKey) AddK8sSecretBasedIdentity(ctx context.Context, new k8s.Secret)
 				delete(a.secrets, oldAPIKeyValue)
 				logger.V(1).Info("api key updated")
 			} else {
+				// refreshes the rest of the secret (e.g. annotations exposed in the identity object)
+				a.secrets[oldAPIKeyValue] = new
 				logger.V(1).Info("api key unchanged")
 			}
 			return
@@ -10,6 +10,7 @@ import (
 	k8s "k8s.io/api/core/v1"
 	k8s_meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8s_labels "k8s.io/apimachinery/pkg/labels"
+	k8s_types "k8s.io/apimachinery/pkg/types"
 
 	gomock "github.com/golang/mock/gomock"
 	"gotest.tools/assert"
@@ -65,6 +66,27 @@ func TestNewApiKeyIdentitySingleNamespace(t *testing.T) {
 	assert.Check(t, !exists)
 }
 
+func TestNewApiKeyIdentityFromSecrets(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	secrets := []k8s.Secret{
+		{ObjectMeta: k8s_meta.ObjectMeta{Name: "obi-wan"}, Data: map[string][]byte{"api_key": []byte("ObiWanKenobiLightSaber")}},
+		{ObjectMeta: k8s_meta.ObjectMeta{Name: "empty"}, Data: map[string][]byte{"api_key": []byte("")}},
+	}
+	apiKey := NewApiKeyIdentityFromSecrets("jedi", secrets, "ns1", mock_auth.NewMockAuthCredentials(ctrl))
+
+	assert.Equal(t, apiKey.Name, "jedi")
+	assert.Equal(t, len(apiKey.secrets), 1)
+	_, exists := apiKey.secrets["ObiWanKenobiLightSaber"]
+	assert.Check(t, exists)
+
+	// kubernetes secrets are not added
+	assert.Check(t, !apiKey.GetK8sSecretLabelSelectors().Matches(k8s_labels.Set(testAPIKeyK8sSecret1.Labels)))
+	apiKey.RevokeK8sSecretBasedIdentity(context.TODO(), k8s_types.NamespacedName{Namespace: "ns1", Name: "obi-wan"})
+	assert.Equal(t, len(apiKey.secrets), 1)
+}
+
 func TestCallSuccess(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -137,6 +159,42 @@ func TestLoadSecretsFail(t *testing.T) {
 	assert.Error(t, err, "something terribly wrong happened")
 }
 
+func TestK8sSecretBasedIdentityUpdates(t *testing.T) {
+	selector, _ := k8s_labels.Parse("planet=coruscant")
+	apiKey := NewApiKeyIdentity("jedi", selector, "", nil, testAPIKeyK8sClient, context.TODO())
+	assert.Equal(t, len(apiKey.secrets), 2)
+
+	// added
+	newSecret := &k8s.Secret{ObjectMeta: k8s_meta.ObjectMeta{Name: "mace", Namespace: "ns1", Labels: map[string]string{"planet": "coruscant"}}, Data: map[string][]byte{"api_key": []byte("MaceWinduLightSaber")}}
+	apiKey.AddK8sSecretBasedIdentity(context.TODO(), *newSecret)
+	assert.Equal(t, len(apiKey.secrets), 3)
+	_, exists := apiKey.secrets["MaceWinduLightSaber"]
+	assert.Check(t, exists)
+
+	// rotated
+	rotatedSecret := newSecret.DeepCopy()
+	rotatedSecret.Data["api_key"] = []byte("MaceWinduPurpleLightSaber")
+	apiKey.AddK8sSecretBasedIdentity(context.TODO(), *rotatedSecret)
+	assert.Equal(t, len(apiKey.secrets), 3)
+	_, exists = apiKey.secrets["MaceWinduLightSaber"]
+	assert.Check(t, !exists)
+	_, exists = apiKey.secrets["MaceWinduPurpleLightSaber"]
+	assert.Check(t, exists)
+
+	// same key, updated metadata
+	annotatedSecret := rotatedSecret.DeepCopy()
+	annotatedSecret.Annotations = map[string]string{"rank": "master"}
+	apiKey.AddK8sSecretBasedIdentity(context.TODO(), *annotatedSecret)
+	assert.Equal(t, len(apiKey.secrets), 3)
+	assert.Equal(t, apiKey.secrets["MaceWinduPurpleLightSaber"].Annotations["rank"], "master")
+
+	// deleted
+	apiKey.RevokeK8sSecretBasedIdentity(context.TODO(), k8s_types.NamespacedName{Namespace: "ns1", Name: "mace"})
+	assert.Equal(t, len(apiKey.secrets), 2)
+	_, exists = apiKey.secrets["MaceWinduPurpleLightSaber"]
+	assert.Check(t, !exists)
+}
+
 func BenchmarkAPIKeyAuthn(b *testing.B) {
 	ctrl := gomock.NewController(b)
 	defer ctrl.Finish()
@@ -0,0 +1,584 @@
+package identity
+
+import (
+	gocontext "context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	gojson "encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/auth"
+	"github.com/kuadrant/authorino/pkg/context"
+	"github.com/kuadrant/authorino/pkg/log"
+	"github.com/kuadrant/authorino/pkg/trace"
+
+	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	k8s "k8s.io/api/core/v1"
+	k8s_labels "k8s.io/apimachinery/pkg/labels"
+	k8s_types "k8s.io/apimachinery/pkg/types"
+	k8s_client "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	awsSigV4Algorithm    = "AWS4-HMAC-SHA256"
+	awsSigV4TimeFormat   = "20060102T150405Z"
+	awsSigV4DateFormat   = "20060102"
+	awsSigV4ScopeSuffix  = "aws4_request"
+	awsSigV4MaxClockSkew = 15 * time.Minute
+	awsSigV4MaxExpires   = 7 * 24 * time.Hour // 604800 seconds
+	awsUnsignedPayload   = "UNSIGNED-PAYLOAD"
+
+	awsAccessKeyIDSelector     = "aws_access_key_id"
+	awsSecretAccessKeySelector = "aws_secret_access_key"
+	awsArnSelector             = "aws_arn"
+
+	awsStsGlobalHost     = "sts.amazonaws.com"
+	awsStsTokenPrefix    = "k8s-aws-v1."
+	awsStsAudienceHeader = "x-k8s-aws-id"
+
+	awsSignatureNotFoundMsg = "the aws signature was not found in the request"
+	invalidAwsSignatureMsg  = "the aws signature of the request is invalid"
+)
+
+// AwsSigV4 verifies requests signed with AWS Signature Version 4 (SigV4) and resolves the AWS IAM principal that
+// signed the request.
+// The signatures are verified against the access keys stored in Kubernetes secrets or, if the STS settings are
+// provided, the identity is resolved by AWS STS out of a presigned GetCallerIdentity request supplied as credentials.
+type AwsSigV4 struct {
+	auth.AuthCredentials
+
+	Name           string
+	LabelSelectors k8s_labels.Selector
+	Namespace      string
+	Region         string
+	Service        string
+	Sts            *AwsSts
+	// Whether to accept requests whose payload is not signed (x-amz-content-sha256: UNSIGNED-PAYLOAD), besides the
+	// presigned URLs
+	AllowUnsignedPayload bool
+
+	secrets    map[string]k8s.Secret
+	mutex      sync.RWMutex
+	k8sClient  k8s_client.Reader
+	httpClient *http.Client
+}
+
+// AwsSts are the settings to resolve AWS IAM principals by AWS STS GetCallerIdentity
+type AwsSts struct {
+	// Hosts of the STS endpoints allowed in the presigned requests
+	Hosts []string
+	// Value of the x-k8s-aws-id header that must be signed in the presigned requests
+	Audience string
+}
+
+func NewAwsSigV4Identity(name string, labelSelectors k8s_labels.Selector, namespace, region, service string, authCred auth.AuthCredentials, k8sClient k8s_client.Reader, ctx gocontext.Context) *AwsSigV4 {
+	sigv4 := &AwsSigV4{
+		AuthCredentials: authCred,
+		Name:            name,
+		LabelSelectors:  labelSelectors,
+		Namespace:       namespace,
+		Region:          region,
+		Service:         service,
+		secrets:         make(map[string]k8s.Secret),
+		k8sClient:       k8sClient,
+	}
+	if err := sigv4.loadSecrets(gocontext.TODO()); err != nil {
+		log.FromContext(ctx).WithName("awssigv4").Error(err, credentialsFetchingErrorMsg)
+	}
+	return sigv4
+}
+
+// NewAwsStsIdentity builds an AwsSigV4 identity verifier that resolves the principals by AWS STS GetCallerIdentity,
+// accepting presigned requests to the global STS endpoint and to the regional endpoints of the given regions
+func NewAwsStsIdentity(name string, regions []string, audience string, authCred auth.AuthCredentials) *AwsSigV4 {
+	hosts := []string{awsStsGlobalHost}
+	for _, region := range regions {
+		hosts = append(hosts, fmt.Sprintf("sts.%s.amazonaws.com", region))
+	}
+	return &AwsSigV4{
+		AuthCredentials: authCred,
+		Name:            name,
+		Sts:             &AwsSts{Hosts: hosts, Audience: audience},
+	}
+}
+
+// loadSecrets will load the matching k8s secrets from the cluster to the cache of trusted access keys
+func (a *AwsSigV4) loadSecrets(ctx gocontext.Context) error {
+	opts := []k8s_client.ListOption{k8s_client.MatchingLabelsSelector{Selector: a.LabelSelectors}}
+	if namespace := a.Namespace; namespace != "" {
+		opts = append(opts, k8s_client.InNamespace(namespace))
+	}
+	var secretList = &k8s.SecretList{}
+	if err := a.k8sClient.List(ctx, secretList, opts...); err != nil {
+		return err
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	for _, secret := range secretList.Items {
+		a.appendK8sSecretBasedIdentity(secret)
+	}
+
+	return nil
+}
+
+func (a *AwsSigV4) Call(pipeline auth.AuthPipeline, ctx gocontext.Context) (interface{}, error) {
+	if err := context.CheckContext(ctx); err != nil {
+		return nil, err
+	}
+
+	if a.Sts != nil {
+		return a.getCallerIdentity(pipeline.GetHttp(), ctx)
+	}
+
+	signedReq, err := parseAwsSigV4Request(pipeline.GetHttp())
+	if err != nil {
+		return nil, err
+	}
+
+	if a.Region != "" && signedReq.region != a.Region {
+		return nil, fmt.Errorf("%s: unexpected region %s", invalidAwsSignatureMsg, signedReq.region)
+	}
+	if a.Service != "" && signedReq.service != a.Service {
+		return nil, fmt.Errorf("%s: unexpected service %s", invalidAwsSignatureMsg, signedReq.service)
+	}
+	if err := signedReq.checkTime(time.Now()); err != nil {
+		return nil, err
+	}
+	if err := signedReq.checkPayload(a.AllowUnsignedPayload); err != nil {
+		return nil, err
+	}
+
+	a.mutex.RLock()
+	secret, found := a.secrets[signedReq.accessKeyID]
+	a.mutex.RUnlock()
+
+	if !found {
+		return nil, fmt.Errorf("%s: unknown access key id", invalidAwsSignatureMsg)
+	}
+
+	signature := signedReq.sign(string(secret.Data[awsSecretAccessKeySelector]))
+	if !hmac.Equal([]byte(signature), []byte(signedReq.signature)) {
+		return nil, fmt.Errorf(invalidAwsSignatureMsg)
+	}
+
+	arn := string(secret.Data[awsArnSelector])
+	return map[string]interface{}{
+		"sub":           arn,
+		"arn":           arn,
+		"account":       awsAccountFromArn(arn),
+		"access_key_id": signedReq.accessKeyID,
+		"region":        signedReq.region,
+		"service":       signedReq.service,
+	}, nil
+}
+
+// awsSigV4Request is a request signed with AWS SigV4, either in the Authorization header or in the query string
+// (presigned URL)
+type awsSigV4Request struct {
+	httpReq *envoy_auth.AttributeContext_HttpRequest
+
+	accessKeyID   string
+	date          string
+	region        string
+	service       string
+	signedAt      time.Time
+	signedHeaders []string
+	signature     string
+	presigned     bool
+	expires       time.Duration
+}
+
+func parseAwsSigV4Request(httpReq *envoy_auth.AttributeContext_HttpRequest) (*awsSigV4Request, error) {
+	signedReq := &awsSigV4Request{httpReq: httpReq}
+
+	var credential, signedHeaders, amzDate string
+
+	headers := httpReq.GetHeaders()
+	_, rawQuery, _ := strings.Cut(httpReq.GetPath(), "?")
+	query, _ := url.ParseQuery(rawQuery)
+
+	if authorization := headers["authorization"]; strings.HasPrefix(authorization, awsSigV4Algorithm+" ") {
+		for _, field := range strings.Split(strings.TrimPrefix(authorization, awsSigV4Algorithm+" "), ",") {
+			key, value, _ := strings.Cut(strings.TrimSpace(field), "=")
+			switch key {
+			case "Credential":
+				credential = value
+			case "SignedHeaders":
+				signedHeaders = value
+			case "Signature":
+				signedReq.signature = value
+			}
+		}
+		amzDate = headers["x-amz-date"]
+	} else if query.Get("X-Amz-Algorithm") == awsSigV4Algorithm {
+		signedReq.presigned = true
+		credential = query.Get("X-Amz-Credential")
+		signedHeaders = query.Get("X-Amz-SignedHeaders")
+		signedReq.signature = query.Get("X-Amz-Signature")
+		amzDate = query.Get("X-Amz-Date")
+		expires, err := strconv.Atoi(query.Get("X-Amz-Expires"))
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid expiration", invalidAwsSignatureMsg)
+		}
+		signedReq.expires = time.Duration(expires) * time.Second
+	} else {
+		return nil, fmt.Errorf(awsSignatureNotFoundMsg)
+	}
+
+	scope := strings.Split(credential, "/")
+	if len(scope) != 5 || scope[4] != awsSigV4ScopeSuffix {
+		return nil, fmt.Errorf("%s: invalid credential scope", invalidAwsSignatureMsg)
+	}
+	signedReq.accessKeyID, signedReq.date, signedReq.region, signedReq.service = scope[0], scope[1], scope[2], scope[3]
+
+	signedAt, err := time.Parse(awsSigV4TimeFormat, amzDate)
+	if err != nil || signedAt.Format(awsSigV4DateFormat) != signedReq.date {
+		return nil, fmt.Errorf("%s: invalid date", invalidAwsSignatureMsg)
+	}
+	signedReq.signedAt = signedAt
+
+	if signedHeaders == "" || signedReq.signature == "" {
+		return nil, fmt.Errorf("%s: missing signed headers or signature", invalidAwsSignatureMsg)
+	}
+	signedReq.signedHeaders = strings.Split(signedHeaders, ";")
+
+	return signedReq, nil
+}
+
+// checkTime checks the signature is not expired, nor signed too far in the past or in the future.
+// Presigned URLs expire within 7 days at most, as in AWS.
+func (r *awsSigV4Request) checkTime(now time.Time) error {
+	if r.signedAt.After(now.Add(awsSigV4MaxClockSkew)) {
+		return fmt.Errorf("%s: signed in the future", invalidAwsSignatureMsg)
+	}
+	validity := awsSigV4MaxClockSkew
+	if r.presigned {
+		if r.expires <= 0 || r.expires > awsSigV4MaxExpires {
+			return fmt.Errorf("%s: invalid expiration", invalidAwsSignatureMsg)
+		}
+		validity = r.expires
+	}
+	if now.After(r.signedAt.Add(validity)) {
+		return fmt.Errorf("%s: signature expired", invalidAwsSignatureMsg)
+	}
+	return nil
+}
+
+// checkPayload checks the payload hash declared in the x-amz-content-sha256 header matches the body of the request.
+// Unsigned payloads are only accepted in presigned URLs or if explicitly allowed.
+func (r *awsSigV4Request) checkPayload(allowUnsigned bool) error {
+	payloadHash, found := r.httpReq.GetHeaders()["x-amz-content-sha256"]
+	switch {
+	case !found:
+		return nil // the hash of the body is signed
+	case payloadHash == awsUnsignedPayload:
+		if r.presigned || allowUnsigned {
+			return nil
+		}
+		return fmt.Errorf("%s: unsigned payload not allowed", invalidAwsSignatureMsg)
+	case payloadHash != sha256Hex([]byte(r.httpReq.GetBody())):
+		return fmt.Errorf("%s: payload hash does not match the body", invalidAwsSignatureMsg)
+	}
+	return nil
+}
+
+// sign computes the signature of the request with a secret access key
+func (r *awsSigV4Request) sign(secretAccessKey string) string {
+	scope := strings.Join([]string{r.date, r.region, r.service, awsSigV4ScopeSuffix}, "/")
+	stringToSign := strings.Join([]string{
+		awsSigV4Algorithm,
+		r.signedAt.Format(awsSigV4TimeFormat),
+		scope,
+		sha256Hex([]byte(r.canonicalRequest())),
+	}, "\n")
+
+	key := []byte("AWS4" + secretAccessKey)
+	for _, part := range []string{r.date, r.region, r.service, awsSigV4ScopeSuffix} {
+		key = hmacSHA256(key, part)
+	}
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func (r *awsSigV4Request) canonicalRequest() string {
+	path, rawQuery, _ := strings.Cut(r.httpReq.GetPath(), "?")
+	headers := r.httpReq.GetHeaders()
+
+	// canonical uri: the path is encoded once more, except for s3
+	canonicalURI := path
+	if canonicalURI == "" {
+		canonicalURI = "/"
+	}
+	if r.service != "s3" {
+		canonicalURI = awsURIEncode(canonicalURI, false)
+	}
+
+	// canonical query string
+	var params []string
+	for _, param := range strings.Split(rawQuery, "&") {
+		if param == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(param, "=")
+		key, _ = url.QueryUnescape(key)
+		value, _ = url.QueryUnescape(value)
+		if r.presigned && key == "X-Amz-Signature" {
+			continue
+		}
+		params = append(params, awsURIEncode(key, true)+"="+awsURIEncode(value, true))
+	}
+	sort.Strings(params)
+
+	// canonical headers
+	var canonicalHeaders strings.Builder
+	for _, name := range r.signedHeaders {
+		value, found := headers[name]
+		if !found && name == "host" {
+			value = r.httpReq.GetHost()
+		}
+		canonicalHeaders.WriteString(name + ":" + strings.Join(strings.Fields(value), " ") + "\n")
+	}
+
+	// payload hash
+	payloadHash := headers["x-amz-content-sha256"]
+	if payloadHash == "" {
+		if r.presigned {
+			payloadHash = awsUnsignedPayload
+		} else {
+			payloadHash = sha256Hex([]byte(r.httpReq.GetBody()))
+		}
+	}
+
+	return strings.Join([]string{
+		r.httpReq.GetMethod(),
+		canonicalURI,
+		strings.Join(params, "&"),
+		canonicalHeaders.String(),
+		strings.Join(r.signedHeaders, ";"),
+		payloadHash,
+	}, "\n")
+}
+
+// getCallerIdentity resolves the principal by sending to AWS STS the presigned GetCallerIdentity request supplied as
+// credentials, in the format of the tokens of the AWS IAM Authenticator for Kubernetes (i.e. "k8s-aws-v1." followed by
+// the presigned URL encoded in base64url)
+func (a *AwsSigV4) getCallerIdentity(httpReq *envoy_auth.AttributeContext_HttpRequest, ctx gocontext.Context) (interface{}, error) {
+	token, err := a.GetCredentialsFromReq(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	presignedURL, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(strings.TrimPrefix(token, awsStsTokenPrefix), "="))
+	if err != nil {
+		return nil, fmt.Errorf("invalid aws sts token")
+	}
+	stsURL, err := a.Sts.validatePresignedURL(string(presignedURL))
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, stsURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if a.Sts.Audience != "" {
+		req.Header.Set(awsStsAudienceHeader, a.Sts.Audience)
+	}
+
+	log.FromContext(ctx).WithName("awssigv4").V(1).Info("calling aws sts get caller identity", "host", stsURL.Host)
+
+	client := a.httpClient
+	if client == nil {
+		client = trace.HTTPClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("aws sts get caller identity failed with status %d", resp.StatusCode)
+	}
+
+	var callerIdentity struct {
+		GetCallerIdentityResponse struct {
+			GetCallerIdentityResult struct {
+				Account string `json:"Account"`
+				Arn     string `json:"Arn"`
+				UserId  string `json:"UserId"`
+			} `json:"GetCallerIdentityResult"`
+		} `json:"GetCallerIdentityResponse"`
+	}
+	if err := gojson.Unmarshal(body, &callerIdentity); err != nil {
+		return nil, err
+	}
+	result := callerIdentity.GetCallerIdentityResponse.GetCallerIdentityResult
+	if result.Arn == "" {
+		return nil, fmt.Errorf("aws sts get caller identity returned no arn")
+	}
+
+	return map[string]interface{}{
+		"sub":     result.Arn,
+		"arn":     result.Arn,
+		"account": result.Account,
+		"user_id": result.UserId,
+	}, nil
+}
+
+// validatePresignedURL checks the presigned URL is a GetCallerIdentity request to one of the allowed STS endpoints,
+// so Authorino cannot be used to send arbitrary requests
+func (s *AwsSts) validatePresignedURL(presignedURL string) (*url.URL, error) {
+	stsURL, err := url.Parse(presignedURL)
+	if err != nil || stsURL.Scheme != "https" || stsURL.User != nil {
+		return nil, fmt.Errorf("invalid aws sts token")
+	}
+	allowedHost := false
+	for _, host := range s.Hosts {
+		if stsURL.Host == host {
+			allowedHost = true
+			break
+		}
+	}
+	if !allowedHost {
+		return nil, fmt.Errorf("invalid aws sts token: unexpected host %s", stsURL.Host)
+	}
+	if stsURL.Path != "" && stsURL.Path != "/" {
+		return nil, fmt.Errorf("invalid aws sts token: unexpected path")
+	}
+	query := stsURL.Query()
+	if query.Get("Action") != "GetCallerIdentity" {
+		return nil, fmt.Errorf("invalid aws sts token: unexpected action")
+	}
+	if s.Audience != "" {
+		signed := false
+		for _, header := range strings.Split(query.Get("X-Amz-SignedHeaders"), ";") {
+			if header == awsStsAudienceHeader {
+				signed = true
+				break
+			}
+		}
+		if !signed {
+			return nil, fmt.Errorf("invalid aws sts token: missing signed %s header", awsStsAudienceHeader)
+		}
+	}
+	return stsURL, nil
+}
+
+// impl:K8sSecretBasedIdentityConfigEvaluator
+
+func (a *AwsSigV4) GetK8sSecretLabelSelectors() k8s_labels.Selector {
+	if a.LabelSelectors == nil {
+		return k8s_labels.Nothing()
+	}
+	return a.LabelSelectors
+}
+
+func (a *AwsSigV4) AddK8sSecretBasedIdentity(ctx gocontext.Context, new k8s.Secret) {
+	if a.Sts != nil || !a.withinScope(new.GetNamespace()) {
+		return
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	logger := log.FromContext(ctx).WithName("awssigv4")
+
+	// updating existing
+	for accessKeyID, current := range a.secrets {
+		if current.GetNamespace() == new.GetNamespace() && current.GetName() == new.GetName() {
+			delete(a.secrets, accessKeyID)
+			if a.appendK8sSecretBasedIdentity(new) {
+				logger.V(1).Info("aws access key updated")
+			} else {
+				logger.V(1).Info("aws access key deleted")
+			}
+			return
+		}
+	}
+
+	if a.appendK8sSecretBasedIdentity(new) {
+		logger.V(1).Info("aws access key added")
+	}
+}
+
+func (a *AwsSigV4) RevokeK8sSecretBasedIdentity(ctx gocontext.Context, deleted k8s_types.NamespacedName) {
+	if a.Sts != nil || !a.withinScope(deleted.Namespace) {
+		return
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	for accessKeyID, secret := range a.secrets {
+		if secret.GetNamespace() == deleted.Namespace && secret.GetName() == deleted.Name {
+			delete(a.secrets, accessKeyID)
+			log.FromContext(ctx).WithName("awssigv4").V(1).Info("aws access key deleted")
+			return
+		}
+	}
+}
+
+func (a *AwsSigV4) withinScope(namespace string) bool {
+	return a.Namespace == "" || a.Namespace == namespace
+}
+
+// Appends the K8s Secret to the cache of access keys
+// Caution! This function is not thread-safe. Make sure to acquire a lock before calling it.
+func (a *AwsSigV4) appendK8sSecretBasedIdentity(secret k8s.Secret) bool {
+	accessKeyID := secret.Data[awsAccessKeyIDSelector]
+	secretAccessKey := secret.Data[awsSecretAccessKeySelector]
+	if len(accessKeyID) > 0 && len(secretAccessKey) > 0 {
+		a.secrets[string(accessKeyID)] = secret
+		return true
+	}
+	return false
+}
+
+// awsURIEncode encodes a string as specified for the canonical requests of AWS SigV4, i.e. every byte except the
+// unreserved characters (A-Z, a-z, 0-9, '-', '.', '_' and '~') is percent-encoded; '/' is kept unless encodeSlash
+func awsURIEncode(s string, encodeSlash bool) string {
+	var encoded strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' || c == '.' || c == '_' || c == '~' || (c == '/' && !encodeSlash) {
+			encoded.WriteByte(c)
+		} else {
+			fmt.Fprintf(&encoded, "%%%02X", c)
+		}
+	}
+	return encoded.String()
+}
+
+// awsAccountFromArn returns the account id of an ARN (arn:partition:service:region:account-id:resource)
+func awsAccountFromArn(arn string) string {
+	if parts := strings.SplitN(arn, ":", 6); len(parts) == 6 {
+		return parts[4]
+	}
+	return ""
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
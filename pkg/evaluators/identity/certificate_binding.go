@@ -0,0 +1,47 @@
+package identity
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+)
+
+const (
+	certificateThumbprintConfirmationClaim = "x5t#S256"
+
+	msg_certificateBindingInvalidError = "invalid certificate-bound token"
+)
+
+// verifyCertificateBinding verifies that the access token, if bound to a TLS client certificate (RFC 8705), is
+// presented by the client that holds the certificate, i.e. that the `cnf.x5t#S256` claim of the token matches the
+// SHA-256 thumbprint of the client certificate forwarded by Envoy (URL-encoded PEM).
+// Tokens not bound to a certificate are not checked.
+func verifyCertificateBinding(urlEncodedCert string, claims map[string]interface{}) error {
+	var thumbprint string
+	if cnf, ok := claims["cnf"].(map[string]interface{}); ok {
+		thumbprint, _ = cnf[certificateThumbprintConfirmationClaim].(string)
+	}
+	if thumbprint == "" {
+		return nil
+	}
+
+	if urlEncodedCert == "" {
+		return fmt.Errorf("%s: client certificate is missing", msg_certificateBindingInvalidError)
+	}
+	pemEncodedCert, err := url.QueryUnescape(urlEncodedCert)
+	if err != nil {
+		return fmt.Errorf("%s: invalid client certificate", msg_certificateBindingInvalidError)
+	}
+	cert := decodeCertificate([]byte(pemEncodedCert))
+	if cert == nil {
+		return fmt.Errorf("%s: invalid client certificate", msg_certificateBindingInvalidError)
+	}
+
+	certThumbprint := sha256.Sum256(cert.Raw)
+	if base64.RawURLEncoding.EncodeToString(certThumbprint[:]) != thumbprint {
+		return fmt.Errorf("%s: client certificate does not match the token binding", msg_certificateBindingInvalidError)
+	}
+
+	return nil
+}
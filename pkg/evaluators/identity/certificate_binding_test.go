@@ -0,0 +1,56 @@
+package identity
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"net/url"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+// newCertificateBindingTestCert returns a self-signed client certificate, url-encoded as forwarded by Envoy, and its thumbprint
+func newCertificateBindingTestCert(t *testing.T, commonName string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NilError(t, err)
+	thumbprint := sha256.Sum256(der)
+	return url.QueryEscape(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))), base64.RawURLEncoding.EncodeToString(thumbprint[:])
+}
+
+func TestVerifyCertificateBinding(t *testing.T) {
+	cert, thumbprint := newCertificateBindingTestCert(t, "john")
+	otherCert, _ := newCertificateBindingTestCert(t, "jane")
+	boundClaims := map[string]interface{}{"sub": "john", "cnf": map[string]interface{}{"x5t#S256": thumbprint}}
+
+	assert.NilError(t, verifyCertificateBinding(cert, boundClaims))
+
+	err := verifyCertificateBinding(otherCert, boundClaims)
+	assert.Error(t, err, "invalid certificate-bound token: client certificate does not match the token binding")
+
+	err = verifyCertificateBinding("", boundClaims)
+	assert.Error(t, err, "invalid certificate-bound token: client certificate is missing")
+
+	err = verifyCertificateBinding("not-a-cert", boundClaims)
+	assert.Error(t, err, "invalid certificate-bound token: invalid client certificate")
+
+	// tokens not bound to a certificate
+	assert.NilError(t, verifyCertificateBinding("", map[string]interface{}{"sub": "john"}))
+	assert.NilError(t, verifyCertificateBinding(otherCert, map[string]interface{}{"sub": "john", "cnf": map[string]interface{}{"jkt": "0ZcOCORZNYy-DWpqq30jZyJGHTN0d2HglBV3uiguA4I"}}))
+}
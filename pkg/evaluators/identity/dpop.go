@@ -0,0 +1,174 @@
+package identity
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"encoding/base64"
+	gojson "encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/auth"
+
+	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+const (
+	dpopProofHeader = "dpop"
+	dpopProofType   = "dpop+jwt"
+
+	// how old a dpop proof can be, based on its iat claim
+	dpopProofMaxAge = 5 * time.Minute
+	// tolerance to proofs issued by clients whose clocks are ahead
+	dpopProofClockSkew = time.Minute
+
+	// ProofReplayWindow is for how long a proof must be remembered to prevent replays
+	ProofReplayWindow = dpopProofMaxAge + dpopProofClockSkew
+
+	msg_dpopProofInvalidError = "invalid dpop proof"
+)
+
+// ProofReplayCache remembers the proofs of possession already presented, so they cannot be replayed.
+type ProofReplayCache interface {
+	// Remember records a proof for at least the ProofReplayWindow, returning false if the proof was already recorded.
+	// Checking and recording must be atomic, so concurrent requests presenting the same proof cannot all pass.
+	Remember(key string) (bool, error)
+}
+
+// BoundTokenVerifier is implemented by the identity evaluators that accept tokens bound to the client (sender-constrained
+// tokens), whose binding must be verified on every request, including the requests whose identity is read from the
+// cache
+type BoundTokenVerifier interface {
+	VerifyBinding(pipeline auth.AuthPipeline, identity interface{}, replays ProofReplayCache) error
+}
+
+// validateDPoPProof validates the DPoP proof (RFC 9449) sent in the `DPoP` header of the request, and its binding to the
+// access token. Tokens bound to a key (`cnf.jkt` claim) require a proof; tokens not bound to a key are only accepted
+// without a proof.
+// The proofs already presented are recorded in the replay cache, so they cannot be replayed.
+func validateDPoPProof(req *envoy_auth.AttributeContext_HttpRequest, accessToken string, claims map[string]interface{}, replays ProofReplayCache) error {
+	var jkt string
+	if cnf, ok := claims["cnf"].(map[string]interface{}); ok {
+		jkt, _ = cnf["jkt"].(string)
+	}
+
+	proof, ok := req.GetHeaders()[dpopProofHeader]
+	if !ok {
+		if jkt != "" {
+			return fmt.Errorf("%s: missing proof for a dpop-bound token", msg_dpopProofInvalidError)
+		}
+		return nil
+	}
+	if jkt == "" {
+		return fmt.Errorf("%s: token not bound to a dpop key", msg_dpopProofInvalidError)
+	}
+	if strings.Contains(proof, ",") {
+		return fmt.Errorf("%s: multiple proofs", msg_dpopProofInvalidError)
+	}
+
+	// signature and public key
+	jws, err := jose.ParseSigned(proof)
+	if err != nil {
+		return fmt.Errorf("%s: %v", msg_dpopProofInvalidError, err)
+	}
+	if len(jws.Signatures) != 1 {
+		return fmt.Errorf("%s: expected 1 signature got %d", msg_dpopProofInvalidError, len(jws.Signatures))
+	}
+	header := jws.Signatures[0].Protected
+	if typ, _ := header.ExtraHeaders[jose.HeaderType].(string); !strings.EqualFold(typ, dpopProofType) {
+		return fmt.Errorf("%s: unexpected type %s", msg_dpopProofInvalidError, typ)
+	}
+	if header.JSONWebKey == nil || !header.JSONWebKey.IsPublic() {
+		return fmt.Errorf("%s: missing public key", msg_dpopProofInvalidError)
+	}
+	payload, err := jws.Verify(header.JSONWebKey)
+	if err != nil {
+		return fmt.Errorf("%s: %v", msg_dpopProofInvalidError, err)
+	}
+
+	thumbprint, err := header.JSONWebKey.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return fmt.Errorf("%s: %v", msg_dpopProofInvalidError, err)
+	}
+	if base64.RawURLEncoding.EncodeToString(thumbprint) != jkt {
+		return fmt.Errorf("%s: key does not match the token binding", msg_dpopProofInvalidError)
+	}
+
+	// claims of the proof
+	var proofClaims struct {
+		JTI string  `json:"jti"`
+		HTM string  `json:"htm"`
+		HTU string  `json:"htu"`
+		IAT float64 `json:"iat"`
+		ATH string  `json:"ath"`
+	}
+	if err := gojson.Unmarshal(payload, &proofClaims); err != nil {
+		return fmt.Errorf("%s: %v", msg_dpopProofInvalidError, err)
+	}
+	if proofClaims.JTI == "" {
+		return fmt.Errorf("%s: missing jti claim", msg_dpopProofInvalidError)
+	}
+	if proofClaims.HTM != req.GetMethod() {
+		return fmt.Errorf("%s: htm does not match the method of the request", msg_dpopProofInvalidError)
+	}
+	if !dpopHtuMatches(proofClaims.HTU, req) {
+		return fmt.Errorf("%s: htu does not match the url of the request", msg_dpopProofInvalidError)
+	}
+	accessTokenHash := sha256.Sum256([]byte(accessToken))
+	if proofClaims.ATH != base64.RawURLEncoding.EncodeToString(accessTokenHash[:]) {
+		return fmt.Errorf("%s: ath does not match the access token", msg_dpopProofInvalidError)
+	}
+	now := time.Now()
+	issuedAt := time.Unix(int64(proofClaims.IAT), 0)
+	if issuedAt.Before(now.Add(-dpopProofMaxAge)) || issuedAt.After(now.Add(dpopProofClockSkew)) {
+		return fmt.Errorf("%s: iat out of the acceptable window", msg_dpopProofInvalidError)
+	}
+
+	// replays
+	replayKey := "dpop:" + jkt + ":" + proofClaims.JTI
+	if fresh, err := replays.Remember(replayKey); err != nil {
+		return fmt.Errorf("%s: %v", msg_dpopProofInvalidError, err)
+	} else if !fresh {
+		return fmt.Errorf("%s: proof already used", msg_dpopProofInvalidError)
+	}
+
+	return nil
+}
+
+// dpopHtuMatches tells whether the htu claim of a proof matches the url of the request, ignoring query and fragment
+func dpopHtuMatches(htu string, req *envoy_auth.AttributeContext_HttpRequest) bool {
+	proofURL, err := url.Parse(htu)
+	if err != nil {
+		return false
+	}
+	if scheme := req.GetScheme(); scheme != "" && !strings.EqualFold(proofURL.Scheme, scheme) {
+		return false
+	}
+	path := req.GetPath()
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	if path == "" {
+		path = "/"
+	}
+	proofPath := proofURL.EscapedPath()
+	if proofPath == "" {
+		proofPath = "/"
+	}
+	return strings.EqualFold(dpopNormalizeHost(proofURL.Scheme, proofURL.Host), dpopNormalizeHost(proofURL.Scheme, req.GetHost())) && proofPath == path
+}
+
+// dpopNormalizeHost strips the default port of the scheme off the host
+func dpopNormalizeHost(scheme, host string) string {
+	switch strings.ToLower(scheme) {
+	case "https":
+		return strings.TrimSuffix(host, ":443")
+	case "http":
+		return strings.TrimSuffix(host, ":80")
+	default:
+		return host
+	}
+}
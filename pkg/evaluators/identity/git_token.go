@@ -0,0 +1,277 @@
+package identity
+
+import (
+	gocontext "context"
+	gojson "encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/auth"
+	"github.com/kuadrant/authorino/pkg/context"
+	"github.com/kuadrant/authorino/pkg/log"
+	"github.com/kuadrant/authorino/pkg/trace"
+	"github.com/kuadrant/authorino/pkg/utils"
+
+	goidc "github.com/coreos/go-oidc"
+)
+
+const (
+	GitHubProvider = "github"
+	GitLabProvider = "gitlab"
+
+	gitTokenTypePersonalAccessToken = "personal_access_token"
+	gitTokenTypeCI                  = "ci"
+
+	gitHubDefaultApiUrl    = "https://api.github.com"
+	gitHubDefaultIssuerUrl = "https://token.actions.githubusercontent.com"
+	gitLabDefaultUrl       = "https://gitlab.com"
+)
+
+// GitToken validates tokens of GitHub and GitLab, i.e. personal access tokens, checked against the API of the provider,
+// and OpenID Connect tokens issued to CI jobs (GitHub Actions and GitLab CI/CD ID tokens), verified against the
+// provider's OIDC issuer.
+// The resolved identity object exposes the user, organizations (or groups) and, for CI tokens, repository of the token
+// under provider-agnostic keys.
+type GitToken struct {
+	auth.AuthCredentials
+
+	Provider  string   `yaml:"provider"`
+	ApiUrl    string   `yaml:"apiUrl"`
+	IssuerUrl string   `yaml:"issuerUrl"`
+	Audiences []string `yaml:"audiences,omitempty"`
+
+	ttl         time.Duration
+	cache       map[string]gitTokenCacheEntry
+	cacheMutex  sync.Mutex
+	oidc        *goidc.Provider
+	oidcMutex   sync.Mutex
+	httpClient  *http.Client
+	userKey     string
+	orgsPath    string
+	orgKey      string
+	ciUserClaim string
+	ciOrgClaim  string
+	ciRepoClaim string
+}
+
+type gitTokenCacheEntry struct {
+	identity  map[string]interface{}
+	expiresAt time.Time
+}
+
+// NewGitToken returns a GitToken evaluator for the given provider ("github" or "gitlab").
+// The url is the base URL of a GitHub Enterprise Server or self-managed GitLab instance; if empty, github.com or
+// gitlab.com are used. The results of the validation of personal access tokens are cached for ttl seconds.
+func NewGitToken(provider, url string, audiences []string, ttl int, creds auth.AuthCredentials) (*GitToken, error) {
+	g := &GitToken{
+		AuthCredentials: creds,
+		Provider:        provider,
+		Audiences:       audiences,
+		ttl:             time.Duration(ttl) * time.Second,
+		cache:           make(map[string]gitTokenCacheEntry),
+		httpClient:      trace.HTTPClient,
+	}
+
+	url = strings.TrimSuffix(url, "/")
+
+	switch provider {
+	case GitHubProvider:
+		g.ApiUrl, g.IssuerUrl = gitHubDefaultApiUrl, gitHubDefaultIssuerUrl
+		if url != "" {
+			// github enterprise server
+			g.ApiUrl, g.IssuerUrl = url+"/api/v3", url+"/_services/token"
+		}
+		g.userKey, g.orgsPath, g.orgKey = "login", "/user/orgs?per_page=100", "login"
+		g.ciUserClaim, g.ciOrgClaim, g.ciRepoClaim = "actor", "repository_owner", "repository"
+	case GitLabProvider:
+		if url == "" {
+			url = gitLabDefaultUrl
+		}
+		g.ApiUrl, g.IssuerUrl = url+"/api/v4", url
+		g.userKey, g.orgsPath, g.orgKey = "username", "/groups?min_access_level=10&per_page=100", "full_path"
+		g.ciUserClaim, g.ciOrgClaim, g.ciRepoClaim = "user_login", "namespace_path", "project_path"
+	default:
+		return nil, fmt.Errorf("unsupported git token provider: %s", provider)
+	}
+
+	return g, nil
+}
+
+func (g *GitToken) Call(pipeline auth.AuthPipeline, ctx gocontext.Context) (interface{}, error) {
+	if err := context.CheckContext(ctx); err != nil {
+		return nil, err
+	}
+
+	request := pipeline.GetHttp()
+	token, err := g.GetCredentialsFromReq(request)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithName("gittoken"))
+
+	// tokens of ci jobs are jwts, whereas personal access tokens are opaque
+	if strings.Count(token, ".") == 2 {
+		return g.verifyCIToken(ctx, token, request.GetHost())
+	}
+	return g.validatePersonalAccessToken(ctx, token)
+}
+
+func (g *GitToken) verifyCIToken(ctx gocontext.Context, token, defaultAudience string) (interface{}, error) {
+	provider, err := g.getOIDCProvider(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	idToken, err := provider.Verifier(&goidc.Config{SkipClientIDCheck: true}).Verify(ctx, token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s ci token: %v", g.Provider, err)
+	}
+
+	audiences := g.Audiences
+	if len(audiences) == 0 {
+		audiences = []string{defaultAudience}
+	}
+	audienceMatches := false
+	for _, audience := range idToken.Audience {
+		if utils.SliceContains(audiences, audience) {
+			audienceMatches = true
+			break
+		}
+	}
+	if !audienceMatches {
+		return nil, fmt.Errorf("invalid %s ci token: audience mismatch", g.Provider)
+	}
+
+	var claims map[string]interface{}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, err
+	}
+
+	var orgs []interface{}
+	if org, ok := claims[g.ciOrgClaim]; ok {
+		orgs = append(orgs, org)
+	}
+
+	return map[string]interface{}{
+		"provider":   g.Provider,
+		"token_type": gitTokenTypeCI,
+		"username":   claims[g.ciUserClaim],
+		"orgs":       orgs,
+		"repository": claims[g.ciRepoClaim],
+		"claims":     claims,
+	}, nil
+}
+
+func (g *GitToken) getOIDCProvider(ctx gocontext.Context) (*goidc.Provider, error) {
+	g.oidcMutex.Lock()
+	defer g.oidcMutex.Unlock()
+
+	if g.oidc == nil {
+		provider, err := goidc.NewProvider(goidc.ClientContext(gocontext.Background(), g.httpClient), g.IssuerUrl)
+		if err != nil {
+			log.FromContext(ctx).Error(err, msg_oidcProviderConfigRefreshError, "endpoint", g.IssuerUrl)
+			return nil, fmt.Errorf(msg_oidcProviderConfigMissingError)
+		}
+		g.oidc = provider
+	}
+
+	return g.oidc, nil
+}
+
+func (g *GitToken) validatePersonalAccessToken(ctx gocontext.Context, token string) (interface{}, error) {
+	cacheKey := sha256Hex([]byte(token))
+	if identity := g.getCached(cacheKey); identity != nil {
+		return identity, nil
+	}
+
+	var user map[string]interface{}
+	if err := g.getFromApi(ctx, "/user", token, &user); err != nil {
+		return nil, err
+	}
+
+	// the organizations (or groups) are fetched on a best-effort basis, since the token may lack the required scopes
+	orgs := []interface{}{}
+	var orgList []map[string]interface{}
+	if err := g.getFromApi(ctx, g.orgsPath, token, &orgList); err != nil {
+		log.FromContext(ctx).V(1).Info("failed to fetch the organizations of the user", "provider", g.Provider, "reason", err)
+	}
+	for _, org := range orgList {
+		orgs = append(orgs, org[g.orgKey])
+	}
+
+	identity := map[string]interface{}{
+		"provider":   g.Provider,
+		"token_type": gitTokenTypePersonalAccessToken,
+		"username":   user[g.userKey],
+		"user_id":    user["id"],
+		"orgs":       orgs,
+		"user":       user,
+	}
+
+	g.setCached(cacheKey, identity)
+
+	return identity, nil
+}
+
+func (g *GitToken) getFromApi(ctx gocontext.Context, path, token string, v interface{}) error {
+	if err := context.CheckContext(ctx); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.ApiUrl+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("invalid %s token: %s", g.Provider, resp.Status)
+	}
+
+	return gojson.NewDecoder(resp.Body).Decode(v)
+}
+
+func (g *GitToken) getCached(key string) map[string]interface{} {
+	if g.ttl <= 0 {
+		return nil
+	}
+
+	g.cacheMutex.Lock()
+	defer g.cacheMutex.Unlock()
+
+	if entry, ok := g.cache[key]; ok {
+		if time.Now().Before(entry.expiresAt) {
+			return entry.identity
+		}
+		delete(g.cache, key)
+	}
+	return nil
+}
+
+func (g *GitToken) setCached(key string, identity map[string]interface{}) {
+	if g.ttl <= 0 {
+		return
+	}
+
+	g.cacheMutex.Lock()
+	defer g.cacheMutex.Unlock()
+
+	now := time.Now()
+	for k, entry := range g.cache {
+		if now.After(entry.expiresAt) {
+			delete(g.cache, k)
+		}
+	}
+	g.cache[key] = gitTokenCacheEntry{identity: identity, expiresAt: now.Add(g.ttl)}
+}
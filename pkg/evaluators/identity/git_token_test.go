@@ -0,0 +1,168 @@
+package identity
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/auth"
+	mock_auth "github.com/kuadrant/authorino/pkg/auth/mocks"
+
+	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"github.com/golang-jwt/jwt"
+	gomock "github.com/golang/mock/gomock"
+	"gotest.tools/assert"
+)
+
+type fakeGitServer struct {
+	*httptest.Server
+	key       *rsa.PrivateKey
+	issuer    string
+	userCalls int
+}
+
+// newFakeGitServer serves the user and orgs endpoints of the api of the git provider under apiPath, and the oidc
+// configuration of the issuer of ci tokens under issuerPath
+func newFakeGitServer(t *testing.T, apiPath, issuerPath, orgsPath string, user map[string]interface{}, orgs []map[string]interface{}) *fakeGitServer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NilError(t, err)
+
+	server := &fakeGitServer{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc(apiPath+"/user", func(w http.ResponseWriter, r *http.Request) {
+		server.userCalls++
+		if r.Header.Get("Authorization") != "Bearer pat-123" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(user)
+	})
+	mux.HandleFunc(apiPath+orgsPath, func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(orgs)
+	})
+	mux.HandleFunc(issuerPath+"/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"issuer": server.issuer, "jwks_uri": server.URL + "/jwks"})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "ci",
+				"alg": "RS256",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	server.Server = httptest.NewServer(mux)
+	server.issuer = server.URL + issuerPath
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (s *fakeGitServer) issueCIToken(claims jwt.MapClaims) string {
+	claims["iss"] = s.issuer
+	claims["exp"] = time.Now().Add(time.Minute).Unix()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "ci"
+	signed, _ := token.SignedString(s.key)
+	return signed
+}
+
+func callGitToken(ctrl *gomock.Controller, gitToken *GitToken, token string) (map[string]interface{}, error) {
+	pipeline := mock_auth.NewMockAuthPipeline(ctrl)
+	pipeline.EXPECT().GetHttp().Return(&envoy_auth.AttributeContext_HttpRequest{
+		Host:    "api.example.com",
+		Headers: map[string]string{"authorization": "Bearer " + token},
+	})
+	obj, err := gitToken.Call(pipeline, context.TODO())
+	if err != nil {
+		return nil, err
+	}
+	return obj.(map[string]interface{}), nil
+}
+
+func TestGitHubToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newFakeGitServer(t, "/api/v3", "/_services/token", "/user/orgs", map[string]interface{}{"login": "octocat", "id": 1}, []map[string]interface{}{{"login": "kuadrant"}})
+	gitToken, err := NewGitToken(GitHubProvider, server.URL, []string{"https://github.com/kuadrant"}, 60, &auth.AuthCredential{KeySelector: "Bearer", In: "authorization_header"})
+	assert.NilError(t, err)
+
+	// personal access token
+	identity, err := callGitToken(ctrl, gitToken, "pat-123")
+	assert.NilError(t, err)
+	assert.Equal(t, identity["provider"], "github")
+	assert.Equal(t, identity["token_type"], "personal_access_token")
+	assert.Equal(t, identity["username"], "octocat")
+	assert.DeepEqual(t, identity["orgs"], []interface{}{"kuadrant"})
+
+	// cached
+	_, err = callGitToken(ctrl, gitToken, "pat-123")
+	assert.NilError(t, err)
+	assert.Equal(t, server.userCalls, 1)
+
+	_, err = callGitToken(ctrl, gitToken, "pat-invalid")
+	assert.Error(t, err, "invalid github token: 401 Unauthorized")
+
+	// github actions oidc token
+	identity, err = callGitToken(ctrl, gitToken, server.issueCIToken(jwt.MapClaims{"aud": "https://github.com/kuadrant", "actor": "octocat", "repository": "kuadrant/authorino", "repository_owner": "kuadrant"}))
+	assert.NilError(t, err)
+	assert.Equal(t, identity["token_type"], "ci")
+	assert.Equal(t, identity["username"], "octocat")
+	assert.Equal(t, identity["repository"], "kuadrant/authorino")
+	assert.DeepEqual(t, identity["orgs"], []interface{}{"kuadrant"})
+
+	_, err = callGitToken(ctrl, gitToken, server.issueCIToken(jwt.MapClaims{"aud": "https://github.com/other", "repository_owner": "other"}))
+	assert.Error(t, err, "invalid github ci token: audience mismatch")
+}
+
+func TestGitLabToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newFakeGitServer(t, "/api/v4", "", "/groups", map[string]interface{}{"username": "jane", "id": 2}, []map[string]interface{}{{"full_path": "kuadrant/infra"}})
+	gitToken, err := NewGitToken(GitLabProvider, server.URL, nil, 0, &auth.AuthCredential{KeySelector: "Bearer", In: "authorization_header"})
+	assert.NilError(t, err)
+
+	// personal access token
+	identity, err := callGitToken(ctrl, gitToken, "pat-123")
+	assert.NilError(t, err)
+	assert.Equal(t, identity["provider"], "gitlab")
+	assert.Equal(t, identity["username"], "jane")
+	assert.DeepEqual(t, identity["orgs"], []interface{}{"kuadrant/infra"})
+
+	// caching disabled
+	_, err = callGitToken(ctrl, gitToken, "pat-123")
+	assert.NilError(t, err)
+	assert.Equal(t, server.userCalls, 2)
+
+	// gitlab ci id token (default audience)
+	identity, err = callGitToken(ctrl, gitToken, server.issueCIToken(jwt.MapClaims{"aud": "api.example.com", "user_login": "jane", "project_path": "kuadrant/infra/deploy", "namespace_path": "kuadrant/infra"}))
+	assert.NilError(t, err)
+	assert.Equal(t, identity["username"], "jane")
+	assert.Equal(t, identity["repository"], "kuadrant/infra/deploy")
+	assert.DeepEqual(t, identity["orgs"], []interface{}{"kuadrant/infra"})
+
+	// signed by another key
+	other := newFakeGitServer(t, "/api/v4", "", "/groups", nil, nil)
+	other.issuer = server.issuer
+	_, err = callGitToken(ctrl, gitToken, other.issueCIToken(jwt.MapClaims{"aud": "api.example.com"}))
+	assert.ErrorContains(t, err, "invalid gitlab ci token: failed to verify signature")
+}
+
+func TestGitTokenUnsupportedProvider(t *testing.T) {
+	_, err := NewGitToken("bitbucket", "", nil, 0, nil)
+	assert.Error(t, err, "unsupported git token provider: bitbucket")
+}
@@ -0,0 +1,117 @@
+package identity
+
+import (
+	gocontext "context"
+	"fmt"
+	"strings"
+
+	"github.com/kuadrant/authorino/pkg/auth"
+	"github.com/kuadrant/authorino/pkg/context"
+	"github.com/kuadrant/authorino/pkg/trace"
+	"github.com/kuadrant/authorino/pkg/utils"
+
+	goidc "github.com/coreos/go-oidc"
+)
+
+const (
+	googleIssuer   = "https://accounts.google.com"
+	googleCertsUrl = "https://www.googleapis.com/oauth2/v3/certs"
+
+	invalidGoogleIDTokenMsg = "invalid google id token"
+)
+
+// GoogleIDToken verifies ID tokens signed by Google, such as the ones issued to Google service accounts to invoke
+// Cloud Run services or to authenticate Pub/Sub push requests.
+// Optionally, the email of the token must be verified and belong to an allowed principal or domain.
+type GoogleIDToken struct {
+	auth.AuthCredentials
+
+	Audiences []string `yaml:"audiences"`
+	Emails    []string `yaml:"emails,omitempty"`
+	Domains   []string `yaml:"domains,omitempty"`
+
+	verifier *goidc.IDTokenVerifier
+}
+
+func NewGoogleIDToken(audiences, emails, domains []string, creds auth.AuthCredentials) *GoogleIDToken {
+	return newGoogleIDToken(googleCertsUrl, audiences, emails, domains, creds)
+}
+
+func newGoogleIDToken(certsUrl string, audiences, emails, domains []string, creds auth.AuthCredentials) *GoogleIDToken {
+	// the remote key set fetches the google certs on demand and whenever the tokens are signed by an unknown key
+	keySet := goidc.NewRemoteKeySet(goidc.ClientContext(gocontext.Background(), trace.HTTPClient), certsUrl)
+
+	return &GoogleIDToken{
+		AuthCredentials: creds,
+		Audiences:       audiences,
+		Emails:          emails,
+		Domains:         domains,
+		verifier:        goidc.NewVerifier(googleIssuer, keySet, &goidc.Config{SkipClientIDCheck: true}),
+	}
+}
+
+func (g *GoogleIDToken) Call(pipeline auth.AuthPipeline, ctx gocontext.Context) (interface{}, error) {
+	if err := context.CheckContext(ctx); err != nil {
+		return nil, err
+	}
+
+	token, err := g.GetCredentialsFromReq(pipeline.GetHttp())
+	if err != nil {
+		return nil, err
+	}
+
+	idToken, err := g.verifier.Verify(ctx, token)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", invalidGoogleIDTokenMsg, err)
+	}
+
+	if !g.audienceMatches(idToken.Audience) {
+		return nil, fmt.Errorf("%s: audience mismatch", invalidGoogleIDTokenMsg)
+	}
+
+	var claims map[string]interface{}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, err
+	}
+
+	if err := g.checkEmail(claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+func (g *GoogleIDToken) audienceMatches(audiences []string) bool {
+	for _, audience := range audiences {
+		if utils.SliceContains(g.Audiences, audience) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkEmail checks the email of the token against the allowed emails and domains, if any.
+// The token passes if either the email is allowed or it belongs to an allowed domain (email domain or "hd" claim of
+// Google Workspace accounts).
+func (g *GoogleIDToken) checkEmail(claims map[string]interface{}) error {
+	if len(g.Emails) == 0 && len(g.Domains) == 0 {
+		return nil
+	}
+
+	email, _ := claims["email"].(string)
+	if verified, _ := claims["email_verified"].(bool); email == "" || !verified {
+		return fmt.Errorf("%s: missing verified email", invalidGoogleIDTokenMsg)
+	}
+
+	if utils.SliceContains(g.Emails, email) {
+		return nil
+	}
+
+	domain := email[strings.LastIndex(email, "@")+1:]
+	hostedDomain, _ := claims["hd"].(string)
+	if utils.SliceContains(g.Domains, domain) || (hostedDomain != "" && utils.SliceContains(g.Domains, hostedDomain)) {
+		return nil
+	}
+
+	return fmt.Errorf("%s: email not allowed", invalidGoogleIDTokenMsg)
+}
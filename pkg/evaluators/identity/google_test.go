@@ -0,0 +1,120 @@
+package identity
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/auth"
+	mock_auth "github.com/kuadrant/authorino/pkg/auth/mocks"
+
+	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"github.com/golang-jwt/jwt"
+	gomock "github.com/golang/mock/gomock"
+	"gotest.tools/assert"
+)
+
+func newGoogleTestCerts(t *testing.T) (*rsa.PrivateKey, *httptest.Server) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NilError(t, err)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "google",
+				"alg": "RS256",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return key, server
+}
+
+func issueGoogleTestIDToken(key *rsa.PrivateKey, claims jwt.MapClaims) string {
+	defaultClaims := jwt.MapClaims{
+		"iss": "https://accounts.google.com",
+		"aud": "https://pets-abc123-uc.a.run.app",
+		"sub": "113664012365414427311",
+		"iat": time.Now().Unix(),
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	for k, v := range claims {
+		defaultClaims[k] = v
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, defaultClaims)
+	token.Header["kid"] = "google"
+	signed, _ := token.SignedString(key)
+	return signed
+}
+
+func callGoogleIDToken(ctrl *gomock.Controller, googleIDToken *GoogleIDToken, token string) (interface{}, error) {
+	pipeline := mock_auth.NewMockAuthPipeline(ctrl)
+	pipeline.EXPECT().GetHttp().Return(&envoy_auth.AttributeContext_HttpRequest{
+		Headers: map[string]string{"authorization": "Bearer " + token},
+	})
+	return googleIDToken.Call(pipeline, context.TODO())
+}
+
+func TestGoogleIDToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	key, certs := newGoogleTestCerts(t)
+	googleIDToken := newGoogleIDToken(certs.URL, []string{"https://pets-abc123-uc.a.run.app"}, nil, nil, &auth.AuthCredential{KeySelector: "Bearer", In: "authorization_header"})
+
+	obj, err := callGoogleIDToken(ctrl, googleIDToken, issueGoogleTestIDToken(key, nil))
+	assert.NilError(t, err)
+	claims := obj.(map[string]interface{})
+	assert.Equal(t, claims["sub"], "113664012365414427311")
+
+	// google sometimes issues tokens without the scheme in the issuer claim
+	_, err = callGoogleIDToken(ctrl, googleIDToken, issueGoogleTestIDToken(key, jwt.MapClaims{"iss": "accounts.google.com"}))
+	assert.NilError(t, err)
+
+	_, err = callGoogleIDToken(ctrl, googleIDToken, issueGoogleTestIDToken(key, jwt.MapClaims{"aud": "https://other.example.com"}))
+	assert.Error(t, err, "invalid google id token: audience mismatch")
+
+	_, err = callGoogleIDToken(ctrl, googleIDToken, issueGoogleTestIDToken(key, jwt.MapClaims{"iss": "https://evil.example.com"}))
+	assert.ErrorContains(t, err, "invalid google id token: oidc: id token issued by a different provider")
+
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	_, err = callGoogleIDToken(ctrl, googleIDToken, issueGoogleTestIDToken(otherKey, nil))
+	assert.ErrorContains(t, err, "invalid google id token: failed to verify signature")
+}
+
+func TestGoogleIDTokenEmailConstraints(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	key, certs := newGoogleTestCerts(t)
+	googleIDToken := newGoogleIDToken(certs.URL, []string{"https://pets-abc123-uc.a.run.app"}, []string{"pubsub-push@my-project.iam.gserviceaccount.com"}, []string{"example.com"}, &auth.AuthCredential{KeySelector: "Bearer", In: "authorization_header"})
+
+	_, err := callGoogleIDToken(ctrl, googleIDToken, issueGoogleTestIDToken(key, jwt.MapClaims{"email": "pubsub-push@my-project.iam.gserviceaccount.com", "email_verified": true}))
+	assert.NilError(t, err)
+
+	_, err = callGoogleIDToken(ctrl, googleIDToken, issueGoogleTestIDToken(key, jwt.MapClaims{"email": "john@example.com", "email_verified": true}))
+	assert.NilError(t, err)
+
+	_, err = callGoogleIDToken(ctrl, googleIDToken, issueGoogleTestIDToken(key, jwt.MapClaims{"email": "john@acme.io", "email_verified": true, "hd": "example.com"}))
+	assert.NilError(t, err)
+
+	_, err = callGoogleIDToken(ctrl, googleIDToken, issueGoogleTestIDToken(key, jwt.MapClaims{"email": "other@my-project.iam.gserviceaccount.com", "email_verified": true}))
+	assert.Error(t, err, "invalid google id token: email not allowed")
+
+	_, err = callGoogleIDToken(ctrl, googleIDToken, issueGoogleTestIDToken(key, jwt.MapClaims{"email": "john@example.com", "email_verified": false}))
+	assert.Error(t, err, "invalid google id token: missing verified email")
+
+	_, err = callGoogleIDToken(ctrl, googleIDToken, issueGoogleTestIDToken(key, nil))
+	assert.Error(t, err, "invalid google id token: missing verified email")
+}
@@ -11,9 +11,7 @@ import (
 	"github.com/kuadrant/authorino/pkg/auth"
 	"github.com/kuadrant/authorino/pkg/context"
 	"github.com/kuadrant/authorino/pkg/log"
-
-	"go.opentelemetry.io/otel"
-	otel_propagation "go.opentelemetry.io/otel/propagation"
+	"github.com/kuadrant/authorino/pkg/trace"
 )
 
 type OAuth2 struct {
@@ -71,9 +69,7 @@ func (oauth *OAuth2) Call(pipeline auth.AuthPipeline, ctx gocontext.Context) (in
 
 	log.FromContext(ctx).WithName("oauth2").V(1).Info("sending token introspection request", "url", tokenIntrospectionURL.String(), "data", encodedFormData)
 
-	otel.GetTextMapPropagator().Inject(ctx, otel_propagation.HeaderCarrier(req.Header))
-
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := trace.HTTPClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -2,12 +2,18 @@ package identity
 
 import (
 	gocontext "context"
+	"encoding/base64"
+	gojson "encoding/json"
 	"fmt"
 	"net/url"
+	"regexp"
+	"strings"
+	"sync"
 
 	"github.com/kuadrant/authorino/pkg/auth"
 	"github.com/kuadrant/authorino/pkg/context"
 	"github.com/kuadrant/authorino/pkg/log"
+	"github.com/kuadrant/authorino/pkg/trace"
 	"github.com/kuadrant/authorino/pkg/workers"
 
 	goidc "github.com/coreos/go-oidc"
@@ -18,13 +24,23 @@ const (
 	msg_oidcProviderConfigRefreshSuccess  = "openid connect configuration updated"
 	msg_oidcProviderConfigRefreshError    = "failed to discovery openid connect configuration"
 	msg_oidcProviderConfigRefreshDisabled = "auto-refresh of openid connect configuration disabled"
+	msg_oidcTenantNotAllowedError         = "tenant not allowed"
 )
 
+// issuerUrlPlaceholder matches the placeholders of the issuer URL templates of multi-tenant identity providers,
+// resolved from the claims of the token (e.g. "{tid}" in "https://login.microsoftonline.com/{tid}/v2.0")
+var issuerUrlPlaceholder = regexp.MustCompile(`\{([^{}/]+)\}`)
+
 type OIDC struct {
 	auth.AuthCredentials
-	Endpoint  string `yaml:"endpoint"`
+	Endpoint  string   `yaml:"endpoint"`
+	Tenants   []string `yaml:"tenants,omitempty"`
 	provider  *goidc.Provider
 	refresher workers.Worker
+
+	// discovered OpenID Connect configurations of the multi-tenant identity providers, by resolved issuer URL
+	tenantProviders map[string]*goidc.Provider
+	mutex           sync.RWMutex
 }
 
 func NewOIDC(endpoint string, creds auth.AuthCredentials, ttl int, ctx gocontext.Context) *OIDC {
@@ -38,6 +54,26 @@ func NewOIDC(endpoint string, creds auth.AuthCredentials, ttl int, ctx gocontext
 	return oidc
 }
 
+// NewMultiTenantOIDC returns an OIDC evaluator whose issuer URL is a template with placeholders resolved from the
+// claims of the tokens (e.g. "https://login.microsoftonline.com/{tid}/v2.0").
+// Only tokens whose resolved placeholder values are listed in the allowed tenants are verified. The OpenID Connect
+// configurations of the tenants are discovered on demand.
+func NewMultiTenantOIDC(endpointTemplate string, tenants []string, creds auth.AuthCredentials, ttl int, ctx gocontext.Context) *OIDC {
+	oidc := &OIDC{
+		AuthCredentials: creds,
+		Endpoint:        endpointTemplate,
+		Tenants:         tenants,
+		tenantProviders: make(map[string]*goidc.Provider),
+	}
+	oidc.configureProviderRefresh(ttl, log.IntoContext(ctx, log.FromContext(ctx).WithName("oidc")))
+	return oidc
+}
+
+// IsMultiTenant tells whether the issuer URL is a template resolved from the claims of the tokens
+func (oidc *OIDC) IsMultiTenant() bool {
+	return oidc.tenantProviders != nil
+}
+
 func (oidc *OIDC) Call(pipeline auth.AuthPipeline, ctx gocontext.Context) (interface{}, error) {
 	// retrieve access token
 	accessToken, err := oidc.GetCredentialsFromReq(pipeline.GetRequest().GetAttributes().GetRequest().GetHttp())
@@ -54,13 +90,26 @@ func (oidc *OIDC) Call(pipeline auth.AuthPipeline, ctx gocontext.Context) (inter
 	}
 }
 
+// VerifyBinding verifies the proof of possession of the key or client certificate the token is bound to, if any.
+// Unlike the signature of the token, the proof and the client certificate come with each request, thus cannot be
+// cached with the identity.
+func (oidc *OIDC) VerifyBinding(pipeline auth.AuthPipeline, identity interface{}, replays ProofReplayCache) error {
+	attrs := pipeline.GetRequest().GetAttributes()
+	httpReq := attrs.GetRequest().GetHttp()
+	accessToken, err := oidc.GetCredentialsFromReq(httpReq)
+	if err != nil {
+		return err
+	}
+	claims, _ := identity.(map[string]interface{})
+	if err := validateDPoPProof(httpReq, accessToken, claims, replays); err != nil {
+		return err
+	}
+	return verifyCertificateBinding(attrs.GetSource().GetCertificate(), claims)
+}
+
 func (oidc *OIDC) getProvider(ctx gocontext.Context, force bool) *goidc.Provider {
 	if oidc.provider == nil || force {
-		endpoint := oidc.Endpoint
-		if provider, err := goidc.NewProvider(gocontext.TODO(), endpoint); err != nil {
-			log.FromContext(ctx).Error(err, msg_oidcProviderConfigRefreshError, "endpoint", endpoint)
-		} else {
-			log.FromContext(ctx).V(1).Info(msg_oidcProviderConfigRefreshSuccess, "endpoint", endpoint)
+		if provider := discoverProvider(ctx, oidc.Endpoint); provider != nil {
 			oidc.provider = provider
 		}
 	}
@@ -68,6 +117,33 @@ func (oidc *OIDC) getProvider(ctx gocontext.Context, force bool) *goidc.Provider
 	return oidc.provider
 }
 
+func (oidc *OIDC) getTenantProvider(ctx gocontext.Context, endpoint string, force bool) *goidc.Provider {
+	oidc.mutex.RLock()
+	provider, cached := oidc.tenantProviders[endpoint]
+	oidc.mutex.RUnlock()
+
+	if !cached || force {
+		if discovered := discoverProvider(ctx, endpoint); discovered != nil {
+			provider = discovered
+			oidc.mutex.Lock()
+			oidc.tenantProviders[endpoint] = provider
+			oidc.mutex.Unlock()
+		}
+	}
+
+	return provider
+}
+
+func discoverProvider(ctx gocontext.Context, endpoint string) *goidc.Provider {
+	provider, err := goidc.NewProvider(goidc.ClientContext(gocontext.TODO(), trace.HTTPClient), endpoint)
+	if err != nil {
+		log.FromContext(ctx).Error(err, msg_oidcProviderConfigRefreshError, "endpoint", endpoint)
+		return nil
+	}
+	log.FromContext(ctx).V(1).Info(msg_oidcProviderConfigRefreshSuccess, "endpoint", endpoint)
+	return provider
+}
+
 func (oidc *OIDC) decodeAndVerifyToken(accessToken string, ctx gocontext.Context, claims *interface{}) (*goidc.IDToken, error) {
 	if err := context.CheckContext(ctx); err != nil {
 		return nil, err
@@ -88,13 +164,24 @@ func (oidc *OIDC) decodeAndVerifyToken(accessToken string, ctx gocontext.Context
 }
 
 func (oidc *OIDC) verifyToken(accessToken string, ctx gocontext.Context) (*goidc.IDToken, error) {
-	provider := oidc.getProvider(ctx, false)
+	var provider *goidc.Provider
+
+	if oidc.IsMultiTenant() {
+		issuerUrl, err := oidc.resolveIssuerUrl(accessToken)
+		if err != nil {
+			return nil, err
+		}
+		provider = oidc.getTenantProvider(ctx, issuerUrl, false)
+	} else {
+		provider = oidc.getProvider(ctx, false)
+	}
 
 	if provider == nil {
 		return nil, fmt.Errorf(msg_oidcProviderConfigMissingError)
 	}
 
-	tokenVerifierConfig := &goidc.Config{SkipClientIDCheck: true, SkipIssuerCheck: true}
+	// the issuer of the tokens of multi-tenant identity providers must match the resolved issuer url
+	tokenVerifierConfig := &goidc.Config{SkipClientIDCheck: true, SkipIssuerCheck: !oidc.IsMultiTenant()}
 	if idToken, err := provider.Verifier(tokenVerifierConfig).Verify(ctx, accessToken); err != nil {
 		return nil, err
 	} else {
@@ -102,9 +189,61 @@ func (oidc *OIDC) verifyToken(accessToken string, ctx gocontext.Context) (*goidc
 	}
 }
 
+// resolveIssuerUrl resolves the issuer url template out of the claims of the token, before verifying the token.
+// The resolved values must be listed in the allowed tenants.
+func (oidc *OIDC) resolveIssuerUrl(accessToken string) (string, error) {
+	parts := strings.Split(accessToken, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("malformed jwt, expected 3 parts got %d", len(parts))
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return "", fmt.Errorf("malformed jwt payload: %v", err)
+	}
+	var claims map[string]interface{}
+	if err := gojson.Unmarshal(payload, &claims); err != nil {
+		return "", fmt.Errorf("malformed jwt payload: %v", err)
+	}
+	return resolveIssuerUrlTemplate(oidc.Endpoint, oidc.Tenants, claims)
+}
+
+func resolveIssuerUrlTemplate(template string, tenants []string, claims map[string]interface{}) (string, error) {
+	var err error
+	issuerUrl := issuerUrlPlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		if err != nil {
+			return ""
+		}
+		claim := issuerUrlPlaceholder.FindStringSubmatch(placeholder)[1]
+		value, _ := claims[claim].(string)
+		if value == "" {
+			err = fmt.Errorf("missing %s claim", claim)
+			return ""
+		}
+		for _, tenant := range tenants {
+			if tenant == value {
+				return value
+			}
+		}
+		err = fmt.Errorf("%s: %s", msg_oidcTenantNotAllowedError, value)
+		return ""
+	})
+	if err != nil {
+		return "", err
+	}
+	return issuerUrl, nil
+}
+
 func (oidc *OIDC) GetURL(name string, ctx gocontext.Context) (*url.URL, error) {
+	if oidc.IsMultiTenant() {
+		return nil, fmt.Errorf("%s: multi-tenant issuer", msg_oidcProviderConfigMissingError)
+	}
+	provider := oidc.getProvider(ctx, false)
+	if provider == nil {
+		return nil, fmt.Errorf(msg_oidcProviderConfigMissingError)
+	}
+
 	var providerClaims map[string]interface{}
-	_ = oidc.getProvider(ctx, false).Claims(&providerClaims)
+	_ = provider.Claims(&providerClaims)
 
 	if endpoint, err := url.Parse(providerClaims[name].(string)); err != nil {
 		return nil, err
@@ -117,7 +256,19 @@ func (oidc *OIDC) configureProviderRefresh(ttl int, ctx gocontext.Context) {
 	var err error
 
 	oidc.refresher, err = workers.StartWorker(ctx, ttl, func() {
-		oidc.getProvider(ctx, true)
+		if !oidc.IsMultiTenant() {
+			oidc.getProvider(ctx, true)
+			return
+		}
+		oidc.mutex.RLock()
+		endpoints := make([]string, 0, len(oidc.tenantProviders))
+		for endpoint := range oidc.tenantProviders {
+			endpoints = append(endpoints, endpoint)
+		}
+		oidc.mutex.RUnlock()
+		for _, endpoint := range endpoints {
+			oidc.getTenantProvider(ctx, endpoint, true)
+		}
 	})
 
 	if err != nil {
@@ -142,3 +142,59 @@ func TestOidcProviderRefreshClean(t *testing.T) {
 	err := evaluator.Clean(context.Background())
 	assert.NilError(t, err)
 }
+
+func TestResolveIssuerUrlTemplate(t *testing.T) {
+	template := "https://login.microsoftonline.com/{tid}/v2.0"
+	tenants := []string{"tenant-a", "tenant-b"}
+
+	issuerUrl, err := resolveIssuerUrlTemplate(template, tenants, map[string]interface{}{"tid": "tenant-b"})
+	assert.NilError(t, err)
+	assert.Equal(t, issuerUrl, "https://login.microsoftonline.com/tenant-b/v2.0")
+
+	_, err = resolveIssuerUrlTemplate(template, tenants, map[string]interface{}{"tid": "tenant-c"})
+	assert.Error(t, err, "tenant not allowed: tenant-c")
+
+	_, err = resolveIssuerUrlTemplate(template, tenants, map[string]interface{}{"sub": "john"})
+	assert.Error(t, err, "missing tid claim")
+
+	_, err = resolveIssuerUrlTemplate(template, tenants, map[string]interface{}{"tid": 123})
+	assert.Error(t, err, "missing tid claim")
+}
+
+func TestMultiTenantOidcVerifyToken(t *testing.T) {
+	count := 0
+	authServer := httptest.NewHttpServerMock(oidcServerHost, map[string]httptest.HttpServerMockResponseFunc{
+		"/tenant-a/.well-known/openid-configuration": func() httptest.HttpServerMockResponse {
+			count += 1
+			return httptest.HttpServerMockResponse{
+				Status:  200,
+				Headers: map[string]string{"Content-Type": "application/json"},
+				Body:    fmt.Sprintf(`{ "issuer": "http://%v/tenant-a", "authorization_endpoint": "http://%v/tenant-a/auth" }`, oidcServerHost, oidcServerHost),
+			}
+		},
+	})
+	defer authServer.Close()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	authCredMock := mock_auth.NewMockAuthCredentials(ctrl)
+
+	evaluator := NewMultiTenantOIDC(fmt.Sprintf("http://%v/{tid}", oidcServerHost), []string{"tenant-a"}, authCredMock, 0, context.TODO())
+	defer evaluator.Clean(context.Background())
+	assert.Equal(t, 0, count)
+
+	// {"alg":"none"}.{"tid":"tenant-b"}
+	_, err := evaluator.verifyToken("eyJhbGciOiJub25lIn0.eyJ0aWQiOiJ0ZW5hbnQtYiJ9.", context.TODO())
+	assert.Error(t, err, "tenant not allowed: tenant-b")
+	assert.Equal(t, 0, count)
+
+	// {"alg":"none"}.{"tid":"tenant-a"}
+	_, err = evaluator.verifyToken("eyJhbGciOiJub25lIn0.eyJ0aWQiOiJ0ZW5hbnQtYSJ9.", context.TODO())
+	assert.Check(t, err != nil) // unsigned token
+	_, err = evaluator.verifyToken("eyJhbGciOiJub25lIn0.eyJ0aWQiOiJ0ZW5hbnQtYSJ9.", context.TODO())
+	assert.Check(t, err != nil)
+	assert.Equal(t, 1, count)
+	_, cached := evaluator.tenantProviders[fmt.Sprintf("http://%v/tenant-a", oidcServerHost)]
+	assert.Check(t, cached)
+}
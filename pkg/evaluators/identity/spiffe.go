@@ -0,0 +1,350 @@
+package identity
+
+import (
+	gocontext "context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	gojson "encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/auth"
+	"github.com/kuadrant/authorino/pkg/context"
+	"github.com/kuadrant/authorino/pkg/log"
+	"github.com/kuadrant/authorino/pkg/trace"
+	"github.com/kuadrant/authorino/pkg/workers"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	spiffeScheme       = "spiffe"
+	spiffeX509SVIDType = "x509-svid"
+	spiffeJWTSVIDType  = "jwt-svid"
+
+	msg_spiffeBundleMissingError    = "missing spiffe trust bundle"
+	msg_spiffeBundleRefreshSuccess  = "spiffe trust bundle updated"
+	msg_spiffeBundleRefreshError    = "failed to fetch spiffe trust bundle"
+	msg_spiffeBundleRefreshDisabled = "auto-refresh of spiffe trust bundle disabled"
+)
+
+// Spiffe verifies SPIFFE Verifiable Identity Documents (SVIDs) of workloads of a trust domain.
+// X.509-SVIDs are read from the client certificate of the request (mTLS) and JWT-SVIDs from the credentials.
+// The trust bundle of the trust domain is fetched from a SPIFFE bundle endpoint (https_web profile).
+type Spiffe struct {
+	auth.AuthCredentials
+
+	TrustDomain    string
+	BundleEndpoint string
+	Audiences      []string
+
+	bundle    *spiffeBundle
+	mutex     sync.RWMutex
+	refresher workers.Worker
+}
+
+type spiffeBundle struct {
+	x509Authorities *x509.CertPool
+	jwtAuthorities  map[string]interface{}
+}
+
+func NewSpiffeIdentity(trustDomain, bundleEndpoint string, audiences []string, creds auth.AuthCredentials, ttl int, ctx gocontext.Context) *Spiffe {
+	spiffe := &Spiffe{
+		AuthCredentials: creds,
+		TrustDomain:     trustDomain,
+		BundleEndpoint:  bundleEndpoint,
+		Audiences:       audiences,
+	}
+	ctxWithLogger := log.IntoContext(ctx, log.FromContext(ctx).WithName("spiffe"))
+	spiffe.refreshBundle(ctxWithLogger)
+	spiffe.configureBundleRefresh(ttl, ctxWithLogger)
+	return spiffe
+}
+
+func (s *Spiffe) Call(pipeline auth.AuthPipeline, ctx gocontext.Context) (interface{}, error) {
+	if err := context.CheckContext(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mutex.RLock()
+	bundle := s.bundle
+	s.mutex.RUnlock()
+
+	if bundle == nil {
+		return nil, fmt.Errorf(msg_spiffeBundleMissingError)
+	}
+
+	if urlEncodedCert := pipeline.GetRequest().Attributes.Source.GetCertificate(); urlEncodedCert != "" {
+		return s.verifyX509SVID(urlEncodedCert, bundle)
+	}
+
+	request := pipeline.GetHttp()
+	token, err := s.GetCredentialsFromReq(request)
+	if err != nil {
+		return nil, err
+	}
+	return s.verifyJWTSVID(token, request.GetHost(), bundle)
+}
+
+func (s *Spiffe) verifyX509SVID(urlEncodedCert string, bundle *spiffeBundle) (interface{}, error) {
+	pemEncodedCert, err := url.QueryUnescape(urlEncodedCert)
+	if err != nil {
+		return nil, fmt.Errorf("invalid x509-svid")
+	}
+	cert := decodeCertificate([]byte(pemEncodedCert))
+	if cert == nil {
+		return nil, fmt.Errorf("invalid x509-svid")
+	}
+	if cert.IsCA {
+		return nil, fmt.Errorf("invalid x509-svid: not a leaf certificate")
+	}
+	if len(cert.URIs) != 1 {
+		return nil, fmt.Errorf("invalid x509-svid: expected exactly one uri san")
+	}
+	spiffeID, err := s.parseSpiffeID(cert.URIs[0].String())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := cert.Verify(x509.VerifyOptions{Roots: bundle.x509Authorities, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}}); err != nil {
+		return nil, err
+	}
+
+	return spiffeIdentity(spiffeID, spiffeX509SVIDType), nil
+}
+
+func (s *Spiffe) verifyJWTSVID(token, host string, bundle *spiffeBundle) (interface{}, error) {
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		switch t.Method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS, *jwt.SigningMethodECDSA:
+		default:
+			return nil, fmt.Errorf("unsupported signing algorithm: %v", t.Header["alg"])
+		}
+		kid, _ := t.Header["kid"].(string)
+		if key, found := bundle.jwtAuthorities[kid]; found {
+			return key, nil
+		}
+		return nil, fmt.Errorf("unknown jwt-svid signing key: %s", kid)
+	}); err != nil {
+		return nil, err
+	}
+
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return nil, fmt.Errorf("invalid jwt-svid: missing or expired exp claim")
+	}
+	audiences := s.Audiences
+	if len(audiences) == 0 {
+		audiences = []string{host}
+	}
+	audienceMatched := false
+	for _, audience := range audiences {
+		if claims.VerifyAudience(audience, true) {
+			audienceMatched = true
+			break
+		}
+	}
+	if !audienceMatched {
+		return nil, fmt.Errorf("invalid jwt-svid: audience mismatch")
+	}
+	sub, _ := claims["sub"].(string)
+	spiffeID, err := s.parseSpiffeID(sub)
+	if err != nil {
+		return nil, err
+	}
+
+	identity := spiffeIdentity(spiffeID, spiffeJWTSVIDType)
+	identity["claims"] = map[string]interface{}(claims)
+	return identity, nil
+}
+
+// parseSpiffeID parses a SPIFFE ID and checks it belongs to the trust domain
+func (s *Spiffe) parseSpiffeID(id string) (*url.URL, error) {
+	spiffeID, err := url.Parse(id)
+	if err != nil || spiffeID.Scheme != spiffeScheme || spiffeID.Host == "" || spiffeID.Port() != "" || spiffeID.User != nil || spiffeID.RawQuery != "" || spiffeID.Fragment != "" {
+		return nil, fmt.Errorf("invalid spiffe id: %s", id)
+	}
+	if !strings.EqualFold(spiffeID.Host, s.TrustDomain) {
+		return nil, fmt.Errorf("spiffe id not in trust domain %s: %s", s.TrustDomain, id)
+	}
+	return spiffeID, nil
+}
+
+func spiffeIdentity(spiffeID *url.URL, svidType string) map[string]interface{} {
+	return map[string]interface{}{
+		"spiffe_id":    spiffeID.String(),
+		"trust_domain": spiffeID.Host,
+		"path":         spiffeID.Path,
+		"svid_type":    svidType,
+	}
+}
+
+func (s *Spiffe) refreshBundle(ctx gocontext.Context) {
+	bundle, err := fetchSpiffeBundle(ctx, s.BundleEndpoint)
+	if err != nil {
+		log.FromContext(ctx).Error(err, msg_spiffeBundleRefreshError, "endpoint", s.BundleEndpoint)
+		return
+	}
+
+	s.mutex.Lock()
+	s.bundle = bundle
+	s.mutex.Unlock()
+
+	log.FromContext(ctx).V(1).Info(msg_spiffeBundleRefreshSuccess, "endpoint", s.BundleEndpoint)
+}
+
+func (s *Spiffe) configureBundleRefresh(ttl int, ctx gocontext.Context) {
+	var err error
+
+	s.refresher, err = workers.StartWorker(ctx, ttl, func() {
+		s.refreshBundle(ctx)
+	})
+
+	if err != nil {
+		log.FromContext(ctx).V(1).Info(msg_spiffeBundleRefreshDisabled, "reason", err)
+	}
+}
+
+// Clean ensures the goroutine started by configureBundleRefresh is cleaned up
+func (s *Spiffe) Clean(_ gocontext.Context) error {
+	if s.refresher == nil {
+		return nil
+	}
+	return s.refresher.Stop()
+}
+
+type spiffeBundleDocument struct {
+	Keys []spiffeBundleKey `json:"keys"`
+}
+
+type spiffeBundleKey struct {
+	Kty string   `json:"kty"`
+	Use string   `json:"use"`
+	Kid string   `json:"kid"`
+	X5c []string `json:"x5c"`
+	N   string   `json:"n"`
+	E   string   `json:"e"`
+	Crv string   `json:"crv"`
+	X   string   `json:"x"`
+	Y   string   `json:"y"`
+}
+
+// fetchSpiffeBundle fetches a SPIFFE trust bundle in the JWKS format from a bundle endpoint.
+// Keys of unknown use are ignored, as required by the SPIFFE Trust Domain and Bundle specification.
+func fetchSpiffeBundle(ctx gocontext.Context, endpoint string) (*spiffeBundle, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := trace.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code from the bundle endpoint: %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return parseSpiffeBundle(body)
+}
+
+func parseSpiffeBundle(data []byte) (*spiffeBundle, error) {
+	var doc spiffeBundleDocument
+	if err := gojson.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid spiffe trust bundle: %v", err)
+	}
+
+	bundle := &spiffeBundle{
+		x509Authorities: x509.NewCertPool(),
+		jwtAuthorities:  make(map[string]interface{}),
+	}
+
+	for _, key := range doc.Keys {
+		switch key.Use {
+		case spiffeX509SVIDType:
+			if len(key.X5c) != 1 {
+				return nil, fmt.Errorf("invalid x509-svid authority: expected exactly one certificate")
+			}
+			der, err := base64.StdEncoding.DecodeString(key.X5c[0])
+			if err != nil {
+				return nil, fmt.Errorf("invalid x509-svid authority: %v", err)
+			}
+			cert, err := x509.ParseCertificate(der)
+			if err != nil {
+				return nil, fmt.Errorf("invalid x509-svid authority: %v", err)
+			}
+			bundle.x509Authorities.AddCert(cert)
+		case spiffeJWTSVIDType:
+			if key.Kid == "" {
+				return nil, fmt.Errorf("invalid jwt-svid authority: missing kid")
+			}
+			publicKey, err := key.publicKey()
+			if err != nil {
+				return nil, fmt.Errorf("invalid jwt-svid authority %s: %v", key.Kid, err)
+			}
+			bundle.jwtAuthorities[key.Kid] = publicKey
+		}
+	}
+
+	return bundle, nil
+}
+
+func (k spiffeBundleKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve: %s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("invalid ec public key")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type: %s", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, fmt.Errorf("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
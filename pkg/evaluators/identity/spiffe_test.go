@@ -0,0 +1,193 @@
+package identity
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/auth"
+	mock_auth "github.com/kuadrant/authorino/pkg/auth/mocks"
+
+	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"github.com/golang-jwt/jwt"
+	gomock "github.com/golang/mock/gomock"
+	"gotest.tools/assert"
+)
+
+type spiffeTestTrustDomain struct {
+	caCert     *x509.Certificate
+	caKey      *ecdsa.PrivateKey
+	jwtKey     *ecdsa.PrivateKey
+	bundleJSON []byte
+}
+
+func newSpiffeTestTrustDomain(t *testing.T) *spiffeTestTrustDomain {
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "spire"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	assert.NilError(t, err)
+	caCert, _ := x509.ParseCertificate(caDER)
+
+	jwtKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	bundleJSON, _ := json.Marshal(map[string]interface{}{
+		"keys": []map[string]interface{}{
+			{"kty": "EC", "use": "x509-svid", "crv": "P-256", "x": base64.RawURLEncoding.EncodeToString(caKey.X.Bytes()), "y": base64.RawURLEncoding.EncodeToString(caKey.Y.Bytes()), "x5c": []string{base64.StdEncoding.EncodeToString(caDER)}},
+			{"kty": "EC", "use": "jwt-svid", "kid": "jwt-1", "crv": "P-256", "x": base64.RawURLEncoding.EncodeToString(jwtKey.X.Bytes()), "y": base64.RawURLEncoding.EncodeToString(jwtKey.Y.Bytes())},
+		},
+		"spiffe_refresh_hint": 300,
+	})
+
+	return &spiffeTestTrustDomain{caCert: caCert, caKey: caKey, jwtKey: jwtKey, bundleJSON: bundleJSON}
+}
+
+func (td *spiffeTestTrustDomain) issueX509SVID(t *testing.T, spiffeID string) string {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	uri, _ := url.Parse(spiffeID)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		URIs:         []*url.URL{uri},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, td.caCert, &key.PublicKey, td.caKey)
+	assert.NilError(t, err)
+	return url.QueryEscape(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})))
+}
+
+func (td *spiffeTestTrustDomain) issueJWTSVID(spiffeID, audience string) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"sub": spiffeID,
+		"aud": []string{audience},
+		"exp": time.Now().Add(time.Minute).Unix(),
+	})
+	token.Header["kid"] = "jwt-1"
+	signed, _ := token.SignedString(td.jwtKey)
+	return signed
+}
+
+func newSpiffeTestIdentity(t *testing.T, td *spiffeTestTrustDomain, audiences []string) *Spiffe {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(td.bundleJSON)
+	}))
+	t.Cleanup(server.Close)
+	return NewSpiffeIdentity("example.org", server.URL, audiences, &auth.AuthCredential{KeySelector: "Bearer", In: "authorization_header"}, 0, context.TODO())
+}
+
+func TestSpiffeX509SVID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	td := newSpiffeTestTrustDomain(t)
+	spiffe := newSpiffeTestIdentity(t, td, nil)
+
+	pipeline := mock_auth.NewMockAuthPipeline(ctrl)
+	pipeline.EXPECT().GetRequest().Return(&envoy_auth.CheckRequest{
+		Attributes: &envoy_auth.AttributeContext{
+			Source: &envoy_auth.AttributeContext_Peer{Certificate: td.issueX509SVID(t, "spiffe://example.org/ns/default/sa/pets")},
+		},
+	})
+	obj, err := spiffe.Call(pipeline, context.TODO())
+	assert.NilError(t, err)
+	identity := obj.(map[string]interface{})
+	assert.Equal(t, identity["spiffe_id"], "spiffe://example.org/ns/default/sa/pets")
+	assert.Equal(t, identity["trust_domain"], "example.org")
+	assert.Equal(t, identity["path"], "/ns/default/sa/pets")
+	assert.Equal(t, identity["svid_type"], "x509-svid")
+
+	// svid of another trust domain
+	pipeline.EXPECT().GetRequest().Return(&envoy_auth.CheckRequest{
+		Attributes: &envoy_auth.AttributeContext{
+			Source: &envoy_auth.AttributeContext_Peer{Certificate: td.issueX509SVID(t, "spiffe://other.org/ns/default/sa/pets")},
+		},
+	})
+	_, err = spiffe.Call(pipeline, context.TODO())
+	assert.ErrorContains(t, err, "spiffe id not in trust domain example.org")
+
+	// svid signed by an untrusted authority
+	untrusted := newSpiffeTestTrustDomain(t)
+	pipeline.EXPECT().GetRequest().Return(&envoy_auth.CheckRequest{
+		Attributes: &envoy_auth.AttributeContext{
+			Source: &envoy_auth.AttributeContext_Peer{Certificate: untrusted.issueX509SVID(t, "spiffe://example.org/ns/default/sa/pets")},
+		},
+	})
+	_, err = spiffe.Call(pipeline, context.TODO())
+	assert.ErrorContains(t, err, "certificate signed by unknown authority")
+}
+
+func TestSpiffeJWTSVID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	td := newSpiffeTestTrustDomain(t)
+	spiffe := newSpiffeTestIdentity(t, td, []string{"pets-api"})
+
+	pipeline := mock_auth.NewMockAuthPipeline(ctrl)
+	pipeline.EXPECT().GetRequest().Return(&envoy_auth.CheckRequest{Attributes: &envoy_auth.AttributeContext{}})
+	pipeline.EXPECT().GetHttp().Return(&envoy_auth.AttributeContext_HttpRequest{
+		Host:    "pets.example.org",
+		Headers: map[string]string{"authorization": "Bearer " + td.issueJWTSVID("spiffe://example.org/ns/default/sa/cars", "pets-api")},
+	})
+	obj, err := spiffe.Call(pipeline, context.TODO())
+	assert.NilError(t, err)
+	identity := obj.(map[string]interface{})
+	assert.Equal(t, identity["spiffe_id"], "spiffe://example.org/ns/default/sa/cars")
+	assert.Equal(t, identity["svid_type"], "jwt-svid")
+
+	// audience mismatch
+	pipeline.EXPECT().GetRequest().Return(&envoy_auth.CheckRequest{Attributes: &envoy_auth.AttributeContext{}})
+	pipeline.EXPECT().GetHttp().Return(&envoy_auth.AttributeContext_HttpRequest{
+		Host:    "pets.example.org",
+		Headers: map[string]string{"authorization": "Bearer " + td.issueJWTSVID("spiffe://example.org/ns/default/sa/cars", "other-api")},
+	})
+	_, err = spiffe.Call(pipeline, context.TODO())
+	assert.Error(t, err, "invalid jwt-svid: audience mismatch")
+}
+
+func TestSpiffeJWTSVIDDefaultAudience(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	td := newSpiffeTestTrustDomain(t)
+	spiffe := newSpiffeTestIdentity(t, td, nil)
+
+	pipeline := mock_auth.NewMockAuthPipeline(ctrl)
+	pipeline.EXPECT().GetRequest().Return(&envoy_auth.CheckRequest{Attributes: &envoy_auth.AttributeContext{}})
+	pipeline.EXPECT().GetHttp().Return(&envoy_auth.AttributeContext_HttpRequest{
+		Host:    "pets.example.org",
+		Headers: map[string]string{"authorization": "Bearer " + td.issueJWTSVID("spiffe://example.org/ns/default/sa/cars", "pets.example.org")},
+	})
+	_, err := spiffe.Call(pipeline, context.TODO())
+	assert.NilError(t, err)
+}
+
+func TestSpiffeMissingBundle(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	spiffe := NewSpiffeIdentity("example.org", "http://127.0.0.1:1/bundle", nil, &auth.AuthCredential{KeySelector: "Bearer", In: "authorization_header"}, 0, context.TODO())
+	_, err := spiffe.Call(mock_auth.NewMockAuthPipeline(ctrl), context.TODO())
+	assert.Error(t, err, "missing spiffe trust bundle")
+}
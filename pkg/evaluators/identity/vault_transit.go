@@ -0,0 +1,206 @@
+package identity
+
+import (
+	gocontext "context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/auth"
+	"github.com/kuadrant/authorino/pkg/context"
+	"github.com/kuadrant/authorino/pkg/utils"
+	"github.com/kuadrant/authorino/pkg/vault"
+
+	goidc "github.com/coreos/go-oidc"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+const (
+	DefaultVaultTransitMount = "transit"
+
+	invalidVaultTransitTokenMsg = "invalid token"
+
+	// how long to rely on the latest version of the transit key read from vault, for tokens that do not tell the version
+	vaultTransitKeyVersionTTL = time.Minute
+)
+
+var vaultTransitHashAlgorithms = map[string]string{"256": "sha2-256", "384": "sha2-384", "512": "sha2-512"}
+
+// VaultTransit verifies the signature of JWTs by calling the verify endpoint of a key of the Transit secrets engine of
+// HashiCorp Vault, instead of holding the public keys locally, for keys whose custody is centralized in Vault.
+// The version of the key is read from the `kid` header of the token (e.g. "3", "v3" or "<key name>:v3"); if missing,
+// the latest version of the key is used.
+// The expiration, issuer and audiences of the token are checked as for any other JWT.
+type VaultTransit struct {
+	auth.AuthCredentials
+
+	KeyName   string   `yaml:"keyName"`
+	Mount     string   `yaml:"mount"`
+	Issuer    string   `yaml:"issuer,omitempty"`
+	Audiences []string `yaml:"audiences,omitempty"`
+
+	client   *vault.Client
+	verifier *goidc.IDTokenVerifier
+
+	latestVersion          string
+	latestVersionExpiresAt time.Time
+	mutex                  sync.Mutex
+}
+
+func NewVaultTransit(client *vault.Client, mount, keyName, issuer string, audiences []string, creds auth.AuthCredentials) (*VaultTransit, error) {
+	if client == nil {
+		return nil, fmt.Errorf("failed to verify tokens with vault transit key %s: vault is not configured", keyName)
+	}
+	if mount == "" {
+		mount = DefaultVaultTransitMount
+	}
+
+	v := &VaultTransit{
+		AuthCredentials: creds,
+		KeyName:         keyName,
+		Mount:           strings.Trim(mount, "/"),
+		Issuer:          issuer,
+		Audiences:       audiences,
+		client:          client,
+	}
+	v.verifier = goidc.NewVerifier(issuer, v, &goidc.Config{
+		SkipClientIDCheck:    true,
+		SkipIssuerCheck:      issuer == "",
+		SupportedSigningAlgs: []string{"ES256", "ES384", "ES512", "RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "EdDSA"},
+	})
+
+	return v, nil
+}
+
+func (v *VaultTransit) Call(pipeline auth.AuthPipeline, ctx gocontext.Context) (interface{}, error) {
+	if err := context.CheckContext(ctx); err != nil {
+		return nil, err
+	}
+
+	token, err := v.GetCredentialsFromReq(pipeline.GetHttp())
+	if err != nil {
+		return nil, err
+	}
+
+	idToken, err := v.verifier.Verify(ctx, token)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", invalidVaultTransitTokenMsg, err)
+	}
+
+	if len(v.Audiences) > 0 && !v.audienceMatches(idToken.Audience) {
+		return nil, fmt.Errorf("%s: audience mismatch", invalidVaultTransitTokenMsg)
+	}
+
+	var claims map[string]interface{}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// VerifySignature verifies the signature of a JWT with the transit key and returns the payload of the token.
+// It implements the go-oidc KeySet interface.
+func (v *VaultTransit) VerifySignature(ctx gocontext.Context, jwt string) ([]byte, error) {
+	jws, err := jose.ParseSigned(jwt)
+	if err != nil {
+		return nil, err
+	}
+	header := jws.Signatures[0].Header
+
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed jwt")
+	}
+
+	version, err := v.keyVersion(ctx, header.KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	algorithm := header.Algorithm
+	request := map[string]interface{}{
+		"input": base64.StdEncoding.EncodeToString([]byte(parts[0] + "." + parts[1])),
+	}
+	path := fmt.Sprintf("%s/verify/%s", v.Mount, v.KeyName)
+	switch {
+	case strings.HasPrefix(algorithm, "ES"):
+		// the jws marshaling takes the signature as is, i.e. base64url-encoded r||s
+		request["signature"] = "vault:v" + version + ":" + parts[2]
+		request["marshaling_algorithm"] = "jws"
+	case strings.HasPrefix(algorithm, "RS"), strings.HasPrefix(algorithm, "PS"):
+		signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("malformed jwt signature: %v", err)
+		}
+		request["signature"] = "vault:v" + version + ":" + base64.StdEncoding.EncodeToString(signature)
+		request["signature_algorithm"] = "pss"
+		if strings.HasPrefix(algorithm, "RS") {
+			request["signature_algorithm"] = "pkcs1v15"
+		}
+	case algorithm == "EdDSA":
+		signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("malformed jwt signature: %v", err)
+		}
+		request["signature"] = "vault:v" + version + ":" + base64.StdEncoding.EncodeToString(signature)
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm: %s", algorithm)
+	}
+	if hash, ok := vaultTransitHashAlgorithms[algorithm[2:]]; ok {
+		path += "/" + hash
+	}
+
+	resp, err := v.client.Write(ctx, path, request)
+	if err != nil {
+		return nil, err
+	}
+	if valid, _ := resp["valid"].(bool); !valid {
+		return nil, fmt.Errorf("invalid signature")
+	}
+
+	return base64.RawURLEncoding.DecodeString(parts[1])
+}
+
+// keyVersion returns the version of the transit key told by the kid header of the token, or the latest version of
+// the key if the kid does not tell it
+func (v *VaultTransit) keyVersion(ctx gocontext.Context, kid string) (string, error) {
+	if version := strings.TrimPrefix(kid[strings.LastIndex(kid, ":")+1:], "v"); version != "" {
+		if n, err := strconv.Atoi(version); err == nil && n > 0 {
+			return version, nil
+		}
+	}
+
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	if v.latestVersion != "" && time.Now().Before(v.latestVersionExpiresAt) {
+		return v.latestVersion, nil
+	}
+
+	key, err := v.client.Read(ctx, fmt.Sprintf("%s/keys/%s", v.Mount, v.KeyName))
+	if err != nil {
+		return "", err
+	}
+	latestVersion := string(key.Data["latest_version"])
+	if n, err := strconv.Atoi(latestVersion); err != nil || n <= 0 {
+		return "", fmt.Errorf("missing latest version of vault transit key %s", v.KeyName)
+	}
+
+	v.latestVersion = latestVersion
+	v.latestVersionExpiresAt = time.Now().Add(vaultTransitKeyVersionTTL)
+
+	return v.latestVersion, nil
+}
+
+func (v *VaultTransit) audienceMatches(audiences []string) bool {
+	for _, audience := range audiences {
+		if utils.SliceContains(v.Audiences, audience) {
+			return true
+		}
+	}
+	return false
+}
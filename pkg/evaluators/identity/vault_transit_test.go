@@ -0,0 +1,133 @@
+package identity
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/auth"
+	mock_auth "github.com/kuadrant/authorino/pkg/auth/mocks"
+	"github.com/kuadrant/authorino/pkg/vault"
+
+	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"github.com/golang-jwt/jwt"
+	gomock "github.com/golang/mock/gomock"
+	"gotest.tools/assert"
+)
+
+// newFakeVaultTransit serves the endpoints of a transit key of vault whose versions are the given keys
+func newFakeVaultTransit(t *testing.T, keys ...*ecdsa.PrivateKey) *vault.Client {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/auth/kubernetes/login", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"auth":{"client_token":"s.token","lease_duration":3600}}`))
+	})
+	mux.HandleFunc("/v1/transit/keys/jwt-signing", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"latest_version": len(keys)}})
+	})
+	mux.HandleFunc("/v1/transit/verify/jwt-signing/sha2-256", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		input, _ := base64.StdEncoding.DecodeString(body["input"])
+		digest := sha256.Sum256(input)
+
+		valid := false
+		var version int
+		var signature string
+		if parts := strings.SplitN(body["signature"], ":", 3); len(parts) == 3 && body["marshaling_algorithm"] == "jws" {
+			_, _ = fmt.Sscanf(parts[1], "v%d", &version)
+			signature = parts[2]
+		}
+		if sig, err := base64.RawURLEncoding.DecodeString(signature); err == nil && len(sig) == 64 && version > 0 && version <= len(keys) {
+			sigR, sigS := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+			valid = ecdsa.Verify(&keys[version-1].PublicKey, digest[:], sigR, sigS)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"valid": valid}})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	assert.NilError(t, os.WriteFile(tokenFile, []byte("sa-token"), 0600))
+	return vault.NewClient(server.URL, "", "authorino", tokenFile)
+}
+
+func issueVaultTransitTestToken(key *ecdsa.PrivateKey, kid string, claims jwt.MapClaims) string {
+	defaultClaims := jwt.MapClaims{
+		"iss": "https://sts.example.com",
+		"aud": "talker-api",
+		"sub": "service-a",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	for k, v := range claims {
+		defaultClaims[k] = v
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, defaultClaims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, _ := token.SignedString(key)
+	return signed
+}
+
+func callVaultTransit(ctrl *gomock.Controller, vaultTransit *VaultTransit, token string) (interface{}, error) {
+	pipeline := mock_auth.NewMockAuthPipeline(ctrl)
+	pipeline.EXPECT().GetHttp().Return(&envoy_auth.AttributeContext_HttpRequest{
+		Headers: map[string]string{"authorization": "Bearer " + token},
+	})
+	return vaultTransit.Call(pipeline, context.TODO())
+}
+
+func TestVaultTransit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	key1, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	key2, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	client := newFakeVaultTransit(t, key1, key2)
+
+	vaultTransit, err := NewVaultTransit(client, "", "jwt-signing", "https://sts.example.com", []string{"talker-api"}, &auth.AuthCredential{KeySelector: "Bearer", In: "authorization_header"})
+	assert.NilError(t, err)
+
+	// latest version
+	obj, err := callVaultTransit(ctrl, vaultTransit, issueVaultTransitTestToken(key2, "", nil))
+	assert.NilError(t, err)
+	assert.Equal(t, obj.(map[string]interface{})["sub"], "service-a")
+
+	// version told by the kid
+	_, err = callVaultTransit(ctrl, vaultTransit, issueVaultTransitTestToken(key1, "jwt-signing:v1", nil))
+	assert.NilError(t, err)
+
+	_, err = callVaultTransit(ctrl, vaultTransit, issueVaultTransitTestToken(key1, "2", nil))
+	assert.ErrorContains(t, err, "invalid token: failed to verify signature: invalid signature")
+
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, err = callVaultTransit(ctrl, vaultTransit, issueVaultTransitTestToken(otherKey, "", nil))
+	assert.ErrorContains(t, err, "invalid token: failed to verify signature: invalid signature")
+
+	_, err = callVaultTransit(ctrl, vaultTransit, issueVaultTransitTestToken(key2, "", jwt.MapClaims{"aud": "other"}))
+	assert.Error(t, err, "invalid token: audience mismatch")
+
+	_, err = callVaultTransit(ctrl, vaultTransit, issueVaultTransitTestToken(key2, "", jwt.MapClaims{"iss": "https://evil.example.com"}))
+	assert.ErrorContains(t, err, "invalid token: oidc: id token issued by a different provider")
+
+	_, err = callVaultTransit(ctrl, vaultTransit, issueVaultTransitTestToken(key2, "", jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()}))
+	assert.ErrorContains(t, err, "invalid token: oidc: token is expired")
+}
+
+func TestVaultTransitNotConfigured(t *testing.T) {
+	_, err := NewVaultTransit(nil, "", "jwt-signing", "", nil, &auth.AuthCredential{KeySelector: "Bearer", In: "authorization_header"})
+	assert.Error(t, err, "failed to verify tokens with vault transit key jwt-signing: vault is not configured")
+}
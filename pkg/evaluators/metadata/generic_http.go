@@ -15,9 +15,7 @@ import (
 	"github.com/kuadrant/authorino/pkg/json"
 	"github.com/kuadrant/authorino/pkg/log"
 	"github.com/kuadrant/authorino/pkg/oauth2"
-
-	"go.opentelemetry.io/otel"
-	otel_propagation "go.opentelemetry.io/otel/propagation"
+	"github.com/kuadrant/authorino/pkg/trace"
 )
 
 type GenericHttp struct {
@@ -46,7 +44,7 @@ func (h *GenericHttp) Call(pipeline auth.AuthPipeline, ctx gocontext.Context) (i
 		return nil, err
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := trace.HTTPClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -131,7 +129,6 @@ func (h *GenericHttp) buildRequest(ctx gocontext.Context, endpoint, authJSON str
 	}
 
 	req.Header.Set("Content-Type", contentType)
-	otel.GetTextMapPropagator().Inject(ctx, otel_propagation.HeaderCarrier(req.Header))
 
 	if logger := log.FromContext(ctx).WithName("http").V(1); logger.Enabled() {
 		logData := []interface{}{
@@ -13,9 +13,7 @@ import (
 	"github.com/kuadrant/authorino/pkg/context"
 	"github.com/kuadrant/authorino/pkg/json"
 	"github.com/kuadrant/authorino/pkg/log"
-
-	"go.opentelemetry.io/otel"
-	otel_propagation "go.opentelemetry.io/otel/propagation"
+	"github.com/kuadrant/authorino/pkg/trace"
 )
 
 type providerJSON struct {
@@ -135,9 +133,8 @@ func (pat *PAT) Get(rawurl string, ctx gocontext.Context, v interface{}) error {
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+pat.String())
 
-	otel.GetTextMapPropagator().Inject(ctx, otel_propagation.HeaderCarrier(req.Header))
 	// get the response
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := trace.HTTPClient.Do(req)
 	if err != nil {
 		return err
 	}
@@ -172,7 +169,7 @@ func (uma *UMA) wellKnownConfigEndpoint() string {
 }
 
 func (uma *UMA) discover() error {
-	if resp, err := http.Get(uma.wellKnownConfigEndpoint()); err != nil {
+	if resp, err := trace.HTTPClient.Get(uma.wellKnownConfigEndpoint()); err != nil {
 		return fmt.Errorf("failed to fetch uma config: %v", err)
 	} else {
 		defer resp.Body.Close()
@@ -245,12 +242,12 @@ func (uma *UMA) requestPAT(ctx gocontext.Context, pat *PAT) error {
 
 	log.FromContext(ctx).V(1).Info("requesting pat", "url", tokenURL.String(), "data", encodedData, "headers", req.Header)
 
-	otel.GetTextMapPropagator().Inject(ctx, otel_propagation.HeaderCarrier(req.Header))
 	// get the response
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := trace.HTTPClient.Do(req)
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
 
 	// parse the pat
 	if err := json.UnmashalJSONResponse(resp, pat, nil); err != nil {
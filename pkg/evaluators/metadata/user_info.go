@@ -10,9 +10,7 @@ import (
 	"github.com/kuadrant/authorino/pkg/context"
 	"github.com/kuadrant/authorino/pkg/evaluators/identity"
 	"github.com/kuadrant/authorino/pkg/log"
-
-	"go.opentelemetry.io/otel"
-	otel_propagation "go.opentelemetry.io/otel/propagation"
+	"github.com/kuadrant/authorino/pkg/trace"
 )
 
 type UserInfo struct {
@@ -57,9 +55,7 @@ func fetchUserInfo(userInfoEndpoint string, accessToken string, ctx gocontext.Co
 		return nil, err
 	}
 
-	otel.GetTextMapPropagator().Inject(ctx, otel_propagation.HeaderCarrier(req.Header))
-
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := trace.HTTPClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,59 @@
+package evaluators
+
+import (
+	"sync"
+	"time"
+)
+
+const proofReplayCacheId = "proofs"
+
+func newProofReplayCache(ttl time.Duration, external *ExternalCacheBackend) *proofReplayCache {
+	return &proofReplayCache{
+		ttl:      ttl,
+		external: external,
+		proofs:   make(map[string]time.Time),
+		now:      time.Now,
+	}
+}
+
+// proofReplayCache remembers the proofs of possession already presented, in memory or, with the external cache backend
+// enabled, in the external store shared by the replicas.
+// Unlike the evaluator caches, proofs are recorded atomically (insert if absent) and never evicted before they expire,
+// however many are recorded, so a flood of proofs cannot reopen the window for replays.
+type proofReplayCache struct {
+	ttl      time.Duration
+	external *ExternalCacheBackend
+
+	proofs    map[string]time.Time // expiration by key
+	lastSweep time.Time
+	mu        sync.Mutex
+	now       func() time.Time
+}
+
+func (c *proofReplayCache) Remember(key string) (bool, error) {
+	if c.external != nil {
+		return c.external.setIfAbsent(proofReplayCacheId, key, []byte("true"), c.ttl)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+
+	// sweeps the expired proofs once per time to live, so the memory held is bounded by the proofs recorded within two
+	// times the time to live
+	if now.Sub(c.lastSweep) >= c.ttl {
+		for proof, expiration := range c.proofs {
+			if !now.Before(expiration) {
+				delete(c.proofs, proof)
+			}
+		}
+		c.lastSweep = now
+	}
+
+	if expiration, found := c.proofs[key]; found && now.Before(expiration) {
+		return false, nil
+	}
+	c.proofs[key] = now.Add(c.ttl)
+	return true, nil
+}
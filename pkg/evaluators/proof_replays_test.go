@@ -0,0 +1,76 @@
+package evaluators
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestProofReplayCache(t *testing.T) {
+	now := time.Unix(600, 0)
+	cache := newProofReplayCache(time.Minute, nil)
+	cache.now = func() time.Time { return now }
+
+	fresh, err := cache.Remember("proof-1")
+	assert.NilError(t, err)
+	assert.Check(t, fresh)
+
+	fresh, err = cache.Remember("proof-1")
+	assert.NilError(t, err)
+	assert.Check(t, !fresh)
+
+	// the proof is remembered for the whole time to live, however many other proofs are recorded
+	for i := 0; i < 10000; i++ {
+		_, _ = cache.Remember(time.Duration(i).String())
+	}
+	now = now.Add(59 * time.Second)
+	fresh, _ = cache.Remember("proof-1")
+	assert.Check(t, !fresh)
+
+	// expired proofs are swept
+	now = now.Add(2 * time.Minute)
+	fresh, _ = cache.Remember("proof-1")
+	assert.Check(t, fresh)
+	assert.Equal(t, len(cache.proofs), 1)
+}
+
+func TestProofReplayCacheConcurrentRequests(t *testing.T) {
+	cache := newProofReplayCache(time.Minute, nil)
+
+	var accepted int
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if fresh, _ := cache.Remember("proof"); fresh {
+				mu.Lock()
+				accepted++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, accepted, 1)
+}
+
+func TestProofReplayCacheSharedByReplicas(t *testing.T) {
+	store := newExternalCacheStoreMock()
+	replica1 := newProofReplayCache(time.Minute, NewExternalCacheBackend(store, ""))
+	replica2 := newProofReplayCache(time.Minute, NewExternalCacheBackend(store, ""))
+
+	fresh, err := replica1.Remember("proof")
+	assert.NilError(t, err)
+	assert.Check(t, fresh)
+
+	fresh, err = replica2.Remember("proof")
+	assert.NilError(t, err)
+	assert.Check(t, !fresh)
+
+	_, found := store.entries[externalCacheKey(proofReplayCacheId, "proof")]
+	assert.Check(t, found)
+}
@@ -2,7 +2,13 @@ package evaluators
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/kuadrant/authorino/pkg/auth"
 	"github.com/kuadrant/authorino/pkg/evaluators/response"
@@ -15,13 +21,35 @@ const (
 	responseWristband = "RESPONSE_WRISTBAND"
 	responseJSON      = "RESPONSE_JSON"
 	responsePlain     = "RESPONSE_PLAIN"
+	responseSignature = "RESPONSE_SIGNATURE"
 
 	HTTP_HEADER_WRAPPER            = "httpHeader"
 	ENVOY_DYNAMIC_METADATA_WRAPPER = "envoyDynamicMetadata"
+	HTTP_RESPONSE_HEADER_WRAPPER   = "httpResponseHeader"
+	SET_COOKIE_WRAPPER             = "setCookie"
+	QUERY_PARAMETER_WRAPPER        = "queryParameter"
+	GRPC_METADATA_WRAPPER          = "grpcMetadata"
 
 	DEFAULT_WRAPPER = HTTP_HEADER_WRAPPER
+
+	grpcBinaryMetadataSuffix = "-bin"
 )
 
+var grpcMetadataKeyRegexp = regexp.MustCompile(`^[0-9a-z_.-]+$`)
+
+// GrpcMetadataKey returns the name of a gRPC metadata key in lowercase, as required by the gRPC over HTTP/2 protocol.
+// Keys can only contain ASCII letters, digits, '-', '_' and '.', and cannot start with the reserved "grpc-" prefix.
+func GrpcMetadataKey(name string) (string, error) {
+	key := strings.ToLower(name)
+	if !grpcMetadataKeyRegexp.MatchString(key) {
+		return "", fmt.Errorf("invalid grpc metadata key: %s", name)
+	}
+	if strings.HasPrefix(key, "grpc-") {
+		return "", fmt.Errorf("reserved grpc metadata key: %s", name)
+	}
+	return key, nil
+}
+
 func NewResponseConfig(name string, priority int, conditions jsonexp.Expression, wrapper string, wrapperKey string, metricsEnabled bool) *ResponseConfig {
 	responseConfig := ResponseConfig{
 		Name:       name,
@@ -44,17 +72,22 @@ func NewResponseConfig(name string, priority int, conditions jsonexp.Expression,
 }
 
 type ResponseConfig struct {
-	Name       string             `yaml:"name"`
-	Priority   int                `yaml:"priority"`
-	Conditions jsonexp.Expression `yaml:"conditions"`
-	Wrapper    string             `yaml:"wrapper"`
-	WrapperKey string             `yaml:"wrapperKey"`
-	Metrics    bool               `yaml:"metrics"`
-	Cache      EvaluatorCache
-
-	Wristband   auth.WristbandIssuer  `yaml:"wristband,omitempty"`
-	DynamicJSON *response.DynamicJSON `yaml:"json,omitempty"`
-	Plain       *response.Plain       `yaml:"plain,omitempty"`
+	Name        string                    `yaml:"name"`
+	Priority    int                       `yaml:"priority"`
+	Conditions  jsonexp.Expression        `yaml:"conditions"`
+	Wrapper     string                    `yaml:"wrapper"`
+	WrapperKey  string                    `yaml:"wrapperKey"`
+	Metrics     bool                      `yaml:"metrics"`
+	Cookie      *CookieAttributes         `yaml:"cookie,omitempty"`
+	Encryption  *response.JWEEncryption   `yaml:"encryption,omitempty"`
+	Compression *response.GzipCompression `yaml:"compression,omitempty"`
+	Append      bool                      `yaml:"append"`
+	Cache       EvaluatorCache
+
+	Wristband   auth.WristbandIssuer       `yaml:"wristband,omitempty"`
+	DynamicJSON *response.DynamicJSON      `yaml:"json,omitempty"`
+	Plain       *response.Plain            `yaml:"plain,omitempty"`
+	Signature   *response.RequestSignature `yaml:"signature,omitempty"`
 }
 
 func (config *ResponseConfig) GetAuthConfigEvaluator() auth.AuthConfigEvaluator {
@@ -65,6 +98,8 @@ func (config *ResponseConfig) GetAuthConfigEvaluator() auth.AuthConfigEvaluator
 		return config.DynamicJSON
 	case responsePlain:
 		return config.Plain
+	case responseSignature:
+		return config.Signature
 	default:
 		return nil
 	}
@@ -118,6 +153,8 @@ func (config *ResponseConfig) GetType() string {
 		return responseJSON
 	case config.Plain != nil:
 		return responsePlain
+	case config.Signature != nil:
+		return responseSignature
 	default:
 		return ""
 	}
@@ -157,18 +194,138 @@ func (config *ResponseConfig) WrapObjectAsHeaderValue(obj any) string {
 	}
 }
 
-func WrapResponses(responses map[*ResponseConfig]interface{}) (responseHeaders map[string]string, responseMetadata map[string]interface{}) {
+// wrapObjectAsEncryptedHeaderValue wraps the object as a header value, compressed if the response config sets
+// compression and then encrypted as a JWE if the response config sets encryption. Headers whose value fail to be
+// encrypted must be dropped, so the plain value is never leaked.
+func (config *ResponseConfig) wrapObjectAsEncryptedHeaderValue(obj any) (string, error) {
+	value := config.WrapObjectAsHeaderValue(obj)
+	if config.Compression != nil {
+		var err error
+		if value, err = config.Compression.Compress(value); err != nil {
+			return "", err
+		}
+	}
+	if config.Encryption == nil {
+		return value, nil
+	}
+	return config.Encryption.Encrypt(value)
+}
+
+// wrapObjectAsGrpcMetadataValue wraps the object as the value of a gRPC metadata entry. Values of binary metadata keys
+// (suffixed with "-bin") are base64-encoded (unpadded), as required by the gRPC over HTTP/2 protocol.
+func (config *ResponseConfig) wrapObjectAsGrpcMetadataValue(obj any) (string, error) {
+	value, err := config.wrapObjectAsEncryptedHeaderValue(obj)
+	if err != nil {
+		return "", err
+	}
+	if strings.HasSuffix(config.WrapperKey, grpcBinaryMetadataSuffix) {
+		return base64.RawStdEncoding.EncodeToString([]byte(value)), nil
+	}
+	return value, nil
+}
+
+// CookieAttributes are the attributes of the cookies set in the response to the client when the response config is
+// wrapped as "setCookie"
+type CookieAttributes struct {
+	Path     string `yaml:"path,omitempty"`
+	Domain   string `yaml:"domain,omitempty"`
+	MaxAge   int    `yaml:"maxAge,omitempty"`
+	Secure   bool   `yaml:"secure,omitempty"`
+	HttpOnly bool   `yaml:"httpOnly,omitempty"`
+	SameSite string `yaml:"sameSite,omitempty"`
+}
+
+// SetCookieHeaderValue builds the value of a `Set-Cookie` header, with the value of the cookie URL-encoded
+func (attrs *CookieAttributes) SetCookieHeaderValue(name, value string) string {
+	cookie := &http.Cookie{
+		Name:  name,
+		Value: url.QueryEscape(value),
+	}
+
+	if attrs != nil {
+		cookie.Path = attrs.Path
+		cookie.Domain = attrs.Domain
+		cookie.MaxAge = attrs.MaxAge
+		cookie.Secure = attrs.Secure
+		cookie.HttpOnly = attrs.HttpOnly
+
+		switch attrs.SameSite {
+		case "Strict":
+			cookie.SameSite = http.SameSiteStrictMode
+		case "Lax":
+			cookie.SameSite = http.SameSiteLaxMode
+		case "None":
+			cookie.SameSite = http.SameSiteNoneMode
+		}
+	}
+
+	return cookie.String()
+}
+
+func WrapResponses(responses map[*ResponseConfig]interface{}) (responseHeaders map[string]string, responseMetadata map[string]interface{}, downstreamHeaders []map[string]string) {
 	responseHeaders = make(map[string]string)
 	responseMetadata = make(map[string]interface{})
+	downstreamResponseHeaders := make(map[string]string)
+	responseCookies := make([]string, 0)
 
 	for responseConfig, authObj := range responses {
 		switch responseConfig.Wrapper {
 		case HTTP_HEADER_WRAPPER:
-			responseHeaders[responseConfig.WrapperKey] = responseConfig.WrapObjectAsHeaderValue(authObj)
+			if value, err := responseConfig.wrapObjectAsEncryptedHeaderValue(authObj); err == nil {
+				responseHeaders[responseConfig.WrapperKey] = value
+			}
+		case GRPC_METADATA_WRAPPER:
+			if value, err := responseConfig.wrapObjectAsGrpcMetadataValue(authObj); err == nil {
+				responseHeaders[responseConfig.WrapperKey] = value
+			}
 		case ENVOY_DYNAMIC_METADATA_WRAPPER:
 			responseMetadata[responseConfig.WrapperKey] = authObj
+		case HTTP_RESPONSE_HEADER_WRAPPER:
+			if value, err := responseConfig.wrapObjectAsEncryptedHeaderValue(authObj); err == nil {
+				downstreamResponseHeaders[responseConfig.WrapperKey] = value
+			}
+		case SET_COOKIE_WRAPPER:
+			if cookie := responseConfig.Cookie.SetCookieHeaderValue(responseConfig.WrapperKey, responseConfig.WrapObjectAsHeaderValue(authObj)); cookie != "" {
+				responseCookies = append(responseCookies, cookie)
+			}
 		}
 	}
 
-	return responseHeaders, responseMetadata
+	downstreamHeaders = make([]map[string]string, 0, len(responseCookies)+1)
+	if len(downstreamResponseHeaders) > 0 {
+		downstreamHeaders = append(downstreamHeaders, downstreamResponseHeaders)
+	}
+
+	// one entry per cookie, so multiple Set-Cookie headers can be added to the response
+	sort.Strings(responseCookies)
+	for _, cookie := range responseCookies {
+		downstreamHeaders = append(downstreamHeaders, map[string]string{"Set-Cookie": cookie})
+	}
+
+	return responseHeaders, responseMetadata, downstreamHeaders
+}
+
+// GetHeadersToAppend returns the sorted names of the headers of a given wrapper ("httpHeader" or "httpResponseHeader")
+// whose values must be appended to the existing values of the headers, instead of replacing them
+func GetHeadersToAppend(responses map[*ResponseConfig]interface{}, wrapper string) []string {
+	headers := make([]string, 0)
+	for responseConfig := range responses {
+		if responseConfig.Append && responseConfig.Wrapper == wrapper {
+			headers = append(headers, responseConfig.WrapperKey)
+		}
+	}
+	sort.Strings(headers)
+	return headers
+}
+
+// GetQueryParametersToSet returns the query parameters to set in the request forwarded upstream, out of the responses
+// wrapped as "queryParameter"
+func GetQueryParametersToSet(responses map[*ResponseConfig]interface{}) map[string]string {
+	queryParameters := make(map[string]string)
+	for responseConfig, authObj := range responses {
+		if responseConfig.Wrapper == QUERY_PARAMETER_WRAPPER {
+			queryParameters[responseConfig.WrapperKey] = responseConfig.WrapObjectAsHeaderValue(authObj)
+		}
+	}
+	return queryParameters
 }
@@ -0,0 +1,41 @@
+package response
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+)
+
+const DEFAULT_GZIP_COMPRESSION_THRESHOLD = 1024 // bytes
+
+// NewGzipCompression builds a compressor of values with gzip, encoded in base64 (standard encoding, with padding).
+// Only values of size equal or greater than the threshold (in bytes) are compressed. If the threshold is not positive,
+// it defaults to 1024 bytes.
+func NewGzipCompression(threshold int) *GzipCompression {
+	if threshold <= 0 {
+		threshold = DEFAULT_GZIP_COMPRESSION_THRESHOLD
+	}
+	return &GzipCompression{Threshold: threshold}
+}
+
+type GzipCompression struct {
+	Threshold int
+}
+
+// Compress returns the value compressed with gzip and encoded in base64, or the value unchanged if smaller than the threshold
+func (c *GzipCompression) Compress(value string) (string, error) {
+	if len(value) < c.Threshold {
+		return value, nil
+	}
+
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write([]byte(value)); err != nil {
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
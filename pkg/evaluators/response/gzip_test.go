@@ -0,0 +1,37 @@
+package response
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestGzipCompression(t *testing.T) {
+	compression := NewGzipCompression(0)
+	assert.Equal(t, compression.Threshold, DEFAULT_GZIP_COMPRESSION_THRESHOLD)
+
+	compression = NewGzipCompression(64)
+
+	value, err := compression.Compress(`{"sub":"john"}`)
+	assert.NilError(t, err)
+	assert.Equal(t, value, `{"sub":"john"}`)
+
+	large := `{"groups":["` + strings.Repeat("group,", 100) + `"]}`
+	value, err = compression.Compress(large)
+	assert.NilError(t, err)
+	assert.Check(t, len(value) < len(large))
+	assert.Check(t, strings.HasPrefix(value, "H4sI")) // gzip magic number, base64-encoded
+
+	compressed, err := base64.StdEncoding.DecodeString(value)
+	assert.NilError(t, err)
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	assert.NilError(t, err)
+	decompressed, err := io.ReadAll(reader)
+	assert.NilError(t, err)
+	assert.Equal(t, string(decompressed), large)
+}
@@ -0,0 +1,105 @@
+package response
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+const (
+	DEFAULT_JWE_RSA_KEY_ALGORITHM  = string(jose.RSA_OAEP_256)
+	DEFAULT_JWE_EC_KEY_ALGORITHM   = string(jose.ECDH_ES_A256KW)
+	DEFAULT_JWE_CONTENT_ENCRYPTION = string(jose.A256GCM)
+)
+
+// NewJWEEncryption builds an encrypter of values as compact JSON Web Encryption (JWE) tokens to a recipient.
+// The public key of the recipient must be formatted as PEM (public key or X.509 certificate). The key management
+// algorithm defaults to RSA-OAEP-256 for RSA keys and ECDH-ES+A256KW for EC keys; the content encryption, to A256GCM.
+func NewJWEEncryption(keyID string, recipientKey []byte, algorithm, contentEncryption string) (*JWEEncryption, error) {
+	keyPEM, _ := pem.Decode(recipientKey)
+	if keyPEM == nil {
+		return nil, fmt.Errorf("failed to decode PEM file")
+	}
+
+	var publicKey interface{}
+	switch keyPEM.Type {
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(keyPEM.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		publicKey = cert.PublicKey
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(keyPEM.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		publicKey = key
+	case "RSA PUBLIC KEY":
+		key, err := x509.ParsePKCS1PublicKey(keyPEM.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		publicKey = key
+	default:
+		return nil, fmt.Errorf("invalid recipient key: %s", keyPEM.Type)
+	}
+
+	switch publicKey.(type) {
+	case *rsa.PublicKey:
+		if algorithm == "" {
+			algorithm = DEFAULT_JWE_RSA_KEY_ALGORITHM
+		}
+	case *ecdsa.PublicKey:
+		if algorithm == "" {
+			algorithm = DEFAULT_JWE_EC_KEY_ALGORITHM
+		}
+	default:
+		return nil, fmt.Errorf("unsupported type of recipient key")
+	}
+
+	if contentEncryption == "" {
+		contentEncryption = DEFAULT_JWE_CONTENT_ENCRYPTION
+	}
+
+	encrypter, err := jose.NewEncrypter(
+		jose.ContentEncryption(contentEncryption),
+		jose.Recipient{
+			Algorithm: jose.KeyAlgorithm(algorithm),
+			Key:       publicKey,
+			KeyID:     keyID,
+		},
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &JWEEncryption{
+		KeyID:             keyID,
+		Algorithm:         algorithm,
+		ContentEncryption: contentEncryption,
+		encrypter:         encrypter,
+	}, nil
+}
+
+type JWEEncryption struct {
+	KeyID             string
+	Algorithm         string
+	ContentEncryption string
+
+	encrypter jose.Encrypter
+}
+
+// Encrypt returns the value encrypted as a compact JWE
+func (e *JWEEncryption) Encrypt(value string) (string, error) {
+	jwe, err := e.encrypter.Encrypt([]byte(value))
+	if err != nil {
+		return "", err
+	}
+	return jwe.CompactSerialize()
+}
@@ -0,0 +1,58 @@
+package response
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	jose "gopkg.in/square/go-jose.v2"
+	"gotest.tools/assert"
+)
+
+func TestJWEEncryption(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	testCases := []struct {
+		name       string
+		privateKey interface{}
+		publicKey  interface{}
+		algorithm  string
+	}{
+		{"rsa", rsaKey, &rsaKey.PublicKey, "RSA-OAEP-256"},
+		{"ec", ecKey, &ecKey.PublicKey, "ECDH-ES+A256KW"},
+	}
+
+	for _, tc := range testCases {
+		der, err := x509.MarshalPKIXPublicKey(tc.publicKey)
+		assert.NilError(t, err)
+		publicKeyPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+		encryption, err := NewJWEEncryption("upstream-key", publicKeyPEM, "", "")
+		assert.NilError(t, err, tc.name)
+		assert.Equal(t, encryption.Algorithm, tc.algorithm, tc.name)
+		assert.Equal(t, encryption.ContentEncryption, "A256GCM", tc.name)
+
+		encrypted, err := encryption.Encrypt(`{"email":"john@example.com"}`)
+		assert.NilError(t, err, tc.name)
+
+		jwe, err := jose.ParseEncrypted(encrypted)
+		assert.NilError(t, err, tc.name)
+		assert.Equal(t, jwe.Header.KeyID, "upstream-key", tc.name)
+		decrypted, err := jwe.Decrypt(tc.privateKey)
+		assert.NilError(t, err, tc.name)
+		assert.Equal(t, string(decrypted), `{"email":"john@example.com"}`, tc.name)
+	}
+}
+
+func TestJWEEncryptionInvalidKey(t *testing.T) {
+	_, err := NewJWEEncryption("upstream-key", []byte("not a pem"), "", "")
+	assert.Error(t, err, "failed to decode PEM file")
+
+	_, err = NewJWEEncryption("upstream-key", []byte(rsaSigningKey), "", "")
+	assert.Error(t, err, "invalid recipient key: RSA PRIVATE KEY")
+}
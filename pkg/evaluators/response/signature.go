@@ -0,0 +1,84 @@
+package response
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/auth"
+	"github.com/kuadrant/authorino/pkg/json"
+
+	"github.com/golang-jwt/jwt"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+const DEFAULT_SIGNATURE_ALGORITHM = "HS256"
+
+var defaultSignedAttributes = []string{
+	"context.request.http.method",
+	"context.request.http.host",
+	"context.request.http.path",
+}
+
+// NewRequestSignature builds a response evaluator that signs attributes of the request, so the upstream service can
+// verify the request was authorized by Authorino.
+// The signed content is the timestamp of the signature (Unix time) followed by the values of the attributes, in order,
+// separated by line feeds. If no attributes are specified, the method, host and path of the request are signed.
+func NewRequestSignature(signingKey *jose.JSONWebKey, attributes []json.JSONValue) (*RequestSignature, error) {
+	if signingKey == nil {
+		return nil, fmt.Errorf("missing signing key")
+	}
+
+	method := jwt.GetSigningMethod(signingKey.Algorithm)
+	if method == nil {
+		return nil, fmt.Errorf("unsupported signing algorithm: %s", signingKey.Algorithm)
+	}
+
+	if len(attributes) == 0 {
+		for _, selector := range defaultSignedAttributes {
+			attributes = append(attributes, json.JSONValue{Pattern: selector})
+		}
+	}
+
+	return &RequestSignature{
+		KeyID:      signingKey.KeyID,
+		Algorithm:  signingKey.Algorithm,
+		Attributes: attributes,
+		method:     method,
+		key:        signingKey.Key,
+	}, nil
+}
+
+type RequestSignature struct {
+	KeyID      string
+	Algorithm  string
+	Attributes []json.JSONValue
+
+	method jwt.SigningMethod
+	key    interface{}
+}
+
+// Call returns the signature formatted as `keyid="…",algorithm="…",created=…,signature="…"`, where the signature is
+// base64url-encoded (without padding)
+func (s *RequestSignature) Call(pipeline auth.AuthPipeline, _ context.Context) (interface{}, error) {
+	created := strconv.FormatInt(time.Now().Unix(), 10)
+
+	signature, err := s.method.Sign(s.SigningString(pipeline.GetAuthorizationJSON(), created), s.key)
+	if err != nil {
+		return nil, err
+	}
+
+	return fmt.Sprintf(`keyid="%s",algorithm="%s",created=%s,signature="%s"`, s.KeyID, s.Algorithm, created, signature), nil
+}
+
+// SigningString returns the content to sign out of the authorization JSON
+func (s *RequestSignature) SigningString(authJSON, created string) string {
+	values := []string{created}
+	for _, attribute := range s.Attributes {
+		value, _ := json.StringifyJSON(attribute.ResolveFor(authJSON))
+		values = append(values, value)
+	}
+	return strings.Join(values, "\n")
+}
@@ -0,0 +1,71 @@
+package response
+
+import (
+	"context"
+	"crypto/ed25519"
+	"regexp"
+	"testing"
+
+	mock_auth "github.com/kuadrant/authorino/pkg/auth/mocks"
+	"github.com/kuadrant/authorino/pkg/json"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/golang/mock/gomock"
+	"gotest.tools/assert"
+)
+
+const signatureAuthJSON = `{"context":{"request":{"http":{"method":"GET","host":"talker-api","path":"/hello"}}},"auth":{"identity":{"sub":"john"}}}`
+
+var signatureRegexp = regexp.MustCompile(`^keyid="([^"]+)",algorithm="([^"]+)",created=(\d+),signature="([^"]+)"$`)
+
+func TestRequestSignatureHMAC(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	key, err := NewSigningKey("upstream-secret", "HS256", []byte("s3cr3t"))
+	assert.NilError(t, err)
+	signature, err := NewRequestSignature(key, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, len(signature.Attributes), 3)
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(signatureAuthJSON)
+
+	obj, err := signature.Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+
+	parts := signatureRegexp.FindStringSubmatch(obj.(string))
+	assert.Equal(t, len(parts), 5)
+	assert.Equal(t, parts[1], "upstream-secret")
+	assert.Equal(t, parts[2], "HS256")
+	signingString := parts[3] + "\nGET\ntalker-api\n/hello"
+	assert.Equal(t, signature.SigningString(signatureAuthJSON, parts[3]), signingString)
+	assert.NilError(t, jwt.SigningMethodHS256.Verify(signingString, parts[4], []byte("s3cr3t")))
+}
+
+func TestRequestSignatureEdDSA(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	key, err := NewSigningKey("upstream-key", "EdDSA", []byte(ed25519SigningKey))
+	assert.NilError(t, err)
+	signature, err := NewRequestSignature(key, []json.JSONValue{{Pattern: "context.request.http.path"}, {Pattern: "auth.identity.sub"}})
+	assert.NilError(t, err)
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(signatureAuthJSON)
+
+	obj, err := signature.Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+
+	parts := signatureRegexp.FindStringSubmatch(obj.(string))
+	assert.Equal(t, len(parts), 5)
+	assert.Equal(t, parts[2], "EdDSA")
+	publicKey := key.Key.(ed25519.PrivateKey).Public()
+	assert.NilError(t, jwt.SigningMethodEdDSA.Verify(parts[3]+"\n/hello\njohn", parts[4], publicKey))
+}
+
+func TestRequestSignatureInvalidSettings(t *testing.T) {
+	_, err := NewRequestSignature(nil, nil)
+	assert.Error(t, err, "missing signing key")
+}
@@ -2,7 +2,11 @@ package response
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
 	"crypto/sha256"
+	"crypto/x509"
 	gojson "encoding/json"
 	"encoding/pem"
 	"fmt"
@@ -19,6 +23,15 @@ import (
 
 const DEFAULT_WRISTBAND_DURATION = int64(300)
 
+// SigningKeySecretKey returns the name of the entry of the Kubernetes secret that stores the signing key for a given
+// algorithm: `key` (shared secret) for HMAC algorithms; `key.pem` (private key formatted as PEM) otherwise
+func SigningKeySecretKey(algorithm string) string {
+	if isHMACAlgorithm(algorithm) {
+		return "key"
+	}
+	return "key.pem"
+}
+
 func NewSigningKey(name string, algorithm string, singingKey []byte) (*jose.JSONWebKey, error) {
 	signingKey := &jose.JSONWebKey{
 		KeyID:     name,
@@ -26,6 +39,19 @@ func NewSigningKey(name string, algorithm string, singingKey []byte) (*jose.JSON
 		Use:       "sig",
 	}
 
+	if jwt.GetSigningMethod(algorithm) == nil {
+		return nil, fmt.Errorf("unsupported signing algorithm: %s", algorithm)
+	}
+
+	// shared secret
+	if isHMACAlgorithm(algorithm) {
+		if len(singingKey) == 0 {
+			return nil, fmt.Errorf("missing shared secret")
+		}
+		signingKey.Key = singingKey
+		return signingKey, nil
+	}
+
 	keyPEM, _ := pem.Decode(singingKey)
 
 	if keyPEM == nil {
@@ -47,13 +73,38 @@ func NewSigningKey(name string, algorithm string, singingKey []byte) (*jose.JSON
 			signingKey.Key = key
 		}
 
+	case "PRIVATE": // PKCS #8
+		if key, err := x509.ParsePKCS8PrivateKey(keyPEM.Bytes); err != nil {
+			return nil, err
+		} else {
+			signingKey.Key = key
+		}
+
 	default:
 		return nil, fmt.Errorf("invalid signing key algorithm")
 	}
 
+	// the type of key must match the algorithm
+	var validKey bool
+	switch signingKey.Key.(type) {
+	case *ecdsa.PrivateKey:
+		validKey = strings.HasPrefix(algorithm, "ES")
+	case *rsa.PrivateKey:
+		validKey = strings.HasPrefix(algorithm, "RS") || strings.HasPrefix(algorithm, "PS")
+	case ed25519.PrivateKey:
+		validKey = algorithm == "EdDSA"
+	}
+	if !validKey {
+		return nil, fmt.Errorf("invalid signing key for algorithm %s", algorithm)
+	}
+
 	return signingKey, nil
 }
 
+func isHMACAlgorithm(algorithm string) bool {
+	return strings.HasPrefix(algorithm, "HS")
+}
+
 type Claims map[string]interface{}
 
 func (c *Claims) Valid() error {
@@ -87,6 +138,8 @@ type Wristband struct {
 	CustomClaims  []json.JSONProperty
 	TokenDuration int64
 	SigningKeys   []jose.JSONWebKey
+	// Interval of rotation of the active signing key, in seconds (0 = no rotation)
+	SigningKeyRotationInterval int64
 }
 
 func (w *Wristband) Call(pipeline auth.AuthPipeline, ctx context.Context) (interface{}, error) {
@@ -103,15 +156,8 @@ func (w *Wristband) Call(pipeline auth.AuthPipeline, ctx context.Context) (inter
 	hash.Write(idStr)
 	sub := fmt.Sprintf("%x", hash.Sum(nil))
 
-	// timestamps
-	iat := time.Now().Unix()
-	exp := iat + int64(w.TokenDuration)
-
 	// claims
 	claims := Claims{
-		"iss": w.GetIssuer(),
-		"iat": iat,
-		"exp": exp,
 		"sub": sub,
 	}
 
@@ -124,23 +170,103 @@ func (w *Wristband) Call(pipeline auth.AuthPipeline, ctx context.Context) (inter
 		}
 	}
 
+	if wristband, err := w.sign(ctx, claims); err != nil {
+		return nil, err
+	} else {
+		return wristband, nil
+	}
+}
+
+// Sign issues a wristband with the given claims, adding the registered claims iss, iat and exp if not set
+func (w *Wristband) Sign(claims Claims) (string, error) {
+	return w.sign(context.TODO(), claims)
+}
+
+func (w *Wristband) sign(ctx context.Context, claims Claims) (string, error) {
+	// timestamps
+	iat := time.Now().Unix()
+
+	registeredClaims := Claims{
+		"iss": w.GetIssuer(),
+		"iat": iat,
+		"exp": iat + int64(w.TokenDuration),
+	}
+	for name, value := range registeredClaims {
+		if _, set := claims[name]; !set {
+			claims[name] = value
+		}
+	}
+
 	// signing key
-	signingKey := w.SigningKeys[0]
+	signingKey := w.activeSigningKey(time.Unix(iat, 0))
+
+	// signed by a key management service
+	if kmsKey, ok := signingKey.Key.(*KMSSigningKey); ok {
+		return kmsKey.SignJWT(ctx, map[string]interface{}{"typ": "JWT"}, claims, time.Unix(iat+w.TokenDuration, 0))
+	}
 
 	token := jwt.NewWithClaims(jwt.GetSigningMethod(signingKey.Algorithm), &claims)
 	token.Header["kid"] = signingKey.KeyID
 
-	if wristband, err := token.SignedString(signingKey.Key); err != nil {
+	return token.SignedString(signingKey.Key)
+}
+
+// Verify checks the signature, the issuer and the expiration of a wristband issued with the signing keys of the
+// config, and returns its claims
+func (w *Wristband) Verify(wristband string) (Claims, error) {
+	claims := Claims{}
+	_, err := jwt.ParseWithClaims(wristband, &claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		for _, signingKey := range w.SigningKeys {
+			if signingKey.Algorithm != token.Method.Alg() {
+				continue
+			}
+			if kmsKey, ok := signingKey.Key.(*KMSSigningKey); ok {
+				if publicKey, found := kmsKey.PublicKey(kid); found {
+					return publicKey, nil
+				}
+				continue
+			}
+			if signingKey.KeyID != kid {
+				continue
+			}
+			if isHMACAlgorithm(signingKey.Algorithm) {
+				return signingKey.Key, nil
+			}
+			return signingKey.Public().Key, nil
+		}
+		return nil, fmt.Errorf("unknown signing key: %s", kid)
+	})
+	if err != nil {
 		return nil, err
-	} else {
-		return wristband, nil
 	}
+
+	if iss, _ := claims["iss"].(string); iss != w.GetIssuer() {
+		return nil, fmt.Errorf("invalid issuer: %s", iss)
+	}
+	if exp, _ := claims["exp"].(float64); int64(exp) < time.Now().Unix() {
+		return nil, fmt.Errorf("wristband expired")
+	}
+
+	return claims, nil
+}
+
+// activeSigningKey returns the key to sign the wristbands issued at a given time.
+// The rotation is based on the unix time, so all instances of Authorino agree on the active key regardless of when
+// the config was loaded.
+func (w *Wristband) activeSigningKey(at time.Time) jose.JSONWebKey {
+	if w.SigningKeyRotationInterval <= 0 || len(w.SigningKeys) == 1 {
+		return w.SigningKeys[0]
+	}
+	slot := at.Unix() / w.SigningKeyRotationInterval
+	return w.SigningKeys[slot%int64(len(w.SigningKeys))]
 }
 
 type oidcConfig struct {
-	Issuer               string   `json:"issuer"`
-	JWKSURI              string   `json:"jwks_uri"`
-	SupportedSigningAlgs []string `json:"id_token_signing_alg_values_supported"`
+	Issuer                string   `json:"issuer"`
+	JWKSURI               string   `json:"jwks_uri"`
+	IntrospectionEndpoint string   `json:"introspection_endpoint"`
+	SupportedSigningAlgs  []string `json:"id_token_signing_alg_values_supported"`
 }
 
 func (w *Wristband) GetIssuer() string {
@@ -150,9 +276,10 @@ func (w *Wristband) GetIssuer() string {
 func (w *Wristband) OpenIDConfig() (string, error) {
 	issuer := w.GetIssuer()
 	config := &oidcConfig{
-		Issuer:               issuer,
-		JWKSURI:              fmt.Sprintf("%v/.well-known/openid-connect/certs", issuer),
-		SupportedSigningAlgs: []string{"ES256", "ES384", "ES512", "RS256", "RS384", "RS512"},
+		Issuer:                issuer,
+		JWKSURI:               fmt.Sprintf("%v/.well-known/openid-connect/certs", issuer),
+		IntrospectionEndpoint: fmt.Sprintf("%v/introspect", issuer),
+		SupportedSigningAlgs:  []string{"ES256", "ES384", "ES512", "RS256", "RS384", "RS512", "EdDSA", "HS256", "HS384", "HS512"},
 	}
 
 	if configJSON, err := gojson.Marshal(config); err != nil {
@@ -166,6 +293,13 @@ func (w *Wristband) JWKS() (string, error) {
 	publicKeys := make([]jose.JSONWebKey, 0)
 
 	for _, signingKey := range w.SigningKeys {
+		if isHMACAlgorithm(signingKey.Algorithm) {
+			continue // shared secrets are never published
+		}
+		if kmsKey, ok := signingKey.Key.(*KMSSigningKey); ok {
+			publicKeys = append(publicKeys, kmsKey.PublicKeys()...)
+			continue
+		}
 		publicKeys = append(publicKeys, signingKey.Public())
 	}
 
@@ -179,3 +313,22 @@ func (w *Wristband) JWKS() (string, error) {
 		return string(encodedJWKS), nil
 	}
 }
+
+// Introspect responds the state of a wristband in the format of an OAuth 2.0 Token Introspection response (RFC 7662),
+// i.e. the claims of the wristband along with `"active":true` if the wristband was issued with the signing keys of the
+// config and has not expired; `{"active":false}` otherwise
+func (w *Wristband) Introspect(wristband string) (string, error) {
+	introspection := map[string]interface{}{"active": false}
+
+	if claims, err := w.Verify(wristband); err == nil {
+		introspection = claims
+		introspection["active"] = true
+		introspection["token_type"] = "Bearer"
+	}
+
+	if encodedIntrospection, err := gojson.Marshal(introspection); err != nil {
+		return "", err
+	} else {
+		return string(encodedIntrospection), nil
+	}
+}
@@ -0,0 +1,257 @@
+package response
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	gojson "encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+const (
+	// interval to check for new versions of the keys stored in the key management services
+	kmsKeyVersionRefreshInterval = 5 * time.Minute
+	// maximum number of signatures of each key cached
+	kmsSignatureCacheSize = 1024
+	kmsTimeout            = 10 * time.Second
+)
+
+// KMSSigner signs with a key stored in a cloud key management service, so the private key never leaves the service
+// (or its HSM)
+type KMSSigner interface {
+	// PublicKey returns the current version of the key and its public key
+	PublicKey(ctx context.Context) (version string, publicKey crypto.PublicKey, err error)
+	// Sign signs the digest of a message with a given version of the key, returning the signature in the format of the
+	// JWS algorithm (i.e. r||s for ECDSA)
+	Sign(ctx context.Context, version string, digest []byte) ([]byte, error)
+}
+
+// NewKMSSigningKey returns a signing key whose key is backed by a key management service.
+// The current version of the key is fetched right away, so misconfigured keys are caught early.
+func NewKMSSigningKey(ctx context.Context, name, algorithm string, signer KMSSigner) (*jose.JSONWebKey, error) {
+	if _, err := kmsHash(algorithm); err != nil {
+		return nil, err
+	}
+
+	key := &KMSSigningKey{
+		Name:       name,
+		Algorithm:  algorithm,
+		Signer:     signer,
+		versions:   make(map[string]crypto.PublicKey),
+		signatures: make(map[string]kmsSignature),
+	}
+	if _, err := key.currentVersion(ctx); err != nil {
+		return nil, err
+	}
+
+	return &jose.JSONWebKey{
+		KeyID:     name,
+		Algorithm: algorithm,
+		Use:       "sig",
+		Key:       key,
+	}, nil
+}
+
+// KMSSigningKey signs wristbands with a key stored in a key management service.
+// The key is checked for new versions periodically; the tokens are signed with the current version, identified by
+// the `kid` header (`<name>/<version>`), whereas the public keys of all versions seen are kept in the JWKS, so tokens
+// signed with previous versions remain verifiable until they expire.
+// Signatures are cached, so identical tokens (e.g. issued to the same identity within the same second) are signed
+// only once.
+type KMSSigningKey struct {
+	Name      string
+	Algorithm string
+	Signer    KMSSigner
+
+	versions    map[string]crypto.PublicKey
+	current     string
+	refreshedAt time.Time
+	signatures  map[string]kmsSignature
+	mutex       sync.Mutex
+}
+
+type kmsSignature struct {
+	signature []byte
+	expiresAt time.Time
+}
+
+func (k *KMSSigningKey) currentVersion(ctx context.Context) (string, error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	if k.refreshedAt.IsZero() || time.Since(k.refreshedAt) > kmsKeyVersionRefreshInterval {
+		version, publicKey, err := k.Signer.PublicKey(ctx)
+		if err != nil {
+			if k.refreshedAt.IsZero() {
+				return "", fmt.Errorf("failed to fetch the public key of the kms key %s: %v", k.Name, err)
+			}
+			// keeps signing with the last version known until the next check
+			k.refreshedAt = time.Now()
+			return k.current, nil
+		}
+		if !kmsKeyMatchesAlgorithm(publicKey, k.Algorithm) {
+			return "", fmt.Errorf("invalid kms key %s for algorithm %s", k.Name, k.Algorithm)
+		}
+		k.versions[version] = publicKey
+		k.current = version
+		k.refreshedAt = time.Now()
+	}
+
+	return k.current, nil
+}
+
+func (k *KMSSigningKey) keyID(version string) string {
+	if version == "" {
+		return k.Name
+	}
+	return k.Name + "/" + version
+}
+
+// SignJWT signs a JWT with the current version of the key
+func (k *KMSSigningKey) SignJWT(ctx context.Context, header map[string]interface{}, claims interface{}, expiresAt time.Time) (string, error) {
+	version, err := k.currentVersion(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	header["alg"] = k.Algorithm
+	header["kid"] = k.keyID(version)
+	encodedHeader, err := encodeJWTSegment(header)
+	if err != nil {
+		return "", err
+	}
+	encodedClaims, err := encodeJWTSegment(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := encodedHeader + "." + encodedClaims
+
+	signature, err := k.sign(ctx, version, signingInput, expiresAt)
+	if err != nil {
+		return "", err
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func (k *KMSSigningKey) sign(ctx context.Context, version, signingInput string, expiresAt time.Time) ([]byte, error) {
+	hash, _ := kmsHash(k.Algorithm)
+	hasher := hash.New()
+	hasher.Write([]byte(signingInput))
+	digest := hasher.Sum(nil)
+
+	cacheKey := version + ":" + hex.EncodeToString(digest)
+	if signature := k.cachedSignature(cacheKey); signature != nil {
+		return signature, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, kmsTimeout)
+	defer cancel()
+	signature, err := k.Signer.Sign(ctx, version, digest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign with the kms key %s: %v", k.Name, err)
+	}
+
+	k.cacheSignature(cacheKey, signature, expiresAt)
+	return signature, nil
+}
+
+func (k *KMSSigningKey) cachedSignature(key string) []byte {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	if entry, ok := k.signatures[key]; ok && time.Now().Before(entry.expiresAt) {
+		return entry.signature
+	}
+	return nil
+}
+
+func (k *KMSSigningKey) cacheSignature(key string, signature []byte, expiresAt time.Time) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	if len(k.signatures) >= kmsSignatureCacheSize {
+		now := time.Now()
+		for key, entry := range k.signatures {
+			if now.After(entry.expiresAt) {
+				delete(k.signatures, key)
+			}
+		}
+		if len(k.signatures) >= kmsSignatureCacheSize {
+			k.signatures = make(map[string]kmsSignature)
+		}
+	}
+	k.signatures[key] = kmsSignature{signature: signature, expiresAt: expiresAt}
+}
+
+// PublicKeys returns the public keys of all versions of the key seen
+func (k *KMSSigningKey) PublicKeys() []jose.JSONWebKey {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	keys := make([]jose.JSONWebKey, 0, len(k.versions))
+	for version, publicKey := range k.versions {
+		keys = append(keys, jose.JSONWebKey{
+			KeyID:     k.keyID(version),
+			Algorithm: k.Algorithm,
+			Use:       "sig",
+			Key:       publicKey,
+		})
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].KeyID < keys[j].KeyID })
+	return keys
+}
+
+// PublicKey returns the public key of the version of the key identified by a kid
+func (k *KMSSigningKey) PublicKey(kid string) (crypto.PublicKey, bool) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	for version, publicKey := range k.versions {
+		if k.keyID(version) == kid {
+			return publicKey, true
+		}
+	}
+	return nil, false
+}
+
+func kmsHash(algorithm string) (crypto.Hash, error) {
+	if !strings.HasPrefix(algorithm, "ES") && !strings.HasPrefix(algorithm, "RS") && !strings.HasPrefix(algorithm, "PS") {
+		return 0, fmt.Errorf("unsupported kms signing algorithm: %s", algorithm)
+	}
+	switch algorithm[2:] {
+	case "256":
+		return crypto.SHA256, nil
+	case "384":
+		return crypto.SHA384, nil
+	case "512":
+		return crypto.SHA512, nil
+	}
+	return 0, fmt.Errorf("unsupported kms signing algorithm: %s", algorithm)
+}
+
+func kmsKeyMatchesAlgorithm(publicKey crypto.PublicKey, algorithm string) bool {
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		return algorithm == map[int]string{256: "ES256", 384: "ES384", 521: "ES512"}[key.Curve.Params().BitSize]
+	default:
+		return !strings.HasPrefix(algorithm, "ES")
+	}
+}
+
+func encodeJWTSegment(v interface{}) (string, error) {
+	encoded, err := gojson.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(encoded), nil
+}
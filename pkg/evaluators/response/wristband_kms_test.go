@@ -0,0 +1,117 @@
+package response
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	jose "gopkg.in/square/go-jose.v2"
+	"gotest.tools/assert"
+)
+
+// fakeKMSSigner is a kms signer that keeps the versions of the key in memory
+type fakeKMSSigner struct {
+	keys    []*ecdsa.PrivateKey
+	signed  int
+	failing bool
+}
+
+func (s *fakeKMSSigner) rotate() {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	s.keys = append(s.keys, key)
+}
+
+func (s *fakeKMSSigner) PublicKey(_ context.Context) (string, crypto.PublicKey, error) {
+	if s.failing {
+		return "", nil, fmt.Errorf("unavailable")
+	}
+	return fmt.Sprintf("%d", len(s.keys)), &s.keys[len(s.keys)-1].PublicKey, nil
+}
+
+func (s *fakeKMSSigner) Sign(_ context.Context, version string, digest []byte) ([]byte, error) {
+	var v int
+	_, _ = fmt.Sscanf(version, "%d", &v)
+	r, sig, err := ecdsa.Sign(rand.Reader, s.keys[v-1], digest)
+	if err != nil {
+		return nil, err
+	}
+	s.signed++
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	sig.FillBytes(signature[32:])
+	return signature, nil
+}
+
+func TestNewKMSSigningKey(t *testing.T) {
+	signer := &fakeKMSSigner{}
+	signer.rotate()
+
+	signingKey, err := NewKMSSigningKey(context.TODO(), "kms-key", "ES256", signer)
+	assert.NilError(t, err)
+	assert.Equal(t, signingKey.KeyID, "kms-key")
+	assert.Equal(t, signingKey.Algorithm, "ES256")
+
+	_, err = NewKMSSigningKey(context.TODO(), "kms-key", "HS256", signer)
+	assert.Error(t, err, "unsupported kms signing algorithm: HS256")
+
+	_, err = NewKMSSigningKey(context.TODO(), "kms-key", "RS256", signer)
+	assert.Error(t, err, "invalid kms key kms-key for algorithm RS256")
+
+	_, err = NewKMSSigningKey(context.TODO(), "kms-key", "ES256", &fakeKMSSigner{failing: true})
+	assert.Error(t, err, "failed to fetch the public key of the kms key kms-key: unavailable")
+}
+
+func TestWristbandSignAndVerifyWithKMS(t *testing.T) {
+	signer := &fakeKMSSigner{}
+	signer.rotate()
+	signingKey, _ := NewKMSSigningKey(context.TODO(), "kms-key", "ES256", signer)
+	wristbandIssuer, _ := NewWristbandConfig("http://authorino", nil, nil, []jose.JSONWebKey{*signingKey})
+
+	wristband, err := wristbandIssuer.Sign(Claims{"sub": "john"})
+	assert.NilError(t, err)
+	header, _ := base64.RawURLEncoding.DecodeString(strings.Split(wristband, ".")[0])
+	assert.Check(t, strings.Contains(string(header), `"kid":"kms-key/1"`))
+
+	claims, err := wristbandIssuer.Verify(wristband)
+	assert.NilError(t, err)
+	assert.Equal(t, claims["sub"], "john")
+
+	// cached signature
+	_, _ = wristbandIssuer.Sign(Claims{"sub": "john", "iat": claims["iat"], "exp": claims["exp"]})
+	assert.Equal(t, signer.signed, 1)
+
+	// new version of the key
+	signer.rotate()
+	kmsKey := signingKey.Key.(*KMSSigningKey)
+	kmsKey.refreshedAt = time.Now().Add(-kmsKeyVersionRefreshInterval)
+
+	newWristband, err := wristbandIssuer.Sign(Claims{"sub": "john"})
+	assert.NilError(t, err)
+	header, _ = base64.RawURLEncoding.DecodeString(strings.Split(newWristband, ".")[0])
+	assert.Check(t, strings.Contains(string(header), `"kid":"kms-key/2"`))
+
+	// tokens signed with both versions are valid
+	_, err = wristbandIssuer.Verify(wristband)
+	assert.NilError(t, err)
+	_, err = wristbandIssuer.Verify(newWristband)
+	assert.NilError(t, err)
+
+	// all versions are published
+	jwks, err := wristbandIssuer.JWKS()
+	assert.NilError(t, err)
+	assert.Check(t, strings.Contains(jwks, `"kid":"kms-key/1"`))
+	assert.Check(t, strings.Contains(jwks, `"kid":"kms-key/2"`))
+
+	// kms unavailable: keeps signing with the last version known
+	signer.failing = true
+	kmsKey.refreshedAt = time.Now().Add(-kmsKeyVersionRefreshInterval)
+	_, err = wristbandIssuer.Sign(Claims{"sub": "jane"})
+	assert.NilError(t, err)
+}
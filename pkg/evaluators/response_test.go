@@ -2,6 +2,7 @@ package evaluators
 
 import (
 	gojson "encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/kuadrant/authorino/pkg/evaluators/response"
@@ -30,3 +31,114 @@ func TestWrapResponseObjectAsHeader(t *testing.T) {
 	responseConfig.Plain = &response.Plain{}
 	assert.Equal(t, responseConfig.WrapObjectAsHeaderValue("my-value"), "my-value")
 }
+
+func TestWrapResponsesAsCookies(t *testing.T) {
+	session := NewResponseConfig("session", 0, nil, SET_COOKIE_WRAPPER, "", false)
+	session.Plain = &response.Plain{}
+	session.Cookie = &CookieAttributes{Path: "/", MaxAge: 3600, Secure: true, HttpOnly: true, SameSite: "Lax"}
+
+	prefs := NewResponseConfig("prefs", 0, nil, SET_COOKIE_WRAPPER, "user-prefs", false)
+	prefs.DynamicJSON = &response.DynamicJSON{}
+
+	headers, metadata, downstreamHeaders := WrapResponses(map[*ResponseConfig]interface{}{
+		session: "abc123",
+		prefs:   map[string]string{"lang": "en"},
+	})
+	assert.Equal(t, len(headers), 0)
+	assert.Equal(t, len(metadata), 0)
+	assert.DeepEqual(t, downstreamHeaders, []map[string]string{
+		{"Set-Cookie": "session=abc123; Path=/; Max-Age=3600; HttpOnly; Secure; SameSite=Lax"},
+		{"Set-Cookie": "user-prefs=%7B%22lang%22%3A%22en%22%7D"},
+	})
+}
+
+func TestWrapResponsesAsDownstreamHeaders(t *testing.T) {
+	upstream := NewResponseConfig("x-user", 0, nil, HTTP_HEADER_WRAPPER, "", false)
+	upstream.Plain = &response.Plain{}
+
+	downstream := NewResponseConfig("tier", 0, nil, HTTP_RESPONSE_HEADER_WRAPPER, "X-RateLimit-Tier", false)
+	downstream.Plain = &response.Plain{}
+
+	headers, _, downstreamHeaders := WrapResponses(map[*ResponseConfig]interface{}{
+		upstream:   "john",
+		downstream: "gold",
+	})
+	assert.DeepEqual(t, headers, map[string]string{"x-user": "john"})
+	assert.DeepEqual(t, downstreamHeaders, []map[string]string{{"X-RateLimit-Tier": "gold"}})
+}
+
+func TestGetHeadersToAppend(t *testing.T) {
+	groups := NewResponseConfig("x-forwarded-groups", 0, nil, HTTP_HEADER_WRAPPER, "X-Forwarded-Groups", false)
+	groups.Plain = &response.Plain{}
+	groups.Append = true
+
+	user := NewResponseConfig("x-user", 0, nil, HTTP_HEADER_WRAPPER, "", false)
+	user.Plain = &response.Plain{}
+
+	vary := NewResponseConfig("vary", 0, nil, HTTP_RESPONSE_HEADER_WRAPPER, "Vary", false)
+	vary.Plain = &response.Plain{}
+	vary.Append = true
+
+	responses := map[*ResponseConfig]interface{}{groups: "admin", user: "john", vary: "X-User"}
+	assert.DeepEqual(t, GetHeadersToAppend(responses, HTTP_HEADER_WRAPPER), []string{"X-Forwarded-Groups"})
+	assert.DeepEqual(t, GetHeadersToAppend(responses, HTTP_RESPONSE_HEADER_WRAPPER), []string{"Vary"})
+}
+
+func TestGetQueryParametersToSet(t *testing.T) {
+	tenant := NewResponseConfig("tenant", 0, nil, QUERY_PARAMETER_WRAPPER, "tenant_id", false)
+	tenant.Plain = &response.Plain{}
+
+	user := NewResponseConfig("x-user", 0, nil, HTTP_HEADER_WRAPPER, "", false)
+	user.Plain = &response.Plain{}
+
+	responses := map[*ResponseConfig]interface{}{tenant: "acme", user: "john"}
+	assert.DeepEqual(t, GetQueryParametersToSet(responses), map[string]string{"tenant_id": "acme"})
+
+	headers, _, _ := WrapResponses(responses)
+	assert.DeepEqual(t, headers, map[string]string{"x-user": "john"})
+}
+
+func TestGrpcMetadataKey(t *testing.T) {
+	key, err := GrpcMetadataKey("X-User-Id")
+	assert.NilError(t, err)
+	assert.Equal(t, key, "x-user-id")
+
+	_, err = GrpcMetadataKey("x user")
+	assert.Error(t, err, "invalid grpc metadata key: x user")
+
+	_, err = GrpcMetadataKey("grpc-status")
+	assert.Error(t, err, "reserved grpc metadata key: grpc-status")
+}
+
+func TestWrapResponsesAsGrpcMetadata(t *testing.T) {
+	user := NewResponseConfig("x-user-id", 0, nil, GRPC_METADATA_WRAPPER, "", false)
+	user.Plain = &response.Plain{}
+
+	identity := NewResponseConfig("identity-bin", 0, nil, GRPC_METADATA_WRAPPER, "", false)
+	identity.DynamicJSON = &response.DynamicJSON{}
+
+	headers, metadata, _ := WrapResponses(map[*ResponseConfig]interface{}{
+		user:     "john",
+		identity: map[string]interface{}{"sub": "john"},
+	})
+	assert.Equal(t, headers["x-user-id"], "john")
+	assert.Equal(t, headers["identity-bin"], "eyJzdWIiOiJqb2huIn0") // base64 (unpadded) of {"sub":"john"}
+	assert.Equal(t, len(metadata), 0)
+}
+
+func TestWrapResponsesWithCompression(t *testing.T) {
+	identity := NewResponseConfig("x-identity", 0, nil, HTTP_HEADER_WRAPPER, "", false)
+	identity.Plain = &response.Plain{}
+	identity.Compression = response.NewGzipCompression(16)
+
+	user := NewResponseConfig("x-user", 0, nil, HTTP_HEADER_WRAPPER, "", false)
+	user.Plain = &response.Plain{}
+	user.Compression = response.NewGzipCompression(16)
+
+	headers, _, _ := WrapResponses(map[*ResponseConfig]interface{}{
+		identity: strings.Repeat("a", 64),
+		user:     "john",
+	})
+	assert.Check(t, strings.HasPrefix(headers["x-identity"], "H4sI"))
+	assert.Equal(t, headers["x-user"], "john")
+}
@@ -29,12 +29,16 @@ func NewHttpServerMock(serverHost string, httpServerMocks map[string]HttpServerM
 				}
 				rw.WriteHeader(response.Status)
 				_, _ = rw.Write([]byte(response.Body))
-				break
+				return
 			}
 		}
+		rw.WriteHeader(http.StatusNotFound)
 	}
 
 	server := &gohttptest.Server{Listener: listener, Config: &http.Server{Handler: http.HandlerFunc(handler)}}
+	// mocks of different tests listen on the same hosts, so connections are not kept alive for clients to reuse after
+	// the mock is closed (only the idle connections of the default transport are closed along with the mock)
+	server.Config.SetKeepAlivesEnabled(false)
 	server.Start()
 
 	return server
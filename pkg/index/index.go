@@ -2,10 +2,12 @@ package index
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 
 	"github.com/kuadrant/authorino/pkg/evaluators"
+	"github.com/kuadrant/authorino/pkg/utils"
 )
 
 const (
@@ -19,6 +21,7 @@ type Index interface {
 	Delete(id string)
 	DeleteKey(id, key string)
 	List() []*evaluators.AuthConfig
+	ListIds() []string
 	Empty() bool
 
 	FindId(key string) (id string, found bool)
@@ -72,8 +75,9 @@ func (c *authConfigTree) Set(id, key string, config evaluators.AuthConfig, overr
 		Id:         id,
 		AuthConfig: config,
 	}
+	// the entry of the key is swapped as a whole, so concurrent readers get either the previous config or the new one
 	err := c.root.set(revertKey(key), entry, override)
-	if err == nil {
+	if err == nil && !utils.SliceContains(c.keys[id], key) {
 		c.keys[id] = append(c.keys[id], key)
 	}
 	return err
@@ -108,6 +112,19 @@ func (c *authConfigTree) List() []*evaluators.AuthConfig {
 	return configs
 }
 
+// ListIds returns the ids of the configs in the index, sorted
+func (c *authConfigTree) ListIds() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ids := make([]string, 0, len(c.keys))
+	for id := range c.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
 func (c *authConfigTree) Empty() bool {
 	return len(c.keys) == 0
 }
@@ -133,6 +150,18 @@ func (c *authConfigTree) deleteKey(id, key string) {
 	if node, _ := c.root.longestCommonLabel(revertKey(key)); node != nil && node.entry != nil && node.entry.Id == id {
 		node.entry = nil
 	}
+
+	keys := make([]string, 0, len(c.keys[id]))
+	for _, k := range c.keys[id] {
+		if k != key {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) > 0 {
+		c.keys[id] = keys
+	} else {
+		delete(c.keys, id)
+	}
 }
 
 func newTreeNode(label string, parent *treeNode) *treeNode {
@@ -150,6 +179,11 @@ type treeNode struct {
 	children map[string]*treeNode
 }
 
+// get returns the entry that matches the key with the highest precedence: an exact match beats any wildcard, and a
+// wildcard of a longer suffix beats a wildcard of a shorter suffix (e.g. for 'api.acme.com': 'api.acme.com', then
+// '*.acme.com', then '*.com').
+// A wildcard also matches the bare suffix it is declared under, e.g. '*.acme.com' matches 'acme.com' unless an exact
+// 'acme.com' is indexed.
 func (n *treeNode) get(key string) *indexEntry {
 	node, tail := n.longestCommonLabel(key)
 
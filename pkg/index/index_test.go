@@ -81,6 +81,9 @@ func TestAuthConfigTree(t *testing.T) {
 	sort.Strings(keys)
 	assert.Check(t, keys == nil)
 
+	// List the ids
+	assert.DeepEqual(t, c.ListIds(), []string{"auth-1", "auth-2", "auth-3", "auth-4"})
+
 	// Get id associated with a host
 	id, found := c.FindId("*.pets.com")
 	assert.Check(t, found)
@@ -139,6 +142,67 @@ func TestAuthConfigTree(t *testing.T) {
 	assert.DeepEqual(t, *config, authConfig4) // because `*.acme.com <- auth-4` is still in the tree
 }
 
+func TestAuthConfigTreeOverride(t *testing.T) {
+	c := newAuthConfigTree()
+
+	authConfig1 := buildTestAuthConfig()
+	authConfig2 := buildTestAuthConfig()
+	authConfig2.Labels = map[string]string{"version": "2"}
+
+	assert.NilError(t, c.Set("auth-1", "talker-api.nip.io", authConfig1, false))
+	assert.NilError(t, c.Set("auth-1", "talker-api.io", authConfig1, false))
+	previous := c.Get("talker-api.nip.io")
+
+	// swap the config of a key
+	assert.NilError(t, c.Set("auth-1", "talker-api.nip.io", authConfig2, true))
+	assert.DeepEqual(t, *c.Get("talker-api.nip.io"), authConfig2)
+	assert.DeepEqual(t, *previous, authConfig1) // readers holding the previous config are not affected
+
+	keys := c.FindKeys("auth-1")
+	sort.Strings(keys)
+	assert.DeepEqual(t, keys, []string{"talker-api.io", "talker-api.nip.io"})
+
+	// delete a single key
+	c.DeleteKey("auth-1", "talker-api.io")
+	assert.DeepEqual(t, c.FindKeys("auth-1"), []string{"talker-api.nip.io"})
+	assert.Check(t, c.Get("talker-api.io") == nil)
+
+	c.Delete("auth-1")
+	assert.Check(t, c.FindKeys("auth-1") == nil)
+	assert.Check(t, c.Empty())
+}
+
+func TestAuthConfigTreePrecedence(t *testing.T) {
+	c := newAuthConfigTree()
+
+	hosts := []string{"*.com", "*.acme.com", "api.acme.com", "*.eu.api.acme.com", "acme.io"}
+	for _, host := range hosts {
+		assert.NilError(t, c.Set(host, host, buildTestAuthConfig(), false))
+	}
+
+	testCases := []struct {
+		host     string
+		expected string
+	}{
+		{"api.acme.com", "api.acme.com"},  // exact match beats wildcards
+		{"www.acme.com", "*.acme.com"},    // longer wildcard suffix beats shorter
+		{"v1.www.acme.com", "*.acme.com"}, // wildcards match more than one label
+		{"v1.api.acme.com", "*.acme.com"}, // an exact match does not extend to the subdomains
+		{"de.eu.api.acme.com", "*.eu.api.acme.com"},
+		{"eu.api.acme.com", "*.eu.api.acme.com"}, // a wildcard matches the apex of its suffix
+		{"acme.com", "*.acme.com"},
+		{"pets.com", "*.com"},
+		{"acme.io", "acme.io"},
+		{"www.acme.io", ""},
+		{"com", "*.com"},
+	}
+
+	for _, tc := range testCases {
+		id, _ := c.FindId(tc.host)
+		assert.Equal(t, id, tc.expected, tc.host)
+	}
+}
+
 type bogusIdentity struct{}
 
 func (f *bogusIdentity) Call(_ auth.AuthPipeline, _ context.Context) (interface{}, error) {
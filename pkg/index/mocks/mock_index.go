@@ -129,6 +129,20 @@ func (mr *MockIndexMockRecorder) List() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockIndex)(nil).List))
 }
 
+// ListIds mocks base method.
+func (m *MockIndex) ListIds() []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListIds")
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// ListIds indicates an expected call of ListIds.
+func (mr *MockIndexMockRecorder) ListIds() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListIds", reflect.TypeOf((*MockIndex)(nil).ListIds))
+}
+
 // Set mocks base method.
 func (m *MockIndex) Set(id, key string, config evaluators.AuthConfig, override bool) error {
 	m.ctrl.T.Helper()
@@ -0,0 +1,391 @@
+package json
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// structs declared outside this module are not walked into when compiling the JSON values
+const modulePath = "github.com/kuadrant/authorino/"
+
+var jsonValueType = reflect.TypeOf(JSONValue{})
+
+// Compile parses and validates the pattern of the value ahead of the requests, storing an accessor that, for each input
+// JSON, does nothing but the lookups of the selectors.
+// Static values need no compilation.
+func (v *JSONValue) Compile() error {
+	v.resolver = nil
+
+	if v.Pattern == "" {
+		return nil
+	}
+
+	if err := ValidateSelector(v.Pattern); err != nil {
+		return err
+	}
+
+	if !v.IsTemplate() {
+		accessor := compileSelector(v.Pattern)
+		v.resolver = func(jsonData string) interface{} {
+			return accessor(jsonData).Value()
+		}
+		return nil
+	}
+
+	segments := parseTemplate(v.Pattern)
+	for i := range segments {
+		if segments[i].selector {
+			segments[i].accessor = compileSelector(segments[i].value)
+		}
+	}
+	v.resolver = func(jsonData string) interface{} {
+		return renderTemplate(segments, jsonData)
+	}
+	return nil
+}
+
+// selectorAccessor fetches the value of a selector from an input JSON
+type selectorAccessor func(jsonData string) gjson.Result
+
+// compileSelector returns the accessor of a selector.
+// Selectors that are plain paths of object keys and array indexes (e.g. `auth.identity.sub`, the vast majority) are
+// split into their keys once, so the accessor only scans the input JSON down to the value; any other selector (with
+// modifiers, wildcards, queries, etc) is left to gjson.
+func compileSelector(selector string) selectorAccessor {
+	keys, ok := splitPlainPath(selector)
+	if !ok {
+		return func(jsonData string) gjson.Result {
+			return gjson.Get(jsonData, selector)
+		}
+	}
+	return func(jsonData string) gjson.Result {
+		return lookupPlainPath(jsonData, keys)
+	}
+}
+
+// splitPlainPath splits a selector into the keys of the path, unescaping the escaped characters.
+// It returns false if the selector is not a plain path of object keys and array indexes.
+func splitPlainPath(selector string) ([]string, bool) {
+	if selector == "" || selector[0] == '.' {
+		return nil, false
+	}
+
+	var keys []string
+	var key strings.Builder
+	for i := 0; i < len(selector); i++ {
+		switch b := selector[i]; b {
+		case '\\':
+			if i++; i == len(selector) {
+				return nil, false
+			}
+			key.WriteByte(selector[i])
+		case '.':
+			if key.Len() == 0 {
+				return nil, false
+			}
+			keys = append(keys, key.String())
+			key.Reset()
+		case '*', '?', '#', '@', '|', '!', '=', '<', '>', '%', '~', '[', ']', '{', '}', '(', ')', '"', ',':
+			return nil, false
+		default:
+			key.WriteByte(b)
+		}
+	}
+	if key.Len() == 0 {
+		return nil, false
+	}
+	return append(keys, key.String()), true
+}
+
+// lookupPlainPath walks a JSON down the keys of a plain path, matching the keys of the objects and the indexes of the
+// arrays as gjson does. The values off the path are skipped without being parsed.
+func lookupPlainPath(jsonData string, keys []string) gjson.Result {
+	start := skipSpaces(jsonData, 0)
+	for _, key := range keys {
+		var found bool
+		switch {
+		case start >= len(jsonData):
+			return gjson.Result{}
+		case jsonData[start] == '{':
+			start, found = lookupObjectKey(jsonData, start+1, key)
+		case jsonData[start] == '[':
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 {
+				return gjson.Result{}
+			}
+			start, found = lookupArrayIndex(jsonData, start+1, index)
+		}
+		if !found {
+			return gjson.Result{}
+		}
+	}
+	end := skipValue(jsonData, start)
+	if end <= start {
+		return gjson.Result{}
+	}
+	return gjson.Parse(jsonData[start:end])
+}
+
+// lookupObjectKey returns the position of the value of a key of the object whose members start at a position
+func lookupObjectKey(jsonData string, i int, key string) (int, bool) {
+	for {
+		i = skipSpaces(jsonData, i)
+		if i >= len(jsonData) || jsonData[i] != '"' {
+			return i, false
+		}
+		end := skipString(jsonData, i)
+		if end < 0 {
+			return i, false
+		}
+		name := jsonData[i+1 : end-1]
+		if strings.IndexByte(name, '\\') >= 0 {
+			name = gjson.Parse(jsonData[i:end]).Str
+		}
+		i = skipSpaces(jsonData, end)
+		if i >= len(jsonData) || jsonData[i] != ':' {
+			return i, false
+		}
+		i = skipSpaces(jsonData, i+1)
+		if name == key {
+			return i, true
+		}
+		if i = skipValue(jsonData, i); i < 0 {
+			return i, false
+		}
+		if i = skipSpaces(jsonData, i); i >= len(jsonData) || jsonData[i] != ',' {
+			return i, false
+		}
+		i++
+	}
+}
+
+// lookupArrayIndex returns the position of an item of the array whose items start at a position
+func lookupArrayIndex(jsonData string, i int, index int) (int, bool) {
+	for n := 0; ; n++ {
+		i = skipSpaces(jsonData, i)
+		if i >= len(jsonData) || jsonData[i] == ']' {
+			return i, false
+		}
+		if n == index {
+			return i, true
+		}
+		if i = skipValue(jsonData, i); i < 0 {
+			return i, false
+		}
+		if i = skipSpaces(jsonData, i); i >= len(jsonData) || jsonData[i] != ',' {
+			return i, false
+		}
+		i++
+	}
+}
+
+// skipValue returns the position right after the JSON value that starts at a position, or -1 if malformed
+func skipValue(jsonData string, i int) int {
+	if i < 0 || i >= len(jsonData) {
+		return -1
+	}
+	switch jsonData[i] {
+	case '"':
+		return skipString(jsonData, i)
+	case '{', '[':
+		depth := 0
+		for ; i < len(jsonData); i++ {
+			switch jsonData[i] {
+			case '"':
+				if i = skipString(jsonData, i); i < 0 {
+					return -1
+				}
+				i--
+			case '{', '[':
+				depth++
+			case '}', ']':
+				if depth--; depth == 0 {
+					return i + 1
+				}
+			}
+		}
+		return -1
+	default:
+		for ; i < len(jsonData); i++ {
+			switch jsonData[i] {
+			case ',', '}', ']', ' ', '\t', '\n', '\r':
+				return i
+			}
+		}
+		return i
+	}
+}
+
+// skipString returns the position right after the JSON string that starts at a position, or -1 if unterminated
+func skipString(jsonData string, i int) int {
+	for i++; i < len(jsonData); i++ {
+		switch jsonData[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return -1
+}
+
+func skipSpaces(jsonData string, i int) int {
+	for i >= 0 && i < len(jsonData) && jsonData[i] <= ' ' {
+		i++
+	}
+	return i
+}
+
+// CompileJSONValues compiles all the JSON values reachable through the exported fields of an object, e.g. the
+// evaluators of an AuthConfig. Returns the error of the first invalid pattern.
+func CompileJSONValues(obj interface{}) error {
+	return compileJSONValues(reflect.ValueOf(obj), make(map[visitedPointer]struct{}))
+}
+
+type visitedPointer struct {
+	typ     reflect.Type
+	address uintptr
+}
+
+func compileJSONValues(value reflect.Value, visited map[visitedPointer]struct{}) error {
+	switch value.Kind() {
+	case reflect.Pointer:
+		if value.IsNil() {
+			return nil
+		}
+		pointer := visitedPointer{value.Type(), value.Pointer()}
+		if _, found := visited[pointer]; found {
+			return nil
+		}
+		visited[pointer] = struct{}{}
+		return compileJSONValues(value.Elem(), visited)
+	case reflect.Interface:
+		if value.IsNil() {
+			return nil
+		}
+		return compileJSONValues(value.Elem(), visited)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if err := compileJSONValues(value.Index(i), visited); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		// map values are not addressable, thus compiled in a copy that replaces the value in the map
+		for _, key := range value.MapKeys() {
+			elem := reflect.New(value.Type().Elem()).Elem()
+			elem.Set(value.MapIndex(key))
+			if err := compileJSONValues(elem, visited); err != nil {
+				return err
+			}
+			value.SetMapIndex(key, elem)
+		}
+	case reflect.Struct:
+		if value.Type() == jsonValueType {
+			if !value.CanAddr() {
+				return nil
+			}
+			return value.Addr().Interface().(*JSONValue).Compile()
+		}
+		if !strings.HasPrefix(value.Type().PkgPath(), modulePath) {
+			return nil
+		}
+		for i := 0; i < value.NumField(); i++ {
+			if !value.Type().Field(i).IsExported() {
+				continue
+			}
+			if err := compileJSONValues(value.Field(i), visited); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// templateSegment is either a static string or a selector of a variable placeholder of a template
+type templateSegment struct {
+	value    string
+	selector bool
+	// accessor of the selector, if compiled
+	accessor selectorAccessor
+}
+
+// parseTemplate splits a template into static strings and selectors of the variable placeholders.
+// Curly braces escaped with a backslash are static; unclosed placeholders are dropped.
+// The segments are substrings of the template, split around the escaping backslashes, so parsing copies nothing.
+func parseTemplate(source string) []templateSegment {
+	var segments []templateSegment
+	var escaping, insidePlaceholder bool
+	var nestedCurlyBraces, placeholderStart, staticStart int
+
+	appendStatic := func(end int) {
+		if end > staticStart {
+			segments = append(segments, templateSegment{value: source[staticStart:end]})
+		}
+	}
+
+	for i := 0; i < len(source); i++ {
+		switch source[i] {
+		case '{':
+			if !escaping {
+				if insidePlaceholder {
+					nestedCurlyBraces = nestedCurlyBraces + 1
+				} else {
+					appendStatic(i)
+					insidePlaceholder = true
+					placeholderStart = i + 1
+				}
+			}
+			escaping = false
+		case '}':
+			if insidePlaceholder {
+				if nestedCurlyBraces > 0 {
+					nestedCurlyBraces = nestedCurlyBraces - 1
+				} else {
+					if selector := source[placeholderStart:i]; selector != "" {
+						segments = append(segments, templateSegment{value: selector, selector: true})
+					}
+					insidePlaceholder = false
+					staticStart = i + 1
+				}
+			}
+			escaping = false
+		case '\\':
+			if !insidePlaceholder {
+				if !escaping {
+					// drops the escaping backslash
+					appendStatic(i)
+					staticStart = i + 1
+				}
+				escaping = !escaping
+			}
+		default:
+			escaping = false
+		}
+	}
+
+	if !insidePlaceholder {
+		appendStatic(len(source))
+	}
+
+	return segments
+}
+
+// renderTemplate joins the segments of a template, replacing the selectors with the values fetched from the input JSON
+func renderTemplate(segments []templateSegment, jsonData string) string {
+	var rendered strings.Builder
+	for _, segment := range segments {
+		switch {
+		case segment.accessor != nil:
+			rendered.WriteString(segment.accessor(jsonData).String())
+		case segment.selector:
+			rendered.WriteString(gjson.Get(jsonData, segment.value).String())
+		default:
+			rendered.WriteString(segment.value)
+		}
+	}
+	return rendered.String()
+}
@@ -0,0 +1,122 @@
+package json
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+	"gotest.tools/assert"
+)
+
+func TestCompileJSONValue(t *testing.T) {
+	const jsonData = `{"auth":{"identity":{"username":"john","email":"john@test","roles":["user","admin"],"kubernetes.io/ns":"ns","0":"zero","escaped\"key":1,"groups":[{"name":"dev"}]}}}`
+
+	values := []JSONValue{
+		{Static: "static"},
+		{Pattern: "auth.identity.username"},
+		{Pattern: "auth.identity.roles"},
+		{Pattern: "auth.identity.roles.1"},
+		{Pattern: "auth.identity.roles.2"},
+		{Pattern: "auth.identity.roles.first"},
+		{Pattern: "auth.identity.groups.0.name"},
+		{Pattern: `auth.identity.kubernetes\.io/ns`},
+		{Pattern: "auth.identity.0"},
+		{Pattern: "auth.identity.username.length"},
+		{Pattern: "auth.identity.roles.#"},
+		{Pattern: `auth.identity.roles.#(=="admin")`},
+		{Pattern: "auth.identity.user*"},
+		{Pattern: "auth"},
+		{Pattern: "auth.identity.username.@case:upper"},
+		{Pattern: `Domain: {auth.identity.email.@extract:{"sep":"@","pos":1}}`},
+		{Pattern: `\{"username":"{auth.identity.username}"\}`},
+		{Pattern: "auth.identity.missing"},
+	}
+	for _, value := range values {
+		expected := value.ResolveFor(jsonData)
+		assert.NilError(t, value.Compile(), value.Pattern)
+		assert.DeepEqual(t, value.ResolveFor(jsonData), expected)
+	}
+
+	// plain paths are looked up without gjson
+	_, plain := splitPlainPath(`auth.identity.kubernetes\.io/ns`)
+	assert.Check(t, plain)
+	_, plain = splitPlainPath("auth.identity.username.@case:upper")
+	assert.Check(t, !plain)
+
+	value := JSONValue{Pattern: "auth.identity.username.@upper"}
+	assert.Error(t, value.Compile(), "unknown modifier '@upper' in selector: auth.identity.username.@upper")
+	assert.Check(t, value.resolver == nil)
+
+	value = JSONValue{Pattern: "Username: {auth.identity.username"}
+	assert.Error(t, value.Compile(), "unclosed placeholder in template: Username: {auth.identity.username")
+}
+
+func TestLookupPlainPath(t *testing.T) {
+	documents := []string{
+		`{"a":{"b":[1,{"c":"x\"y"},[2,3]],"d\"e":true,"f.g":null,"0":"zero","n":-1.5e3,"s":"a,b}]"}}`,
+		` { "a" : { "b" : [ 1 , { "c" : "v" } ] } } `,
+		`[{"a":1},{"a":2}]`,
+		`"a"`,
+		`{"a":{"b":1}`,
+	}
+	selectors := []string{"a", "a.b", "a.b.1.c", "a.b.2.1", "a.b.5", "a.b.-1", `a.d\"e`, `a.f\.g`, "a.0", "a.n", "a.s", "a.s.x", "a.missing", "1.a"}
+
+	for _, document := range documents {
+		for _, selector := range selectors {
+			keys, plain := splitPlainPath(selector)
+			assert.Check(t, plain, selector)
+			expected := gjson.Get(document, selector)
+			resolved := lookupPlainPath(document, keys)
+			assert.Equal(t, resolved.Exists(), expected.Exists(), "%s %s", document, selector)
+			assert.DeepEqual(t, resolved.Value(), expected.Value())
+		}
+	}
+}
+
+type compilableConfig struct {
+	Value    JSONValue
+	Values   []JSONProperty
+	Pointer  *JSONValue
+	Nested   interface{}
+	Self     *compilableConfig
+	Map      map[string]JSONValue
+	internal JSONValue
+}
+
+func TestCompileJSONValues(t *testing.T) {
+	config := &compilableConfig{
+		Value:    JSONValue{Pattern: "auth.identity.username"},
+		Values:   []JSONProperty{{Name: "email", Value: JSONValue{Pattern: "auth.identity.email"}}},
+		Pointer:  &JSONValue{Pattern: "Hello, {auth.identity.username}!"},
+		Nested:   &compilableConfig{Value: JSONValue{Static: "static"}},
+		Map:      map[string]JSONValue{"username": {Pattern: "auth.identity.username"}},
+		internal: JSONValue{Pattern: "auth.identity.username"},
+	}
+	config.Self = config
+
+	assert.NilError(t, CompileJSONValues(config))
+	assert.Check(t, config.Value.resolver != nil)
+	assert.Check(t, config.Values[0].Value.resolver != nil)
+	assert.Check(t, config.Pointer.resolver != nil)
+	assert.Check(t, config.Map["username"].resolver != nil)
+	assert.Check(t, config.Nested.(*compilableConfig).Value.resolver == nil) // static
+	assert.Check(t, config.internal.resolver == nil)                         // unexported
+
+	config.Values = append(config.Values, JSONProperty{Name: "invalid", Value: JSONValue{Pattern: "auth.identity]"}})
+	assert.Error(t, CompileJSONValues(config), "unbalanced ']' in selector: auth.identity]")
+}
+
+func BenchmarkCompiledJSONValue(b *testing.B) {
+	const jsonData = `{"auth":{"identity":{"username":"john","email":"john@test","github.com":"https://github.com/john"}}}`
+
+	value := JSONValue{Pattern: `\{"username":"{auth.identity.username}","email":"{auth.identity.email}","github":"{auth.identity.github\.com|@extract:{"sep":"/","pos":3}}"\}`}
+	assert.NilError(b, value.Compile())
+
+	var resolved interface{}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		resolved = value.ResolveFor(jsonData)
+	}
+	b.StopTimer()
+	assert.Equal(b, resolved, `{"username":"john","email":"john@test","github":"john"}`)
+}
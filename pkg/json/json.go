@@ -31,6 +31,9 @@ type JSONValue struct {
 	Static interface{}
 	// Resolves the value of the JSON property by fetching the pattern from the authorization JSON.
 	Pattern string
+
+	// Accessor of the pattern, set by Compile
+	resolver func(jsonData string) interface{}
 }
 
 // ResolveFor resolves a value for a given input JSON.
@@ -39,6 +42,9 @@ type JSONValue struct {
 // In case of a template that mixes no variable placeholder, but it contains nothing but a static string value, users
 // should use `JSONValue.Static` instead of `JSONValue.Pattern`.
 func (v *JSONValue) ResolveFor(jsonData string) interface{} {
+	if v.resolver != nil {
+		return v.resolver(jsonData)
+	}
 	if v.Pattern != "" {
 		// If all curly braces in the pattern are for passing arguments to modifiers, then it's likely NOT a template.
 		// To be a template, the pattern must contain at least one curly brace delimiting a variable placeholder.
@@ -93,61 +99,94 @@ func UnmashalJSONResponse(resp *http.Response, v interface{}, b *[]byte) error {
 	return fmt.Errorf("expected Content-Type = application/json, got %q: %v", ct, err)
 }
 
+// ReplaceJSONPlaceholders replaces the variable placeholders of a template (e.g. `Hello, {auth.identity.name}!`) with
+// the values fetched from the input JSON.
+// Compiled JSON values (see JSONValue.Compile) parse the template once instead.
 func ReplaceJSONPlaceholders(source string, jsonData string) string {
-	var replaced, buffer []byte
+	return renderTemplate(parseTemplate(source), jsonData)
+}
+
+// ValidateSelector checks whether a selector of the authorization JSON is well formed, i.e. brackets, parentheses and
+// curly braces are balanced and all the modifiers are known.
+// Templates that mix static values and variable placeholders are validated by placeholder.
+func ValidateSelector(selector string) error {
+	if !(&JSONValue{Pattern: selector}).IsTemplate() {
+		return validatePath(selector)
+	}
+
+	var buffer []byte
 	var escaping, insidePlaceholder bool
 	var nestedCurlyBraces int
 
-	for _, b := range []byte(source) {
-		switch b {
-		case 123: // '{'
-			if escaping {
-				replaced = append(replaced, b)
-			} else {
-				if insidePlaceholder {
-					buffer = append(buffer, b)
-					nestedCurlyBraces = nestedCurlyBraces + 1
-				} else {
-					insidePlaceholder = true
-				}
+	for _, b := range []byte(selector) {
+		switch {
+		case b == '{' && !escaping && !insidePlaceholder:
+			insidePlaceholder = true
+		case b == '}' && insidePlaceholder && nestedCurlyBraces == 0:
+			if err := validatePath(string(buffer)); err != nil {
+				return err
 			}
-			escaping = false
-		case 125: // '}'
-			if insidePlaceholder {
-				if nestedCurlyBraces > 0 {
-					buffer = append(buffer, b)
-					nestedCurlyBraces = nestedCurlyBraces - 1
-				} else {
-					if len(buffer) > 0 {
-						replaced = append(replaced, []byte(gjson.Get(jsonData, string(buffer)).String())...)
-						buffer = []byte{}
-					}
-					insidePlaceholder = false
-				}
-			} else {
-				replaced = append(replaced, b)
+			buffer = []byte{}
+			insidePlaceholder = false
+		case insidePlaceholder:
+			if b == '{' {
+				nestedCurlyBraces++
+			} else if b == '}' {
+				nestedCurlyBraces--
 			}
+			buffer = append(buffer, b)
+		}
+		escaping = b == '\\' && !escaping && !insidePlaceholder
+	}
+
+	if insidePlaceholder {
+		return fmt.Errorf("unclosed placeholder in template: %s", selector)
+	}
+	return nil
+}
+
+var closingDelimiters = map[byte]byte{'(': ')', '[': ']', '{': '}'}
+
+func validatePath(path string) error {
+	var delimiters []byte
+	var quoted, escaping bool
+
+	for i := 0; i < len(path); i++ {
+		b := path[i]
+		switch {
+		case escaping:
 			escaping = false
-		case 92: // '\'
-			if insidePlaceholder {
-				buffer = append(buffer, b)
-			} else {
-				if escaping {
-					replaced = append(replaced, b)
-				}
-				escaping = !escaping
+		case b == '\\':
+			escaping = true
+		case b == '"':
+			quoted = !quoted
+		case quoted:
+		case b == '(' || b == '[' || b == '{':
+			delimiters = append(delimiters, closingDelimiters[b])
+		case b == ')' || b == ']' || b == '}':
+			if len(delimiters) == 0 || delimiters[len(delimiters)-1] != b {
+				return fmt.Errorf("unbalanced '%c' in selector: %s", b, path)
 			}
-		default:
-			if insidePlaceholder {
-				buffer = append(buffer, b)
-			} else {
-				replaced = append(replaced, b)
+			delimiters = delimiters[:len(delimiters)-1]
+		case b == '@' && (i == 0 || path[i-1] == '.' || path[i-1] == '|'):
+			j := i + 1
+			for j < len(path) && (unicode.IsLetter(rune(path[j])) || unicode.IsDigit(rune(path[j])) || path[j] == '_') {
+				j++
 			}
-			escaping = false
+			if name := path[i+1 : j]; !gjson.ModifierExists(name, nil) {
+				return fmt.Errorf("unknown modifier '@%s' in selector: %s", name, path)
+			}
+			i = j - 1
 		}
 	}
 
-	return string(replaced)
+	if quoted {
+		return fmt.Errorf("unclosed quotes in selector: %s", path)
+	}
+	if len(delimiters) > 0 {
+		return fmt.Errorf("missing '%c' in selector: %s", delimiters[len(delimiters)-1], path)
+	}
+	return nil
 }
 
 func StringifyJSON(data interface{}) (string, error) {
@@ -346,3 +346,44 @@ func TestStringifyJSON(t *testing.T) {
 	assert.Equal(t, str, `{"prop_str":"str","prop_num":123,"prop_bool":false,"prop_null":null,"prop_arr":["a","b","c"],"prop_obj":{"a_prop":"a_value"}}`)
 	assert.NilError(t, err)
 }
+
+func TestValidateSelector(t *testing.T) {
+	validSelectors := []string{
+		"auth.identity.sub",
+		"auth.identity.groups|@reverse",
+		`context.request.http.headers.authorization.@extract:{"sep":" ","pos":1}`,
+		`auth.identity.roles.#(name=="admin(1)").permissions`,
+		"Hello, {auth.identity.name}!",
+		"user@example.com/{auth.identity.sub}",
+		`{auth.identity.name.@case:upper} \{literal\}`,
+	}
+	for _, selector := range validSelectors {
+		assert.NilError(t, ValidateSelector(selector), selector)
+	}
+
+	invalidSelectors := map[string]string{
+		`auth.identity.roles.#(name=="admin"`:    `missing ')' in selector: auth.identity.roles.#(name=="admin"`,
+		"auth.identity]":                         "unbalanced ']' in selector: auth.identity]",
+		"auth.identity.sub.@upper":               "unknown modifier '@upper' in selector: auth.identity.sub.@upper",
+		`auth.identity.name.@replace:{"old":"a}`: `unclosed quotes in selector: auth.identity.name.@replace:{"old":"a}`,
+		"Hello, {auth.identity.name":             "unclosed placeholder in template: Hello, {auth.identity.name",
+		"Hello, {auth.identity.name.@nope}":      "unknown modifier '@nope' in selector: auth.identity.name.@nope",
+	}
+	for selector, expectedErr := range invalidSelectors {
+		assert.Error(t, ValidateSelector(selector), expectedErr)
+	}
+}
+
+func BenchmarkReplaceJSONPlaceholders(b *testing.B) {
+	const jsonData = `{"auth":{"identity":{"username":"john","email":"john@test","github.com":"https://github.com/john"}}}`
+	const template = `\{"username":"{auth.identity.username}","email":"{auth.identity.email}","github":"{auth.identity.github\.com|@extract:{"sep":"/","pos":3}}"\}`
+
+	var replaced string
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		replaced = ReplaceJSONPlaceholders(template, jsonData)
+	}
+	b.StopTimer()
+	assert.Equal(b, replaced, `{"username":"john","email":"john@test","github":"john"}`)
+}
@@ -3,6 +3,7 @@ package jsonexp
 import (
 	"fmt"
 	"regexp"
+	"strings"
 
 	"github.com/tidwall/gjson"
 )
@@ -16,6 +17,8 @@ const (
 	IncludesOperator
 	ExcludesOperator
 	RegexOperator
+	AuthorizationDetailTypeOperator
+	AuthorizationDetailActionOperator
 )
 
 func (o *Operator) String() string {
@@ -30,6 +33,10 @@ func (o *Operator) String() string {
 		return "excl"
 	case RegexOperator:
 		return "matches"
+	case AuthorizationDetailTypeOperator:
+		return "rar_type"
+	case AuthorizationDetailActionOperator:
+		return "rar_action"
 	}
 	return "unknown"
 }
@@ -46,14 +53,43 @@ func OperatorFromString(operator string) Operator {
 		return ExcludesOperator
 	case "matches":
 		return RegexOperator
+	case "rar_type":
+		return AuthorizationDetailTypeOperator
+	case "rar_action":
+		return AuthorizationDetailActionOperator
 	}
 	return UnknownOperator
 }
 
+// NewPattern builds a pattern, compiling upfront the regular expression of `matches` patterns, so an invalid expression
+// is caught when the pattern is built rather than when it is evaluated
+func NewPattern(selector string, operator Operator, value string) (Pattern, error) {
+	pattern := Pattern{
+		Selector: selector,
+		Operator: operator,
+		Value:    value,
+	}
+
+	switch operator {
+	case UnknownOperator:
+		return pattern, fmt.Errorf("unsupported operator in pattern %s", pattern.String())
+	case RegexOperator:
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return pattern, fmt.Errorf("invalid regular expression in pattern %s: %v", pattern.String(), err)
+		}
+		pattern.regex = re
+	}
+
+	return pattern, nil
+}
+
 type Pattern struct {
 	Selector string
 	Operator Operator
 	Value    string
+
+	regex *regexp.Regexp
 }
 
 func (p Pattern) Matches(json string) (bool, error) {
@@ -84,12 +120,42 @@ func (p Pattern) Matches(json string) (bool, error) {
 		return true, nil
 
 	case RegexOperator:
-		re, err := regexp.Compile(expectedValue)
-		if err != nil {
-			return false, err
+		re := p.regex
+		if re == nil {
+			var err error
+			if re, err = regexp.Compile(expectedValue); err != nil {
+				return false, err
+			}
 		}
 		return re.MatchString(obtainedValue.String()), nil
 
+	case AuthorizationDetailTypeOperator:
+		for _, detail := range obtainedValue.Array() {
+			if detail.Get("type").String() == expectedValue {
+				return true, nil
+			}
+		}
+		return false, nil
+
+	case AuthorizationDetailActionOperator:
+		// the value is either the action alone or the type of the authorization detail and the action, separated by ':'
+		var expectedType string
+		expectedAction := expectedValue
+		if i := strings.LastIndex(expectedValue, ":"); i >= 0 {
+			expectedType, expectedAction = expectedValue[:i], expectedValue[i+1:]
+		}
+		for _, detail := range obtainedValue.Array() {
+			if expectedType != "" && detail.Get("type").String() != expectedType {
+				continue
+			}
+			for _, action := range detail.Get("actions").Array() {
+				if action.String() == expectedAction {
+					return true, nil
+				}
+			}
+		}
+		return false, nil
+
 	default:
 		return false, fmt.Errorf("unsupported operator for json authorization")
 	}
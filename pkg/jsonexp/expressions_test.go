@@ -549,3 +549,57 @@ func TestOrAnd(t *testing.T) {
 	assert.NilError(t, err)
 	assert.Check(t, ok)
 }
+
+func TestNewPattern(t *testing.T) {
+	pattern, err := NewPattern("str", RegexOperator, `^my-\w+$`)
+	assert.NilError(t, err)
+	ok, err := pattern.Matches(testJsonData)
+	assert.NilError(t, err)
+	assert.Check(t, ok)
+
+	_, err = NewPattern("str", RegexOperator, `^my-(value$`)
+	assert.ErrorContains(t, err, "invalid regular expression in pattern str matches ^my-(value$")
+
+	_, err = NewPattern("str", UnknownOperator, "my-value")
+	assert.ErrorContains(t, err, "unsupported operator in pattern str unknown my-value")
+}
+
+func TestAuthorizationDetailOperators(t *testing.T) {
+	const authJSON = `{"auth":{"authorization_details":[
+		{"type":"payment_initiation","actions":["initiate","status"]},
+		{"type":"https://example.com/accounts","actions":["list_accounts"]}
+	]}}`
+
+	testCases := []struct {
+		operator Operator
+		value    string
+		expected bool
+	}{
+		{AuthorizationDetailTypeOperator, "payment_initiation", true},
+		{AuthorizationDetailTypeOperator, "https://example.com/accounts", true},
+		{AuthorizationDetailTypeOperator, "customer_information", false},
+		{AuthorizationDetailActionOperator, "status", true},
+		{AuthorizationDetailActionOperator, "list_accounts", true},
+		{AuthorizationDetailActionOperator, "cancel", false},
+		{AuthorizationDetailActionOperator, "payment_initiation:initiate", true},
+		{AuthorizationDetailActionOperator, "https://example.com/accounts:list_accounts", true},
+		{AuthorizationDetailActionOperator, "payment_initiation:list_accounts", false},
+	}
+
+	for _, tc := range testCases {
+		pattern, err := NewPattern("auth.authorization_details", tc.operator, tc.value)
+		assert.NilError(t, err)
+		ok, err := pattern.Matches(authJSON)
+		assert.NilError(t, err)
+		assert.Equal(t, ok, tc.expected, pattern.String())
+	}
+
+	// no authorization details
+	pattern, _ := NewPattern("auth.authorization_details", AuthorizationDetailActionOperator, "initiate")
+	ok, err := pattern.Matches(`{"auth":{}}`)
+	assert.NilError(t, err)
+	assert.Check(t, !ok)
+
+	assert.Equal(t, OperatorFromString("rar_type"), AuthorizationDetailTypeOperator)
+	assert.Equal(t, OperatorFromString("rar_action"), AuthorizationDetailActionOperator)
+}
@@ -0,0 +1,249 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/trace"
+)
+
+var awsSigningAlgorithms = map[string]string{
+	"ES256": "ECDSA_SHA_256",
+	"ES384": "ECDSA_SHA_384",
+	"ES512": "ECDSA_SHA_512",
+	"RS256": "RSASSA_PKCS1_V1_5_SHA_256",
+	"RS384": "RSASSA_PKCS1_V1_5_SHA_384",
+	"RS512": "RSASSA_PKCS1_V1_5_SHA_512",
+	"PS256": "RSASSA_PSS_SHA_256",
+	"PS384": "RSASSA_PSS_SHA_384",
+	"PS512": "RSASSA_PSS_SHA_512",
+}
+
+// AWSCredentials are the static credentials to call the AWS KMS API
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// NewAWSSigner returns a signer for an asymmetric key of AWS KMS, identified by key id, key ARN, alias name or alias ARN.
+// The credentials are read from the `accessKeyId`, `secretAccessKey` and `sessionToken` entries, or from the
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN environment variables.
+func NewAWSSigner(keyID, region, algorithm string, credentials map[string][]byte) (*AWSSigner, error) {
+	signingAlgorithm, ok := awsSigningAlgorithms[algorithm]
+	if !ok {
+		return nil, fmt.Errorf("unsupported aws kms signing algorithm: %s", algorithm)
+	}
+
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if region == "" && strings.HasPrefix(keyID, "arn:") {
+		if parts := strings.Split(keyID, ":"); len(parts) > 3 {
+			region = parts[3]
+		}
+	}
+	if region == "" {
+		return nil, fmt.Errorf("missing aws region")
+	}
+
+	creds := AWSCredentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if credentials != nil {
+		creds = AWSCredentials{
+			AccessKeyID:     string(credentials["accessKeyId"]),
+			SecretAccessKey: string(credentials["secretAccessKey"]),
+			SessionToken:    string(credentials["sessionToken"]),
+		}
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return nil, fmt.Errorf("missing aws credentials")
+	}
+
+	return &AWSSigner{
+		KeyID:            keyID,
+		Region:           region,
+		SigningAlgorithm: signingAlgorithm,
+		Algorithm:        algorithm,
+		Credentials:      creds,
+		Endpoint:         fmt.Sprintf("https://kms.%s.amazonaws.com", region),
+		httpClient:       trace.HTTPClient,
+		keyARNs:          make(map[string]string),
+	}, nil
+}
+
+// AWSSigner signs with an asymmetric key of AWS KMS.
+// The version of the key is the id of the key the configured key id resolves to, so repointing an alias to a new key
+// counts as a new version.
+type AWSSigner struct {
+	KeyID            string
+	Region           string
+	SigningAlgorithm string
+	Algorithm        string
+	Credentials      AWSCredentials
+	Endpoint         string
+
+	httpClient *http.Client
+	keyARNs    map[string]string // ARNs of the keys by version
+	mutex      sync.RWMutex
+}
+
+func (s *AWSSigner) PublicKey(ctx context.Context) (string, crypto.PublicKey, error) {
+	resp := struct {
+		KeyId     string `json:"KeyId"`
+		PublicKey []byte `json:"PublicKey"`
+	}{}
+	if err := s.call(ctx, "GetPublicKey", map[string]string{"KeyId": s.KeyID}, &resp); err != nil {
+		return "", nil, err
+	}
+
+	publicKey, err := x509.ParsePKIXPublicKey(resp.PublicKey)
+	if err != nil {
+		return "", nil, err
+	}
+
+	version := resp.KeyId[strings.LastIndex(resp.KeyId, "/")+1:]
+	s.mutex.Lock()
+	s.keyARNs[version] = resp.KeyId
+	s.mutex.Unlock()
+
+	return version, publicKey, nil
+}
+
+func (s *AWSSigner) Sign(ctx context.Context, version string, digest []byte) ([]byte, error) {
+	s.mutex.RLock()
+	keyID, ok := s.keyARNs[version]
+	s.mutex.RUnlock()
+	if !ok {
+		keyID = version
+	}
+
+	resp := struct {
+		Signature []byte `json:"Signature"`
+	}{}
+	req := map[string]interface{}{
+		"KeyId":            keyID,
+		"Message":          digest,
+		"MessageType":      "DIGEST",
+		"SigningAlgorithm": s.SigningAlgorithm,
+	}
+	if err := s.call(ctx, "Sign", req, &resp); err != nil {
+		return nil, err
+	}
+
+	return jwsSignature(resp.Signature, s.Algorithm)
+}
+
+func (s *AWSSigner) call(ctx context.Context, action string, in, out interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.Endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "TrentService."+action)
+	signAWSRequest(req, body, s.Credentials, s.Region, "kms", time.Now())
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		e := struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}{}
+		_ = json.Unmarshal(respBody, &e)
+		return fmt.Errorf("aws kms %s failed: %s: %s %s", action, resp.Status, e.Type, e.Message)
+	}
+
+	return json.Unmarshal(respBody, out)
+}
+
+// signAWSRequest signs a request with the AWS Signature Version 4
+func signAWSRequest(req *http.Request, body []byte, creds AWSCredentials, region, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	bodyHash := sha256.Sum256(body)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n")
+
+	scope := strings.Join([]string{date, region, service, "aws4_request"}, "/")
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, hex.EncodeToString(canonicalRequestHash[:])}, "\n")
+
+	key := []byte("AWS4" + creds.SecretAccessKey)
+	for _, part := range []string{date, region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,233 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/trace"
+
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+const (
+	azureKeyVaultAPIVersion = "7.4"
+	azureKeyVaultScope      = "https://vault.azure.net/.default"
+	azureAuthorityHost      = "https://login.microsoftonline.com/"
+)
+
+// NewAzureSigner returns a signer for a key of Azure Key Vault, identified by the URL of the key
+// (https://{vault}.vault.azure.net/keys/{name}) or of one of its versions (…/keys/{name}/{version}).
+// The credentials of the service principal are read from the `tenantId`, `clientId` and `clientSecret` entries, or
+// discovered from the environment: AZURE_TENANT_ID, AZURE_CLIENT_ID and either AZURE_CLIENT_SECRET or
+// AZURE_FEDERATED_TOKEN_FILE (workload identity).
+func NewAzureSigner(keyID, algorithm string, credentials map[string][]byte) (*AzureSigner, error) {
+	if hashSize(algorithm) == "" || strings.HasPrefix(algorithm, "HS") {
+		return nil, fmt.Errorf("unsupported azure key vault signing algorithm: %s", algorithm)
+	}
+
+	keyURL, err := url.Parse(strings.TrimSuffix(keyID, "/"))
+	if err != nil || keyURL.Host == "" {
+		return nil, fmt.Errorf("invalid azure key vault key url: %s", keyID)
+	}
+
+	signer := &AzureSigner{
+		KeyURL:             keyURL.String(),
+		Algorithm:          algorithm,
+		TenantID:           os.Getenv("AZURE_TENANT_ID"),
+		ClientID:           os.Getenv("AZURE_CLIENT_ID"),
+		ClientSecret:       os.Getenv("AZURE_CLIENT_SECRET"),
+		FederatedTokenFile: os.Getenv("AZURE_FEDERATED_TOKEN_FILE"),
+		AuthorityHost:      os.Getenv("AZURE_AUTHORITY_HOST"),
+		httpClient:         trace.HTTPClient,
+	}
+	if credentials != nil {
+		signer.TenantID = string(credentials["tenantId"])
+		signer.ClientID = string(credentials["clientId"])
+		signer.ClientSecret = string(credentials["clientSecret"])
+		signer.FederatedTokenFile = ""
+	}
+	if signer.AuthorityHost == "" {
+		signer.AuthorityHost = azureAuthorityHost
+	}
+	if signer.TenantID == "" || signer.ClientID == "" || (signer.ClientSecret == "" && signer.FederatedTokenFile == "") {
+		return nil, fmt.Errorf("missing azure credentials")
+	}
+
+	return signer, nil
+}
+
+// AzureSigner signs with a key of Azure Key Vault.
+// Unless the key url pins a version, the current version of the key is the one Key Vault returns for the key, so
+// rotated keys are picked up as soon as the new version is created.
+type AzureSigner struct {
+	KeyURL             string
+	Algorithm          string
+	TenantID           string
+	ClientID           string
+	ClientSecret       string
+	FederatedTokenFile string
+	AuthorityHost      string
+
+	httpClient     *http.Client
+	token          string
+	tokenExpiresAt time.Time
+	mutex          sync.Mutex
+}
+
+func (s *AzureSigner) PublicKey(ctx context.Context) (string, crypto.PublicKey, error) {
+	resp := struct {
+		Key json.RawMessage `json:"key"`
+	}{}
+	if err := s.call(ctx, http.MethodGet, s.KeyURL, nil, &resp); err != nil {
+		return "", nil, err
+	}
+
+	// keys stored in HSMs are of type "EC-HSM" or "RSA-HSM"
+	jwk := map[string]interface{}{}
+	if err := json.Unmarshal(resp.Key, &jwk); err != nil {
+		return "", nil, err
+	}
+	kid, _ := jwk["kid"].(string)
+	if kty, ok := jwk["kty"].(string); ok {
+		jwk["kty"] = strings.TrimSuffix(kty, "-HSM")
+	}
+	delete(jwk, "key_ops")
+	encoded, _ := json.Marshal(jwk)
+	publicKey := jose.JSONWebKey{}
+	if err := publicKey.UnmarshalJSON(encoded); err != nil {
+		return "", nil, fmt.Errorf("invalid azure key vault key: %v", err)
+	}
+
+	return kid[strings.LastIndex(kid, "/")+1:], publicKey.Key, nil
+}
+
+func (s *AzureSigner) Sign(ctx context.Context, version string, digest []byte) ([]byte, error) {
+	req := map[string]string{
+		"alg":   s.Algorithm,
+		"value": base64.RawURLEncoding.EncodeToString(digest),
+	}
+	resp := struct {
+		Value string `json:"value"`
+	}{}
+	if err := s.call(ctx, http.MethodPost, s.versionURL(version)+"/sign", req, &resp); err != nil {
+		return nil, err
+	}
+
+	// key vault returns the signatures already in the format of the jws algorithms
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(resp.Value, "="))
+}
+
+func (s *AzureSigner) versionURL(version string) string {
+	if strings.HasSuffix(s.KeyURL, "/"+version) {
+		return s.KeyURL
+	}
+	return s.KeyURL + "/" + version
+}
+
+func (s *AzureSigner) call(ctx context.Context, method, endpoint string, in, out interface{}) error {
+	token, err := s.getToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	var body io.Reader
+	if in != nil {
+		encoded, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint+"?api-version="+azureKeyVaultAPIVersion, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	return s.do(req, out)
+}
+
+// getToken returns the current Azure AD access token to call Key Vault, requesting a new one if there is none or it
+// is about to expire
+func (s *AzureSigner) getToken(ctx context.Context) (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.token != "" && time.Now().Before(s.tokenExpiresAt) {
+		return s.token, nil
+	}
+
+	form := url.Values{
+		"grant_type": {"client_credentials"},
+		"client_id":  {s.ClientID},
+		"scope":      {azureKeyVaultScope},
+	}
+	if s.ClientSecret != "" {
+		form.Set("client_secret", s.ClientSecret)
+	} else {
+		assertion, err := os.ReadFile(s.FederatedTokenFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the azure federated token: %v", err)
+		}
+		form.Set("client_assertion_type", "urn:ietf:params:oauth:client-assertion-type:jwt-bearer")
+		form.Set("client_assertion", strings.TrimSpace(string(assertion)))
+	}
+
+	tokenURL := strings.TrimSuffix(s.AuthorityHost, "/") + "/" + url.PathEscape(s.TenantID) + "/oauth2/v2.0/token"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp := struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}{}
+	if err := s.do(req, &resp); err != nil {
+		return "", fmt.Errorf("failed to obtain an azure access token: %v", err)
+	}
+
+	s.token = resp.AccessToken
+	// renews the token one minute before it expires
+	s.tokenExpiresAt = time.Now().Add(time.Duration(resp.ExpiresIn-60) * time.Second)
+
+	return s.token, nil
+}
+
+func (s *AzureSigner) do(req *http.Request, out interface{}) error {
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		e := struct {
+			Error interface{} `json:"error"`
+		}{}
+		_ = json.Unmarshal(respBody, &e)
+		return fmt.Errorf("%s: %v", resp.Status, e.Error)
+	}
+
+	return json.Unmarshal(respBody, out)
+}
@@ -0,0 +1,181 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/kuadrant/authorino/pkg/trace"
+
+	gooauth2 "golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	gcpKMSEndpoint = "https://cloudkms.googleapis.com/v1/"
+	gcpKMSScope    = "https://www.googleapis.com/auth/cloudkms"
+)
+
+// NewGCPSigner returns a signer for an asymmetric signing key of Google Cloud KMS, identified by the resource name of
+// the key (projects/*/locations/*/keyRings/*/cryptoKeys/*) or of one of its versions (…/cryptoKeyVersions/*).
+// The credentials are read from the `credentials.json` entry (service account key or external account config), or
+// discovered from the environment (Application Default Credentials, e.g. GKE workload identity).
+func NewGCPSigner(ctx context.Context, keyID, algorithm string, credentials map[string][]byte) (*GCPSigner, error) {
+	size := hashSize(algorithm)
+	if size == "" || strings.HasPrefix(algorithm, "HS") {
+		return nil, fmt.Errorf("unsupported gcp kms signing algorithm: %s", algorithm)
+	}
+
+	var creds *google.Credentials
+	var err error
+	if credentials != nil {
+		creds, err = google.CredentialsFromJSON(ctx, credentials["credentials.json"], gcpKMSScope)
+	} else {
+		creds, err = google.FindDefaultCredentials(ctx, gcpKMSScope)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid gcp credentials: %v", err)
+	}
+
+	return &GCPSigner{
+		KeyID:       strings.Trim(keyID, "/"),
+		Algorithm:   algorithm,
+		Endpoint:    gcpKMSEndpoint,
+		TokenSource: creds.TokenSource,
+		httpClient:  trace.HTTPClient,
+	}, nil
+}
+
+// GCPSigner signs with an asymmetric signing key of Google Cloud KMS.
+// Unless the key id pins a version, the current version of the key is the enabled version with the highest number,
+// so new versions are picked up as soon as they are enabled.
+type GCPSigner struct {
+	KeyID       string
+	Algorithm   string
+	Endpoint    string
+	TokenSource gooauth2.TokenSource
+
+	httpClient *http.Client
+}
+
+func (s *GCPSigner) PublicKey(ctx context.Context) (string, crypto.PublicKey, error) {
+	name, err := s.currentVersionName(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	resp := struct {
+		PEM string `json:"pem"`
+	}{}
+	if err := s.call(ctx, http.MethodGet, name+"/publicKey", nil, &resp); err != nil {
+		return "", nil, err
+	}
+	publicKey, err := parsePublicKeyPEM([]byte(resp.PEM))
+	if err != nil {
+		return "", nil, err
+	}
+
+	return name[strings.LastIndex(name, "/")+1:], publicKey, nil
+}
+
+func (s *GCPSigner) Sign(ctx context.Context, version string, digest []byte) ([]byte, error) {
+	req := map[string]interface{}{
+		"digest": map[string][]byte{"sha" + hashSize(s.Algorithm): digest},
+	}
+	resp := struct {
+		Signature []byte `json:"signature"`
+	}{}
+	if err := s.call(ctx, http.MethodPost, s.versionName(version)+":asymmetricSign", req, &resp); err != nil {
+		return nil, err
+	}
+
+	return jwsSignature(resp.Signature, s.Algorithm)
+}
+
+func (s *GCPSigner) versionName(version string) string {
+	if strings.Contains(s.KeyID, "/cryptoKeyVersions/") {
+		return s.KeyID
+	}
+	return s.KeyID + "/cryptoKeyVersions/" + version
+}
+
+func (s *GCPSigner) currentVersionName(ctx context.Context) (string, error) {
+	if strings.Contains(s.KeyID, "/cryptoKeyVersions/") {
+		return s.KeyID, nil
+	}
+
+	resp := struct {
+		CryptoKeyVersions []struct {
+			Name string `json:"name"`
+		} `json:"cryptoKeyVersions"`
+	}{}
+	if err := s.call(ctx, http.MethodGet, s.KeyID+"/cryptoKeyVersions?filter=state%3DENABLED", nil, &resp); err != nil {
+		return "", err
+	}
+
+	var current string
+	var highest int
+	for _, version := range resp.CryptoKeyVersions {
+		number, err := strconv.Atoi(version.Name[strings.LastIndex(version.Name, "/")+1:])
+		if err == nil && number > highest {
+			highest = number
+			current = version.Name
+		}
+	}
+	if current == "" {
+		return "", fmt.Errorf("no enabled version of the gcp kms key %s", s.KeyID)
+	}
+	return current, nil
+}
+
+func (s *GCPSigner) call(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		encoded, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, s.Endpoint+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	token, err := s.TokenSource.Token()
+	if err != nil {
+		return fmt.Errorf("failed to obtain a gcp access token: %v", err)
+	}
+	token.SetAuthHeader(req)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		e := struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}{}
+		_ = json.Unmarshal(respBody, &e)
+		return fmt.Errorf("gcp kms request failed: %s: %s", resp.Status, e.Error.Message)
+	}
+
+	return json.Unmarshal(respBody, out)
+}
@@ -0,0 +1,88 @@
+package kms
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const (
+	ProviderAWS   = "aws"
+	ProviderGCP   = "gcp"
+	ProviderAzure = "azure"
+)
+
+// Signer signs with a key stored in a cloud key management service, without the private key ever leaving the service
+type Signer interface {
+	// PublicKey returns the current version of the key and its public key
+	PublicKey(ctx context.Context) (version string, publicKey crypto.PublicKey, err error)
+	// Sign signs the digest of a message with a given version of the key, returning the signature in the format of the
+	// JWS algorithm (i.e. r||s for ECDSA)
+	Sign(ctx context.Context, version string, digest []byte) ([]byte, error)
+}
+
+// NewSigner returns a signer for a key stored in a key management service.
+// The credentials are the entries of the Kubernetes secret referred in the config for the provider, if any; otherwise,
+// the credentials are discovered from the environment.
+func NewSigner(ctx context.Context, provider, keyID, region, algorithm string, credentials map[string][]byte) (Signer, error) {
+	if keyID == "" {
+		return nil, fmt.Errorf("missing kms key id")
+	}
+	switch provider {
+	case ProviderAWS:
+		return NewAWSSigner(keyID, region, algorithm, credentials)
+	case ProviderGCP:
+		return NewGCPSigner(ctx, keyID, algorithm, credentials)
+	case ProviderAzure:
+		return NewAzureSigner(keyID, algorithm, credentials)
+	default:
+		return nil, fmt.Errorf("unsupported kms provider: %s", provider)
+	}
+}
+
+// hashSize returns the size in bits of the hash function of a JWS algorithm, e.g. 256 for ES256
+func hashSize(algorithm string) string {
+	if len(algorithm) != 5 {
+		return ""
+	}
+	return algorithm[2:]
+}
+
+func parsePublicKeyPEM(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM public key")
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+// ecdsaSignatureFromDER converts an ECDSA signature encoded as ASN.1 DER, as returned by some key management services,
+// into the format of the JWS algorithms, i.e. the concatenation of r and s, each padded to the size of the curve
+func ecdsaSignatureFromDER(der []byte, algorithm string) ([]byte, error) {
+	var sig struct{ R, S *big.Int }
+	if rest, err := asn1.Unmarshal(der, &sig); err != nil || len(rest) > 0 || sig.R == nil || sig.S == nil {
+		return nil, fmt.Errorf("invalid ecdsa signature")
+	}
+
+	size := map[string]int{"ES256": 32, "ES384": 48, "ES512": 66}[algorithm]
+	if size == 0 || sig.R.BitLen() > size*8 || sig.S.BitLen() > size*8 {
+		return nil, fmt.Errorf("invalid ecdsa signature")
+	}
+	signature := make([]byte, 2*size)
+	sig.R.FillBytes(signature[:size])
+	sig.S.FillBytes(signature[size:])
+	return signature, nil
+}
+
+// jwsSignature converts a signature returned by a key management service into the format of the JWS algorithm
+func jwsSignature(signature []byte, algorithm string) ([]byte, error) {
+	if strings.HasPrefix(algorithm, "ES") {
+		return ecdsaSignatureFromDER(signature, algorithm)
+	}
+	return signature, nil
+}
@@ -0,0 +1,222 @@
+package kms
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+	jose "gopkg.in/square/go-jose.v2"
+	"gotest.tools/assert"
+)
+
+func newTestKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	return key
+}
+
+func verifyTestSignature(t *testing.T, key *ecdsa.PrivateKey, digest, signature []byte) {
+	assert.Equal(t, len(signature), 64)
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+	assert.Check(t, ecdsa.Verify(&key.PublicKey, digest, r, s))
+}
+
+func TestAWSSigner(t *testing.T) {
+	key := newTestKey(t)
+	publicKeyDER, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	keyARN := "arn:aws:kms:eu-west-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Check(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"))
+		assert.Check(t, strings.Contains(r.Header.Get("Authorization"), "/eu-west-1/kms/aws4_request, SignedHeaders=content-type;host;x-amz-date;x-amz-target, Signature="))
+
+		req := map[string]interface{}{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		switch r.Header.Get("X-Amz-Target") {
+		case "TrentService.GetPublicKey":
+			assert.Equal(t, req["KeyId"], "alias/authorino")
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"KeyId": keyARN, "PublicKey": publicKeyDER})
+		case "TrentService.Sign":
+			assert.Equal(t, req["KeyId"], keyARN)
+			assert.Equal(t, req["MessageType"], "DIGEST")
+			assert.Equal(t, req["SigningAlgorithm"], "ECDSA_SHA_256")
+			digest, _ := base64.StdEncoding.DecodeString(req["Message"].(string))
+			signature, _ := ecdsa.SignASN1(rand.Reader, key, digest)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"KeyId": keyARN, "Signature": signature})
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	signer, err := NewAWSSigner("alias/authorino", "eu-west-1", "ES256", map[string][]byte{"accessKeyId": []byte("AKID"), "secretAccessKey": []byte("secret")})
+	assert.NilError(t, err)
+	signer.Endpoint = server.URL
+
+	version, publicKey, err := signer.PublicKey(context.TODO())
+	assert.NilError(t, err)
+	assert.Equal(t, version, "1234abcd-12ab-34cd-56ef-1234567890ab")
+	assert.Check(t, key.PublicKey.Equal(publicKey))
+
+	digest := sha256.Sum256([]byte("header.claims"))
+	signature, err := signer.Sign(context.TODO(), version, digest[:])
+	assert.NilError(t, err)
+	verifyTestSignature(t, key, digest[:], signature)
+}
+
+func TestAWSSignerMissingCredentials(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	_, err := NewAWSSigner("alias/authorino", "eu-west-1", "ES256", nil)
+	assert.Error(t, err, "missing aws credentials")
+
+	_, err = NewAWSSigner("alias/authorino", "eu-west-1", "HS256", nil)
+	assert.Error(t, err, "unsupported aws kms signing algorithm: HS256")
+}
+
+func TestGCPSigner(t *testing.T) {
+	key := newTestKey(t)
+	publicKeyDER, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	publicKeyPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyDER})
+	keyName := "projects/p/locations/global/keyRings/authorino/cryptoKeys/wristband"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.Header.Get("Authorization"), "Bearer gcp-token")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/"+keyName+"/cryptoKeyVersions":
+			assert.Equal(t, r.URL.Query().Get("filter"), "state=ENABLED")
+			_, _ = w.Write([]byte(`{"cryptoKeyVersions":[{"name":"` + keyName + `/cryptoKeyVersions/2"},{"name":"` + keyName + `/cryptoKeyVersions/10"},{"name":"` + keyName + `/cryptoKeyVersions/3"}]}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/"+keyName+"/cryptoKeyVersions/10/publicKey":
+			_ = json.NewEncoder(w).Encode(map[string]string{"pem": string(publicKeyPEM)})
+		case r.Method == http.MethodPost && r.URL.Path == "/"+keyName+"/cryptoKeyVersions/10:asymmetricSign":
+			req := struct {
+				Digest struct {
+					SHA256 []byte `json:"sha256"`
+				} `json:"digest"`
+			}{}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			signature, _ := ecdsa.SignASN1(rand.Reader, key, req.Digest.SHA256)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"signature": signature})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	signer := &GCPSigner{
+		KeyID:       keyName,
+		Algorithm:   "ES256",
+		Endpoint:    server.URL + "/",
+		TokenSource: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "gcp-token"}),
+		httpClient:  http.DefaultClient,
+	}
+
+	version, publicKey, err := signer.PublicKey(context.TODO())
+	assert.NilError(t, err)
+	assert.Equal(t, version, "10")
+	assert.Check(t, key.PublicKey.Equal(publicKey))
+
+	digest := sha256.Sum256([]byte("header.claims"))
+	signature, err := signer.Sign(context.TODO(), version, digest[:])
+	assert.NilError(t, err)
+	verifyTestSignature(t, key, digest[:], signature)
+}
+
+func TestAzureSigner(t *testing.T) {
+	key := newTestKey(t)
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/tenant/oauth2/v2.0/token":
+			_ = r.ParseForm()
+			assert.Equal(t, r.PostForm.Get("client_id"), "client")
+			assert.Equal(t, r.PostForm.Get("client_secret"), "secret")
+			assert.Equal(t, r.PostForm.Get("scope"), "https://vault.azure.net/.default")
+			_, _ = w.Write([]byte(`{"access_token":"azure-token","expires_in":3600}`))
+		case r.URL.Path == "/keys/wristband":
+			assert.Equal(t, r.Header.Get("Authorization"), "Bearer azure-token")
+			assert.Equal(t, r.URL.Query().Get("api-version"), "7.4")
+			jwk, _ := jose.JSONWebKey{Key: &key.PublicKey}.MarshalJSON()
+			k := map[string]interface{}{}
+			_ = json.Unmarshal(jwk, &k)
+			k["kty"] = "EC-HSM"
+			k["kid"] = server.URL + "/keys/wristband/v2"
+			k["key_ops"] = []string{"sign", "verify"}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"key": k})
+		case r.URL.Path == "/keys/wristband/v2/sign":
+			req := map[string]string{}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			assert.Equal(t, req["alg"], "ES256")
+			digest, _ := base64.RawURLEncoding.DecodeString(req["value"])
+			sigR, sigS, _ := ecdsa.Sign(rand.Reader, key, digest)
+			signature := make([]byte, 64)
+			sigR.FillBytes(signature[:32])
+			sigS.FillBytes(signature[32:])
+			_ = json.NewEncoder(w).Encode(map[string]string{"kid": server.URL + "/keys/wristband/v2", "value": base64.RawURLEncoding.EncodeToString(signature)})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	signer, err := NewAzureSigner(server.URL+"/keys/wristband", "ES256", map[string][]byte{"tenantId": []byte("tenant"), "clientId": []byte("client"), "clientSecret": []byte("secret")})
+	assert.NilError(t, err)
+	signer.AuthorityHost = server.URL
+	signer.httpClient = http.DefaultClient
+
+	version, publicKey, err := signer.PublicKey(context.TODO())
+	assert.NilError(t, err)
+	assert.Equal(t, version, "v2")
+	assert.Check(t, key.PublicKey.Equal(publicKey))
+
+	digest := sha256.Sum256([]byte("header.claims"))
+	signature, err := signer.Sign(context.TODO(), version, digest[:])
+	assert.NilError(t, err)
+	verifyTestSignature(t, key, digest[:], signature)
+}
+
+func TestNewSignerUnsupportedProvider(t *testing.T) {
+	_, err := NewSigner(context.TODO(), "ibm", "key", "", "ES256", nil)
+	assert.Error(t, err, "unsupported kms provider: ibm")
+
+	_, err = NewSigner(context.TODO(), ProviderAWS, "", "", "ES256", nil)
+	assert.Error(t, err, "missing kms key id")
+}
+
+func TestEcdsaSignatureFromDER(t *testing.T) {
+	key := newTestKey(t)
+	digest := sha256.Sum256([]byte("header.claims"))
+	der, _ := ecdsa.SignASN1(rand.Reader, key, digest[:])
+
+	signature, err := ecdsaSignatureFromDER(der, "ES256")
+	assert.NilError(t, err)
+	verifyTestSignature(t, key, digest[:], signature)
+
+	_, err = ecdsaSignatureFromDER([]byte("invalid"), "ES256")
+	assert.Error(t, err, "invalid ecdsa signature")
+}
+
+func TestSignAWSRequest(t *testing.T) {
+	// example of the aws docs
+	req, _ := http.NewRequest(http.MethodGet, "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08", nil)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	now, _ := time.Parse("20060102T150405Z", "20150830T123600Z")
+	signAWSRequest(req, nil, AWSCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}, "us-east-1", "iam", now)
+	assert.Equal(t, req.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, SignedHeaders=content-type;host;x-amz-date, Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7")
+}
@@ -3,8 +3,10 @@ package log
 import (
 	"context"
 	"strings"
+	"sync"
 
 	"github.com/go-logr/logr"
+	uberzap "go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"k8s.io/klog/v2"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -20,6 +22,16 @@ var (
 	// until `SetLogger` is called.
 	// This is also useful for mocking the default logger tests.
 	Log Logger = ctrl.Log
+
+	// level is the min level of the loggers created with `NewLogger`, which can be changed on the fly with `SetLevel`
+	level = uberzap.NewAtomicLevel()
+
+	// options of the last logger created with `NewLogger`; nil if no logger was created with `NewLogger`
+	baseOpts *Options
+
+	// leveledLoggers are the loggers created with `WithLevel`, by level
+	leveledLoggers   = map[LogLevel]Logger{}
+	leveledLoggersMu sync.Mutex
 )
 
 type Logger = logr.Logger
@@ -37,6 +49,15 @@ func ToLogLevel(level string) LogLevel {
 	return LogLevel(l)
 }
 
+// ParseLogLevel converts a string to a log level, failing if the string is not a known log level.
+func ParseLogLevel(level string) (LogLevel, error) {
+	var l zapcore.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return LogLevel(l), err
+	}
+	return LogLevel(l), nil
+}
+
 // LogMode defines the log output mode.
 type LogMode int8
 
@@ -76,6 +97,8 @@ func ToLogMode(mode string) LogMode {
 type Options struct {
 	Level LogLevel
 	Mode  LogMode
+	// Name of the logger, if not empty
+	Name string
 }
 
 // SetLogger sets up a logger.
@@ -123,9 +146,55 @@ func FromContext(ctx context.Context, keysAndValues ...interface{}) Logger {
 // NewLogger returns a new logger with the given options.
 // `logger` param is the actual logger implementation; when omitted, a new
 // logger based on sigs.k8s.io/controller-runtime/pkg/log/zap is created.
+// All the loggers created with NewLogger share the same min level, the one of
+// the last logger created, or the one set with `SetLevel` afterwards.
 func NewLogger(opts Options) Logger {
-	return zap.New(
-		zap.Level(zapcore.Level(opts.Level)),
+	level.SetLevel(zapcore.Level(opts.Level))
+
+	leveledLoggersMu.Lock()
+	baseOpts = &opts
+	leveledLoggers = map[LogLevel]Logger{}
+	leveledLoggersMu.Unlock()
+
+	return newLogger(level, opts)
+}
+
+func newLogger(level zapcore.LevelEnabler, opts Options) Logger {
+	logger := zap.New(
+		zap.Level(level),
 		zap.UseDevMode(opts.Mode == LogModeDev),
 	)
+	if opts.Name != "" {
+		logger = logger.WithName(opts.Name)
+	}
+	return logger
+}
+
+// SetLevel changes the min level of the loggers created with `NewLogger`, on the fly.
+func SetLevel(l LogLevel) {
+	level.SetLevel(zapcore.Level(l))
+}
+
+// Level returns the current min level of the loggers created with `NewLogger`.
+func Level() LogLevel {
+	return LogLevel(level.Level())
+}
+
+// WithLevel returns a logger with the mode and name of the loggers created with `NewLogger`, but locked to a min level of its own,
+// regardless of the level set with `SetLevel`. It allows to change the verbosity of the logs of a part of the system
+// only (e.g. the auth pipelines of a given AuthConfig).
+// It returns the singleton logger if no logger was created with `NewLogger`.
+func WithLevel(l LogLevel) Logger {
+	leveledLoggersMu.Lock()
+	defer leveledLoggersMu.Unlock()
+
+	if baseOpts == nil {
+		return Log
+	}
+	if logger, ok := leveledLoggers[l]; ok {
+		return logger
+	}
+	logger := newLogger(zapcore.Level(l), *baseOpts)
+	leveledLoggers[l] = logger
+	return logger
 }
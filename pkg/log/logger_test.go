@@ -40,6 +40,41 @@ func TestToLogLevel(t *testing.T) {
 	assert.Equal(t, int(ToLogLevel("invalid")), 0) // falls back to default log level (info) without panicking
 }
 
+func TestParseLogLevel(t *testing.T) {
+	level, err := ParseLogLevel("debug")
+	assert.NilError(t, err)
+	assert.Equal(t, int(level), -1)
+
+	_, err = ParseLogLevel("invalid")
+	assert.Check(t, err != nil)
+}
+
+func TestSetLevel(t *testing.T) {
+	logger := NewLogger(Options{Level: ToLogLevel("info")})
+	assert.Check(t, !logger.V(1).Enabled())
+
+	SetLevel(ToLogLevel("debug"))
+	assert.Equal(t, int(Level()), -1)
+	assert.Check(t, logger.V(1).Enabled())
+
+	SetLevel(ToLogLevel("error"))
+	assert.Check(t, !logger.Enabled())
+}
+
+func TestWithLevel(t *testing.T) {
+	logger := NewLogger(Options{Level: ToLogLevel("info")})
+	assert.Check(t, !logger.V(1).Enabled())
+
+	debugLogger := WithLevel(ToLogLevel("debug"))
+	assert.Check(t, debugLogger.V(1).Enabled())
+	assert.Check(t, !logger.V(1).Enabled())
+
+	SetLevel(ToLogLevel("error"))
+	assert.Check(t, debugLogger.V(1).Enabled()) // not affected by the global level
+	assert.Check(t, !WithLevel(ToLogLevel("info")).V(1).Enabled())
+	assert.Check(t, WithLevel(ToLogLevel("info")).Enabled())
+}
+
 func TestLogModeToString(t *testing.T) {
 	level := LogMode(0)
 	assert.Equal(t, level.String(), "production")
@@ -39,6 +39,16 @@ func NewDurationMetric(name, help string, labels ...string) *prometheus.Histogra
 	)
 }
 
+func NewGaugeMetric(name, help string, labels ...string) *prometheus.GaugeVec {
+	return prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: name,
+			Help: help,
+		},
+		labels,
+	)
+}
+
 func ReportMetric(metric *prometheus.CounterVec, labels ...string) {
 	metric.WithLabelValues(labels...).Inc()
 }
@@ -5,6 +5,8 @@ import (
 	"net/url"
 	"sync"
 
+	"github.com/kuadrant/authorino/pkg/trace"
+
 	gooauth2 "golang.org/x/oauth2"
 	gooauth2clientcredentials "golang.org/x/oauth2/clientcredentials"
 )
@@ -40,7 +42,8 @@ func (c *ClientCredentials) ClientCredentialsToken(ctx context.Context, force bo
 	}
 	c.mu.RUnlock()
 
-	token, err := c.Token(ctx)
+	// the token request is traced along with the request that requires the token
+	token, err := c.Token(context.WithValue(ctx, gooauth2.HTTPClient, trace.HTTPClient))
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,116 @@
+// Package rar parses the authorization details of OAuth 2.0 Rich Authorization Requests (RFC 9396) granted to the
+// access tokens.
+package rar
+
+import (
+	gojson "encoding/json"
+)
+
+// AuthorizationDetailsClaim is the claim of the access tokens (or token introspection responses) that holds the
+// authorization details granted to the client
+const AuthorizationDetailsClaim = "authorization_details"
+
+// AuthorizationDetail is one of the authorization details granted to an access token.
+// The fields common to all types of authorization details are typed; the fields specific to the type are kept as is.
+type AuthorizationDetail struct {
+	Type       string                 `json:"type"`
+	Locations  []string               `json:"locations,omitempty"`
+	Actions    []string               `json:"actions,omitempty"`
+	Datatypes  []string               `json:"datatypes,omitempty"`
+	Identifier string                 `json:"identifier,omitempty"`
+	Privileges []string               `json:"privileges,omitempty"`
+	Fields     map[string]interface{} `json:"-"`
+}
+
+// MarshalJSON marshals the authorization detail with the fields specific to the type alongside the common ones
+func (d AuthorizationDetail) MarshalJSON() ([]byte, error) {
+	obj := make(map[string]interface{}, len(d.Fields)+6)
+	for name, value := range d.Fields {
+		obj[name] = value
+	}
+	obj["type"] = d.Type
+	setIfNotEmpty(obj, "locations", d.Locations)
+	setIfNotEmpty(obj, "actions", d.Actions)
+	setIfNotEmpty(obj, "datatypes", d.Datatypes)
+	if d.Identifier != "" {
+		obj["identifier"] = d.Identifier
+	}
+	setIfNotEmpty(obj, "privileges", d.Privileges)
+	return gojson.Marshal(obj)
+}
+
+// FromIdentity returns the authorization details granted to an identity object resolved out of an access token.
+// The claim can be a JSON array or a string that encodes the JSON array. Details without a type are ignored.
+func FromIdentity(identity interface{}) []AuthorizationDetail {
+	claims, ok := identity.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	var items []interface{}
+	switch claim := claims[AuthorizationDetailsClaim].(type) {
+	case []interface{}:
+		items = claim
+	case string:
+		if err := gojson.Unmarshal([]byte(claim), &items); err != nil {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	details := make([]AuthorizationDetail, 0, len(items))
+	for _, item := range items {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		detail := AuthorizationDetail{Fields: make(map[string]interface{})}
+		for name, value := range obj {
+			switch name {
+			case "type":
+				detail.Type, _ = value.(string)
+			case "locations":
+				detail.Locations = stringList(value)
+			case "actions":
+				detail.Actions = stringList(value)
+			case "datatypes":
+				detail.Datatypes = stringList(value)
+			case "identifier":
+				detail.Identifier, _ = value.(string)
+			case "privileges":
+				detail.Privileges = stringList(value)
+			default:
+				detail.Fields[name] = value
+			}
+		}
+		if detail.Type == "" {
+			continue
+		}
+		details = append(details, detail)
+	}
+	return details
+}
+
+func stringList(value interface{}) []string {
+	items, ok := value.([]interface{})
+	if !ok {
+		if s, ok := value.(string); ok {
+			return []string{s}
+		}
+		return nil
+	}
+	list := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+func setIfNotEmpty(obj map[string]interface{}, name string, list []string) {
+	if len(list) > 0 {
+		obj[name] = list
+	}
+}
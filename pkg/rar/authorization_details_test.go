@@ -0,0 +1,72 @@
+package rar
+
+import (
+	gojson "encoding/json"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestFromIdentity(t *testing.T) {
+	var identity interface{}
+	_ = gojson.Unmarshal([]byte(`{
+		"sub": "john",
+		"authorization_details": [
+			{
+				"type": "payment_initiation",
+				"actions": ["initiate", "status"],
+				"locations": ["https://example.com/payments"],
+				"instructedAmount": {"currency": "EUR", "amount": "123.50"}
+			},
+			{
+				"type": "account_information",
+				"actions": "list_accounts",
+				"identifier": "DE89370400440532013000",
+				"privileges": ["read"]
+			},
+			{
+				"actions": ["missing-type"]
+			},
+			"invalid"
+		]
+	}`), &identity)
+
+	details := FromIdentity(identity)
+	assert.Equal(t, len(details), 2)
+
+	payment := details[0]
+	assert.Equal(t, payment.Type, "payment_initiation")
+	assert.DeepEqual(t, payment.Actions, []string{"initiate", "status"})
+	assert.DeepEqual(t, payment.Locations, []string{"https://example.com/payments"})
+	assert.DeepEqual(t, payment.Fields, map[string]interface{}{"instructedAmount": map[string]interface{}{"currency": "EUR", "amount": "123.50"}})
+
+	account := details[1]
+	assert.Equal(t, account.Type, "account_information")
+	assert.DeepEqual(t, account.Actions, []string{"list_accounts"})
+	assert.Equal(t, account.Identifier, "DE89370400440532013000")
+	assert.DeepEqual(t, account.Privileges, []string{"read"})
+}
+
+func TestFromIdentityEncodedClaim(t *testing.T) {
+	details := FromIdentity(map[string]interface{}{
+		"authorization_details": `[{"type":"payment_initiation","actions":["initiate"]}]`,
+	})
+	assert.Equal(t, len(details), 1)
+	assert.Equal(t, details[0].Type, "payment_initiation")
+	assert.DeepEqual(t, details[0].Actions, []string{"initiate"})
+
+	assert.Equal(t, len(FromIdentity(map[string]interface{}{"authorization_details": "not json"})), 0)
+	assert.Equal(t, len(FromIdentity(map[string]interface{}{"sub": "john"})), 0)
+	assert.Equal(t, len(FromIdentity("john")), 0)
+}
+
+func TestAuthorizationDetailMarshalJSON(t *testing.T) {
+	detail := AuthorizationDetail{
+		Type:    "payment_initiation",
+		Actions: []string{"initiate"},
+		Fields:  map[string]interface{}{"creditorName": "Merchant A", "type": "overridden"},
+	}
+	encoded, err := gojson.Marshal(detail)
+	assert.NilError(t, err)
+	assert.Equal(t, string(encoded), `{"actions":["initiate"],"creditorName":"Merchant A","type":"payment_initiation"}`)
+}
@@ -0,0 +1,532 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	gojson "encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"strconv"
+	"strings"
+
+	"github.com/kuadrant/authorino/api/v1beta1"
+	"github.com/kuadrant/authorino/api/v1beta2"
+	"github.com/kuadrant/authorino/pkg/auth"
+	"github.com/kuadrant/authorino/pkg/evaluators"
+	"github.com/kuadrant/authorino/pkg/index"
+	"github.com/kuadrant/authorino/pkg/log"
+	"github.com/kuadrant/authorino/pkg/trace"
+
+	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"github.com/go-logr/logr"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	AdminBasePath       = "/admin/"
+	adminCachePurgePath = AdminBasePath + "cache/purge"
+	adminIndexPath      = AdminBasePath + "index"
+	adminExportPath     = AdminBasePath + "authconfigs/export"
+	adminDryRunPath     = AdminBasePath + "authconfigs/dry-run"
+	adminCheckPath      = AdminBasePath + "check"
+	adminSettingsPath   = AdminBasePath + "settings"
+	adminReloadPath     = AdminBasePath + "settings/reload"
+	adminProfilingPath  = AdminBasePath + "debug/pprof/"
+	adminCircuitsPath   = AdminBasePath + "circuit-breakers"
+
+	maxDryRunRequestBodySize = 1 << 20 // 1 MiB
+
+	// KubernetesTokenHeader is the header of the requests to the admin server that carries the Kubernetes token of the
+	// caller, for the operations performed on its behalf
+	KubernetesTokenHeader = "X-Kubernetes-Token"
+)
+
+// ReconciledAuthConfigs gives access to the effective configs of the reconciled AuthConfigs and dry-runs the
+// reconciliation of candidate ones on behalf of callers identified by their Kubernetes tokens
+type ReconciledAuthConfigs interface {
+	EffectiveAuthConfig(resourceId string) (*v1beta2.AuthConfig, bool)
+	DryRun(ctx context.Context, authConfig *v1beta1.AuthConfig, callerToken string) (*v1beta2.AuthConfig, map[string]string, error)
+}
+
+// AdminService implements an HTTP server for administrative operations on the AuthConfigs loaded in the index
+type AdminService struct {
+	Index index.Index
+	// Source of the effective configs to export and reconciler of the candidate configs to dry-run, if not nil
+	AuthConfigs ReconciledAuthConfigs
+	// Reloader of the runtime settings of the auth server, if not nil
+	Settings *RuntimeSettingsReloader
+	// Token required in the Authorization header of the requests (Bearer), if not empty
+	Token string
+	// Whether to serve the runtime profiling data of the server (net/http/pprof) to the clients on the loopback
+	// interface
+	Profiling bool
+}
+
+// IndexedAuthConfig describes an AuthConfig loaded in the index
+type IndexedAuthConfig struct {
+	Id         string         `json:"id"`
+	Namespace  string         `json:"namespace"`
+	Name       string         `json:"name"`
+	Cluster    string         `json:"cluster,omitempty"`
+	Generation int64          `json:"generation"`
+	Hosts      []string       `json:"hosts"`
+	Evaluators map[string]int `json:"evaluators"`
+}
+
+// DryRunResult is the outcome of the dry-run reconciliation of a candidate AuthConfig
+type DryRunResult struct {
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+	// Effective config, i.e. the candidate as defaulted by the API server and with the overlays applied
+	AuthConfig *v1beta2.AuthConfig `json:"authconfig,omitempty"`
+	// Hosts that would not be linked to the candidate, mapped to the id of the AuthConfig that has taken them
+	HostsNotLinked map[string]string `json:"hostsNotLinked,omitempty"`
+}
+
+// CheckRequestDescription is a simplified description of an HTTP request to evaluate with the admin server, as an
+// alternative to the Envoy CheckRequest
+type CheckRequestDescription struct {
+	Host    string            `json:"host"`
+	Method  string            `json:"method,omitempty"`
+	Path    string            `json:"path,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+	// Context extensions, as set in the Envoy external authorization filter
+	ContextExtensions map[string]string `json:"contextExtensions,omitempty"`
+}
+
+// CheckResult is the outcome of the evaluation of a request by the Auth Pipeline
+type CheckResult struct {
+	// Id of the AuthConfig linked to the host of the request, in the format <namespace>/<name>
+	AuthConfig string `json:"authconfig"`
+	Authorized bool   `json:"authorized"`
+	Code       string `json:"code"`
+	Status     int    `json:"status"`
+	// Full result of the Auth Pipeline (headers, metadata, body, etc)
+	Result auth.AuthResult `json:"result"`
+	// Authorization JSON at the end of the pipeline
+	AuthorizationJSON interface{} `json:"authorizationJSON,omitempty"`
+}
+
+func (a *AdminService) ServeHTTP(writer http.ResponseWriter, req *http.Request) {
+	requestLogger := log.WithName("service").WithName("admin").WithValues("method", req.Method, "uri", req.URL.String())
+	requestLogger.Info("request received")
+
+	if !a.authenticated(req) {
+		a.respond(writer, http.StatusUnauthorized, map[string]interface{}{"error": "unauthorized"}, requestLogger)
+		return
+	}
+
+	if strings.HasPrefix(req.URL.Path, adminProfilingPath) {
+		a.profile(writer, req, requestLogger)
+		return
+	}
+
+	switch req.URL.Path {
+	case adminIndexPath:
+		a.listIndex(writer, req, requestLogger)
+	case adminCachePurgePath:
+		a.purgeCache(writer, req, requestLogger)
+	case adminExportPath:
+		a.exportAuthConfig(writer, req, requestLogger)
+	case adminDryRunPath:
+		a.dryRunAuthConfig(writer, req, requestLogger)
+	case adminCheckPath:
+		a.check(writer, req, requestLogger)
+	case adminSettingsPath:
+		a.getSettings(writer, req, requestLogger)
+	case adminReloadPath:
+		a.reloadSettings(writer, req, requestLogger)
+	case adminCircuitsPath:
+		a.listCircuitBreakers(writer, req, requestLogger)
+	default:
+		a.respond(writer, http.StatusNotFound, map[string]interface{}{"error": "not found"}, requestLogger)
+	}
+}
+
+// purgeCache deletes the cached results of the evaluators of one AuthConfig (query param `authconfig`, in the format
+// <namespace>/<name>) or of all AuthConfigs in the index, optionally limited to the cache keys that start with a given
+// prefix (query param `prefix`)
+func (a *AdminService) purgeCache(writer http.ResponseWriter, req *http.Request, logger logr.Logger) {
+	if req.Method != http.MethodPost {
+		a.respond(writer, http.StatusMethodNotAllowed, map[string]interface{}{"error": "method not allowed"}, logger)
+		return
+	}
+
+	query := req.URL.Query()
+	authConfigId := query.Get("authconfig")
+	prefix := query.Get("prefix")
+
+	var authConfigs []*evaluators.AuthConfig
+	if authConfigId != "" {
+		hosts := a.Index.FindKeys(authConfigId)
+		if len(hosts) == 0 {
+			a.respond(writer, http.StatusNotFound, map[string]interface{}{"error": fmt.Sprintf("authconfig %s not found", authConfigId)}, logger)
+			return
+		}
+		// no need to purge for all the hosts as the evaluators are the same
+		authConfigs = append(authConfigs, a.Index.Get(hosts[0]))
+	} else {
+		authConfigs = a.Index.List()
+	}
+
+	purged := 0
+	for _, authConfig := range authConfigs {
+		if authConfig == nil {
+			continue
+		}
+		n, err := authConfig.PurgeCache(prefix)
+		purged += n
+		if err != nil {
+			a.respond(writer, http.StatusInternalServerError, map[string]interface{}{"error": err.Error(), "purged": purged}, logger)
+			return
+		}
+	}
+
+	logger.Info("evaluator cache purged", "authconfig", authConfigId, "prefix", prefix, "purged", purged)
+	a.respond(writer, http.StatusOK, map[string]interface{}{"purged": purged}, logger)
+}
+
+// listIndex describes the AuthConfigs loaded in the index: the hosts linked to each AuthConfig, the generation of the
+// resource and the number of evaluators per phase of the auth pipeline
+func (a *AdminService) listIndex(writer http.ResponseWriter, req *http.Request, logger logr.Logger) {
+	if req.Method != http.MethodGet {
+		a.respond(writer, http.StatusMethodNotAllowed, map[string]interface{}{"error": "method not allowed"}, logger)
+		return
+	}
+
+	authConfigs := []IndexedAuthConfig{}
+	for _, id := range a.Index.ListIds() {
+		hosts := a.Index.FindKeys(id)
+		if len(hosts) == 0 {
+			continue
+		}
+		// no need to check all the hosts as the config should be the same
+		authConfig := a.Index.Get(hosts[0])
+		if authConfig == nil {
+			continue
+		}
+		generation, _ := strconv.ParseInt(authConfig.Labels["generation"], 10, 64)
+		authConfigs = append(authConfigs, IndexedAuthConfig{
+			Id:         id,
+			Namespace:  authConfig.Labels["namespace"],
+			Name:       authConfig.Labels["name"],
+			Cluster:    authConfig.Labels["cluster"],
+			Generation: generation,
+			Hosts:      hosts,
+			Evaluators: map[string]int{
+				"identity":      len(authConfig.IdentityConfigs),
+				"metadata":      len(authConfig.MetadataConfigs),
+				"authorization": len(authConfig.AuthorizationConfigs),
+				"response":      len(authConfig.ResponseConfigs),
+				"callbacks":     len(authConfig.CallbackConfigs),
+			},
+		})
+	}
+
+	a.respond(writer, http.StatusOK, map[string]interface{}{"authconfigs": authConfigs}, logger)
+}
+
+// listCircuitBreakers describes the state of the circuit breakers of the external endpoints called by the evaluators
+func (a *AdminService) listCircuitBreakers(writer http.ResponseWriter, req *http.Request, logger logr.Logger) {
+	if req.Method != http.MethodGet {
+		a.respond(writer, http.StatusMethodNotAllowed, map[string]interface{}{"error": "method not allowed"}, logger)
+		return
+	}
+
+	circuitBreakers := trace.CircuitBreakers()
+	if circuitBreakers == nil {
+		a.respond(writer, http.StatusOK, map[string]interface{}{"enabled": false, "circuitBreakers": []trace.CircuitBreakerStatus{}}, logger)
+		return
+	}
+	a.respond(writer, http.StatusOK, map[string]interface{}{"enabled": true, "circuitBreakers": circuitBreakers}, logger)
+}
+
+// exportAuthConfig responds the effective config linked to a host (query param `host`), i.e. the AuthConfig as
+// defaulted by the API server and with the overlays applied, in the format of the v1beta2 api
+func (a *AdminService) exportAuthConfig(writer http.ResponseWriter, req *http.Request, logger logr.Logger) {
+	if req.Method != http.MethodGet {
+		a.respond(writer, http.StatusMethodNotAllowed, map[string]interface{}{"error": "method not allowed"}, logger)
+		return
+	}
+	if a.AuthConfigs == nil {
+		a.respond(writer, http.StatusNotImplemented, map[string]interface{}{"error": "export not available"}, logger)
+		return
+	}
+
+	host := req.URL.Query().Get("host")
+	if host == "" {
+		a.respond(writer, http.StatusBadRequest, map[string]interface{}{"error": "missing host"}, logger)
+		return
+	}
+	id, found := a.Index.FindId(host)
+	if !found {
+		a.respond(writer, http.StatusNotFound, map[string]interface{}{"error": fmt.Sprintf("no authconfig found for host %s", host)}, logger)
+		return
+	}
+	authConfig, found := a.AuthConfigs.EffectiveAuthConfig(id)
+	if !found {
+		a.respond(writer, http.StatusNotFound, map[string]interface{}{"error": fmt.Sprintf("effective config of authconfig %s not available", id)}, logger)
+		return
+	}
+
+	a.respond(writer, http.StatusOK, authConfig, logger)
+}
+
+// dryRunAuthConfig reconciles the candidate AuthConfig in the body of the request (JSON, authorino.kuadrant.io/v1beta1
+// or v1beta2) without changing either the cluster or the index, and responds whether the candidate is valid, its
+// effective config and the hosts that would not be linked to it
+func (a *AdminService) dryRunAuthConfig(writer http.ResponseWriter, req *http.Request, logger logr.Logger) {
+	if req.Method != http.MethodPost {
+		a.respond(writer, http.StatusMethodNotAllowed, map[string]interface{}{"error": "method not allowed"}, logger)
+		return
+	}
+	if a.AuthConfigs == nil {
+		a.respond(writer, http.StatusNotImplemented, map[string]interface{}{"error": "dry-run not available"}, logger)
+		return
+	}
+	// dry-runs make the server fetch secrets and reach external endpoints, thus never without a token
+	if a.Token == "" {
+		a.respond(writer, http.StatusForbidden, map[string]interface{}{"error": "dry-run requires an admin server token"}, logger)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxDryRunRequestBodySize))
+	if err != nil {
+		a.respond(writer, http.StatusBadRequest, map[string]interface{}{"error": err.Error()}, logger)
+		return
+	}
+	candidate, err := decodeAuthConfig(body)
+	if err != nil {
+		a.respond(writer, http.StatusBadRequest, map[string]interface{}{"error": err.Error()}, logger)
+		return
+	}
+
+	authConfig, looseHosts, err := a.AuthConfigs.DryRun(req.Context(), candidate, req.Header.Get(KubernetesTokenHeader))
+	if k8s_errors.IsUnauthorized(err) || k8s_errors.IsForbidden(err) {
+		a.respond(writer, http.StatusForbidden, map[string]interface{}{"error": err.Error()}, logger)
+		return
+	}
+	if err != nil {
+		a.respond(writer, http.StatusUnprocessableEntity, DryRunResult{Valid: false, Error: err.Error()}, logger)
+		return
+	}
+
+	a.respond(writer, http.StatusOK, DryRunResult{Valid: true, AuthConfig: authConfig, HostsNotLinked: looseHosts}, logger)
+}
+
+// check evaluates the request described in the body of the request (JSON, see CheckRequestDescription) with the
+// AuthConfig linked to its host, and responds the full result of the Auth Pipeline, including the authorization JSON.
+// The evaluators run for real, i.e. calls to external services (metadata, callbacks, etc) are sent as well.
+func (a *AdminService) check(writer http.ResponseWriter, req *http.Request, logger logr.Logger) {
+	if req.Method != http.MethodPost {
+		a.respond(writer, http.StatusMethodNotAllowed, map[string]interface{}{"error": "method not allowed"}, logger)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxDryRunRequestBodySize))
+	if err != nil {
+		a.respond(writer, http.StatusBadRequest, map[string]interface{}{"error": err.Error()}, logger)
+		return
+	}
+	var description CheckRequestDescription
+	if err := gojson.Unmarshal(body, &description); err != nil {
+		a.respond(writer, http.StatusBadRequest, map[string]interface{}{"error": err.Error()}, logger)
+		return
+	}
+	checkRequest := description.checkRequest()
+
+	host := checkRequest.Attributes.Request.Http.Host
+	if h, overridden := description.ContextExtensions[X_LOOKUP_KEY_NAME]; overridden {
+		host = h
+	}
+	if host == "" {
+		a.respond(writer, http.StatusBadRequest, map[string]interface{}{"error": "missing host"}, logger)
+		return
+	}
+	authConfig := a.Index.Get(host)
+	if authConfig == nil && strings.Contains(host, ":") {
+		authConfig = a.Index.Get(strings.Split(host, ":")[0])
+	}
+	if authConfig == nil {
+		a.respond(writer, http.StatusNotFound, map[string]interface{}{"error": fmt.Sprintf("no authconfig found for host %s", host)}, logger)
+		return
+	}
+	authConfig, _ = authConfig.Version(checkRequest.Attributes.Request.Http.Id)
+
+	pipeline := NewAuthPipeline(log.IntoContext(req.Context(), logger), checkRequest, *authConfig)
+	result := pipeline.Evaluate()
+
+	status := result.Status
+	if status == 0 {
+		status = statusCodeMapping[result.Code]
+	}
+	var authJSON interface{}
+	_ = gojson.Unmarshal([]byte(pipeline.GetAuthorizationJSON()), &authJSON)
+
+	a.respond(writer, http.StatusOK, CheckResult{
+		AuthConfig:        authConfig.Labels["namespace"] + "/" + authConfig.Labels["name"],
+		Authorized:        result.Success(),
+		Code:              result.Code.String(),
+		Status:            int(status),
+		Result:            result,
+		AuthorizationJSON: authJSON,
+	}, logger)
+}
+
+func (d CheckRequestDescription) checkRequest() *envoy_auth.CheckRequest {
+	method := d.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	path := d.Path
+	if path == "" {
+		path = "/"
+	}
+	headers := make(map[string]string, len(d.Headers))
+	for key, value := range d.Headers {
+		headers[strings.ToLower(key)] = value
+	}
+
+	return &envoy_auth.CheckRequest{
+		Attributes: &envoy_auth.AttributeContext{
+			Request: &envoy_auth.AttributeContext_Request{
+				Http: &envoy_auth.AttributeContext_HttpRequest{
+					Id:      ensureRequestId(headers[strings.ToLower(ENVOY_TRACE_REQUEST_ID_HEADER)]),
+					Method:  method,
+					Headers: headers,
+					Path:    path,
+					Host:    d.Host,
+					Body:    d.Body,
+				},
+			},
+			ContextExtensions: d.ContextExtensions,
+		},
+	}
+}
+
+// getSettings responds the runtime settings in effect
+func (a *AdminService) getSettings(writer http.ResponseWriter, req *http.Request, logger logr.Logger) {
+	if req.Method != http.MethodGet {
+		a.respond(writer, http.StatusMethodNotAllowed, map[string]interface{}{"error": "method not allowed"}, logger)
+		return
+	}
+	if a.Settings == nil {
+		a.respond(writer, http.StatusNotImplemented, map[string]interface{}{"error": "runtime settings not available"}, logger)
+		return
+	}
+
+	a.respond(writer, http.StatusOK, a.Settings.Current(), logger)
+}
+
+// reloadSettings reloads the runtime settings from the file of environment variables and responds the settings in
+// effect afterwards
+func (a *AdminService) reloadSettings(writer http.ResponseWriter, req *http.Request, logger logr.Logger) {
+	if req.Method != http.MethodPost {
+		a.respond(writer, http.StatusMethodNotAllowed, map[string]interface{}{"error": "method not allowed"}, logger)
+		return
+	}
+	if a.Settings == nil {
+		a.respond(writer, http.StatusNotImplemented, map[string]interface{}{"error": "runtime settings not available"}, logger)
+		return
+	}
+
+	settings, err := a.Settings.Reload()
+	if err != nil {
+		a.respond(writer, http.StatusUnprocessableEntity, map[string]interface{}{"error": err.Error(), "settings": settings}, logger)
+		return
+	}
+
+	a.respond(writer, http.StatusOK, settings, logger)
+}
+
+// profile serves the runtime profiling data of the server in the format expected by the pprof visualization tool,
+// including on-demand CPU profiles (`profile?seconds=N`), execution traces (`trace?seconds=N`) and snapshots of the
+// heap and other runtime profiles (e.g. `heap`, `goroutine`, `allocs`)
+func (a *AdminService) profile(writer http.ResponseWriter, req *http.Request, logger logr.Logger) {
+	if !a.Profiling || !isLoopback(req.RemoteAddr) {
+		a.respond(writer, http.StatusNotFound, map[string]interface{}{"error": "not found"}, logger)
+		return
+	}
+
+	switch name := strings.TrimPrefix(req.URL.Path, adminProfilingPath); name {
+	case "":
+		pprof.Index(writer, req)
+	case "cmdline":
+		pprof.Cmdline(writer, req)
+	case "profile":
+		pprof.Profile(writer, req)
+	case "symbol":
+		pprof.Symbol(writer, req)
+	case "trace":
+		pprof.Trace(writer, req)
+	default:
+		pprof.Handler(name).ServeHTTP(writer, req)
+	}
+	logger.Info("profiling data served")
+}
+
+// decodeAuthConfig decodes an AuthConfig in any of the supported versions of the api into the hub version
+func decodeAuthConfig(data []byte) (*v1beta1.AuthConfig, error) {
+	var typeMeta metav1.TypeMeta
+	if err := gojson.Unmarshal(data, &typeMeta); err != nil {
+		return nil, err
+	}
+	if typeMeta.Kind != "AuthConfig" {
+		return nil, fmt.Errorf("unsupported kind %q", typeMeta.Kind)
+	}
+
+	switch typeMeta.APIVersion {
+	case v1beta1.GroupVersion.String():
+		authConfig := &v1beta1.AuthConfig{}
+		if err := gojson.Unmarshal(data, authConfig); err != nil {
+			return nil, err
+		}
+		return authConfig, nil
+	case v1beta2.GroupVersion.String():
+		authConfig := &v1beta2.AuthConfig{}
+		if err := gojson.Unmarshal(data, authConfig); err != nil {
+			return nil, err
+		}
+		hub := &v1beta1.AuthConfig{}
+		if err := authConfig.ConvertTo(hub); err != nil {
+			return nil, err
+		}
+		hub.TypeMeta = metav1.TypeMeta{Kind: "AuthConfig", APIVersion: v1beta1.GroupVersion.String()}
+		return hub, nil
+	default:
+		return nil, fmt.Errorf("unsupported api version %q", typeMeta.APIVersion)
+	}
+}
+
+func (a *AdminService) authenticated(req *http.Request) bool {
+	if a.Token == "" {
+		return true
+	}
+	token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(token), []byte(a.Token)) == 1
+}
+
+func isLoopback(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func (a *AdminService) respond(writer http.ResponseWriter, statusCode int, body interface{}, logger logr.Logger) {
+	writer.Header().Add("Content-Type", "application/json")
+	writer.WriteHeader(statusCode)
+
+	if err := gojson.NewEncoder(writer).Encode(body); err != nil {
+		logger.Error(err, "failed to serve admin request")
+	} else {
+		logger.Info("response sent", "status", statusCode)
+	}
+}
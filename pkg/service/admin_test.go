@@ -0,0 +1,473 @@
+package service
+
+import (
+	"context"
+	gojson "encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gohttptest "net/http/httptest"
+
+	"github.com/kuadrant/authorino/api/v1beta1"
+	"github.com/kuadrant/authorino/api/v1beta2"
+	"github.com/kuadrant/authorino/pkg/auth"
+	"github.com/kuadrant/authorino/pkg/evaluators"
+	"github.com/kuadrant/authorino/pkg/evaluators/identity"
+	"github.com/kuadrant/authorino/pkg/index"
+	"github.com/kuadrant/authorino/pkg/log"
+	"github.com/kuadrant/authorino/pkg/metrics"
+	"github.com/kuadrant/authorino/pkg/trace"
+
+	"gotest.tools/assert"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type fakeEvaluatorCache struct {
+	entries map[string]interface{}
+}
+
+func (c *fakeEvaluatorCache) Get(key interface{}) (interface{}, error) {
+	return c.entries[key.(string)], nil
+}
+
+func (c *fakeEvaluatorCache) Set(key, value interface{}) error {
+	c.entries[key.(string)] = value
+	return nil
+}
+
+func (c *fakeEvaluatorCache) ResolveKeyFor(_ string) interface{} {
+	return nil
+}
+
+func (c *fakeEvaluatorCache) Purge(prefix string) (int, error) {
+	purged := 0
+	for key := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.entries, key)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (c *fakeEvaluatorCache) Shutdown() error {
+	return nil
+}
+
+func newAdminTestIndex() (index.Index, *fakeEvaluatorCache) {
+	cache := &fakeEvaluatorCache{entries: map[string]interface{}{"john": 1, "jane": 2, "joe": 3}}
+	idx := index.NewIndex()
+	_ = idx.Set("ns/authconfig", "example.com", evaluators.AuthConfig{
+		IdentityConfigs: []auth.AuthConfigEvaluator{&evaluators.IdentityConfig{Name: "cached", Cache: cache}},
+	}, false)
+	return idx, cache
+}
+
+func purgeResponse(t *testing.T, recorder *gohttptest.ResponseRecorder) map[string]interface{} {
+	var body map[string]interface{}
+	assert.NilError(t, gojson.Unmarshal(recorder.Body.Bytes(), &body))
+	return body
+}
+
+func TestAdminServicePurgeCacheByPrefix(t *testing.T) {
+	idx, cache := newAdminTestIndex()
+	service := &AdminService{Index: idx}
+
+	recorder := gohttptest.NewRecorder()
+	service.ServeHTTP(recorder, gohttptest.NewRequest(http.MethodPost, "/admin/cache/purge?authconfig=ns/authconfig&prefix=ja", nil))
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	assert.Equal(t, purgeResponse(t, recorder)["purged"], float64(1))
+	assert.Equal(t, len(cache.entries), 2)
+}
+
+func TestAdminServicePurgeAllCaches(t *testing.T) {
+	idx, cache := newAdminTestIndex()
+	service := &AdminService{Index: idx}
+
+	recorder := gohttptest.NewRecorder()
+	service.ServeHTTP(recorder, gohttptest.NewRequest(http.MethodPost, "/admin/cache/purge", nil))
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	assert.Equal(t, purgeResponse(t, recorder)["purged"], float64(3))
+	assert.Equal(t, len(cache.entries), 0)
+}
+
+func TestAdminServicePurgeCacheUnknownAuthConfig(t *testing.T) {
+	idx, _ := newAdminTestIndex()
+	service := &AdminService{Index: idx}
+
+	recorder := gohttptest.NewRecorder()
+	service.ServeHTTP(recorder, gohttptest.NewRequest(http.MethodPost, "/admin/cache/purge?authconfig=ns/other", nil))
+	assert.Equal(t, recorder.Code, http.StatusNotFound)
+}
+
+func TestAdminServicePurgeCacheMethodNotAllowed(t *testing.T) {
+	idx, _ := newAdminTestIndex()
+	service := &AdminService{Index: idx}
+
+	recorder := gohttptest.NewRecorder()
+	service.ServeHTTP(recorder, gohttptest.NewRequest(http.MethodGet, "/admin/cache/purge", nil))
+	assert.Equal(t, recorder.Code, http.StatusMethodNotAllowed)
+}
+
+func TestAdminServiceListIndex(t *testing.T) {
+	idx, _ := newAdminTestIndex()
+	_ = idx.Set("ns/other", "other.com", evaluators.AuthConfig{
+		Labels:          map[string]string{"namespace": "ns", "name": "other", "generation": "3"},
+		IdentityConfigs: []auth.AuthConfigEvaluator{&evaluators.IdentityConfig{Name: "anonymous"}},
+		ResponseConfigs: []auth.AuthConfigEvaluator{&evaluators.ResponseConfig{Name: "x-user"}, &evaluators.ResponseConfig{Name: "x-org"}},
+	}, false)
+	_ = idx.Set("ns/other", "*.other.com", *idx.Get("other.com"), false)
+	service := &AdminService{Index: idx}
+
+	recorder := gohttptest.NewRecorder()
+	service.ServeHTTP(recorder, gohttptest.NewRequest(http.MethodGet, "/admin/index", nil))
+	assert.Equal(t, recorder.Code, http.StatusOK)
+
+	var body struct {
+		AuthConfigs []IndexedAuthConfig `json:"authconfigs"`
+	}
+	assert.NilError(t, gojson.Unmarshal(recorder.Body.Bytes(), &body))
+	assert.Equal(t, len(body.AuthConfigs), 2)
+	assert.Equal(t, body.AuthConfigs[0].Id, "ns/authconfig")
+	assert.DeepEqual(t, body.AuthConfigs[0].Hosts, []string{"example.com"})
+	assert.DeepEqual(t, body.AuthConfigs[1], IndexedAuthConfig{
+		Id:         "ns/other",
+		Namespace:  "ns",
+		Name:       "other",
+		Generation: 3,
+		Hosts:      []string{"other.com", "*.other.com"},
+		Evaluators: map[string]int{"identity": 1, "metadata": 0, "authorization": 0, "response": 2, "callbacks": 0},
+	})
+}
+
+func TestAdminServiceListIndexMethodNotAllowed(t *testing.T) {
+	idx, _ := newAdminTestIndex()
+	service := &AdminService{Index: idx}
+
+	recorder := gohttptest.NewRecorder()
+	service.ServeHTTP(recorder, gohttptest.NewRequest(http.MethodDelete, "/admin/index", nil))
+	assert.Equal(t, recorder.Code, http.StatusMethodNotAllowed)
+}
+
+func TestAdminServiceCircuitBreakers(t *testing.T) {
+	service := &AdminService{Index: index.NewIndex()}
+
+	recorder := gohttptest.NewRecorder()
+	service.ServeHTTP(recorder, gohttptest.NewRequest(http.MethodGet, "/admin/circuit-breakers", nil))
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	assert.Equal(t, purgeResponse(t, recorder)["enabled"], false)
+
+	trace.ConfigureHTTPTransport(trace.HTTPTransportOptions{CircuitBreakerFailureThreshold: 1})
+	defer trace.ConfigureHTTPTransport(trace.HTTPTransportOptions{})
+
+	server := gohttptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	resp, err := trace.HTTPClient.Get(server.URL)
+	assert.NilError(t, err)
+	resp.Body.Close()
+
+	recorder = gohttptest.NewRecorder()
+	service.ServeHTTP(recorder, gohttptest.NewRequest(http.MethodGet, "/admin/circuit-breakers", nil))
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	body := purgeResponse(t, recorder)
+	assert.Equal(t, body["enabled"], true)
+	circuitBreakers := body["circuitBreakers"].([]interface{})
+	assert.Equal(t, len(circuitBreakers), 1)
+	circuitBreaker := circuitBreakers[0].(map[string]interface{})
+	assert.Equal(t, circuitBreaker["host"], strings.TrimPrefix(server.URL, "http://"))
+	assert.Equal(t, circuitBreaker["state"], "open")
+	assert.Equal(t, circuitBreaker["consecutiveFailures"], float64(1))
+}
+
+func TestAdminServiceToken(t *testing.T) {
+	idx, _ := newAdminTestIndex()
+	service := &AdminService{Index: idx, Token: "s3cr3t"}
+
+	recorder := gohttptest.NewRecorder()
+	service.ServeHTTP(recorder, gohttptest.NewRequest(http.MethodGet, "/admin/index", nil))
+	assert.Equal(t, recorder.Code, http.StatusUnauthorized)
+
+	req := gohttptest.NewRequest(http.MethodGet, "/admin/index", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	recorder = gohttptest.NewRecorder()
+	service.ServeHTTP(recorder, req)
+	assert.Equal(t, recorder.Code, http.StatusUnauthorized)
+
+	req = gohttptest.NewRequest(http.MethodGet, "/admin/index", nil)
+	req.Header.Set("Authorization", "Bearer s3cr3t")
+	recorder = gohttptest.NewRecorder()
+	service.ServeHTTP(recorder, req)
+	assert.Equal(t, recorder.Code, http.StatusOK)
+}
+
+type fakeReconciledAuthConfigs struct {
+	effective map[string]*v1beta2.AuthConfig
+	dryRun    *v1beta1.AuthConfig
+}
+
+func (f *fakeReconciledAuthConfigs) EffectiveAuthConfig(resourceId string) (*v1beta2.AuthConfig, bool) {
+	authConfig, found := f.effective[resourceId]
+	return authConfig, found
+}
+
+func (f *fakeReconciledAuthConfigs) DryRun(_ context.Context, authConfig *v1beta1.AuthConfig, callerToken string) (*v1beta2.AuthConfig, map[string]string, error) {
+	if callerToken != "k8s-token" {
+		return nil, nil, k8s_errors.NewForbidden(schema.GroupResource{Group: "authorino.kuadrant.io", Resource: "authconfigs"}, authConfig.Name, fmt.Errorf("denied"))
+	}
+	f.dryRun = authConfig
+	if len(authConfig.Spec.Hosts) == 0 {
+		return nil, nil, fmt.Errorf("spec.hosts: Required value")
+	}
+	return &v1beta2.AuthConfig{ObjectMeta: authConfig.ObjectMeta, Spec: v1beta2.AuthConfigSpec{Hosts: authConfig.Spec.Hosts}}, map[string]string{"example.com": "ns/authconfig"}, nil
+}
+
+func TestAdminServiceExportAuthConfig(t *testing.T) {
+	idx, _ := newAdminTestIndex()
+	authConfigs := &fakeReconciledAuthConfigs{effective: map[string]*v1beta2.AuthConfig{
+		"ns/authconfig": {ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "authconfig"}, Spec: v1beta2.AuthConfigSpec{Hosts: []string{"example.com"}}},
+	}}
+	service := &AdminService{Index: idx, AuthConfigs: authConfigs}
+
+	recorder := gohttptest.NewRecorder()
+	service.ServeHTTP(recorder, gohttptest.NewRequest(http.MethodGet, "/admin/authconfigs/export?host=example.com", nil))
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	var authConfig v1beta2.AuthConfig
+	assert.NilError(t, gojson.Unmarshal(recorder.Body.Bytes(), &authConfig))
+	assert.Equal(t, authConfig.Name, "authconfig")
+	assert.DeepEqual(t, authConfig.Spec.Hosts, []string{"example.com"})
+
+	recorder = gohttptest.NewRecorder()
+	service.ServeHTTP(recorder, gohttptest.NewRequest(http.MethodGet, "/admin/authconfigs/export?host=other.com", nil))
+	assert.Equal(t, recorder.Code, http.StatusNotFound)
+
+	recorder = gohttptest.NewRecorder()
+	service.ServeHTTP(recorder, gohttptest.NewRequest(http.MethodGet, "/admin/authconfigs/export", nil))
+	assert.Equal(t, recorder.Code, http.StatusBadRequest)
+
+	// not reconciled by the reconciler of authconfigs
+	delete(authConfigs.effective, "ns/authconfig")
+	recorder = gohttptest.NewRecorder()
+	service.ServeHTTP(recorder, gohttptest.NewRequest(http.MethodGet, "/admin/authconfigs/export?host=example.com", nil))
+	assert.Equal(t, recorder.Code, http.StatusNotFound)
+
+	// disabled
+	service.AuthConfigs = nil
+	recorder = gohttptest.NewRecorder()
+	service.ServeHTTP(recorder, gohttptest.NewRequest(http.MethodGet, "/admin/authconfigs/export?host=example.com", nil))
+	assert.Equal(t, recorder.Code, http.StatusNotImplemented)
+}
+
+func TestAdminServiceDryRunAuthConfig(t *testing.T) {
+	idx, _ := newAdminTestIndex()
+	authConfigs := &fakeReconciledAuthConfigs{}
+	service := &AdminService{Index: idx, AuthConfigs: authConfigs, Token: "s3cr3t"}
+	newDryRunRequest := func(method, body string) *http.Request {
+		req := gohttptest.NewRequest(method, "/admin/authconfigs/dry-run", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer s3cr3t")
+		req.Header.Set(KubernetesTokenHeader, "k8s-token")
+		return req
+	}
+
+	// v1beta2
+	recorder := gohttptest.NewRecorder()
+	service.ServeHTTP(recorder, newDryRunRequest(http.MethodPost, `{"apiVersion":"authorino.kuadrant.io/v1beta2","kind":"AuthConfig","metadata":{"namespace":"ns","name":"candidate"},"spec":{"hosts":["example.com","candidate.com"],"authentication":{"anonymous":{"anonymous":{}}}}}`))
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	var result DryRunResult
+	assert.NilError(t, gojson.Unmarshal(recorder.Body.Bytes(), &result))
+	assert.Check(t, result.Valid)
+	assert.Equal(t, result.AuthConfig.Name, "candidate")
+	assert.DeepEqual(t, result.HostsNotLinked, map[string]string{"example.com": "ns/authconfig"})
+	assert.Equal(t, authConfigs.dryRun.APIVersion, "authorino.kuadrant.io/v1beta1")
+	assert.Equal(t, len(authConfigs.dryRun.Spec.Identity), 1)
+	assert.Check(t, authConfigs.dryRun.Spec.Identity[0].Anonymous != nil)
+
+	// v1beta1, invalid
+	recorder = gohttptest.NewRecorder()
+	service.ServeHTTP(recorder, newDryRunRequest(http.MethodPost, `{"apiVersion":"authorino.kuadrant.io/v1beta1","kind":"AuthConfig","metadata":{"namespace":"ns","name":"candidate"},"spec":{}}`))
+	assert.Equal(t, recorder.Code, http.StatusUnprocessableEntity)
+	result = DryRunResult{}
+	assert.NilError(t, gojson.Unmarshal(recorder.Body.Bytes(), &result))
+	assert.Check(t, !result.Valid)
+	assert.Equal(t, result.Error, "spec.hosts: Required value")
+
+	// unsupported
+	recorder = gohttptest.NewRecorder()
+	service.ServeHTTP(recorder, newDryRunRequest(http.MethodPost, `{"apiVersion":"v1","kind":"ConfigMap"}`))
+	assert.Equal(t, recorder.Code, http.StatusBadRequest)
+
+	recorder = gohttptest.NewRecorder()
+	service.ServeHTTP(recorder, newDryRunRequest(http.MethodGet, ""))
+	assert.Equal(t, recorder.Code, http.StatusMethodNotAllowed)
+
+	// caller not allowed to create the authconfig
+	authConfigs.dryRun = nil
+	req := newDryRunRequest(http.MethodPost, `{"apiVersion":"authorino.kuadrant.io/v1beta2","kind":"AuthConfig","metadata":{"namespace":"ns","name":"candidate"},"spec":{"hosts":["candidate.com"]}}`)
+	req.Header.Set(KubernetesTokenHeader, "other-token")
+	recorder = gohttptest.NewRecorder()
+	service.ServeHTTP(recorder, req)
+	assert.Equal(t, recorder.Code, http.StatusForbidden)
+	assert.Check(t, authConfigs.dryRun == nil)
+
+	// admin server without token
+	service.Token = ""
+	recorder = gohttptest.NewRecorder()
+	service.ServeHTTP(recorder, newDryRunRequest(http.MethodPost, `{"apiVersion":"authorino.kuadrant.io/v1beta2","kind":"AuthConfig","metadata":{"namespace":"ns","name":"candidate"},"spec":{"hosts":["candidate.com"]}}`))
+	assert.Equal(t, recorder.Code, http.StatusForbidden)
+	assert.Check(t, authConfigs.dryRun == nil)
+}
+
+func TestAdminServiceCheck(t *testing.T) {
+	idx := index.NewIndex()
+	_ = idx.Set("ns/authconfig", "example.com", evaluators.AuthConfig{
+		Labels:               map[string]string{"namespace": "ns", "name": "authconfig"},
+		IdentityConfigs:      []auth.AuthConfigEvaluator{&evaluators.IdentityConfig{Name: "anonymous", Noop: &identity.Noop{AuthCredentials: auth.NewAuthCredential("", "")}}},
+		AuthorizationConfigs: []auth.AuthConfigEvaluator{denyAuthorizationConfig("only-post", 0)},
+	}, false)
+	service := &AdminService{Index: idx}
+
+	// authorized
+	recorder := gohttptest.NewRecorder()
+	service.ServeHTTP(recorder, gohttptest.NewRequest(http.MethodPost, "/admin/check", strings.NewReader(`{"host":"example.com","method":"POST","path":"/pets","headers":{"X-Foo":"bar"}}`)))
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	var result CheckResult
+	assert.NilError(t, gojson.Unmarshal(recorder.Body.Bytes(), &result))
+	assert.Equal(t, result.AuthConfig, "ns/authconfig")
+	assert.Check(t, result.Authorized)
+	assert.Equal(t, result.Code, "OK")
+	assert.Equal(t, result.Status, 200)
+	authJSON, _ := gojson.Marshal(result.AuthorizationJSON)
+	assert.Check(t, strings.Contains(string(authJSON), `"x-foo":"bar"`))
+
+	// denied
+	recorder = gohttptest.NewRecorder()
+	service.ServeHTTP(recorder, gohttptest.NewRequest(http.MethodPost, "/admin/check", strings.NewReader(`{"host":"example.com","path":"/pets"}`)))
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	result = CheckResult{}
+	assert.NilError(t, gojson.Unmarshal(recorder.Body.Bytes(), &result))
+	assert.Check(t, !result.Authorized)
+	assert.Equal(t, result.Code, "PERMISSION_DENIED")
+	assert.Equal(t, result.Status, 403)
+	assert.Equal(t, result.Result.Message, "Unauthorized")
+
+	// unknown host
+	recorder = gohttptest.NewRecorder()
+	service.ServeHTTP(recorder, gohttptest.NewRequest(http.MethodPost, "/admin/check", strings.NewReader(`{"host":"other.com"}`)))
+	assert.Equal(t, recorder.Code, http.StatusNotFound)
+
+	recorder = gohttptest.NewRecorder()
+	service.ServeHTTP(recorder, gohttptest.NewRequest(http.MethodPost, "/admin/check", strings.NewReader(`{"path":"/pets"}`)))
+	assert.Equal(t, recorder.Code, http.StatusBadRequest)
+
+	recorder = gohttptest.NewRecorder()
+	service.ServeHTTP(recorder, gohttptest.NewRequest(http.MethodGet, "/admin/check", nil))
+	assert.Equal(t, recorder.Code, http.StatusMethodNotAllowed)
+}
+
+func TestAdminServiceSettings(t *testing.T) {
+	idx, _ := newAdminTestIndex()
+	file := filepath.Join(t.TempDir(), "settings.env")
+	authService := &AuthService{Index: idx, Timeout: 100 * time.Millisecond}
+	settings := &RuntimeSettingsReloader{
+		Defaults:     RuntimeSettings{LogLevel: "info", TracingSamplingRatio: 1, Timeout: 100},
+		File:         file,
+		AuthServices: []*AuthService{authService},
+	}
+	service := &AdminService{Index: idx, Settings: settings}
+	defer func() {
+		log.SetLevel(log.ToLogLevel("info"))
+		metrics.DeepMetricsEnabled = false
+		trace.SetSamplingRatio(1)
+	}()
+
+	assert.NilError(t, os.WriteFile(file, []byte("# reloaded on the fly\nLOG_LEVEL=debug\nDEEP_METRICS_ENABLED=true\nTRACING_SAMPLING_RATIO=\"0.25\"\nOTHER=ignored\n"), 0600))
+	recorder := gohttptest.NewRecorder()
+	service.ServeHTTP(recorder, gohttptest.NewRequest(http.MethodPost, "/admin/settings/reload", nil))
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	expected := RuntimeSettings{LogLevel: "debug", DeepMetricsEnabled: true, TracingSamplingRatio: 0.25, Timeout: 100}
+	var current RuntimeSettings
+	assert.NilError(t, gojson.Unmarshal(recorder.Body.Bytes(), &current))
+	assert.Equal(t, current, expected)
+	assert.Check(t, metrics.DeepMetricsEnabled)
+	assert.Equal(t, trace.SamplingRatio(), 0.25)
+	assert.Equal(t, authService.timeout(), 100*time.Millisecond)
+
+	// invalid settings are not applied
+	assert.NilError(t, os.WriteFile(file, []byte("TIMEOUT=500\nTRACING_SAMPLING_RATIO=2\n"), 0600))
+	recorder = gohttptest.NewRecorder()
+	service.ServeHTTP(recorder, gohttptest.NewRequest(http.MethodPost, "/admin/settings/reload", nil))
+	assert.Equal(t, recorder.Code, http.StatusUnprocessableEntity)
+	assert.Equal(t, settings.Current(), expected)
+	assert.Equal(t, authService.timeout(), 100*time.Millisecond)
+
+	// settings missing in the file fall back to the defaults
+	assert.NilError(t, os.WriteFile(file, []byte("TIMEOUT=500\n"), 0600))
+	recorder = gohttptest.NewRecorder()
+	service.ServeHTTP(recorder, gohttptest.NewRequest(http.MethodPost, "/admin/settings/reload", nil))
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	assert.Check(t, !metrics.DeepMetricsEnabled)
+	assert.Equal(t, authService.timeout(), 500*time.Millisecond)
+
+	recorder = gohttptest.NewRecorder()
+	service.ServeHTTP(recorder, gohttptest.NewRequest(http.MethodGet, "/admin/settings", nil))
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	current = RuntimeSettings{}
+	assert.NilError(t, gojson.Unmarshal(recorder.Body.Bytes(), &current))
+	assert.Equal(t, current, RuntimeSettings{LogLevel: "info", TracingSamplingRatio: 1, Timeout: 500})
+
+	recorder = gohttptest.NewRecorder()
+	service.ServeHTTP(recorder, gohttptest.NewRequest(http.MethodGet, "/admin/settings/reload", nil))
+	assert.Equal(t, recorder.Code, http.StatusMethodNotAllowed)
+
+	// disabled
+	service.Settings = nil
+	recorder = gohttptest.NewRecorder()
+	service.ServeHTTP(recorder, gohttptest.NewRequest(http.MethodGet, "/admin/settings", nil))
+	assert.Equal(t, recorder.Code, http.StatusNotImplemented)
+}
+
+func TestAdminServiceProfiling(t *testing.T) {
+	idx, _ := newAdminTestIndex()
+	service := &AdminService{Index: idx}
+
+	newRequest := func(path string) *http.Request {
+		req := gohttptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = "127.0.0.1:51234"
+		return req
+	}
+
+	// disabled
+	recorder := gohttptest.NewRecorder()
+	service.ServeHTTP(recorder, newRequest("/admin/debug/pprof/"))
+	assert.Equal(t, recorder.Code, http.StatusNotFound)
+
+	service.Profiling = true
+
+	recorder = gohttptest.NewRecorder()
+	service.ServeHTTP(recorder, newRequest("/admin/debug/pprof/"))
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	assert.Check(t, strings.Contains(recorder.Body.String(), "goroutine"))
+
+	recorder = gohttptest.NewRecorder()
+	service.ServeHTTP(recorder, newRequest("/admin/debug/pprof/heap"))
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	assert.Equal(t, recorder.Header().Get("Content-Type"), "application/octet-stream")
+
+	recorder = gohttptest.NewRecorder()
+	service.ServeHTTP(recorder, newRequest("/admin/debug/pprof/goroutine?debug=1"))
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	assert.Check(t, strings.Contains(recorder.Body.String(), "goroutine profile"))
+
+	// not from the loopback interface
+	recorder = gohttptest.NewRecorder()
+	service.ServeHTTP(recorder, gohttptest.NewRequest(http.MethodGet, "/admin/debug/pprof/heap", nil))
+	assert.Equal(t, recorder.Code, http.StatusNotFound)
+}
@@ -4,15 +4,20 @@ import (
 	"encoding/json"
 	"encoding/pem"
 	"io"
+	"math/rand"
 	"net/http"
 	"net/url"
+	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	gocontext "golang.org/x/net/context"
 
+	"github.com/kuadrant/authorino/pkg/audit"
 	"github.com/kuadrant/authorino/pkg/auth"
 	"github.com/kuadrant/authorino/pkg/context"
+	"github.com/kuadrant/authorino/pkg/evaluators"
 	"github.com/kuadrant/authorino/pkg/index"
 	"github.com/kuadrant/authorino/pkg/log"
 	"github.com/kuadrant/authorino/pkg/metrics"
@@ -23,21 +28,33 @@ import (
 	envoy_type "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/gogo/googleapis/google/rpc"
 	"github.com/google/uuid"
+	"github.com/tidwall/gjson"
 	otel_codes "go.opentelemetry.io/otel/codes"
+	otel_trace "go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap/zapcore"
 	rpcstatus "google.golang.org/genproto/googleapis/rpc/status"
 	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 	v1 "k8s.io/api/admission/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
 	HTTPAuthorizationBasePath = "/check"
+	// HTTPExtAuthzBasePath is the path prefix of the authorization requests sent by the ext_authz HTTP filter of Envoy
+	// in HTTP client mode (http_service.path_prefix); the path of the original request follows the prefix
+	HTTPExtAuthzBasePath = "/ext-authz"
+	// HTTPNginxAuthRequestPath is the path of the subrequests sent by the auth_request module of NGINX
+	HTTPNginxAuthRequestPath = "/auth-request"
+	// HTTPTraefikForwardAuthPath is the path of the authorization requests sent by the ForwardAuth middleware of Traefik
+	HTTPTraefikForwardAuthPath = "/forward-auth"
 
 	X_EXT_AUTH_REASON_HEADER      = "X-Ext-Auth-Reason"
 	ENVOY_TRACE_REQUEST_ID_HEADER = "X-Request-Id"
 
 	RESPONSE_MESSAGE_INVALID_REQUEST   = "Invalid request"
 	RESPONSE_MESSAGE_SERVICE_NOT_FOUND = "Service not found"
+	RESPONSE_MESSAGE_TOO_MANY_REQUESTS = "Too many requests"
 
 	HTTP_MESSAGE_400 = "bad request"
 	HTTP_MESSAGE_404 = "not found"
@@ -48,17 +65,24 @@ const (
 )
 
 var (
+	debugLogLevel = log.ToLogLevel("debug")
+
 	statusCodeMapping = map[rpc.Code]envoy_type.StatusCode{
 		rpc.OK:                  envoy_type.StatusCode_OK,
 		rpc.FAILED_PRECONDITION: envoy_type.StatusCode_BadRequest,
 		rpc.NOT_FOUND:           envoy_type.StatusCode_NotFound,
 		rpc.UNAUTHENTICATED:     envoy_type.StatusCode_Unauthorized,
 		rpc.PERMISSION_DENIED:   envoy_type.StatusCode_Forbidden,
+		rpc.RESOURCE_EXHAUSTED:  envoy_type.StatusCode_TooManyRequests,
 	}
 
 	authServerResponseStatusMetric = metrics.NewCounterMetric("auth_server_response_status", "Response status of authconfigs sent by the auth server.", "status")
 	httpServerHandledTotal         = metrics.NewCounterMetric("http_server_handled_total", "Total number of calls completed on the raw HTTP authorization server, regardless of success or failure.", "status")
 	httpServerDuration             = metrics.NewDurationMetric("http_server_handling_seconds", "Response latency (seconds) of raw HTTP authorization request that had been application-level handled by the server.")
+	authServerLookupMetric         = metrics.NewCounterMetric("auth_server_authconfig_lookup_total", "Number of lookups of authconfigs in the index by the auth server, partitioned by result.", "result")
+	authServerCanaryMetric         = metrics.NewCounterMetric("auth_server_authconfig_canary_total", "Number of requests evaluated by the auth server with authconfigs under canary rollout, partitioned by revision.", "namespace", "authconfig", "revision", "track")
+	authServerRejectedMetric       = metrics.NewCounterMetric("auth_server_rejected_total", "Number of requests rejected by the auth server for exceeding the limit of concurrent requests.")
+	authServerDeduplicatedMetric   = metrics.NewCounterMetric("auth_server_deduplicated_total", "Number of requests answered by the auth server with the response of an identical request.")
 )
 
 func init() {
@@ -66,6 +90,10 @@ func init() {
 		authServerResponseStatusMetric,
 		httpServerHandledTotal,
 		httpServerDuration,
+		authServerLookupMetric,
+		authServerCanaryMetric,
+		authServerRejectedMetric,
+		authServerDeduplicatedMetric,
 	)
 }
 
@@ -74,21 +102,95 @@ type AuthService struct {
 	Index                  index.Index
 	Timeout                time.Duration
 	MaxHttpRequestBodySize int64
+
+	// Ratio of the requests promoted to debug logging, between 0 (none) and 1 (all)
+	DebugSamplingRatio float64
+
+	// Headers of the original request in the subrequests of NGINX auth_request; the defaults apply to the unset ones
+	NginxAuthRequest OriginalRequestHeaders
+
+	// bounds the requests evaluated concurrently; unlimited if nil
+	limiter *concurrencyLimiter
+	// shares the responses between identical requests; disabled if nil
+	deduplicator *requestDeduplicator
+
+	// overrides Timeout once set with SetTimeout
+	reloadedTimeout atomic.Pointer[time.Duration]
+	// overrides DebugSamplingRatio once set with SetDebugSamplingRatio
+	reloadedDebugSamplingRatio atomic.Pointer[float64]
 }
 
 func NewAuthService(index index.Index, timeout time.Duration, maxHttpRequestBodySize int64) *AuthService {
 	return &AuthService{Index: index, Timeout: timeout, MaxHttpRequestBodySize: maxHttpRequestBodySize}
 }
 
+// LimitConcurrency bounds the number of requests evaluated concurrently to maxConcurrent, with up to maxQueued requests
+// waiting for a slot. Requests beyond that are denied with RESOURCE_EXHAUSTED. Unlimited if maxConcurrent is 0.
+// Must be called before the service starts serving requests.
+func (a *AuthService) LimitConcurrency(maxConcurrent, maxQueued int) {
+	if maxConcurrent <= 0 {
+		a.limiter = nil
+		return
+	}
+	if maxQueued < 0 {
+		maxQueued = 0
+	}
+	a.limiter = newConcurrencyLimiter(maxConcurrent, maxQueued)
+}
+
+// DeduplicateRequests makes identical requests received concurrently share the response of a single evaluation, except
+// for the requests to AuthConfigs with stateful evaluators.
+// Must be called before the service starts serving requests.
+func (a *AuthService) DeduplicateRequests(enabled bool) {
+	if !enabled {
+		a.deduplicator = nil
+		return
+	}
+	a.deduplicator = newRequestDeduplicator()
+}
+
+// SetTimeout changes the timeout of the auth requests on the fly
+func (a *AuthService) SetTimeout(timeout time.Duration) {
+	a.reloadedTimeout.Store(&timeout)
+}
+
+func (a *AuthService) timeout() time.Duration {
+	if timeout := a.reloadedTimeout.Load(); timeout != nil {
+		return *timeout
+	}
+	return a.Timeout
+}
+
+// SetDebugSamplingRatio changes the ratio of the requests promoted to debug logging on the fly
+func (a *AuthService) SetDebugSamplingRatio(ratio float64) {
+	a.reloadedDebugSamplingRatio.Store(&ratio)
+}
+
+// sampleDebug tells whether to promote a request to debug logging
+func (a *AuthService) sampleDebug() bool {
+	ratio := a.DebugSamplingRatio
+	if reloaded := a.reloadedDebugSamplingRatio.Load(); reloaded != nil {
+		ratio = *reloaded
+	}
+	return ratio > 0 && rand.Float64() < ratio
+}
+
 // ServeHTTP invokes authorization check for a simple GET/POST HTTP authorization request
 // Content-Type header must be 'application/json'
 // The body can be any JSON object; in case the input is a Kubernetes AdmissionReview resource,
 // the response is compatible with the Dynamic Admission API
+// Requests under HTTPExtAuthzBasePath follow instead the contract of the HTTP authorization service of the Envoy
+// ext_authz filter: any method is accepted and the path of the original request is the remainder of the path after the
+// prefix
+// Requests to HTTPNginxAuthRequestPath follow the contract of the NGINX auth_request module: the original request is
+// read from the headers set in NginxAuthRequest and denials are either 401 or 403
+// Requests to HTTPTraefikForwardAuthPath follow the contract of the Traefik ForwardAuth middleware: the original request
+// is read from the X-Forwarded-* headers and denials (e.g. redirects) are passed through to the client as is
 func (a *AuthService) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
 	propagationRequestId := req.Header.Get(ENVOY_TRACE_REQUEST_ID_HEADER)
 	requestId := ensureRequestId(propagationRequestId)
 
-	ctx := context.New(context.WithParent(req.Context()), context.WithTimeout(a.Timeout))
+	ctx := context.New(context.WithParent(req.Context()), context.WithTimeout(a.timeout()))
 	ctx, span := trace.NewAuthorizationRequestSpan(ctx, "AuthService", "ServeHTTP", requestId, propagationRequestId)
 	defer span.End()
 
@@ -99,20 +201,41 @@ func (a *AuthService) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
 		WithValues("request id", requestId).
 		V(1)
 
-	switch req.Method {
-	case "GET", "POST":
+	protocol := rawHTTPProtocol
+	method, host, scheme := req.Method, req.Host, req.URL.Scheme
+	path, extAuthz := extAuthzOriginalPath(req.URL)
+	query := ""
+
+	switch {
+	case extAuthz:
+		protocol = envoyExtAuthzProtocol
+	case strings.TrimSuffix(req.URL.Path, "/") == HTTPNginxAuthRequestPath:
+		protocol = nginxAuthRequestProtocol
+		method, host, path = a.NginxAuthRequest.originalRequest(req, defaultNginxAuthRequestHeaders)
+	case strings.TrimSuffix(req.URL.Path, "/") == HTTPTraefikForwardAuthPath:
+		protocol = traefikForwardAuthProtocol
+		method, host, path = OriginalRequestHeaders{}.originalRequest(req, traefikForwardAuthHeaders)
+		if proto := req.Header.Get("X-Forwarded-Proto"); proto != "" {
+			scheme = proto
+		}
 	default:
-		logger.Info(HTTP_MESSAGE_404)
-		closeWithStatus(envoy_type.StatusCode_NotFound, resp, ctx, nil)
-		return
-	}
+		switch req.Method {
+		case "GET", "POST":
+		default:
+			logger.Info(HTTP_MESSAGE_404)
+			closeWithStatus(envoy_type.StatusCode_NotFound, resp, ctx, nil)
+			return
+		}
 
-	path := strings.TrimSuffix(req.URL.Path, "/")
+		path = strings.TrimSuffix(req.URL.Path, "/")
 
-	if path != HTTPAuthorizationBasePath {
-		logger.Info(HTTP_MESSAGE_404)
-		closeWithStatus(envoy_type.StatusCode_NotFound, resp, ctx, nil)
-		return
+		if path != HTTPAuthorizationBasePath {
+			logger.Info(HTTP_MESSAGE_404)
+			closeWithStatus(envoy_type.StatusCode_NotFound, resp, ctx, nil)
+			return
+		}
+
+		query = req.URL.Query().Encode()
 	}
 
 	var payload []byte
@@ -146,12 +269,12 @@ func (a *AuthService) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
 				Request: &envoy_auth.AttributeContext_Request{
 					Http: &envoy_auth.AttributeContext_HttpRequest{
 						Id:       requestId,
-						Method:   req.Method,
+						Method:   method,
 						Headers:  headers,
 						Path:     path,
-						Host:     req.Host,
-						Scheme:   req.URL.Scheme,
-						Query:    req.URL.Query().Encode(),
+						Host:     host,
+						Scheme:   scheme,
+						Query:    query,
 						Fragment: req.URL.Fragment,
 						Protocol: req.Proto,
 						Body:     string(payload),
@@ -186,7 +309,12 @@ func (a *AuthService) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
 		var respStatusCode envoy_type.StatusCode
 		var respBody []byte
 
-		if admissionReviewRequest := admissionReviewFromPayload(payload); admissionReviewRequest != nil {
+		var admissionReviewRequest *v1.AdmissionReview
+		if protocol == rawHTTPProtocol {
+			admissionReviewRequest = admissionReviewFromPayload(payload)
+		}
+
+		if admissionReviewRequest != nil {
 			// it's an admission review request
 			respStatusCode = envoy_type.StatusCode_OK
 			admissionResponse := &v1.AdmissionResponse{}
@@ -214,15 +342,31 @@ func (a *AuthService) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
 		} else {
 			// not an admission review request
 			respStatusCode = statusCodeMapping[code]
+			switch protocol {
+			case nginxAuthRequestProtocol:
+				respStatusCode = nginxAuthRequestStatus(code)
+			case envoyExtAuthzProtocol, traefikForwardAuthProtocol:
+				// the proxy sends the denial to the client as is, so custom status codes (e.g. redirects) must be kept
+				if status := checkResponse.GetDeniedResponse().GetStatus().GetCode(); code != rpc.OK && status != 0 {
+					respStatusCode = status
+				}
+			}
 			var headers []*envoy_core.HeaderValueOption
 			if code == rpc.OK {
 				headers = checkResponse.GetOkResponse().GetHeaders()
+				for _, h := range checkResponse.GetOkResponse().GetResponseHeadersToAdd() {
+					resp.Header().Add(h.Header.GetKey(), h.Header.GetValue())
+				}
 			} else {
 				headers = checkResponse.GetDeniedResponse().GetHeaders()
 				respBody = []byte(checkResponse.GetDeniedResponse().GetBody())
 			}
 			for _, h := range headers {
-				resp.Header().Set(h.Header.GetKey(), h.Header.GetValue())
+				if h.GetAppend().GetValue() {
+					resp.Header().Add(h.Header.GetKey(), h.Header.GetValue())
+				} else {
+					resp.Header().Set(h.Header.GetKey(), h.Header.GetValue())
+				}
 			}
 		}
 
@@ -245,7 +389,12 @@ func (a *AuthService) Check(parentContext gocontext.Context, req *envoy_auth.Che
 	defer span.End()
 
 	requestLogger := log.WithName("service").WithName("auth").WithValues("request id", requestId)
-	ctx = log.IntoContext(context.New(context.WithParent(ctx), context.WithTimeout(a.Timeout)), requestLogger)
+	// a sample of the requests is promoted to debug logging, regardless of the log level
+	debugSampled := a.sampleDebug()
+	if debugSampled {
+		requestLogger = log.WithLevel(debugLogLevel).WithName("service").WithName("auth").WithValues("request id", requestId, "debug sampled", true)
+	}
+	ctx = log.IntoContext(context.New(context.WithParent(ctx), context.WithTimeout(a.timeout())), requestLogger)
 
 	a.logAuthRequest(req, ctx)
 
@@ -257,39 +406,140 @@ func (a *AuthService) Check(parentContext gocontext.Context, req *envoy_auth.Che
 		host = requestData.Host
 	}
 
-	authConfig := a.Index.Get(host)
-	// If the host is not found, but contains a port, remove the port part and retry.
-	if authConfig == nil && strings.Contains(host, ":") {
-		splitHost := strings.Split(host, ":")
-		authConfig = a.Index.Get(splitHost[0])
+	// identical requests in-flight share the response of a single evaluation, unless the evaluation changes state
+	if a.deduplicator != nil {
+		// requests to authconfigs under canary rollout only share the responses of requests routed to the same track
+		if authConfig := a.lookup(host); !authConfig.Stateful() {
+			result, shared := a.deduplicator.do(ctx, deduplicationKey(req, host, canaryTrack(authConfig, requestId)), func() *deduplicatedResult {
+				resp, record := a.check(ctx, span, req, requestId, host, debugSampled)
+				return &deduplicatedResult{resp: resp, record: record}
+			})
+			if !shared {
+				return result.resp, nil
+			}
+
+			metrics.ReportMetric(authServerDeduplicatedMetric)
+			if result == nil {
+				authResult := auth.AuthResult{Code: rpc.UNAVAILABLE}
+				a.logAuthResult(authResult, ctx)
+				a.auditAuthResult(requestId, host, nil, nil, authResult)
+				return a.deniedResponse(authResult), nil
+			}
+			code := rpc.Code(result.resp.GetStatus().GetCode())
+			reportStatusMetric(code)
+			requestLogger.Info("outgoing authorization response", "authorized", code == rpc.OK, "response", code.String(), "deduplicated", true)
+			// every request gets its own audit record, with the decision of the identical request evaluated
+			if result.record != nil {
+				record := *result.record
+				record.Timestamp = time.Now().UTC()
+				record.RequestId = requestId
+				record.Deduplicated = true
+				audit.Records.Log(record)
+			}
+			return result.resp, nil
+		}
+	}
+
+	resp, _ := a.check(ctx, span, req, requestId, host, debugSampled)
+	return resp, nil
+}
+
+// check evaluates the auth request for a host and returns the response, along with the audit record of the decision
+func (a *AuthService) check(ctx gocontext.Context, span otel_trace.Span, req *envoy_auth.CheckRequest, requestId, host string, debugSampled bool) (*envoy_auth.CheckResponse, *audit.Record) {
+	requestLogger := log.FromContext(ctx)
+
+	// under overload, requests exceeding the limit of concurrent requests are rejected quickly
+	if a.limiter != nil {
+		if !a.limiter.acquire(ctx) {
+			metrics.ReportMetric(authServerRejectedMetric)
+			result := auth.AuthResult{Code: rpc.RESOURCE_EXHAUSTED, Message: RESPONSE_MESSAGE_TOO_MANY_REQUESTS}
+			a.logAuthResult(result, ctx)
+			return a.deniedResponse(result), a.auditAuthResult(requestId, host, nil, nil, result)
+		}
+		defer a.limiter.release()
 	}
 
+	authConfig := a.lookup(host)
+
 	// If we couldn't find the AuthConfig in the config, we return and deny.
 	if authConfig == nil {
+		metrics.ReportMetric(authServerLookupMetric, "miss")
 		result := auth.AuthResult{Code: rpc.NOT_FOUND, Message: RESPONSE_MESSAGE_SERVICE_NOT_FOUND}
 		a.logAuthResult(result, ctx)
-		return a.deniedResponse(result), nil
+		return a.deniedResponse(result), a.auditAuthResult(requestId, host, nil, nil, result)
+	}
+	metrics.ReportMetric(authServerLookupMetric, "hit")
+
+	// the config is not cleaned while the request is being evaluated, even if replaced in the index in the meantime
+	defer authConfig.InFlight.Add()()
+
+	// authconfigs under canary rollout are split between the stable and the canary revisions
+	if authConfig.Canary != nil {
+		version, canary := authConfig.Version(requestId)
+		track := "stable"
+		if canary {
+			track = "canary"
+		}
+		metrics.ReportMetric(authServerCanaryMetric, version.Labels["namespace"], version.Labels["name"], version.Labels["generation"], track)
+		requestLogger.V(1).Info("authconfig under canary rollout", "revision", version.Labels["generation"], "track", track)
+		authConfig = version
 	}
 
 	if err := context.CheckContext(ctx); err != nil {
 		result := auth.AuthResult{Code: rpc.UNAVAILABLE}
 		a.logAuthResult(result, ctx)
+		record := a.auditAuthResult(requestId, host, authConfig, nil, result)
 		context.Cancel(ctx)
 		span.RecordError(err)
 		span.SetStatus(otel_codes.Error, err.Error())
-		return a.deniedResponse(result), nil
+		return a.deniedResponse(result), record
+	}
+
+	// authconfigs can override the log level of their pipelines
+	if authConfig.LogLevel != nil && !debugSampled {
+		requestLogger = log.WithLevel(*authConfig.LogLevel).WithName("service").WithName("auth").WithValues("request id", requestId)
+		ctx = log.IntoContext(ctx, requestLogger)
 	}
 
 	pipeline := NewAuthPipeline(log.IntoContext(ctx, requestLogger), req, *authConfig)
 	result := pipeline.Evaluate()
 
+	if debugSampled {
+		var authJSON interface{}
+		_ = json.Unmarshal([]byte(pipeline.GetAuthorizationJSON()), &authJSON)
+		requestLogger.V(1).Info("evaluated authorization json", "authorization json", authJSON)
+	}
+
 	a.logAuthResult(result, ctx)
+	record := a.auditAuthResult(requestId, host, authConfig, pipeline.(*AuthPipeline), result)
 
 	if result.Success() {
-		return a.successResponse(result, ctx), nil
-	} else {
-		return a.deniedResponse(result), nil
+		return a.successResponse(result, ctx), record
+	}
+	return a.deniedResponse(result), record
+}
+
+// canaryTrack returns the track ("stable" or "canary") a request to an authconfig under canary rollout is routed to,
+// or an empty string if the authconfig is not under canary rollout
+func canaryTrack(authConfig *evaluators.AuthConfig, requestId string) string {
+	if authConfig == nil || authConfig.Canary == nil {
+		return ""
+	}
+	if _, canary := authConfig.Version(requestId); canary {
+		return "canary"
+	}
+	return "stable"
+}
+
+// lookup returns the AuthConfig of a host, or nil if not found
+func (a *AuthService) lookup(host string) *evaluators.AuthConfig {
+	authConfig := a.Index.Get(host)
+	// If the host is not found, but contains a port, remove the port part and retry.
+	if authConfig == nil && strings.Contains(host, ":") {
+		splitHost := strings.Split(host, ":")
+		authConfig = a.Index.Get(splitHost[0])
 	}
+	return authConfig
 }
 
 func (a *AuthService) successResponse(authResult auth.AuthResult, ctx gocontext.Context) *envoy_auth.CheckResponse {
@@ -307,7 +557,11 @@ func (a *AuthService) successResponse(authResult auth.AuthResult, ctx gocontext.
 		},
 		HttpResponse: &envoy_auth.CheckResponse_OkResponse{
 			OkResponse: &envoy_auth.OkHttpResponse{
-				Headers: buildResponseHeaders(authResult.Headers),
+				Headers:                 appendHeaderValues(buildResponseHeaders(authResult.Headers), authResult.HeadersToAppend),
+				ResponseHeadersToAdd:    appendHeaderValues(buildResponseHeaders(authResult.ResponseHeaders), authResult.ResponseHeadersToAppend),
+				HeadersToRemove:         authResult.HeadersToRemove,
+				QueryParametersToSet:    buildQueryParameters(authResult.QueryParametersToSet),
+				QueryParametersToRemove: authResult.QueryParametersToRemove,
 			},
 		},
 		DynamicMetadata: dynamicMetadata,
@@ -339,43 +593,63 @@ func (a *AuthService) deniedResponse(authResult auth.AuthResult) *envoy_auth.Che
 	}
 }
 
+// logAuthRequest logs the auth request, reduced to a few attributes at info level and in full at debug level.
+// The request is only serialized if the corresponding level is enabled.
 func (a *AuthService) logAuthRequest(req *envoy_auth.CheckRequest, ctx gocontext.Context) {
 	logger := log.FromContext(ctx)
 	reqAttrs := req.Attributes
-	httpAttrs := reqAttrs.Request.Http
-
-	reducedReq := &struct {
-		Source      *envoy_auth.AttributeContext_Peer `json:"source,omitempty"`
-		Destination *envoy_auth.AttributeContext_Peer `json:"destination,omitempty"`
-		Request     interface{}                       `json:"request,omitempty"`
-	}{
-		Source:      reqAttrs.Source,
-		Destination: reqAttrs.Destination,
-		Request: struct {
-			Http interface{} `json:"http,omitempty"`
-		}{
-			Http: struct {
-				Id     string `json:"id,omitempty"`
-				Method string `json:"method,omitempty"`
-				Path   string `json:"path,omitempty"`
-				Host   string `json:"host,omitempty"`
-				Scheme string `json:"scheme,omitempty"`
-			}{
-				Id:     httpAttrs.Id,
-				Method: httpAttrs.Method,
-				Path:   strings.Split(httpAttrs.Path, "?")[0],
-				Host:   httpAttrs.Host,
-				Scheme: httpAttrs.Scheme,
-			},
-		},
+
+	if logger.Enabled() {
+		logger.Info("incoming authorization request", "object", reducedAuthRequest{ // info
+			source:      reqAttrs.Source,
+			destination: reqAttrs.Destination,
+			http:        reqAttrs.Request.Http,
+		})
 	}
-	logger.Info("incoming authorization request", "object", reducedReq) // info
 
 	if logger.V(1).Enabled() {
 		logger.V(1).Info("incoming authorization request", "object", &reqAttrs) // debug
 	}
 }
 
+// reducedAuthRequest is the version of the auth request logged at info level.
+// It encodes itself into the log entry, so the attributes of the http request are logged without reflection.
+type reducedAuthRequest struct {
+	source      *envoy_auth.AttributeContext_Peer
+	destination *envoy_auth.AttributeContext_Peer
+	http        *envoy_auth.AttributeContext_HttpRequest
+}
+
+func (r reducedAuthRequest) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	if r.source != nil {
+		if err := enc.AddReflected("source", r.source); err != nil {
+			return err
+		}
+	}
+	if r.destination != nil {
+		if err := enc.AddReflected("destination", r.destination); err != nil {
+			return err
+		}
+	}
+	return enc.AddObject("request", zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+		return enc.AddObject("http", zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+			path, _, _ := strings.Cut(r.http.GetPath(), "?")
+			addNonEmptyString(enc, "id", r.http.GetId())
+			addNonEmptyString(enc, "method", r.http.GetMethod())
+			addNonEmptyString(enc, "path", path)
+			addNonEmptyString(enc, "host", r.http.GetHost())
+			addNonEmptyString(enc, "scheme", r.http.GetScheme())
+			return nil
+		}))
+	}))
+}
+
+func addNonEmptyString(enc zapcore.ObjectEncoder, key, value string) {
+	if value != "" {
+		enc.AddString(key, value)
+	}
+}
+
 func (a *AuthService) logAuthResult(result auth.AuthResult, ctx gocontext.Context) {
 	logger := log.FromContext(ctx)
 	success := result.Success()
@@ -400,6 +674,65 @@ func (a *AuthService) logAuthResult(result auth.AuthResult, ctx gocontext.Contex
 	}
 }
 
+// auditAuthResult sends the record of the auth decision to the audit sink, if enabled, and returns it.
+// The pipeline is nil if the request was denied before evaluating the authconfig.
+func (a *AuthService) auditAuthResult(requestId, host string, authConfig *evaluators.AuthConfig, pipeline *AuthPipeline, result auth.AuthResult) *audit.Record {
+	if audit.Records == nil {
+		return nil
+	}
+
+	record := audit.Record{
+		Timestamp: time.Now().UTC(),
+		RequestId: requestId,
+		Host:      host,
+		Verdict:   evaluators.CallbackEventAllow,
+		Code:      result.Code.String(),
+		Reason:    result.Message,
+	}
+
+	if authConfig != nil {
+		record.AuthConfig = authConfig.Labels["namespace"] + "/" + authConfig.Labels["name"]
+	}
+
+	switch {
+	case pipeline != nil && pipeline.Decision != nil:
+		record.Verdict, _ = pipeline.Decision["verdict"].(string)
+		if timings, ok := pipeline.Decision["timings"].(map[string]interface{}); ok && len(timings) > 0 {
+			record.Timings = timings
+		}
+	case result.Code == rpc.UNAVAILABLE:
+		record.Verdict = evaluators.CallbackEventError
+	case !result.Success():
+		record.Verdict = evaluators.CallbackEventDeny
+	}
+
+	if pipeline != nil {
+		_, identity := pipeline.GetResolvedIdentity()
+		record.Subject = auditSubject(identity)
+	}
+
+	audit.Records.Log(record)
+	return &record
+}
+
+// auditSubject resolves the subject of the auth decision out of the resolved identity object, looking for the usual
+// claims and attributes that identify the user (`sub` and `username`)
+func auditSubject(identity interface{}) string {
+	if identity == nil {
+		return ""
+	}
+	identityJSON, err := json.Marshal(identity)
+	if err != nil {
+		return ""
+	}
+	for _, attr := range []string{"sub", "username"} {
+		if value := gjson.GetBytes(identityJSON, attr); value.Exists() {
+			return value.String()
+		}
+	}
+	return ""
+}
+
 func buildResponseHeaders(headers []map[string]string) []*envoy_core.HeaderValueOption {
 	responseHeaders := make([]*envoy_core.HeaderValueOption, 0)
 
@@ -417,6 +750,35 @@ func buildResponseHeaders(headers []map[string]string) []*envoy_core.HeaderValue
 	return responseHeaders
 }
 
+// appendHeaderValues sets the headers whose values must be appended to the existing values of the headers, instead of
+// replacing them
+func appendHeaderValues(headers []*envoy_core.HeaderValueOption, headersToAppend []string) []*envoy_core.HeaderValueOption {
+	for _, header := range headers {
+		for _, name := range headersToAppend {
+			if header.Header.GetKey() == name {
+				header.Append = wrapperspb.Bool(true)
+				break
+			}
+		}
+	}
+	return headers
+}
+
+// buildQueryParameters returns the query parameters sorted by key, so the order in which they are set is stable
+func buildQueryParameters(params map[string]string) []*envoy_core.QueryParameter {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	queryParameters := make([]*envoy_core.QueryParameter, 0, len(keys))
+	for _, key := range keys {
+		queryParameters = append(queryParameters, &envoy_core.QueryParameter{Key: key, Value: params[key]})
+	}
+	return queryParameters
+}
+
 func buildResponseHeadersWithReason(authReason string, extraHeaders []map[string]string) []*envoy_core.HeaderValueOption {
 	var headers []map[string]string
 
@@ -462,6 +824,96 @@ func admissionReviewFromPayload(payload []byte) *v1.AdmissionReview {
 	return nil
 }
 
+// extAuthzOriginalPath returns the path of the original request of an authorization request sent by the Envoy ext_authz
+// HTTP filter, i.e. the path after HTTPExtAuthzBasePath, with the query string, the same as in the gRPC protocol.
+// The second value is false if the request is not under HTTPExtAuthzBasePath.
+func extAuthzOriginalPath(u *url.URL) (string, bool) {
+	escapedPath := u.EscapedPath()
+	if escapedPath != HTTPExtAuthzBasePath && !strings.HasPrefix(escapedPath, HTTPExtAuthzBasePath+"/") {
+		return "", false
+	}
+	path := strings.TrimPrefix(escapedPath, HTTPExtAuthzBasePath)
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path = path + "?" + u.RawQuery
+	}
+	return path, true
+}
+
+// httpAuthorizationProtocol is the contract of the authorization requests to the raw HTTP interface
+type httpAuthorizationProtocol int
+
+const (
+	rawHTTPProtocol httpAuthorizationProtocol = iota
+	envoyExtAuthzProtocol
+	nginxAuthRequestProtocol
+	traefikForwardAuthProtocol
+)
+
+// OriginalRequestHeaders are the names of the HTTP headers that carry the attributes of the original request in the
+// authorization requests sent by proxies that do not forward the original request as is, e.g. NGINX auth_request
+type OriginalRequestHeaders struct {
+	// Request URI (path and query string) or absolute URL of the original request
+	URI string
+	// Method of the original request
+	Method string
+	// Host of the original request, if the URI is not an absolute URL
+	Host string
+}
+
+var defaultNginxAuthRequestHeaders = OriginalRequestHeaders{
+	URI:    "X-Original-URI",
+	Method: "X-Original-Method",
+	Host:   "X-Forwarded-Host",
+}
+
+var traefikForwardAuthHeaders = OriginalRequestHeaders{
+	URI:    "X-Forwarded-Uri",
+	Method: "X-Forwarded-Method",
+	Host:   "X-Forwarded-Host",
+}
+
+// originalRequest returns the method, host and path (with the query string) of the original request read from the
+// headers of an authorization request, defaulting to the attributes of the authorization request itself.
+// The names of the headers not set are taken from the defaults.
+func (h OriginalRequestHeaders) originalRequest(req *http.Request, defaults OriginalRequestHeaders) (method, host, path string) {
+	headerOrDefault := func(name, defaultName, defaultValue string) string {
+		if name == "" {
+			name = defaultName
+		}
+		if value := req.Header.Get(name); value != "" {
+			return value
+		}
+		return defaultValue
+	}
+
+	method = headerOrDefault(h.Method, defaults.Method, req.Method)
+	host = headerOrDefault(h.Host, defaults.Host, req.Host)
+	path = headerOrDefault(h.URI, defaults.URI, "/")
+
+	if u, err := url.Parse(path); err == nil && u.IsAbs() {
+		host = u.Host
+		path = u.RequestURI()
+	}
+
+	return method, host, path
+}
+
+// nginxAuthRequestStatus maps the code of an authorization response to the status codes understood by the NGINX
+// auth_request module, which only tells 2xx (allow) from 401 and 403 (deny), and handles anything else as an error
+func nginxAuthRequestStatus(code rpc.Code) envoy_type.StatusCode {
+	switch code {
+	case rpc.OK:
+		return envoy_type.StatusCode_OK
+	case rpc.UNAUTHENTICATED:
+		return envoy_type.StatusCode_Unauthorized
+	default:
+		return envoy_type.StatusCode_Forbidden
+	}
+}
+
 // Writes the response status code to the raw HTTP external authorization and cancels the context
 func closeWithStatus(respStatusCode envoy_type.StatusCode, response http.ResponseWriter, ctx gocontext.Context, closingFunc func()) {
 	metrics.ReportMetric(httpServerHandledTotal, respStatusCode.String())
@@ -3,8 +3,11 @@ package service
 import (
 	gojson "encoding/json"
 	"fmt"
+	"math"
 	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/kuadrant/authorino/pkg/auth"
 	"github.com/kuadrant/authorino/pkg/context"
@@ -13,6 +16,7 @@ import (
 	"github.com/kuadrant/authorino/pkg/jsonexp"
 	"github.com/kuadrant/authorino/pkg/log"
 	"github.com/kuadrant/authorino/pkg/metrics"
+	"github.com/kuadrant/authorino/pkg/rar"
 
 	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
 	envoy_type "github.com/envoyproxy/go-control-plane/envoy/type/v3"
@@ -28,19 +32,60 @@ var (
 	authServerEvaluatorCancelledMetric = metrics.NewAuthConfigCounterMetric("auth_server_evaluator_cancelled", "Number of evaluations of individual authconfig rule cancelled by the auth server.", evaluatorMetricLabels...)
 	authServerEvaluatorIgnoredMetric   = metrics.NewAuthConfigCounterMetric("auth_server_evaluator_ignored", "Number of evaluations of individual authconfig rule ignored by the auth server.", evaluatorMetricLabels...)
 	authServerEvaluatorDeniedMetric    = metrics.NewAuthConfigCounterMetric("auth_server_evaluator_denied", "Number of denials from individual authconfig rule evaluated by the auth server.", evaluatorMetricLabels...)
+	authServerEvaluatorDryRunMetric    = metrics.NewAuthConfigCounterMetric("auth_server_evaluator_dry_run_denied", "Number of denials from individual authconfig rule in dry-run mode, not enforced by the auth server.", evaluatorMetricLabels...)
 	authServerEvaluatorDurationMetric  = metrics.NewAuthConfigDurationMetric("auth_server_evaluator_duration_seconds", "Response latency of individual authconfig rule evaluated by the auth server (in seconds).", evaluatorMetricLabels...)
 	// authconfig metrics
 	authServerAuthConfigTotalMetric          = metrics.NewAuthConfigCounterMetric("auth_server_authconfig_total", "Total number of authconfigs enforced by the auth server, partitioned by authconfig.")
 	authServerAuthConfigResponseStatusMetric = metrics.NewAuthConfigCounterMetric("auth_server_authconfig_response_status", "Response status of authconfigs sent by the auth server, partitioned by authconfig.", "status")
 	authServerAuthConfigDurationMetric       = metrics.NewAuthConfigDurationMetric("auth_server_authconfig_duration_seconds", "Response latency of authconfig enforced by the auth server (in seconds).")
+
+	// callbacks fired by all the pipelines and still running, drained on shutdown
+	callbacksInFlight sync.WaitGroup
+
+	// bounds the evaluators running concurrently across all the pipelines; unlimited if nil
+	evaluatorLimiter *concurrencyLimiter
+	// maximum number of evaluators of a pipeline running concurrently; unlimited if 0
+	maxConcurrentEvaluatorsPerPipeline int
+
+	// PipelinePhaseTimeouts are the time budgets of the phases of all the auth pipelines
+	PipelinePhaseTimeouts PhaseTimeouts
 )
 
+// PhaseTimeouts are the time budgets of each phase of an auth pipeline, so a slow phase does not consume the whole
+// timeout of the auth request and leave no time for the next phases to run.
+// A phase that exceeds its budget moves on with the evaluators that finished in time, as if the others failed.
+// A budget of 0 bounds the phase to the timeout of the auth request only.
+type PhaseTimeouts struct {
+	Identity      time.Duration
+	Metadata      time.Duration
+	Authorization time.Duration
+	Response      time.Duration
+}
+
+// LimitEvaluatorConcurrency bounds the number of evaluators running concurrently across all the auth pipelines (global)
+// and within each auth pipeline (per pipeline). The evaluators beyond the limits wait for a free worker, for as long as
+// the auth request does not time out. Unlimited if 0.
+// Must be called before the auth service starts serving requests.
+func LimitEvaluatorConcurrency(global, perPipeline int) {
+	if global > 0 {
+		evaluatorLimiter = newConcurrencyLimiter(global, math.MaxInt)
+	} else {
+		evaluatorLimiter = nil
+	}
+	if perPipeline > 0 {
+		maxConcurrentEvaluatorsPerPipeline = perPipeline
+	} else {
+		maxConcurrentEvaluatorsPerPipeline = 0
+	}
+}
+
 func init() {
 	metrics.Register(
 		authServerEvaluatorTotalMetric,
 		authServerEvaluatorCancelledMetric,
 		authServerEvaluatorIgnoredMetric,
 		authServerEvaluatorDeniedMetric,
+		authServerEvaluatorDryRunMetric,
 		authServerEvaluatorDurationMetric,
 		authServerAuthConfigTotalMetric,
 		authServerAuthConfigResponseStatusMetric,
@@ -83,7 +128,9 @@ func NewAuthPipeline(parentCtx gocontext.Context, req *envoy_auth.CheckRequest,
 		Authorization: make(map[*evaluators.AuthorizationConfig]interface{}),
 		Response:      make(map[*evaluators.ResponseConfig]interface{}),
 		Callbacks:     make(map[*evaluators.CallbackConfig]interface{}),
+		Exports:       make(map[string]interface{}),
 		Logger:        logger,
+		durations:     make(map[auth.AuthConfigEvaluator]time.Duration),
 		mu:            sync.RWMutex{},
 	}
 }
@@ -102,9 +149,29 @@ type AuthPipeline struct {
 	Response      map[*evaluators.ResponseConfig]interface{}
 	Callbacks     map[*evaluators.CallbackConfig]interface{}
 
+	// Named values exported by the authorization policies
+	Exports map[string]interface{}
+
+	// Details of the failure when access is denied
+	Denial map[string]interface{}
+
+	// Summary of the outcome of the auth pipeline, available to the callbacks
+	Decision map[string]interface{}
+
 	Logger log.Logger
 
 	mu sync.RWMutex
+
+	// tracks the callbacks still running after the auth decision is returned
+	pendingCallbacks sync.WaitGroup
+
+	// time taken by each evaluator
+	durations map[auth.AuthConfigEvaluator]time.Duration
+
+	// authorization JSON built since the last change to the pipeline, reused by all evaluators until the next change
+	authJSON *string
+	// incremented at every change to the pipeline, so an authorization JSON built concurrently to a change is not cached
+	revision uint64
 }
 
 func (pipeline *AuthPipeline) evaluateAuthConfig(config auth.AuthConfigEvaluator, ctx gocontext.Context, respChannel *chan EvaluationResponse, successCallback func(), failureCallback func()) {
@@ -124,8 +191,21 @@ func (pipeline *AuthPipeline) evaluateAuthConfig(config auth.AuthConfigEvaluator
 		}
 	}
 
+	if evaluatorLimiter != nil {
+		if !evaluatorLimiter.acquire(ctx) {
+			pipeline.Logger.V(1).Info("skipping config", "config", config, "reason", "no worker available")
+			metrics.ReportMetricWithObject(authServerEvaluatorCancelledMetric, monitorable, pipeline.metricLabels()...)
+			return
+		}
+		defer evaluatorLimiter.release()
+	}
+
 	evaluateFunc := func() {
-		if authObj, err := config.Call(pipeline, ctx); err != nil {
+		start := time.Now()
+		authObj, err := config.Call(pipeline, ctx)
+		pipeline.setDuration(config, time.Since(start))
+
+		if err != nil {
 			*respChannel <- newEvaluationResponse(config, nil, err)
 
 			metrics.ReportMetricWithObject(authServerEvaluatorDeniedMetric, monitorable, pipeline.metricLabels()...)
@@ -147,16 +227,30 @@ func (pipeline *AuthPipeline) evaluateAuthConfig(config auth.AuthConfigEvaluator
 
 type authConfigEvaluationStrategy func(conf auth.AuthConfigEvaluator, ctx gocontext.Context, respChannel *chan EvaluationResponse, cancel func())
 
-func (pipeline *AuthPipeline) evaluateAuthConfigs(authConfigs []auth.AuthConfigEvaluator, respChannel *chan EvaluationResponse, evaluate authConfigEvaluationStrategy) {
-	ctx, cancel := gocontext.WithCancel(pipeline.Context)
-	waitGroup := new(sync.WaitGroup)
-	waitGroup.Add(len(authConfigs))
+func (pipeline *AuthPipeline) evaluateAuthConfigs(parentCtx gocontext.Context, authConfigs []auth.AuthConfigEvaluator, respChannel *chan EvaluationResponse, evaluate authConfigEvaluationStrategy) {
+	ctx, cancel := gocontext.WithCancel(parentCtx)
+
+	// the configs are evaluated by a pool of workers, sized up to the maximum number of evaluators per pipeline
+	workers := len(authConfigs)
+	if maxConcurrentEvaluatorsPerPipeline > 0 && workers > maxConcurrentEvaluatorsPerPipeline {
+		workers = maxConcurrentEvaluatorsPerPipeline
+	}
 
+	queue := make(chan auth.AuthConfigEvaluator, len(authConfigs))
 	for _, authConfig := range authConfigs {
-		objConfig := authConfig
+		queue <- authConfig
+	}
+	close(queue)
+
+	waitGroup := new(sync.WaitGroup)
+	waitGroup.Add(workers)
+
+	for i := 0; i < workers; i++ {
 		go func() {
 			defer waitGroup.Done()
-			evaluate(objConfig, ctx, respChannel, cancel)
+			for objConfig := range queue {
+				evaluate(objConfig, ctx, respChannel, cancel)
+			}
 		}()
 	}
 
@@ -164,23 +258,46 @@ func (pipeline *AuthPipeline) evaluateAuthConfigs(authConfigs []auth.AuthConfigE
 }
 
 func (pipeline *AuthPipeline) evaluateOneAuthConfig(authConfigs []auth.AuthConfigEvaluator, respChannel *chan EvaluationResponse) {
-	pipeline.evaluateAuthConfigs(authConfigs, respChannel, func(conf auth.AuthConfigEvaluator, ctx gocontext.Context, respChannel *chan EvaluationResponse, cancel func()) {
+	pipeline.evaluateOneAuthConfigWithContext(pipeline.Context, authConfigs, respChannel)
+}
+
+func (pipeline *AuthPipeline) evaluateOneAuthConfigWithContext(parentCtx gocontext.Context, authConfigs []auth.AuthConfigEvaluator, respChannel *chan EvaluationResponse) {
+	pipeline.evaluateAuthConfigs(parentCtx, authConfigs, respChannel, func(conf auth.AuthConfigEvaluator, ctx gocontext.Context, respChannel *chan EvaluationResponse, cancel func()) {
 		pipeline.evaluateAuthConfig(conf, ctx, respChannel, cancel, nil) // cancels the context if at least one thread succeeds
 	})
 }
 
 func (pipeline *AuthPipeline) evaluateAllAuthConfigs(authConfigs []auth.AuthConfigEvaluator, respChannel *chan EvaluationResponse) {
-	pipeline.evaluateAuthConfigs(authConfigs, respChannel, func(conf auth.AuthConfigEvaluator, ctx gocontext.Context, respChannel *chan EvaluationResponse, cancel func()) {
+	pipeline.evaluateAllAuthConfigsWithContext(pipeline.Context, authConfigs, respChannel)
+}
+
+func (pipeline *AuthPipeline) evaluateAllAuthConfigsWithContext(parentCtx gocontext.Context, authConfigs []auth.AuthConfigEvaluator, respChannel *chan EvaluationResponse) {
+	pipeline.evaluateAuthConfigs(parentCtx, authConfigs, respChannel, func(conf auth.AuthConfigEvaluator, ctx gocontext.Context, respChannel *chan EvaluationResponse, cancel func()) {
+		if isDryRun(conf) {
+			cancel = nil // a dry-run policy never stops the evaluation of the others
+		}
 		pipeline.evaluateAuthConfig(conf, ctx, respChannel, nil, cancel) // cancels the context if at least one thread fails
 	})
 }
 
 func (pipeline *AuthPipeline) evaluateAnyAuthConfig(authConfigs []auth.AuthConfigEvaluator, respChannel *chan EvaluationResponse) {
-	pipeline.evaluateAuthConfigs(authConfigs, respChannel, func(conf auth.AuthConfigEvaluator, ctx gocontext.Context, respChannel *chan EvaluationResponse, _ func()) {
+	pipeline.evaluateAnyAuthConfigWithContext(pipeline.Context, authConfigs, respChannel)
+}
+
+func (pipeline *AuthPipeline) evaluateAnyAuthConfigWithContext(parentCtx gocontext.Context, authConfigs []auth.AuthConfigEvaluator, respChannel *chan EvaluationResponse) {
+	pipeline.evaluateAuthConfigs(parentCtx, authConfigs, respChannel, func(conf auth.AuthConfigEvaluator, ctx gocontext.Context, respChannel *chan EvaluationResponse, _ func()) {
 		pipeline.evaluateAuthConfig(conf, ctx, respChannel, nil, nil)
 	})
 }
 
+// phaseContext returns the context of a phase of the pipeline, bound to the time budget of the phase, if any
+func (pipeline *AuthPipeline) phaseContext(budget time.Duration) (gocontext.Context, gocontext.CancelFunc) {
+	if budget <= 0 {
+		return gocontext.WithCancel(pipeline.Context)
+	}
+	return gocontext.WithTimeout(pipeline.Context, budget)
+}
+
 func groupAuthConfigsByPriority(authConfigs []auth.AuthConfigEvaluator) (map[int][]auth.AuthConfigEvaluator, []int) {
 	priorities := []int{}
 	authConfigsByPriority := make(map[int][]auth.AuthConfigEvaluator)
@@ -206,13 +323,16 @@ func (pipeline *AuthPipeline) evaluateIdentityConfigs() EvaluationResponse {
 	count := len(pipeline.AuthConfig.IdentityConfigs)
 	errors := make(map[string]string)
 
+	ctx, cancel := pipeline.phaseContext(PipelinePhaseTimeouts.Identity)
+	defer cancel()
+
 	for _, priority := range priorities {
 		configs := authConfigsByPriority[priority]
 		respChannel := make(chan EvaluationResponse, len(configs))
 
 		go func() {
 			defer close(respChannel)
-			pipeline.evaluateOneAuthConfig(configs, &respChannel)
+			pipeline.evaluateOneAuthConfigWithContext(ctx, configs, &respChannel)
 		}()
 
 		for resp := range respChannel {
@@ -251,6 +371,12 @@ func (pipeline *AuthPipeline) evaluateIdentityConfigs() EvaluationResponse {
 		}
 	}
 
+	// identity sources skipped for exceeding the time budget of the phase cannot be trusted as failed only
+	if err := ctx.Err(); err != nil {
+		logger.Info("identity phase timed out", "reason", err)
+		return EvaluationResponse{Error: fmt.Errorf("identity verification timed out")}
+	}
+
 	errorsJSON, _ := gojson.Marshal(errors)
 	return EvaluationResponse{
 		Error: fmt.Errorf("%s", errorsJSON),
@@ -261,13 +387,16 @@ func (pipeline *AuthPipeline) evaluateMetadataConfigs() {
 	logger := pipeline.Logger.WithName("metadata").V(1)
 	authConfigsByPriority, priorities := groupAuthConfigsByPriority(pipeline.AuthConfig.MetadataConfigs)
 
+	ctx, cancel := pipeline.phaseContext(PipelinePhaseTimeouts.Metadata)
+	defer cancel()
+
 	for _, priority := range priorities {
 		configs := authConfigsByPriority[priority]
 		respChannel := make(chan EvaluationResponse, len(configs))
 
 		go func() {
 			defer close(respChannel)
-			pipeline.evaluateAnyAuthConfig(configs, &respChannel)
+			pipeline.evaluateAnyAuthConfigWithContext(ctx, configs, &respChannel)
 		}()
 
 		for resp := range respChannel {
@@ -295,43 +424,211 @@ func (pipeline *AuthPipeline) evaluateAuthorizationConfigs() EvaluationResponse
 
 	authConfigsByPriority, priorities := groupAuthConfigsByPriority(pipeline.AuthConfig.AuthorizationConfigs)
 
+	ctx, cancel := pipeline.phaseContext(PipelinePhaseTimeouts.Authorization)
+	defer cancel()
+
+	// with any strategy other than 'allOf', all policies are evaluated and the verdicts combined only at the end
+	strategy := pipeline.AuthConfig.AuthorizationStrategy
+	combineVerdicts := strategy != "" && strategy != evaluators.AllOfAuthorizationStrategy
+	var verdicts []EvaluationResponse
+
 	for _, priority := range priorities {
 		configs := authConfigsByPriority[priority]
 		respChannel := make(chan EvaluationResponse, len(configs))
 
 		go func() {
 			defer close(respChannel)
-			pipeline.evaluateAllAuthConfigs(configs, &respChannel)
+			if combineVerdicts {
+				pipeline.evaluateAnyAuthConfigWithContext(ctx, configs, &respChannel)
+			} else {
+				pipeline.evaluateAllAuthConfigsWithContext(ctx, configs, &respChannel)
+			}
 		}()
 
 		for resp := range respChannel {
 			conf, _ := resp.Evaluator.(*evaluators.AuthorizationConfig)
 			obj := resp.Object
 
+			if isDryRun(conf) {
+				pipeline.recordDryRunVerdict(conf, resp)
+				continue
+			}
+
 			if resp.Success() {
 				pipeline.setAuthorizationObj(conf, obj)
+				pipeline.exportAuthorizationValues(conf)
 				logger.Info("access granted", "config", conf, "object", obj)
 			} else {
 				logger.Info("access denied", "config", conf, "reason", resp.Error)
-				return resp
+				if !combineVerdicts {
+					return resp
+				}
 			}
+
+			verdicts = append(verdicts, resp)
 		}
 	}
 
+	// policies skipped for exceeding the time budget of the phase must not grant access
+	if err := ctx.Err(); err != nil {
+		logger.Info("authorization phase timed out", "reason", err)
+		return EvaluationResponse{Error: fmt.Errorf("authorization timed out")}
+	}
+
+	if combineVerdicts {
+		resp := combineAuthorizationVerdicts(strategy, verdicts)
+		logger.Info("authorization verdicts combined", "strategy", strategy, "allowed", resp.Success())
+		return resp
+	}
+
 	return EvaluationResponse{}
 }
 
+// exportAuthorizationValues resolves the values exported by an authorization policy that granted access and stores
+// them in the pipeline, so they are available to the next evaluators in the authorization JSON at `auth.exports`
+func (pipeline *AuthPipeline) exportAuthorizationValues(conf *evaluators.AuthorizationConfig) {
+	if conf == nil || len(conf.Exports) == 0 {
+		return
+	}
+
+	authJSON := pipeline.GetAuthorizationJSON()
+	exports := make(map[string]interface{}, len(conf.Exports))
+	for _, export := range conf.Exports {
+		exports[export.Name] = export.Value.ResolveFor(authJSON)
+	}
+
+	pipeline.mu.Lock()
+	defer pipeline.mu.Unlock()
+	for name, value := range exports {
+		pipeline.Exports[name] = value
+	}
+	pipeline.changed()
+	pipeline.Logger.WithName("authorization").V(1).Info("values exported", "config", conf, "exports", exports)
+}
+
+// recordDryRunVerdict stores the verdict of an authorization policy in dry-run mode in the authorization JSON, instead of
+// enforcing it, and reports the denials in a metric of their own
+func (pipeline *AuthPipeline) recordDryRunVerdict(conf *evaluators.AuthorizationConfig, resp EvaluationResponse) {
+	verdict := map[string]interface{}{"dryRun": true, "allowed": resp.Success()}
+
+	if resp.Success() {
+		verdict["object"] = resp.Object
+		pipeline.Logger.WithName("authorization").V(1).Info("access granted (dry run)", "config", conf, "object", resp.Object)
+	} else {
+		verdict["reason"] = resp.GetErrorMessage()
+		metrics.ReportMetricWithObject(authServerEvaluatorDryRunMetric, conf, pipeline.metricLabels()...)
+		pipeline.Logger.WithName("authorization").Info("access would be denied (dry run)", "config", conf, "reason", resp.Error)
+	}
+
+	pipeline.setAuthorizationObj(conf, verdict)
+}
+
+func isDryRun(conf auth.AuthConfigEvaluator) bool {
+	authorizationConfig, ok := conf.(*evaluators.AuthorizationConfig)
+	return ok && authorizationConfig != nil && authorizationConfig.DryRun
+}
+
+// combineAuthorizationVerdicts decides upon the verdicts of the authorization policies according to a strategy.
+// Policies whose conditions did not match are not among the verdicts. No verdicts at all means access granted.
+func combineAuthorizationVerdicts(strategy string, verdicts []EvaluationResponse) EvaluationResponse {
+	switch strategy {
+	case evaluators.AnyOfAuthorizationStrategy:
+		if len(verdicts) == 0 {
+			return EvaluationResponse{}
+		}
+		for _, verdict := range verdicts {
+			if verdict.Success() {
+				return verdict
+			}
+		}
+		return combineAuthorizationDenials(verdicts)
+
+	case evaluators.DenyOverridesAuthorizationStrategy, evaluators.PermitOverridesAuthorizationStrategy:
+		prevailing := prevailingAuthorizationVerdicts(verdicts)
+		var permits, denials []EvaluationResponse
+		for _, verdict := range prevailing {
+			if verdict.Success() {
+				permits = append(permits, verdict)
+			} else {
+				denials = append(denials, verdict)
+			}
+		}
+		if strategy == evaluators.DenyOverridesAuthorizationStrategy && len(denials) > 0 {
+			return combineAuthorizationDenials(denials)
+		}
+		if strategy == evaluators.PermitOverridesAuthorizationStrategy && len(permits) == 0 && len(denials) > 0 {
+			return combineAuthorizationDenials(denials)
+		}
+		return EvaluationResponse{}
+
+	default:
+		for _, verdict := range verdicts {
+			if !verdict.Success() {
+				return verdict
+			}
+		}
+		return EvaluationResponse{}
+	}
+}
+
+// prevailingAuthorizationVerdicts returns the verdicts of the authorization policies with the highest weight
+func prevailingAuthorizationVerdicts(verdicts []EvaluationResponse) []EvaluationResponse {
+	var prevailing []EvaluationResponse
+	maxWeight := 0
+
+	for _, verdict := range verdicts {
+		weight := 0
+		if conf, ok := verdict.Evaluator.(*evaluators.AuthorizationConfig); ok && conf != nil {
+			weight = conf.Weight
+		}
+		switch {
+		case len(prevailing) == 0 || weight > maxWeight:
+			prevailing = []EvaluationResponse{verdict}
+			maxWeight = weight
+		case weight == maxWeight:
+			prevailing = append(prevailing, verdict)
+		}
+	}
+
+	return prevailing
+}
+
+// combineAuthorizationDenials keeps the response of a single denial as is, so the reason is preserved, or otherwise
+// merges the reasons of multiple denials into a JSON map of policy name to reason
+func combineAuthorizationDenials(denials []EvaluationResponse) EvaluationResponse {
+	if len(denials) == 1 {
+		return denials[0]
+	}
+
+	errors := make(map[string]string)
+	for i, denial := range denials {
+		name := fmt.Sprintf("%d", i)
+		if conf, ok := denial.Evaluator.(*evaluators.AuthorizationConfig); ok && conf != nil {
+			name = conf.Name
+		}
+		errors[name] = denial.GetErrorMessage()
+	}
+
+	errorsJSON, _ := gojson.Marshal(errors)
+	return EvaluationResponse{
+		Error: fmt.Errorf("%s", errorsJSON),
+	}
+}
+
 func (pipeline *AuthPipeline) evaluateResponseConfigs() {
 	logger := pipeline.Logger.WithName("response").V(1)
 	authConfigsByPriority, priorities := groupAuthConfigsByPriority(pipeline.AuthConfig.ResponseConfigs)
 
+	ctx, cancel := pipeline.phaseContext(PipelinePhaseTimeouts.Response)
+	defer cancel()
+
 	for _, priority := range priorities {
 		configs := authConfigsByPriority[priority]
 		respChannel := make(chan EvaluationResponse, len(configs))
 
 		go func() {
 			defer close(respChannel)
-			pipeline.evaluateAllAuthConfigs(configs, &respChannel)
+			pipeline.evaluateAllAuthConfigsWithContext(ctx, configs, &respChannel)
 		}()
 
 		for resp := range respChannel {
@@ -348,31 +645,59 @@ func (pipeline *AuthPipeline) evaluateResponseConfigs() {
 	}
 }
 
-func (pipeline *AuthPipeline) executeCallbacks() {
-	logger := pipeline.Logger.WithName("callbacks").V(1)
-	authConfigsByPriority, priorities := groupAuthConfigsByPriority(pipeline.AuthConfig.CallbackConfigs)
+// executeCallbacks fires the callbacks in the background, without holding the auth decision.
+// Since the callbacks outlive the request, they are executed detached from the context of the request, each one bound
+// to its own timeout instead.
+// Only the callbacks triggered by the outcome of the auth pipeline (event) are fired.
+func (pipeline *AuthPipeline) executeCallbacks(event string) {
+	var callbackConfigs []auth.AuthConfigEvaluator
+	for _, conf := range pipeline.AuthConfig.CallbackConfigs {
+		if callbackConfig, ok := conf.(*evaluators.CallbackConfig); ok && !callbackConfig.TriggeredBy(event) {
+			continue
+		}
+		callbackConfigs = append(callbackConfigs, conf)
+	}
 
-	for _, priority := range priorities {
-		configs := authConfigsByPriority[priority]
-		respChannel := make(chan EvaluationResponse, len(configs))
+	if len(callbackConfigs) == 0 {
+		return
+	}
 
-		go func() {
-			defer close(respChannel)
-			pipeline.evaluateAnyAuthConfig(configs, &respChannel)
-		}()
+	logger := pipeline.Logger.WithName("callbacks").V(1)
+	ctx := log.IntoContext(gocontext.Background(), pipeline.Logger)
+	authConfigsByPriority, priorities := groupAuthConfigsByPriority(callbackConfigs)
 
-		for resp := range respChannel {
-			conf, _ := resp.Evaluator.(*evaluators.CallbackConfig)
-			obj := resp.Object
+	pipeline.pendingCallbacks.Add(1)
+	callbacksInFlight.Add(1)
+	// callbacks outlive the request, thus count as in flight on their own
+	done := pipeline.AuthConfig.InFlight.Add()
 
-			if resp.Success() {
-				pipeline.setCallbackObj(conf, obj)
-				logger.Info("callback executed", "config", conf, "object", obj)
-			} else {
-				logger.Info("cannot execute callback", "config", conf, "reason", resp.Error)
+	go func() {
+		defer callbacksInFlight.Done()
+		defer pipeline.pendingCallbacks.Done()
+		defer done()
+
+		for _, priority := range priorities {
+			configs := authConfigsByPriority[priority]
+			respChannel := make(chan EvaluationResponse, len(configs))
+
+			go func() {
+				defer close(respChannel)
+				pipeline.evaluateAnyAuthConfigWithContext(ctx, configs, &respChannel)
+			}()
+
+			for resp := range respChannel {
+				conf, _ := resp.Evaluator.(*evaluators.CallbackConfig)
+				obj := resp.Object
+
+				if resp.Success() {
+					pipeline.setCallbackObj(conf, obj)
+					logger.Info("callback executed", "config", conf, "object", obj)
+				} else {
+					logger.Info("cannot execute callback", "config", conf, "reason", resp.Error)
+				}
 			}
 		}
-	}
+	}()
 }
 
 func (pipeline *AuthPipeline) evaluateConditions(conditions jsonexp.Expression) error {
@@ -405,6 +730,7 @@ func (pipeline *AuthPipeline) setIdentityObj(conf *evaluators.IdentityConfig, ob
 	pipeline.mu.Lock()
 	defer pipeline.mu.Unlock()
 	pipeline.Identity[conf] = obj
+	pipeline.changed()
 }
 
 func (pipeline *AuthPipeline) getMetadataObjs() map[*evaluators.MetadataConfig]interface{} {
@@ -415,6 +741,7 @@ func (pipeline *AuthPipeline) setMetadataObj(conf *evaluators.MetadataConfig, ob
 	pipeline.mu.Lock()
 	defer pipeline.mu.Unlock()
 	pipeline.Metadata[conf] = obj
+	pipeline.changed()
 }
 
 func (pipeline *AuthPipeline) getAuthorizationObjs() map[*evaluators.AuthorizationConfig]interface{} {
@@ -425,6 +752,17 @@ func (pipeline *AuthPipeline) setAuthorizationObj(conf *evaluators.Authorization
 	pipeline.mu.Lock()
 	defer pipeline.mu.Unlock()
 	pipeline.Authorization[conf] = obj
+	pipeline.changed()
+}
+
+func (pipeline *AuthPipeline) getExports() map[string]interface{} {
+	pipeline.mu.RLock()
+	defer pipeline.mu.RUnlock()
+	exports := make(map[string]interface{}, len(pipeline.Exports))
+	for name, value := range pipeline.Exports {
+		exports[name] = value
+	}
+	return exports
 }
 
 func (pipeline *AuthPipeline) getResponseObjs() map[*evaluators.ResponseConfig]interface{} {
@@ -435,6 +773,7 @@ func (pipeline *AuthPipeline) setResponseObj(conf *evaluators.ResponseConfig, ob
 	pipeline.mu.Lock()
 	defer pipeline.mu.Unlock()
 	pipeline.Response[conf] = obj
+	pipeline.changed()
 }
 
 func (pipeline *AuthPipeline) getCallbackObjs() map[*evaluators.CallbackConfig]interface{} {
@@ -445,6 +784,7 @@ func (pipeline *AuthPipeline) setCallbackObj(conf *evaluators.CallbackConfig, ob
 	pipeline.mu.Lock()
 	defer pipeline.mu.Unlock()
 	pipeline.Callbacks[conf] = obj
+	pipeline.changed()
 }
 
 // Evaluate evaluates all steps of the auth pipeline (identity → metadata → policy enforcement)
@@ -459,6 +799,7 @@ func (pipeline *AuthPipeline) Evaluate() auth.AuthResult {
 	metrics.ReportMetric(authServerAuthConfigTotalMetric, pipeline.metricLabels()...)
 
 	authResult := make(chan auth.AuthResult)
+	event := evaluators.CallbackEventAllow
 
 	go func() {
 		defer close(authResult)
@@ -482,14 +823,36 @@ func (pipeline *AuthPipeline) Evaluate() auth.AuthResult {
 				} else {
 					// phase 4: response
 					pipeline.evaluateResponseConfigs()
-					responseHeaders, responseMetadata := evaluators.WrapResponses(pipeline.Response)
+					responseHeaders, responseMetadata, downstreamHeaders := evaluators.WrapResponses(pipeline.Response)
+					if namespace := pipeline.AuthConfig.DynamicMetadataNamespace; namespace != "" && len(responseMetadata) > 0 {
+						responseMetadata = map[string]interface{}{namespace: responseMetadata}
+					}
 					result.Headers = []map[string]string{responseHeaders}
 					result.Metadata = responseMetadata
+					result.ResponseHeaders = downstreamHeaders
+					result.HeadersToAppend = evaluators.GetHeadersToAppend(pipeline.Response, evaluators.HTTP_HEADER_WRAPPER)
+					result.ResponseHeadersToAppend = evaluators.GetHeadersToAppend(pipeline.Response, evaluators.HTTP_RESPONSE_HEADER_WRAPPER)
+					result.QueryParametersToSet = evaluators.GetQueryParametersToSet(pipeline.Response)
+					result.QueryParametersToRemove = pipeline.AuthConfig.QueryParametersToRemove
+					if identityConfig, _ := pipeline.GetResolvedIdentity(); identityConfig != nil {
+						identityEvaluator := identityConfig.(*evaluators.IdentityConfig)
+						result.HeadersToRemove = identityEvaluator.GetCredentialsHeadersToRemove()
+						headersToRewrite, headersToRemove := identityEvaluator.GetCredentialsHeadersToRewrite(pipeline.GetHttp().GetHeaders())
+						for name, value := range headersToRewrite {
+							responseHeaders[name] = value
+						}
+						result.HeadersToRemove = append(result.HeadersToRemove, headersToRemove...)
+					}
 				}
 			}
 
-			// phase 5: callbacks
-			pipeline.executeCallbacks()
+			if !result.Success() {
+				if pipeline.Context.Err() != nil {
+					event = evaluators.CallbackEventError
+				} else {
+					event = evaluators.CallbackEventDeny
+				}
+			}
 
 			pipeline.reportStatusMetric(result.Code)
 			authResult <- result
@@ -498,7 +861,29 @@ func (pipeline *AuthPipeline) Evaluate() auth.AuthResult {
 		metrics.ReportTimedMetric(authServerAuthConfigDurationMetric, evaluateFunc, pipeline.metricLabels()...)
 	}()
 
-	return <-authResult
+	decision := <-authResult
+	pipeline.setDecision(event, decision)
+
+	// phase 5: callbacks (fired after the auth decision)
+	pipeline.executeCallbacks(event)
+
+	return decision
+}
+
+// DrainCallbacks waits for the callbacks fired by the auth pipelines, which run after the auth decision is returned, to
+// finish, until the context is done
+func DrainCallbacks(ctx gocontext.Context) error {
+	drained := make(chan struct{})
+	go func() {
+		callbacksInFlight.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 func (pipeline *AuthPipeline) reportStatusMetric(rpcStatusCode rpc.Code) {
@@ -539,11 +924,42 @@ type authorizationJSON struct {
 	*WellKnownAttributes `json:""`
 }
 
+// GetAuthorizationJSON returns the authorization JSON of the pipeline at its current state.
+// The JSON is built once and then reused by all the evaluators that resolve values from it, until the next change to
+// the pipeline (e.g. an identity, metadata or authorization object is set).
+// Caching the string is what it takes to resolve the selectors without re-parsing: selectors are resolved by scanning
+// the string in place (see json.JSONValue.Compile), which allocates nothing but the values returned, whereas a parsed
+// tree would allocate every node of the JSON at every change of the pipeline and could not serve the modifiers and
+// queries of the selectors, which operate on the raw JSON. Building the JSON, the costly part, happens once per change.
 func (pipeline *AuthPipeline) GetAuthorizationJSON() string {
+	pipeline.mu.RLock()
+	cached, revision := pipeline.authJSON, pipeline.revision
+	pipeline.mu.RUnlock()
+	if cached != nil {
+		return *cached
+	}
+
+	authJSON := pipeline.buildAuthorizationJSON()
+
+	pipeline.mu.Lock()
+	defer pipeline.mu.Unlock()
+	if pipeline.revision == revision {
+		pipeline.authJSON = &authJSON
+	}
+	return authJSON
+}
+
+func (pipeline *AuthPipeline) buildAuthorizationJSON() string {
 	authData := make(map[string]interface{})
 
 	// identity
-	_, authData["identity"] = pipeline.GetResolvedIdentity()
+	_, identity := pipeline.GetResolvedIdentity()
+	authData["identity"] = identity
+
+	// authorization details (rich authorization requests)
+	if details := rar.FromIdentity(identity); len(details) > 0 {
+		authData["authorization_details"] = details
+	}
 
 	// metadata
 	metadata := make(map[string]interface{})
@@ -559,6 +975,11 @@ func (pipeline *AuthPipeline) GetAuthorizationJSON() string {
 	}
 	authData["authorization"] = authorization
 
+	// exports
+	if exports := pipeline.getExports(); len(exports) > 0 {
+		authData["exports"] = exports
+	}
+
 	// response
 	response := make(map[string]interface{})
 	for config, obj := range pipeline.getResponseObjs() {
@@ -566,6 +987,16 @@ func (pipeline *AuthPipeline) GetAuthorizationJSON() string {
 	}
 	authData["response"] = response
 
+	// denial
+	if pipeline.Denial != nil {
+		authData["denial"] = pipeline.Denial
+	}
+
+	// decision
+	if pipeline.Decision != nil {
+		authData["decision"] = pipeline.Decision
+	}
+
 	// callbacks
 	callbacks := make(map[string]interface{})
 	for config, obj := range pipeline.getCallbackObjs() {
@@ -579,11 +1010,13 @@ func (pipeline *AuthPipeline) GetAuthorizationJSON() string {
 }
 
 func (pipeline *AuthPipeline) customizeDenyWith(authResult auth.AuthResult, denyWith *evaluators.DenyWithValues) auth.AuthResult {
-	if denyWith != nil {
-		if denyWith.Code != 0 {
-			authResult.Status = envoy_type.StatusCode(denyWith.Code)
-		}
+	if denyWith != nil && denyWith.Code != 0 {
+		authResult.Status = envoy_type.StatusCode(denyWith.Code)
+	}
+
+	pipeline.setDenial(authResult)
 
+	if denyWith != nil {
 		authJSON := pipeline.GetAuthorizationJSON()
 
 		if denyWith.Message != nil {
@@ -602,11 +1035,112 @@ func (pipeline *AuthPipeline) customizeDenyWith(authResult auth.AuthResult, deny
 			}
 			authResult.Headers = headers
 		}
+
+		if denyWith.RedirectTo != nil {
+			if denyWith.Code == 0 {
+				authResult.Status = envoy_type.StatusCode_Found
+			}
+			location, _ := json.StringifyJSON(denyWith.RedirectTo.ResolveFor(authJSON))
+			authResult.Headers = append(authResult.Headers, map[string]string{"Location": location})
+		}
+
+		if denyWith.ProblemDetails != nil && denyWith.Body == nil {
+			status := authResult.Status
+			if status == 0 {
+				status = statusCodeMapping[authResult.Code]
+			}
+			authResult.Body = denyWith.ProblemDetails.Build(int(status), authResult.Message, pipeline.GetHttp().GetPath())
+			if !hasHeader(authResult.Headers, "content-type") {
+				authResult.Headers = append(authResult.Headers, map[string]string{"Content-Type": evaluators.ProblemDetailsContentType})
+			}
+		}
 	}
 
 	return authResult
 }
 
+func hasHeader(headers []map[string]string, name string) bool {
+	for _, header := range headers {
+		for key := range header {
+			if strings.EqualFold(key, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// setDenial exposes the details of the failure in the authorization JSON, so custom denial responses (and callbacks)
+// can refer to them
+func (pipeline *AuthPipeline) setDenial(authResult auth.AuthResult) {
+	status := authResult.Status
+	if status == 0 {
+		status = statusCodeMapping[authResult.Code]
+	}
+
+	pipeline.mu.Lock()
+	defer pipeline.mu.Unlock()
+
+	pipeline.Denial = map[string]interface{}{
+		"code":    authResult.Code.String(),
+		"status":  int(status),
+		"message": authResult.Message,
+	}
+	pipeline.changed()
+}
+
+func (pipeline *AuthPipeline) setDuration(conf auth.AuthConfigEvaluator, duration time.Duration) {
+	pipeline.mu.Lock()
+	defer pipeline.mu.Unlock()
+	pipeline.durations[conf] = duration
+}
+
+// setDecision summarizes the outcome of the auth pipeline in the authorization JSON, for the callbacks to refer to.
+// Besides the verdict and the response code, the decision includes the time taken by each evaluator (in milliseconds),
+// by phase of the pipeline.
+func (pipeline *AuthPipeline) setDecision(event string, authResult auth.AuthResult) {
+	pipeline.mu.Lock()
+	defer pipeline.mu.Unlock()
+
+	timings := make(map[string]interface{})
+	for _, phase := range []struct {
+		name    string
+		configs []auth.AuthConfigEvaluator
+	}{
+		{"identity", pipeline.AuthConfig.IdentityConfigs},
+		{"metadata", pipeline.AuthConfig.MetadataConfigs},
+		{"authorization", pipeline.AuthConfig.AuthorizationConfigs},
+		{"response", pipeline.AuthConfig.ResponseConfigs},
+	} {
+		phaseTimings := make(map[string]float64)
+		for _, conf := range phase.configs {
+			named, ok := conf.(auth.NamedEvaluator)
+			if !ok {
+				continue
+			}
+			if duration, ok := pipeline.durations[conf]; ok {
+				phaseTimings[named.GetName()] = float64(duration.Microseconds()) / 1000
+			}
+		}
+		if len(phaseTimings) > 0 {
+			timings[phase.name] = phaseTimings
+		}
+	}
+
+	pipeline.Decision = map[string]interface{}{
+		"verdict": event,
+		"code":    authResult.Code.String(),
+		"timings": timings,
+	}
+	pipeline.changed()
+}
+
+// changed invalidates the cached authorization JSON. Must be called with the lock held.
+func (pipeline *AuthPipeline) changed() {
+	pipeline.revision++
+	pipeline.authJSON = nil
+}
+
 func NewAuthorizationJSON(request *envoy_auth.CheckRequest, authPipeline map[string]any) string {
 	authJSON, _ := gojson.Marshal(&authorizationJSON{
 		Context:             request.Attributes,
@@ -4,13 +4,17 @@ import (
 	"context"
 	gojson "encoding/json"
 	"fmt"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/kuadrant/authorino/pkg/auth"
 	mock_auth "github.com/kuadrant/authorino/pkg/auth/mocks"
 	"github.com/kuadrant/authorino/pkg/evaluators"
 	"github.com/kuadrant/authorino/pkg/evaluators/authorization"
 	"github.com/kuadrant/authorino/pkg/evaluators/identity"
+	"github.com/kuadrant/authorino/pkg/evaluators/metadata"
+	"github.com/kuadrant/authorino/pkg/evaluators/response"
 	"github.com/kuadrant/authorino/pkg/httptest"
 	"github.com/kuadrant/authorino/pkg/json"
 	"github.com/kuadrant/authorino/pkg/jsonexp"
@@ -19,6 +23,7 @@ import (
 	envoy_type_v3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/gogo/googleapis/google/rpc"
 	"github.com/golang/mock/gomock"
+	"github.com/tidwall/gjson"
 	"gotest.tools/assert"
 )
 
@@ -38,7 +43,8 @@ const (
 		}
 	}`
 
-	oidcServerHost = "127.0.0.1:9009"
+	oidcServerHost     = "127.0.0.1:9009"
+	callbackServerHost = "127.0.0.1:9013"
 )
 
 var (
@@ -311,6 +317,54 @@ func TestEvaluateAnyAuthConfigsWithoutError(t *testing.T) {
 	assert.NilError(t, err)
 }
 
+type concurrencyCountingConfig struct {
+	running *atomic.Int32
+	max     *atomic.Int32
+}
+
+func (c *concurrencyCountingConfig) Call(_ auth.AuthPipeline, _ context.Context) (interface{}, error) {
+	running := c.running.Add(1)
+	defer c.running.Add(-1)
+	for {
+		max := c.max.Load()
+		if running <= max || c.max.CompareAndSwap(max, running) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	return nil, nil
+}
+
+func TestEvaluateAuthConfigsWithLimitedConcurrency(t *testing.T) {
+	defer LimitEvaluatorConcurrency(0, 0)
+
+	evaluate := func(count int) int32 {
+		running, max := &atomic.Int32{}, &atomic.Int32{}
+		var configs []auth.AuthConfigEvaluator
+		for i := 0; i < count; i++ {
+			configs = append(configs, &concurrencyCountingConfig{running: running, max: max})
+		}
+		pipeline := newTestAuthPipeline(evaluators.AuthConfig{}, &requestMock)
+		respChannel := make(chan EvaluationResponse, count)
+		pipeline.evaluateAnyAuthConfig(configs, &respChannel)
+		close(respChannel)
+		responses := 0
+		for range respChannel {
+			responses++
+		}
+		assert.Equal(t, responses, count)
+		return max.Load()
+	}
+
+	assert.Check(t, evaluate(10) > 3) // unlimited
+
+	LimitEvaluatorConcurrency(0, 3) // per pipeline
+	assert.Equal(t, evaluate(10), int32(3))
+
+	LimitEvaluatorConcurrency(2, 3) // global
+	assert.Equal(t, evaluate(10), int32(2))
+}
+
 func TestAuthPipelineGetAuthorizationJSON(t *testing.T) {
 	pipeline := newTestAuthPipeline(evaluators.AuthConfig{
 		IdentityConfigs: []auth.AuthConfigEvaluator{&successConfig{}, &successConfig{}},
@@ -361,6 +415,95 @@ func TestEvaluateWithCustomDenyOptions(t *testing.T) {
 	assert.Equal(t, string(headers), `[{"X-Static-Header":"some-value"},{"Location":"https://my-app.io/login?redirect_to=https://my-api/operation"}]`)
 }
 
+func TestEvaluateWithTemplatedDenialBody(t *testing.T) {
+	request := envoy_auth.CheckRequest{}
+	_ = gojson.Unmarshal([]byte(rawRequest), &request)
+
+	pipeline := newTestAuthPipeline(evaluators.AuthConfig{
+		IdentityConfigs:      []auth.AuthConfigEvaluator{&evaluators.IdentityConfig{Noop: &identity.Noop{}}},
+		AuthorizationConfigs: []auth.AuthConfigEvaluator{&failConfig{}},
+		DenyWith: evaluators.DenyWith{
+			Unauthorized: &evaluators.DenyWithValues{
+				Headers: []json.JSONProperty{{Name: "Content-Type", Value: json.JSONValue{Static: "application/json"}}},
+				Body:    &json.JSONValue{Pattern: `\{"error":"{auth.denial.code}","status":{auth.denial.status},"path":"{context.request.http.path}"\}`},
+			},
+		},
+	}, &request)
+
+	authResult := pipeline.Evaluate()
+	assert.Equal(t, authResult.Code, rpc.PERMISSION_DENIED)
+	assert.Equal(t, authResult.Body, `{"error":"PERMISSION_DENIED","status":403,"path":"/operation"}`)
+}
+
+func TestEvaluateWithProblemDetails(t *testing.T) {
+	request := envoy_auth.CheckRequest{}
+	_ = gojson.Unmarshal([]byte(rawRequest), &request)
+
+	pipeline := newTestAuthPipeline(evaluators.AuthConfig{
+		IdentityConfigs:      []auth.AuthConfigEvaluator{&evaluators.IdentityConfig{Noop: &identity.Noop{}}},
+		AuthorizationConfigs: []auth.AuthConfigEvaluator{&failConfig{}},
+		DenyWith: evaluators.DenyWith{
+			Unauthorized: &evaluators.DenyWithValues{
+				Message:        &json.JSONValue{Static: "Access to the operation is restricted"},
+				ProblemDetails: &evaluators.ProblemDetails{Type: "https://example.com/problems/forbidden"},
+			},
+		},
+	}, &request)
+
+	authResult := pipeline.Evaluate()
+	assert.Equal(t, authResult.Code, rpc.PERMISSION_DENIED)
+	assert.Equal(t, authResult.Body, `{"type":"https://example.com/problems/forbidden","title":"Forbidden","status":403,"detail":"Access to the operation is restricted","instance":"/operation"}`)
+	assert.DeepEqual(t, authResult.Headers, []map[string]string{{"Content-Type": "application/problem+json"}})
+}
+
+func TestEvaluateWithRedirectToLogin(t *testing.T) {
+	request := envoy_auth.CheckRequest{}
+	_ = gojson.Unmarshal([]byte(rawRequest), &request)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	authCredMock := mock_auth.NewMockAuthCredentials(ctrl)
+	authCredMock.EXPECT().GetCredentialsFromReq(request.GetAttributes().GetRequest().Http).Return("xxx", nil)
+	authCredMock.EXPECT().GetCredentialsKeySelector().Return("APIKEY")
+
+	pipeline := newTestAuthPipeline(evaluators.AuthConfig{
+		IdentityConfigs: []auth.AuthConfigEvaluator{&evaluators.IdentityConfig{Name: "faulty-api-key", APIKey: &identity.APIKey{AuthCredentials: authCredMock}}},
+		DenyWith: evaluators.DenyWith{
+			Unauthenticated: &evaluators.DenyWithValues{
+				RedirectTo: &json.JSONValue{Pattern: "https://idp.io/auth?redirect_uri=https://{context.request.http.host}{context.request.http.path}"},
+			},
+		},
+	}, &request)
+
+	authResult := pipeline.Evaluate()
+	assert.Equal(t, authResult.Code, rpc.UNAUTHENTICATED)
+	assert.Equal(t, authResult.Status, envoy_type_v3.StatusCode_Found)
+
+	headers, _ := gojson.Marshal(authResult.Headers)
+	assert.Equal(t, string(headers), `[{"WWW-Authenticate":"APIKEY realm=\"faulty-api-key\""},{"Location":"https://idp.io/auth?redirect_uri=https://my-api/operation"}]`)
+}
+
+func TestEvaluateWithStripCredentials(t *testing.T) {
+	request := envoy_auth.CheckRequest{}
+	_ = gojson.Unmarshal([]byte(rawRequest), &request)
+
+	creds := &auth.AuthCredential{KeySelector: "X-API-KEY", In: "custom_header"}
+
+	pipeline := newTestAuthPipeline(evaluators.AuthConfig{
+		IdentityConfigs: []auth.AuthConfigEvaluator{&evaluators.IdentityConfig{Name: "api-key", StripCredentials: true, Noop: &identity.Noop{AuthCredentials: creds}}},
+	}, &request)
+	authResult := pipeline.Evaluate()
+	assert.Equal(t, authResult.Code, rpc.OK)
+	assert.DeepEqual(t, authResult.HeadersToRemove, []string{"x-api-key"})
+
+	pipeline = newTestAuthPipeline(evaluators.AuthConfig{
+		IdentityConfigs: []auth.AuthConfigEvaluator{&evaluators.IdentityConfig{Name: "api-key", Noop: &identity.Noop{AuthCredentials: creds}}},
+	}, &request)
+	authResult = pipeline.Evaluate()
+	assert.Equal(t, authResult.Code, rpc.OK)
+	assert.Equal(t, len(authResult.HeadersToRemove), 0)
+}
+
 func TestEvaluatePriorities(t *testing.T) {
 	request := envoy_auth.CheckRequest{}
 	_ = gojson.Unmarshal([]byte(rawRequest), &request)
@@ -386,6 +529,181 @@ func TestEvaluatePriorities(t *testing.T) {
 	assert.Check(t, !authzConfig2.called)
 }
 
+type blockingConfig struct{}
+
+func (c *blockingConfig) Call(_ auth.AuthPipeline, ctx context.Context) (interface{}, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (c *blockingConfig) GetPriority() int {
+	return 0
+}
+
+func TestEvaluateWithPhaseTimeouts(t *testing.T) {
+	defer func() { PipelinePhaseTimeouts = PhaseTimeouts{} }()
+	PipelinePhaseTimeouts = PhaseTimeouts{Metadata: 10 * time.Millisecond, Authorization: 10 * time.Millisecond}
+
+	// slow metadata does not consume the budget of the authorization phase
+	authzConfig := &successConfig{}
+	pipeline := newTestAuthPipeline(evaluators.AuthConfig{
+		IdentityConfigs:      []auth.AuthConfigEvaluator{&evaluators.IdentityConfig{Noop: &identity.Noop{}}},
+		MetadataConfigs:      []auth.AuthConfigEvaluator{&blockingConfig{}},
+		AuthorizationConfigs: []auth.AuthConfigEvaluator{authzConfig},
+	}, &requestMock)
+	result := pipeline.Evaluate()
+	assert.Check(t, result.Success())
+	assert.Check(t, authzConfig.called)
+
+	// slow authorization does not grant access
+	pipeline = newTestAuthPipeline(evaluators.AuthConfig{
+		IdentityConfigs:       []auth.AuthConfigEvaluator{&evaluators.IdentityConfig{Noop: &identity.Noop{}}},
+		AuthorizationConfigs:  []auth.AuthConfigEvaluator{&blockingConfig{}, &successConfig{}},
+		AuthorizationStrategy: evaluators.AnyOfAuthorizationStrategy,
+	}, &requestMock)
+	result = pipeline.Evaluate()
+	assert.Equal(t, result.Code, rpc.PERMISSION_DENIED)
+	assert.Equal(t, result.Message, "authorization timed out")
+}
+
+func newTestAuthorizationStrategyPipeline(strategy string, authzConfigs ...auth.AuthConfigEvaluator) *AuthPipeline {
+	return newTestAuthPipeline(evaluators.AuthConfig{
+		IdentityConfigs:       []auth.AuthConfigEvaluator{&evaluators.IdentityConfig{Noop: &identity.Noop{}}},
+		AuthorizationConfigs:  authzConfigs,
+		AuthorizationStrategy: strategy,
+	}, &requestMock)
+}
+
+func allowAuthorizationConfig(name string, weight int) *evaluators.AuthorizationConfig {
+	return &evaluators.AuthorizationConfig{Name: name, Weight: weight, JSON: &authorization.JSONPatternMatching{}}
+}
+
+func denyAuthorizationConfig(name string, weight int) *evaluators.AuthorizationConfig {
+	return &evaluators.AuthorizationConfig{Name: name, Weight: weight, JSON: &authorization.JSONPatternMatching{
+		Rules: jsonexp.Pattern{Selector: "context.request.http.method", Operator: jsonexp.EqualOperator, Value: "POST"},
+	}}
+}
+
+func TestEvaluateAuthorizationAllOfStrategy(t *testing.T) {
+	pipeline := newTestAuthorizationStrategyPipeline("", allowAuthorizationConfig("a", 0), denyAuthorizationConfig("b", 0))
+	authResult := pipeline.Evaluate()
+	assert.Equal(t, authResult.Code, rpc.PERMISSION_DENIED)
+	assert.Equal(t, authResult.Message, "Unauthorized")
+
+	pipeline = newTestAuthorizationStrategyPipeline(evaluators.AllOfAuthorizationStrategy, allowAuthorizationConfig("a", 0), allowAuthorizationConfig("b", 0))
+	authResult = pipeline.Evaluate()
+	assert.Equal(t, authResult.Code, rpc.OK)
+}
+
+func TestEvaluateAuthorizationAnyOfStrategy(t *testing.T) {
+	denied := denyAuthorizationConfig("b", 0)
+	denied.Priority = 1
+	pipeline := newTestAuthorizationStrategyPipeline(evaluators.AnyOfAuthorizationStrategy, allowAuthorizationConfig("a", 0), denied)
+	authResult := pipeline.Evaluate()
+	assert.Equal(t, authResult.Code, rpc.OK)
+
+	pipeline = newTestAuthorizationStrategyPipeline(evaluators.AnyOfAuthorizationStrategy, denyAuthorizationConfig("a", 0), denyAuthorizationConfig("b", 0))
+	authResult = pipeline.Evaluate()
+	assert.Equal(t, authResult.Code, rpc.PERMISSION_DENIED)
+	assert.Equal(t, authResult.Message, `{"a":"Unauthorized","b":"Unauthorized"}`)
+}
+
+func TestEvaluateAuthorizationDenyOverridesStrategy(t *testing.T) {
+	pipeline := newTestAuthorizationStrategyPipeline(evaluators.DenyOverridesAuthorizationStrategy, allowAuthorizationConfig("a", 0), denyAuthorizationConfig("b", 0))
+	authResult := pipeline.Evaluate()
+	assert.Equal(t, authResult.Code, rpc.PERMISSION_DENIED)
+	assert.Equal(t, authResult.Message, "Unauthorized")
+
+	// the denial is outweighed
+	pipeline = newTestAuthorizationStrategyPipeline(evaluators.DenyOverridesAuthorizationStrategy, allowAuthorizationConfig("a", 10), denyAuthorizationConfig("b", 0))
+	authResult = pipeline.Evaluate()
+	assert.Equal(t, authResult.Code, rpc.OK)
+}
+
+func TestEvaluateAuthorizationPermitOverridesStrategy(t *testing.T) {
+	pipeline := newTestAuthorizationStrategyPipeline(evaluators.PermitOverridesAuthorizationStrategy, allowAuthorizationConfig("a", 0), denyAuthorizationConfig("b", 0))
+	authResult := pipeline.Evaluate()
+	assert.Equal(t, authResult.Code, rpc.OK)
+
+	// the permission is outweighed
+	pipeline = newTestAuthorizationStrategyPipeline(evaluators.PermitOverridesAuthorizationStrategy, allowAuthorizationConfig("a", 0), denyAuthorizationConfig("b", 10))
+	authResult = pipeline.Evaluate()
+	assert.Equal(t, authResult.Code, rpc.PERMISSION_DENIED)
+}
+
+func TestEvaluateAuthorizationInDryRunMode(t *testing.T) {
+	dryRun := denyAuthorizationConfig("dry-run", 0)
+	dryRun.DryRun = true
+	enforced := allowAuthorizationConfig("enforced", 0)
+
+	pipeline := newTestAuthorizationStrategyPipeline("", dryRun, enforced)
+	authResult := pipeline.Evaluate()
+	assert.Equal(t, authResult.Code, rpc.OK)
+
+	authJSON := gjson.Parse(pipeline.GetAuthorizationJSON())
+	assert.Equal(t, authJSON.Get("auth.authorization.dry-run.dryRun").Bool(), true)
+	assert.Equal(t, authJSON.Get("auth.authorization.dry-run.allowed").Bool(), false)
+	assert.Equal(t, authJSON.Get("auth.authorization.dry-run.reason").String(), "Unauthorized")
+	assert.Equal(t, authJSON.Get("auth.authorization.enforced").Bool(), true)
+
+	// dry-run verdicts do not count when combining the verdicts of the other policies
+	dryRun = allowAuthorizationConfig("dry-run", 0)
+	dryRun.DryRun = true
+	pipeline = newTestAuthorizationStrategyPipeline(evaluators.AnyOfAuthorizationStrategy, dryRun, denyAuthorizationConfig("enforced", 0))
+	authResult = pipeline.Evaluate()
+	assert.Equal(t, authResult.Code, rpc.PERMISSION_DENIED)
+}
+
+func TestEvaluateAuthorizationWithExportedValues(t *testing.T) {
+	exporter := allowAuthorizationConfig("exporter", 0)
+	exporter.Exports = []json.JSONProperty{
+		{Name: "effectiveRole", Value: json.JSONValue{Static: "editor"}},
+		{Name: "method", Value: json.JSONValue{Pattern: "context.request.http.method"}},
+	}
+	consumer := &evaluators.AuthorizationConfig{Name: "consumer", Priority: 1, JSON: &authorization.JSONPatternMatching{
+		Rules: jsonexp.Pattern{Selector: "auth.exports.effectiveRole", Operator: jsonexp.EqualOperator, Value: "editor"},
+	}}
+
+	pipeline := newTestAuthorizationStrategyPipeline("", exporter, consumer)
+	authResult := pipeline.Evaluate()
+	assert.Equal(t, authResult.Code, rpc.OK)
+
+	authJSON := gjson.Parse(pipeline.GetAuthorizationJSON())
+	assert.Equal(t, authJSON.Get("auth.exports.effectiveRole").String(), "editor")
+	assert.Equal(t, authJSON.Get("auth.exports.method").String(), "GET")
+
+	// denied policies do not export values
+	denied := denyAuthorizationConfig("denied", 0)
+	denied.Exports = exporter.Exports
+	pipeline = newTestAuthorizationStrategyPipeline(evaluators.AnyOfAuthorizationStrategy, denied, consumer)
+	authResult = pipeline.Evaluate()
+	assert.Equal(t, authResult.Code, rpc.PERMISSION_DENIED)
+	assert.Equal(t, len(pipeline.Exports), 0)
+}
+
+func TestEvaluateDynamicMetadataNamespace(t *testing.T) {
+	authConfig := evaluators.AuthConfig{
+		IdentityConfigs: []auth.AuthConfigEvaluator{&evaluators.IdentityConfig{Noop: &identity.Noop{}}},
+		ResponseConfigs: []auth.AuthConfigEvaluator{
+			evaluators.NewResponseConfig("tier", 0, nil, evaluators.ENVOY_DYNAMIC_METADATA_WRAPPER, "", false),
+			evaluators.NewResponseConfig("x-tier", 0, nil, evaluators.HTTP_HEADER_WRAPPER, "", false),
+		},
+	}
+	for _, conf := range authConfig.ResponseConfigs {
+		conf.(*evaluators.ResponseConfig).Plain = &response.Plain{JSONValue: json.JSONValue{Static: "gold"}}
+	}
+
+	authResult := newTestAuthPipeline(authConfig, &requestMock).Evaluate()
+	assert.Equal(t, authResult.Code, rpc.OK)
+	assert.DeepEqual(t, authResult.Metadata, map[string]interface{}{"tier": "gold"})
+
+	authConfig.DynamicMetadataNamespace = "authorino"
+	authResult = newTestAuthPipeline(authConfig, &requestMock).Evaluate()
+	assert.Equal(t, authResult.Code, rpc.OK)
+	assert.DeepEqual(t, authResult.Metadata, map[string]interface{}{"authorino": map[string]interface{}{"tier": "gold"}})
+	assert.DeepEqual(t, authResult.Headers, []map[string]string{{"x-tier": "gold"}})
+}
+
 func TestAuthPipelineWithUnmatchingConditionsInTheAuthConfig(t *testing.T) {
 	request := envoy_auth.CheckRequest{}
 	_ = gojson.Unmarshal([]byte(rawRequest), &request)
@@ -466,6 +784,28 @@ func TestAuthPipelineWithUnmatchingConditionsInTheEvaluator(t *testing.T) {
 	assert.Check(t, !authzConfig.called)
 }
 
+func TestAuthPipelineWithConditionsInTheResponseConfigs(t *testing.T) {
+	request := envoy_auth.CheckRequest{}
+	_ = gojson.Unmarshal([]byte(rawRequest), &request)
+
+	publicGateway := jsonexp.All(jsonexp.Pattern{Selector: "context.request.http.host", Operator: jsonexp.EqualOperator, Value: "my-api"})
+	internalGateway := jsonexp.All(jsonexp.Pattern{Selector: "context.request.http.host", Operator: jsonexp.EqualOperator, Value: "my-api.internal"})
+
+	publicResponse := evaluators.NewResponseConfig("x-public", 0, publicGateway, evaluators.HTTP_HEADER_WRAPPER, "", false)
+	publicResponse.Plain = &response.Plain{JSONValue: json.JSONValue{Static: "public"}}
+	internalResponse := evaluators.NewResponseConfig("x-internal", 0, internalGateway, evaluators.HTTP_HEADER_WRAPPER, "", false)
+	internalResponse.Plain = &response.Plain{JSONValue: json.JSONValue{Static: "internal"}}
+
+	pipeline := newTestAuthPipeline(evaluators.AuthConfig{
+		IdentityConfigs: []auth.AuthConfigEvaluator{&evaluators.IdentityConfig{Noop: &identity.Noop{}}},
+		ResponseConfigs: []auth.AuthConfigEvaluator{publicResponse, internalResponse},
+	}, &request)
+
+	authResult := pipeline.Evaluate()
+	assert.Equal(t, authResult.Code, rpc.OK)
+	assert.DeepEqual(t, authResult.Headers, []map[string]string{{"x-public": "public"}})
+}
+
 func TestAuthPipelineWithMatchingConditionsInTheEvaluator(t *testing.T) {
 	request := envoy_auth.CheckRequest{}
 	_ = gojson.Unmarshal([]byte(rawRequest), &request)
@@ -504,6 +844,7 @@ func TestAuthPipelineWithCallbacks(t *testing.T) {
 	}, &requestMock)
 
 	_ = pipeline.Evaluate()
+	pipeline.pendingCallbacks.Wait()
 
 	assert.Check(t, callbackConfig.called)
 }
@@ -519,6 +860,7 @@ func TestAuthPipelineWithCallbacksUnauthorized(t *testing.T) {
 	}, &requestMock)
 
 	_ = pipeline.Evaluate()
+	pipeline.pendingCallbacks.Wait()
 
 	assert.Check(t, !authzConfig.called)
 	assert.Check(t, callbackConfig.called)
@@ -534,8 +876,105 @@ func TestAuthPipelineWithCallbacksForbidden(t *testing.T) {
 	}, &requestMock)
 
 	_ = pipeline.Evaluate()
+	pipeline.pendingCallbacks.Wait()
+
+	assert.Check(t, callbackConfig.called)
+}
+
+type slowCallbackConfig struct {
+	release chan struct{}
+	called  bool
+}
+
+func (c *slowCallbackConfig) Call(_ auth.AuthPipeline, ctx context.Context) (interface{}, error) {
+	<-c.release
+	c.called = true
+	return nil, ctx.Err()
+}
+
+func (c *slowCallbackConfig) GetPriority() int {
+	return 0
+}
+
+func TestAuthPipelineWithCallbacksNonBlocking(t *testing.T) {
+	callbackConfig := &slowCallbackConfig{release: make(chan struct{})}
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	p := NewAuthPipeline(ctx, &requestMock, evaluators.AuthConfig{
+		IdentityConfigs: []auth.AuthConfigEvaluator{&evaluators.IdentityConfig{Noop: &identity.Noop{}}},
+		CallbackConfigs: []auth.AuthConfigEvaluator{callbackConfig},
+	})
+	pipeline, _ := p.(*AuthPipeline)
+
+	result := pipeline.Evaluate()
+	assert.Equal(t, result.Code, rpc.OK)
+
+	// the request is over
+	cancel()
+	close(callbackConfig.release)
 
+	pipeline.pendingCallbacks.Wait()
 	assert.Check(t, callbackConfig.called)
+	assert.Equal(t, len(pipeline.Callbacks), 1) // succeeded despite the context of the request being cancelled
+}
+
+func TestAuthPipelineWithCallbacksFilteredByEvent(t *testing.T) {
+	callbackServer := httptest.NewHttpServerMock(callbackServerHost, map[string]httptest.HttpServerMockResponseFunc{
+		"/callback": httptest.NewHttpServerMockResponseFuncPlain("OK"),
+	})
+	defer callbackServer.Close()
+
+	newCallback := func(name string, events ...string) *evaluators.CallbackConfig {
+		return &evaluators.CallbackConfig{
+			Name:   name,
+			Events: events,
+			HTTP: &metadata.GenericHttp{
+				Endpoint:        fmt.Sprintf("http://%s/callback", callbackServerHost),
+				Method:          "GET",
+				AuthCredentials: auth.NewAuthCredential("", "authorization_header"),
+			},
+		}
+	}
+
+	onAllow := newCallback("on-allow", evaluators.CallbackEventAllow)
+	onDeny := newCallback("on-deny", evaluators.CallbackEventDeny)
+	onError := newCallback("on-error", evaluators.CallbackEventError)
+	always := newCallback("always")
+	callbacks := []auth.AuthConfigEvaluator{onAllow, onDeny, onError, always}
+
+	allowed := newTestAuthPipeline(evaluators.AuthConfig{
+		IdentityConfigs: []auth.AuthConfigEvaluator{&evaluators.IdentityConfig{Noop: &identity.Noop{}}},
+		CallbackConfigs: callbacks,
+	}, &requestMock)
+	_ = allowed.Evaluate()
+	allowed.pendingCallbacks.Wait()
+	assert.Equal(t, len(allowed.Callbacks), 2)
+	assert.Check(t, allowed.Callbacks[onAllow] != nil)
+	assert.Check(t, allowed.Callbacks[always] != nil)
+
+	denied := newTestAuthPipeline(evaluators.AuthConfig{
+		IdentityConfigs:      []auth.AuthConfigEvaluator{&evaluators.IdentityConfig{Noop: &identity.Noop{}}},
+		AuthorizationConfigs: []auth.AuthConfigEvaluator{&failConfig{}},
+		CallbackConfigs:      callbacks,
+	}, &requestMock)
+	_ = denied.Evaluate()
+	denied.pendingCallbacks.Wait()
+	assert.Equal(t, len(denied.Callbacks), 2)
+	assert.Check(t, denied.Callbacks[onDeny] != nil)
+	assert.Check(t, denied.Callbacks[always] != nil)
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	p := NewAuthPipeline(ctx, &requestMock, evaluators.AuthConfig{
+		IdentityConfigs: []auth.AuthConfigEvaluator{&evaluators.IdentityConfig{Noop: &identity.Noop{AuthCredentials: auth.NewAuthCredential("", "authorization_header")}}},
+		CallbackConfigs: callbacks,
+	})
+	failed, _ := p.(*AuthPipeline)
+	_ = failed.Evaluate()
+	failed.pendingCallbacks.Wait()
+	assert.Equal(t, len(failed.Callbacks), 2)
+	assert.Check(t, failed.Callbacks[onError] != nil)
+	assert.Check(t, failed.Callbacks[always] != nil)
 }
 
 func BenchmarkAuthPipeline(b *testing.B) {
@@ -594,3 +1033,31 @@ func TestNewAuthorizationJSON(t *testing.T) {
 
 	assert.Equal(t, expectedAuthJSON, NewAuthorizationJSON(request, authPipeline))
 }
+
+func TestGetAuthorizationJSONCachedUntilChanged(t *testing.T) {
+	pipeline := newTestAuthorizationStrategyPipeline("", allowAuthorizationConfig("a", 0))
+
+	authJSON := pipeline.GetAuthorizationJSON()
+	assert.Check(t, pipeline.authJSON != nil)
+	assert.Equal(t, pipeline.GetAuthorizationJSON(), authJSON)
+
+	identityConfig := &evaluators.IdentityConfig{Name: "noop"}
+	pipeline.setIdentityObj(identityConfig, map[string]interface{}{"sub": "john"})
+	assert.Check(t, pipeline.authJSON == nil)
+	assert.Equal(t, gjson.Get(pipeline.GetAuthorizationJSON(), "auth.identity.sub").String(), "john")
+
+	pipeline.setDenial(auth.AuthResult{Code: rpc.PERMISSION_DENIED, Message: "Unauthorized"})
+	assert.Equal(t, gjson.Get(pipeline.GetAuthorizationJSON(), "auth.denial.message").String(), "Unauthorized")
+}
+
+func TestDrainCallbacks(t *testing.T) {
+	assert.NilError(t, DrainCallbacks(context.TODO()))
+
+	callbacksInFlight.Add(1)
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorContains(t, DrainCallbacks(ctx), "deadline exceeded")
+
+	callbacksInFlight.Done()
+	assert.NilError(t, DrainCallbacks(context.TODO()))
+}
@@ -2,8 +2,11 @@ package service
 
 import (
 	"bytes"
+	gojson "encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"testing"
 
 	gohttptest "net/http/httptest"
@@ -11,7 +14,9 @@ import (
 	"golang.org/x/net/context"
 	"gotest.tools/assert"
 
+	"github.com/kuadrant/authorino/pkg/audit"
 	"github.com/kuadrant/authorino/pkg/auth"
+	mock_auth "github.com/kuadrant/authorino/pkg/auth/mocks"
 	"github.com/kuadrant/authorino/pkg/evaluators"
 	"github.com/kuadrant/authorino/pkg/evaluators/authorization"
 	"github.com/kuadrant/authorino/pkg/evaluators/identity"
@@ -19,13 +24,18 @@ import (
 	"github.com/kuadrant/authorino/pkg/index"
 	mock_index "github.com/kuadrant/authorino/pkg/index/mocks"
 	"github.com/kuadrant/authorino/pkg/json"
+	"github.com/kuadrant/authorino/pkg/log"
 
 	envoy_core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
 	envoy_type "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/gogo/googleapis/google/rpc"
 	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap/zapcore"
+	rpcstatus "google.golang.org/genproto/googleapis/rpc/status"
 	"k8s.io/apimachinery/pkg/runtime"
+	ctrlzap "sigs.k8s.io/controller-runtime/pkg/log/zap"
 )
 
 const (
@@ -54,6 +64,25 @@ func TestSuccessResponse(t *testing.T) {
 	headers := []map[string]string{{"X-Custom-Header": "some-value"}}
 	resp = service.successResponse(auth.AuthResult{Headers: headers}, nil).GetOkResponse()
 	assert.Equal(t, getHeader(resp.GetHeaders(), "X-Custom-Header"), "some-value")
+
+	responseHeaders := []map[string]string{{"Set-Cookie": "session=abc123; Path=/"}}
+	resp = service.successResponse(auth.AuthResult{ResponseHeaders: responseHeaders}, nil).GetOkResponse()
+	assert.Equal(t, len(resp.GetHeaders()), 0)
+	assert.Equal(t, getHeader(resp.GetResponseHeadersToAdd(), "Set-Cookie"), "session=abc123; Path=/")
+
+	headers = []map[string]string{{"X-Forwarded-Groups": "admin", "X-User": "john"}}
+	resp = service.successResponse(auth.AuthResult{Headers: headers, HeadersToAppend: []string{"X-Forwarded-Groups"}}, nil).GetOkResponse()
+	for _, h := range resp.GetHeaders() {
+		assert.Equal(t, h.GetAppend().GetValue(), h.Header.GetKey() == "X-Forwarded-Groups")
+	}
+
+	queryParameters := map[string]string{"tenant_id": "acme", "lang": "en"}
+	resp = service.successResponse(auth.AuthResult{QueryParametersToSet: queryParameters, QueryParametersToRemove: []string{"access_token"}}, nil).GetOkResponse()
+	assert.Equal(t, len(resp.GetQueryParametersToSet()), 2)
+	assert.Equal(t, resp.GetQueryParametersToSet()[0].GetKey(), "lang")
+	assert.Equal(t, resp.GetQueryParametersToSet()[1].GetKey(), "tenant_id")
+	assert.Equal(t, resp.GetQueryParametersToSet()[1].GetValue(), "acme")
+	assert.DeepEqual(t, resp.GetQueryParametersToRemove(), []string{"access_token"})
 }
 
 func TestDeniedResponse(t *testing.T) {
@@ -100,6 +129,9 @@ func TestAuthConfigLookup(t *testing.T) {
 	var resp *envoy_auth.CheckResponse
 	var err error
 
+	hits := testutil.ToFloat64(authServerLookupMetric.WithLabelValues("hit"))
+	misses := testutil.ToFloat64(authServerLookupMetric.WithLabelValues("miss"))
+
 	i.EXPECT().Get("host.com").Return(nil)
 	resp, err = service.Check(context.TODO(), &envoy_auth.CheckRequest{Attributes: &envoy_auth.AttributeContext{
 		Request: &envoy_auth.AttributeContext_Request{Http: &envoy_auth.AttributeContext_HttpRequest{Host: "host.com"}},
@@ -129,6 +161,9 @@ func TestAuthConfigLookup(t *testing.T) {
 	}})
 	assert.Equal(t, int32(resp.GetDeniedResponse().Status.Code), int32(401))
 	assert.NilError(t, err)
+
+	assert.Equal(t, testutil.ToFloat64(authServerLookupMetric.WithLabelValues("hit")), hits+2)
+	assert.Equal(t, testutil.ToFloat64(authServerLookupMetric.WithLabelValues("miss")), misses+2)
 }
 
 func TestBuildDynamicEnvoyMetadata(t *testing.T) {
@@ -260,6 +295,155 @@ func TestAuthServiceRawHTTPAuthorization_WithHeaders(t *testing.T) {
 	assert.Equal(t, response.Header().Get("X-Auth-Data"), `{"headers":{"authorization":"Bearer secret","content-type":"application/json"}}`)
 }
 
+func TestAuthServiceRawHTTPAuthorization_EnvoyExtAuthz(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	authConfig := mockAnonymousAccessAuthConfig()
+	authConfig.ResponseConfigs = []auth.AuthConfigEvaluator{&evaluators.ResponseConfig{
+		Name:       "x-auth-data",
+		Wrapper:    "httpHeader",
+		WrapperKey: "x-auth-data",
+		DynamicJSON: &response.DynamicJSON{
+			Properties: []json.JSONProperty{
+				{Name: "method", Value: json.JSONValue{Pattern: "context.request.http.method"}},
+				{Name: "path", Value: json.JSONValue{Pattern: "context.request.http.path"}},
+				{Name: "query", Value: json.JSONValue{Pattern: "request.query"}},
+			},
+		},
+	}}
+	indexMock := mock_index.NewMockIndex(mockController)
+	indexMock.EXPECT().Get("myapp.io").Return(authConfig)
+	authService := &AuthService{Index: indexMock, MaxHttpRequestBodySize: defaultMaxHttpRequestBytes}
+	request, _ := http.NewRequest("DELETE", "http://myapp.io/ext-authz/pets/123?force=true", http.NoBody)
+	response := gohttptest.NewRecorder()
+	authService.ServeHTTP(response, request)
+	assert.Equal(t, response.Code, 200)
+	assert.Equal(t, response.Header().Get("X-Auth-Data"), `{"method":"DELETE","path":"/pets/123?force=true","query":"force=true"}`)
+}
+
+func TestExtAuthzOriginalPath(t *testing.T) {
+	u, _ := url.Parse("http://myapp.io/ext-authz")
+	path, extAuthz := extAuthzOriginalPath(u)
+	assert.Check(t, extAuthz)
+	assert.Equal(t, path, "/")
+
+	u, _ = url.Parse("http://myapp.io/ext-authz/foo%2Fbar//baz")
+	path, extAuthz = extAuthzOriginalPath(u)
+	assert.Check(t, extAuthz)
+	assert.Equal(t, path, "/foo%2Fbar//baz")
+
+	u, _ = url.Parse("http://myapp.io/ext-authzz/foo")
+	_, extAuthz = extAuthzOriginalPath(u)
+	assert.Check(t, !extAuthz)
+
+	u, _ = url.Parse("http://myapp.io/check")
+	_, extAuthz = extAuthzOriginalPath(u)
+	assert.Check(t, !extAuthz)
+}
+
+func TestAuthServiceRawHTTPAuthorization_NginxAuthRequest(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	authConfig := mockAnonymousAccessAuthConfig()
+	authConfig.ResponseConfigs = []auth.AuthConfigEvaluator{&evaluators.ResponseConfig{
+		Name:       "x-auth-data",
+		Wrapper:    "httpHeader",
+		WrapperKey: "x-auth-data",
+		DynamicJSON: &response.DynamicJSON{
+			Properties: []json.JSONProperty{
+				{Name: "method", Value: json.JSONValue{Pattern: "request.method"}},
+				{Name: "path", Value: json.JSONValue{Pattern: "request.path"}},
+			},
+		},
+	}}
+	indexMock := mock_index.NewMockIndex(mockController)
+	indexMock.EXPECT().Get("myapp.io").Return(authConfig)
+	authService := &AuthService{Index: indexMock, MaxHttpRequestBodySize: defaultMaxHttpRequestBytes}
+	request, _ := http.NewRequest("GET", "http://authorino:5001/auth-request", http.NoBody)
+	request.Header = map[string][]string{"X-Original-Uri": {"/pets/123?force=true"}, "X-Original-Method": {"DELETE"}, "X-Forwarded-Host": {"myapp.io"}}
+	response := gohttptest.NewRecorder()
+	authService.ServeHTTP(response, request)
+	assert.Equal(t, response.Code, 200)
+	assert.Equal(t, response.Header().Get("X-Auth-Data"), `{"method":"DELETE","path":"/pets/123?force=true"}`)
+}
+
+func TestAuthServiceRawHTTPAuthorization_NginxAuthRequestCustomHeaders(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+	indexMock := mock_index.NewMockIndex(mockController)
+	indexMock.EXPECT().Get("myapp.io").Return(mockAnonymousAccessAuthConfig())
+	authService := &AuthService{Index: indexMock, MaxHttpRequestBodySize: defaultMaxHttpRequestBytes, NginxAuthRequest: OriginalRequestHeaders{URI: "X-Original-URL"}}
+	request, _ := http.NewRequest("GET", "http://authorino:5001/auth-request", http.NoBody)
+	request.Header = map[string][]string{"X-Original-Url": {"https://myapp.io/pets"}}
+	response := gohttptest.NewRecorder()
+	authService.ServeHTTP(response, request)
+	assert.Equal(t, response.Code, 200)
+}
+
+func TestNginxAuthRequestStatus(t *testing.T) {
+	assert.Equal(t, nginxAuthRequestStatus(rpc.OK), envoy_type.StatusCode_OK)
+	assert.Equal(t, nginxAuthRequestStatus(rpc.UNAUTHENTICATED), envoy_type.StatusCode_Unauthorized)
+	assert.Equal(t, nginxAuthRequestStatus(rpc.PERMISSION_DENIED), envoy_type.StatusCode_Forbidden)
+	assert.Equal(t, nginxAuthRequestStatus(rpc.NOT_FOUND), envoy_type.StatusCode_Forbidden)
+	assert.Equal(t, nginxAuthRequestStatus(rpc.RESOURCE_EXHAUSTED), envoy_type.StatusCode_Forbidden)
+}
+
+func TestAuthServiceRawHTTPAuthorization_TraefikForwardAuth(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	authConfig := mockAnonymousAccessAuthConfig()
+	authConfig.ResponseConfigs = []auth.AuthConfigEvaluator{&evaluators.ResponseConfig{
+		Name:       "x-auth-data",
+		Wrapper:    "httpHeader",
+		WrapperKey: "x-auth-data",
+		DynamicJSON: &response.DynamicJSON{
+			Properties: []json.JSONProperty{
+				{Name: "method", Value: json.JSONValue{Pattern: "request.method"}},
+				{Name: "path", Value: json.JSONValue{Pattern: "request.path"}},
+				{Name: "scheme", Value: json.JSONValue{Pattern: "request.scheme"}},
+			},
+		},
+	}}
+	indexMock := mock_index.NewMockIndex(mockController)
+	indexMock.EXPECT().Get("myapp.io").Return(authConfig)
+	authService := &AuthService{Index: indexMock, MaxHttpRequestBodySize: defaultMaxHttpRequestBytes}
+	request, _ := http.NewRequest("GET", "http://authorino:5001/forward-auth", http.NoBody)
+	request.Header = map[string][]string{"X-Forwarded-Uri": {"/pets?limit=10"}, "X-Forwarded-Method": {"POST"}, "X-Forwarded-Host": {"myapp.io"}, "X-Forwarded-Proto": {"https"}}
+	response := gohttptest.NewRecorder()
+	authService.ServeHTTP(response, request)
+	assert.Equal(t, response.Code, 200)
+	assert.Equal(t, response.Header().Get("X-Auth-Data"), `{"method":"POST","path":"/pets?limit=10","scheme":"https"}`)
+}
+
+func TestAuthServiceRawHTTPAuthorization_TraefikForwardAuthRedirect(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	authCredMock := mock_auth.NewMockAuthCredentials(mockController)
+	authCredMock.EXPECT().GetCredentialsFromReq(gomock.Any()).Return("xxx", nil)
+	authCredMock.EXPECT().GetCredentialsKeySelector().Return("APIKEY")
+	authConfig := &evaluators.AuthConfig{
+		IdentityConfigs: []auth.AuthConfigEvaluator{&evaluators.IdentityConfig{Name: "api-key", APIKey: &identity.APIKey{AuthCredentials: authCredMock}}},
+		DenyWith: evaluators.DenyWith{
+			Unauthenticated: &evaluators.DenyWithValues{
+				RedirectTo: &json.JSONValue{Pattern: "https://idp.io/auth?redirect_uri=https://{request.host}{request.path}"},
+			},
+		},
+	}
+	indexMock := mock_index.NewMockIndex(mockController)
+	indexMock.EXPECT().Get("myapp.io").Return(authConfig)
+	authService := &AuthService{Index: indexMock, MaxHttpRequestBodySize: defaultMaxHttpRequestBytes}
+	request, _ := http.NewRequest("GET", "http://authorino:5001/forward-auth", http.NoBody)
+	request.Header = map[string][]string{"X-Forwarded-Uri": {"/pets"}, "X-Forwarded-Method": {"GET"}, "X-Forwarded-Host": {"myapp.io"}}
+	response := gohttptest.NewRecorder()
+	authService.ServeHTTP(response, request)
+	assert.Equal(t, response.Code, 302)
+	assert.Equal(t, response.Header().Get("Location"), "https://idp.io/auth?redirect_uri=https://myapp.io/pets")
+}
+
 func TestAuthServiceRawHTTPAuthorization_K8sAdmissionReviewAuthorized(t *testing.T) {
 	mockController := gomock.NewController(t)
 	defer mockController.Finish()
@@ -279,7 +463,7 @@ func TestAuthServiceRawHTTPAuthorization_K8sAdmissionReviewForbidden(t *testing.
 	defer mockController.Finish()
 	authCred := auth.NewAuthCredential("", "")
 	identityConfig := &evaluators.IdentityConfig{Name: "anonymous", Noop: &identity.Noop{AuthCredentials: authCred}}
-	authorizationPolicy, _ := authorization.NewOPAAuthorization("a-policy", `allow = false`, nil, false, 0, context.TODO())
+	authorizationPolicy, _ := authorization.NewOPAAuthorization("a-policy", `allow = false`, nil, false, context.TODO())
 	authorizationConfig := &evaluators.AuthorizationConfig{Name: "always-deny", OPA: authorizationPolicy}
 	authConfig := &evaluators.AuthConfig{
 		IdentityConfigs:      []auth.AuthConfigEvaluator{identityConfig},
@@ -302,3 +486,145 @@ func mockAnonymousAccessAuthConfig() *evaluators.AuthConfig {
 	identityConfig := &evaluators.IdentityConfig{Name: "anonymous", Noop: &identity.Noop{AuthCredentials: authCred}}
 	return &evaluators.AuthConfig{IdentityConfigs: []auth.AuthConfigEvaluator{identityConfig}}
 }
+
+type auditRecordsMock struct {
+	records []audit.Record
+}
+
+func (l *auditRecordsMock) Log(record audit.Record) {
+	l.records = append(l.records, record)
+}
+
+func TestAuditAuthResult(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	records := &auditRecordsMock{}
+	audit.Records = records
+	defer func() { audit.Records = nil }()
+
+	authorizationPolicy, _ := authorization.NewOPAAuthorization("a-policy", `allow = false`, nil, false, context.TODO())
+	authConfig := mockAnonymousAccessAuthConfig()
+	authConfig.Labels = map[string]string{"namespace": "ns", "name": "myapp"}
+	authConfig.AuthorizationConfigs = []auth.AuthConfigEvaluator{&evaluators.AuthorizationConfig{Name: "always-deny", OPA: authorizationPolicy}}
+
+	indexMock := mock_index.NewMockIndex(mockController)
+	indexMock.EXPECT().Get("myapp.io").Return(authConfig)
+	indexMock.EXPECT().Get("other.io").Return(nil)
+	service := AuthService{Index: indexMock}
+
+	_, _ = service.Check(context.TODO(), &envoy_auth.CheckRequest{Attributes: &envoy_auth.AttributeContext{
+		Request: &envoy_auth.AttributeContext_Request{Http: &envoy_auth.AttributeContext_HttpRequest{Id: "req-1", Host: "myapp.io"}},
+	}})
+	_, _ = service.Check(context.TODO(), &envoy_auth.CheckRequest{Attributes: &envoy_auth.AttributeContext{
+		Request: &envoy_auth.AttributeContext_Request{Http: &envoy_auth.AttributeContext_HttpRequest{Id: "req-2", Host: "other.io"}},
+	}})
+
+	assert.Equal(t, len(records.records), 2)
+
+	record := records.records[0]
+	assert.Equal(t, record.RequestId, "req-1")
+	assert.Equal(t, record.Host, "myapp.io")
+	assert.Equal(t, record.AuthConfig, "ns/myapp")
+	assert.Equal(t, record.Verdict, "deny")
+	assert.Equal(t, record.Code, "PERMISSION_DENIED")
+	assert.Equal(t, record.Reason, "Unauthorized")
+	_, ok := record.Timings["authorization"].(map[string]float64)["always-deny"]
+	assert.Check(t, ok)
+
+	record = records.records[1]
+	assert.Equal(t, record.RequestId, "req-2")
+	assert.Equal(t, record.AuthConfig, "")
+	assert.Equal(t, record.Verdict, "deny")
+	assert.Equal(t, record.Code, "NOT_FOUND")
+	assert.Equal(t, record.Reason, RESPONSE_MESSAGE_SERVICE_NOT_FOUND)
+}
+
+func TestAuditDeduplicatedAuthResult(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	records := &auditRecordsMock{}
+	audit.Records = records
+	defer func() { audit.Records = nil }()
+
+	indexMock := mock_index.NewMockIndex(mockController)
+	indexMock.EXPECT().Get("myapp.io").Return(mockAnonymousAccessAuthConfig())
+	service := AuthService{Index: indexMock, deduplicator: newRequestDeduplicator()}
+
+	req := &envoy_auth.CheckRequest{Attributes: &envoy_auth.AttributeContext{
+		Request: &envoy_auth.AttributeContext_Request{Http: &envoy_auth.AttributeContext_HttpRequest{Id: "req-2", Host: "myapp.io"}},
+	}}
+
+	// an identical request in-flight, whose evaluation is done
+	call := &deduplicatedCall{done: make(chan struct{}), result: &deduplicatedResult{
+		resp:   &envoy_auth.CheckResponse{Status: &rpcstatus.Status{Code: int32(rpc.OK)}},
+		record: &audit.Record{RequestId: "req-1", Host: "myapp.io", AuthConfig: "ns/myapp", Subject: "john", Verdict: "allow", Code: "OK"},
+	}}
+	close(call.done)
+	service.deduplicator.calls[deduplicationKey(req, "myapp.io", "")] = call
+
+	resp, _ := service.Check(context.TODO(), req)
+	assert.Check(t, resp == call.result.resp)
+
+	assert.Equal(t, len(records.records), 1)
+	record := records.records[0]
+	assert.Equal(t, record.RequestId, "req-2")
+	assert.Equal(t, record.AuthConfig, "ns/myapp")
+	assert.Equal(t, record.Subject, "john")
+	assert.Equal(t, record.Verdict, "allow")
+	assert.Check(t, record.Deduplicated)
+	assert.Equal(t, call.result.record.RequestId, "req-1")
+}
+
+func TestAuditSubject(t *testing.T) {
+	assert.Equal(t, auditSubject(nil), "")
+	assert.Equal(t, auditSubject(map[string]interface{}{"sub": "1234", "username": "john"}), "1234")
+	assert.Equal(t, auditSubject(map[string]interface{}{"username": "john"}), "john")
+	assert.Equal(t, auditSubject(map[string]interface{}{"anonymous": true}), "")
+}
+
+func TestDebugSampling(t *testing.T) {
+	service := AuthService{}
+	for i := 0; i < 100; i++ {
+		assert.Check(t, !service.sampleDebug())
+	}
+
+	service.DebugSamplingRatio = 1
+	for i := 0; i < 100; i++ {
+		assert.Check(t, service.sampleDebug())
+	}
+
+	service.SetDebugSamplingRatio(0)
+	for i := 0; i < 100; i++ {
+		assert.Check(t, !service.sampleDebug())
+	}
+}
+
+func TestReducedAuthRequest(t *testing.T) {
+	request := envoy_auth.CheckRequest{}
+	_ = gojson.Unmarshal([]byte(rawRequest), &request)
+	request.Attributes.Request.Http.Path = "/operation?token=secret"
+
+	enc := zapcore.NewMapObjectEncoder()
+	assert.NilError(t, reducedAuthRequest{http: request.Attributes.Request.Http}.MarshalLogObject(enc))
+
+	_, source := enc.Fields["source"]
+	assert.Check(t, !source)
+	httpAttrs := enc.Fields["request"].(map[string]interface{})["http"].(map[string]interface{})
+	assert.DeepEqual(t, httpAttrs, map[string]interface{}{"method": "GET", "path": "/operation", "host": "my-api"})
+}
+
+func BenchmarkLogAuthRequest(b *testing.B) {
+	request := envoy_auth.CheckRequest{}
+	_ = gojson.Unmarshal([]byte(rawRequest), &request)
+
+	service := AuthService{}
+	ctx := log.IntoContext(context.TODO(), ctrlzap.New(ctrlzap.WriteTo(io.Discard), ctrlzap.Level(zapcore.InfoLevel)))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		service.logAuthRequest(&request, ctx)
+	}
+}
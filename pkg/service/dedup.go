@@ -0,0 +1,122 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+
+	"github.com/kuadrant/authorino/pkg/audit"
+
+	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	gocontext "golang.org/x/net/context"
+	"google.golang.org/protobuf/proto"
+)
+
+// headers that vary between identical requests (e.g. retries) and therefore are not part of the deduplication key
+var volatileHeaders = map[string]struct{}{
+	"x-request-id":                   {},
+	"x-envoy-attempt-count":          {},
+	"x-envoy-expected-rq-timeout-ms": {},
+	"traceparent":                    {},
+	"tracestate":                     {},
+	"x-b3-traceid":                   {},
+	"x-b3-spanid":                    {},
+	"x-b3-parentspanid":              {},
+	"x-b3-sampled":                   {},
+	"x-b3-flags":                     {},
+	"b3":                             {},
+}
+
+// requestDeduplicator makes identical auth requests received concurrently share the response of a single evaluation.
+// Only the evaluations in-flight are shared; a request received after the evaluation of an identical request is done
+// is evaluated again.
+type requestDeduplicator struct {
+	calls map[string]*deduplicatedCall
+	mu    sync.Mutex
+}
+
+type deduplicatedCall struct {
+	done   chan struct{}
+	result *deduplicatedResult
+}
+
+// deduplicatedResult is the outcome of an evaluation shared between identical requests: the response and the audit
+// record of the decision, if audit logging is enabled
+type deduplicatedResult struct {
+	resp   *envoy_auth.CheckResponse
+	record *audit.Record
+}
+
+func newRequestDeduplicator() *requestDeduplicator {
+	return &requestDeduplicator{
+		calls: make(map[string]*deduplicatedCall),
+	}
+}
+
+// do returns the response of the call in-flight for the same key, if any, or otherwise evaluates the request.
+// The result is nil if the context is done while waiting for the result of the call in-flight.
+// shared tells whether the result was obtained by another identical request.
+func (d *requestDeduplicator) do(ctx gocontext.Context, key string, evaluate func() *deduplicatedResult) (result *deduplicatedResult, shared bool) {
+	d.mu.Lock()
+	if call, found := d.calls[key]; found {
+		d.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.result, true
+		case <-ctx.Done():
+			return nil, true
+		}
+	}
+	call := &deduplicatedCall{done: make(chan struct{})}
+	d.calls[key] = call
+	d.mu.Unlock()
+
+	defer func() {
+		d.mu.Lock()
+		delete(d.calls, key)
+		d.mu.Unlock()
+		close(call.done)
+	}()
+
+	call.result = evaluate()
+	return call.result, false
+}
+
+// deduplicationKey identifies identical auth requests for the same host, i.e. requests with all the same attributes
+// (method, path, query, fragment, headers, body, raw body, source, destination, context extensions, metadata context,
+// etc), apart from the ones that vary between retries (e.g. request id, time, trace context and source port).
+// Requests to an authconfig under canary rollout are only identical if routed to the same track.
+func deduplicationKey(req *envoy_auth.CheckRequest, host, canaryTrack string) string {
+	attrs, _ := proto.Clone(req.GetAttributes()).(*envoy_auth.AttributeContext)
+	if attrs == nil {
+		attrs = &envoy_auth.AttributeContext{}
+	}
+
+	if request := attrs.GetRequest(); request != nil {
+		request.Time = nil
+		if httpAttrs := request.GetHttp(); httpAttrs != nil {
+			httpAttrs.Id = ""
+			for name := range httpAttrs.Headers {
+				if _, volatile := volatileHeaders[strings.ToLower(name)]; volatile {
+					delete(httpAttrs.Headers, name)
+				}
+			}
+		}
+	}
+
+	// the source port differs between connections of the same client
+	if socketAddress := attrs.GetSource().GetAddress().GetSocketAddress(); socketAddress != nil {
+		socketAddress.PortSpecifier = nil
+	}
+
+	serialized, _ := proto.MarshalOptions{Deterministic: true}.Marshal(attrs)
+
+	hash := sha256.New()
+	hash.Write([]byte(host))
+	hash.Write([]byte{0})
+	hash.Write([]byte(canaryTrack))
+	hash.Write([]byte{0})
+	hash.Write(serialized)
+	return hex.EncodeToString(hash.Sum(nil))
+}
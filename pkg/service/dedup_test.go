@@ -0,0 +1,140 @@
+package service
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+	"gotest.tools/assert"
+
+	envoy_core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestRequestDeduplicator(t *testing.T) {
+	deduplicator := newRequestDeduplicator()
+
+	var evaluations atomic.Int32
+	release := make(chan struct{})
+	evaluate := func() *deduplicatedResult {
+		evaluations.Add(1)
+		<-release
+		return &deduplicatedResult{resp: &envoy_auth.CheckResponse{}}
+	}
+
+	var waitGroup sync.WaitGroup
+	var sharedCount atomic.Int32
+	responses := make(chan *deduplicatedResult, 5)
+	for i := 0; i < 5; i++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			resp, shared := deduplicator.do(context.TODO(), "key", evaluate)
+			if shared {
+				sharedCount.Add(1)
+			}
+			responses <- resp
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	waitGroup.Wait()
+	close(responses)
+
+	assert.Equal(t, evaluations.Load(), int32(1))
+	assert.Equal(t, sharedCount.Load(), int32(4))
+	var first *deduplicatedResult
+	for resp := range responses {
+		if first == nil {
+			first = resp
+		}
+		assert.Check(t, resp == first)
+	}
+
+	// the evaluation is done, the response is not shared anymore
+	_, shared := deduplicator.do(context.TODO(), "key", func() *deduplicatedResult {
+		evaluations.Add(1)
+		return &deduplicatedResult{resp: &envoy_auth.CheckResponse{}}
+	})
+	assert.Check(t, !shared)
+	assert.Equal(t, evaluations.Load(), int32(2))
+	assert.Equal(t, len(deduplicator.calls), 0)
+}
+
+func TestRequestDeduplicatorContextDone(t *testing.T) {
+	deduplicator := newRequestDeduplicator()
+
+	release := make(chan struct{})
+	defer close(release)
+	go deduplicator.do(context.TODO(), "key", func() *deduplicatedResult {
+		<-release
+		return &deduplicatedResult{resp: &envoy_auth.CheckResponse{}}
+	})
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+	result, shared := deduplicator.do(ctx, "key", func() *deduplicatedResult { return nil })
+	assert.Check(t, shared)
+	assert.Check(t, result == nil)
+}
+
+func TestDeduplicationKey(t *testing.T) {
+	newRequest := func(headers map[string]string) *envoy_auth.CheckRequest {
+		return &envoy_auth.CheckRequest{Attributes: &envoy_auth.AttributeContext{
+			Source: &envoy_auth.AttributeContext_Peer{Address: &envoy_core.Address{Address: &envoy_core.Address_SocketAddress{SocketAddress: &envoy_core.SocketAddress{
+				Address:       "10.0.0.1",
+				PortSpecifier: &envoy_core.SocketAddress_PortValue{PortValue: 51234},
+			}}}},
+			Request: &envoy_auth.AttributeContext_Request{
+				Time: timestamppb.Now(),
+				Http: &envoy_auth.AttributeContext_HttpRequest{
+					Id:      "1",
+					Method:  "GET",
+					Path:    "/pets",
+					Host:    "myapp.io",
+					Headers: headers,
+				},
+			},
+		}}
+	}
+
+	key := deduplicationKey(newRequest(map[string]string{"authorization": "Bearer abc", "x-request-id": "1"}), "myapp.io", "")
+
+	// retry
+	retry := newRequest(map[string]string{"authorization": "Bearer abc", "x-request-id": "2", "x-envoy-attempt-count": "2"})
+	retry.Attributes.Request.Http.Id = "2"
+	retry.Attributes.Source.Address.GetSocketAddress().PortSpecifier = &envoy_core.SocketAddress_PortValue{PortValue: 51235}
+	assert.Equal(t, deduplicationKey(retry, "myapp.io", ""), key)
+	// other credentials
+	assert.Check(t, deduplicationKey(newRequest(map[string]string{"authorization": "Bearer xyz", "x-request-id": "1"}), "myapp.io", "") != key)
+	// other canary track
+	assert.Check(t, deduplicationKey(newRequest(map[string]string{"authorization": "Bearer abc", "x-request-id": "1"}), "myapp.io", "canary") != key)
+	// other host
+	assert.Check(t, deduplicationKey(newRequest(map[string]string{"authorization": "Bearer abc", "x-request-id": "1"}), "other.io", "") != key)
+
+	// any other attribute
+	for name, modify := range map[string]func(req *envoy_auth.CheckRequest){
+		"query":    func(req *envoy_auth.CheckRequest) { req.Attributes.Request.Http.Query = "limit=10" },
+		"fragment": func(req *envoy_auth.CheckRequest) { req.Attributes.Request.Http.Fragment = "top" },
+		"body":     func(req *envoy_auth.CheckRequest) { req.Attributes.Request.Http.Body = "{}" },
+		"raw body": func(req *envoy_auth.CheckRequest) { req.Attributes.Request.Http.RawBody = []byte{0x01} },
+		"destination": func(req *envoy_auth.CheckRequest) {
+			req.Attributes.Destination = &envoy_auth.AttributeContext_Peer{Principal: "spiffe://myapp"}
+		},
+		"metadata context": func(req *envoy_auth.CheckRequest) {
+			req.Attributes.MetadataContext = &envoy_core.Metadata{FilterMetadata: map[string]*structpb.Struct{"envoy.filters.http.jwt_authn": {}}}
+		},
+		"context extensions": func(req *envoy_auth.CheckRequest) {
+			req.Attributes.ContextExtensions = map[string]string{"tenant": "acme"}
+		},
+	} {
+		req := newRequest(map[string]string{"authorization": "Bearer abc", "x-request-id": "1"})
+		modify(req)
+		assert.Check(t, deduplicationKey(req, "myapp.io", "") != key, name)
+	}
+}
@@ -0,0 +1,333 @@
+package service
+
+import (
+	gocontext "context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	gojson "encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/evaluators/response"
+	"github.com/kuadrant/authorino/pkg/log"
+	"github.com/kuadrant/authorino/pkg/trace"
+
+	goidc "github.com/coreos/go-oidc"
+	"github.com/go-logr/logr"
+	gooauth2 "golang.org/x/oauth2"
+)
+
+const (
+	EdgeAuthBasePath = "/"
+
+	edgeAuthAuthorizePath  = "/authorize"
+	edgeAuthCallbackPath   = "/callback"
+	edgeAuthTokenPath      = "/token"
+	edgeAuthIntrospectPath = "/introspect"
+	edgeAuthDiscoveryPath  = "/.well-known/openid-configuration"
+	edgeAuthJWKSPath       = "/.well-known/openid-connect/certs"
+
+	// lifetime of the state of the authorization requests, i.e. for the users to sign in at the provider
+	edgeAuthStateMaxAge = 600
+)
+
+// claims of the ID tokens not copied to the sessions, as they are either replaced or only meaningful to the client
+var edgeAuthIDTokenOnlyClaims = []string{"iss", "aud", "azp", "exp", "iat", "nbf", "nonce", "at_hash", "c_hash"}
+
+// EdgeAuthService is an OpenID Connect relying party that handles the authorization code flow at the edge, so browser
+// apps can be protected without a separate proxy:
+// - /authorize redirects the user to sign in at the provider;
+// - /callback exchanges the authorization code and issues the session, as a Festival Wristband stored in a cookie;
+// - /token responds the wristband of the session, for apps to send it as a bearer token;
+// - /introspect responds the state of a session, for services deeper in the call chain to verify it, if authenticated.
+// The wristbands of the sessions can be verified by the AuthConfigs as any other JWT, with the OpenID Connect
+// Discovery endpoints served by the service.
+type EdgeAuthService struct {
+	// Issuer URL of the OpenID Connect provider
+	ProviderURL  string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// Issuer of the wristbands of the sessions, whose issuer is the public URL of the service; the redirect URL
+	// registered at the provider is <issuer>/callback
+	Wristband *response.Wristband
+	// Name of the cookie that stores the session
+	CookieName string
+	// Domain of the cookies, shared by the protected apps; only redirects to hosts within the domain are allowed.
+	// If empty, the cookies are host-only and only redirects to the host of the service are allowed.
+	CookieDomain string
+	// Token required in the Authorization header of the requests to the introspection endpoint (Bearer). If empty, the
+	// introspection endpoint is only served to the clients on the loopback interface.
+	IntrospectionToken string
+
+	provider *goidc.Provider
+	mu       sync.Mutex
+}
+
+// edgeAuthState is the state of an authorization request, stored in a cookie until the callback
+type edgeAuthState struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Redirect string `json:"rd"`
+}
+
+func (s *EdgeAuthService) ServeHTTP(writer http.ResponseWriter, req *http.Request) {
+	requestLogger := log.WithName("service").WithName("edge-auth").WithValues("method", req.Method, "path", req.URL.Path)
+	requestLogger.Info("request received")
+
+	switch strings.TrimSuffix(req.URL.Path, "/") {
+	case edgeAuthAuthorizePath:
+		s.authorize(writer, req, requestLogger)
+	case edgeAuthCallbackPath:
+		s.callback(writer, req, requestLogger)
+	case edgeAuthTokenPath:
+		s.token(writer, req, requestLogger)
+	case edgeAuthIntrospectPath:
+		s.introspect(writer, req, requestLogger)
+	case edgeAuthDiscoveryPath:
+		s.respondJSON(writer, s.Wristband.OpenIDConfig, requestLogger)
+	case edgeAuthJWKSPath:
+		s.respondJSON(writer, s.Wristband.JWKS, requestLogger)
+	default:
+		s.respondError(writer, http.StatusNotFound, "not found", requestLogger)
+	}
+}
+
+// authorize redirects the user to sign in at the provider, storing the state of the authorization request in a cookie
+// along with the URL to redirect the user back to (query param `rd`)
+func (s *EdgeAuthService) authorize(writer http.ResponseWriter, req *http.Request, logger logr.Logger) {
+	redirect := req.URL.Query().Get("rd")
+	if redirect == "" {
+		redirect = "/"
+	}
+	if !s.allowedRedirect(redirect) {
+		s.respondError(writer, http.StatusBadRequest, "invalid redirect", logger)
+		return
+	}
+
+	config, err := s.oauth2Config(req.Context())
+	if err != nil {
+		logger.Error(err, "failed to discover the openid connect provider")
+		s.respondError(writer, http.StatusBadGateway, "openid connect provider unavailable", logger)
+		return
+	}
+
+	state := edgeAuthState{State: randomString(), Nonce: randomString(), Redirect: redirect}
+	encodedState, _ := gojson.Marshal(state)
+	http.SetCookie(writer, s.cookie(s.stateCookieName(), base64.RawURLEncoding.EncodeToString(encodedState), edgeAuthStateMaxAge))
+
+	logger.Info("redirecting to the openid connect provider", "rd", redirect)
+	http.Redirect(writer, req, config.AuthCodeURL(state.State, goidc.Nonce(state.Nonce)), http.StatusFound)
+}
+
+// callback exchanges the authorization code for the tokens of the user, verifies the ID token and issues the session
+func (s *EdgeAuthService) callback(writer http.ResponseWriter, req *http.Request, logger logr.Logger) {
+	query := req.URL.Query()
+	if errorCode := query.Get("error"); errorCode != "" {
+		s.respondError(writer, http.StatusUnauthorized, fmt.Sprintf("sign in failed: %s", errorCode), logger)
+		return
+	}
+
+	var state edgeAuthState
+	if cookie, err := req.Cookie(s.stateCookieName()); err == nil {
+		if decoded, err := base64.RawURLEncoding.DecodeString(cookie.Value); err == nil {
+			_ = gojson.Unmarshal(decoded, &state)
+		}
+	}
+	if state.State == "" || query.Get("state") != state.State {
+		s.respondError(writer, http.StatusBadRequest, "invalid state", logger)
+		return
+	}
+
+	// both the token exchange and the verification of the id token send requests with the http client of the context
+	ctx := goidc.ClientContext(req.Context(), trace.HTTPClient)
+
+	config, err := s.oauth2Config(ctx)
+	if err != nil {
+		logger.Error(err, "failed to discover the openid connect provider")
+		s.respondError(writer, http.StatusBadGateway, "openid connect provider unavailable", logger)
+		return
+	}
+	token, err := config.Exchange(ctx, query.Get("code"))
+	if err != nil {
+		logger.Error(err, "failed to exchange the authorization code")
+		s.respondError(writer, http.StatusUnauthorized, "invalid authorization code", logger)
+		return
+	}
+	rawIDToken, _ := token.Extra("id_token").(string)
+	idToken, err := s.provider.Verifier(&goidc.Config{ClientID: s.ClientID}).Verify(ctx, rawIDToken)
+	if err != nil || idToken.Nonce != state.Nonce {
+		logger.Error(err, "invalid id token")
+		s.respondError(writer, http.StatusUnauthorized, "invalid id token", logger)
+		return
+	}
+
+	claims := response.Claims{}
+	if err := idToken.Claims(&claims); err != nil {
+		logger.Error(err, "failed to read the claims of the id token")
+		s.respondError(writer, http.StatusUnauthorized, "invalid id token", logger)
+		return
+	}
+	for _, claim := range edgeAuthIDTokenOnlyClaims {
+		delete(claims, claim)
+	}
+	session, err := s.Wristband.Sign(claims)
+	if err != nil {
+		logger.Error(err, "failed to issue the session")
+		s.respondError(writer, http.StatusInternalServerError, "failed to issue the session", logger)
+		return
+	}
+
+	http.SetCookie(writer, s.cookie(s.stateCookieName(), "", -1))
+	http.SetCookie(writer, s.cookie(s.CookieName, session, int(s.Wristband.TokenDuration)))
+
+	logger.Info("session issued", "sub", claims["sub"], "rd", state.Redirect)
+	http.Redirect(writer, req, state.Redirect, http.StatusFound)
+}
+
+// token responds the wristband of the session of the user, in the format of an OAuth 2.0 token response
+func (s *EdgeAuthService) token(writer http.ResponseWriter, req *http.Request, logger logr.Logger) {
+	cookie, err := req.Cookie(s.CookieName)
+	if err != nil {
+		s.respondError(writer, http.StatusUnauthorized, "no session", logger)
+		return
+	}
+	claims, err := s.Wristband.Verify(cookie.Value)
+	if err != nil {
+		s.respondError(writer, http.StatusUnauthorized, "invalid session", logger)
+		return
+	}
+
+	exp, _ := claims["exp"].(float64)
+	s.respondJSON(writer, func() (string, error) {
+		tokenResponse, err := gojson.Marshal(map[string]interface{}{
+			"access_token": cookie.Value,
+			"token_type":   "Bearer",
+			"expires_in":   int64(exp) - time.Now().Unix(),
+		})
+		return string(tokenResponse), err
+	}, logger)
+}
+
+// introspect responds the state of a session submitted in the `token` form param (rfc 7662)
+func (s *EdgeAuthService) introspect(writer http.ResponseWriter, req *http.Request, logger logr.Logger) {
+	if req.Method != http.MethodPost {
+		s.respondError(writer, http.StatusMethodNotAllowed, "method not allowed", logger)
+		return
+	}
+	if !s.introspectionAuthenticated(req) {
+		writer.Header().Set("WWW-Authenticate", `Bearer realm="introspect"`)
+		s.respondError(writer, http.StatusUnauthorized, "unauthorized", logger)
+		return
+	}
+	token := req.PostFormValue("token")
+	if token == "" {
+		s.respondError(writer, http.StatusBadRequest, "missing token", logger)
+		return
+	}
+	s.respondJSON(writer, func() (string, error) { return s.Wristband.Introspect(token) }, logger)
+}
+
+// introspectionAuthenticated tells whether the client of the introspection endpoint is authenticated with the
+// introspection token, as the endpoint must not be open to anyone holding a session (rfc 7662, section 2.1)
+func (s *EdgeAuthService) introspectionAuthenticated(req *http.Request) bool {
+	if s.IntrospectionToken == "" {
+		return isLoopback(req.RemoteAddr)
+	}
+	token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.IntrospectionToken)) == 1
+}
+
+// oauth2Config returns the config of the authorization code flow with the provider, discovering the endpoints of the
+// provider on first use
+func (s *EdgeAuthService) oauth2Config(ctx gocontext.Context) (*gooauth2.Config, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.provider == nil {
+		provider, err := goidc.NewProvider(goidc.ClientContext(ctx, trace.HTTPClient), s.ProviderURL)
+		if err != nil {
+			return nil, err
+		}
+		s.provider = provider
+	}
+
+	return &gooauth2.Config{
+		ClientID:     s.ClientID,
+		ClientSecret: s.ClientSecret,
+		Endpoint:     s.provider.Endpoint(),
+		RedirectURL:  strings.TrimSuffix(s.Wristband.GetIssuer(), "/") + edgeAuthCallbackPath,
+		Scopes:       s.Scopes,
+	}, nil
+}
+
+// allowedRedirect tells whether the users can be redirected to a URL after signing in, i.e. either a path or a URL
+// within the domain of the cookies, so the service cannot be used as an open redirector
+func (s *EdgeAuthService) allowedRedirect(redirect string) bool {
+	u, err := url.Parse(redirect)
+	if err != nil {
+		return false
+	}
+	if !u.IsAbs() {
+		return u.Host == "" && strings.HasPrefix(redirect, "/") && !strings.HasPrefix(redirect, "//") && !strings.HasPrefix(redirect, "/\\")
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return false
+	}
+	domain := strings.TrimPrefix(s.CookieDomain, ".")
+	if domain == "" {
+		issuer, _ := url.Parse(s.Wristband.GetIssuer())
+		return u.Hostname() == issuer.Hostname()
+	}
+	return u.Hostname() == domain || strings.HasSuffix(u.Hostname(), "."+domain)
+}
+
+func (s *EdgeAuthService) stateCookieName() string {
+	return s.CookieName + "_state"
+}
+
+func (s *EdgeAuthService) cookie(name, value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		Domain:   s.CookieDomain,
+		MaxAge:   maxAge,
+		Secure:   strings.HasPrefix(s.Wristband.GetIssuer(), "https://"),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+func (s *EdgeAuthService) respondJSON(writer http.ResponseWriter, body func() (string, error), logger logr.Logger) {
+	responseBody, err := body()
+	if err != nil {
+		logger.Error(err, "failed to build the response")
+		s.respondError(writer, http.StatusInternalServerError, "internal error", logger)
+		return
+	}
+	writer.Header().Set("Content-Type", "application/json")
+	writer.Header().Set("Cache-Control", "no-store")
+	if _, err := writer.Write([]byte(responseBody)); err != nil {
+		logger.Error(err, "failed to serve edge auth request")
+	} else {
+		logger.Info("response sent", "status", http.StatusOK)
+	}
+}
+
+func (s *EdgeAuthService) respondError(writer http.ResponseWriter, statusCode int, message string, logger logr.Logger) {
+	http.Error(writer, message, statusCode)
+	logger.Info("response sent", "status", statusCode, "reason", message)
+}
+
+func randomString() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	gojson "encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/evaluators/response"
+
+	"github.com/golang-jwt/jwt"
+	jose "gopkg.in/square/go-jose.v2"
+	"gotest.tools/assert"
+)
+
+// fakeOIDCProvider is an OpenID Connect provider that issues ID tokens for any authorization code
+type fakeOIDCProvider struct {
+	*httptest.Server
+	key   *rsa.PrivateKey
+	nonce string
+}
+
+func newFakeOIDCProvider(t *testing.T) *fakeOIDCProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NilError(t, err)
+
+	provider := &fakeOIDCProvider{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = gojson.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                 provider.URL,
+			"authorization_endpoint": provider.URL + "/authorize",
+			"token_endpoint":         provider.URL + "/token",
+			"jwks_uri":               provider.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = gojson.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "idp",
+				"alg": "RS256",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, _ *http.Request) {
+		idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":   provider.URL,
+			"aud":   "edge",
+			"sub":   "john",
+			"email": "john@example.com",
+			"nonce": provider.nonce,
+			"iat":   time.Now().Unix(),
+			"exp":   time.Now().Add(time.Minute).Unix(),
+		})
+		idToken.Header["kid"] = "idp"
+		signedIDToken, _ := idToken.SignedString(key)
+		w.Header().Set("Content-Type", "application/json")
+		_ = gojson.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "idp-access-token",
+			"token_type":   "Bearer",
+			"id_token":     signedIDToken,
+		})
+	})
+	provider.Server = httptest.NewServer(mux)
+	return provider
+}
+
+func newEdgeAuthService(t *testing.T, providerURL string) *EdgeAuthService {
+	signingKey, err := response.NewSigningKey("edge-auth", "HS256", []byte("secret"))
+	assert.NilError(t, err)
+	duration := int64(3600)
+	wristband, err := response.NewWristbandConfig("https://auth.example.com", nil, &duration, []jose.JSONWebKey{*signingKey})
+	assert.NilError(t, err)
+
+	return &EdgeAuthService{
+		ProviderURL:  providerURL,
+		ClientID:     "edge",
+		ClientSecret: "secret",
+		Scopes:       []string{"openid", "email"},
+		Wristband:    wristband,
+		CookieName:   "session",
+		CookieDomain: "example.com",
+	}
+}
+
+func findCookie(cookies []*http.Cookie, name string) *http.Cookie {
+	for _, cookie := range cookies {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+	return nil
+}
+
+func TestEdgeAuthService(t *testing.T) {
+	provider := newFakeOIDCProvider(t)
+	defer provider.Close()
+	edgeAuthService := newEdgeAuthService(t, provider.URL)
+
+	// authorize
+	resp := httptest.NewRecorder()
+	edgeAuthService.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/authorize?rd="+url.QueryEscape("https://app.example.com/pets"), nil))
+	assert.Equal(t, resp.Code, http.StatusFound)
+	authorizeURL, _ := url.Parse(resp.Header().Get("Location"))
+	assert.Equal(t, authorizeURL.Scheme+"://"+authorizeURL.Host+authorizeURL.Path, provider.URL+"/authorize")
+	assert.Equal(t, authorizeURL.Query().Get("client_id"), "edge")
+	assert.Equal(t, authorizeURL.Query().Get("redirect_uri"), "https://auth.example.com/callback")
+	stateCookie := findCookie(resp.Result().Cookies(), "session_state")
+	assert.Check(t, stateCookie != nil)
+	provider.nonce = authorizeURL.Query().Get("nonce")
+
+	// callback
+	req := httptest.NewRequest(http.MethodGet, "/callback?code=abc&state="+authorizeURL.Query().Get("state"), nil)
+	req.AddCookie(stateCookie)
+	resp = httptest.NewRecorder()
+	edgeAuthService.ServeHTTP(resp, req)
+	assert.Equal(t, resp.Code, http.StatusFound)
+	assert.Equal(t, resp.Header().Get("Location"), "https://app.example.com/pets")
+	sessionCookie := findCookie(resp.Result().Cookies(), "session")
+	assert.Check(t, sessionCookie != nil)
+	assert.Equal(t, sessionCookie.Domain, "example.com")
+	assert.Check(t, sessionCookie.HttpOnly)
+	assert.Check(t, sessionCookie.Secure)
+	assert.Equal(t, sessionCookie.MaxAge, 3600)
+	claims, err := edgeAuthService.Wristband.Verify(sessionCookie.Value)
+	assert.NilError(t, err)
+	assert.Equal(t, claims["iss"], "https://auth.example.com")
+	assert.Equal(t, claims["sub"], "john")
+	assert.Equal(t, claims["email"], "john@example.com")
+	_, found := claims["nonce"]
+	assert.Check(t, !found)
+
+	// token
+	req = httptest.NewRequest(http.MethodGet, "/token", nil)
+	req.AddCookie(sessionCookie)
+	resp = httptest.NewRecorder()
+	edgeAuthService.ServeHTTP(resp, req)
+	assert.Equal(t, resp.Code, http.StatusOK)
+	var tokenResponse map[string]interface{}
+	assert.NilError(t, gojson.Unmarshal(resp.Body.Bytes(), &tokenResponse))
+	assert.Equal(t, tokenResponse["access_token"], sessionCookie.Value)
+	assert.Equal(t, tokenResponse["token_type"], "Bearer")
+}
+
+func TestEdgeAuthServiceInvalidState(t *testing.T) {
+	provider := newFakeOIDCProvider(t)
+	defer provider.Close()
+	edgeAuthService := newEdgeAuthService(t, provider.URL)
+
+	resp := httptest.NewRecorder()
+	edgeAuthService.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/authorize?rd=/pets", nil))
+	assert.Equal(t, resp.Code, http.StatusFound)
+
+	req := httptest.NewRequest(http.MethodGet, "/callback?code=abc&state=forged", nil)
+	req.AddCookie(findCookie(resp.Result().Cookies(), "session_state"))
+	resp = httptest.NewRecorder()
+	edgeAuthService.ServeHTTP(resp, req)
+	assert.Equal(t, resp.Code, http.StatusBadRequest)
+	assert.Check(t, findCookie(resp.Result().Cookies(), "session") == nil)
+}
+
+func TestEdgeAuthServiceInvalidRedirect(t *testing.T) {
+	edgeAuthService := newEdgeAuthService(t, "http://idp.example.com")
+
+	for _, redirect := range []string{"https://evil.com/pets", "//evil.com/pets", "/\\evil.com", "javascript:alert(1)", "https://example.com.evil.com"} {
+		resp := httptest.NewRecorder()
+		edgeAuthService.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/authorize?rd="+url.QueryEscape(redirect), nil))
+		assert.Equal(t, resp.Code, http.StatusBadRequest, redirect)
+	}
+}
+
+func TestEdgeAuthServiceTokenWithoutSession(t *testing.T) {
+	edgeAuthService := newEdgeAuthService(t, "http://idp.example.com")
+
+	resp := httptest.NewRecorder()
+	edgeAuthService.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/token", nil))
+	assert.Equal(t, resp.Code, http.StatusUnauthorized)
+
+	req := httptest.NewRequest(http.MethodGet, "/token", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: "invalid"})
+	resp = httptest.NewRecorder()
+	edgeAuthService.ServeHTTP(resp, req)
+	assert.Equal(t, resp.Code, http.StatusUnauthorized)
+}
+
+func TestEdgeAuthServiceDiscovery(t *testing.T) {
+	edgeAuthService := newEdgeAuthService(t, "http://idp.example.com")
+
+	resp := httptest.NewRecorder()
+	edgeAuthService.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/.well-known/openid-configuration", nil))
+	assert.Equal(t, resp.Code, http.StatusOK)
+	assert.Check(t, strings.Contains(resp.Body.String(), `"issuer":"https://auth.example.com"`))
+}
+
+func TestEdgeAuthServiceIntrospect(t *testing.T) {
+	edgeAuthService := newEdgeAuthService(t, "http://idp.example.com")
+	edgeAuthService.IntrospectionToken = "secret"
+	session, _ := edgeAuthService.Wristband.Sign(response.Claims{"sub": "john"})
+
+	req := httptest.NewRequest(http.MethodPost, "/introspect", strings.NewReader(url.Values{"token": {session}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer secret")
+	resp := httptest.NewRecorder()
+	edgeAuthService.ServeHTTP(resp, req)
+	assert.Equal(t, resp.Code, http.StatusOK)
+	var introspection map[string]interface{}
+	assert.NilError(t, gojson.Unmarshal(resp.Body.Bytes(), &introspection))
+	assert.Equal(t, introspection["active"], true)
+	assert.Equal(t, introspection["sub"], "john")
+
+	req = httptest.NewRequest(http.MethodPost, "/introspect", strings.NewReader("token=invalid"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer secret")
+	resp = httptest.NewRecorder()
+	edgeAuthService.ServeHTTP(resp, req)
+	assert.Equal(t, resp.Code, http.StatusOK)
+	assert.Equal(t, resp.Body.String(), `{"active":false}`)
+
+	resp = httptest.NewRecorder()
+	edgeAuthService.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/introspect?token="+session, nil))
+	assert.Equal(t, resp.Code, http.StatusMethodNotAllowed)
+}
+
+func TestEdgeAuthServiceIntrospectUnauthenticated(t *testing.T) {
+	edgeAuthService := newEdgeAuthService(t, "http://idp.example.com")
+	session, _ := edgeAuthService.Wristband.Sign(response.Claims{"sub": "john"})
+
+	introspect := func(remoteAddr, authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/introspect", strings.NewReader(url.Values{"token": {session}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.RemoteAddr = remoteAddr
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		resp := httptest.NewRecorder()
+		edgeAuthService.ServeHTTP(resp, req)
+		return resp
+	}
+
+	// without an introspection token, only clients on the loopback interface are served
+	resp := introspect("10.0.0.1:51234", "")
+	assert.Equal(t, resp.Code, http.StatusUnauthorized)
+	assert.Equal(t, resp.Header().Get("WWW-Authenticate"), `Bearer realm="introspect"`)
+	assert.Equal(t, introspect("127.0.0.1:51234", "").Code, http.StatusOK)
+
+	edgeAuthService.IntrospectionToken = "secret"
+	assert.Equal(t, introspect("127.0.0.1:51234", "").Code, http.StatusUnauthorized)
+	assert.Equal(t, introspect("10.0.0.1:51234", "Bearer other").Code, http.StatusUnauthorized)
+	assert.Equal(t, introspect("10.0.0.1:51234", "Bearer secret").Code, http.StatusOK)
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	envoy_core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	envoy_ext_proc "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	envoy_type "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/gogo/googleapis/google/rpc"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// ExtProcService is the server API of the Envoy External Processing (ext_proc) gRPC protocol.
+// The requests are evaluated by the Auth Pipeline of the AuthService at the request headers, the same as with the
+// external authorization protocol; the response of the evaluation is then applied as mutations of the headers of both
+// the request and the response of the stream, or sent back as an immediate response in case of denial.
+type ExtProcService struct {
+	AuthService *AuthService
+}
+
+// Process handles a stream of processing requests, i.e. the phases of one HTTP request through Envoy
+func (s *ExtProcService) Process(stream envoy_ext_proc.ExternalProcessor_ProcessServer) error {
+	ctx := stream.Context()
+
+	// the ok response of the authorization check, whose mutations apply to the following phases of the stream
+	var okResponse *envoy_auth.OkHttpResponse
+
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var resp *envoy_ext_proc.ProcessingResponse
+
+		switch r := req.Request.(type) {
+		case *envoy_ext_proc.ProcessingRequest_RequestHeaders:
+			checkResponse, _ := s.AuthService.Check(ctx, extProcCheckRequest(r.RequestHeaders))
+			if rpc.Code(checkResponse.GetStatus().GetCode()) != rpc.OK {
+				resp = extProcImmediateResponse(checkResponse)
+				break
+			}
+			okResponse = checkResponse.GetOkResponse()
+			resp = &envoy_ext_proc.ProcessingResponse{
+				Response: &envoy_ext_proc.ProcessingResponse_RequestHeaders{
+					RequestHeaders: &envoy_ext_proc.HeadersResponse{
+						Response: &envoy_ext_proc.CommonResponse{
+							HeaderMutation: extProcRequestHeaderMutation(okResponse, extProcHeader(r.RequestHeaders, ":path")),
+						},
+					},
+				},
+				DynamicMetadata: checkResponse.GetDynamicMetadata(),
+			}
+		case *envoy_ext_proc.ProcessingRequest_ResponseHeaders:
+			resp = &envoy_ext_proc.ProcessingResponse{
+				Response: &envoy_ext_proc.ProcessingResponse_ResponseHeaders{
+					ResponseHeaders: &envoy_ext_proc.HeadersResponse{
+						Response: &envoy_ext_proc.CommonResponse{
+							HeaderMutation: extProcHeaderMutation(okResponse.GetResponseHeadersToAdd(), nil),
+						},
+					},
+				},
+			}
+		case *envoy_ext_proc.ProcessingRequest_RequestBody:
+			resp = &envoy_ext_proc.ProcessingResponse{
+				Response: &envoy_ext_proc.ProcessingResponse_RequestBody{RequestBody: &envoy_ext_proc.BodyResponse{}},
+			}
+		case *envoy_ext_proc.ProcessingRequest_ResponseBody:
+			resp = &envoy_ext_proc.ProcessingResponse{
+				Response: &envoy_ext_proc.ProcessingResponse_ResponseBody{ResponseBody: &envoy_ext_proc.BodyResponse{}},
+			}
+		case *envoy_ext_proc.ProcessingRequest_RequestTrailers:
+			resp = &envoy_ext_proc.ProcessingResponse{
+				Response: &envoy_ext_proc.ProcessingResponse_RequestTrailers{RequestTrailers: &envoy_ext_proc.TrailersResponse{}},
+			}
+		case *envoy_ext_proc.ProcessingRequest_ResponseTrailers:
+			resp = &envoy_ext_proc.ProcessingResponse{
+				Response: &envoy_ext_proc.ProcessingResponse_ResponseTrailers{ResponseTrailers: &envoy_ext_proc.TrailersResponse{}},
+			}
+		default:
+			continue
+		}
+
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+}
+
+// extProcCheckRequest builds the authorization request out of the request headers of an ext_proc stream.
+// The HTTP attributes are read from the pseudo-headers; repeated headers are joined by comma, as Envoy does for the
+// external authorization requests. The source, the destination and the protocol of the request are read from the
+// attributes selected in the `request_attributes` of the ext_proc filter, if any.
+func extProcCheckRequest(httpHeaders *envoy_ext_proc.HttpHeaders) *envoy_auth.CheckRequest {
+	headers := make(map[string]string)
+	for _, header := range httpHeaders.GetHeaders().GetHeaders() {
+		key := strings.ToLower(header.GetKey())
+		if value, found := headers[key]; found {
+			headers[key] = value + "," + header.GetValue()
+		} else {
+			headers[key] = header.GetValue()
+		}
+	}
+
+	attributes := extProcAttributes(httpHeaders)
+
+	checkRequest := &envoy_auth.CheckRequest{
+		Attributes: &envoy_auth.AttributeContext{
+			Source:      extProcPeer(attributes, "source", "connection.uri_san_peer_certificate"),
+			Destination: extProcPeer(attributes, "destination", "connection.uri_san_local_certificate"),
+			Request: &envoy_auth.AttributeContext_Request{
+				Http: &envoy_auth.AttributeContext_HttpRequest{
+					Method:   headers[":method"],
+					Headers:  headers,
+					Path:     headers[":path"],
+					Host:     headers[":authority"],
+					Scheme:   headers[":scheme"],
+					Protocol: attributes["request.protocol"].GetStringValue(),
+				},
+			},
+		},
+	}
+
+	if sni := attributes["connection.requested_server_name"].GetStringValue(); sni != "" {
+		checkRequest.Attributes.TlsSession = &envoy_auth.AttributeContext_TLSSession{Sni: sni}
+	}
+
+	return checkRequest
+}
+
+// extProcAttributes returns the attributes of the request sent by Envoy (e.g. `source.address`, `request.protocol`),
+// regardless of the namespace they are grouped by
+func extProcAttributes(httpHeaders *envoy_ext_proc.HttpHeaders) map[string]*structpb.Value {
+	attributes := make(map[string]*structpb.Value)
+	for _, group := range httpHeaders.GetAttributes() {
+		for name, value := range group.GetFields() {
+			attributes[name] = value
+		}
+	}
+	return attributes
+}
+
+// extProcPeer builds the source or the destination of the request out of the `<peer>.address` and `<peer>.port`
+// attributes and the URI SAN of the certificate of the peer, or returns nil if none of the attributes was sent
+func extProcPeer(attributes map[string]*structpb.Value, peer, principalAttribute string) *envoy_auth.AttributeContext_Peer {
+	address := attributes[peer+".address"].GetStringValue()
+	principal := attributes[principalAttribute].GetStringValue()
+	if address == "" && principal == "" {
+		return nil
+	}
+
+	result := &envoy_auth.AttributeContext_Peer{Principal: principal}
+	if address != "" {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			host = address
+		}
+		socketAddress := &envoy_core.SocketAddress{Address: host}
+		portValue, _ := strconv.ParseUint(port, 10, 32)
+		if p := attributes[peer+".port"].GetNumberValue(); p > 0 {
+			portValue = uint64(p)
+		}
+		if portValue > 0 {
+			socketAddress.PortSpecifier = &envoy_core.SocketAddress_PortValue{PortValue: uint32(portValue)}
+		}
+		result.Address = &envoy_core.Address{Address: &envoy_core.Address_SocketAddress{SocketAddress: socketAddress}}
+	}
+	return result
+}
+
+func extProcHeader(httpHeaders *envoy_ext_proc.HttpHeaders, name string) string {
+	for _, header := range httpHeaders.GetHeaders().GetHeaders() {
+		if strings.EqualFold(header.GetKey(), name) {
+			return header.GetValue()
+		}
+	}
+	return ""
+}
+
+// extProcRequestHeaderMutation translates the mutations of the request of an ok authorization response to the ext_proc
+// protocol. Changes to the query string are applied by rewriting the path.
+func extProcRequestHeaderMutation(okResponse *envoy_auth.OkHttpResponse, path string) *envoy_ext_proc.HeaderMutation {
+	headers := append([]*envoy_core.HeaderValueOption{}, okResponse.GetHeaders()...)
+	if len(okResponse.GetQueryParametersToSet()) > 0 || len(okResponse.GetQueryParametersToRemove()) > 0 {
+		headers = append(headers, &envoy_core.HeaderValueOption{
+			Header: &envoy_core.HeaderValue{
+				Key:   ":path",
+				Value: rewriteQueryParameters(path, okResponse.GetQueryParametersToSet(), okResponse.GetQueryParametersToRemove()),
+			},
+		})
+	}
+	return extProcHeaderMutation(headers, okResponse.GetHeadersToRemove())
+}
+
+func extProcHeaderMutation(headers []*envoy_core.HeaderValueOption, headersToRemove []string) *envoy_ext_proc.HeaderMutation {
+	if len(headers) == 0 && len(headersToRemove) == 0 {
+		return nil
+	}
+
+	mutation := &envoy_ext_proc.HeaderMutation{RemoveHeaders: headersToRemove}
+	for _, h := range headers {
+		header := &envoy_core.HeaderValueOption{
+			Header:       h.GetHeader(),
+			Append:       wrapperspb.Bool(h.GetAppend().GetValue()),
+			AppendAction: envoy_core.HeaderValueOption_OVERWRITE_IF_EXISTS_OR_ADD,
+		}
+		if h.GetAppend().GetValue() {
+			header.AppendAction = envoy_core.HeaderValueOption_APPEND_IF_EXISTS_OR_ADD
+		}
+		mutation.SetHeaders = append(mutation.SetHeaders, header)
+	}
+	return mutation
+}
+
+// rewriteQueryParameters sets and removes query parameters of a path
+func rewriteQueryParameters(path string, paramsToSet []*envoy_core.QueryParameter, paramsToRemove []string) string {
+	urlPath, rawQuery, _ := strings.Cut(path, "?")
+	query, _ := url.ParseQuery(rawQuery)
+	for _, param := range paramsToRemove {
+		query.Del(param)
+	}
+	for _, param := range paramsToSet {
+		query.Set(param.GetKey(), param.GetValue())
+	}
+	if len(query) == 0 {
+		return urlPath
+	}
+	return urlPath + "?" + query.Encode()
+}
+
+// extProcImmediateResponse translates a denied authorization response to the ext_proc protocol
+func extProcImmediateResponse(checkResponse *envoy_auth.CheckResponse) *envoy_ext_proc.ProcessingResponse {
+	deniedResponse := checkResponse.GetDeniedResponse()
+
+	status := deniedResponse.GetStatus()
+	if status == nil {
+		status = &envoy_type.HttpStatus{Code: statusCodeMapping[rpc.Code(checkResponse.GetStatus().GetCode())]}
+	}
+
+	return &envoy_ext_proc.ProcessingResponse{
+		Response: &envoy_ext_proc.ProcessingResponse_ImmediateResponse{
+			ImmediateResponse: &envoy_ext_proc.ImmediateResponse{
+				Status:  status,
+				Headers: extProcHeaderMutation(deniedResponse.GetHeaders(), nil),
+				Body:    deniedResponse.GetBody(),
+			},
+		},
+	}
+}
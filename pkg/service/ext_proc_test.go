@@ -0,0 +1,155 @@
+package service
+
+import (
+	"io"
+	"testing"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"gotest.tools/assert"
+
+	"github.com/kuadrant/authorino/pkg/auth"
+	"github.com/kuadrant/authorino/pkg/evaluators"
+	"github.com/kuadrant/authorino/pkg/evaluators/response"
+	mock_index "github.com/kuadrant/authorino/pkg/index/mocks"
+	"github.com/kuadrant/authorino/pkg/json"
+
+	envoy_core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_ext_proc "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	envoy_type "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/golang/mock/gomock"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+type extProcStreamMock struct {
+	grpc.ServerStream
+	requests  []*envoy_ext_proc.ProcessingRequest
+	responses []*envoy_ext_proc.ProcessingResponse
+}
+
+func (s *extProcStreamMock) Context() context.Context {
+	return context.Background()
+}
+
+func (s *extProcStreamMock) Send(resp *envoy_ext_proc.ProcessingResponse) error {
+	s.responses = append(s.responses, resp)
+	return nil
+}
+
+func (s *extProcStreamMock) Recv() (*envoy_ext_proc.ProcessingRequest, error) {
+	if len(s.requests) == 0 {
+		return nil, io.EOF
+	}
+	req := s.requests[0]
+	s.requests = s.requests[1:]
+	return req, nil
+}
+
+func extProcRequestHeaders(headers map[string]string) *envoy_ext_proc.ProcessingRequest {
+	headerMap := &envoy_core.HeaderMap{}
+	for key, value := range headers {
+		headerMap.Headers = append(headerMap.Headers, &envoy_core.HeaderValue{Key: key, Value: value})
+	}
+	return &envoy_ext_proc.ProcessingRequest{
+		Request: &envoy_ext_proc.ProcessingRequest_RequestHeaders{
+			RequestHeaders: &envoy_ext_proc.HttpHeaders{Headers: headerMap, EndOfStream: true},
+		},
+	}
+}
+
+func TestExtProcAuthorized(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	authConfig := mockAnonymousAccessAuthConfig()
+	authConfig.ResponseConfigs = []auth.AuthConfigEvaluator{
+		&evaluators.ResponseConfig{
+			Name:       "x-auth-data",
+			Wrapper:    "httpHeader",
+			WrapperKey: "x-auth-data",
+			Plain:      &response.Plain{JSONValue: json.JSONValue{Pattern: "request.method"}},
+		},
+	}
+	indexMock := mock_index.NewMockIndex(mockController)
+	indexMock.EXPECT().Get("myapp.io").Return(authConfig)
+
+	stream := &extProcStreamMock{requests: []*envoy_ext_proc.ProcessingRequest{
+		extProcRequestHeaders(map[string]string{":method": "GET", ":path": "/pets", ":authority": "myapp.io", ":scheme": "https"}),
+		{Request: &envoy_ext_proc.ProcessingRequest_ResponseHeaders{ResponseHeaders: &envoy_ext_proc.HttpHeaders{}}},
+	}}
+	extProcService := &ExtProcService{AuthService: &AuthService{Index: indexMock}}
+
+	err := extProcService.Process(stream)
+	assert.NilError(t, err)
+	assert.Equal(t, len(stream.responses), 2)
+
+	headerMutation := stream.responses[0].GetRequestHeaders().GetResponse().GetHeaderMutation()
+	assert.Equal(t, len(headerMutation.GetSetHeaders()), 1)
+	assert.Equal(t, headerMutation.GetSetHeaders()[0].GetHeader().GetKey(), "x-auth-data")
+	assert.Equal(t, headerMutation.GetSetHeaders()[0].GetHeader().GetValue(), "GET")
+	assert.Equal(t, headerMutation.GetSetHeaders()[0].GetAppendAction(), envoy_core.HeaderValueOption_OVERWRITE_IF_EXISTS_OR_ADD)
+	assert.Check(t, stream.responses[1].GetResponseHeaders() != nil)
+}
+
+func TestExtProcDenied(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	authConfig := mockAnonymousAccessAuthConfig()
+	authConfig.AuthorizationConfigs = []auth.AuthConfigEvaluator{denyAuthorizationConfig("deny", 0)}
+	indexMock := mock_index.NewMockIndex(mockController)
+	indexMock.EXPECT().Get("myapp.io").Return(authConfig)
+
+	stream := &extProcStreamMock{requests: []*envoy_ext_proc.ProcessingRequest{
+		extProcRequestHeaders(map[string]string{":method": "GET", ":path": "/pets", ":authority": "myapp.io"}),
+	}}
+	extProcService := &ExtProcService{AuthService: &AuthService{Index: indexMock}}
+
+	err := extProcService.Process(stream)
+	assert.NilError(t, err)
+	assert.Equal(t, len(stream.responses), 1)
+
+	immediateResponse := stream.responses[0].GetImmediateResponse()
+	assert.Check(t, immediateResponse != nil)
+	assert.Equal(t, immediateResponse.GetStatus().GetCode(), envoy_type.StatusCode_Forbidden)
+}
+
+func TestExtProcCheckRequest(t *testing.T) {
+	attributes, _ := structpb.NewStruct(map[string]interface{}{
+		"source.address":                       "10.0.0.1:51234",
+		"destination.address":                  "10.0.0.2:8080",
+		"connection.uri_san_peer_certificate":  "spiffe://cluster.local/ns/default/sa/client",
+		"connection.uri_san_local_certificate": "spiffe://cluster.local/ns/default/sa/myapp",
+		"connection.requested_server_name":     "myapp.io",
+		"request.protocol":                     "HTTP/2",
+	})
+	httpHeaders := extProcRequestHeaders(map[string]string{":method": "GET", ":path": "/pets", ":authority": "myapp.io", ":scheme": "https", ":protocol": "websocket"}).GetRequestHeaders()
+	httpHeaders.Attributes = map[string]*structpb.Struct{"envoy.filters.http.ext_proc": attributes}
+
+	attrs := extProcCheckRequest(httpHeaders).GetAttributes()
+	assert.Equal(t, attrs.GetSource().GetAddress().GetSocketAddress().GetAddress(), "10.0.0.1")
+	assert.Equal(t, attrs.GetSource().GetAddress().GetSocketAddress().GetPortValue(), uint32(51234))
+	assert.Equal(t, attrs.GetSource().GetPrincipal(), "spiffe://cluster.local/ns/default/sa/client")
+	assert.Equal(t, attrs.GetDestination().GetAddress().GetSocketAddress().GetAddress(), "10.0.0.2")
+	assert.Equal(t, attrs.GetDestination().GetAddress().GetSocketAddress().GetPortValue(), uint32(8080))
+	assert.Equal(t, attrs.GetDestination().GetPrincipal(), "spiffe://cluster.local/ns/default/sa/myapp")
+	assert.Equal(t, attrs.GetTlsSession().GetSni(), "myapp.io")
+	assert.Equal(t, attrs.GetRequest().GetHttp().GetProtocol(), "HTTP/2")
+
+	// without attributes
+	httpHeaders.Attributes = nil
+	attrs = extProcCheckRequest(httpHeaders).GetAttributes()
+	assert.Check(t, attrs.GetSource() == nil)
+	assert.Check(t, attrs.GetDestination() == nil)
+	assert.Check(t, attrs.GetTlsSession() == nil)
+	assert.Equal(t, attrs.GetRequest().GetHttp().GetProtocol(), "")
+	assert.Equal(t, attrs.GetRequest().GetHttp().GetMethod(), "GET")
+}
+
+func TestRewriteQueryParameters(t *testing.T) {
+	path := rewriteQueryParameters("/pets?limit=10&debug=true", []*envoy_core.QueryParameter{{Key: "tenant", Value: "acme"}, {Key: "limit", Value: "5"}}, []string{"debug"})
+	assert.Equal(t, path, "/pets?limit=5&tenant=acme")
+
+	path = rewriteQueryParameters("/pets?debug=true", nil, []string{"debug"})
+	assert.Equal(t, path, "/pets")
+}
@@ -7,6 +7,7 @@ package service
 
 import (
 	"log"
+	"sync/atomic"
 
 	"golang.org/x/net/context"
 
@@ -16,14 +17,24 @@ import (
 )
 
 // HealthService is the server API for the gRPC health service
-type HealthService struct{}
+type HealthService struct {
+	shuttingDown atomic.Bool
+}
 
 // Check performs a health of the gRPC service
 func (hs *HealthService) Check(ctx context.Context, in *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
 	log.Printf("[HealthService] Check()")
+	if hs.shuttingDown.Load() {
+		return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_NOT_SERVING}, nil
+	}
 	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
 }
 
+// Shutdown reports the service as not serving from now on, so the clients stop sending new requests
+func (hs *HealthService) Shutdown() {
+	hs.shuttingDown.Store(true)
+}
+
 // Watch is for streaming health-check (not yet implemented)
 func (hs *HealthService) Watch(in *healthpb.HealthCheckRequest, srv healthpb.Health_WatchServer) error {
 	return status.Error(codes.Unimplemented, "Watch is not implemented")
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"sync/atomic"
+
+	gocontext "golang.org/x/net/context"
+)
+
+// concurrencyLimiter bounds the number of auth requests evaluated concurrently.
+// Requests beyond the limit wait in a bounded queue for a slot; requests beyond the queue are rejected right away.
+type concurrencyLimiter struct {
+	slots     chan struct{}
+	maxQueued int64
+	queued    atomic.Int64
+}
+
+func newConcurrencyLimiter(maxConcurrent, maxQueued int) *concurrencyLimiter {
+	return &concurrencyLimiter{
+		slots:     make(chan struct{}, maxConcurrent),
+		maxQueued: int64(maxQueued),
+	}
+}
+
+// acquire takes a slot to evaluate a request, waiting in the queue if all slots are taken.
+// Returns false if the queue is full or the context is done before getting a slot.
+func (l *concurrencyLimiter) acquire(ctx gocontext.Context) bool {
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+	}
+
+	if l.queued.Add(1) > l.maxQueued {
+		l.queued.Add(-1)
+		return false
+	}
+	defer l.queued.Add(-1)
+
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// release frees a slot taken with acquire
+func (l *concurrencyLimiter) release() {
+	<-l.slots
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+	"gotest.tools/assert"
+
+	"github.com/kuadrant/authorino/pkg/index"
+
+	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"github.com/gogo/googleapis/google/rpc"
+)
+
+func TestConcurrencyLimiter(t *testing.T) {
+	limiter := newConcurrencyLimiter(2, 1)
+
+	assert.Check(t, limiter.acquire(context.TODO()))
+	assert.Check(t, limiter.acquire(context.TODO()))
+
+	// waits in the queue for a slot
+	queued := make(chan bool)
+	go func() { queued <- limiter.acquire(context.TODO()) }()
+	for limiter.queued.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// queue is full
+	assert.Check(t, !limiter.acquire(context.TODO()))
+
+	limiter.release()
+	assert.Check(t, <-queued)
+	assert.Equal(t, limiter.queued.Load(), int64(0))
+
+	// context done while waiting in the queue
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+	assert.Check(t, !limiter.acquire(ctx))
+	assert.Equal(t, limiter.queued.Load(), int64(0))
+
+	limiter.release()
+	limiter.release()
+	assert.Check(t, limiter.acquire(context.TODO()))
+}
+
+func TestAuthServiceLimitConcurrency(t *testing.T) {
+	service := AuthService{Index: index.NewIndex()}
+	service.LimitConcurrency(1, 0)
+	assert.Check(t, service.limiter.acquire(context.TODO())) // takes the only slot
+
+	resp, err := service.Check(context.TODO(), &envoy_auth.CheckRequest{Attributes: &envoy_auth.AttributeContext{
+		Request: &envoy_auth.AttributeContext_Request{Http: &envoy_auth.AttributeContext_HttpRequest{Host: "host.com"}},
+	}})
+	assert.NilError(t, err)
+	assert.Equal(t, resp.GetStatus().GetCode(), int32(rpc.RESOURCE_EXHAUSTED))
+	assert.Equal(t, int32(resp.GetDeniedResponse().Status.Code), int32(429))
+
+	service.limiter.release()
+	resp, _ = service.Check(context.TODO(), &envoy_auth.CheckRequest{Attributes: &envoy_auth.AttributeContext{
+		Request: &envoy_auth.AttributeContext_Request{Http: &envoy_auth.AttributeContext_HttpRequest{Host: "host.com"}},
+	}})
+	assert.Equal(t, resp.GetStatus().GetCode(), int32(rpc.NOT_FOUND))
+
+	service.LimitConcurrency(0, 0)
+	assert.Check(t, service.limiter == nil)
+}
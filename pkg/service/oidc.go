@@ -59,6 +59,18 @@ func (o *OidcService) ServeHTTP(writer http.ResponseWriter, req *http.Request) {
 				responseBody, err = wristband.OpenIDConfig()
 			case "/.well-known/openid-connect/certs":
 				responseBody, err = wristband.JWKS()
+			case "/introspect":
+				// token introspection (rfc 7662)
+				if req.Method != http.MethodPost {
+					statusCode = http.StatusMethodNotAllowed
+					err = fmt.Errorf("method not allowed")
+				} else if token := req.PostFormValue("token"); token == "" {
+					statusCode = http.StatusBadRequest
+					err = fmt.Errorf("missing token")
+				} else {
+					writer.Header().Add("Cache-Control", "no-store")
+					responseBody, err = wristband.Introspect(token)
+				}
 			default:
 				statusCode = http.StatusNotFound
 				err = fmt.Errorf("not found")
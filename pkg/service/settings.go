@@ -0,0 +1,141 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/log"
+	"github.com/kuadrant/authorino/pkg/metrics"
+	"github.com/kuadrant/authorino/pkg/trace"
+
+	"github.com/go-logr/logr"
+)
+
+const (
+	settingLogLevel             = "LOG_LEVEL"
+	settingDeepMetricsEnabled   = "DEEP_METRICS_ENABLED"
+	settingTracingSamplingRatio = "TRACING_SAMPLING_RATIO"
+	settingTimeout              = "TIMEOUT"
+	settingDebugSamplingRatio   = "DEBUG_SAMPLING_RATIO"
+)
+
+// RuntimeSettings are the settings of the auth server that can be changed without restarting it
+type RuntimeSettings struct {
+	LogLevel             string  `json:"logLevel"`
+	DeepMetricsEnabled   bool    `json:"deepMetricsEnabled"`
+	TracingSamplingRatio float64 `json:"tracingSamplingRatio"`
+	// Timeout of the auth requests, in milliseconds
+	Timeout int `json:"timeout"`
+	// Ratio of the auth requests promoted to debug logging
+	DebugSamplingRatio float64 `json:"debugSamplingRatio"`
+}
+
+// RuntimeSettingsReloader reloads the runtime settings of the auth server from a file of environment variables
+// (e.g. a mounted ConfigMap), in the format KEY=value, one per line.
+// The settings missing in the file keep the values the server booted with.
+// Settings reloaded apply to the requests received afterwards; the index and the caches are preserved.
+type RuntimeSettingsReloader struct {
+	// Settings the server booted with, i.e. the ones set in the command-line flags and in the environment
+	Defaults RuntimeSettings
+	// Path to the file of environment variables; only the defaults apply if empty
+	File string
+	// Auth services whose timeout and debug sampling ratio are reloaded
+	AuthServices []*AuthService
+	Logger       logr.Logger
+
+	mu      sync.Mutex
+	current RuntimeSettings
+}
+
+// Current returns the settings in effect
+func (r *RuntimeSettingsReloader) Current() RuntimeSettings {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// Reload reads the settings from the file and applies them.
+// The settings in effect are kept if the file cannot be read or any of the settings is invalid.
+func (r *RuntimeSettingsReloader) Reload() (RuntimeSettings, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	settings := r.Defaults
+	if r.File != "" {
+		content, err := os.ReadFile(r.File)
+		if err != nil {
+			return r.current, err
+		}
+		if err := r.parse(content, &settings); err != nil {
+			return r.current, err
+		}
+	}
+
+	level, err := log.ParseLogLevel(settings.LogLevel)
+	if err != nil {
+		return r.current, fmt.Errorf("invalid %s: %w", settingLogLevel, err)
+	}
+	if settings.TracingSamplingRatio < 0 || settings.TracingSamplingRatio > 1 {
+		return r.current, fmt.Errorf("invalid %s: must be between 0 and 1", settingTracingSamplingRatio)
+	}
+	if settings.DebugSamplingRatio < 0 || settings.DebugSamplingRatio > 1 {
+		return r.current, fmt.Errorf("invalid %s: must be between 0 and 1", settingDebugSamplingRatio)
+	}
+	if settings.Timeout < 0 {
+		return r.current, fmt.Errorf("invalid %s: must not be negative", settingTimeout)
+	}
+
+	log.SetLevel(level)
+	metrics.DeepMetricsEnabled = settings.DeepMetricsEnabled
+	trace.SetSamplingRatio(settings.TracingSamplingRatio)
+	for _, authService := range r.AuthServices {
+		authService.SetTimeout(time.Duration(settings.Timeout) * time.Millisecond)
+		authService.SetDebugSamplingRatio(settings.DebugSamplingRatio)
+	}
+	r.current = settings
+
+	r.Logger.Info("runtime settings reloaded", "logLevel", settings.LogLevel, "deepMetricsEnabled", settings.DeepMetricsEnabled, "tracingSamplingRatio", settings.TracingSamplingRatio, "timeout", settings.Timeout, "debugSamplingRatio", settings.DebugSamplingRatio)
+	return settings, nil
+}
+
+func (r *RuntimeSettingsReloader) parse(content []byte, settings *RuntimeSettings) error {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, found := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		if !found {
+			return fmt.Errorf("invalid line %q: missing '='", line)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+
+		var err error
+		switch key {
+		case settingLogLevel:
+			settings.LogLevel = value
+		case settingDeepMetricsEnabled:
+			settings.DeepMetricsEnabled, err = strconv.ParseBool(value)
+		case settingTracingSamplingRatio:
+			settings.TracingSamplingRatio, err = strconv.ParseFloat(value, 64)
+		case settingTimeout:
+			settings.Timeout, err = strconv.Atoi(value)
+		case settingDebugSamplingRatio:
+			settings.DebugSamplingRatio, err = strconv.ParseFloat(value, 64)
+		default:
+			r.Logger.V(1).Info("ignoring unknown runtime setting", "setting", key)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", key, err)
+		}
+	}
+	return scanner.Err()
+}
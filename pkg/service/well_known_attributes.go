@@ -74,6 +74,15 @@ type RequestAttributes struct {
 	// extension mechanism for sending additional information to the auth service without modifying the proto definition.
 	// It maps to the internal opaque context in the proxy filter chain. (Requires additional configuration in the proxy.)
 	ContextExtensions map[string]string `json:"context_extensions,omitempty"`
+	// gRPC attributes, for gRPC requests only
+	GRPC *GRPCAttributes `json:"grpc,omitempty"`
+}
+
+type GRPCAttributes struct {
+	// Fully-qualified name of the gRPC service e.g. “helloworld.Greeter”
+	Service string `json:"service,omitempty"`
+	// Name of the gRPC method e.g. “SayHello”
+	Method string `json:"method,omitempty"`
 }
 
 type SourceAttributes struct {
@@ -119,8 +128,14 @@ type AuthAttributes struct {
 	Metadata map[string]any `json:"metadata,omitempty"`
 	// Authorization results resolved by each authorization rule, access granted only
 	Authorization map[string]any `json:"authorization,omitempty"`
+	// Named values exported by the authorization rules
+	Exports map[string]any `json:"exports,omitempty"`
 	// Response objects exported by the auth service post-access granted
 	Response map[string]any `json:"response,omitempty"`
+	// Details of the failure (code, status and message), when access is denied
+	Denial map[string]any `json:"denial,omitempty"`
+	// Summary of the outcome of the auth pipeline (verdict, code and timings of the evaluators), available to the callbacks
+	Decision map[string]any `json:"decision,omitempty"`
 	// Response objects returned by the callback requests issued by the auth service
 	Callbacks map[string]any `json:"callbacks,omitempty"`
 }
@@ -158,7 +173,21 @@ func newRequestAttributes(attributes *envoyauth.AttributeContext) *RequestAttrib
 		Body:              httpRequest.GetBody(),
 		RawBody:           httpRequest.GetRawBody(),
 		ContextExtensions: attributes.GetContextExtensions(),
+		GRPC:              newGRPCAttributes(urlParsed.Path, headers),
+	}
+}
+
+// newGRPCAttributes parses the service and the method of a gRPC request out of the path (i.e. /<service>/<method>).
+// Returns nil if the request is not a gRPC request.
+func newGRPCAttributes(path string, headers map[string]string) *GRPCAttributes {
+	if !strings.HasPrefix(headers["content-type"], "application/grpc") {
+		return nil
+	}
+	service, method, found := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	if !found || service == "" || method == "" || strings.Contains(method, "/") {
+		return nil
 	}
+	return &GRPCAttributes{Service: service, Method: method}
 }
 
 func newSourceAttributes(attributes *envoyauth.AttributeContext) *SourceAttributes {
@@ -52,3 +52,22 @@ func TestNewWellKnownAttributes(t *testing.T) {
 	assert.Equal(t, map[string]any{"status": 200}, wellKnownAttributes.Auth.Response)
 	assert.Nil(t, wellKnownAttributes.Auth.Callbacks)
 }
+
+func TestNewWellKnownAttributesGRPC(t *testing.T) {
+	envoyAttrs := &envoyauth.AttributeContext{
+		Request: &envoyauth.AttributeContext_Request{
+			Http: &envoyauth.AttributeContext_HttpRequest{
+				Headers: map[string]string{"content-type": "application/grpc+proto"},
+				Path:    "/helloworld.Greeter/SayHello",
+				Method:  "POST",
+			},
+		},
+	}
+
+	wellKnownAttributes := NewWellKnownAttributes(envoyAttrs, nil)
+	assert.Equal(t, &GRPCAttributes{Service: "helloworld.Greeter", Method: "SayHello"}, wellKnownAttributes.Request.GRPC)
+
+	envoyAttrs.Request.Http.Headers = map[string]string{"content-type": "application/json"}
+	wellKnownAttributes = NewWellKnownAttributes(envoyAttrs, nil)
+	assert.Nil(t, wellKnownAttributes.Request.GRPC)
+}
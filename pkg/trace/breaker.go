@@ -0,0 +1,224 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/metrics"
+)
+
+const (
+	CircuitClosed   CircuitState = "closed"
+	CircuitOpen     CircuitState = "open"
+	CircuitHalfOpen CircuitState = "half-open"
+
+	DefaultCircuitBreakerOpenDuration = 30 * time.Second
+)
+
+// ErrCircuitOpen is the error of the outbound HTTP requests failed right away because the circuit breaker of the
+// endpoint is open
+var ErrCircuitOpen = errors.New("circuit breaker of the endpoint is open")
+
+var circuitBreakerStateMetric = metrics.NewGaugeMetric("http_client_circuit_breaker_state", "State of the circuit breakers of the external endpoints called by the evaluators (1 for the current state of the circuit, 0 otherwise).", "host", "state")
+
+func init() {
+	metrics.Register(circuitBreakerStateMetric)
+}
+
+// circuitBreakers is the circuit breaker transport of the outbound HTTP requests, if enabled
+var circuitBreakers *circuitBreakerTransport
+
+type CircuitState string
+
+// CircuitBreakerStatus describes the circuit breaker of an external endpoint
+type CircuitBreakerStatus struct {
+	Host                string       `json:"host"`
+	State               CircuitState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutiveFailures"`
+	// Time of the last change of state
+	Since time.Time `json:"since"`
+}
+
+// CircuitBreakers returns the status of the circuit breakers of the external endpoints called so far, sorted by host;
+// nil if the circuit breakers are disabled
+func CircuitBreakers() []CircuitBreakerStatus {
+	if circuitBreakers == nil {
+		return nil
+	}
+	return circuitBreakers.status()
+}
+
+// circuitBreakerTransport gives up on an endpoint (host) after a number of consecutive failed outbound HTTP requests,
+// i.e. requests that fail to get a response or whose response is a server error.
+// While the circuit of an endpoint is open, the requests to the endpoint fail right away with ErrCircuitOpen. After the
+// open duration, the circuit is half-open and a single request is let through to probe the endpoint: the circuit
+// closes if the request succeeds or opens again otherwise.
+type circuitBreakerTransport struct {
+	transport        http.RoundTripper
+	failureThreshold int
+	openDuration     time.Duration
+	now              func() time.Time
+
+	breakers map[string]*circuitBreaker
+	mu       sync.Mutex
+}
+
+func breakCircuits(transport http.RoundTripper, opts HTTPTransportOptions) http.RoundTripper {
+	if opts.CircuitBreakerFailureThreshold <= 0 {
+		circuitBreakers = nil
+		return transport
+	}
+	openDuration := opts.CircuitBreakerOpenDuration
+	if openDuration <= 0 {
+		openDuration = DefaultCircuitBreakerOpenDuration
+	}
+	circuitBreakers = &circuitBreakerTransport{
+		transport:        transport,
+		failureThreshold: opts.CircuitBreakerFailureThreshold,
+		openDuration:     openDuration,
+		now:              time.Now,
+		breakers:         make(map[string]*circuitBreaker),
+	}
+	return circuitBreakers
+}
+
+func (t *circuitBreakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	breaker := t.breakerFor(req.URL.Host)
+
+	if !breaker.allow(t.now(), t.openDuration) {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, fmt.Errorf("%w: %s", ErrCircuitOpen, req.URL.Host)
+	}
+
+	resp, err := t.transport.RoundTrip(req)
+	switch {
+	case errors.Is(err, ErrEndpointBusy), errors.Is(err, context.Canceled):
+		// shed by the concurrency limit or given up by the caller, says nothing about the endpoint
+		breaker.release()
+	case err != nil || resp.StatusCode >= 500:
+		breaker.failure(t.now(), t.failureThreshold)
+	default:
+		breaker.success(t.now())
+	}
+	return resp, err
+}
+
+func (t *circuitBreakerTransport) breakerFor(host string) *circuitBreaker {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	breaker, found := t.breakers[host]
+	if !found {
+		breaker = &circuitBreaker{host: host, state: CircuitClosed, since: t.now()}
+		breaker.report()
+		t.breakers[host] = breaker
+	}
+	return breaker
+}
+
+func (t *circuitBreakerTransport) status() []CircuitBreakerStatus {
+	t.mu.Lock()
+	breakers := make([]*circuitBreaker, 0, len(t.breakers))
+	for _, breaker := range t.breakers {
+		breakers = append(breakers, breaker)
+	}
+	t.mu.Unlock()
+
+	status := make([]CircuitBreakerStatus, 0, len(breakers))
+	for _, breaker := range breakers {
+		status = append(status, breaker.status(t.now(), t.openDuration))
+	}
+	sort.Slice(status, func(i, j int) bool { return status[i].Host < status[j].Host })
+	return status
+}
+
+type circuitBreaker struct {
+	host     string
+	state    CircuitState
+	since    time.Time
+	failures int
+	probing  bool
+	mu       sync.Mutex
+}
+
+// allow tells whether a request can be sent to the endpoint, moving the circuit to half-open if it has been open for
+// long enough
+func (b *circuitBreaker) allow(now time.Time, openDuration time.Duration) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if now.Sub(b.since) < openDuration {
+			return false
+		}
+		b.setState(CircuitHalfOpen, now)
+		fallthrough
+	case CircuitHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+	}
+	return true
+}
+
+// release gives up the probe of a half-open circuit without an outcome
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+func (b *circuitBreaker) success(now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.probing = false
+	if b.state != CircuitClosed {
+		b.setState(CircuitClosed, now)
+	}
+}
+
+func (b *circuitBreaker) failure(now time.Time, threshold int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.probing = false
+	if b.state == CircuitHalfOpen || (b.state == CircuitClosed && b.failures >= threshold) {
+		b.setState(CircuitOpen, now)
+	}
+}
+
+func (b *circuitBreaker) status(now time.Time, openDuration time.Duration) CircuitBreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	state := b.state
+	if state == CircuitOpen && now.Sub(b.since) >= openDuration {
+		state = CircuitHalfOpen // the next request will probe the endpoint
+	}
+	return CircuitBreakerStatus{Host: b.host, State: state, ConsecutiveFailures: b.failures, Since: b.since}
+}
+
+// setState must be called with the lock held
+func (b *circuitBreaker) setState(state CircuitState, now time.Time) {
+	b.state = state
+	b.since = now
+	b.report()
+}
+
+func (b *circuitBreaker) report() {
+	for _, state := range []CircuitState{CircuitClosed, CircuitOpen, CircuitHalfOpen} {
+		value := 0.0
+		if state == b.state {
+			value = 1
+		}
+		circuitBreakerStateMetric.WithLabelValues(b.host, string(state)).Set(value)
+	}
+}
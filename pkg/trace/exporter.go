@@ -37,6 +37,7 @@ func CreateTraceProvider(config Config) (*trace.TracerProvider, error) {
 	return trace.NewTracerProvider(
 		trace.WithBatcher(exporter),
 		trace.WithResource(resource),
+		trace.WithSampler(sampler),
 	), nil
 }
 
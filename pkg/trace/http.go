@@ -0,0 +1,99 @@
+package trace
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+
+	otel_http "go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+const (
+	DefaultHTTPMaxIdleConns        = 100
+	DefaultHTTPMaxIdleConnsPerHost = 100
+	DefaultHTTPIdleConnTimeout     = 90 * time.Second
+	DefaultHTTPDialTimeout         = 30 * time.Second
+	DefaultHTTPTLSHandshakeTimeout = 10 * time.Second
+	DefaultHTTPTLSSessionCacheSize = 64
+)
+
+// HTTPClient is the client of the outbound HTTP requests to the external dependencies of the auth pipeline.
+// Each request is recorded in a client span, child of the span in the context of the request, and carries the trace
+// context in the W3C `traceparent` and `tracestate` headers, so the latency of the external dependencies shows up in the
+// same distributed trace as the auth request.
+// All requests share the same transport, thus reusing the connections and the TLS sessions across the evaluators.
+var HTTPClient = &http.Client{Transport: otel_http.NewTransport(NewHTTPTransport(HTTPTransportOptions{}))}
+
+// HTTPTransportOptions tune the transport shared by the outbound HTTP requests. Zero values fall back to the defaults.
+type HTTPTransportOptions struct {
+	// Maximum number of idle (keep-alive) connections across all hosts
+	MaxIdleConns int
+	// Maximum number of idle (keep-alive) connections to keep per host
+	MaxIdleConnsPerHost int
+	// Time an idle connection is kept before closing itself
+	IdleConnTimeout time.Duration
+	// Time to wait for a connection to be established
+	DialTimeout time.Duration
+	// Time to wait for a TLS handshake
+	TLSHandshakeTimeout time.Duration
+	// Number of TLS sessions cached to be resumed on new connections
+	TLSSessionCacheSize int
+	// Maximum number of requests in-flight per host; unlimited if 0
+	MaxConcurrentRequestsPerHost int
+	// Maximum number of requests in-flight to specific hosts, overriding MaxConcurrentRequestsPerHost; unlimited if 0
+	HostConcurrencyLimits map[string]int
+	// Maximum number of requests waiting for a slot per host, beyond which the requests fail with ErrEndpointBusy
+	MaxQueuedRequestsPerHost int
+	// Number of consecutive failed requests to a host that opens its circuit breaker; circuit breakers disabled if 0
+	CircuitBreakerFailureThreshold int
+	// Time the circuit breaker of a host stays open before letting a request through to probe the host
+	CircuitBreakerOpenDuration time.Duration
+}
+
+// NewHTTPTransport returns a transport for the outbound HTTP requests, with the keep-alive connections pooled per
+// host and the TLS sessions cached for resumption
+func NewHTTPTransport(opts HTTPTransportOptions) *http.Transport {
+	if opts.MaxIdleConns <= 0 {
+		opts.MaxIdleConns = DefaultHTTPMaxIdleConns
+	}
+	if opts.MaxIdleConnsPerHost <= 0 {
+		opts.MaxIdleConnsPerHost = DefaultHTTPMaxIdleConnsPerHost
+	}
+	if opts.IdleConnTimeout <= 0 {
+		opts.IdleConnTimeout = DefaultHTTPIdleConnTimeout
+	}
+	if opts.DialTimeout <= 0 {
+		opts.DialTimeout = DefaultHTTPDialTimeout
+	}
+	if opts.TLSHandshakeTimeout <= 0 {
+		opts.TLSHandshakeTimeout = DefaultHTTPTLSHandshakeTimeout
+	}
+	if opts.TLSSessionCacheSize <= 0 {
+		opts.TLSSessionCacheSize = DefaultHTTPTLSSessionCacheSize
+	}
+
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   opts.DialTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          opts.MaxIdleConns,
+		MaxIdleConnsPerHost:   opts.MaxIdleConnsPerHost,
+		IdleConnTimeout:       opts.IdleConnTimeout,
+		TLSHandshakeTimeout:   opts.TLSHandshakeTimeout,
+		ExpectContinueTimeout: 1 * time.Second,
+		TLSClientConfig: &tls.Config{
+			ClientSessionCache: tls.NewLRUClientSessionCache(opts.TLSSessionCacheSize),
+		},
+	}
+}
+
+// ConfigureHTTPTransport replaces the transport shared by the outbound HTTP requests, capping the number of requests
+// in-flight per host and breaking the circuit of the failing hosts if set in the options.
+// Must be called before any outbound HTTP request is sent.
+func ConfigureHTTPTransport(opts HTTPTransportOptions) {
+	HTTPClient.Transport = otel_http.NewTransport(breakCircuits(limitEndpointConcurrency(NewHTTPTransport(opts), opts), opts))
+}
@@ -0,0 +1,243 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	otel_propagation "go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/trace"
+	"gotest.tools/assert"
+)
+
+func TestHTTPClientPropagatesTraceContext(t *testing.T) {
+	otel.SetTracerProvider(trace.NewTracerProvider(trace.WithSampler(trace.AlwaysSample())))
+	otel.SetTextMapPropagator(otel_propagation.TraceContext{})
+
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+	}))
+	defer server.Close()
+
+	ctx, span := NewSpan(context.Background(), "test", "Check")
+	defer span.End()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	resp, err := HTTPClient.Do(req)
+	assert.NilError(t, err)
+	resp.Body.Close()
+
+	// traceparent: <version>-<trace id>-<parent id>-<flags>
+	parts := strings.Split(traceparent, "-")
+	assert.Equal(t, len(parts), 4)
+	assert.Equal(t, parts[1], span.SpanContext().TraceID().String())
+	assert.Check(t, parts[2] != span.SpanContext().SpanID().String()) // child client span
+}
+
+func TestNewHTTPTransport(t *testing.T) {
+	transport := NewHTTPTransport(HTTPTransportOptions{})
+	assert.Equal(t, transport.MaxIdleConns, DefaultHTTPMaxIdleConns)
+	assert.Equal(t, transport.MaxIdleConnsPerHost, DefaultHTTPMaxIdleConnsPerHost)
+	assert.Equal(t, transport.IdleConnTimeout, DefaultHTTPIdleConnTimeout)
+	assert.Equal(t, transport.TLSHandshakeTimeout, DefaultHTTPTLSHandshakeTimeout)
+	assert.Check(t, transport.TLSClientConfig.ClientSessionCache != nil)
+
+	transport = NewHTTPTransport(HTTPTransportOptions{MaxIdleConnsPerHost: 10, IdleConnTimeout: time.Second})
+	assert.Equal(t, transport.MaxIdleConnsPerHost, 10)
+	assert.Equal(t, transport.IdleConnTimeout, time.Second)
+}
+
+func TestHTTPClientReusesConnections(t *testing.T) {
+	var remoteAddrs []string
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		remoteAddrs = append(remoteAddrs, r.RemoteAddr)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewHTTPTransport(HTTPTransportOptions{})}
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(server.URL)
+		assert.NilError(t, err)
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, len(remoteAddrs), 3)
+	assert.Equal(t, remoteAddrs[1], remoteAddrs[0])
+	assert.Equal(t, remoteAddrs[2], remoteAddrs[0])
+}
+
+func TestEndpointConcurrencyLimit(t *testing.T) {
+	release := make(chan struct{})
+	var inFlight, maxInFlight int
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+		<-release
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	transport := limitEndpointConcurrency(NewHTTPTransport(HTTPTransportOptions{}), HTTPTransportOptions{MaxConcurrentRequestsPerHost: 2, MaxQueuedRequestsPerHost: 1})
+	client := &http.Client{Transport: transport}
+	limiter := func() *endpointLimiter {
+		limited := transport.(*endpointLimitedTransport)
+		limited.mu.Lock()
+		defer limited.mu.Unlock()
+		for _, limiter := range limited.limiters {
+			return limiter
+		}
+		return nil
+	}
+
+	var waitGroup sync.WaitGroup
+	errs := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			resp, err := client.Get(server.URL)
+			if err == nil {
+				_, _ = io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+			}
+			errs <- err
+		}()
+	}
+	for {
+		mu.Lock()
+		ready := inFlight == 2
+		mu.Unlock()
+		if ready && limiter().queued.Load() == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// the queue is full
+	_, err := client.Get(server.URL)
+	assert.Check(t, errors.Is(err, ErrEndpointBusy))
+
+	close(release)
+	waitGroup.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NilError(t, err)
+	}
+	assert.Equal(t, maxInFlight, 2)
+	assert.Equal(t, len(limiter().slots), 0)
+}
+
+func TestHostConcurrencyLimits(t *testing.T) {
+	transport := limitEndpointConcurrency(http.DefaultTransport, HTTPTransportOptions{
+		MaxConcurrentRequestsPerHost: 10,
+		HostConcurrencyLimits:        map[string]int{"idp.example.com": 50, "api.example.com:8443": 0},
+	}).(*endpointLimitedTransport)
+
+	parse := func(rawURL string) *url.URL {
+		u, _ := url.Parse(rawURL)
+		return u
+	}
+	assert.Equal(t, cap(transport.limiterFor(parse("https://idp.example.com/introspect")).slots), 50)
+	assert.Equal(t, cap(transport.limiterFor(parse("https://idp.example.com:8443/introspect")).slots), 50)
+	assert.Check(t, transport.limiterFor(parse("https://api.example.com:8443/data")) == nil)
+	assert.Equal(t, cap(transport.limiterFor(parse("https://other.example.com")).slots), 10)
+
+	assert.Check(t, limitEndpointConcurrency(http.DefaultTransport, HTTPTransportOptions{}) == http.DefaultTransport)
+
+	limits, err := ParseHostConcurrencyLimits("idp.example.com=50, api.example.com:8443=10")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, limits, map[string]int{"idp.example.com": 50, "api.example.com:8443": 10})
+	_, err = ParseHostConcurrencyLimits("idp.example.com")
+	assert.Error(t, err, `invalid host concurrency limit "idp.example.com"`)
+	_, err = ParseHostConcurrencyLimits("idp.example.com=many")
+	assert.Error(t, err, `invalid host concurrency limit "idp.example.com=many"`)
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	var failing bool
+	var calls int
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	transport := breakCircuits(http.DefaultTransport, HTTPTransportOptions{
+		CircuitBreakerFailureThreshold: 2,
+		CircuitBreakerOpenDuration:     time.Minute,
+	}).(*circuitBreakerTransport)
+	defer func() { circuitBreakers = nil }()
+	transport.now = func() time.Time { return now }
+	client := &http.Client{Transport: transport}
+
+	get := func() error {
+		resp, err := client.Get(server.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+	state := func() CircuitState {
+		return CircuitBreakers()[0].State
+	}
+
+	assert.NilError(t, get())
+	assert.Equal(t, state(), CircuitClosed)
+
+	// trips after the consecutive failures
+	failing = true
+	assert.NilError(t, get())
+	assert.Equal(t, state(), CircuitClosed)
+	assert.NilError(t, get())
+	assert.Equal(t, state(), CircuitOpen)
+
+	// fails fast while open
+	assert.Check(t, errors.Is(get(), ErrCircuitOpen))
+	assert.Equal(t, calls, 3)
+
+	// the probe of the half-open circuit fails and opens the circuit again
+	now = now.Add(time.Minute)
+	assert.Equal(t, state(), CircuitHalfOpen)
+	assert.NilError(t, get())
+	assert.Equal(t, calls, 4)
+	assert.Equal(t, state(), CircuitOpen)
+	assert.Check(t, errors.Is(get(), ErrCircuitOpen))
+
+	// the probe of the half-open circuit succeeds and closes the circuit
+	now = now.Add(time.Minute)
+	failing = false
+	assert.NilError(t, get())
+	assert.Equal(t, state(), CircuitClosed)
+	assert.Equal(t, CircuitBreakers()[0].ConsecutiveFailures, 0)
+
+	assert.Check(t, breakCircuits(http.DefaultTransport, HTTPTransportOptions{}) == http.DefaultTransport)
+	assert.Check(t, CircuitBreakers() == nil)
+}
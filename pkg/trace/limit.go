@@ -0,0 +1,168 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrEndpointBusy is the error of the outbound HTTP requests shed because the endpoint has reached the maximum number of
+// concurrent requests and of requests waiting for a slot
+var ErrEndpointBusy = errors.New("too many concurrent requests to the endpoint")
+
+// endpointLimitedTransport caps the number of outbound HTTP requests in-flight per endpoint (host), so a spike of auth
+// requests does not turn into a spike of requests to fragile external dependencies (e.g. the token introspection
+// endpoint of an IdP).
+// Requests beyond the cap of an endpoint wait in a bounded queue for a slot; requests beyond the queue are shed.
+// A request is in-flight until the body of its response is closed.
+type endpointLimitedTransport struct {
+	transport    http.RoundTripper
+	defaultLimit int
+	limits       map[string]int
+	maxQueued    int
+
+	limiters map[string]*endpointLimiter
+	mu       sync.Mutex
+}
+
+func limitEndpointConcurrency(transport http.RoundTripper, opts HTTPTransportOptions) http.RoundTripper {
+	if opts.MaxConcurrentRequestsPerHost <= 0 && len(opts.HostConcurrencyLimits) == 0 {
+		return transport
+	}
+	return &endpointLimitedTransport{
+		transport:    transport,
+		defaultLimit: opts.MaxConcurrentRequestsPerHost,
+		limits:       opts.HostConcurrencyLimits,
+		maxQueued:    opts.MaxQueuedRequestsPerHost,
+		limiters:     make(map[string]*endpointLimiter),
+	}
+}
+
+func (t *endpointLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	limiter := t.limiterFor(req.URL)
+	if limiter == nil {
+		return t.transport.RoundTrip(req)
+	}
+
+	if !limiter.acquire(req.Context()) {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, fmt.Errorf("%w: %s", ErrEndpointBusy, req.URL.Host)
+	}
+
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil {
+		limiter.release()
+		return nil, err
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: limiter.release}
+	return resp, nil
+}
+
+// limiterFor returns the limiter of the endpoint of a URL, or nil if the endpoint is not limited.
+// Limits of specific hosts can be set either with or without the port, and override the default limit; 0 means
+// unlimited.
+func (t *endpointLimitedTransport) limiterFor(u *url.URL) *endpointLimiter {
+	host := u.Host
+	limit, found := t.limits[host]
+	if !found {
+		limit, found = t.limits[u.Hostname()]
+	}
+	if !found {
+		limit = t.defaultLimit
+	}
+	if limit <= 0 {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	limiter, found := t.limiters[host]
+	if !found {
+		limiter = newEndpointLimiter(limit, t.maxQueued)
+		t.limiters[host] = limiter
+	}
+	return limiter
+}
+
+type endpointLimiter struct {
+	slots     chan struct{}
+	maxQueued int64
+	queued    atomic.Int64
+}
+
+func newEndpointLimiter(maxConcurrent, maxQueued int) *endpointLimiter {
+	return &endpointLimiter{
+		slots:     make(chan struct{}, maxConcurrent),
+		maxQueued: int64(maxQueued),
+	}
+}
+
+// acquire takes a slot for a request, waiting in the queue if all slots are taken.
+// Returns false if the queue is full or the context is done before getting a slot.
+func (l *endpointLimiter) acquire(ctx context.Context) bool {
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+	}
+
+	if l.queued.Add(1) > l.maxQueued {
+		l.queued.Add(-1)
+		return false
+	}
+	defer l.queued.Add(-1)
+
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (l *endpointLimiter) release() {
+	<-l.slots
+}
+
+// releasingBody frees the slot of a request when the body of the response is closed
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
+
+// ParseHostConcurrencyLimits parses a comma-separated list of limits of concurrent outbound HTTP requests per host, in
+// the format host=limit (e.g. idp.example.com=50,api.example.com:8443=10)
+func ParseHostConcurrencyLimits(s string) (map[string]int, error) {
+	limits := make(map[string]int)
+	if s == "" {
+		return limits, nil
+	}
+	for _, entry := range strings.Split(s, ",") {
+		host, value, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found || host == "" {
+			return nil, fmt.Errorf("invalid host concurrency limit %q", entry)
+		}
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 0 {
+			return nil, fmt.Errorf("invalid host concurrency limit %q", entry)
+		}
+		limits[host] = limit
+	}
+	return limits, nil
+}
@@ -0,0 +1,46 @@
+package trace
+
+import (
+	"sync"
+
+	"go.opentelemetry.io/otel/sdk/trace"
+)
+
+// sampler is the sampler of the trace providers created with `CreateTraceProvider`, whose ratio can be changed on the
+// fly with `SetSamplingRatio`
+var sampler = &ratioSampler{ratio: 1, sampler: trace.ParentBased(trace.TraceIDRatioBased(1))}
+
+// ratioSampler samples a ratio of the traces, respecting the sampling decision of the parent span, if any
+type ratioSampler struct {
+	mu      sync.RWMutex
+	ratio   float64
+	sampler trace.Sampler
+}
+
+func (s *ratioSampler) ShouldSample(parameters trace.SamplingParameters) trace.SamplingResult {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sampler.ShouldSample(parameters)
+}
+
+func (s *ratioSampler) Description() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sampler.Description()
+}
+
+// SetSamplingRatio changes the ratio of the traces sampled, between 0 (none) and 1 (all).
+// The traces whose parent span is sampled by the caller are always sampled.
+func SetSamplingRatio(ratio float64) {
+	sampler.mu.Lock()
+	defer sampler.mu.Unlock()
+	sampler.ratio = ratio
+	sampler.sampler = trace.ParentBased(trace.TraceIDRatioBased(ratio))
+}
+
+// SamplingRatio returns the current ratio of the traces sampled
+func SamplingRatio() float64 {
+	sampler.mu.RLock()
+	defer sampler.mu.RUnlock()
+	return sampler.ratio
+}
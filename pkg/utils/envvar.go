@@ -7,7 +7,7 @@ import (
 )
 
 type envVar interface {
-	string | int | int64 | bool
+	string | int | int64 | float64 | bool
 }
 
 func EnvVar[T envVar](key string, def T) T {
@@ -24,6 +24,9 @@ func EnvVar[T envVar](key string, def T) T {
 		case reflect.Int64:
 			v, _ := strconv.ParseInt(val, 10, 64)
 			return any(v).(T)
+		case reflect.Float64:
+			v, _ := strconv.ParseFloat(val, 64)
+			return any(v).(T)
 		case reflect.Bool:
 			v, _ := strconv.ParseBool(val)
 			return any(v).(T)
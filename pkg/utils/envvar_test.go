@@ -31,6 +31,14 @@ func TestFetchEnvVarInt64(t *testing.T) {
 	assert.Equal(t, EnvVar("AUTHORINO_TEST_ENV_VAR_OTHER", int64(456)), int64(456))
 }
 
+func TestFetchEnvVarFloat64(t *testing.T) {
+	os.Setenv("AUTHORINO_TEST_ENV_VAR", "0.25")
+	defer os.Unsetenv("AUTHORINO_TEST_ENV_VAR")
+
+	assert.Equal(t, EnvVar("AUTHORINO_TEST_ENV_VAR", 1.0), 0.25)
+	assert.Equal(t, EnvVar("AUTHORINO_TEST_ENV_VAR_OTHER", 1.0), 1.0)
+}
+
 func TestFetchEnvVarBool(t *testing.T) {
 	os.Setenv("AUTHORINO_TEST_ENV_VAR", "true")
 	defer os.Unsetenv("AUTHORINO_TEST_ENV_VAR")
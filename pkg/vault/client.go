@@ -0,0 +1,213 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/trace"
+)
+
+const (
+	DefaultAuthMount = "kubernetes"
+	DefaultTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+	// share of the lease of the vault token after which the client logs in again
+	tokenRenewalThreshold = 0.8
+)
+
+// Client reads secrets from HashiCorp Vault, logging in with the Kubernetes auth method, i.e. with the token of the
+// service account of Authorino.
+// The Vault token is renewed by logging in again before its lease expires, or whenever Vault rejects it.
+type Client struct {
+	Address   string
+	AuthMount string
+	Role      string
+	TokenFile string
+
+	httpClient     *http.Client
+	token          string
+	tokenExpiresAt time.Time
+	mutex          sync.Mutex
+}
+
+// Secret is the data of a secret read from Vault, along with its lease
+type Secret struct {
+	Data          map[string][]byte
+	LeaseDuration time.Duration
+}
+
+func NewClient(address, authMount, role, tokenFile string) *Client {
+	if authMount == "" {
+		authMount = DefaultAuthMount
+	}
+	if tokenFile == "" {
+		tokenFile = DefaultTokenFile
+	}
+	return &Client{
+		Address:    strings.TrimSuffix(address, "/"),
+		AuthMount:  strings.Trim(authMount, "/"),
+		Role:       role,
+		TokenFile:  tokenFile,
+		httpClient: trace.HTTPClient,
+	}
+}
+
+// Read reads a secret from a path in Vault.
+// Secrets of KV version 2 engines (i.e. whose data is nested under "data", along with the "metadata") are unwrapped.
+// Values other than strings are JSON-encoded.
+func (c *Client) Read(ctx context.Context, path string) (*Secret, error) {
+	resp, err := c.call(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault secret %s: %v", path, err)
+	}
+
+	data := resp.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok := data["metadata"].(map[string]interface{}); ok {
+			data = nested
+		}
+	}
+
+	secret := &Secret{
+		Data:          make(map[string][]byte, len(data)),
+		LeaseDuration: time.Duration(resp.LeaseDuration) * time.Second,
+	}
+	for key, value := range data {
+		if s, ok := value.(string); ok {
+			secret.Data[key] = []byte(s)
+			continue
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		secret.Data[key] = encoded
+	}
+
+	return secret, nil
+}
+
+// Write sends data to a path in Vault (e.g. to an endpoint of the Transit secrets engine) and returns the data of the
+// response
+func (c *Client) Write(ctx context.Context, path string, data interface{}) (map[string]interface{}, error) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.call(ctx, http.MethodPost, path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write to vault path %s: %v", path, err)
+	}
+	return resp.Data, nil
+}
+
+// call requests a path of the Vault API, logging in again once if the token is rejected
+func (c *Client) call(ctx context.Context, method, path string, body []byte) (*response, error) {
+	resp, err := c.request(ctx, method, path, body, false)
+	if err == errPermissionDenied {
+		// the token may have been revoked
+		resp, err = c.request(ctx, method, path, body, true)
+	}
+	return resp, err
+}
+
+var errPermissionDenied = errors.New("permission denied")
+
+type response struct {
+	LeaseDuration int                    `json:"lease_duration"`
+	Data          map[string]interface{} `json:"data"`
+	Auth          *struct {
+		ClientToken   string `json:"client_token"`
+		LeaseDuration int    `json:"lease_duration"`
+	} `json:"auth"`
+	Errors []string `json:"errors"`
+}
+
+func (c *Client) request(ctx context.Context, method, path string, body []byte, forceLogin bool) (*response, error) {
+	token, err := c.getToken(ctx, forceLogin)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.Address+"/v1/"+strings.TrimPrefix(path, "/"), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return c.do(req)
+}
+
+// getToken returns the current vault token, logging in if there is none, it is about to expire, or forced to
+func (c *Client) getToken(ctx context.Context, forceLogin bool) (string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.token != "" && !forceLogin && (c.tokenExpiresAt.IsZero() || time.Now().Before(c.tokenExpiresAt)) {
+		return c.token, nil
+	}
+
+	jwt, err := os.ReadFile(c.TokenFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the service account token: %v", err)
+	}
+
+	body, _ := json.Marshal(map[string]string{"role": c.Role, "jwt": strings.TrimSpace(string(jwt))})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/v1/auth/%s/login", c.Address, c.AuthMount), bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to log in to vault: %v", err)
+	}
+	if resp.Auth == nil || resp.Auth.ClientToken == "" {
+		return "", fmt.Errorf("failed to log in to vault: missing client token")
+	}
+
+	c.token = resp.Auth.ClientToken
+	c.tokenExpiresAt = time.Time{}
+	if lease := resp.Auth.LeaseDuration; lease > 0 {
+		c.tokenExpiresAt = time.Now().Add(time.Duration(float64(lease)*tokenRenewalThreshold) * time.Second)
+	}
+
+	return c.token, nil
+}
+
+func (c *Client) do(req *http.Request) (*response, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	r := &response{}
+	if err := json.NewDecoder(resp.Body).Decode(r); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusForbidden:
+		return nil, errPermissionDenied
+	case resp.StatusCode != http.StatusOK:
+		if len(r.Errors) > 0 {
+			return nil, fmt.Errorf("%s: %s", resp.Status, strings.Join(r.Errors, "; "))
+		}
+		return nil, errors.New(resp.Status)
+	}
+
+	return r, nil
+}
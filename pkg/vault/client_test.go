@@ -0,0 +1,123 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+type fakeVault struct {
+	*httptest.Server
+	logins  int
+	revoked bool
+}
+
+func newFakeVault(t *testing.T) *fakeVault {
+	vault := &fakeVault{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/auth/kubernetes/login", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["role"] != "authorino" || body["jwt"] != "sa-token" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"errors":["invalid role name"]}`))
+			return
+		}
+		vault.logins++
+		vault.revoked = false
+		_, _ = w.Write([]byte(`{"auth":{"client_token":"s.token","lease_duration":3600}}`))
+	})
+	mux.HandleFunc("/v1/secret/data/authorino", func(w http.ResponseWriter, r *http.Request) {
+		if vault.revoked || r.Header.Get("X-Vault-Token") != "s.token" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"lease_duration":0,"data":{"data":{"clientID":"authorino","clientSecret":"s3cr3t","ttl":60},"metadata":{"version":2}}}`))
+	})
+	mux.HandleFunc("/v1/kv/authorino", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"lease_duration":1800,"data":{"api_key":"ndyBzreUzF4zqDQsqSPMHkRhriEOtcRx"}}`))
+	})
+	mux.HandleFunc("/v1/transit/verify/authorino/sha2-256", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" || body["input"] == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"valid":true}}`))
+	})
+	vault.Server = httptest.NewServer(mux)
+	t.Cleanup(vault.Close)
+	return vault
+}
+
+func newTestClient(t *testing.T, address, role string) *Client {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	assert.NilError(t, os.WriteFile(tokenFile, []byte("sa-token\n"), 0600))
+	return NewClient(address, "", role, tokenFile)
+}
+
+func TestReadKVv2(t *testing.T) {
+	vault := newFakeVault(t)
+	client := newTestClient(t, vault.URL, "authorino")
+
+	secret, err := client.Read(context.TODO(), "secret/data/authorino")
+	assert.NilError(t, err)
+	assert.Equal(t, string(secret.Data["clientID"]), "authorino")
+	assert.Equal(t, string(secret.Data["clientSecret"]), "s3cr3t")
+	assert.Equal(t, string(secret.Data["ttl"]), "60")
+	assert.Equal(t, secret.LeaseDuration.Seconds(), float64(0))
+
+	// reuses the token
+	_, err = client.Read(context.TODO(), "secret/data/authorino")
+	assert.NilError(t, err)
+	assert.Equal(t, vault.logins, 1)
+
+	// logs in again when the token is rejected
+	vault.revoked = true
+	_, err = client.Read(context.TODO(), "secret/data/authorino")
+	assert.NilError(t, err)
+	assert.Equal(t, vault.logins, 2)
+}
+
+func TestReadKVv1(t *testing.T) {
+	vault := newFakeVault(t)
+	client := newTestClient(t, vault.URL, "authorino")
+
+	secret, err := client.Read(context.TODO(), "/kv/authorino")
+	assert.NilError(t, err)
+	assert.Equal(t, string(secret.Data["api_key"]), "ndyBzreUzF4zqDQsqSPMHkRhriEOtcRx")
+	assert.Equal(t, secret.LeaseDuration.Seconds(), float64(1800))
+}
+
+func TestReadErrors(t *testing.T) {
+	vault := newFakeVault(t)
+
+	_, err := newTestClient(t, vault.URL, "other").Read(context.TODO(), "secret/data/authorino")
+	assert.Error(t, err, "failed to read vault secret secret/data/authorino: failed to log in to vault: 400 Bad Request: invalid role name")
+
+	_, err = newTestClient(t, vault.URL, "authorino").Read(context.TODO(), "secret/data/missing")
+	assert.Error(t, err, "failed to read vault secret secret/data/missing: 404 Not Found")
+
+	_, err = NewClient(vault.URL, "", "authorino", filepath.Join(t.TempDir(), "missing")).Read(context.TODO(), "secret/data/authorino")
+	assert.ErrorContains(t, err, "failed to read the service account token")
+}
+
+func TestWrite(t *testing.T) {
+	vault := newFakeVault(t)
+	client := newTestClient(t, vault.URL, "authorino")
+
+	data, err := client.Write(context.TODO(), "transit/verify/authorino/sha2-256", map[string]string{"input": "aGVsbG8=", "signature": "vault:v1:c2ln"})
+	assert.NilError(t, err)
+	assert.Equal(t, data["valid"], true)
+
+	_, err = client.Write(context.TODO(), "transit/verify/authorino/sha2-256", map[string]string{})
+	assert.Error(t, err, "failed to write to vault path transit/verify/authorino/sha2-256: 400 Bad Request")
+}